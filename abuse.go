@@ -0,0 +1,184 @@
+package main
+
+// Signup abuse detection. Every public signup is logged with the address it
+// came from; one that goes over the limits set on the settings page (signups
+// per hour, distinct emails per day from the same address) is still recorded
+// and keeps its place, but waits in the /admin/review queue, without its
+// confirmation email, until an organizer approves or rejects it.
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	signupRateWindow   = time.Hour
+	signupEmailsWindow = 24 * time.Hour
+	maxSignupLimit     = 1000
+)
+
+// clientIP returns the address a request came from. It trusts the reverse
+// proxy's X-Forwarded-For, taking the address the proxy itself appended.
+func clientIP(r *http.Request) string {
+	if fwd := r.Header.Get("X-Forwarded-For"); fwd != "" {
+		parts := strings.Split(fwd, ",")
+		return strings.TrimSpace(parts[len(parts)-1])
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// signupLimits returns the configured limits; 0 means no limit.
+func signupLimits(app *App) (perHour, emailsPerDay int) {
+	perHour, _ = strconv.Atoi(GetSetting(app.DB, "signup_max_per_hour"))
+	emailsPerDay, _ = strconv.Atoi(GetSetting(app.DB, "signup_max_emails"))
+	return perHour, emailsPerDay
+}
+
+// checkSignupAbuse logs a signup from ip and returns why it should be held
+// for review ("rate" or "emails"), or "".
+func (app *App) checkSignupAbuse(ip, email string) string {
+	if err := LogSignup(app.DB, ip, email); err != nil {
+		log.Printf("signup log: %v", err)
+	}
+	PurgeSignupLog(app.DB, signupEmailsWindow)
+	perHour, emailsPerDay := signupLimits(app)
+	if perHour > 0 {
+		if n, _ := CountSignupsFrom(app.DB, ip, signupRateWindow); n > perHour {
+			return "rate"
+		}
+	}
+	if emailsPerDay > 0 {
+		if _, n := CountSignupsFrom(app.DB, ip, signupEmailsWindow); n > emailsPerDay {
+			return "emails"
+		}
+	}
+	return ""
+}
+
+// parseSignupLimit reads a limit typed on the settings page; empty is 0.
+func parseSignupLimit(raw string) (int, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 0 || n > maxSignupLimit {
+		return 0, fmt.Errorf("invalid signup limit %q", raw)
+	}
+	return n, nil
+}
+
+// handleAdminSettingsSignupLimits saves the signup limits and whether email
+// domains are checked.
+func (app *App) handleAdminSettingsSignupLimits(w http.ResponseWriter, r *http.Request) {
+	lang := LangFromRequest(r)
+	settingsURL := "/admin/settings?lang=" + lang
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, settingsURL, http.StatusSeeOther)
+		return
+	}
+	perHour, err1 := parseSignupLimit(r.FormValue("signup_max_per_hour"))
+	emails, err2 := parseSignupLimit(r.FormValue("signup_max_emails"))
+	if err1 != nil || err2 != nil {
+		setFlash(w, "error", T("signup_limits_invalid", lang))
+		http.Redirect(w, r, settingsURL, http.StatusSeeOther)
+		return
+	}
+	checkMX := "0"
+	if r.FormValue("signup_check_mx") != "" {
+		checkMX = "1"
+	}
+	if err := SetSetting(app.DB, "signup_max_per_hour", strconv.Itoa(perHour)); err != nil {
+		log.Printf("settings: signup limits: %v", err)
+		setFlash(w, "error", T("error_server", lang))
+	} else if err := SetSetting(app.DB, "signup_max_emails", strconv.Itoa(emails)); err != nil {
+		log.Printf("settings: signup limits: %v", err)
+		setFlash(w, "error", T("error_server", lang))
+	} else if err := SetSetting(app.DB, "signup_check_mx", checkMX); err != nil {
+		log.Printf("settings: signup limits: %v", err)
+		setFlash(w, "error", T("error_server", lang))
+	} else {
+		setFlash(w, "success", T("settings_saved", lang))
+	}
+	http.Redirect(w, r, settingsURL, http.StatusSeeOther)
+}
+
+// ---- Admin review queue ----
+
+func (app *App) handleAdminReview(w http.ResponseWriter, r *http.Request) {
+	reviews, err := ListSignupReviews(app.DB)
+	if err != nil {
+		log.Printf("review: list: %v", err)
+	}
+	pd := app.newPageData(r, map[string]any{"Reviews": reviews})
+	pd.Success, pd.Error = takeFlash(w, r)
+	app.render(w, r, "admin_review.html", pd)
+}
+
+// handleAdminReviewApprove confirms a held registration, sending the emails
+// it was held back from.
+func (app *App) handleAdminReviewApprove(w http.ResponseWriter, r *http.Request) {
+	lang := LangFromRequest(r)
+	reviewURL := "/admin/review?lang=" + lang
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, reviewURL, http.StatusSeeOther)
+		return
+	}
+	id, _ := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	review, err := GetSignupReview(app.DB, id)
+	if err != nil {
+		http.Redirect(w, r, reviewURL, http.StatusSeeOther)
+		return
+	}
+	task, err1 := GetTask(app.DB, review.TaskID)
+	event, err2 := GetEvent(app.DB, review.EventID)
+	if err1 != nil || err2 != nil {
+		http.Redirect(w, r, reviewURL, http.StatusSeeOther)
+		return
+	}
+	if err := ClearSignupReview(app.DB, id); err != nil {
+		log.Printf("review: approve %d: %v", id, err)
+		setFlash(w, "error", T("error_server", lang))
+		http.Redirect(w, r, reviewURL, http.StatusSeeOther)
+		return
+	}
+	reg := review.Registration
+	app.dispatchSignupConfirmation(reg, *task, *event, app.eventBaseURL(r, event))
+	if len(event.NotifyAddresses()) > 0 {
+		subject, html := renderRegistrationNotificationEmail(instanceLang(), reg, *task, CountTaskRegistrations(app.DB, task.ID), *event, app.eventBaseURL(r, event))
+		app.dispatchAdminNotification(*event, subject, html)
+	}
+	setFlash(w, "success", T("review_approved", lang))
+	http.Redirect(w, r, reviewURL, http.StatusSeeOther)
+}
+
+// handleAdminReviewReject deletes a held registration, freeing its place.
+func (app *App) handleAdminReviewReject(w http.ResponseWriter, r *http.Request) {
+	lang := LangFromRequest(r)
+	reviewURL := "/admin/review?lang=" + lang
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, reviewURL, http.StatusSeeOther)
+		return
+	}
+	id, _ := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	if _, err := GetSignupReview(app.DB, id); err != nil {
+		http.Redirect(w, r, reviewURL, http.StatusSeeOther)
+		return
+	}
+	if err := DeleteRegistration(app.DB, id); err != nil {
+		log.Printf("review: reject %d: %v", id, err)
+		setFlash(w, "error", T("error_server", lang))
+	} else {
+		setFlash(w, "success", T("review_rejected", lang))
+	}
+	http.Redirect(w, r, reviewURL, http.StatusSeeOther)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "203.0.113.7:5123"
+	if ip := clientIP(r); ip != "203.0.113.7" {
+		t.Errorf("clientIP = %q", ip)
+	}
+	r.Header.Set("X-Forwarded-For", "10.0.0.1, 198.51.100.4")
+	if ip := clientIP(r); ip != "198.51.100.4" {
+		t.Errorf("clientIP behind a proxy = %q", ip)
+	}
+}
+
+func TestSignupAbuseReview(t *testing.T) {
+	app := testApp(t)
+	e := seedEvent(t, app.DB)
+	tk := seedTask(t, app.DB, e.ID, "Vaisselle", nil)
+	mux := newMux(app)
+	admin := adminCookie(app)
+	fake := app.Email.(*fakeEmailSender)
+
+	w := postForm(mux, "/admin/settings/signup-limits?lang=fr", url.Values{"signup_max_per_hour": {"abc"}}, admin)
+	if body := followRedirect(mux, w, admin).Body.String(); !strings.Contains(body, T("signup_limits_invalid", "fr")) {
+		t.Error("invalid limit accepted")
+	}
+	postForm(mux, "/admin/settings/signup-limits?lang=fr", url.Values{"signup_max_per_hour": {"2"}}, admin)
+	if perHour, emails := signupLimits(app); perHour != 2 || emails != 0 {
+		t.Fatalf("limits = %d, %d", perHour, emails)
+	}
+
+	signup := func(name string) {
+		postForm(mux, "/signup?lang=fr", url.Values{
+			"task_id": {fmt.Sprint(tk.ID)}, "first_name": {name}, "last_name": {"Dupont"},
+			"email": {strings.ToLower(name) + "@test.com"}, "phone": {"0601"},
+		})
+	}
+	signup("Alice")
+	signup("Bob")
+	if fake.count() != 2 || CountSignupReviews(app.DB) != 0 {
+		t.Fatalf("within the limit: %d emails, %d held", fake.count(), CountSignupReviews(app.DB))
+	}
+	signup("Carol")
+	signup("Dave")
+	if CountRegistrations(app.DB, e.ID) != 4 {
+		t.Fatalf("held signups should keep their place, got %d registrations", CountRegistrations(app.DB, e.ID))
+	}
+	if fake.count() != 2 || CountSignupReviews(app.DB) != 2 {
+		t.Fatalf("over the limit: %d emails, %d held", fake.count(), CountSignupReviews(app.DB))
+	}
+
+	body := getRequest(mux, "/admin/review?lang=fr", admin).Body.String()
+	if !strings.Contains(body, "carol@test.com") || !strings.Contains(body, "192.0.2.1") || strings.Contains(body, "alice@test.com") {
+		t.Errorf("review queue does not list the held signups")
+	}
+	reviews, _ := ListSignupReviews(app.DB)
+	carol, dave := reviews[0], reviews[1]
+
+	postForm(mux, "/admin/review/approve?lang=fr", url.Values{"id": {fmt.Sprint(carol.ID)}}, admin)
+	if fake.count() != 3 || fake.sent[2].To != "carol@test.com" {
+		t.Errorf("approval did not send the confirmation (%d emails)", fake.count())
+	}
+	postForm(mux, "/admin/review/reject?lang=fr", url.Values{"id": {fmt.Sprint(dave.ID)}}, admin)
+	if _, err := GetRegistrationByToken(app.DB, dave.Token); err == nil {
+		t.Error("rejected signup still registered")
+	}
+	if CountSignupReviews(app.DB) != 0 || CountRegistrations(app.DB, e.ID) != 3 {
+		t.Errorf("after review: %d held, %d registrations", CountSignupReviews(app.DB), CountRegistrations(app.DB, e.ID))
+	}
+
+	// Only held registrations can be rejected from the queue.
+	alice, _ := GetRegistrationByEmailAndEvent(app.DB, "alice@test.com", e.ID)
+	postForm(mux, "/admin/review/reject?lang=fr", url.Values{"id": {fmt.Sprint(alice.ID)}}, admin)
+	if CountRegistrations(app.DB, e.ID) != 3 {
+		t.Error("reject deleted a registration that was not held")
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+// Access codes. An event can have a code that lets its core team pick their
+// tasks first: with it — in the event link as ?access_code=… — people can
+// sign up before registration opens, and take the slots a task reserves out
+// of its maximum, which everyone else sees as taken. Closed registration and
+// the event's participant cap hold for everyone.
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxAccessCodeLen bounds Event.AccessCode, in characters.
+const maxAccessCodeLen = 40
+
+// normalizeAccessCode reads an access code typed in the admin: trimmed,
+// without inner spaces, cut to maxAccessCodeLen.
+func normalizeAccessCode(raw string) string {
+	code := strings.Join(strings.Fields(raw), "")
+	for utf8.RuneCountInString(code) > maxAccessCodeLen {
+		_, size := utf8.DecodeLastRuneInString(code)
+		code = code[:len(code)-size]
+	}
+	return code
+}
+
+// HasAccess tells whether code is the event's access code, case aside.
+func (e Event) HasAccess(code string) bool {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return e.AccessCode != "" && subtle.ConstantTimeCompare([]byte(code), []byte(strings.ToLower(e.AccessCode))) == 1
+}
+
+// normalizeReservedSlots keeps a task's reserved slots within its maximum;
+// a task without one has none.
+func normalizeReservedSlots(t *Task) {
+	if !t.MaxSlots.Valid {
+		t.ReservedSlots = 0
+		return
+	}
+	t.ReservedSlots = min(max(t.ReservedSlots, 0), int(t.MaxSlots.Int64))
+}
+
+// accessSignupState is signupState for someone who has the event's access
+// code when access is set: to them, registration not open yet is open.
+func accessSignupState(db *sql.DB, e *Event, now time.Time, access bool) string {
+	state := signupState(db, e, now)
+	if access && state == registrationNotYetOpen {
+		opens, _ := e.RegistrationOpens()
+		return signupState(db, e, opens)
+	}
+	return state
+}
+
+// holdReservedSlots takes the reserved slots of v out of those left, as
+// seen by someone without the access code.
+func holdReservedSlots(v *TaskView) {
+	if v.ReservedSlots == 0 || !v.MaxSlots.Valid {
+		return
+	}
+	left := max(0, int(v.MaxSlots.Int64)-v.ReservedSlots-v.RegCount)
+	if v.SlotsLeft >= 0 && v.SlotsLeft <= left {
+		return
+	}
+	v.SlotsLeft = left
+	v.IsFull = left == 0
+	v.GuestsLeft = max(0, min(v.MaxGuests, left-1))
+}
+
+// holdReservedTree applies holdReservedSlots to the tasks of tree.
+func holdReservedTree(tree []TreeNode) {
+	for i := range tree {
+		if tree[i].Task != nil {
+			holdReservedSlots(tree[i].Task)
+		}
+		holdReservedTree(tree[i].Children)
+	}
+}
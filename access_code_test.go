@@ -0,0 +1,55 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAccessCode(t *testing.T) {
+	app := testApp(t)
+	mux := newMux(app)
+	opens := time.Now().Add(48 * time.Hour).UTC().Format(registrationTimeLayout)
+	e := &Event{TitleFR: "Fête", EventDate: "2026-06-15", MaxTasksPerPerson: 1, RegistrationOpensAt: opens, AccessCode: normalizeAccessCode(" Core Team ")}
+	if err := CreateEvent(app.DB, e); err != nil {
+		t.Fatal(err)
+	}
+	if e.AccessCode != "CoreTeam" {
+		t.Errorf("access code = %q", e.AccessCode)
+	}
+	bar := &Task{EventID: e.ID, TitleFR: "Buvette", MaxSlots: sql.NullInt64{Int64: 2, Valid: true}, ReservedSlots: 1}
+	CreateTask(app.DB, bar)
+
+	signup := func(email, code string) string {
+		return postForm(mux, "/signup?lang=en", url.Values{
+			"task_id": {fmt.Sprint(bar.ID)}, "first_name": {"Test"}, "last_name": {"Test"},
+			"email": {email}, "phone": {"0601"}, "access_code": {code},
+		}).Body.String()
+	}
+	if body := signup("alice@test.com", ""); !strings.Contains(body, T("registration_not_open", "en")) {
+		t.Error("signup before opening without the code not turned away")
+	}
+	if body := signup("alice@test.com", "nope"); !strings.Contains(body, T("registration_not_open", "en")) {
+		t.Error("signup before opening with a wrong code not turned away")
+	}
+	signup("alice@test.com", "coreteam")
+	if n := CountTaskRegistrations(app.DB, bar.ID); n != 1 {
+		t.Fatalf("registrations with the code = %d, want 1", n)
+	}
+
+	// Once open, the reserved slot stays out of reach without the code.
+	app.DB.Exec("UPDATE events SET registration_opens_at='' WHERE id=?", e.ID)
+	page := getRequest(mux, "/e/"+e.Slug+"?lang=en").Body.String()
+	if !strings.Contains(page, `value="`+fmt.Sprint(bar.ID)+`" disabled`) {
+		t.Error("task with only reserved slots left not shown full")
+	}
+	if _, err := RegisterForTask(app.DB, bar.ID, "Bob", "Test", "bob@test.com", "0601", "en"); err == nil {
+		t.Error("reserved slot taken without the code")
+	}
+	if _, err := RegisterWithAccessCode(app.DB, bar.ID, 0, "Bob", "Test", "bob@test.com", "0601", "en"); err != nil {
+		t.Errorf("reserved slot with the code: %v", err)
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+// After-signup texts. Organizers can write what people need to know once
+// signed up — practical information, what to bring, whom to contact — in
+// French and English. The texts, in Markdown, show on the event page under
+// the signup confirmation and in the confirmation email, so they needn't
+// crowd the description everyone reads before deciding.
+
+import "html/template"
+
+// AfterSignupBlock is one after-signup text, rendered.
+type AfterSignupBlock struct {
+	Title string
+	Body  template.HTML
+}
+
+// AfterSignup returns e's after-signup texts in lang, French standing in for
+// missing English ones. Empty texts are left out.
+func (e Event) AfterSignup(lang string) []AfterSignupBlock {
+	var blocks []AfterSignupBlock
+	for _, b := range []struct{ key, fr, en string }{
+		{"after_signup_info", e.AfterInfoFR, e.AfterInfoEN},
+		{"after_signup_bring", e.AfterBringFR, e.AfterBringEN},
+		{"after_signup_contact", e.AfterContactFR, e.AfterContactEN},
+	} {
+		if body := renderMarkdown(Localized(b.fr, b.en, lang)); body != "" {
+			blocks = append(blocks, AfterSignupBlock{Title: T(b.key, lang), Body: template.HTML(body)})
+		}
+	}
+	return blocks
+}
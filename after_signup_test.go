@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAfterSignup(t *testing.T) {
+	app := testApp(t)
+	mux := newMux(app)
+	e := seedEvent(t, app.DB)
+
+	body, _ := json.Marshal(map[string]any{
+		"event_id": e.ID, "title_fr": e.TitleFR, "event_date": e.EventDate,
+		"after_info_fr":    "  Rendez-vous à **8h** au portail.  ",
+		"after_info_en":    "Meet at **8am** by the gate.",
+		"after_bring_fr":   "- des gants\n- une gourde",
+		"after_contact_fr": "Marie <script>alert(1)</script> 06 12 34 56 78",
+	})
+	req := httptest.NewRequest(http.MethodPost, "/admin/api/event/save", bytes.NewReader(body))
+	req.AddCookie(adminCookie(app))
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != 200 {
+		t.Fatalf("save: status %d: %s", w.Code, w.Body)
+	}
+	e, _ = GetEvent(app.DB, e.ID)
+	if e.AfterInfoFR != "Rendez-vous à **8h** au portail." {
+		t.Errorf("info = %q", e.AfterInfoFR)
+	}
+
+	// English falls back to French block by block.
+	blocks := e.AfterSignup(LangEN)
+	if len(blocks) != 3 || blocks[0].Title != "Practical information" || blocks[0].Body != "<p>Meet at <strong>8am</strong> by the gate.</p>" {
+		t.Fatalf("blocks = %+v", blocks)
+	}
+	if !strings.Contains(string(blocks[1].Body), "<li>des gants</li>") {
+		t.Errorf("bring = %s", blocks[1].Body)
+	}
+	if strings.Contains(string(blocks[2].Body), "<script") {
+		t.Errorf("contact not sanitized: %s", blocks[2].Body)
+	}
+
+	page := getRequest(mux, "/e/"+e.Slug+"?lang=fr").Body.String()
+	for _, want := range []string{"<h3>Infos pratiques</h3>", "Rendez-vous à <strong>8h</strong> au portail.", "<h3>Contact</h3>"} {
+		if !strings.Contains(page, want) {
+			t.Errorf("event page misses %q", want)
+		}
+	}
+
+	_, html := renderSignupConfirmationEmail(LangEN, Registration{FirstName: "Alice"}, Task{TitleFR: "Vaisselle"}, *e, "https://fete.example.org", "https://fete.example.org/cancel/tok", "", "")
+	for _, want := range []string{"What to bring", "Meet at <strong>8am</strong> by the gate."} {
+		if !strings.Contains(html, want) {
+			t.Errorf("email misses %q", want)
+		}
+	}
+
+	// Without texts, nothing shows.
+	if blocks := (Event{}).AfterSignup(LangFR); blocks != nil {
+		t.Errorf("empty event blocks = %+v", blocks)
+	}
+}
@@ -149,6 +149,7 @@ Rules:
 - Organize logically: use groups to categorize related tasks.
 - If the text mentions a number of people needed, set max_slots accordingly.
 - Keep titles concise and descriptions informative.
+- The text may be preceded by the event's title and when it takes place; use them as context only, not as tasks.
 - Do NOT invent tasks not mentioned in the text.`
 
 const updateSystemPrompt = `You are a helpful assistant that structures event volunteer tasks.
@@ -165,8 +166,33 @@ Rules:
 - Translate between French and English as needed.
 - Organize logically: use groups to categorize related tasks.
 - If the text mentions a number of people needed, set max_slots accordingly.
+- The instructions may be preceded by the event's title and when it takes place; use them as context only, not as tasks.
 - Do NOT invent tasks not mentioned or implied by the text.`
 
+// aiEventContext tells the model when the event takes place, so that an
+// all-day event can be told apart from a two-hour one.
+func aiEventContext(e Event) string {
+	when := e.EventDate
+	if e.EventTime != "" {
+		when += " " + e.EventTime
+	}
+	if e.EndDate != "" || (e.EndTime != "" && e.EventTime != "") {
+		when += " to"
+		if e.EndDate != "" {
+			when += " " + e.EndDate
+		}
+		if e.EndTime != "" && e.EventTime != "" {
+			when += " " + e.EndTime
+		}
+	}
+	if e.EventTime == "" {
+		when += ", all day"
+	} else if d, ok := e.Duration(); ok {
+		when += " (" + formatDuration(d) + ")"
+	}
+	return "Event: " + e.TitleFR + "\nWhen: " + when
+}
+
 // applyAINodes recursively creates/updates groups and tasks from the AI output.
 func applyAINodes(db *sql.DB, eventID int64, nodes []AINode, parentGroupID sql.NullInt64, position *int) error {
 	for _, node := range nodes {
@@ -251,7 +277,7 @@ func (app *App) handleAdminAIParse(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
-	if app.AnthropicKey == "" {
+	if app.anthropicKey() == "" {
 		http.Error(w, "ANTHROPIC_API_KEY not configured", http.StatusServiceUnavailable)
 		return
 	}
@@ -280,6 +306,10 @@ func (app *App) handleAdminAIParse(w http.ResponseWriter, r *http.Request) {
 		userPrompt = req.Text
 	}
 
+	if event, err := GetEvent(app.DB, req.EventID); err == nil {
+		userPrompt = aiEventContext(*event) + "\n\n" + userPrompt
+	}
+
 	sysPrompt := systemPrompt
 	if req.Mode == "update" {
 		sysPrompt = updateSystemPrompt
@@ -288,7 +318,7 @@ func (app *App) handleAdminAIParse(w http.ResponseWriter, r *http.Request) {
 		sysPrompt += "\n- IMPORTANT: For tasks where no specific number of people is mentioned, set max_slots to 1."
 	}
 
-	response, err := callClaude(app.AnthropicKey, sysPrompt, userPrompt)
+	response, err := callClaude(app.anthropicKey(), sysPrompt, userPrompt)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("AI error: %v", err), http.StatusBadGateway)
 		return
@@ -300,6 +330,8 @@ func (app *App) handleAdminAIParse(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	app.snapshotStructure(req.EventID, "before_ai")
+
 	// In update mode, delete items that are no longer in the AI output
 	if req.Mode == "update" {
 		keepGroupIDs, keepTaskIDs := collectExistingIDs(aiNodes)
@@ -341,6 +373,7 @@ func (app *App) handleAdminAIParse(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, fmt.Sprintf("Failed to apply changes: %v", err), http.StatusInternalServerError)
 		return
 	}
+	app.snapshotStructure(req.EventID, "ai")
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
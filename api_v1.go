@@ -0,0 +1,997 @@
+package main
+
+// Versioned admin REST API. /admin/api/v1 exposes events, groups, tasks,
+// registrations and attendances as JSON resources, for check-in apps and
+// scripts that would otherwise post the admin forms:
+//
+//	GET, POST           /admin/api/v1/events
+//	GET, PATCH, DELETE  /admin/api/v1/events/{id}
+//	GET, POST           /admin/api/v1/events/{id}/groups  (and tasks, registrations, attendances)
+//	GET, PATCH, DELETE  /admin/api/v1/groups/{id}         (and tasks, registrations, attendances)
+//
+// It is guarded by requireAPI, like the older endpoints. PATCH bodies hold
+// only the fields to change. Every error is {"error": "<code>"} with a
+// matching status. Deletes go to the trash, as in the admin — except
+// attendances, which the admin deletes for good too. Where a group or task
+// sits in the tree is the reorder API's business, the magic-link email
+// wording and the slug the edit page's.
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// apiV1Prefix is where the versioned API is mounted.
+const apiV1Prefix = "/admin/api/v1/"
+
+// handleAPIV1 routes the versioned API; main mounts it on apiV1Prefix.
+func (app *App) handleAPIV1() http.HandlerFunc {
+	mux := http.NewServeMux()
+	mux.HandleFunc(apiV1Prefix+"events", app.apiV1Events)
+	mux.HandleFunc(apiV1Prefix+"events/{id}", app.apiV1Event)
+	mux.HandleFunc(apiV1Prefix+"events/{id}/groups", app.apiV1EventGroups)
+	mux.HandleFunc(apiV1Prefix+"events/{id}/tasks", app.apiV1EventTasks)
+	mux.HandleFunc(apiV1Prefix+"events/{id}/registrations", app.apiV1EventRegistrations)
+	mux.HandleFunc(apiV1Prefix+"events/{id}/attendances", app.apiV1EventAttendances)
+	mux.HandleFunc(apiV1Prefix+"groups/{id}", app.apiV1Group)
+	mux.HandleFunc(apiV1Prefix+"tasks/{id}", app.apiV1Task)
+	mux.HandleFunc(apiV1Prefix+"registrations/{id}", app.apiV1Registration)
+	mux.HandleFunc(apiV1Prefix+"attendances/{id}", app.apiV1Attendance)
+	mux.HandleFunc(apiV1Prefix, func(w http.ResponseWriter, r *http.Request) {
+		apiError(w, http.StatusNotFound, "not_found")
+	})
+	return mux.ServeHTTP
+}
+
+// apiError answers with the API's error format.
+func apiError(w http.ResponseWriter, status int, code string) {
+	writeJSON(w, status, map[string]string{"error": code})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// decodeAPIBody decodes r's JSON body over v, so fields it leaves out keep
+// the values v had: PATCH semantics, and defaults on POST.
+func decodeAPIBody(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		apiError(w, http.StatusBadRequest, "bad_request")
+		return false
+	}
+	return true
+}
+
+// apiPathID returns the {id} of r's path, answering 404 when it is not one.
+func apiPathID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || id <= 0 {
+		apiError(w, http.StatusNotFound, "not_found")
+		return 0, false
+	}
+	return id, true
+}
+
+// apiLoad answers for a failed lookup of a resource and reports whether
+// there was one.
+func apiLoad(w http.ResponseWriter, what string, id int64, err error) bool {
+	if err == sql.ErrNoRows {
+		apiError(w, http.StatusNotFound, "not_found")
+		return false
+	} else if err != nil {
+		log.Printf("api: %s %d: %v", what, id, err)
+		apiError(w, http.StatusInternalServerError, "server_error")
+		return false
+	}
+	return true
+}
+
+// apiServerError logs err and answers 500.
+func apiServerError(w http.ResponseWriter, what string, err error) {
+	log.Printf("api: %s: %v", what, err)
+	apiError(w, http.StatusInternalServerError, "server_error")
+}
+
+func methodNotAllowed(w http.ResponseWriter) {
+	apiError(w, http.StatusMethodNotAllowed, "method_not_allowed")
+}
+
+func nullInt(n sql.NullInt64) *int64 {
+	if !n.Valid {
+		return nil
+	}
+	return &n.Int64
+}
+
+// positiveNullInt is the NullInt64 of a limit where 0 or less means none.
+func positiveNullInt(p *int64) sql.NullInt64 {
+	if p == nil || *p <= 0 {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: *p, Valid: true}
+}
+
+func intString(p *int64) string {
+	if p == nil {
+		return ""
+	}
+	return strconv.FormatInt(*p, 10)
+}
+
+// apiEvent is an Event as the API reads and writes it.
+type apiEvent struct {
+	ID                   int64     `json:"id"`
+	Slug                 string    `json:"slug"`
+	TitleFR              string    `json:"title_fr"`
+	TitleEN              string    `json:"title_en"`
+	DescriptionFR        string    `json:"description_fr"`
+	DescriptionEN        string    `json:"description_en"`
+	EventType            string    `json:"event_type"`
+	EventDate            string    `json:"event_date"`
+	EventTime            string    `json:"event_time"`
+	EndDate              string    `json:"end_date"`
+	EndTime              string    `json:"end_time"`
+	Timezone             string    `json:"timezone"`
+	BaseURL              string    `json:"base_url"`
+	TermsFR              string    `json:"terms_fr"`
+	TermsEN              string    `json:"terms_en"`
+	NotifyEmails         string    `json:"notify_emails"`
+	OrganizerEmail       string    `json:"organizer_email"`
+	CancelDays           *int64    `json:"cancel_days"`
+	RetentionMonths      *int64    `json:"retention_months"`
+	RegistrationOpensAt  string    `json:"registration_opens_at"`
+	RegistrationClosesAt string    `json:"registration_closes_at"`
+	AccessCode           string    `json:"access_code"`
+	MaxTasksPerPerson    int       `json:"max_tasks_per_person"`
+	MaxParticipants      *int64    `json:"max_participants"`
+	CollectDiet          bool      `json:"collect_diet"`
+	CountsOnly           bool      `json:"counts_only"`
+	CheckIn              bool      `json:"checkin"`
+	AssignMode           bool      `json:"assign_mode"`
+	FamilySignup         bool      `json:"family_signup"`
+	MaxGuests            int       `json:"max_guests"`
+	Draft                bool      `json:"draft"`
+	Notes                string    `json:"notes"`
+	CreatedAt            time.Time `json:"created_at"`
+}
+
+func toAPIEvent(e Event) apiEvent {
+	return apiEvent{
+		ID: e.ID, Slug: e.Slug, TitleFR: e.TitleFR, TitleEN: e.TitleEN,
+		DescriptionFR: e.DescriptionFR, DescriptionEN: e.DescriptionEN,
+		EventType: e.EventType, EventDate: e.EventDate, EventTime: e.EventTime,
+		EndDate: e.EndDate, EndTime: e.EndTime, Timezone: e.Timezone,
+		BaseURL: e.BaseURL, TermsFR: e.TermsFR, TermsEN: e.TermsEN,
+		NotifyEmails: e.NotifyEmails, OrganizerEmail: e.OrganizerEmail,
+		CancelDays: nullInt(e.CancelDays), RetentionMonths: nullInt(e.RetentionMonths),
+		RegistrationOpensAt: e.RegistrationOpensAt, RegistrationClosesAt: e.RegistrationClosesAt,
+		AccessCode: e.AccessCode, MaxTasksPerPerson: e.MaxTasksPerPerson, MaxParticipants: nullInt(e.MaxParticipants),
+		CollectDiet: e.CollectDiet, CountsOnly: e.CountsOnly, CheckIn: e.CheckIn,
+		AssignMode: e.AssignMode, FamilySignup: e.FamilySignup, MaxGuests: e.MaxGuests, Draft: e.Draft,
+		Notes: e.Notes, CreatedAt: e.CreatedAt,
+	}
+}
+
+// applyAPIEvent copies the writable fields of a onto e, checked and
+// normalized as the event editor does, and returns the error code of the
+// first invalid one.
+func applyAPIEvent(a apiEvent, e *Event) string {
+	switch a.EventType {
+	case "tasks", "attendance", "secret_santa":
+	default:
+		return "invalid_event_type"
+	}
+	if strings.TrimSpace(a.TitleFR) == "" {
+		return "missing_title"
+	}
+	if _, err := time.Parse("2006-01-02", a.EventDate); err != nil {
+		return "invalid_event_date"
+	}
+	var err error
+	if e.BaseURL, err = normalizeBaseURL(a.BaseURL); err != nil {
+		return "invalid_base_url"
+	}
+	if e.NotifyEmails, err = normalizeNotifyEmails(a.NotifyEmails); err != nil {
+		return "invalid_notify_emails"
+	}
+	e.OrganizerEmail = strings.TrimSpace(a.OrganizerEmail)
+	if e.OrganizerEmail != "" {
+		addr, err := mail.ParseAddress(e.OrganizerEmail)
+		if err != nil {
+			return "invalid_organizer_email"
+		}
+		e.OrganizerEmail = addr.Address
+	}
+	if e.Timezone, err = normalizeTimezone(a.Timezone); err != nil {
+		return "invalid_timezone"
+	}
+	if e.CancelDays, err = parseCancelDays(intString(a.CancelDays)); err != nil {
+		return "invalid_cancel_days"
+	}
+	if e.RetentionMonths, err = parseRetentionMonths(intString(a.RetentionMonths)); err != nil {
+		return "invalid_retention_months"
+	}
+	if e.MaxParticipants, err = parseMaxParticipants(intString(a.MaxParticipants)); err != nil {
+		return "invalid_max_participants"
+	}
+	e.TitleFR, e.TitleEN = a.TitleFR, a.TitleEN
+	e.DescriptionFR = sanitizeEventDescription(a.DescriptionFR)
+	e.DescriptionEN = sanitizeEventDescription(a.DescriptionEN)
+	e.EventType = a.EventType
+	e.EventDate, e.EventTime, e.EndDate, e.EndTime = a.EventDate, a.EventTime, a.EndDate, a.EndTime
+	if err := normalizeEventEnd(e); err != nil {
+		return "invalid_end"
+	}
+	e.TermsFR, e.TermsEN = strings.TrimSpace(a.TermsFR), strings.TrimSpace(a.TermsEN)
+	e.RegistrationOpensAt, e.RegistrationClosesAt = a.RegistrationOpensAt, a.RegistrationClosesAt
+	if err := normalizeRegistrationWindow(e); err != nil {
+		return "invalid_registration_window"
+	}
+	e.AccessCode = normalizeAccessCode(a.AccessCode)
+	e.MaxTasksPerPerson = min(max(a.MaxTasksPerPerson, 1), maxTasksPerPerson)
+	e.CollectDiet, e.CountsOnly, e.CheckIn = a.CollectDiet, a.CountsOnly, a.CheckIn
+	e.AssignMode, e.FamilySignup = a.AssignMode, a.FamilySignup
+	e.MaxGuests = min(max(a.MaxGuests, 0), maxTaskGuests)
+	e.Draft = a.Draft
+	e.Notes = strings.TrimSpace(a.Notes)
+	return ""
+}
+
+// apiV1Events lists the events, or creates one: a draft, unless the body
+// says otherwise.
+func (app *App) apiV1Events(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		events, err := ListEvents(app.DB)
+		if err != nil {
+			apiServerError(w, "events", err)
+			return
+		}
+		result := make([]apiEvent, len(events))
+		for i, e := range events {
+			result[i] = toAPIEvent(e)
+		}
+		writeJSON(w, http.StatusOK, result)
+	case http.MethodPost:
+		a := apiEvent{EventType: "tasks", MaxTasksPerPerson: 1, Draft: true}
+		if !decodeAPIBody(w, r, &a) {
+			return
+		}
+		e := &Event{}
+		if code := applyAPIEvent(a, e); code != "" {
+			apiError(w, http.StatusBadRequest, code)
+			return
+		}
+		if err := CreateEvent(app.DB, e); err != nil {
+			apiServerError(w, "create event", err)
+			return
+		}
+		app.writeAPIEvent(w, http.StatusCreated, e.ID)
+	default:
+		methodNotAllowed(w)
+	}
+}
+
+func (app *App) writeAPIEvent(w http.ResponseWriter, status int, id int64) {
+	e, err := GetEvent(app.DB, id)
+	if !apiLoad(w, "event", id, err) {
+		return
+	}
+	writeJSON(w, status, toAPIEvent(*e))
+}
+
+func (app *App) apiV1Event(w http.ResponseWriter, r *http.Request) {
+	id, ok := apiPathID(w, r)
+	if !ok {
+		return
+	}
+	e, err := GetEvent(app.DB, id)
+	if !apiLoad(w, "event", id, err) {
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, toAPIEvent(*e))
+	case http.MethodPatch:
+		a := toAPIEvent(*e)
+		if !decodeAPIBody(w, r, &a) {
+			return
+		}
+		wasDraft := e.Draft
+		if code := applyAPIEvent(a, e); code != "" {
+			apiError(w, http.StatusBadRequest, code)
+			return
+		}
+		if err := UpdateEvent(app.DB, e); err != nil {
+			apiServerError(w, fmt.Sprintf("update event %d", id), err)
+			return
+		}
+		if e.Draft != wasDraft {
+			if err := SetEventDraft(app.DB, id, e.Draft); err != nil {
+				apiServerError(w, fmt.Sprintf("publish event %d", id), err)
+				return
+			}
+		}
+		app.writeAPIEvent(w, http.StatusOK, id)
+	case http.MethodDelete:
+		if err := DeleteEvent(app.DB, id); err != nil {
+			apiServerError(w, fmt.Sprintf("delete event %d", id), err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		methodNotAllowed(w)
+	}
+}
+
+// apiV1EventOf loads the event of an /events/{id}/... path.
+func (app *App) apiV1EventOf(w http.ResponseWriter, r *http.Request) (*Event, bool) {
+	id, ok := apiPathID(w, r)
+	if !ok {
+		return nil, false
+	}
+	e, err := GetEvent(app.DB, id)
+	if !apiLoad(w, "event", id, err) {
+		return nil, false
+	}
+	return e, true
+}
+
+// ---- Groups ----
+
+// apiGroup is a TaskGroup as the API reads and writes it. Its parent is
+// only set on creation.
+type apiGroup struct {
+	ID            int64  `json:"id"`
+	EventID       int64  `json:"event_id"`
+	ParentGroupID *int64 `json:"parent_group_id"`
+	TitleFR       string `json:"title_fr"`
+	TitleEN       string `json:"title_en"`
+	Position      int    `json:"position"`
+	MaxSlots      *int64 `json:"max_slots"`
+	Color         string `json:"color"`
+	Icon          string `json:"icon"`
+}
+
+func toAPIGroup(g TaskGroup) apiGroup {
+	return apiGroup{
+		ID: g.ID, EventID: g.EventID, ParentGroupID: nullInt(g.ParentGroupID),
+		TitleFR: g.TitleFR, TitleEN: g.TitleEN, Position: g.Position, MaxSlots: nullInt(g.MaxSlots),
+		Color: g.Color, Icon: g.Icon,
+	}
+}
+
+func (app *App) apiV1EventGroups(w http.ResponseWriter, r *http.Request) {
+	event, ok := app.apiV1EventOf(w, r)
+	if !ok {
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		groups, err := ListTaskGroups(app.DB, event.ID)
+		if err != nil {
+			apiServerError(w, fmt.Sprintf("groups of event %d", event.ID), err)
+			return
+		}
+		result := make([]apiGroup, len(groups))
+		for i, g := range groups {
+			result[i] = toAPIGroup(g)
+		}
+		writeJSON(w, http.StatusOK, result)
+	case http.MethodPost:
+		var a apiGroup
+		if !decodeAPIBody(w, r, &a) {
+			return
+		}
+		g := &TaskGroup{EventID: event.ID, TitleFR: a.TitleFR, TitleEN: a.TitleEN, MaxSlots: positiveNullInt(a.MaxSlots), Color: a.Color, Icon: a.Icon}
+		normalizeGroupStyle(g)
+		if a.ParentGroupID != nil {
+			parent, err := GetTaskGroup(app.DB, *a.ParentGroupID)
+			if err != nil || parent.EventID != event.ID {
+				apiError(w, http.StatusBadRequest, "invalid_parent_group")
+				return
+			}
+			g.ParentGroupID = sql.NullInt64{Int64: parent.ID, Valid: true}
+		}
+		if err := CreateTaskGroup(app.DB, g); err != nil {
+			apiServerError(w, fmt.Sprintf("create group in event %d", event.ID), err)
+			return
+		}
+		app.snapshotStructure(event.ID, "edit")
+		writeJSON(w, http.StatusCreated, toAPIGroup(*g))
+	default:
+		methodNotAllowed(w)
+	}
+}
+
+func (app *App) apiV1Group(w http.ResponseWriter, r *http.Request) {
+	id, ok := apiPathID(w, r)
+	if !ok {
+		return
+	}
+	g, err := GetTaskGroup(app.DB, id)
+	if !apiLoad(w, "group", id, err) {
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, toAPIGroup(*g))
+	case http.MethodPatch:
+		a := toAPIGroup(*g)
+		if !decodeAPIBody(w, r, &a) {
+			return
+		}
+		g.TitleFR, g.TitleEN, g.MaxSlots = a.TitleFR, a.TitleEN, positiveNullInt(a.MaxSlots)
+		g.Color, g.Icon = a.Color, a.Icon
+		normalizeGroupStyle(g)
+		if err := UpdateTaskGroup(app.DB, g); err != nil {
+			apiServerError(w, fmt.Sprintf("update group %d", id), err)
+			return
+		}
+		app.snapshotStructure(g.EventID, "edit")
+		writeJSON(w, http.StatusOK, toAPIGroup(*g))
+	case http.MethodDelete:
+		if err := DeleteTaskGroup(app.DB, id); err != nil {
+			apiServerError(w, fmt.Sprintf("delete group %d", id), err)
+			return
+		}
+		app.snapshotStructure(g.EventID, "delete")
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		methodNotAllowed(w)
+	}
+}
+
+// ---- Tasks ----
+
+// apiTask is a Task as the API reads and writes it. Its group is only set
+// on creation.
+type apiTask struct {
+	ID            int64  `json:"id"`
+	EventID       int64  `json:"event_id"`
+	GroupID       *int64 `json:"group_id"`
+	TitleFR       string `json:"title_fr"`
+	TitleEN       string `json:"title_en"`
+	DescriptionFR string `json:"description_fr"`
+	DescriptionEN string `json:"description_en"`
+	MaxSlots      *int64 `json:"max_slots"`
+	StartTime     string `json:"start_time"`
+	EndTime       string `json:"end_time"`
+	Position      int    `json:"position"`
+	MaxGuests     int    `json:"max_guests"`
+	MinSlots      int    `json:"min_slots"`
+	Skills        string `json:"skills"`
+	ReservedSlots int    `json:"reserved_slots"`
+	Notes         string `json:"notes"`
+}
+
+func toAPITask(t Task) apiTask {
+	return apiTask{
+		ID: t.ID, EventID: t.EventID, GroupID: nullInt(t.GroupID),
+		TitleFR: t.TitleFR, TitleEN: t.TitleEN, DescriptionFR: t.DescriptionFR, DescriptionEN: t.DescriptionEN,
+		MaxSlots: nullInt(t.MaxSlots), StartTime: t.StartTime, EndTime: t.EndTime, Position: t.Position,
+		MaxGuests: t.MaxGuests, MinSlots: t.MinSlots, Skills: t.Skills, ReservedSlots: t.ReservedSlots, Notes: t.Notes,
+	}
+}
+
+// applyAPITask copies the writable fields of a onto t as the task editor
+// does, and returns the error code of the first invalid one.
+func applyAPITask(a apiTask, t *Task) string {
+	if !validTaskTime(a.StartTime) || !validTaskTime(a.EndTime) {
+		return "invalid_time"
+	}
+	t.TitleFR, t.TitleEN = a.TitleFR, a.TitleEN
+	t.DescriptionFR, t.DescriptionEN = a.DescriptionFR, a.DescriptionEN
+	t.MaxSlots = positiveNullInt(a.MaxSlots)
+	t.StartTime, t.EndTime = a.StartTime, a.EndTime
+	t.MaxGuests = min(max(a.MaxGuests, 0), maxTaskGuests)
+	t.MinSlots = a.MinSlots
+	t.Skills = normalizeSkills(a.Skills)
+	t.ReservedSlots = a.ReservedSlots
+	t.Notes = strings.TrimSpace(a.Notes)
+	normalizeMinSlots(t)
+	normalizeReservedSlots(t)
+	return ""
+}
+
+func (app *App) apiV1EventTasks(w http.ResponseWriter, r *http.Request) {
+	event, ok := app.apiV1EventOf(w, r)
+	if !ok {
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		tasks, err := ListTasks(app.DB, event.ID)
+		if err != nil {
+			apiServerError(w, fmt.Sprintf("tasks of event %d", event.ID), err)
+			return
+		}
+		result := make([]apiTask, len(tasks))
+		for i, t := range tasks {
+			result[i] = toAPITask(t)
+		}
+		writeJSON(w, http.StatusOK, result)
+	case http.MethodPost:
+		var a apiTask
+		if !decodeAPIBody(w, r, &a) {
+			return
+		}
+		t := &Task{EventID: event.ID}
+		if code := applyAPITask(a, t); code != "" {
+			apiError(w, http.StatusBadRequest, code)
+			return
+		}
+		if a.GroupID != nil {
+			g, err := GetTaskGroup(app.DB, *a.GroupID)
+			if err != nil || g.EventID != event.ID {
+				apiError(w, http.StatusBadRequest, "invalid_group")
+				return
+			}
+			t.GroupID = sql.NullInt64{Int64: g.ID, Valid: true}
+		}
+		if err := CreateTask(app.DB, t); err != nil {
+			apiServerError(w, fmt.Sprintf("create task in event %d", event.ID), err)
+			return
+		}
+		app.snapshotStructure(event.ID, "edit")
+		writeJSON(w, http.StatusCreated, toAPITask(*t))
+	default:
+		methodNotAllowed(w)
+	}
+}
+
+func (app *App) apiV1Task(w http.ResponseWriter, r *http.Request) {
+	id, ok := apiPathID(w, r)
+	if !ok {
+		return
+	}
+	t, err := GetTask(app.DB, id)
+	if !apiLoad(w, "task", id, err) {
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, toAPITask(*t))
+	case http.MethodPatch:
+		a := toAPITask(*t)
+		if !decodeAPIBody(w, r, &a) {
+			return
+		}
+		if code := applyAPITask(a, t); code != "" {
+			apiError(w, http.StatusBadRequest, code)
+			return
+		}
+		if err := UpdateTask(app.DB, t); err != nil {
+			apiServerError(w, fmt.Sprintf("update task %d", id), err)
+			return
+		}
+		app.snapshotStructure(t.EventID, "edit")
+		writeJSON(w, http.StatusOK, toAPITask(*t))
+	case http.MethodDelete:
+		if err := DeleteTask(app.DB, id); err != nil {
+			apiServerError(w, fmt.Sprintf("delete task %d", id), err)
+			return
+		}
+		app.snapshotStructure(t.EventID, "delete")
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		methodNotAllowed(w)
+	}
+}
+
+// ---- Registrations ----
+
+// apiRegistration is a registration to a task as the API reads and writes
+// it. Moving it to another task goes through MoveRegistration; its guests,
+// diet and language stay as signed up.
+type apiRegistration struct {
+	ID          int64     `json:"id"`
+	EventID     int64     `json:"event_id"`
+	TaskID      int64     `json:"task_id"`
+	FirstName   string    `json:"first_name"`
+	LastName    string    `json:"last_name"`
+	Email       string    `json:"email"`
+	Phone       string    `json:"phone"`
+	Lang        string    `json:"lang"`
+	Guests      int       `json:"guests"`
+	Comment     string    `json:"comment"`
+	Diet        string    `json:"diet"`
+	Allergies   string    `json:"allergies"`
+	CheckedIn   bool      `json:"checked_in"`
+	CheckedInAt *string   `json:"checked_in_at"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func toAPIRegistration(eventID int64, reg RegistrationExport) apiRegistration {
+	a := apiRegistration{
+		ID: reg.ID, EventID: eventID, TaskID: reg.TaskID,
+		FirstName: reg.FirstName, LastName: reg.LastName, Email: reg.Email, Phone: reg.Phone, Lang: reg.Lang,
+		Guests: reg.Guests, Comment: reg.Comment, Diet: reg.Diet, Allergies: reg.Allergies,
+		CheckedIn: reg.CheckedInAt.Valid, CreatedAt: reg.CreatedAt,
+	}
+	if reg.CheckedInAt.Valid {
+		a.CheckedInAt = &reg.CheckedInAt.String
+	}
+	return a
+}
+
+// getAPIRegistration returns live registration id with its event.
+func getAPIRegistration(db *sql.DB, id int64) (apiRegistration, error) {
+	var eventID int64
+	err := db.QueryRow(`SELECT t.event_id FROM registrations r JOIN tasks t ON t.id = r.task_id
+		WHERE r.id = ? AND r.trash_id IS NULL AND t.trash_id IS NULL`, id).Scan(&eventID)
+	if err != nil {
+		return apiRegistration{}, err
+	}
+	regs, err := SelectedRegistrations(db, eventID, []int64{id})
+	if err != nil {
+		return apiRegistration{}, err
+	}
+	if len(regs) == 0 {
+		return apiRegistration{}, sql.ErrNoRows
+	}
+	return toAPIRegistration(eventID, regs[0]), nil
+}
+
+// signupErrorCode returns the API error code telling why
+// AddManualRegistration failed; "" for a server error.
+func signupErrorCode(err error) string {
+	var ruleErr *taskRuleError
+	switch {
+	case errors.Is(err, errAlreadyRegistered), errors.Is(err, errTaskLimit):
+		return "already_registered"
+	case errors.Is(err, errTooManyGuests):
+		return "too_many_guests"
+	case errors.Is(err, errGroupFull):
+		return "group_full"
+	case errors.Is(err, errEventFull):
+		return "event_full"
+	case errors.As(err, &ruleErr):
+		return "task_rule"
+	case err.Error() == "task_full":
+		return "task_full"
+	}
+	return ""
+}
+
+// checkAPIPerson checks the contact details of a registration or
+// attendance, and returns the error code of the first invalid one.
+func checkAPIPerson(firstName, lastName, email string, emailRequired bool) string {
+	if strings.TrimSpace(firstName) == "" || strings.TrimSpace(lastName) == "" {
+		return "missing_name"
+	}
+	if (email != "" || emailRequired) && !validEmailSyntax(email) {
+		return "invalid_email"
+	}
+	return ""
+}
+
+// apiV1EventRegistrations lists a task event's registrations, or adds one
+// as the registrations page does: with "override" past the task's and
+// event's limits, with "notify" sending its confirmation. An email address
+// already signed up for the task, or for as many tasks as the event allows,
+// gets 409 already_registered even with "override".
+func (app *App) apiV1EventRegistrations(w http.ResponseWriter, r *http.Request) {
+	event, ok := app.apiV1EventOf(w, r)
+	if !ok {
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		regs, err := ListAllRegistrations(app.DB, event.ID)
+		if err != nil {
+			apiServerError(w, fmt.Sprintf("registrations of event %d", event.ID), err)
+			return
+		}
+		result := make([]apiRegistration, len(regs))
+		for i, reg := range regs {
+			result[i] = toAPIRegistration(event.ID, reg)
+		}
+		writeJSON(w, http.StatusOK, result)
+	case http.MethodPost:
+		req := struct {
+			apiRegistration
+			Override bool `json:"override"`
+			Notify   bool `json:"notify"`
+		}{apiRegistration: apiRegistration{Lang: "fr"}}
+		if !decodeAPIBody(w, r, &req) {
+			return
+		}
+		a := req.apiRegistration
+		a.FirstName, a.LastName = strings.TrimSpace(a.FirstName), strings.TrimSpace(a.LastName)
+		a.Email, a.Phone = strings.TrimSpace(a.Email), strings.TrimSpace(a.Phone)
+		a.Comment = strings.TrimSpace(a.Comment)
+		if a.Lang != "en" {
+			a.Lang = "fr"
+		}
+		task, err := GetTask(app.DB, a.TaskID)
+		if err != nil || task.EventID != event.ID {
+			apiError(w, http.StatusBadRequest, "invalid_task")
+			return
+		}
+		if code := checkAPIPerson(a.FirstName, a.LastName, a.Email, false); code != "" {
+			apiError(w, http.StatusBadRequest, code)
+			return
+		}
+		if utf8.RuneCountInString(a.Comment) > maxCommentLen {
+			apiError(w, http.StatusBadRequest, "comment_too_long")
+			return
+		}
+		reg, err := AddManualRegistration(app.DB, task.ID, a.Guests, req.Override, a.FirstName, a.LastName, a.Email, a.Phone, a.Lang)
+		if err != nil {
+			if code := signupErrorCode(err); code != "" {
+				apiError(w, http.StatusConflict, code)
+			} else {
+				apiServerError(w, fmt.Sprintf("register for task %d", task.ID), err)
+			}
+			return
+		}
+		if a.Comment != "" {
+			if err := SetRegistrationComment(app.DB, reg.ID, a.Comment); err != nil {
+				log.Printf("api: registration %d: comment: %v", reg.ID, err)
+			}
+			reg.Comment = a.Comment
+		}
+		if a.Email != "" {
+			if err := SaveVolunteer(app.DB, a.Email, a.FirstName, a.LastName, a.Phone); err != nil {
+				log.Printf("api: registration %d: volunteer: %v", reg.ID, err)
+			}
+			if req.Notify {
+				app.dispatchSignupConfirmation(*reg, *task, *event, app.eventBaseURL(r, event))
+			}
+		}
+		app.writeAPIRegistration(w, http.StatusCreated, reg.ID)
+	default:
+		methodNotAllowed(w)
+	}
+}
+
+func (app *App) writeAPIRegistration(w http.ResponseWriter, status int, id int64) {
+	a, err := getAPIRegistration(app.DB, id)
+	if !apiLoad(w, "registration", id, err) {
+		return
+	}
+	writeJSON(w, status, a)
+}
+
+// apiV1Registration reads, edits or deletes a registration. A PATCH may
+// move it to another task ("notify" then sends the new confirmation), edit
+// its contact details and comment, and check it in or out.
+func (app *App) apiV1Registration(w http.ResponseWriter, r *http.Request) {
+	id, ok := apiPathID(w, r)
+	if !ok {
+		return
+	}
+	cur, err := getAPIRegistration(app.DB, id)
+	if !apiLoad(w, "registration", id, err) {
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, cur)
+	case http.MethodPatch:
+		req := struct {
+			apiRegistration
+			Notify bool `json:"notify"`
+		}{apiRegistration: cur}
+		if !decodeAPIBody(w, r, &req) {
+			return
+		}
+		a := req.apiRegistration
+		a.FirstName, a.LastName = strings.TrimSpace(a.FirstName), strings.TrimSpace(a.LastName)
+		a.Email, a.Phone = strings.TrimSpace(a.Email), strings.TrimSpace(a.Phone)
+		a.Comment = strings.TrimSpace(a.Comment)
+		if code := checkAPIPerson(a.FirstName, a.LastName, a.Email, false); code != "" {
+			apiError(w, http.StatusBadRequest, code)
+			return
+		}
+		if utf8.RuneCountInString(a.Comment) > maxCommentLen {
+			apiError(w, http.StatusBadRequest, "comment_too_long")
+			return
+		}
+		if a.TaskID != cur.TaskID {
+			reg, err := MoveRegistration(app.DB, id, a.TaskID)
+			if err != nil {
+				_, code := moveErrorKey(err)
+				switch code {
+				case "not_found":
+					apiError(w, http.StatusBadRequest, "invalid_task")
+				case "server_error":
+					apiServerError(w, fmt.Sprintf("move registration %d to task %d", id, a.TaskID), err)
+				default:
+					apiError(w, http.StatusConflict, code)
+				}
+				return
+			}
+			if req.Notify {
+				app.notifyMove(r, reg)
+			}
+		}
+		if a.FirstName != cur.FirstName || a.LastName != cur.LastName || a.Email != cur.Email || a.Phone != cur.Phone {
+			if err := UpdateRegistrationContact(app.DB, id, a.FirstName, a.LastName, a.Email, a.Phone); err != nil {
+				if isUniqueViolation(err) {
+					apiError(w, http.StatusConflict, "already_registered")
+				} else {
+					apiServerError(w, fmt.Sprintf("update registration %d", id), err)
+				}
+				return
+			}
+		}
+		if a.Comment != cur.Comment {
+			if err := SetRegistrationComment(app.DB, id, a.Comment); err != nil {
+				apiServerError(w, fmt.Sprintf("comment of registration %d", id), err)
+				return
+			}
+		}
+		if a.CheckedIn != cur.CheckedIn {
+			check := MarkCheckedIn
+			if !a.CheckedIn {
+				check = UndoCheckIn
+			}
+			if err := check(app.DB, id); err != nil {
+				apiServerError(w, fmt.Sprintf("check-in of registration %d", id), err)
+				return
+			}
+		}
+		app.writeAPIRegistration(w, http.StatusOK, id)
+	case http.MethodDelete:
+		if err := DeleteRegistration(app.DB, id); err != nil {
+			apiServerError(w, fmt.Sprintf("delete registration %d", id), err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		methodNotAllowed(w)
+	}
+}
+
+// ---- Attendances ----
+
+// apiAttendance is an Attendance as the API reads and writes it.
+type apiAttendance struct {
+	ID          int64     `json:"id"`
+	EventID     int64     `json:"event_id"`
+	FirstName   string    `json:"first_name"`
+	LastName    string    `json:"last_name"`
+	Email       string    `json:"email"`
+	Phone       string    `json:"phone"`
+	Attending   bool      `json:"attending"`
+	AdultGuests int       `json:"adult_guests"`
+	ChildGuests int       `json:"child_guests"`
+	Message     string    `json:"message"`
+	Lang        string    `json:"lang"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func toAPIAttendance(a Attendance) apiAttendance {
+	return apiAttendance{
+		ID: a.ID, EventID: a.EventID, FirstName: a.FirstName, LastName: a.LastName, Email: a.Email, Phone: a.Phone,
+		Attending: a.Attending, AdultGuests: a.AdultGuests, ChildGuests: a.ChildGuests, Message: a.Message, Lang: a.Lang, CreatedAt: a.CreatedAt, UpdatedAt: a.UpdatedAt,
+	}
+}
+
+// applyAPIAttendance copies the writable fields of in onto a, and returns
+// the error code of the first invalid one. An email address is required
+// as on the RSVP form, except on answers imported without one.
+func applyAPIAttendance(in apiAttendance, a *Attendance, emailRequired bool) string {
+	in.FirstName, in.LastName = strings.TrimSpace(in.FirstName), strings.TrimSpace(in.LastName)
+	in.Email = strings.TrimSpace(in.Email)
+	if code := checkAPIPerson(in.FirstName, in.LastName, in.Email, emailRequired); code != "" {
+		return code
+	}
+	a.FirstName, a.LastName, a.Email = in.FirstName, in.LastName, in.Email
+	a.Phone = strings.TrimSpace(in.Phone)
+	a.Attending = in.Attending
+	if in.AdultGuests < 0 || in.ChildGuests < 0 || in.AdultGuests+in.ChildGuests > maxTaskGuests {
+		return "invalid_guests"
+	}
+	a.AdultGuests, a.ChildGuests = in.AdultGuests, in.ChildGuests
+	a.Message = strings.TrimSpace(in.Message)
+	a.Lang = in.Lang
+	if a.Lang != "en" {
+		a.Lang = "fr"
+	}
+	return ""
+}
+
+// apiV1EventAttendances lists an attendance event's RSVPs, or records one:
+// like the RSVP form, a second answer from the same email address replaces
+// the first.
+func (app *App) apiV1EventAttendances(w http.ResponseWriter, r *http.Request) {
+	event, ok := app.apiV1EventOf(w, r)
+	if !ok {
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		attendances, err := ListAttendances(app.DB, event.ID)
+		if err != nil {
+			apiServerError(w, fmt.Sprintf("attendances of event %d", event.ID), err)
+			return
+		}
+		result := make([]apiAttendance, len(attendances))
+		for i, a := range attendances {
+			result[i] = toAPIAttendance(a)
+		}
+		writeJSON(w, http.StatusOK, result)
+	case http.MethodPost:
+		if event.EventType != "attendance" {
+			apiError(w, http.StatusConflict, "not_an_attendance_event")
+			return
+		}
+		var in apiAttendance
+		if !decodeAPIBody(w, r, &in) {
+			return
+		}
+		a := &Attendance{}
+		if code := applyAPIAttendance(in, a, true); code != "" {
+			apiError(w, http.StatusBadRequest, code)
+			return
+		}
+		a, err := UpsertAttendance(app.DB, event.ID, a.FirstName, a.LastName, a.Email, a.Phone, a.Attending, a.AdultGuests, a.ChildGuests, a.Message, a.Lang)
+		if err != nil {
+			apiServerError(w, fmt.Sprintf("RSVP to event %d", event.ID), err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, toAPIAttendance(*a))
+	default:
+		methodNotAllowed(w)
+	}
+}
+
+func (app *App) apiV1Attendance(w http.ResponseWriter, r *http.Request) {
+	id, ok := apiPathID(w, r)
+	if !ok {
+		return
+	}
+	a, err := GetAttendance(app.DB, id)
+	if !apiLoad(w, "attendance", id, err) {
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, toAPIAttendance(*a))
+	case http.MethodPatch:
+		in := toAPIAttendance(*a)
+		if !decodeAPIBody(w, r, &in) {
+			return
+		}
+		if code := applyAPIAttendance(in, a, a.Email != ""); code != "" {
+			apiError(w, http.StatusBadRequest, code)
+			return
+		}
+		if other, err := GetAttendanceByEmail(app.DB, a.Email, a.EventID); err == nil && other.ID != a.ID {
+			apiError(w, http.StatusConflict, "already_registered")
+			return
+		}
+		if err := UpdateAttendance(app.DB, a); err != nil {
+			apiServerError(w, fmt.Sprintf("update attendance %d", id), err)
+			return
+		}
+		a, err = GetAttendance(app.DB, id)
+		if !apiLoad(w, "attendance", id, err) {
+			return
+		}
+		writeJSON(w, http.StatusOK, toAPIAttendance(*a))
+	case http.MethodDelete:
+		if err := DeleteAttendance(app.DB, id); err != nil {
+			apiServerError(w, fmt.Sprintf("delete attendance %d", id), err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		methodNotAllowed(w)
+	}
+}
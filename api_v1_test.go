@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAPIV1(t *testing.T) {
+	app := testApp(t)
+	token, err := CreateAPIToken(app.DB, "Check-in app", "write")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := app.csrfProtect(newMux(app))
+	call := func(method, path string, body any, out any) int {
+		t.Helper()
+		var buf bytes.Buffer
+		if body != nil {
+			json.NewEncoder(&buf).Encode(body)
+		}
+		req := httptest.NewRequest(method, path, &buf)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if out != nil {
+			if err := json.Unmarshal(w.Body.Bytes(), out); err != nil {
+				t.Fatalf("%s %s: status %d, %q", method, path, w.Code, w.Body.String())
+			}
+		}
+		return w.Code
+	}
+
+	var e apiEvent
+	if code := call(http.MethodPost, "/admin/api/v1/events", map[string]any{"title_fr": "Kermesse", "event_date": "2026-06-15"}, &e); code != http.StatusCreated || e.ID == 0 || !e.Draft || e.Slug != "kermesse" {
+		t.Fatalf("create event: %d %+v", code, e)
+	}
+	var apiErr map[string]string
+	if code := call(http.MethodPost, "/admin/api/v1/events", map[string]any{"title_fr": "Sans date"}, &apiErr); code != http.StatusBadRequest || apiErr["error"] != "invalid_event_date" {
+		t.Errorf("event without date: %d %v", code, apiErr)
+	}
+	eventPath := fmt.Sprintf("/admin/api/v1/events/%d", e.ID)
+	if code := call(http.MethodPatch, eventPath, map[string]any{"title_en": "Fair", "draft": false, "max_participants": 40}, &e); code != http.StatusOK ||
+		e.TitleFR != "Kermesse" || e.TitleEN != "Fair" || e.Draft || e.MaxParticipants == nil || *e.MaxParticipants != 40 {
+		t.Errorf("patch event: %d %+v", code, e)
+	}
+
+	var g apiGroup
+	if code := call(http.MethodPost, eventPath+"/groups", map[string]any{"title_fr": "Cuisine"}, &g); code != http.StatusCreated || g.EventID != e.ID {
+		t.Fatalf("create group: %d %+v", code, g)
+	}
+	var tk apiTask
+	if code := call(http.MethodPost, eventPath+"/tasks", map[string]any{"title_fr": "Vaisselle", "group_id": g.ID, "max_slots": 1}, &tk); code != http.StatusCreated || tk.GroupID == nil || *tk.GroupID != g.ID {
+		t.Fatalf("create task: %d %+v", code, tk)
+	}
+	var other apiTask
+	call(http.MethodPost, eventPath+"/tasks", map[string]any{"title_fr": "Buvette"}, &other)
+	if code := call(http.MethodPatch, fmt.Sprintf("/admin/api/v1/tasks/%d", other.ID), map[string]any{"start_time": "25:00"}, &apiErr); code != http.StatusBadRequest || apiErr["error"] != "invalid_time" {
+		t.Errorf("invalid task time: %d %v", code, apiErr)
+	}
+	var tasks []apiTask
+	if call(http.MethodGet, eventPath+"/tasks", nil, &tasks); len(tasks) != 2 {
+		t.Errorf("tasks = %+v", tasks)
+	}
+
+	var reg apiRegistration
+	if code := call(http.MethodPost, eventPath+"/registrations", map[string]any{"task_id": tk.ID, "first_name": "Alice", "last_name": "Dupont", "email": "alice@test.com"}, &reg); code != http.StatusCreated || reg.TaskID != tk.ID || reg.Lang != "fr" {
+		t.Fatalf("create registration: %d %+v", code, reg)
+	}
+	if code := call(http.MethodPost, eventPath+"/registrations", map[string]any{"task_id": tk.ID, "first_name": "Bob", "last_name": "Martin"}, &apiErr); code != http.StatusConflict || apiErr["error"] != "task_full" {
+		t.Errorf("registration on a full task: %d %v", code, apiErr)
+	}
+	regPath := fmt.Sprintf("/admin/api/v1/registrations/%d", reg.ID)
+	if code := call(http.MethodPatch, regPath, map[string]any{"task_id": other.ID, "phone": "0601", "checked_in": true}, &reg); code != http.StatusOK ||
+		reg.TaskID != other.ID || reg.Phone != "0601" || reg.Email != "alice@test.com" || !reg.CheckedIn || reg.CheckedInAt == nil {
+		t.Errorf("patch registration: %d %+v", code, reg)
+	}
+	if code := call(http.MethodDelete, regPath, nil, nil); code != http.StatusNoContent {
+		t.Errorf("delete registration: %d", code)
+	}
+	if code := call(http.MethodGet, regPath, nil, &apiErr); code != http.StatusNotFound || apiErr["error"] != "not_found" {
+		t.Errorf("deleted registration: %d %v", code, apiErr)
+	}
+
+	var rsvp apiEvent
+	call(http.MethodPost, "/admin/api/v1/events", map[string]any{"title_fr": "AG", "event_date": "2026-07-01", "event_type": "attendance"}, &rsvp)
+	var a apiAttendance
+	if code := call(http.MethodPost, fmt.Sprintf("/admin/api/v1/events/%d/attendances", rsvp.ID), map[string]any{"first_name": "Chloé", "last_name": "Petit", "email": "chloe@test.com", "attending": true}, &a); code != http.StatusCreated || !a.Attending {
+		t.Fatalf("create attendance: %d %+v", code, a)
+	}
+	if code := call(http.MethodPatch, fmt.Sprintf("/admin/api/v1/attendances/%d", a.ID), map[string]any{"attending": false, "message": "Malade"}, &a); code != http.StatusOK || a.Attending || a.Message != "Malade" || a.Email != "chloe@test.com" {
+		t.Errorf("patch attendance: %d %+v", code, a)
+	}
+
+	if code := call(http.MethodPut, eventPath, nil, &apiErr); code != http.StatusMethodNotAllowed || apiErr["error"] != "method_not_allowed" {
+		t.Errorf("PUT: %d %v", code, apiErr)
+	}
+	if code := call(http.MethodGet, "/admin/api/v1/nothing", nil, &apiErr); code != http.StatusNotFound {
+		t.Errorf("unknown path: %d", code)
+	}
+	if code := call(http.MethodDelete, eventPath, nil, nil); code != http.StatusNoContent {
+		t.Errorf("delete event: %d", code)
+	}
+	if n := CountTrash(app.DB); n != 2 {
+		t.Errorf("trash holds %d entries, want the registration and the event", n)
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+// API tokens let scripts call the admin JSON endpoints with an
+// "Authorization: Bearer <token>" header instead of a session cookie — to
+// pull registrations into a spreadsheet, say. Read tokens may only GET;
+// write tokens may also call the editing endpoints. Tokens are created and
+// revoked on the settings page and survive password changes.
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// bearerToken returns the token of an Authorization: Bearer header, or "".
+func bearerToken(r *http.Request) string {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// requireAPI guards a JSON endpoint: a request carrying a bearer token is
+// authenticated by it alone, anything else needs an admin session.
+func (app *App) requireAPI(next http.HandlerFunc) http.HandlerFunc {
+	return app.requireAPIScope(next, false)
+}
+
+// requireReadAPI is requireAPI for an endpoint that never writes, which
+// read tokens may then POST to: the GraphQL one.
+func (app *App) requireReadAPI(next http.HandlerFunc) http.HandlerFunc {
+	return app.requireAPIScope(next, true)
+}
+
+func (app *App) requireAPIScope(next http.HandlerFunc, readOnly bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := bearerToken(r)
+		if token == "" {
+			if _, ok := app.touchSession(w, r); !ok {
+				http.Error(w, `{"error":"unauthorized"}`, 401)
+				return
+			}
+			next(w, r)
+			return
+		}
+		t, err := LookupAPIToken(app.DB, token)
+		if err != nil {
+			http.Error(w, `{"error":"unauthorized"}`, 401)
+			return
+		}
+		if t.Scope != "write" && !readOnly && r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, `{"error":"read-only token"}`, 403)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// handleAPIEvents lists every event.
+func (app *App) handleAPIEvents(w http.ResponseWriter, r *http.Request) {
+	events, err := ListEvents(app.DB)
+	if err != nil {
+		log.Printf("api: events: %v", err)
+		http.Error(w, `{"error":"server error"}`, 500)
+		return
+	}
+	type eventInfo struct {
+		ID        int64  `json:"id"`
+		Slug      string `json:"slug"`
+		TitleFR   string `json:"title_fr"`
+		TitleEN   string `json:"title_en"`
+		EventType string `json:"event_type"`
+		EventDate string `json:"event_date"`
+		EventTime string `json:"event_time"`
+		EndDate   string `json:"end_date"`
+		EndTime   string `json:"end_time"`
+		Timezone  string `json:"timezone"`
+	}
+	result := make([]eventInfo, len(events))
+	for i, e := range events {
+		result[i] = eventInfo{e.ID, e.Slug, e.TitleFR, e.TitleEN, e.EventType, e.EventDate, e.EventTime, e.EndDate, e.EndTime, e.Timezone}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// handleAPIRegistrations lists an event's sign-ups: registrations for a
+// task event, RSVPs for an attendance event.
+func (app *App) handleAPIRegistrations(w http.ResponseWriter, r *http.Request) {
+	eventID, _ := strconv.ParseInt(r.URL.Query().Get("event_id"), 10, 64)
+	if eventID == 0 {
+		http.Error(w, `{"error":"missing event_id"}`, 400)
+		return
+	}
+	event, err := GetEvent(app.DB, eventID)
+	if err != nil {
+		http.Error(w, `{"error":"not found"}`, 404)
+		return
+	}
+	type registrationInfo struct {
+		ID        int64     `json:"id"`
+		Group     string    `json:"group,omitempty"`
+		Task      string    `json:"task,omitempty"`
+		Attending *bool     `json:"attending,omitempty"`
+		Message   string    `json:"message,omitempty"`
+		FirstName string    `json:"first_name"`
+		LastName  string    `json:"last_name"`
+		Email     string    `json:"email"`
+		Phone     string    `json:"phone"`
+		Lang      string    `json:"lang"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+	result := []registrationInfo{}
+	if event.EventType == "attendance" {
+		attendances, err := ListAttendances(app.DB, eventID)
+		if err != nil {
+			log.Printf("api: attendances of event %d: %v", eventID, err)
+			http.Error(w, `{"error":"server error"}`, 500)
+			return
+		}
+		for _, a := range attendances {
+			result = append(result, registrationInfo{
+				ID: a.ID, Attending: &a.Attending, Message: a.Message,
+				FirstName: a.FirstName, LastName: a.LastName, Email: a.Email, Phone: a.Phone, Lang: a.Lang, CreatedAt: a.CreatedAt,
+			})
+		}
+	} else {
+		regs, err := ListAllRegistrations(app.DB, eventID)
+		if err != nil {
+			log.Printf("api: registrations of event %d: %v", eventID, err)
+			http.Error(w, `{"error":"server error"}`, 500)
+			return
+		}
+		for _, reg := range regs {
+			result = append(result, registrationInfo{
+				ID: reg.ID, Group: reg.GroupTitle, Task: reg.TaskTitle,
+				FirstName: reg.FirstName, LastName: reg.LastName, Email: reg.Email, Phone: reg.Phone, Lang: reg.Lang, CreatedAt: reg.CreatedAt,
+			})
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// handleAdminAPITokenCreate creates a token and shows it once on the
+// settings page.
+func (app *App) handleAdminAPITokenCreate(w http.ResponseWriter, r *http.Request) {
+	lang := LangFromRequest(r)
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/admin/settings?lang="+lang, http.StatusSeeOther)
+		return
+	}
+	name := strings.TrimSpace(r.FormValue("name"))
+	scope := r.FormValue("scope")
+	if scope != "write" {
+		scope = "read"
+	}
+	var token string
+	errKey := ""
+	if name == "" {
+		errKey = "api_tokens_error_name"
+	} else if t, err := CreateAPIToken(app.DB, name, scope); err != nil {
+		log.Printf("api token: create: %v", err)
+		errKey = "error_server"
+	} else {
+		token = t
+	}
+	current, _ := GetSMTPSettings(app.DB)
+	data := app.settingsData(r, current, current.Password != "")
+	pd := app.newPageData(r, data)
+	if errKey != "" {
+		pd.Error = T(errKey, lang)
+	} else {
+		data["NewAPIToken"] = token
+		pd.Success = T("api_tokens_created", lang)
+	}
+	app.render(w, r, "admin_settings.html", pd)
+}
+
+func (app *App) handleAdminAPITokenRevoke(w http.ResponseWriter, r *http.Request) {
+	lang := LangFromRequest(r)
+	if r.Method == http.MethodPost {
+		id, _ := strconv.ParseInt(r.FormValue("id"), 10, 64)
+		if err := DeleteAPIToken(app.DB, id); err != nil {
+			log.Printf("api token %d: delete: %v", id, err)
+			setFlash(w, "error", T("error_server", lang))
+		} else {
+			setFlash(w, "success", T("api_tokens_revoked", lang))
+		}
+	}
+	http.Redirect(w, r, "/admin/settings?lang="+lang, http.StatusSeeOther)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"testing"
+)
+
+func TestAPITokens(t *testing.T) {
+	app := testApp(t)
+	e := seedEvent(t, app.DB)
+	tk := seedTask(t, app.DB, e.ID, "Vaisselle", nil)
+	RegisterForTask(app.DB, tk.ID, "Alice", "Dupont", "alice@test.com", "0601", "fr")
+	h := app.csrfProtect(newMux(app))
+
+	create := func(scope string) string {
+		w := postForm(newMux(app), "/admin/settings/api-tokens?lang=fr", url.Values{"name": {"Script"}, "scope": {scope}}, adminCookie(app))
+		m := regexp.MustCompile(`id="new_api_token" value="([0-9a-f]+)"`).FindStringSubmatch(w.Body.String())
+		if m == nil {
+			t.Fatalf("no %s token shown after creation", scope)
+		}
+		return m[1]
+	}
+	call := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+	read, write := create("read"), create("write")
+	regsPath := fmt.Sprintf("/admin/api/registrations?event_id=%d", e.ID)
+	save := fmt.Sprintf(`{"event_id":%d,"title_fr":"Renamed","event_date":"2026-06-15"}`, e.ID)
+
+	if w := call(http.MethodGet, regsPath, "", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("no token: status %d, want 401", w.Code)
+	}
+	if w := call(http.MethodGet, regsPath, "0123", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("unknown token: status %d, want 401", w.Code)
+	}
+	w := call(http.MethodGet, regsPath, read, "")
+	var regs []map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &regs); err != nil || len(regs) != 1 || regs[0]["email"] != "alice@test.com" {
+		t.Fatalf("registrations: status %d, %s", w.Code, w.Body.String())
+	}
+
+	// Token requests skip the CSRF check but honour the token's scope.
+	if w := call(http.MethodPost, "/admin/api/event/save", read, save); w.Code != http.StatusForbidden {
+		t.Errorf("read token saving: status %d, want 403", w.Code)
+	}
+	if w := call(http.MethodPost, "/admin/api/event/save", write, save); w.Code != http.StatusOK {
+		t.Errorf("write token saving: status %d %s", w.Code, w.Body.String())
+	}
+
+	tokens, _ := ListAPITokens(app.DB)
+	if len(tokens) != 2 || !tokens[0].LastUsed.Valid {
+		t.Fatalf("tokens = %+v", tokens)
+	}
+	postForm(newMux(app), "/admin/settings/api-tokens/revoke", url.Values{"id": {fmt.Sprint(tokens[0].ID)}}, adminCookie(app))
+	if w := call(http.MethodGet, regsPath, read, ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("revoked token: status %d, want 401", w.Code)
+	}
+}
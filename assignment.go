@@ -0,0 +1,321 @@
+package main
+
+// Assign mode. Instead of picking a task on a first-come basis, people rank
+// up to three tasks they'd like; when signups are in, an organizer resolves
+// the assignments in one go. Everyone who can be is given their first
+// choice, then those left over their second, then their third — in signup
+// order within each round, so when a task has too few places for everyone
+// who put it first, the earliest get it. Each assignment is an ordinary
+// registration made with RegisterForTask, so task and group capacities, the
+// event's participant cap and task rules all hold. People get their signup
+// confirmation, or an email saying none of their choices had room.
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxPreferenceChoices is how many tasks someone ranks.
+const maxPreferenceChoices = 3
+
+// errPreferenceResolved is returned by SavePreference once the assignments
+// of the email address have been made.
+var errPreferenceResolved = errors.New("preference_resolved")
+
+// Preference is the tasks someone would like, best first.
+type Preference struct {
+	ID        int64
+	EventID   int64
+	FirstName string
+	LastName  string
+	Email     string
+	Phone     string
+	Lang      string
+	Choices   [maxPreferenceChoices]sql.NullInt64
+	// ResolvedAt is when assignments ran for the preference, and
+	// AssignedTaskID the task it got then, if any and still signed up for.
+	ResolvedAt     sql.NullString
+	AssignedTaskID sql.NullInt64
+	CreatedAt      string
+}
+
+// SavePreference records p, in place of the preference its email address
+// gave before for the event, which keeps its place in line.
+func SavePreference(db *sql.DB, p *Preference) error {
+	p.Email = strings.ToLower(p.Email)
+	var resolved sql.NullString
+	err := db.QueryRow("SELECT resolved_at FROM task_preferences WHERE event_id=? AND email=?", p.EventID, p.Email).Scan(&resolved)
+	if err == nil && resolved.Valid {
+		return errPreferenceResolved
+	} else if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	_, err = db.Exec(`INSERT INTO task_preferences (event_id, first_name, last_name, email, phone, lang, choice1, choice2, choice3)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(event_id, email) DO UPDATE SET
+			first_name=excluded.first_name, last_name=excluded.last_name, phone=excluded.phone, lang=excluded.lang,
+			choice1=excluded.choice1, choice2=excluded.choice2, choice3=excluded.choice3`,
+		p.EventID, p.FirstName, p.LastName, p.Email, p.Phone, p.Lang, p.Choices[0], p.Choices[1], p.Choices[2])
+	return err
+}
+
+// ListPreferences returns the preferences of event eventID in signup order.
+func ListPreferences(db *sql.DB, eventID int64) ([]Preference, error) {
+	rows, err := db.Query(`SELECT p.id, p.event_id, p.first_name, p.last_name, p.email, p.phone, p.lang,
+			p.choice1, p.choice2, p.choice3, p.resolved_at, r.task_id, p.created_at
+		FROM task_preferences p LEFT JOIN registrations r ON r.id = p.registration_id AND r.trash_id IS NULL
+		WHERE p.event_id = ? ORDER BY p.created_at, p.id`, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var prefs []Preference
+	for rows.Next() {
+		var p Preference
+		if err := rows.Scan(&p.ID, &p.EventID, &p.FirstName, &p.LastName, &p.Email, &p.Phone, &p.Lang,
+			&p.Choices[0], &p.Choices[1], &p.Choices[2], &p.ResolvedAt, &p.AssignedTaskID, &p.CreatedAt); err != nil {
+			return nil, err
+		}
+		prefs = append(prefs, p)
+	}
+	return prefs, rows.Err()
+}
+
+// parsePreferenceChoices reads the ranked tasks of a preference form: tasks
+// of the event, each once, the first one required.
+func parsePreferenceChoices(r *http.Request, tasks []Task) ([maxPreferenceChoices]sql.NullInt64, bool) {
+	var choices [maxPreferenceChoices]sql.NullInt64
+	n := 0
+	for i := range maxPreferenceChoices {
+		id, _ := strconv.ParseInt(r.FormValue(fmt.Sprintf("choice_%d", i+1)), 10, 64)
+		if id == 0 {
+			continue
+		}
+		known := false
+		for _, t := range tasks {
+			known = known || t.ID == id
+		}
+		for _, c := range choices[:n] {
+			known = known && c.Int64 != id
+		}
+		if !known {
+			return choices, false
+		}
+		choices[n] = sql.NullInt64{Int64: id, Valid: true}
+		n++
+	}
+	return choices, choices[0].Valid
+}
+
+// Assignment is a preference resolved into a registration.
+type Assignment struct {
+	Preference Preference
+	Reg        *Registration
+}
+
+// ResolveAssignments assigns the preferences of event eventID not resolved
+// yet, as told at the top of this file, and returns those that got a task
+// and those that didn't.
+func ResolveAssignments(db *sql.DB, eventID int64, now time.Time) (assigned []Assignment, unplaced []Preference, err error) {
+	prefs, err := ListPreferences(db, eventID)
+	if err != nil {
+		return nil, nil, err
+	}
+	var pending []Preference
+	for _, p := range prefs {
+		if !p.ResolvedAt.Valid {
+			pending = append(pending, p)
+		}
+	}
+	regs := make([]*Registration, len(pending))
+	for rank := range maxPreferenceChoices {
+		for i, p := range pending {
+			if regs[i] != nil || !p.Choices[rank].Valid {
+				continue
+			}
+			// An error means the task has no room left, or a limit or rule
+			// keeps the person from it: on to their next choice.
+			if reg, err := RegisterForTask(db, p.Choices[rank].Int64, p.FirstName, p.LastName, p.Email, p.Phone, p.Lang); err == nil {
+				regs[i] = reg
+			}
+		}
+	}
+	resolvedAt := now.UTC().Format("2006-01-02 15:04:05")
+	for i, p := range pending {
+		var regID sql.NullInt64
+		if regs[i] != nil {
+			regID = sql.NullInt64{Int64: regs[i].ID, Valid: true}
+			assigned = append(assigned, Assignment{Preference: p, Reg: regs[i]})
+		} else {
+			unplaced = append(unplaced, p)
+		}
+		if _, err := db.Exec("UPDATE task_preferences SET registration_id=?, resolved_at=? WHERE id=?", regID, resolvedAt, p.ID); err != nil {
+			return assigned, unplaced, err
+		}
+	}
+	return assigned, unplaced, nil
+}
+
+// ---- Public ----
+
+// renderPreferenceForm shows the preference form of event, with errMsg when
+// saving failed, or that it was saved.
+func (app *App) renderPreferenceForm(w http.ResponseWriter, r *http.Request, event *Event, saved bool, errMsg string) {
+	tree, _ := BuildEventTree(app.DB, event.ID)
+	open := openTaskViews(tree)
+	ranks := make([]int, maxPreferenceChoices)
+	for i := range ranks {
+		ranks[i] = i + 1
+	}
+	lang := eventLang(r, event)
+	pd := app.newPageData(r, map[string]any{
+		"Event": event, "Tasks": open, "Ranks": ranks, "Saved": saved,
+		"Registration": signupState(app.DB, event, time.Now()), "Opening": eventOpening(r, event, time.Now()),
+		"OpenGraph": app.eventOpenGraph(r, event, lang), "Share": app.eventShare(r, event, lang),
+	})
+	pd.Error = errMsg
+	app.render(w, r, "public_preferences.html", pd)
+}
+
+func (app *App) handlePublicPreferences(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+		return
+	}
+	lang := LangFromRequest(r)
+	eventID, _ := strconv.ParseInt(r.FormValue("event_id"), 10, 64)
+	event, err := GetEvent(app.DB, eventID)
+	if err != nil || event.EventType != "tasks" || !event.AssignMode {
+		http.NotFound(w, r)
+		return
+	}
+	if app.hiddenDraft(r, event) {
+		app.renderComingSoon(w, r, event)
+		return
+	}
+	if app.inviteDenied(r, event) {
+		app.renderInviteRequired(w, r, event)
+		return
+	}
+	if errKey := app.checkSpam(r); errKey != "" {
+		app.renderPreferenceForm(w, r, event, false, T(errKey, lang))
+		return
+	}
+	switch signupState(app.DB, event, time.Now()) {
+	case registrationNotYetOpen:
+		app.renderPreferenceForm(w, r, event, false, T("registration_not_open", lang))
+		return
+	case registrationClosed:
+		app.renderPreferenceForm(w, r, event, false, T("registration_closed", lang))
+		return
+	case registrationFull:
+		app.renderPreferenceForm(w, r, event, false, T("registration_full", lang))
+		return
+	}
+
+	p := &Preference{
+		EventID:   event.ID,
+		FirstName: strings.TrimSpace(r.FormValue("first_name")),
+		LastName:  strings.TrimSpace(r.FormValue("last_name")),
+		Email:     strings.TrimSpace(r.FormValue("email")),
+		Phone:     strings.TrimSpace(r.FormValue("phone")),
+		Lang:      lang,
+	}
+	tasks, _ := ListTasks(app.DB, event.ID)
+	choices, ok := parsePreferenceChoices(r, tasks)
+	if p.FirstName == "" || p.LastName == "" || p.Email == "" || p.Phone == "" || !ok {
+		app.renderPreferenceForm(w, r, event, false, T("error_invalid_form", lang))
+		return
+	}
+	if errKey := app.checkSignupEmail(r.Context(), p.Email); errKey != "" {
+		app.renderPreferenceForm(w, r, event, false, T(errKey, lang))
+		return
+	}
+	inv, refused := app.claimInvite(r, event, p.Email)
+	if refused {
+		app.renderPreferenceForm(w, r, event, false, T("invite_used", lang))
+		return
+	}
+	defer app.releaseInvite(inv, p.Email)
+	p.Choices = choices
+	if err := SavePreference(app.DB, p); errors.Is(err, errPreferenceResolved) {
+		app.renderPreferenceForm(w, r, event, false, T("preferences_resolved", lang))
+		return
+	} else if err != nil {
+		log.Printf("preferences: save for event %d: %v", event.ID, err)
+		app.renderPreferenceForm(w, r, event, false, T("error_server", lang))
+		return
+	}
+	app.useInvite(inv, p.Email)
+	if err := SaveVolunteer(app.DB, p.Email, p.FirstName, p.LastName, p.Phone); err != nil {
+		log.Printf("preferences volunteer error (event %d): %v", event.ID, err)
+	}
+	app.renderPreferenceForm(w, r, event, true, "")
+}
+
+// ---- Admin ----
+
+// handleAdminAssign lists the preferences of an event (GET) and resolves
+// the pending ones (POST).
+func (app *App) handleAdminAssign(w http.ResponseWriter, r *http.Request) {
+	lang := LangFromRequest(r)
+	id, _ := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	event, err := GetEvent(app.DB, id)
+	if err != nil {
+		http.Redirect(w, r, "/admin", http.StatusSeeOther)
+		return
+	}
+	pageURL := fmt.Sprintf("/admin/event/assign?id=%d&lang=%s", event.ID, lang)
+
+	if r.Method == http.MethodPost {
+		assigned, unplaced, err := ResolveAssignments(app.DB, event.ID, time.Now())
+		if err != nil {
+			log.Printf("assignments: resolve event %d: %v", event.ID, err)
+			setFlash(w, "error", T("error_server", lang))
+		} else {
+			setFlash(w, "success", fmt.Sprintf(T("assign_resolved", lang), len(assigned), len(unplaced)))
+		}
+		baseURL := app.eventBaseURL(r, event)
+		for _, a := range assigned {
+			if task, err := GetTask(app.DB, a.Reg.TaskID); err == nil {
+				app.dispatchSignupConfirmation(*a.Reg, *task, *event, baseURL)
+			}
+		}
+		for _, p := range unplaced {
+			subject, html := renderAssignmentUnplacedEmail(p.Lang, *event, baseURL)
+			if html == "" {
+				log.Printf("assignments: empty rendered email body for preference %d, skipping", p.ID)
+				continue
+			}
+			app.queueEmail("assignment_unplaced", event.ID, p.Email, event.OrganizerEmail, subject, html)
+		}
+		http.Redirect(w, r, pageURL, http.StatusSeeOther)
+		return
+	}
+
+	prefs, err := ListPreferences(app.DB, event.ID)
+	if err != nil {
+		http.Error(w, T("error_server", lang), 500)
+		return
+	}
+	tasks, _ := ListTasks(app.DB, event.ID)
+	byID := map[int64]*Task{}
+	for i := range tasks {
+		byID[tasks[i].ID] = &tasks[i]
+	}
+	pending := 0
+	for _, p := range prefs {
+		if !p.ResolvedAt.Valid {
+			pending++
+		}
+	}
+	pd := app.newPageData(r, map[string]any{"Event": event, "Preferences": prefs, "Tasks": byID, "Pending": pending})
+	pd.Success, pd.Error = takeFlash(w, r)
+	app.render(w, r, "admin_assign.html", pd)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResolveAssignments(t *testing.T) {
+	app := testApp(t)
+	mux := newMux(app)
+	e := &Event{TitleFR: "Fête", EventDate: "2026-06-15", MaxTasksPerPerson: 1, AssignMode: true}
+	if err := CreateEvent(app.DB, e); err != nil {
+		t.Fatal(err)
+	}
+	one := int64(1)
+	bar := seedTask(t, app.DB, e.ID, "Buvette", &one)
+	grill := seedTask(t, app.DB, e.ID, "Grill", &one)
+
+	prefer := func(first string, choices ...int64) string {
+		v := url.Values{
+			"event_id": {fmt.Sprint(e.ID)}, "first_name": {first}, "last_name": {"Test"},
+			"email": {strings.ToLower(first) + "@test.com"}, "phone": {"0601"},
+		}
+		for i, c := range choices {
+			v.Set(fmt.Sprintf("choice_%d", i+1), fmt.Sprint(c))
+		}
+		return postForm(mux, "/preferences?lang=en", v).Body.String()
+	}
+	if body := prefer("Alice", bar.ID, grill.ID); !strings.Contains(body, T("preferences_saved", "en")) {
+		t.Fatal("preference not saved")
+	}
+	prefer("Bob", bar.ID, grill.ID)
+	prefer("Carol", bar.ID)
+	if body := prefer("Dan", bar.ID, bar.ID); !strings.Contains(body, T("error_invalid_form", "en")) {
+		t.Error("same task ranked twice accepted")
+	}
+
+	assigned, unplaced, err := ResolveAssignments(app.DB, e.ID, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(assigned) != 2 || assigned[0].Reg.TaskID != bar.ID || assigned[1].Reg.TaskID != grill.ID {
+		t.Errorf("assigned = %+v", assigned)
+	}
+	if len(unplaced) != 1 || unplaced[0].FirstName != "Carol" {
+		t.Errorf("unplaced = %+v", unplaced)
+	}
+	if body := prefer("Alice", grill.ID); !strings.Contains(body, T("preferences_resolved", "en")) {
+		t.Error("resolved preference changed")
+	}
+	body := getRequest(mux, fmt.Sprintf("/admin/event/assign?id=%d&lang=en", e.ID), adminCookie(app)).Body.String()
+	if !strings.Contains(body, "Carol") || !strings.Contains(body, T("assign_unplaced", "en")) {
+		t.Error("assign page misses the unplaced preference")
+	}
+	if again, _, _ := ResolveAssignments(app.DB, e.ID, time.Now()); len(again) != 0 {
+		t.Errorf("resolved twice: %+v", again)
+	}
+}
@@ -0,0 +1,464 @@
+package main
+
+// Scheduled backups. When EVENT_SIGNUP_BACKUP_DIR or _S3_BUCKET is set, a
+// background job writes a timestamped snapshot (the same VACUUM INTO copy as
+// the /admin/backup download) on a cron schedule and keeps the latest few.
+// The outcome of the last run is kept in the settings table so the admin
+// dashboard can warn when backups stop working.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+const (
+	backupNamePrefix      = "event-signup-"
+	defaultBackupSchedule = "0 3 * * *"
+	defaultBackupKeep     = 7
+)
+
+// BackupStore is where scheduled snapshots go.
+type BackupStore interface {
+	// Put stores the file at path under name.
+	Put(ctx context.Context, name, path string) error
+	// List returns the names of stored snapshots.
+	List(ctx context.Context) ([]string, error)
+	Delete(ctx context.Context, name string) error
+	// String describes the destination for the admin.
+	String() string
+}
+
+// DirStore keeps snapshots in a local directory.
+type DirStore struct {
+	Dir string
+}
+
+func (s DirStore) Put(ctx context.Context, name, path string) error {
+	if err := os.MkdirAll(s.Dir, 0o700); err != nil {
+		return err
+	}
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	// Written under a temporary name first so a half-written file never
+	// looks like a snapshot.
+	tmp := filepath.Join(s.Dir, "."+name+".tmp")
+	dst, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, src)
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(s.Dir, name))
+}
+
+func (s DirStore) List(ctx context.Context) ([]string, error) {
+	entries, err := os.ReadDir(s.Dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if isBackupName(e.Name()) {
+			names = append(names, e.Name())
+		}
+	}
+	return names, nil
+}
+
+func (s DirStore) Delete(ctx context.Context, name string) error {
+	return os.Remove(filepath.Join(s.Dir, name))
+}
+
+// Get copies the file stored under name to path.
+func (s DirStore) Get(ctx context.Context, name, path string) error {
+	src, err := os.Open(filepath.Join(s.Dir, name))
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	return writeFileFrom(path, src)
+}
+
+func (s DirStore) String() string { return s.Dir }
+
+// S3Store keeps snapshots in an S3 bucket, under an optional key prefix.
+type S3Store struct {
+	client *s3.Client
+	Bucket string
+	Prefix string
+}
+
+// NewS3Store connects to bucket with the standard AWS configuration; for
+// another S3-compatible service, set AWS_ENDPOINT_URL_S3, and pathStyle if
+// it doesn't support bucket subdomains.
+func NewS3Store(ctx context.Context, bucket, prefix string, pathStyle bool) (*S3Store, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("load aws config: %w", err)
+	}
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	client := s3.NewFromConfig(cfg, func(o *s3.Options) { o.UsePathStyle = pathStyle })
+	return &S3Store{client: client, Bucket: bucket, Prefix: prefix}, nil
+}
+
+func (s *S3Store) Put(ctx context.Context, name, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.Bucket),
+		Key:         aws.String(s.Prefix + name),
+		Body:        f,
+		ContentType: aws.String("application/vnd.sqlite3"),
+	})
+	return err
+}
+
+func (s *S3Store) List(ctx context.Context) ([]string, error) {
+	var names []string
+	p := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.Bucket),
+		Prefix: aws.String(s.Prefix + backupNamePrefix),
+	})
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range page.Contents {
+			if name := strings.TrimPrefix(aws.ToString(obj.Key), s.Prefix); isBackupName(name) {
+				names = append(names, name)
+			}
+		}
+	}
+	return names, nil
+}
+
+func (s *S3Store) Delete(ctx context.Context, name string) error {
+	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(s.Prefix + name),
+	})
+	return err
+}
+
+// Get downloads the object stored under name to path. A missing object is
+// reported as os.ErrNotExist.
+func (s *S3Store) Get(ctx context.Context, name, path string) error {
+	out, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(s.Prefix + name),
+	})
+	var noKey *types.NoSuchKey
+	if errors.As(err, &noKey) {
+		return fmt.Errorf("%s%s: %w", s, name, os.ErrNotExist)
+	}
+	if err != nil {
+		return err
+	}
+	defer out.Body.Close()
+	return writeFileFrom(path, out.Body)
+}
+
+func (s *S3Store) String() string { return "s3://" + s.Bucket + "/" + s.Prefix }
+
+// writeFileFrom creates the file at path, which must not exist yet, with the
+// contents of r.
+func writeFileFrom(path string, r io.Reader) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, r)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path)
+	}
+	return err
+}
+
+// backupName names the snapshot taken at t; names sort by date.
+func backupName(t time.Time) string {
+	return backupNamePrefix + t.UTC().Format("20060102-150405") + ".db"
+}
+
+func isBackupName(name string) bool {
+	stamp, ok := strings.CutPrefix(name, backupNamePrefix)
+	if !ok {
+		return false
+	}
+	stamp, ok = strings.CutSuffix(stamp, ".db")
+	if !ok {
+		return false
+	}
+	_, err := time.Parse("20060102-150405", stamp)
+	return err == nil
+}
+
+// AutoBackup is the scheduled backup configuration.
+type AutoBackup struct {
+	Store    BackupStore
+	Schedule *CronSchedule
+	Keep     int // snapshots kept; older ones are deleted
+}
+
+// runScheduledBackup takes a snapshot, stores it and prunes old ones,
+// recording the outcome for the dashboard.
+func (app *App) runScheduledBackup(ctx context.Context, now time.Time) error {
+	b := app.AutoBackup
+	err := func() error {
+		dir, err := os.MkdirTemp("", "event-signup-backup-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "backup.db")
+		if err := app.snapshotDB(ctx, path); err != nil {
+			return err
+		}
+		if err := b.Store.Put(ctx, backupName(now), path); err != nil {
+			return fmt.Errorf("store: %w", err)
+		}
+		names, err := b.Store.List(ctx)
+		if err != nil {
+			return fmt.Errorf("list: %w", err)
+		}
+		slices.Sort(names)
+		for len(names) > b.Keep {
+			if err := b.Store.Delete(ctx, names[0]); err != nil {
+				return fmt.Errorf("rotate: %w", err)
+			}
+			names = names[1:]
+		}
+		return nil
+	}()
+	stamp := now.UTC().Format(time.RFC3339)
+	if err != nil {
+		log.Printf("scheduled backup: %v", err)
+		SetSetting(app.DB, "backup_last_error", err.Error())
+		SetSetting(app.DB, "backup_last_error_at", stamp)
+		return err
+	}
+	log.Printf("scheduled backup: %s written to %s", backupName(now), b.Store)
+	SetSetting(app.DB, "backup_last_ok_at", stamp)
+	SetSetting(app.DB, "backup_last_error", "")
+	return nil
+}
+
+// runBackupWorker runs scheduled backups until ctx is done.
+func (app *App) runBackupWorker(ctx context.Context) {
+	for {
+		next := app.AutoBackup.Schedule.Next(time.Now())
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			app.runScheduledBackup(ctx, time.Now())
+		}
+	}
+}
+
+// BackupStatus is what the admin sees of scheduled backups.
+type BackupStatus struct {
+	Destination string
+	Schedule    string
+	Keep        int
+	LastOK      time.Time // zero: never
+	LastError   string    // set when the latest run failed
+	LastErrorAt time.Time
+	Next        time.Time
+}
+
+// Failing reports whether the latest run failed.
+func (s BackupStatus) Failing() bool { return s.LastError != "" }
+
+// backupStatus returns the scheduled backups' status, or nil when they are
+// not configured.
+func (app *App) backupStatus() *BackupStatus {
+	b := app.AutoBackup
+	if b == nil {
+		return nil
+	}
+	s := &BackupStatus{
+		Destination: b.Store.String(),
+		Schedule:    b.Schedule.String(),
+		Keep:        b.Keep,
+		LastError:   GetSetting(app.DB, "backup_last_error"),
+		Next:        b.Schedule.Next(time.Now()),
+	}
+	if t, err := time.Parse(time.RFC3339, GetSetting(app.DB, "backup_last_ok_at")); err == nil {
+		s.LastOK = t.Local()
+	}
+	if t, err := time.Parse(time.RFC3339, GetSetting(app.DB, "backup_last_error_at")); err == nil {
+		s.LastErrorAt = t.Local()
+	}
+	return s
+}
+
+// parseBackupKeep reads EVENT_SIGNUP_BACKUP_KEEP.
+func parseBackupKeep(raw string) (int, error) {
+	if raw == "" {
+		return defaultBackupKeep, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid backup count %q", raw)
+	}
+	return n, nil
+}
+
+// ---- Cron schedule ----
+
+// CronSchedule is a standard five-field cron expression (minute, hour, day
+// of month, month, day of week) supporting *, lists, ranges and steps,
+// evaluated in the server's local time.
+type CronSchedule struct {
+	expr                          string
+	minute, hour, dom, month, dow []bool
+	domRestricted, dowRestricted  bool
+}
+
+// ParseCron parses a cron expression such as "0 3 * * *".
+func ParseCron(expr string) (*CronSchedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron %q: want 5 fields", expr)
+	}
+	c := &CronSchedule{expr: strings.Join(fields, " ")}
+	var err error
+	bounds := []struct {
+		dst      *[]bool
+		min, max int
+	}{{&c.minute, 0, 59}, {&c.hour, 0, 23}, {&c.dom, 1, 31}, {&c.month, 1, 12}, {&c.dow, 0, 7}}
+	for i, b := range bounds {
+		if *b.dst, err = parseCronField(fields[i], b.min, b.max); err != nil {
+			return nil, fmt.Errorf("cron %q: %w", expr, err)
+		}
+	}
+	c.dow[0] = c.dow[0] || c.dow[7] // both 0 and 7 are Sunday
+	c.domRestricted = fields[2] != "*"
+	c.dowRestricted = fields[4] != "*"
+	if c.Next(time.Now()).IsZero() {
+		return nil, fmt.Errorf("cron %q never matches", expr)
+	}
+	return c, nil
+}
+
+func parseCronField(field string, min, max int) ([]bool, error) {
+	set := make([]bool, max+1)
+	for _, part := range strings.Split(field, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("bad step in %q", part)
+			}
+			step = n
+		}
+		lo, hi := min, max
+		if rng != "*" {
+			a, b, isRange := strings.Cut(rng, "-")
+			var err error
+			if lo, err = strconv.Atoi(a); err != nil {
+				return nil, fmt.Errorf("bad value %q", part)
+			}
+			hi = lo
+			if isRange {
+				if hi, err = strconv.Atoi(b); err != nil {
+					return nil, fmt.Errorf("bad range %q", part)
+				}
+			} else if hasStep {
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return nil, fmt.Errorf("%q out of range %d-%d", part, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			set[v] = true
+		}
+	}
+	return set, nil
+}
+
+// Next returns the first time strictly after t that matches the schedule.
+func (c *CronSchedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	// Every schedule matches within a few years (29 February on a Monday).
+	for limit := t.AddDate(5, 0, 0); t.Before(limit); {
+		switch {
+		case !c.month[t.Month()]:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !c.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case !c.hour[t.Hour()]:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case !c.minute[t.Minute()]:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// dayMatches follows cron: when both day fields are restricted, either may
+// match.
+func (c *CronSchedule) dayMatches(t time.Time) bool {
+	dom, dow := c.dom[t.Day()], c.dow[t.Weekday()]
+	if c.domRestricted && c.dowRestricted {
+		return dom || dow
+	}
+	return dom && dow
+}
+
+func (c *CronSchedule) String() string { return c.expr }
+
+// handleAdminBackupRun takes a scheduled-style backup right away.
+func (app *App) handleAdminBackupRun(w http.ResponseWriter, r *http.Request) {
+	lang := LangFromRequest(r)
+	backupURL := "/admin/backup?lang=" + lang
+	if r.Method != http.MethodPost || app.AutoBackup == nil {
+		http.Redirect(w, r, backupURL, http.StatusSeeOther)
+		return
+	}
+	if err := app.runScheduledBackup(r.Context(), time.Now()); err != nil {
+		setFlash(w, "error", T("autobackup_failed", lang))
+	} else {
+		setFlash(w, "success", T("autobackup_done", lang))
+	}
+	http.Redirect(w, r, backupURL, http.StatusSeeOther)
+}
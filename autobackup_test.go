@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCronSchedule(t *testing.T) {
+	from := time.Date(2026, 10, 16, 14, 7, 30, 0, time.UTC) // a Friday
+	cases := map[string]string{
+		"0 3 * * *":     "2026-10-17 03:00",
+		"*/15 * * * *":  "2026-10-16 14:15",
+		"30 14 * * *":   "2026-10-16 14:30",
+		"0 9 * * 1-5":   "2026-10-19 09:00",
+		"0 0 1 * *":     "2026-11-01 00:00",
+		"0 12 * * 0":    "2026-10-18 12:00",
+		"0 12 * * 7":    "2026-10-18 12:00",
+		"0 6,18 * * *":  "2026-10-16 18:00",
+		"0 0 29 2 *":    "2028-02-29 00:00",
+		"0 0 13 * 5":    "2026-10-23 00:00", // either the 13th or a Friday
+		"5/20 10 * * *": "2026-10-17 10:05",
+	}
+	for expr, want := range cases {
+		c, err := ParseCron(expr)
+		if err != nil {
+			t.Errorf("ParseCron(%q): %v", expr, err)
+			continue
+		}
+		if got := c.Next(from).Format("2006-01-02 15:04"); got != want {
+			t.Errorf("%q: next = %s, want %s", expr, got, want)
+		}
+	}
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "*/0 * * * *", "5-1 * * * *", "0 0 31 2 *", "a * * * *"} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) accepted", expr)
+		}
+	}
+}
+
+func TestScheduledBackups(t *testing.T) {
+	app := testApp(t)
+	seedEvent(t, app.DB)
+	mux := newMux(app)
+	admin := adminCookie(app)
+	if body := getRequest(mux, "/admin/backup?lang=en", admin).Body.String(); !strings.Contains(body, "EVENT_SIGNUP_BACKUP_DIR") {
+		t.Error("backup page does not say scheduled backups are off")
+	}
+
+	dir := t.TempDir()
+	cron, _ := ParseCron(defaultBackupSchedule)
+	app.AutoBackup = &AutoBackup{Store: DirStore{Dir: dir}, Schedule: cron, Keep: 2}
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a backup"), 0o600)
+
+	start := time.Date(2026, 10, 13, 3, 0, 0, 0, time.UTC)
+	for day := range 3 {
+		if err := app.runScheduledBackup(context.Background(), start.AddDate(0, 0, day)); err != nil {
+			t.Fatalf("backup %d: %v", day, err)
+		}
+	}
+	names, _ := DirStore{Dir: dir}.List(context.Background())
+	slices.Sort(names)
+	want := []string{"event-signup-20261014-030000.db", "event-signup-20261015-030000.db"}
+	if !slices.Equal(names, want) {
+		t.Errorf("kept %v, want %v", names, want)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "notes.txt")); err != nil {
+		t.Error("rotation deleted a file that isn't a backup")
+	}
+	if summary, err := checkBackupFile(filepath.Join(dir, want[1])); err != nil || summary.Events != 1 {
+		t.Errorf("snapshot unusable: %+v, %v", summary, err)
+	}
+	s := app.backupStatus()
+	if s.Failing() || !s.LastOK.Equal(start.AddDate(0, 0, 2)) {
+		t.Errorf("status = %+v", s)
+	}
+
+	// A destination that can't be written to shows on the dashboard.
+	app.AutoBackup.Store = DirStore{Dir: filepath.Join(dir, "notes.txt")}
+	if err := app.runScheduledBackup(context.Background(), time.Now()); err == nil {
+		t.Fatal("backup into a file succeeded")
+	}
+	if body := getRequest(mux, "/admin?lang=en", admin).Body.String(); !strings.Contains(body, T("autobackup_failing_short", "en")) {
+		t.Error("dashboard does not show the failing backup")
+	}
+	app.AutoBackup.Store = DirStore{Dir: dir}
+	postForm(mux, "/admin/backup/run?lang=en", nil, admin)
+	if app.backupStatus().Failing() {
+		t.Error("a successful run did not clear the failure")
+	}
+}
@@ -0,0 +1,263 @@
+package main
+
+// Backups from the admin, for organizers who'd rather not touch the server
+// before a big change. A download is a consistent snapshot taken with VACUUM
+// INTO, safe while the app keeps serving. A restore uploads such a file,
+// shows what it holds and, once confirmed, copies it over the live database
+// with SQLite's online backup API, then migrates it to the current schema.
+// The admin password in use survives the restore, so nobody gets locked out
+// by a backup taken before a password change.
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+const backupStagePrefix = "event-signup-restore-"
+
+// backupStageTTL is how long an uploaded backup waits for confirmation.
+const backupStageTTL = time.Hour
+
+var backupTokenRe = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// BackupSummary is what a database holds, shown before restoring it.
+type BackupSummary struct {
+	Events        int
+	Registrations int
+	Attendances   int
+}
+
+func summarizeDB(db *sql.DB) (BackupSummary, error) {
+	var s BackupSummary
+	if err := db.QueryRow("SELECT COUNT(*) FROM events").Scan(&s.Events); err != nil {
+		return s, err
+	}
+	// Tables added later may be missing from old backups.
+	db.QueryRow("SELECT COUNT(*) FROM registrations").Scan(&s.Registrations)
+	db.QueryRow("SELECT COUNT(*) FROM attendances").Scan(&s.Attendances)
+	return s, nil
+}
+
+// checkBackupFile opens an uploaded file read-only and makes sure it is an
+// intact database of this app.
+func checkBackupFile(path string) (BackupSummary, error) {
+	db, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		return BackupSummary{}, err
+	}
+	defer db.Close()
+	var result string
+	if err := db.QueryRow("PRAGMA integrity_check").Scan(&result); err != nil {
+		return BackupSummary{}, err
+	}
+	if result != "ok" {
+		return BackupSummary{}, fmt.Errorf("integrity check: %s", result)
+	}
+	return summarizeDB(db)
+}
+
+func backupStagePath(token string) string {
+	return filepath.Join(os.TempDir(), backupStagePrefix+token+".db")
+}
+
+// purgeStagedBackups removes uploads nobody confirmed.
+func purgeStagedBackups() {
+	paths, _ := filepath.Glob(filepath.Join(os.TempDir(), backupStagePrefix+"*.db"))
+	for _, p := range paths {
+		if info, err := os.Stat(p); err == nil && time.Since(info.ModTime()) > backupStageTTL {
+			os.Remove(p)
+		}
+	}
+}
+
+// restoreDB replaces the contents of app.DB with the database at path.
+func (app *App) restoreDB(ctx context.Context, path string) error {
+	src, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	passwordHash := GetSetting(app.DB, "admin_password_hash")
+
+	dst, err := app.DB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	srcConn, err := src.Conn(ctx)
+	if err != nil {
+		dst.Close()
+		return err
+	}
+	err = dst.Raw(func(d any) error {
+		return srcConn.Raw(func(s any) error {
+			b, err := d.(*sqlite3.SQLiteConn).Backup("main", s.(*sqlite3.SQLiteConn), "main")
+			if err != nil {
+				return err
+			}
+			if _, err := b.Step(-1); err != nil {
+				b.Finish()
+				return err
+			}
+			return b.Finish()
+		})
+	})
+	srcConn.Close()
+	dst.Close()
+	if err != nil {
+		return fmt.Errorf("backup copy: %w", err)
+	}
+	if err := migrateDB(app.DB); err != nil {
+		return err
+	}
+	if err := loadInstanceSettings(app.DB); err != nil {
+		return err
+	}
+	if passwordHash != "" {
+		return SetSetting(app.DB, "admin_password_hash", passwordHash)
+	}
+	return nil
+}
+
+// snapshotDB writes a consistent copy of the database to path, which must
+// not exist yet.
+func (app *App) snapshotDB(ctx context.Context, path string) error {
+	_, err := app.DB.ExecContext(ctx, "VACUUM INTO ?", path)
+	return err
+}
+
+func (app *App) handleAdminBackup(w http.ResponseWriter, r *http.Request) {
+	summary, err := summarizeDB(app.DB)
+	if err != nil {
+		log.Printf("backup: summary: %v", err)
+	}
+	pd := app.newPageData(r, map[string]any{"Current": summary, "AutoBackup": app.backupStatus()})
+	pd.Success, pd.Error = takeFlash(w, r)
+	app.render(w, r, "admin_backup.html", pd)
+}
+
+// handleAdminBackupDownload sends a snapshot of the database.
+func (app *App) handleAdminBackupDownload(w http.ResponseWriter, r *http.Request) {
+	dir, err := os.MkdirTemp("", "event-signup-backup-")
+	if err != nil {
+		log.Printf("backup: %v", err)
+		http.Error(w, T("error_server", LangFromRequest(r)), http.StatusInternalServerError)
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "backup.db")
+	if err := app.snapshotDB(r.Context(), path); err != nil {
+		log.Printf("backup: vacuum into: %v", err)
+		http.Error(w, T("error_server", LangFromRequest(r)), http.StatusInternalServerError)
+		return
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		log.Printf("backup: %v", err)
+		http.Error(w, T("error_server", LangFromRequest(r)), http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+	name := "event-signup-" + time.Now().Format("2006-01-02-1504") + ".db"
+	w.Header().Set("Content-Type", "application/vnd.sqlite3")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+name+`"`)
+	if info, err := f.Stat(); err == nil {
+		w.Header().Set("Content-Length", fmt.Sprint(info.Size()))
+	}
+	io.Copy(w, f)
+}
+
+// handleAdminBackupRestore takes an uploaded backup and shows what it holds;
+// posted again with its token and the confirmation, it restores it.
+func (app *App) handleAdminBackupRestore(w http.ResponseWriter, r *http.Request) {
+	lang := LangFromRequest(r)
+	backupURL := "/admin/backup?lang=" + lang
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, backupURL, http.StatusSeeOther)
+		return
+	}
+	fail := func(key string) {
+		setFlash(w, "error", T(key, lang))
+		http.Redirect(w, r, backupURL, http.StatusSeeOther)
+	}
+
+	if token := r.FormValue("token"); token != "" {
+		if !backupTokenRe.MatchString(token) {
+			fail("backup_restore_expired")
+			return
+		}
+		path := backupStagePath(token)
+		if _, err := os.Stat(path); err != nil {
+			fail("backup_restore_expired")
+			return
+		}
+		if r.FormValue("confirm") == "" {
+			fail("backup_restore_unconfirmed")
+			return
+		}
+		current, _ := app.adminSession(r)
+		err := app.restoreDB(r.Context(), path)
+		os.Remove(path)
+		if err != nil {
+			log.Printf("backup: restore: %v", err)
+			fail("backup_restore_failed")
+			return
+		}
+		log.Printf("backup: database restored from an uploaded backup")
+		// The sessions table came from the backup too; this browser gets a
+		// new session.
+		setFlash(w, "success", T("backup_restored", lang))
+		app.startSession(w, r, backupURL, current.Remember)
+		return
+	}
+
+	purgeStagedBackups()
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		fail("backup_no_file")
+		return
+	}
+	defer file.Close()
+	token := GenerateToken()
+	path := backupStagePath(token)
+	staged, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		log.Printf("backup: stage: %v", err)
+		fail("error_server")
+		return
+	}
+	_, err = io.Copy(staged, file)
+	if cerr := staged.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path)
+		log.Printf("backup: stage: %v", err)
+		fail("error_server")
+		return
+	}
+	summary, err := checkBackupFile(path)
+	if err != nil {
+		os.Remove(path)
+		log.Printf("backup: rejected upload: %v", err)
+		fail("backup_bad_file")
+		return
+	}
+	current, _ := summarizeDB(app.DB)
+	pd := app.newPageData(r, map[string]any{
+		"Current":    current,
+		"Staged":     summary,
+		"Token":      token,
+		"AutoBackup": app.backupStatus(),
+	})
+	app.render(w, r, "admin_backup.html", pd)
+}
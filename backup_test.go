@@ -0,0 +1,71 @@
+package main
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestBackupRestore(t *testing.T) {
+	app := testApp(t)
+	e := seedEvent(t, app.DB)
+	tk := seedTask(t, app.DB, e.ID, "Vaisselle", nil)
+	RegisterForTask(app.DB, tk.ID, "Alice", "Dupont", "alice@test.com", "0601", "fr")
+	mux := newMux(app)
+	admin := adminCookie(app)
+
+	w := getRequest(mux, "/admin/backup/download", admin)
+	backup := w.Body.String()
+	if !strings.HasPrefix(backup, "SQLite format 3") || !strings.Contains(w.Header().Get("Content-Disposition"), "attachment") {
+		t.Fatalf("download is not a database file (%d bytes)", len(backup))
+	}
+
+	// Things change after the backup, including the admin password.
+	DeleteEvent(app.DB, e.ID)
+	if err := app.setAdminPassword("a-newer-password"); err != nil {
+		t.Fatal(err)
+	}
+	admin = adminCookie(app)
+
+	w = postMultipart(mux, "/admin/backup/restore?lang=en", "notes.db", "not a database", nil, admin)
+	if body := followRedirect(mux, w, admin).Body.String(); !strings.Contains(body, T("backup_bad_file", "en")) {
+		t.Error("a file that isn't a database was accepted")
+	}
+
+	body := postMultipart(mux, "/admin/backup/restore?lang=en", "backup.db", backup, nil, admin).Body.String()
+	if !strings.Contains(body, "This backup holds: 1 events, 1 registrations") {
+		t.Errorf("upload does not summarize the backup")
+	}
+	m := regexp.MustCompile(`name="token" value="([0-9a-f]+)"`).FindStringSubmatch(body)
+	if m == nil {
+		t.Fatal("no confirmation form")
+	}
+	postForm(mux, "/admin/backup/restore?lang=en", url.Values{"token": {m[1]}}, admin)
+	if _, err := GetEvent(app.DB, e.ID); err == nil {
+		t.Fatal("restored without the confirmation")
+	}
+	// Unconfirmed uploads stay staged for another try.
+	w = postForm(mux, "/admin/backup/restore?lang=en", url.Values{"token": {m[1]}, "confirm": {"1"}}, admin)
+	if _, err := GetEvent(app.DB, e.ID); err != nil {
+		t.Fatalf("event not restored: %v", err)
+	}
+	if CountRegistrations(app.DB, e.ID) != 1 {
+		t.Error("registration not restored")
+	}
+	if !app.checkAdminPassword("a-newer-password") {
+		t.Error("restore brought back the old admin password")
+	}
+	if w := followRedirect(mux, w); !strings.Contains(w.Body.String(), T("backup_restored", "en")) {
+		t.Error("no new session after the restore")
+	}
+	for _, c := range w.Result().Cookies() {
+		if c.Name == "admin_session" {
+			admin = c
+		}
+	}
+	w = postForm(mux, "/admin/backup/restore?lang=en", url.Values{"token": {m[1]}, "confirm": {"1"}}, admin)
+	if body := followRedirect(mux, w, admin).Body.String(); !strings.Contains(body, T("backup_restore_expired", "en")) {
+		t.Error("a restored upload can be replayed")
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+// Signed cancellation links. A link's token is the registration's random
+// token, the link's expiry and an HMAC of both, so the expiry can't be edited
+// away. The server has the last word: the registration must still exist and
+// the event's current deadline (see Event.CancelDeadline) applies too, so
+// shortening it also cuts off links already sent. A bare registration token
+// only stands for a link for registrations made before signing, whose links
+// went out unsigned; it is honoured until that deadline.
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cancelSig signs a registration token and the expiry part of its link.
+func (app *App) cancelSig(regToken, expires string) (string, error) {
+	secret, err := SettingSecret(app.DB, "cancel_secret")
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(regToken + "." + expires))
+	return hex.EncodeToString(mac.Sum(nil)[:16]), nil
+}
+
+// cancelToken returns the token of reg's cancellation link, expiring at the
+// event's deadline ("0" when there is none). Without a signing secret it
+// falls back to the bare registration token.
+func (app *App) cancelToken(reg Registration, event Event) string {
+	expires := "0"
+	if deadline, ok := event.CancelDeadline(); ok {
+		expires = strconv.FormatInt(deadline.Unix(), 36)
+	}
+	sig, err := app.cancelSig(reg.Token, expires)
+	if err != nil {
+		log.Printf("cancel: sign: %v", err)
+		return reg.Token
+	}
+	return reg.Token + "." + expires + "." + sig
+}
+
+func (app *App) cancelURL(baseURL string, reg Registration, event Event, lang string) string {
+	return baseURL + "/cancel/" + app.cancelToken(reg, event) + "?lang=" + lang
+}
+
+// parseCancelToken checks a cancellation token's signature and returns the
+// registration token it stands for and its expiry (zero if it has none).
+func (app *App) parseCancelToken(token string) (regToken string, expires time.Time, ok bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) == 1 {
+		return token, time.Time{}, token != "" && LegacyRegistrationToken(app.DB, token)
+	}
+	if len(parts) != 3 {
+		return "", time.Time{}, false
+	}
+	want, err := app.cancelSig(parts[0], parts[1])
+	if err != nil {
+		log.Printf("cancel: sign: %v", err)
+		return "", time.Time{}, false
+	}
+	unix, err := strconv.ParseInt(parts[1], 36, 64)
+	if err != nil || !hmac.Equal([]byte(want), []byte(parts[2])) {
+		return "", time.Time{}, false
+	}
+	if unix != 0 {
+		expires = time.Unix(unix, 0)
+	}
+	return parts[0], expires, true
+}
+
+// maxCancelDays bounds Event.CancelDays.
+const maxCancelDays = 365
+
+// parseCancelDays reads the cancellation window typed in the event editor;
+// empty means links never expire.
+func parseCancelDays(raw string) (sql.NullInt64, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return sql.NullInt64{}, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 0 || n > maxCancelDays {
+		return sql.NullInt64{}, fmt.Errorf("invalid cancel days %q", raw)
+	}
+	return sql.NullInt64{Int64: int64(n), Valid: true}, nil
+}
+
+// cancelOpen reports whether a link expiring at expires (zero: never) may
+// still cancel a registration for event.
+func cancelOpen(event Event, expires time.Time) bool {
+	now := time.Now()
+	if !expires.IsZero() && !now.Before(expires) {
+		return false
+	}
+	deadline, ok := event.CancelDeadline()
+	return !ok || now.Before(deadline)
+}
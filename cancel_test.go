@@ -0,0 +1,80 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCancelDeadline(t *testing.T) {
+	e := Event{EventDate: "2026-06-14", EndDate: "2026-06-15", Timezone: "Europe/Paris"}
+	if _, ok := e.CancelDeadline(); ok {
+		t.Error("no window set: links should never expire")
+	}
+	e.CancelDays = sql.NullInt64{Int64: 2, Valid: true}
+	deadline, ok := e.CancelDeadline()
+	if want := "2026-06-18T00:00:00+02:00"; !ok || deadline.Format(time.RFC3339) != want {
+		t.Errorf("deadline = %v, want %s", deadline, want)
+	}
+	for raw, valid := range map[string]bool{"": true, "0": true, "30": true, "-1": false, "abc": false, "1000": false} {
+		if _, err := parseCancelDays(raw); (err == nil) != valid {
+			t.Errorf("parseCancelDays(%q) error = %v", raw, err)
+		}
+	}
+}
+
+func TestSignedCancelLinks(t *testing.T) {
+	app := testApp(t)
+	e := seedEvent(t, app.DB)
+	tk := seedTask(t, app.DB, e.ID, "Vaisselle", nil)
+	reg, _ := RegisterForTask(app.DB, tk.ID, "Alice", "Dupont", "alice@test.com", "0601", "fr")
+	mux := newMux(app)
+
+	// A window reaching past today: the signed link works.
+	e.CancelDays = sql.NullInt64{Int64: int64(time.Since(time.Date(2026, 6, 15, 0, 0, 0, 0, time.UTC)).Hours()/24) + 2, Valid: true}
+	UpdateEvent(app.DB, e)
+	token := app.cancelToken(*reg, *e)
+	if w := getRequest(mux, "/cancel/"+token+"?lang=fr"); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "Alice") {
+		t.Fatalf("signed link: status %d", w.Code)
+	}
+
+	// Editing the expiry breaks the signature.
+	parts := strings.Split(token, ".")
+	forged := parts[0] + ".zzzzzzz." + parts[2]
+	if body := getRequest(mux, "/cancel/"+forged+"?lang=fr").Body.String(); strings.Contains(body, "Alice") {
+		t.Error("forged expiry accepted")
+	}
+
+	// A bare token only stands for a link for registrations made before
+	// links were signed.
+	if body := getRequest(mux, "/cancel/"+reg.Token+"?lang=fr").Body.String(); strings.Contains(body, "Alice") {
+		t.Error("bare token of a new registration accepted")
+	}
+	app.DB.Exec("UPDATE registrations SET legacy_token = 1 WHERE id = ?", reg.ID)
+	if body := getRequest(mux, "/cancel/"+reg.Token+"?lang=fr").Body.String(); !strings.Contains(body, "Alice") {
+		t.Error("bare token of a legacy registration refused")
+	}
+
+	// Shortening the window cuts off links already sent, signed or bare.
+	e.CancelDays = sql.NullInt64{Int64: 0, Valid: true}
+	UpdateEvent(app.DB, e)
+	for _, tok := range []string{token, reg.Token} {
+		if w := postForm(mux, "/cancel/"+tok+"?lang=fr", url.Values{}); w.Code != http.StatusGone {
+			t.Errorf("expired link %q: status %d, want 410", tok, w.Code)
+		}
+	}
+	if CountRegistrations(app.DB, e.ID) != 1 {
+		t.Fatal("expired link cancelled the registration")
+	}
+
+	// Without a window, links keep working.
+	e.CancelDays = sql.NullInt64{}
+	UpdateEvent(app.DB, e)
+	postForm(mux, "/cancel/"+app.cancelToken(*reg, *e)+"?lang=fr", url.Values{})
+	if CountRegistrations(app.DB, e.ID) != 0 {
+		t.Error("signed link without a window did not cancel")
+	}
+}
@@ -0,0 +1,326 @@
+package main
+
+// Check-in at the door. On events with CheckIn set, each registration gets a
+// QR code — on the event page once signed up, and in the confirmation email —
+// holding a link to /admin/event/checkin with a check-in code: the
+// registration's id signed for its event. The code is good for nothing but
+// checking in, so a badge seen by others can't cancel the registration.
+// Organizers open that page on a phone: it scans codes with the camera, marks
+// people as arrived and keeps arrival counts per task live across phones.
+// Where the browser can't scan, the phone's camera app opens the link, which
+// asks to confirm the arrival.
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// CheckInRegistration is a registration as the check-in page shows it.
+type CheckInRegistration struct {
+	ID          int64
+	FirstName   string
+	LastName    string
+	Guests      int
+	TaskTitleFR string
+	TaskTitleEN string
+	CheckedInAt sql.NullString
+}
+
+// GetCheckInRegistration finds registration regID among eventID's.
+func GetCheckInRegistration(db *sql.DB, eventID, regID int64) (*CheckInRegistration, error) {
+	c := &CheckInRegistration{}
+	err := db.QueryRow(`SELECT r.id, r.first_name, r.last_name, r.guests, t.title_fr, t.title_en, r.checked_in_at
+		FROM registrations r JOIN tasks t ON t.id = r.task_id
+		WHERE r.id = ? AND t.event_id = ? AND r.trash_id IS NULL AND t.trash_id IS NULL`, regID, eventID,
+	).Scan(&c.ID, &c.FirstName, &c.LastName, &c.Guests, &c.TaskTitleFR, &c.TaskTitleEN, &c.CheckedInAt)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// MarkCheckedIn records a registration's arrival, keeping the first one.
+func MarkCheckedIn(db *sql.DB, regID int64) error {
+	_, err := db.Exec("UPDATE registrations SET checked_in_at=CURRENT_TIMESTAMP WHERE id=? AND checked_in_at IS NULL", regID)
+	return err
+}
+
+// UndoCheckIn takes back a registration's arrival recorded by mistake.
+func UndoCheckIn(db *sql.DB, regID int64) error {
+	_, err := db.Exec("UPDATE registrations SET checked_in_at=NULL WHERE id=?", regID)
+	return err
+}
+
+// CheckInCount is how many of a task's people have arrived, guests included.
+type CheckInCount struct {
+	TaskID   int64  `json:"task_id"`
+	TitleFR  string `json:"-"`
+	TitleEN  string `json:"-"`
+	Arrived  int    `json:"arrived"`
+	Expected int    `json:"expected"`
+}
+
+// GetCheckInCounts returns arrivals for each of eventID's tasks.
+func GetCheckInCounts(db *sql.DB, eventID int64) ([]CheckInCount, error) {
+	rows, err := db.Query(`SELECT t.id, t.title_fr, t.title_en,
+			COALESCE(SUM(CASE WHEN r.checked_in_at IS NOT NULL THEN 1 + r.guests END), 0), `+countPeople+`
+		FROM tasks t LEFT JOIN registrations r ON r.task_id = t.id AND r.trash_id IS NULL
+		WHERE t.event_id = ? AND t.trash_id IS NULL
+		GROUP BY t.id ORDER BY t.position, t.id`, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var counts []CheckInCount
+	for rows.Next() {
+		var c CheckInCount
+		if err := rows.Scan(&c.TaskID, &c.TitleFR, &c.TitleEN, &c.Arrived, &c.Expected); err != nil {
+			return nil, err
+		}
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
+
+// checkInSig signs a registration's id for its event.
+func (app *App) checkInSig(eventID, regID int64) (string, error) {
+	secret, err := SettingSecret(app.DB, "checkin_secret")
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d:%d", eventID, regID)
+	return hex.EncodeToString(mac.Sum(nil)[:16]), nil
+}
+
+// checkInCode returns the code of registration regID's QR code.
+func (app *App) checkInCode(eventID, regID int64) (string, error) {
+	sig, err := app.checkInSig(eventID, regID)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(regID, 10) + "." + sig, nil
+}
+
+// parseCheckInCode checks a check-in code's signature and returns the
+// registration id it stands for.
+func (app *App) parseCheckInCode(eventID int64, code string) (regID int64, ok bool) {
+	id, sig, found := strings.Cut(code, ".")
+	if !found {
+		return 0, false
+	}
+	regID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	want, err := app.checkInSig(eventID, regID)
+	if err != nil {
+		log.Printf("checkin: sign: %v", err)
+		return 0, false
+	}
+	return regID, hmac.Equal([]byte(want), []byte(sig))
+}
+
+// checkInURL is the link a registration's QR code holds.
+func checkInURL(baseURL string, eventID int64, code string) string {
+	return fmt.Sprintf("%s/admin/event/checkin?id=%d&code=%s", baseURL, eventID, url.QueryEscape(code))
+}
+
+// qrCodeURL is the image of a registration's QR code.
+func (app *App) qrCodeURL(baseURL string, reg Registration, event Event) string {
+	return baseURL + "/qr/" + app.cancelToken(reg, event) + ".png"
+}
+
+// scannedCheckInCode returns the check-in code in a scanned QR code: a
+// checkInURL, or the bare code.
+func scannedCheckInCode(scanned string) string {
+	scanned = strings.TrimSpace(scanned)
+	if u, err := url.Parse(scanned); err == nil && u.Query().Get("code") != "" {
+		return u.Query().Get("code")
+	}
+	return scanned
+}
+
+// scannedRegistration returns the registration to event whose check-in code
+// was scanned.
+func (app *App) scannedRegistration(event *Event, scanned string) (*CheckInRegistration, error) {
+	regID, ok := app.parseCheckInCode(event.ID, scannedCheckInCode(scanned))
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return GetCheckInRegistration(app.DB, event.ID, regID)
+}
+
+// checkInMessage describes an arrival for the organizer who scanned it.
+func checkInMessage(c *CheckInRegistration, event *Event, lang string) string {
+	name := c.FirstName + " " + c.LastName
+	if c.Guests > 0 {
+		name += fmt.Sprintf(" +%d", c.Guests)
+	}
+	if c.CheckedInAt.Valid {
+		at := c.CheckedInAt.String
+		if t, err := time.Parse("2006-01-02 15:04:05", at); err == nil {
+			at = event.InZone(t).Format("15:04")
+		}
+		return fmt.Sprintf(T("checkin_already", lang), name, at)
+	}
+	return fmt.Sprintf(T("checkin_done", lang), name, Localized(c.TaskTitleFR, c.TaskTitleEN, lang))
+}
+
+// handleRegistrationQR serves /qr/<cancel token>.png, the QR code of a
+// registration to an event with check-in.
+func (app *App) handleRegistrationQR(w http.ResponseWriter, r *http.Request) {
+	regToken, _, ok := app.parseCancelToken(strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/qr/"), ".png"))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	reg, err := GetRegistrationByToken(app.DB, regToken)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	task, err := GetTask(app.DB, reg.TaskID)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	event, err := GetEvent(app.DB, task.EventID)
+	if err != nil || !event.CheckIn {
+		http.NotFound(w, r)
+		return
+	}
+	code, err := app.checkInCode(event.ID, reg.ID)
+	if err != nil {
+		log.Printf("checkin: sign registration %d: %v", reg.ID, err)
+		http.Error(w, T("error_server", LangFromRequest(r)), 500)
+		return
+	}
+	png, err := qrcode.Encode(checkInURL(app.eventBaseURL(r, event), event.ID, code), qrcode.Medium, 256)
+	if err != nil {
+		log.Printf("checkin: qr code for registration %d: %v", reg.ID, err)
+		http.Error(w, T("error_server", LangFromRequest(r)), 500)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "private, max-age=86400")
+	w.Write(png)
+}
+
+// handleAdminCheckIn shows the check-in page. Opened from a QR code
+// (?code=), it shows whose code it is and, on POST, checks them in.
+func (app *App) handleAdminCheckIn(w http.ResponseWriter, r *http.Request) {
+	lang := LangFromRequest(r)
+	id, _ := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	event, err := GetEvent(app.DB, id)
+	if err != nil || !event.CheckIn {
+		http.Redirect(w, r, "/admin?lang="+lang, http.StatusSeeOther)
+		return
+	}
+	pageURL := fmt.Sprintf("/admin/event/checkin?id=%d&lang=%s", event.ID, lang)
+
+	var pending *CheckInRegistration
+	if code := r.FormValue("code"); code != "" {
+		pending, err = app.scannedRegistration(event, code)
+		if err != nil {
+			setFlash(w, "error", T("checkin_unknown", lang))
+			http.Redirect(w, r, pageURL, http.StatusSeeOther)
+			return
+		}
+	}
+	if r.Method == http.MethodPost {
+		if pending != nil {
+			msg := checkInMessage(pending, event, lang)
+			if err := MarkCheckedIn(app.DB, pending.ID); err != nil {
+				log.Printf("checkin: registration %d: %v", pending.ID, err)
+				setFlash(w, "error", T("error_server", lang))
+			} else {
+				setFlash(w, "success", msg)
+			}
+		}
+		http.Redirect(w, r, pageURL, http.StatusSeeOther)
+		return
+	}
+
+	counts, err := GetCheckInCounts(app.DB, event.ID)
+	if err != nil {
+		http.Error(w, T("error_server", lang), 500)
+		return
+	}
+	data := map[string]any{"Event": event, "Counts": counts, "Code": r.FormValue("code")}
+	if pending != nil {
+		data["Pending"] = pending
+		if pending.CheckedInAt.Valid {
+			data["PendingMessage"] = checkInMessage(pending, event, lang)
+		}
+	}
+	pd := app.newPageData(r, data)
+	pd.Success, pd.Error = takeFlash(w, r)
+	app.render(w, r, "admin_checkin.html", pd)
+}
+
+// handleAdminCheckInScan checks in the registration of a code scanned on the
+// check-in page and replies with the updated counts.
+func (app *App) handleAdminCheckInScan(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", 405)
+		return
+	}
+	var req struct {
+		EventID int64  `json:"event_id"`
+		Code    string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "bad request", 400)
+		return
+	}
+	lang := LangFromRequest(r)
+	event, err := GetEvent(app.DB, req.EventID)
+	if err != nil || !event.CheckIn {
+		http.NotFound(w, r)
+		return
+	}
+	result := map[string]any{"ok": false}
+	if c, err := app.scannedRegistration(event, req.Code); err != nil {
+		result["message"] = T("checkin_unknown", lang)
+	} else {
+		result["ok"] = true
+		result["already"] = c.CheckedInAt.Valid
+		result["message"] = checkInMessage(c, event, lang)
+		if err := MarkCheckedIn(app.DB, c.ID); err != nil {
+			log.Printf("checkin: registration %d: %v", c.ID, err)
+			http.Error(w, T("error_server", lang), 500)
+			return
+		}
+	}
+	if counts, err := GetCheckInCounts(app.DB, event.ID); err == nil {
+		result["counts"] = counts
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// handleAdminCheckInStatus returns arrival counts, polled by the check-in
+// page so phones scanning side by side stay in step.
+func (app *App) handleAdminCheckInStatus(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	counts, err := GetCheckInCounts(app.DB, id)
+	if err != nil {
+		http.Error(w, T("error_server", LangFromRequest(r)), 500)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(counts)
+}
@@ -0,0 +1,121 @@
+package main
+
+// Printed check-in list. For door volunteers without a phone, every
+// registration of an event on paper, by last name, with a box to tick on
+// arrival and the task to send them to. Those already checked in have
+// their box crossed.
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Check-in list columns, in points from the left of the page.
+const (
+	checkInListLastX  = 64.0
+	checkInListFirstX = 200.0
+	checkInListTaskX  = 350.0
+)
+
+// sortKeyFolder lowers the accents of French names away, so that Émile
+// comes among the E's.
+var sortKeyFolder = strings.NewReplacer(
+	"à", "a", "â", "a", "ä", "a", "á", "a", "ç", "c", "é", "e", "è", "e", "ê", "e", "ë", "e",
+	"î", "i", "ï", "i", "í", "i", "ô", "o", "ö", "o", "ó", "o", "ù", "u", "û", "u", "ü", "u", "ú", "u",
+	"ÿ", "y", "ñ", "n", "œ", "oe", "æ", "ae",
+)
+
+// nameSortKey returns what name sorts by.
+func nameSortKey(name string) string {
+	return sortKeyFolder.Replace(strings.ToLower(strings.TrimSpace(name)))
+}
+
+// sortByLastName sorts regs by last name, then first name.
+func sortByLastName(regs []RegistrationExport) {
+	sort.SliceStable(regs, func(i, j int) bool {
+		a, b := nameSortKey(regs[i].LastName), nameSortKey(regs[j].LastName)
+		if a != b {
+			return a < b
+		}
+		return nameSortKey(regs[i].FirstName) < nameSortKey(regs[j].FirstName)
+	})
+}
+
+func (app *App) handleAdminCheckInList(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	event, err := GetEvent(app.DB, id)
+	if err != nil || event.EventType != "tasks" {
+		http.Error(w, "Not found", 404)
+		return
+	}
+	regs, err := ListAllRegistrations(app.DB, event.ID)
+	if err != nil {
+		log.Printf("check-in list: registrations of event %d: %v", event.ID, err)
+		http.Error(w, T("error_server", LangFromRequest(r)), 500)
+		return
+	}
+	sortByLastName(regs)
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="%s-checkin.pdf"`, event.Slug))
+	w.Write(renderCheckInList(event, regs, LangFromRequest(r)))
+}
+
+// renderCheckInList returns the check-in list of event, regs in the order
+// given, as a PDF.
+func renderCheckInList(event *Event, regs []RegistrationExport, lang string) []byte {
+	d := &pdfDoc{}
+	right := pdfPageWidth - sheetMargin
+	d.addPage()
+	y := sheetHeader(d, event, fmt.Sprintf(T("checkin_list_count", lang), len(regs)), lang)
+
+	columns := func() {
+		y -= 14
+		d.text(checkInListLastX, y, 9, true, T("registration_last_name", lang))
+		d.text(checkInListFirstX, y, 9, true, T("registration_first_name", lang))
+		d.text(checkInListTaskX, y, 9, true, T("confirmation_task", lang))
+		y -= 5
+		d.line(sheetMargin, y, right, y, 0.75, 0)
+	}
+	columns()
+	letter := ""
+	for _, reg := range regs {
+		if y-sheetRowHeight < sheetMargin+20 {
+			d.addPage()
+			y = pdfPageHeight - sheetMargin
+			columns()
+			letter = ""
+		}
+		y -= sheetRowHeight
+		d.rect(sheetMargin+2, y+3, 10, 10, 0.75)
+		if reg.CheckedInAt.Valid {
+			d.line(sheetMargin+2, y+3, sheetMargin+12, y+13, 0.75, 0)
+			d.line(sheetMargin+2, y+13, sheetMargin+12, y+3, 0.75, 0)
+		}
+		// The last name shows in bold where its initial changes.
+		initial := ""
+		if key := nameSortKey(reg.LastName); key != "" {
+			initial = string([]rune(key)[:1])
+		}
+		first := initial != letter
+		letter = initial
+		d.text(checkInListLastX, y+5, 10, first, pdfFit(reg.LastName, checkInListFirstX-checkInListLastX-8, 10, first))
+		person := reg.FirstName
+		if reg.Guests > 0 {
+			person += fmt.Sprintf(" +%d", reg.Guests)
+		}
+		d.text(checkInListFirstX, y+5, 10, false, pdfFit(person, checkInListTaskX-checkInListFirstX-8, 10, false))
+		task := Localized(reg.TaskTitle, reg.TaskTitleEN, lang)
+		if group := Localized(reg.GroupTitle, reg.GroupTitleEN, lang); group != "" {
+			task = group + " › " + task
+		}
+		d.text(checkInListTaskX, y+5, 10, false, pdfFit(task, right-checkInListTaskX, 10, false))
+		d.line(sheetMargin, y, right, y, 0.5, 0.75)
+	}
+	sheetFooters(d, T("checkin_list_title", lang)+" — "+Localized(event.TitleFR, event.TitleEN, lang), lang)
+	return d.bytes()
+}
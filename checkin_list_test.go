@@ -0,0 +1,38 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestCheckInList(t *testing.T) {
+	app := testApp(t)
+	mux := newMux(app)
+	e := seedEvent(t, app.DB)
+	bar := seedTask(t, app.DB, e.ID, "Buvette", nil)
+	RegisterForTask(app.DB, bar.ID, "Zoé", "Martin", "zoe@test.com", "0601", "fr")
+	RegisterForTask(app.DB, bar.ID, "Léa", "Émery", "lea@test.com", "0602", "fr")
+	alice, _ := RegisterForTask(app.DB, bar.ID, "Alice", "dupont", "alice@test.com", "0603", "fr")
+	MarkCheckedIn(app.DB, alice.ID)
+
+	regs, _ := ListAllRegistrations(app.DB, e.ID)
+	sortByLastName(regs)
+	if len(regs) != 3 || regs[0].LastName != "dupont" || regs[1].LastName != "Émery" || regs[2].LastName != "Martin" {
+		t.Fatalf("order = %+v", regs)
+	}
+
+	w := getRequest(mux, fmt.Sprintf("/admin/event/checkin/list?id=%d&lang=fr", e.ID), adminCookie(app))
+	body := w.Body.Bytes()
+	if w.Code != 200 || !bytes.HasPrefix(body, []byte("%PDF-")) {
+		t.Fatalf("status %d, type %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	dupont, martin := bytes.Index(body, []byte("(dupont)")), bytes.Index(body, []byte("(Martin)"))
+	if dupont < 0 || martin < dupont || !bytes.Contains(body, []byte("\xc9mery")) {
+		t.Error("names missing or out of order in the PDF")
+	}
+	// One box to tick per registration.
+	if n := bytes.Count(body, []byte(" re S")); n != 3 {
+		t.Errorf("%d boxes, want 3", n)
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+func TestCheckIn(t *testing.T) {
+	app := testApp(t)
+	mux := newMux(app)
+	admin := adminCookie(app)
+
+	e := &Event{TitleFR: "Fête", EventDate: "2026-06-15", CheckIn: true}
+	if err := CreateEvent(app.DB, e); err != nil {
+		t.Fatal(err)
+	}
+	setup := seedTask(t, app.DB, e.ID, "Montage", nil)
+	bar := seedTask(t, app.DB, e.ID, "Buvette", nil)
+	alice, _ := RegisterForTask(app.DB, setup.ID, "Alice", "Dupont", "alice@test.com", "0601", "en")
+	RegisterForTask(app.DB, setup.ID, "Bob", "Martin", "bob@test.com", "0602", "en")
+	carol, _ := RegisterForTask(app.DB, bar.ID, "Carol", "Durand", "carol@test.com", "0603", "en")
+	carolCode, _ := app.checkInCode(e.ID, carol.ID)
+
+	code, err := app.checkInCode(e.ID, alice.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := getRequest(mux, "/qr/"+app.cancelToken(*alice, *e)+".png")
+	if w.Code != 200 || w.Header().Get("Content-Type") != "image/png" {
+		t.Fatalf("QR code: status %d, type %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	if want, _ := qrcode.Encode(checkInURL("http://example.com", e.ID, code), qrcode.Medium, 256); !bytes.Equal(w.Body.Bytes(), want) {
+		t.Error("QR code doesn't hold the check-in link")
+	}
+	// The code only checks in: it neither cancels nor opens the registration.
+	if body := postForm(mux, "/cancel/"+code+"?lang=en", url.Values{}).Body.String(); !strings.Contains(body, T("cancel_not_found", "en")) {
+		t.Error("/cancel/ accepted the check-in code")
+	}
+	if w := getRequest(mux, "/ics/"+code); w.Code != 404 {
+		t.Errorf("/ics/ accepted the check-in code: status %d", w.Code)
+	}
+	if CountRegistrations(app.DB, e.ID) != 3 {
+		t.Fatal("the check-in code cancelled a registration")
+	}
+	if w := getRequest(mux, "/qr/"+alice.Token+"x.png"); w.Code != 404 {
+		t.Errorf("QR code of an unknown registration: status %d", w.Code)
+	}
+
+	scan := func(code string) map[string]any {
+		body := fmt.Sprintf(`{"event_id": %d, "code": %q}`, e.ID, code)
+		req := httptest.NewRequest(http.MethodPost, "/admin/event/checkin/scan?lang=en", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.AddCookie(admin)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		var res map[string]any
+		if err := json.NewDecoder(w.Body).Decode(&res); err != nil {
+			t.Fatalf("scan %q: status %d: %v", code, w.Code, err)
+		}
+		return res
+	}
+	res := scan(checkInURL("https://fete.example.org", e.ID, code))
+	if res["ok"] != true || res["already"] != false || !strings.Contains(res["message"].(string), "Alice Dupont") {
+		t.Errorf("first scan = %v", res)
+	}
+	if res := scan(code); res["already"] != true {
+		t.Errorf("second scan = %v", res)
+	}
+	bobID := fmt.Sprint(alice.ID + 1)
+	for _, forged := range []string{"nope", alice.Token, bobID + code[strings.Index(code, "."):]} {
+		if res := scan(forged); res["ok"] != false {
+			t.Errorf("code %q = %v", forged, res)
+		}
+	}
+
+	// Opened from a phone's camera app, the link asks before checking in.
+	w = getRequest(mux, fmt.Sprintf("/admin/event/checkin?id=%d&code=%s&lang=en", e.ID, url.QueryEscape(carolCode)), admin)
+	if !strings.Contains(w.Body.String(), T("checkin_confirm", "en")) {
+		t.Error("no confirmation asked for the opened code")
+	}
+	postForm(mux, "/admin/event/checkin?lang=en", url.Values{"id": {fmt.Sprint(e.ID)}, "code": {carolCode}}, admin)
+
+	counts, err := GetCheckInCounts(app.DB, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []CheckInCount{{setup.ID, "Montage", "Montage", 1, 2}, {bar.ID, "Buvette", "Buvette", 1, 1}}
+	if fmt.Sprint(counts) != fmt.Sprint(want) {
+		t.Errorf("counts = %+v, want %+v", counts, want)
+	}
+}
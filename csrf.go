@@ -0,0 +1,81 @@
+package main
+
+// Cross-site request forgery protection. Every browser gets a random token in
+// a cookie; pages echo it in their forms (csrfField) and in a meta tag read
+// by the scripts, which send it back as X-CSRF-Token. A POST whose token
+// doesn't match the cookie is refused, so another site can no longer make a
+// logged-in admin's browser delete events or a volunteer's cancel a signup.
+
+import (
+	"context"
+	"crypto/subtle"
+	"html/template"
+	"net/http"
+	"strings"
+)
+
+const csrfCookie = "csrf_token"
+
+type csrfKey struct{}
+
+// csrfExempt lists POST endpoints called by other servers rather than
+// browsers; they authenticate requests themselves.
+var csrfExempt = map[string]bool{
+	"/webhooks/ses": true,
+}
+
+// csrfProtect wraps the whole mux: it hands out the token and checks it on
+// every state-changing request.
+func (app *App) csrfProtect(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var token string
+		if c, err := r.Cookie(csrfCookie); err == nil && len(c.Value) == 32 {
+			token = c.Value
+		} else {
+			// Long-lived so pages kept by the service worker stay usable.
+			token = GenerateToken()
+			http.SetCookie(w, &http.Cookie{
+				Name:     csrfCookie,
+				Value:    token,
+				Path:     "/",
+				MaxAge:   365 * 24 * 60 * 60,
+				HttpOnly: true,
+				SameSite: http.SameSiteLaxMode,
+			})
+		}
+
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+		default:
+			// Browsers can't attach an Authorization header cross-site, and
+			// requireAPI authenticates such requests by their token alone.
+			if csrfExempt[r.URL.Path] || bearerToken(r) != "" {
+				break
+			}
+			got := r.Header.Get("X-CSRF-Token")
+			if got == "" {
+				got = r.FormValue("csrf_token")
+			}
+			if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+					http.Error(w, `{"error":"invalid csrf token"}`, http.StatusForbidden)
+					return
+				}
+				http.Error(w, T("error_csrf", LangFromRequest(r)), http.StatusForbidden)
+				return
+			}
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), csrfKey{}, token)))
+	})
+}
+
+// csrfToken returns the token csrfProtect attached to r ("" outside it).
+func csrfToken(r *http.Request) string {
+	token, _ := r.Context().Value(csrfKey{}).(string)
+	return token
+}
+
+// csrfField is the hidden input every POST form carries.
+func csrfField(r *http.Request) template.HTML {
+	return template.HTML(`<input type="hidden" name="csrf_token" value="` + template.HTMLEscapeString(csrfToken(r)) + `">`)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestCSRFProtection(t *testing.T) {
+	app := testApp(t)
+	e := seedEvent(t, app.DB)
+	tk := seedTask(t, app.DB, e.ID, "Vaisselle", nil)
+	reg, _ := RegisterForTask(app.DB, tk.ID, "Alice", "Dupont", "alice@test.com", "0601", "fr")
+	token := app.cancelToken(*reg, *e)
+	h := app.csrfProtect(newMux(app))
+
+	// A cross-site form post carries no token.
+	if w := postForm(h, "/cancel/"+token+"?lang=fr", url.Values{}); w.Code != http.StatusForbidden {
+		t.Fatalf("cancel without token: status %d, want 403", w.Code)
+	}
+	if CountRegistrations(app.DB, e.ID) != 1 {
+		t.Fatal("registration cancelled without a token")
+	}
+
+	// The page hands out the cookie and echoes its token in the form.
+	w := getRequest(h, "/cancel/"+token+"?lang=fr")
+	var cookie *http.Cookie
+	for _, c := range w.Result().Cookies() {
+		if c.Name == csrfCookie {
+			cookie = c
+		}
+	}
+	if cookie == nil {
+		t.Fatal("no csrf cookie set")
+	}
+	m := regexp.MustCompile(`name="csrf_token" value="([0-9a-f]+)"`).FindStringSubmatch(w.Body.String())
+	if m == nil || m[1] != cookie.Value {
+		t.Fatalf("form token %v does not match cookie %q", m, cookie.Value)
+	}
+	if w := postForm(h, "/cancel/"+token+"?lang=fr", url.Values{"csrf_token": {"0123456789abcdef0123456789abcdef"}}, cookie); w.Code != http.StatusForbidden {
+		t.Errorf("cancel with a wrong token: status %d, want 403", w.Code)
+	}
+	postForm(h, "/cancel/"+token+"?lang=fr", url.Values{"csrf_token": {cookie.Value}}, cookie)
+	if CountRegistrations(app.DB, e.ID) != 0 {
+		t.Error("cancel with the right token did not go through")
+	}
+
+	// JSON APIs take the token as a header.
+	save := func(token string) *httptest.ResponseRecorder {
+		body := fmt.Sprintf(`{"event_id":%d,"title_fr":"Renamed","event_date":"2026-06-15"}`, e.ID)
+		req := httptest.NewRequest(http.MethodPost, "/admin/api/event/save", bytes.NewReader([]byte(body)))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-CSRF-Token", token)
+		req.AddCookie(adminCookie(app))
+		req.AddCookie(cookie)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+	if w := save(""); w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), "csrf") {
+		t.Errorf("API without token: status %d %s", w.Code, w.Body.String())
+	}
+	if w := save(cookie.Value); w.Code != http.StatusOK {
+		t.Errorf("API with token: status %d", w.Code)
+	}
+
+	// Server-to-server webhooks are exempt.
+	if w := postForm(h, "/webhooks/ses", url.Values{}); w.Code == http.StatusForbidden {
+		t.Error("webhook refused for lack of a csrf token")
+	}
+}
@@ -0,0 +1,239 @@
+package main
+
+// Day-of console. /admin/event/today is the event's day on a phone: every
+// task with its arrivals, the people signed up to it with a call link and a
+// check-in toggle, and a "covered" mark organizers set once the task is
+// taken care of. Counts, arrivals and marks refresh every few seconds so
+// phones used side by side stay in step. Buttons are plain forms, so the
+// page works without JavaScript too.
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// DayOfTask is a task as the day-of console shows it.
+type DayOfTask struct {
+	CheckInCount
+	Covered bool
+	People  []RegistrationExport
+}
+
+// DayOfStatus is what the day-of console polls: counts and covered marks
+// per task, and who has arrived.
+type DayOfStatus struct {
+	Tasks     []DayOfTaskStatus `json:"tasks"`
+	CheckedIn []int64           `json:"checked_in"`
+}
+
+// DayOfTaskStatus is a task's line in DayOfStatus.
+type DayOfTaskStatus struct {
+	CheckInCount
+	Covered bool `json:"covered"`
+}
+
+// SetTaskCovered sets or clears a task's "covered" mark.
+func SetTaskCovered(db *sql.DB, taskID int64, covered bool) error {
+	_, err := db.Exec("UPDATE tasks SET covered_at = CASE WHEN ? THEN CURRENT_TIMESTAMP END WHERE id=?", covered, taskID)
+	return err
+}
+
+// coveredTasks returns the IDs of eventID's tasks marked as covered.
+func coveredTasks(db *sql.DB, eventID int64) (map[int64]bool, error) {
+	rows, err := db.Query("SELECT id FROM tasks WHERE event_id=? AND covered_at IS NOT NULL AND trash_id IS NULL", eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	covered := map[int64]bool{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		covered[id] = true
+	}
+	return covered, rows.Err()
+}
+
+// GetDayOfTasks returns eventID's tasks with their counts, mark and people.
+func GetDayOfTasks(db *sql.DB, eventID int64) ([]DayOfTask, error) {
+	counts, err := GetCheckInCounts(db, eventID)
+	if err != nil {
+		return nil, err
+	}
+	covered, err := coveredTasks(db, eventID)
+	if err != nil {
+		return nil, err
+	}
+	regs, err := ListAllRegistrations(db, eventID)
+	if err != nil {
+		return nil, err
+	}
+	sortByLastName(regs)
+	people := map[int64][]RegistrationExport{}
+	for _, reg := range regs {
+		people[reg.TaskID] = append(people[reg.TaskID], reg)
+	}
+	tasks := make([]DayOfTask, len(counts))
+	for i, c := range counts {
+		tasks[i] = DayOfTask{CheckInCount: c, Covered: covered[c.TaskID], People: people[c.TaskID]}
+	}
+	return tasks, nil
+}
+
+// GetDayOfStatus returns what the day-of console polls for eventID.
+func GetDayOfStatus(db *sql.DB, eventID int64) (*DayOfStatus, error) {
+	counts, err := GetCheckInCounts(db, eventID)
+	if err != nil {
+		return nil, err
+	}
+	covered, err := coveredTasks(db, eventID)
+	if err != nil {
+		return nil, err
+	}
+	status := &DayOfStatus{Tasks: []DayOfTaskStatus{}, CheckedIn: []int64{}}
+	for _, c := range counts {
+		status.Tasks = append(status.Tasks, DayOfTaskStatus{CheckInCount: c, Covered: covered[c.TaskID]})
+	}
+	rows, err := db.Query(`SELECT r.id FROM registrations r JOIN tasks t ON t.id = r.task_id
+		WHERE t.event_id = ? AND r.checked_in_at IS NOT NULL AND r.trash_id IS NULL AND t.trash_id IS NULL`, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		status.CheckedIn = append(status.CheckedIn, id)
+	}
+	return status, rows.Err()
+}
+
+// telURL is a tel: link calling phone, as typed by the registrant.
+func telURL(phone string) template.URL {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' || r == '+' {
+			return r
+		}
+		return -1
+	}, phone)
+	return template.URL("tel:" + digits)
+}
+
+// dayOfEvent loads the task event a day-of request is about, or redirects
+// to the dashboard and returns nil.
+func (app *App) dayOfEvent(w http.ResponseWriter, r *http.Request) *Event {
+	id, _ := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	event, err := GetEvent(app.DB, id)
+	if err != nil || event.EventType != "tasks" {
+		http.Redirect(w, r, "/admin?lang="+LangFromRequest(r), http.StatusSeeOther)
+		return nil
+	}
+	return event
+}
+
+// dayOfURL is the day-of console of eventID, scrolled to taskID if set.
+func dayOfURL(eventID, taskID int64, lang string) string {
+	u := fmt.Sprintf("/admin/event/today?id=%d&lang=%s", eventID, lang)
+	if taskID != 0 {
+		u += fmt.Sprintf("#task-%d", taskID)
+	}
+	return u
+}
+
+// handleAdminDayOf shows the day-of console.
+func (app *App) handleAdminDayOf(w http.ResponseWriter, r *http.Request) {
+	event := app.dayOfEvent(w, r)
+	if event == nil {
+		return
+	}
+	tasks, err := GetDayOfTasks(app.DB, event.ID)
+	if err != nil {
+		log.Printf("day-of: tasks of event %d: %v", event.ID, err)
+		http.Error(w, T("error_server", LangFromRequest(r)), 500)
+		return
+	}
+	arrived, expected := 0, 0
+	for _, t := range tasks {
+		arrived += t.Arrived
+		expected += t.Expected
+	}
+	pd := app.newPageData(r, map[string]any{"Event": event, "Tasks": tasks, "Arrived": arrived, "Expected": expected})
+	pd.Success, pd.Error = takeFlash(w, r)
+	app.render(w, r, "admin_dayof.html", pd)
+}
+
+// handleAdminDayOfCheckIn checks a registration in (checked_in=1) or takes
+// its arrival back.
+func (app *App) handleAdminDayOfCheckIn(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", 405)
+		return
+	}
+	event := app.dayOfEvent(w, r)
+	if event == nil {
+		return
+	}
+	regID, _ := strconv.ParseInt(r.FormValue("registration_id"), 10, 64)
+	var taskID int64
+	err := app.DB.QueryRow(`SELECT t.id FROM registrations r JOIN tasks t ON t.id = r.task_id
+		WHERE r.id = ? AND t.event_id = ? AND r.trash_id IS NULL AND t.trash_id IS NULL`, regID, event.ID).Scan(&taskID)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	if r.FormValue("checked_in") == "1" {
+		err = MarkCheckedIn(app.DB, regID)
+	} else {
+		err = UndoCheckIn(app.DB, regID)
+	}
+	if err != nil {
+		log.Printf("day-of: check-in of registration %d: %v", regID, err)
+		setFlash(w, "error", T("error_server", LangFromRequest(r)))
+	}
+	http.Redirect(w, r, dayOfURL(event.ID, taskID, LangFromRequest(r)), http.StatusSeeOther)
+}
+
+// handleAdminDayOfCovered sets (covered=1) or clears a task's covered mark.
+func (app *App) handleAdminDayOfCovered(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", 405)
+		return
+	}
+	event := app.dayOfEvent(w, r)
+	if event == nil {
+		return
+	}
+	taskID, _ := strconv.ParseInt(r.FormValue("task_id"), 10, 64)
+	task, err := GetTask(app.DB, taskID)
+	if err != nil || task.EventID != event.ID {
+		http.NotFound(w, r)
+		return
+	}
+	if err := SetTaskCovered(app.DB, task.ID, r.FormValue("covered") == "1"); err != nil {
+		log.Printf("day-of: covered mark of task %d: %v", task.ID, err)
+		setFlash(w, "error", T("error_server", LangFromRequest(r)))
+	}
+	http.Redirect(w, r, dayOfURL(event.ID, task.ID, LangFromRequest(r)), http.StatusSeeOther)
+}
+
+// handleAdminDayOfStatus returns the DayOfStatus the console polls.
+func (app *App) handleAdminDayOfStatus(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	status, err := GetDayOfStatus(app.DB, id)
+	if err != nil {
+		http.Error(w, T("error_server", LangFromRequest(r)), 500)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestDayOf(t *testing.T) {
+	app := testApp(t)
+	mux := newMux(app)
+	admin := adminCookie(app)
+	e := seedEvent(t, app.DB)
+	setup := seedTask(t, app.DB, e.ID, "Montage", nil)
+	bar := seedTask(t, app.DB, e.ID, "Buvette", nil)
+	alice, _ := RegisterForTask(app.DB, setup.ID, "Alice", "Dupont", "alice@test.com", "06 01 02 03 04", "en")
+	RegisterForTask(app.DB, bar.ID, "Bob", "Martin", "bob@test.com", "", "en")
+
+	body := getRequest(mux, fmt.Sprintf("/admin/event/today?id=%d&lang=en", e.ID), admin).Body.String()
+	if !strings.Contains(body, `href="tel:0601020304"`) || !strings.Contains(body, "Bob Martin") {
+		t.Error("console misses people or their call link")
+	}
+
+	status := func() DayOfStatus {
+		var s DayOfStatus
+		w := getRequest(mux, fmt.Sprintf("/admin/event/today/status?id=%d", e.ID), admin)
+		if err := json.NewDecoder(w.Body).Decode(&s); err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+
+	w := postForm(mux, "/admin/event/today/checkin?lang=en", url.Values{"id": {fmt.Sprint(e.ID)}, "registration_id": {fmt.Sprint(alice.ID)}, "checked_in": {"1"}}, admin)
+	if loc := w.Header().Get("Location"); !strings.HasSuffix(loc, fmt.Sprintf("#task-%d", setup.ID)) {
+		t.Errorf("redirect to %q", loc)
+	}
+	postForm(mux, "/admin/event/today/covered?lang=en", url.Values{"id": {fmt.Sprint(e.ID)}, "task_id": {fmt.Sprint(bar.ID)}, "covered": {"1"}}, admin)
+	s := status()
+	if len(s.CheckedIn) != 1 || s.CheckedIn[0] != alice.ID {
+		t.Errorf("checked in = %v", s.CheckedIn)
+	}
+	if len(s.Tasks) != 2 || s.Tasks[0].Arrived != 1 || s.Tasks[0].Covered || !s.Tasks[1].Covered {
+		t.Errorf("tasks = %+v", s.Tasks)
+	}
+
+	// Both toggles go back.
+	postForm(mux, "/admin/event/today/checkin?lang=en", url.Values{"id": {fmt.Sprint(e.ID)}, "registration_id": {fmt.Sprint(alice.ID)}, "checked_in": {"0"}}, admin)
+	postForm(mux, "/admin/event/today/covered?lang=en", url.Values{"id": {fmt.Sprint(e.ID)}, "task_id": {fmt.Sprint(bar.ID)}}, admin)
+	if s := status(); len(s.CheckedIn) != 0 || s.Tasks[1].Covered {
+		t.Errorf("after undo: %+v", s)
+	}
+
+	// A registration of another event is out of reach.
+	other := &Event{TitleFR: "Autre", EventDate: "2026-07-01"}
+	CreateEvent(app.DB, other)
+	if w := postForm(mux, "/admin/event/today/checkin?lang=en", url.Values{"id": {fmt.Sprint(other.ID)}, "registration_id": {fmt.Sprint(alice.ID)}, "checked_in": {"1"}}, admin); w.Code != 404 {
+		t.Errorf("other event's registration: status %d", w.Code)
+	}
+}
@@ -61,3 +61,53 @@ func TestSanitizeEventDescription(t *testing.T) {
 		t.Errorf("style attribute should be stripped: %q", sanitizeEventDescription(styled))
 	}
 }
+
+func TestHTMLToText(t *testing.T) {
+	in := "<p>Rendez-vous à 9h &amp; café<br>offert</p><ul><li>Gants</li><li>Bottes</li></ul><p></p><p></p><p>Merci&nbsp;!</p>"
+	want := "Rendez-vous à 9h & café\noffert\nGants\nBottes\n\nMerci\u00a0!"
+	if got := htmlToText(in); got != want {
+		t.Errorf("htmlToText = %q, want %q", got, want)
+	}
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	cases := []struct {
+		name, in, want string
+	}{
+		{"empty", "  ", ""},
+		{"emphasis and links", "**Gants** et *bottes* : [liste](https://example.com/liste)",
+			`<p><strong>Gants</strong> et <em>bottes</em> : <a href="https://example.com/liste">liste</a></p>`},
+		{"line breaks are kept", "Ligne un\nLigne deux", "<p>Ligne un<br>\nLigne deux</p>"},
+		{"lists", "- un\n- deux", "<ul>\n<li>un</li>\n<li>deux</li>\n</ul>"},
+		{"bare URLs become links", "Voir https://example.com", `<p>Voir <a href="https://example.com">https://example.com</a></p>`},
+		{"raw HTML is dropped", "Hi <script>alert(1)</script> [x](javascript:bad())", "<p>Hi alert(1) x</p>"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := renderMarkdown(c.in); got != c.want {
+				t.Errorf("renderMarkdown(%q) = %q, want %q", c.in, got, c.want)
+			}
+		})
+	}
+
+	// A description sent without HTML, through the API, is Markdown.
+	if got := sanitizeEventDescription("Venez **nombreux**"); got != "<p>Venez <strong>nombreux</strong></p>" {
+		t.Errorf("plain description = %q", got)
+	}
+}
+
+func TestTaskDescriptionMarkdown(t *testing.T) {
+	app := testApp(t)
+	e := seedEvent(t, app.DB)
+	task := seedTask(t, app.DB, e.ID, "Vaisselle", nil)
+	task.DescriptionFR = "Apportez :\n\n- un **torchon**\n- <b>du savon</b>"
+	UpdateTask(app.DB, task)
+
+	body := getRequest(newMux(app), "/e/"+e.Slug+"?lang=fr").Body.String()
+	if !strings.Contains(body, "<li>un <strong>torchon</strong></li>") {
+		t.Error("task description not rendered as Markdown")
+	}
+	if strings.Contains(body, "<b>du savon</b>") {
+		t.Error("raw HTML in a task description reached the page")
+	}
+}
@@ -25,10 +25,11 @@ li { margin: 0.75rem 0; display: flex; justify-content: space-between; align-ite
 .links a:hover { background: #fff; border-color: #0366d6; }
 </style></head><body>
 <h1>Email previews</h1>
-<p>Each link renders the exact HTML the app would email to participants, using real data from the latest Secret Santa event. Hit Cmd-R after a template change to re-render.</p>
+<p>Each link renders the exact HTML the app would email to participants, using real data from the latest matching event. Hit Cmd-R after a template change to re-render.</p>
 <ul>
   <li><strong>Lien magique / Invitation</strong><span class="links"><a href="/dev/emails/santa-link?lang=fr">FR</a><a href="/dev/emails/santa-link?lang=en">EN</a></span></li>
   <li><strong>Tirage / Reveal</strong><span class="links"><a href="/dev/emails/santa-reveal?lang=fr">FR</a><a href="/dev/emails/santa-reveal?lang=en">EN</a></span></li>
+  <li><strong>Confirmation d'inscription / Signup confirmation</strong><span class="links"><a href="/dev/emails/signup-confirmation?lang=fr">FR</a><a href="/dev/emails/signup-confirmation?lang=en">EN</a></span></li>
 </ul>
 </body></html>`)
 }
@@ -42,8 +43,8 @@ func (app *App) handleDevEmailSantaLink(w http.ResponseWriter, r *http.Request)
 		http.Error(w, "preview unavailable: "+err.Error(), http.StatusNotFound)
 		return
 	}
-	editURL := fmt.Sprintf("%s/santa/edit?token=%s&lang=%s", baseURLFor(r), p.Token, lang)
-	_, html := renderSantaLinkEmail(lang, *p, *event, editURL)
+	editURL := fmt.Sprintf("%s/santa/edit?token=%s&lang=%s", app.eventBaseURL(r, event), p.Token, lang)
+	_, html := renderSantaLinkEmail(lang, *p, *event, editURL, app.unsubscribeURL(app.eventBaseURL(r, event), p.Email, lang))
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	fmt.Fprint(w, html)
 }
@@ -58,7 +59,41 @@ func (app *App) handleDevEmailSantaReveal(w http.ResponseWriter, r *http.Request
 		http.Error(w, "preview unavailable: "+err.Error(), http.StatusNotFound)
 		return
 	}
-	_, html := renderSantaRevealEmail(lang, *giver, *receiver, *event, baseURLFor(r))
+	_, html := renderSantaRevealEmail(lang, *giver, *receiver, *event, app.eventBaseURL(r, event))
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprint(w, html)
+}
+
+// handleDevEmailSignupConfirmation renders the signup confirmation email for
+// the most recent task registration in the database.
+func (app *App) handleDevEmailSignupConfirmation(w http.ResponseWriter, r *http.Request) {
+	lang := LangFromRequest(r)
+	var token string
+	if err := app.DB.QueryRow("SELECT token FROM registrations ORDER BY id DESC LIMIT 1").Scan(&token); err != nil {
+		http.Error(w, "preview unavailable: no task registration yet", http.StatusNotFound)
+		return
+	}
+	reg, err := GetRegistrationByToken(app.DB, token)
+	if err != nil {
+		http.Error(w, "preview unavailable: "+err.Error(), http.StatusNotFound)
+		return
+	}
+	task, err := GetTask(app.DB, reg.TaskID)
+	if err != nil {
+		http.Error(w, "preview unavailable: "+err.Error(), http.StatusNotFound)
+		return
+	}
+	event, err := GetEvent(app.DB, task.EventID)
+	if err != nil {
+		http.Error(w, "preview unavailable: "+err.Error(), http.StatusNotFound)
+		return
+	}
+	baseURL := app.eventBaseURL(r, event)
+	var qrURL string
+	if event.CheckIn {
+		qrURL = app.qrCodeURL(baseURL, *reg, *event)
+	}
+	_, html := renderSignupConfirmationEmail(lang, *reg, *task, *event, baseURL, app.cancelURL(baseURL, *reg, *event, lang), qrURL, app.unsubscribeURL(baseURL, reg.Email, lang))
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	fmt.Fprint(w, html)
 }
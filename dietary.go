@@ -0,0 +1,131 @@
+package main
+
+// Dietary needs. An event serving food can ask each registrant for their
+// diet (vegetarian, vegan, gluten-free) and allergies; the kitchen team gets
+// the totals and the list of allergies on /admin/event/dietary.
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// dietOptions are the diets a registrant can tick, in display order.
+var dietOptions = []string{"vegetarian", "vegan", "gluten_free"}
+
+// maxAllergiesLen bounds the allergies a registrant can type, in characters.
+const maxAllergiesLen = 300
+
+// parseDiet keeps the known diets among values, in dietOptions order, as
+// stored in registrations.diet.
+func parseDiet(values []string) string {
+	var diets []string
+	for _, d := range dietOptions {
+		if slices.Contains(values, d) {
+			diets = append(diets, d)
+		}
+	}
+	return strings.Join(diets, ",")
+}
+
+// dietLabels spells out a stored diet in lang.
+func dietLabels(diet, lang string) string {
+	if diet == "" {
+		return ""
+	}
+	var labels []string
+	for _, d := range strings.Split(diet, ",") {
+		labels = append(labels, T("diet_"+d, lang))
+	}
+	return strings.Join(labels, ", ")
+}
+
+// SetRegistrationDiet stores the dietary needs given with a registration.
+func SetRegistrationDiet(db *sql.DB, regID int64, diet, allergies string) error {
+	_, err := db.Exec("UPDATE registrations SET diet=?, allergies=? WHERE id=?", diet, allergies, regID)
+	return err
+}
+
+// DietCount is how many registrants follow a diet.
+type DietCount struct {
+	Diet  string
+	Count int
+}
+
+// AllergyNote is a registrant's allergies, for the kitchen.
+type AllergyNote struct {
+	FirstName   string
+	LastName    string
+	TaskTitleFR string
+	TaskTitleEN string
+	Allergies   string
+}
+
+// DietarySummary totals an event's dietary needs.
+type DietarySummary struct {
+	Registrants int // registrations, guests not included
+	Guests      int // people whose needs nobody told
+	Diets       []DietCount
+	Allergies   []AllergyNote
+}
+
+// GetDietarySummary totals the dietary needs of the event's live
+// registrations: one count per diet, in dietOptions order, and every
+// allergy by registrant name.
+func GetDietarySummary(db *sql.DB, eventID int64) (*DietarySummary, error) {
+	rows, err := db.Query(`SELECT r.first_name, r.last_name, t.title_fr, t.title_en, r.guests, r.diet, r.allergies
+		FROM registrations r JOIN tasks t ON t.id = r.task_id
+		WHERE t.event_id = ? AND r.trash_id IS NULL AND t.trash_id IS NULL
+		ORDER BY r.last_name, r.first_name`, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	s := &DietarySummary{}
+	counts := map[string]int{}
+	for rows.Next() {
+		var a AllergyNote
+		var guests int
+		var diet string
+		if err := rows.Scan(&a.FirstName, &a.LastName, &a.TaskTitleFR, &a.TaskTitleEN, &guests, &diet, &a.Allergies); err != nil {
+			return nil, err
+		}
+		s.Registrants++
+		s.Guests += guests
+		if diet != "" {
+			for _, d := range strings.Split(diet, ",") {
+				counts[d]++
+			}
+		}
+		if a.Allergies != "" {
+			s.Allergies = append(s.Allergies, a)
+		}
+	}
+	for _, d := range dietOptions {
+		s.Diets = append(s.Diets, DietCount{Diet: d, Count: counts[d]})
+	}
+	return s, rows.Err()
+}
+
+func (app *App) handleAdminDietary(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	event, err := GetEvent(app.DB, id)
+	if err != nil {
+		http.Redirect(w, r, "/admin", http.StatusSeeOther)
+		return
+	}
+	if !event.CollectDiet {
+		http.Redirect(w, r, fmt.Sprintf("/admin/event/registrations?id=%d&lang=%s", id, LangFromRequest(r)), http.StatusSeeOther)
+		return
+	}
+	summary, err := GetDietarySummary(app.DB, event.ID)
+	if err != nil {
+		http.Error(w, T("error_server", LangFromRequest(r)), 500)
+		return
+	}
+	pd := app.newPageData(r, map[string]any{"Event": event, "Summary": summary})
+	app.render(w, r, "admin_dietary.html", pd)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestDietaryNeeds(t *testing.T) {
+	app := testApp(t)
+	mux := newMux(app)
+	admin := adminCookie(app)
+	e := seedEvent(t, app.DB)
+	tk := seedTask(t, app.DB, e.ID, "Cuisine", nil)
+	tk.MaxGuests = 2
+	UpdateTask(app.DB, tk)
+
+	signup := func(email string, form url.Values) {
+		t.Helper()
+		form.Set("task_id", fmt.Sprint(tk.ID))
+		form.Set("first_name", "Alice")
+		form.Set("last_name", strings.Split(email, "@")[0])
+		form.Set("email", email)
+		form.Set("phone", "0601")
+		postForm(mux, "/signup?lang=en", form)
+	}
+	// Until the event asks, the form has no diet and ignores one.
+	if strings.Contains(getRequest(mux, "/e/"+e.Slug).Body.String(), `name="diet"`) {
+		t.Error("diet asked while the event doesn't collect it")
+	}
+	signup("ann@test.com", url.Values{"diet": {"vegan"}})
+
+	e.CollectDiet = true
+	UpdateEvent(app.DB, e)
+	if !strings.Contains(getRequest(mux, "/e/"+e.Slug).Body.String(), `name="allergies"`) {
+		t.Error("form lacks the diet fields")
+	}
+	signup("bea@test.com", url.Values{"diet": {"gluten_free", "vegetarian", "keto"}, "allergies": {" Peanuts "}})
+	signup("cal@test.com", url.Values{"diet": {"vegetarian"}, "guests_" + fmt.Sprint(tk.ID): {"2"}})
+	signup("dan@test.com", url.Values{"allergies": {strings.Repeat("x", maxAllergiesLen+1)}})
+
+	regs, _ := ListAllRegistrations(app.DB, e.ID)
+	if len(regs) != 3 {
+		t.Fatalf("%d registrations, want 3", len(regs))
+	}
+	s, err := GetDietarySummary(app.DB, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []DietCount{{"vegetarian", 2}, {"vegan", 0}, {"gluten_free", 1}}
+	if s.Registrants != 3 || s.Guests != 2 || fmt.Sprint(s.Diets) != fmt.Sprint(want) {
+		t.Errorf("summary = %+v", s)
+	}
+	if len(s.Allergies) != 1 || s.Allergies[0].Allergies != "Peanuts" {
+		t.Errorf("allergies = %+v", s.Allergies)
+	}
+
+	page := getRequest(mux, fmt.Sprintf("/admin/event/dietary?id=%d&lang=en", e.ID), admin).Body.String()
+	for _, s := range []string{"Gluten-free", "Peanuts", "bea"} {
+		if !strings.Contains(page, s) {
+			t.Errorf("kitchen summary lacks %q", s)
+		}
+	}
+	csv := getRequest(mux, fmt.Sprintf("/admin/export?event_id=%d", e.ID), admin).Body.String()
+	if !strings.Contains(csv, "Régime,Allergies") || !strings.Contains(csv, `"Végétarien, Sans gluten",Peanuts`) {
+		t.Errorf("CSV lacks the diets:\n%s", csv)
+	}
+
+	AnonymizeEvent(app.DB, e.ID)
+	if s, _ := GetDietarySummary(app.DB, e.ID); len(s.Allergies) != 0 || s.Diets[0].Count != 0 {
+		t.Errorf("diets left after anonymization: %+v", s)
+	}
+}
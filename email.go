@@ -3,6 +3,8 @@ package main
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"database/sql"
 	_ "embed"
 	"encoding/base64"
 	"fmt"
@@ -12,7 +14,9 @@ import (
 	"mime"
 	"mime/multipart"
 	"mime/quotedprintable"
+	"net"
 	"net/mail"
+	"net/smtp"
 	"net/textproto"
 	"net/url"
 	"strings"
@@ -25,10 +29,11 @@ import (
 )
 
 // EmailSender delivers a single transactional HTML email and returns the
-// provider's message ID (used to correlate later delivery events). Optional
-// attachments are delivered alongside the HTML body.
+// provider's message ID (used to correlate later delivery events). replyTo,
+// when non-empty, becomes the Reply-To header. Optional attachments are
+// delivered alongside the HTML body.
 type EmailSender interface {
-	Send(ctx context.Context, to, subject, htmlBody string, attachments ...emailAttachment) (messageID string, err error)
+	Send(ctx context.Context, to, replyTo, subject, htmlBody string, attachments ...emailAttachment) (messageID string, err error)
 }
 
 // emailAttachment is a file delivered alongside the HTML body. SES carries
@@ -58,20 +63,20 @@ func invitationAttachments() []emailAttachment {
 	}}
 }
 
-// LogSender writes emails to the log instead of sending them. Used when SES is
-// not configured (development, manual testing).
+// LogSender writes emails to the log instead of sending them. Used when
+// neither SMTP nor SES is configured (development, manual testing).
 type LogSender struct{}
 
-func (LogSender) Send(ctx context.Context, to, subject, htmlBody string, attachments ...emailAttachment) (string, error) {
+func (LogSender) Send(ctx context.Context, to, replyTo, subject, htmlBody string, attachments ...emailAttachment) (string, error) {
 	if len(attachments) > 0 {
 		names := make([]string, len(attachments))
 		for i, a := range attachments {
 			names[i] = a.Filename
 		}
-		log.Printf("[email] to=%s subject=%q attachments=%v\n%s", to, subject, names, htmlBody)
+		log.Printf("[email] to=%s reply_to=%s subject=%q attachments=%v\n%s", to, replyTo, subject, names, htmlBody)
 		return "", nil
 	}
-	log.Printf("[email] to=%s subject=%q\n%s", to, subject, htmlBody)
+	log.Printf("[email] to=%s reply_to=%s subject=%q\n%s", to, replyTo, subject, htmlBody)
 	return "", nil
 }
 
@@ -80,6 +85,16 @@ func (LogSender) Send(ctx context.Context, to, subject, htmlBody string, attachm
 // URL the email client can fetch directly.
 type emailCommon struct {
 	Lang, Title, LogoURL string
+	// Signed preferences link shown in the footer; empty hides it.
+	UnsubscribeURL, UnsubscribeText string
+}
+
+// withUnsubscribe sets the footer preferences link, if any.
+func (c emailCommon) withUnsubscribe(url string) emailCommon {
+	if url != "" {
+		c.UnsubscribeURL, c.UnsubscribeText = url, T("unsubscribe_link", c.Lang)
+	}
+	return c
 }
 
 type santaLinkEmailData struct {
@@ -91,11 +106,44 @@ type santaLinkEmailData struct {
 	Disclaimer                                string
 }
 
+type signupConfirmationEmailData struct {
+	emailCommon
+	Greeting, Intro                    string
+	TaskLabel, TaskTitle, TaskTimes    string
+	WhenLabel, When                    string
+	EventDescription                   template.HTML
+	AfterSignup                        []AfterSignupBlock
+	CancelIntro, CancelText, CancelURL string
+	QRCodeURL, QRCodeIntro, QRCodeAlt  string
+	EventURL, EventLinkText            string
+}
+
+// emailRow is one "label: value" line of a details table.
+type emailRow struct {
+	Label, Value string
+}
+
+type adminNotificationEmailData struct {
+	emailCommon
+	Intro             string
+	Rows              []emailRow
+	LinkURL, LinkText string
+}
+
+// messageEmailData is a message written by an organizer, one paragraph per
+// blank-line-separated block.
+type messageEmailData struct {
+	emailCommon
+	Paragraphs        []string
+	LinkURL, LinkText string
+}
+
 type santaRevealEmailData struct {
 	emailCommon
 	Greeting, Intro, ReceiverName, WishesIntro string
 	WishBuyLabel, WishMakeLabel, WishFreeLabel string
 	WishBuy, WishMake, WishFree                string
+	EventURL, EventLinkText                    string
 }
 
 // logoURLFromBase returns the absolute URL of the email logo (the red
@@ -148,7 +196,7 @@ func emailOverrideOrDefault(frOverride, enOverride, lang, fallbackKey string) st
 // Every customizable string (hook, how-it-works title and 3 steps, button,
 // disclaimer) honours the per-event override first, falls back to the
 // matching i18n key when the override is empty.
-func renderSantaLinkEmail(lang string, p SantaParticipant, event Event, editURL string) (subject, html string) {
+func renderSantaLinkEmail(lang string, p SantaParticipant, event Event, editURL, unsubscribeURL string) (subject, html string) {
 	eventTitle := Localized(event.TitleFR, event.TitleEN, lang)
 	// Description is admin-authored HTML, sanitized at save time — render as-is.
 	desc := template.HTML(Localized(event.DescriptionFR, event.DescriptionEN, lang))
@@ -157,7 +205,7 @@ func renderSantaLinkEmail(lang string, p SantaParticipant, event Event, editURL
 			Lang:    lang,
 			Title:   eventTitle,
 			LogoURL: logoURLFromBase(baseFromURL(editURL)),
-		},
+		}.withUnsubscribe(unsubscribeURL),
 		Greeting:         fmt.Sprintf(T("santa_email_greeting", lang), p.FirstName),
 		Hook:             emailOverrideOrDefault(event.EmailHookFR, event.EmailHookEN, lang, "santa_email_link_hook"),
 		HowItWorksTitle:  emailOverrideOrDefault(event.EmailHowTitleFR, event.EmailHowTitleEN, lang, "santa_email_how_title"),
@@ -191,10 +239,204 @@ func renderSantaRevealEmail(lang string, giver, receiver SantaParticipant, event
 		WishBuy:       receiver.WishBuy,
 		WishMake:      receiver.WishMake,
 		WishFree:      receiver.WishFree,
+		EventURL:      baseURL + "/e/" + event.Slug + "?lang=" + lang,
+		EventLinkText: T("santa_email_reveal_event", lang),
 	}
 	return T("santa_email_reveal_subject", lang) + " " + eventTitle, renderEmailTemplate("email_santa_reveal.html", data)
 }
 
+// renderSignupConfirmationEmail builds the email sent after a task signup.
+// It carries the cancel link so the registrant can still reach it from
+// another device than the one that signed up.
+func renderSignupConfirmationEmail(lang string, reg Registration, task Task, event Event, baseURL, cancelURL, qrURL, unsubscribeURL string) (subject, html string) {
+	eventTitle := Localized(event.TitleFR, event.TitleEN, lang)
+	when := formatEventDate(event.EventDate, lang)
+	if event.EventTime != "" {
+		when += ", " + formatEventTimeZoned(event, lang)
+	}
+	data := signupConfirmationEmailData{
+		emailCommon: emailCommon{
+			Lang:    lang,
+			Title:   eventTitle,
+			LogoURL: logoURLFromBase(baseURL),
+		}.withUnsubscribe(unsubscribeURL),
+		Greeting:         fmt.Sprintf(T("santa_email_greeting", lang), reg.FirstName),
+		Intro:            T("signup_email_intro", lang),
+		TaskLabel:        emailLabel(T("confirmation_task", lang), lang),
+		TaskTitle:        Localized(task.TitleFR, task.TitleEN, lang),
+		TaskTimes:        formatTaskTimes(task, lang),
+		WhenLabel:        emailLabel(T("signup_email_when", lang), lang),
+		When:             when,
+		EventDescription: template.HTML(Localized(event.DescriptionFR, event.DescriptionEN, lang)),
+		AfterSignup:      event.AfterSignup(lang),
+		CancelIntro:      T("signup_email_cancel_intro", lang),
+		CancelText:       T("signup_email_cancel_button", lang),
+		CancelURL:        cancelURL,
+		QRCodeURL:        qrURL,
+		QRCodeIntro:      T("checkin_qr_hint", lang),
+		QRCodeAlt:        T("checkin_qr_alt", lang),
+		EventURL:         fmt.Sprintf("%s/e/%s?lang=%s", baseURL, event.Slug, lang),
+		EventLinkText:    T("santa_email_reveal_event", lang),
+	}
+	return T("signup_email_subject", lang) + " " + eventTitle, renderEmailTemplate("email_signup_confirmation.html", data)
+}
+
+// renderRegistrationNotificationEmail builds the admin notice for a new task
+// signup, with the task's fill level after it.
+func renderRegistrationNotificationEmail(lang string, reg Registration, task Task, taken int, event Event, baseURL string) (subject, html string) {
+	eventTitle := Localized(event.TitleFR, event.TitleEN, lang)
+	name := reg.FirstName + " " + reg.LastName
+	fill := fmt.Sprint(taken)
+	if task.MaxSlots.Valid {
+		fill = fmt.Sprintf("%d / %d", taken, task.MaxSlots.Int64)
+	}
+	rows := []emailRow{
+		{emailLabel(T("confirmation_task", lang), lang), Localized(task.TitleFR, task.TitleEN, lang)},
+		{emailLabel(T("notify_email_fill", lang), lang), fill},
+		{emailLabel(T("registration_email", lang), lang), reg.Email},
+		{emailLabel(T("registration_phone", lang), lang), reg.Phone},
+		{emailLabel(T("registration_lang", lang), lang), reg.Lang},
+	}
+	if reg.Comment != "" {
+		rows = append(rows, emailRow{emailLabel(T("registration_comment", lang), lang), reg.Comment})
+	}
+	if reg.Diet != "" {
+		rows = append(rows, emailRow{emailLabel(T("diet_label", lang), lang), dietLabels(reg.Diet, lang)})
+	}
+	if reg.Allergies != "" {
+		rows = append(rows, emailRow{emailLabel(T("diet_allergies", lang), lang), reg.Allergies})
+	}
+	data := adminNotificationEmailData{
+		emailCommon: emailCommon{Lang: lang, Title: eventTitle, LogoURL: logoURLFromBase(baseURL)},
+		Intro:       fmt.Sprintf(T("notify_email_registration_intro", lang), name),
+		Rows:        rows,
+		LinkURL:     fmt.Sprintf("%s/admin/event/registrations?id=%d&lang=%s", baseURL, event.ID, lang),
+		LinkText:    T("notify_email_link", lang),
+	}
+	return fmt.Sprintf("[%s] %s — %s", eventTitle, name, Localized(task.TitleFR, task.TitleEN, lang)), renderEmailTemplate("email_admin_notification.html", data)
+}
+
+// renderAttendanceNotificationEmail builds the admin notice for an RSVP,
+// with the running yes/total count and headcount after it.
+func renderAttendanceNotificationEmail(lang string, att Attendance, yes, total int, heads Headcount, event Event, baseURL string) (subject, html string) {
+	eventTitle := Localized(event.TitleFR, event.TitleEN, lang)
+	name := att.FirstName + " " + att.LastName
+	answer := T("attendance_no", lang)
+	if att.Attending {
+		answer = T("attendance_yes", lang)
+	}
+	rows := []emailRow{
+		{emailLabel(T("attendance_attending", lang), lang), answer},
+		{emailLabel(T("notify_email_attendance_count", lang), lang), fmt.Sprintf("%d / %d", yes, total)},
+		{emailLabel(T("notify_email_attendance_people", lang), lang), fmt.Sprintf(T("attendance_headcount", lang), heads.Total(), heads.Adults, heads.Children)},
+		{emailLabel(T("registration_email", lang), lang), att.Email},
+	}
+	if att.People() > 1 {
+		rows = append(rows, emailRow{emailLabel(T("attendance_people", lang), lang), fmt.Sprint(att.People())})
+	}
+	if att.Phone != "" {
+		rows = append(rows, emailRow{emailLabel(T("registration_phone", lang), lang), att.Phone})
+	}
+	if att.Message != "" {
+		rows = append(rows, emailRow{emailLabel(T("attendance_message", lang), lang), att.Message})
+	}
+	data := adminNotificationEmailData{
+		emailCommon: emailCommon{Lang: lang, Title: eventTitle, LogoURL: logoURLFromBase(baseURL)},
+		Intro:       fmt.Sprintf(T("notify_email_attendance_intro", lang), name),
+		Rows:        rows,
+		LinkURL:     fmt.Sprintf("%s/admin/event/attendances?id=%d&lang=%s", baseURL, event.ID, lang),
+		LinkText:    T("notify_email_link", lang),
+	}
+	return fmt.Sprintf("[%s] %s — %s", eventTitle, name, answer), renderEmailTemplate("email_admin_notification.html", data)
+}
+
+// renderSMTPTestEmail builds the "send test email" message of the settings
+// page. It reuses the admin notification layout, listing the relay in use.
+func renderSMTPTestEmail(lang string, cfg SMTPSettings, baseURL string) (subject, htmlBody string) {
+	relay := T("settings_smtp_env_short", lang)
+	if cfg.Configured() {
+		relay = net.JoinHostPort(cfg.Host, cfg.Port)
+	}
+	data := adminNotificationEmailData{
+		emailCommon: emailCommon{Lang: lang, Title: T("settings_test_subject", lang), LogoURL: logoURLFromBase(baseURL)},
+		Intro:       T("settings_test_intro", lang),
+		Rows:        []emailRow{{emailLabel(T("settings_smtp_host", lang), lang), relay}},
+		LinkURL:     fmt.Sprintf("%s/admin/settings?lang=%s", baseURL, lang),
+		LinkText:    T("settings_title", lang),
+	}
+	return T("settings_test_subject", lang), renderEmailTemplate("email_admin_notification.html", data)
+}
+
+// renderLoginLinkEmail builds the one-time admin login email.
+func renderLoginLinkEmail(lang, link, baseURL string) (subject, htmlBody string) {
+	data := adminNotificationEmailData{
+		emailCommon: emailCommon{Lang: lang, Title: T("login_link_subject", lang), LogoURL: logoURLFromBase(baseURL)},
+		Intro:       fmt.Sprintf(T("login_link_email_intro", lang), int(loginLinkTTL.Minutes())),
+		LinkURL:     link,
+		LinkText:    T("login_link_email_button", lang),
+	}
+	return T("login_link_subject", lang), renderEmailTemplate("email_admin_notification.html", data)
+}
+
+// renderRegistrantLinkEmail builds the email with the link to someone's
+// registrations.
+func renderRegistrantLinkEmail(lang, link, baseURL string) (subject, htmlBody string) {
+	data := adminNotificationEmailData{
+		emailCommon: emailCommon{Lang: lang, Title: T("my_link_subject", lang), LogoURL: logoURLFromBase(baseURL)},
+		Intro:       fmt.Sprintf(T("my_link_email_intro", lang), int(registrantLinkTTL.Hours())),
+		LinkURL:     link,
+		LinkText:    T("my_link_email_button", lang),
+	}
+	return T("my_link_subject", lang), renderEmailTemplate("email_admin_notification.html", data)
+}
+
+// renderAssignmentUnplacedEmail builds the email telling someone that none
+// of the tasks they ranked had room when assignments were made.
+func renderAssignmentUnplacedEmail(lang string, event Event, baseURL string) (subject, htmlBody string) {
+	title := Localized(event.TitleFR, event.TitleEN, lang)
+	subject = fmt.Sprintf(T("assign_unplaced_subject", lang), title)
+	data := adminNotificationEmailData{
+		emailCommon: emailCommon{Lang: lang, Title: subject, LogoURL: logoURLFromBase(baseURL)},
+		Intro:       fmt.Sprintf(T("assign_unplaced_intro", lang), title),
+		LinkURL:     baseURL + "/e/" + event.Slug + "?lang=" + lang,
+		LinkText:    T("assign_unplaced_button", lang),
+	}
+	return subject, renderEmailTemplate("email_admin_notification.html", data)
+}
+
+// renderOpeningNoticeEmail builds the email telling someone who asked for
+// it that registration to event is open, linking to pageURL.
+func renderOpeningNoticeEmail(lang string, event Event, pageURL, baseURL, unsubscribeURL string) (subject, htmlBody string) {
+	title := Localized(event.TitleFR, event.TitleEN, lang)
+	subject = fmt.Sprintf(T("opening_notice_subject", lang), title)
+	data := adminNotificationEmailData{
+		emailCommon: emailCommon{Lang: lang, Title: subject, LogoURL: logoURLFromBase(baseURL)}.withUnsubscribe(unsubscribeURL),
+		Intro:       fmt.Sprintf(T("opening_notice_intro", lang), title),
+		LinkURL:     pageURL,
+		LinkText:    T("opening_notice_button", lang),
+	}
+	return subject, renderEmailTemplate("email_admin_notification.html", data)
+}
+
+// renderOrganizerMessageEmail renders message, sent by the organizers of
+// event to some of its registrants, with a link to the event page and, being
+// bulk mail, the preferences link in the footer.
+func renderOrganizerMessageEmail(lang string, event Event, subject, message, baseURL, unsubscribeURL string) string {
+	var paragraphs []string
+	for _, p := range strings.Split(strings.ReplaceAll(message, "\r\n", "\n"), "\n\n") {
+		if p = strings.TrimSpace(p); p != "" {
+			paragraphs = append(paragraphs, p)
+		}
+	}
+	data := messageEmailData{
+		emailCommon: emailCommon{Lang: lang, Title: subject, LogoURL: logoURLFromBase(baseURL)}.withUnsubscribe(unsubscribeURL),
+		Paragraphs:  paragraphs,
+		LinkURL:     baseURL + "/e/" + event.Slug + "?lang=" + lang,
+		LinkText:    Localized(event.TitleFR, event.TitleEN, lang),
+	}
+	return renderEmailTemplate("email_message.html", data)
+}
+
 // renderEmailTemplate executes an embedded email template wrapped in the
 // shared email_layout (logo + title bar + body shell). The template name must
 // equal the file's base name as registered by ParseFS. Returns "" on failure
@@ -240,14 +482,14 @@ func formatFrom(addr, name string) string {
 	return (&mail.Address{Name: name, Address: addr}).String()
 }
 
-func (s *SESSender) Send(ctx context.Context, to, subject, htmlBody string, attachments ...emailAttachment) (string, error) {
+func (s *SESSender) Send(ctx context.Context, to, replyTo, subject, htmlBody string, attachments ...emailAttachment) (string, error) {
 	in := &sesv2.SendEmailInput{
 		FromEmailAddress: aws.String(s.from),
 		Destination:      &sesv2types.Destination{ToAddresses: []string{to}},
 	}
 	if len(attachments) > 0 {
 		// Simple content can't carry attachments — assemble a raw MIME message.
-		raw, err := buildRawMIME(s.from, to, subject, htmlBody, attachments)
+		raw, err := buildRawMIME(s.from, to, replyTo, subject, htmlBody, attachments)
 		if err != nil {
 			return "", fmt.Errorf("build raw mime: %w", err)
 		}
@@ -262,6 +504,9 @@ func (s *SESSender) Send(ctx context.Context, to, subject, htmlBody string, atta
 			},
 		}
 	}
+	if replyTo != "" {
+		in.ReplyToAddresses = []string{replyTo}
+	}
 	if s.configSet != "" {
 		in.ConfigurationSetName = aws.String(s.configSet)
 	}
@@ -275,21 +520,117 @@ func (s *SESSender) Send(ctx context.Context, to, subject, htmlBody string, atta
 	return *out.MessageId, nil
 }
 
+// SMTPSender sends email through any SMTP relay. Port 465 uses implicit TLS;
+// other ports upgrade with STARTTLS whenever the server offers it.
+type SMTPSender struct {
+	host, port string
+	auth       smtp.Auth // nil when the relay needs no login
+	from       string    // RFC 5322 From; may carry a display name
+	envelope   string    // bare address for MAIL FROM
+}
+
+func NewSMTPSender(host, port, username, password, from, fromName string) *SMTPSender {
+	s := &SMTPSender{host: host, port: port, from: formatFrom(from, fromName), envelope: from}
+	if username != "" {
+		s.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return s
+}
+
+func (s *SMTPSender) Send(ctx context.Context, to, replyTo, subject, htmlBody string, attachments ...emailAttachment) (string, error) {
+	body, err := buildRawMIME(s.from, to, replyTo, subject, htmlBody, attachments)
+	if err != nil {
+		return "", fmt.Errorf("build mime: %w", err)
+	}
+	// buildRawMIME leaves Date and Message-ID to the provider, as SES adds
+	// them; plain SMTP relays may not, so set them here.
+	messageID := fmt.Sprintf("<%s@%s>", GenerateToken(), s.host)
+	msg := append([]byte("Date: "+time.Now().Format(time.RFC1123Z)+"\r\nMessage-ID: "+messageID+"\r\n"), body...)
+
+	addr := net.JoinHostPort(s.host, s.port)
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+	var conn net.Conn
+	if s.port == "465" {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: s.host}}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return "", fmt.Errorf("smtp dial: %w", err)
+	}
+	c, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		conn.Close()
+		return "", fmt.Errorf("smtp hello: %w", err)
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok && s.port != "465" {
+		if err := c.StartTLS(&tls.Config{ServerName: s.host}); err != nil {
+			return "", fmt.Errorf("smtp starttls: %w", err)
+		}
+	}
+	if s.auth != nil {
+		if err := c.Auth(s.auth); err != nil {
+			return "", fmt.Errorf("smtp auth: %w", err)
+		}
+	}
+	if err := c.Mail(s.envelope); err != nil {
+		return "", fmt.Errorf("smtp mail from: %w", err)
+	}
+	if err := c.Rcpt(to); err != nil {
+		return "", fmt.Errorf("smtp rcpt to: %w", err)
+	}
+	w, err := c.Data()
+	if err != nil {
+		return "", fmt.Errorf("smtp data: %w", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return "", fmt.Errorf("smtp write: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return "", fmt.Errorf("smtp send: %w", err)
+	}
+	return messageID, c.Quit()
+}
+
+// SettingsSender sends through the SMTP relay saved on the admin settings
+// page when there is one, and through Fallback (the environment-configured
+// sender) otherwise. Settings are read on every send, so a change applies
+// without a restart.
+type SettingsSender struct {
+	DB       *sql.DB
+	Fallback EmailSender
+}
+
+func (s *SettingsSender) Send(ctx context.Context, to, replyTo, subject, htmlBody string, attachments ...emailAttachment) (string, error) {
+	cfg, err := GetSMTPSettings(s.DB)
+	if err != nil {
+		return "", fmt.Errorf("load smtp settings: %w", err)
+	}
+	if !cfg.Configured() {
+		return s.Fallback.Send(ctx, to, replyTo, subject, htmlBody, attachments...)
+	}
+	return NewSMTPSender(cfg.Host, cfg.Port, cfg.Username, cfg.Password, cfg.From, cfg.FromName).
+		Send(ctx, to, replyTo, subject, htmlBody, attachments...)
+}
+
 // buildRawMIME assembles a multipart/mixed RFC 5322 message — the HTML body
 // plus each attachment — for SES's Raw content type. The Subject is RFC
 // 2047-encoded so non-ASCII (French) survives; the HTML body is quoted-printable
 // and attachments are base64 with the line wrapping RFC 2045 requires.
-func buildRawMIME(from, to, subject, htmlBody string, attachments []emailAttachment) ([]byte, error) {
+func buildRawMIME(from, to, replyTo, subject, htmlBody string, attachments []emailAttachment) ([]byte, error) {
 	var buf bytes.Buffer
 	w := multipart.NewWriter(&buf)
 
-	headers := []string{
-		"From: " + from,
-		"To: " + to,
-		"Subject: " + mime.QEncoding.Encode("utf-8", subject),
-		"MIME-Version: 1.0",
-		"Content-Type: multipart/mixed; boundary=" + w.Boundary(),
+	headers := []string{"From: " + from, "To: " + to}
+	if replyTo != "" {
+		headers = append(headers, "Reply-To: "+replyTo)
 	}
+	headers = append(headers,
+		"Subject: "+mime.QEncoding.Encode("utf-8", subject),
+		"MIME-Version: 1.0",
+		"Content-Type: multipart/mixed; boundary="+w.Boundary(),
+	)
 	buf.WriteString(strings.Join(headers, "\r\n") + "\r\n\r\n")
 
 	htmlHeader := textproto.MIMEHeader{}
@@ -397,7 +738,7 @@ func (app *App) sendRevealEmails(eventID int64, baseURL string) {
 			log.Printf("sendRevealEmails: empty rendered email body for participant %d, skipping", p.ID)
 			continue
 		}
-		messageID, err := app.sendWithRetry(p.Email, subject, htmlBody)
+		messageID, err := app.sendWithRetry(p.Email, event.OrganizerEmail, subject, htmlBody)
 		if err != nil {
 			log.Printf("sendRevealEmails: send to %s failed: %v", p.Email, err)
 			continue
@@ -458,17 +799,21 @@ func (app *App) sendInviteEmails(eventID int64, baseURL string) {
 		if invited[p.ID] {
 			continue
 		}
+		// Invitations are bulk mail: honor opt-outs from the preferences link.
+		if IsUnsubscribed(app.DB, p.Email) {
+			continue
+		}
 		if !first {
 			time.Sleep(app.EmailSendDelay)
 		}
 		first = false
 		editURL := fmt.Sprintf("%s/santa/edit?token=%s&lang=%s", baseURL, p.Token, p.Lang)
-		subject, htmlBody := renderSantaLinkEmail(p.Lang, p, *event, editURL)
+		subject, htmlBody := renderSantaLinkEmail(p.Lang, p, *event, editURL, app.unsubscribeURL(baseURL, p.Email, p.Lang))
 		if htmlBody == "" {
 			log.Printf("sendInviteEmails: empty rendered email body for participant %d, skipping", p.ID)
 			continue
 		}
-		messageID, err := app.sendWithRetry(p.Email, subject, htmlBody, invitationAttachments()...)
+		messageID, err := app.sendWithRetry(p.Email, event.OrganizerEmail, subject, htmlBody, invitationAttachments()...)
 		if err != nil {
 			log.Printf("sendInviteEmails: send to %s failed: %v", p.Email, err)
 			continue
@@ -479,8 +824,42 @@ func (app *App) sendInviteEmails(eventID int64, baseURL string) {
 	}
 }
 
+// dispatchSignupConfirmation queues a new registrant's signup summary,
+// cancel link and calendar file in the outbox. Failures are logged only: the
+// signup itself already succeeded.
+func (app *App) dispatchSignupConfirmation(reg Registration, task Task, event Event, baseURL string) {
+	cancelURL := app.cancelURL(baseURL, reg, event, reg.Lang)
+	var qrURL string
+	if event.CheckIn {
+		qrURL = app.qrCodeURL(baseURL, reg, event)
+	}
+	subject, htmlBody := renderSignupConfirmationEmail(reg.Lang, reg, task, event, baseURL, cancelURL, qrURL, app.unsubscribeURL(baseURL, reg.Email, reg.Lang))
+	if htmlBody == "" {
+		log.Printf("signup confirmation: empty rendered email body for registration %d, skipping", reg.ID)
+		return
+	}
+	ics := registrationICSAttachment(reg, task, event, baseURL, cancelURL, reg.Lang)
+	app.queueEmail("signup_confirmation", event.ID, reg.Email, event.OrganizerEmail, subject, htmlBody, ics)
+}
+
+// dispatchAdminNotification queues an already-rendered notice for each of the
+// event's notification addresses, if any.
+func (app *App) dispatchAdminNotification(event Event, subject, htmlBody string) {
+	addrs := event.NotifyAddresses()
+	if len(addrs) == 0 {
+		return
+	}
+	if htmlBody == "" {
+		log.Printf("admin notification: empty rendered email body for event %d, skipping", event.ID)
+		return
+	}
+	for _, to := range addrs {
+		app.queueEmail("admin_notification", event.ID, to, event.OrganizerEmail, subject, htmlBody)
+	}
+}
+
 // sendWithRetry retries a transient send failure up to 3 attempts.
-func (app *App) sendWithRetry(to, subject, htmlBody string, attachments ...emailAttachment) (string, error) {
+func (app *App) sendWithRetry(to, replyTo, subject, htmlBody string, attachments ...emailAttachment) (string, error) {
 	var lastErr error
 	for attempt := 0; attempt < 3; attempt++ {
 		if attempt > 0 {
@@ -488,7 +867,7 @@ func (app *App) sendWithRetry(to, subject, htmlBody string, attachments ...email
 			// app's scale; a dedicated retry-delay field would be over-engineering.
 			time.Sleep(app.EmailSendDelay)
 		}
-		messageID, err := app.Email.Send(context.Background(), to, subject, htmlBody, attachments...)
+		messageID, err := app.Email.Send(context.Background(), to, replyTo, subject, htmlBody, attachments...)
 		if err == nil {
 			return messageID, nil
 		}
@@ -0,0 +1,141 @@
+package main
+
+// Signup email checks. /signup turns away addresses that can't be right —
+// bad syntax, no dot in the domain — and, when the settings page asks for
+// it, domains that receive no mail. Typos of common mail domains are only
+// suggested, as the person types (/api/email-check): the address may be
+// right after all.
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+// commonEmailDomains are the mail domains typos are matched against.
+var commonEmailDomains = []string{
+	"gmail.com", "googlemail.com", "yahoo.com", "yahoo.fr", "hotmail.com", "hotmail.fr",
+	"outlook.com", "outlook.fr", "live.com", "live.fr", "msn.com", "icloud.com", "me.com",
+	"orange.fr", "wanadoo.fr", "free.fr", "sfr.fr", "neuf.fr", "laposte.net", "bbox.fr",
+	"gmx.fr", "gmx.com", "aol.com", "protonmail.com", "proton.me",
+}
+
+// mailResolver looks up whether a domain receives mail; tests replace it.
+var mailResolver interface {
+	LookupMX(ctx context.Context, name string) ([]*net.MX, error)
+	LookupHost(ctx context.Context, host string) ([]string, error)
+} = net.DefaultResolver
+
+// validEmailSyntax reports whether email is a bare address whose domain has
+// a dot and no empty label.
+func validEmailSyntax(email string) bool {
+	a, err := mail.ParseAddress(email)
+	if err != nil || a.Address != email {
+		return false
+	}
+	domain := email[strings.LastIndex(email, "@")+1:]
+	if !strings.Contains(domain, ".") {
+		return false
+	}
+	for _, label := range strings.Split(domain, ".") {
+		if label == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// suggestEmail returns email with its domain replaced by the common mail
+// domain it is most likely a typo of, or "" if there's none.
+func suggestEmail(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at < 1 {
+		return ""
+	}
+	domain := strings.ToLower(email[at+1:])
+	best, bestDist := "", 3 // at most two edits away
+	for _, d := range commonEmailDomains {
+		if d == domain {
+			return ""
+		}
+		if dist := editDistance(domain, d); dist < bestDist {
+			best, bestDist = d, dist
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return email[:at+1] + best
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// receivesMail reports whether domain has a mail server, by its MX records
+// or, lacking any, its address. Lookup failures other than "no such
+// record" count as yes: a DNS hiccup shouldn't block a signup.
+func receivesMail(ctx context.Context, domain string) bool {
+	mx, err := mailResolver.LookupMX(ctx, domain)
+	if err == nil {
+		// A single "." is a null MX: the domain says it takes no mail.
+		return !(len(mx) == 1 && mx[0].Host == ".")
+	}
+	var dnsErr *net.DNSError
+	if !errors.As(err, &dnsErr) || !dnsErr.IsNotFound {
+		return true
+	}
+	_, err = mailResolver.LookupHost(ctx, domain)
+	return err == nil || !errors.As(err, &dnsErr) || !dnsErr.IsNotFound
+}
+
+// checkMailDomains reports whether signups check that email domains
+// receive mail.
+func checkMailDomains(app *App) bool {
+	return GetSetting(app.DB, "signup_check_mx") == "1"
+}
+
+// checkSignupEmail returns the i18n key of what is wrong with a signup
+// address, or "".
+func (app *App) checkSignupEmail(ctx context.Context, email string) string {
+	if !validEmailSyntax(email) {
+		return "error_email_invalid"
+	}
+	if checkMailDomains(app) {
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		if !receivesMail(ctx, email[strings.LastIndex(email, "@")+1:]) {
+			return "error_email_domain"
+		}
+	}
+	return ""
+}
+
+// handleAPIEmailCheck suggests a correction for a probable typo in the
+// address being typed on a signup form.
+func (app *App) handleAPIEmailCheck(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"suggestion": suggestEmail(strings.TrimSpace(r.URL.Query().Get("email")))})
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"net"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestValidEmailSyntax(t *testing.T) {
+	for email, want := range map[string]bool{
+		"alice@example.org":         true,
+		"a.b+tag@mail.example.fr":   true,
+		"alice@example":             false,
+		"alice@example..org":        false,
+		"alice@.org":                false,
+		"alice example@test.com":    false,
+		"Alice <alice@example.org>": false,
+		"alice":                     false,
+	} {
+		if got := validEmailSyntax(email); got != want {
+			t.Errorf("validEmailSyntax(%q) = %v", email, got)
+		}
+	}
+}
+
+func TestSuggestEmail(t *testing.T) {
+	for email, want := range map[string]string{
+		"alice@gmial.com":      "alice@gmail.com",
+		"alice@gmail.con":      "alice@gmail.com",
+		"alice@hotmial.fr":     "alice@hotmail.fr",
+		"alice@orange.fe":      "alice@orange.fr",
+		"alice@gmail.com":      "",
+		"alice@example.org":    "",
+		"alice@association.fr": "",
+		"gmial.com":            "",
+	} {
+		if got := suggestEmail(email); got != want {
+			t.Errorf("suggestEmail(%q) = %q, want %q", email, got, want)
+		}
+	}
+}
+
+// fakeMailResolver answers from fixed records; other names don't exist.
+type fakeMailResolver struct {
+	mx    map[string][]*net.MX
+	hosts map[string]bool
+}
+
+func (f fakeMailResolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	if mx, ok := f.mx[name]; ok {
+		return mx, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+}
+
+func (f fakeMailResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	if f.hosts[host] {
+		return []string{"192.0.2.1"}, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+}
+
+func TestSignupEmailChecks(t *testing.T) {
+	app := testApp(t)
+	mux := newMux(app)
+	e := seedEvent(t, app.DB)
+	tk := seedTask(t, app.DB, e.ID, "Task", nil)
+
+	saved := mailResolver
+	t.Cleanup(func() { mailResolver = saved })
+	mailResolver = fakeMailResolver{
+		mx: map[string][]*net.MX{
+			"example.org": {{Host: "mx.example.org.", Pref: 10}},
+			"nomail.org":  {{Host: ".", Pref: 0}},
+		},
+		hosts: map[string]bool{"bare.org": true},
+	}
+
+	signup := func(email string) string {
+		return postForm(mux, "/signup?lang=en", url.Values{
+			"task_id": {fmt.Sprint(tk.ID)}, "first_name": {"Alice"}, "last_name": {"Dupont"},
+			"email": {email}, "phone": {"0601"},
+		}).Body.String()
+	}
+	if body := signup("alice@localhost"); !strings.Contains(body, T("error_email_invalid", "en")) {
+		t.Error("address without a dotted domain accepted")
+	}
+	// Domains aren't looked up until the settings ask for it.
+	signup("alice@nowhere.org")
+	if CountRegistrations(app.DB, e.ID) != 1 {
+		t.Fatal("signup refused without domain checks")
+	}
+
+	postForm(mux, "/admin/settings/signup-limits?lang=en", url.Values{"signup_check_mx": {"1"}}, adminCookie(app))
+	if !checkMailDomains(app) {
+		t.Fatal("domain checks not saved")
+	}
+	for _, email := range []string{"bob@nowhere.org", "bob@nomail.org"} {
+		if body := signup(email); !strings.Contains(body, template.HTMLEscapeString(T("error_email_domain", "en"))) {
+			t.Errorf("%s accepted", email)
+		}
+	}
+	signup("bob@example.org")
+	signup("carol@bare.org")
+	if n := CountRegistrations(app.DB, e.ID); n != 3 {
+		t.Errorf("%d registrations, want 3", n)
+	}
+
+	if body := getRequest(mux, "/api/email-check?email=dan@gmial.com").Body.String(); !strings.Contains(body, `"suggestion":"dan@gmail.com"`) {
+		t.Errorf("email check = %s", body)
+	}
+}
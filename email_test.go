@@ -2,11 +2,14 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"io"
 	"mime"
 	"mime/multipart"
+	"net"
 	"net/mail"
+	"net/textproto"
 	"strings"
 	"testing"
 )
@@ -44,7 +47,7 @@ func TestBuildRawMIME(t *testing.T) {
 	}}
 	from := formatFrom("no-reply@chanteloube.fr", "Évènements")
 
-	raw, err := buildRawMIME(from, "alice@example.com", "Rappel — Saga Dawa", htmlBody, att)
+	raw, err := buildRawMIME(from, "alice@example.com", "orga@chanteloube.fr", "Rappel — Saga Dawa", htmlBody, att)
 	if err != nil {
 		t.Fatalf("buildRawMIME: %v", err)
 	}
@@ -54,6 +57,10 @@ func TestBuildRawMIME(t *testing.T) {
 		t.Fatalf("parse message: %v", err)
 	}
 
+	if got := msg.Header.Get("Reply-To"); got != "orga@chanteloube.fr" {
+		t.Errorf("Reply-To = %q", got)
+	}
+
 	dec := new(mime.WordDecoder)
 	gotSubj, err := dec.DecodeHeader(msg.Header.Get("Subject"))
 	if err != nil {
@@ -112,3 +119,111 @@ func TestBuildRawMIME(t *testing.T) {
 		t.Errorf("expected EOF after 2 parts, got %v", err)
 	}
 }
+
+// fakeSMTPServer accepts one connection and speaks just enough SMTP for
+// net/smtp (no STARTTLS offered), returning the received envelope and data.
+func fakeSMTPServer(t *testing.T) (addr string, got chan []string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	got = make(chan []string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		tp := textproto.NewConn(conn)
+		var lines []string
+		tp.PrintfLine("220 localhost ESMTP")
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				return
+			}
+			cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+			switch cmd {
+			case "EHLO":
+				tp.PrintfLine("250-localhost")
+				tp.PrintfLine("250 AUTH PLAIN")
+			case "AUTH":
+				lines = append(lines, line)
+				tp.PrintfLine("235 ok")
+			case "MAIL", "RCPT":
+				lines = append(lines, line)
+				tp.PrintfLine("250 ok")
+			case "DATA":
+				tp.PrintfLine("354 go ahead")
+				data, _ := tp.ReadDotBytes()
+				lines = append(lines, string(data))
+				tp.PrintfLine("250 queued")
+			case "QUIT":
+				tp.PrintfLine("221 bye")
+				got <- lines
+				return
+			default:
+				tp.PrintfLine("250 ok")
+			}
+		}
+	}()
+	return ln.Addr().String(), got
+}
+
+func TestSMTPSender(t *testing.T) {
+	addr, got := fakeSMTPServer(t)
+	host, port, _ := net.SplitHostPort(addr)
+	s := NewSMTPSender(host, port, "user", "secret", "no-reply@chanteloube.fr", "Chanteloube")
+
+	id, err := s.Send(context.Background(), "alice@example.com", "", "Inscription confirmée", "<p>Bonjour</p>")
+	if err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	lines := <-got
+	if len(lines) != 4 {
+		t.Fatalf("server saw %q", lines)
+	}
+	if !strings.HasPrefix(lines[0], "AUTH PLAIN") {
+		t.Errorf("expected AUTH PLAIN, got %q", lines[0])
+	}
+	if lines[1] != "MAIL FROM:<no-reply@chanteloube.fr>" || lines[2] != "RCPT TO:<alice@example.com>" {
+		t.Errorf("envelope = %q, %q", lines[1], lines[2])
+	}
+	msg, err := mail.ReadMessage(strings.NewReader(lines[3]))
+	if err != nil {
+		t.Fatalf("parse message: %v", err)
+	}
+	if msg.Header.Get("Message-ID") != id || id == "" {
+		t.Errorf("Message-ID = %q, returned %q", msg.Header.Get("Message-ID"), id)
+	}
+	if _, err := msg.Header.Date(); err != nil {
+		t.Errorf("missing or bad Date header: %v", err)
+	}
+	if from := msg.Header.Get("From"); from != `"Chanteloube" <no-reply@chanteloube.fr>` {
+		t.Errorf("From = %q", from)
+	}
+}
+
+func TestRenderSignupConfirmationEmail(t *testing.T) {
+	event := Event{Slug: "fete", TitleFR: "Fête", TitleEN: "Party", EventDate: "2026-06-14", EventTime: "14:30"}
+	task := Task{TitleFR: "Vaisselle", TitleEN: "Dishes"}
+	reg := Registration{FirstName: "Alice", Token: "tok123", Lang: LangEN}
+
+	subject, html := renderSignupConfirmationEmail(LangEN, reg, task, event, "https://fete.example.org", "https://fete.example.org/cancel/tok123?lang=en", "", "")
+	if subject != "Signup confirmed: Party" {
+		t.Errorf("subject = %q", subject)
+	}
+	for _, want := range []string{
+		"Hello Alice,",
+		"Dishes",
+		"Sunday, June 14, 2026, 2:30 PM",
+		`href="https://fete.example.org/cancel/tok123?lang=en"`,
+		`href="https://fete.example.org/e/fete?lang=en"`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("email body missing %q", want)
+		}
+	}
+}
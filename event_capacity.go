@@ -0,0 +1,79 @@
+package main
+
+// Event capacity. An event can cap how many people take part in it as a
+// whole — a venue's fire-code limit — whatever room its tasks have left.
+// Someone signed up for several tasks is one person there; guests count
+// too. Once the cap is reached the public page shows the event is full and
+// /signup turns posts away; RegisterGroupForTask settles races for the last
+// places.
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxEventParticipants bounds Event.MaxParticipants.
+const maxEventParticipants = 100000
+
+// errEventFull is returned by RegisterGroupForTask when the signup would take
+// the event over its MaxParticipants.
+var errEventFull = errors.New("event_full")
+
+// parseMaxParticipants reads an event's participant limit typed in the
+// admin; empty means none.
+func parseMaxParticipants(raw string) (sql.NullInt64, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return sql.NullInt64{}, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 1 || n > maxEventParticipants {
+		return sql.NullInt64{}, fmt.Errorf("invalid max participants %q", raw)
+	}
+	return sql.NullInt64{Int64: int64(n), Valid: true}, nil
+}
+
+// countParticipantsSQL counts the people signed up for an event: each email
+// address and member once, with the most guests they bring to any task.
+const countParticipantsSQL = `SELECT COALESCE(SUM(people), 0) FROM (
+	SELECT MAX(1 + r.guests) AS people FROM registrations r JOIN tasks t ON t.id = r.task_id
+	WHERE t.event_id = ? AND t.trash_id IS NULL AND r.trash_id IS NULL
+	GROUP BY CASE WHEN r.email = '' THEN 'id:' || r.id ELSE LOWER(r.email) || ':' || r.member END)`
+
+// CountEventParticipants returns how many people are signed up for event
+// eventID, guests included.
+func CountEventParticipants(db *sql.DB, eventID int64) int {
+	var n int
+	db.QueryRow(countParticipantsSQL, eventID).Scan(&n)
+	return n
+}
+
+// checkEventCapacity returns errEventFull when the registrations of event
+// eventID, the one being made included, outnumber its limit.
+func checkEventCapacity(tx *sql.Tx, eventID int64, limit sql.NullInt64) error {
+	if !limit.Valid {
+		return nil
+	}
+	var n int
+	if err := tx.QueryRow(countParticipantsSQL, eventID).Scan(&n); err != nil {
+		return err
+	}
+	if n > int(limit.Int64) {
+		return errEventFull
+	}
+	return nil
+}
+
+// signupState is RegistrationState, reporting registrationFull when the
+// window is open but the event has no places left.
+func signupState(db *sql.DB, e *Event, now time.Time) string {
+	state := e.RegistrationState(now)
+	if state == registrationOpen && e.MaxParticipants.Valid && CountEventParticipants(db, e.ID) >= int(e.MaxParticipants.Int64) {
+		return registrationFull
+	}
+	return state
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestEventCapacity(t *testing.T) {
+	app := testApp(t)
+	mux := newMux(app)
+	e := &Event{TitleFR: "Fête", EventDate: "2026-06-15", MaxTasksPerPerson: 2, MaxParticipants: sql.NullInt64{Int64: 3, Valid: true}}
+	if err := CreateEvent(app.DB, e); err != nil {
+		t.Fatal(err)
+	}
+	setup := &Task{EventID: e.ID, TitleFR: "Montage", MaxGuests: 2}
+	CreateTask(app.DB, setup)
+	bar := seedTask(t, app.DB, e.ID, "Buvette", nil)
+
+	if _, err := RegisterGroupForTask(app.DB, setup.ID, 1, "Alice", "Dupont", "alice@test.com", "0601", "fr"); err != nil {
+		t.Fatal(err)
+	}
+	// Alice and her guest are already counted.
+	if _, err := RegisterForTask(app.DB, bar.ID, "Alice", "Dupont", "Alice@test.com", "0601", "fr"); err != nil {
+		t.Errorf("second task of someone already counted: %v", err)
+	}
+	if n := CountEventParticipants(app.DB, e.ID); n != 2 {
+		t.Errorf("participants = %d, want 2", n)
+	}
+	if _, err := RegisterGroupForTask(app.DB, setup.ID, 1, "Bob", "Martin", "bob@test.com", "0602", "fr"); err != errEventFull {
+		t.Errorf("two people for the last place: %v", err)
+	}
+
+	form := url.Values{
+		"task_id": {fmt.Sprint(bar.ID)}, "first_name": {"Bob"}, "last_name": {"Martin"},
+		"email": {"bob@test.com"}, "phone": {"0602"},
+	}
+	postForm(mux, "/signup?lang=en", form)
+	if n := CountEventParticipants(app.DB, e.ID); n != 3 {
+		t.Fatalf("participants = %d, want 3", n)
+	}
+	if body := getRequest(mux, "/e/"+e.Slug+"?lang=en").Body.String(); !strings.Contains(body, T("registration_full", "en")) {
+		t.Error("full event not shown as such")
+	}
+	form.Set("email", "carol@test.com")
+	if body := postForm(mux, "/signup?lang=en", form).Body.String(); !strings.Contains(body, T("registration_full", "en")) {
+		t.Error("signup to a full event not turned away")
+	}
+}
@@ -0,0 +1,27 @@
+package main
+
+// Single-language events. Many events are never translated, and their
+// English pages show French texts under English headings while the admin
+// shows empty English fields. Such an event can be set to French or English
+// only: its texts are then the French fields alone, labelled without a
+// language in the admin, and its public pages show in that language
+// whatever the visitor's, without the language switch.
+
+import "net/http"
+
+// normalizeEventLang returns the event language lang stands for, "" (both)
+// when it is none.
+func normalizeEventLang(lang string) string {
+	if lang == LangFR || lang == LangEN {
+		return lang
+	}
+	return ""
+}
+
+// eventLang is the language event's public pages show in for r.
+func eventLang(r *http.Request, event *Event) string {
+	if event.Lang != "" {
+		return event.Lang
+	}
+	return LangFromRequest(r)
+}
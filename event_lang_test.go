@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSingleLanguageEvent(t *testing.T) {
+	app := testApp(t)
+	mux := newMux(app)
+	e := seedEvent(t, app.DB)
+
+	save := func(lang string) {
+		t.Helper()
+		body, _ := json.Marshal(map[string]any{
+			"event_id": e.ID, "title_fr": e.TitleFR, "event_date": e.EventDate, "lang": lang,
+		})
+		req := httptest.NewRequest(http.MethodPost, "/admin/api/event/save", bytes.NewReader(body))
+		req.AddCookie(adminCookie(app))
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		if w.Code != 200 {
+			t.Fatalf("save: status %d: %s", w.Code, w.Body)
+		}
+	}
+
+	save("de")
+	if got, _ := GetEvent(app.DB, e.ID); got.Lang != "" {
+		t.Errorf("lang = %q, want both", got.Lang)
+	}
+	if page := getRequest(mux, "/e/"+e.Slug+"?lang=en").Body.String(); !strings.Contains(page, `<html lang="en">`) || !strings.Contains(page, `class="lang-switch"`) {
+		t.Error("bilingual event page not in English with a switch")
+	}
+
+	save("fr")
+	if got, _ := GetEvent(app.DB, e.ID); got.Lang != LangFR {
+		t.Errorf("lang = %q, want fr", got.Lang)
+	}
+	page := getRequest(mux, "/e/"+e.Slug+"?lang=en").Body.String()
+	if !strings.Contains(page, `<html lang="fr">`) {
+		t.Error("French-only event page not in French")
+	}
+	if strings.Contains(page, `class="lang-switch"`) {
+		t.Error("French-only event page has a language switch")
+	}
+
+	admin := getRequest(mux, fmt.Sprintf("/admin/event/edit?id=%d", e.ID), adminCookie(app)).Body.String()
+	if !strings.Contains(admin, `class="event-edit single-lang"`) || !strings.Contains(admin, `<option value="fr" selected>`) {
+		t.Error("admin edit page not in single-language mode")
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+// The admin dashboard's event list: tabs for upcoming events, past ones and
+// archived ones — past events whose registrations the retention policy has
+// already anonymized (see retention.go) — a search in titles and a choice
+// of order. Events are few, so they are filtered and sorted in memory; what
+// costs is counting each one's registrations, so only those of the page
+// shown are counted. Years of history fit better in the compact table view
+// than in cards.
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// The dashboard tabs, in order.
+var eventTabs = []string{"upcoming", "past", "archived"}
+
+// The orders the event list can be sorted in.
+var eventSorts = []string{"date", "title", "created"}
+
+// The ways the event list can be shown: cards or a compact table.
+var eventViews = []string{"cards", "table"}
+
+// eventsPerPage is how many events the dashboard shows at once.
+const eventsPerPage = 24
+
+// EventListFilter picks and orders the events of the dashboard.
+type EventListFilter struct {
+	Tab   string // one of eventTabs
+	Query string // part of a title or slug
+	Sort  string // one of eventSorts
+	View  string // one of eventViews
+}
+
+func parseEventListFilter(r *http.Request) EventListFilter {
+	q := r.URL.Query()
+	f := EventListFilter{Tab: q.Get("tab"), Query: strings.TrimSpace(q.Get("q")), Sort: q.Get("sort"), View: q.Get("view")}
+	if !slices.Contains(eventTabs, f.Tab) {
+		f.Tab = eventTabs[0]
+	}
+	if !slices.Contains(eventSorts, f.Sort) {
+		f.Sort = eventSorts[0]
+	}
+	if !slices.Contains(eventViews, f.View) {
+		f.View = eventViews[0]
+	}
+	return f
+}
+
+// values returns f as query parameters of the dashboard, in lang.
+func (f EventListFilter) values(lang string) url.Values {
+	v := url.Values{"tab": {f.Tab}, "lang": {lang}}
+	if f.Query != "" {
+		v.Set("q", f.Query)
+	}
+	if f.Sort != eventSorts[0] {
+		v.Set("sort", f.Sort)
+	}
+	if f.View != eventViews[0] {
+		v.Set("view", f.View)
+	}
+	return v
+}
+
+// TabURL returns the dashboard address showing tab, keeping f's search,
+// order and view.
+func (f EventListFilter) TabURL(tab, lang string) string {
+	f.Tab = tab
+	return "/admin?" + f.values(lang).Encode()
+}
+
+// ViewURL returns the dashboard address showing f's events as view.
+func (f EventListFilter) ViewURL(view, lang string) string {
+	f.View = view
+	return "/admin?" + f.values(lang).Encode()
+}
+
+// eventTab returns the dashboard tab e belongs in, today being a
+// "2006-01-02" date.
+func eventTab(e Event, today string) string {
+	switch {
+	case e.AnonymizedAt.Valid:
+		return "archived"
+	case e.EndDay() < today:
+		return "past"
+	}
+	return "upcoming"
+}
+
+// foldText lowercases s and strips its accents, for searches that ignore
+// both.
+func foldText(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if mapped, ok := accentMap[r]; ok {
+			r = mapped
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// filterEvents returns the events of f's tab matching its search, in its
+// order, titles compared in lang, with how many events match in each tab.
+// Upcoming events come soonest first, the others latest first.
+func filterEvents(events []Event, f EventListFilter, today, lang string) ([]Event, map[string]int) {
+	query := foldText(f.Query)
+	counts := map[string]int{}
+	var shown []Event
+	for _, e := range events {
+		if query != "" && !strings.Contains(foldText(e.TitleFR+" "+e.TitleEN+" "+e.Slug), query) {
+			continue
+		}
+		tab := eventTab(e, today)
+		counts[tab]++
+		if tab == f.Tab {
+			shown = append(shown, e)
+		}
+	}
+	sort.SliceStable(shown, func(i, j int) bool {
+		a, b := shown[i], shown[j]
+		switch f.Sort {
+		case "title":
+			return foldText(Localized(a.TitleFR, a.TitleEN, lang)) < foldText(Localized(b.TitleFR, b.TitleEN, lang))
+		case "created":
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+		if f.Tab == "upcoming" {
+			return a.EventDate < b.EventDate
+		}
+		return a.EventDate > b.EventDate
+	})
+	return shown, counts
+}
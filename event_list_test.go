@@ -0,0 +1,96 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFilterEvents(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 1, d, 0, 0, 0, 0, time.UTC) }
+	events := []Event{
+		{ID: 1, TitleFR: "Kermesse", EventDate: "2026-06-20", CreatedAt: day(1)},
+		{ID: 2, TitleFR: "Fête d'été", TitleEN: "Summer party", EventDate: "2026-06-10", CreatedAt: day(3)},
+		{ID: 3, TitleFR: "Brocante", EventDate: "2026-05-01", EndDate: "2026-06-02", CreatedAt: day(2)},
+		{ID: 4, TitleFR: "Loto", EventDate: "2025-11-01", CreatedAt: day(4)},
+		{ID: 5, TitleFR: "Vide-grenier", EventDate: "2024-09-01", AnonymizedAt: sql.NullString{String: "2025-09-01", Valid: true}},
+	}
+	ids := func(list []Event) []int64 {
+		var out []int64
+		for _, e := range list {
+			out = append(out, e.ID)
+		}
+		return out
+	}
+	const today = "2026-06-01"
+
+	shown, counts := filterEvents(events, EventListFilter{Tab: "upcoming", Sort: "date"}, today, LangFR)
+	if got := ids(shown); len(got) != 3 || got[0] != 3 || got[1] != 2 || got[2] != 1 {
+		t.Errorf("upcoming = %v, want soonest first with the multi-day one still on", got)
+	}
+	if counts["upcoming"] != 3 || counts["past"] != 1 || counts["archived"] != 1 {
+		t.Errorf("counts = %v", counts)
+	}
+	if shown, _ = filterEvents(events, EventListFilter{Tab: "upcoming", Sort: "title"}, today, LangEN); ids(shown)[0] != 3 || ids(shown)[2] != 2 {
+		t.Errorf("by English title = %v", ids(shown))
+	}
+	if shown, _ = filterEvents(events, EventListFilter{Tab: "upcoming", Sort: "created"}, today, LangFR); ids(shown)[0] != 2 {
+		t.Errorf("latest created = %v", ids(shown))
+	}
+	shown, counts = filterEvents(events, EventListFilter{Tab: "upcoming", Query: "FETE", Sort: "date"}, today, LangFR)
+	if got := ids(shown); len(got) != 1 || got[0] != 2 || counts["past"] != 0 {
+		t.Errorf("search ignoring case and accents = %v, counts %v", got, counts)
+	}
+	if shown, _ = filterEvents(events, EventListFilter{Tab: "archived", Sort: "date"}, today, LangFR); len(shown) != 1 || shown[0].ID != 5 {
+		t.Errorf("archived = %v", ids(shown))
+	}
+}
+
+func TestAdminEventsTabs(t *testing.T) {
+	app := testApp(t)
+	mux := newMux(app)
+	admin := adminCookie(app)
+	next := time.Now().AddDate(0, 1, 0).Format("2006-01-02")
+	CreateEvent(app.DB, &Event{TitleFR: "Marché de Noël", EventDate: next})
+	CreateEvent(app.DB, &Event{TitleFR: "Kermesse 2020", EventDate: "2020-06-01"})
+
+	body := getRequest(mux, "/admin?lang=fr", admin).Body.String()
+	if !strings.Contains(body, "Marché de Noël") || strings.Contains(body, "Kermesse 2020") {
+		t.Error("default tab should list upcoming events only")
+	}
+	body = getRequest(mux, "/admin?tab=past&q=kermesse&lang=fr", admin).Body.String()
+	if !strings.Contains(body, "Kermesse 2020") {
+		t.Error("past tab search lacks the matching event")
+	}
+	body = getRequest(mux, "/admin?tab=past&q=brocante&lang=en", admin).Body.String()
+	if !strings.Contains(body, "No event matches this search.") {
+		t.Error("search without match should say so")
+	}
+}
+
+func TestAdminEventsPagination(t *testing.T) {
+	app := testApp(t)
+	mux := newMux(app)
+	admin := adminCookie(app)
+	start := time.Now().AddDate(0, 1, 0)
+	for i := range eventsPerPage + 3 {
+		CreateEvent(app.DB, &Event{TitleFR: fmt.Sprintf("Soirée %02d", i), EventDate: start.AddDate(0, 0, i).Format("2006-01-02")})
+	}
+
+	first := getRequest(mux, "/admin?lang=en", admin).Body.String()
+	if !strings.Contains(first, "Soirée 00") || strings.Contains(first, fmt.Sprintf("Soirée %02d", eventsPerPage)) {
+		t.Error("first page should hold the soonest events only")
+	}
+	if !strings.Contains(first, fmt.Sprintf("1–%d of %d", eventsPerPage, eventsPerPage+3)) || !strings.Contains(first, "page=2") {
+		t.Error("first page lacks its pagination")
+	}
+	last := getRequest(mux, "/admin?page=9&view=table&lang=en", admin).Body.String()
+	if !strings.Contains(last, fmt.Sprintf("Soirée %02d", eventsPerPage+2)) || strings.Contains(last, "Soirée 00") {
+		t.Error("a page past the end should show the last one")
+	}
+	if !strings.Contains(last, `class="data-table"`) || strings.Contains(last, `class="card-list"`) {
+		t.Error("table view should list events in a table")
+	}
+}
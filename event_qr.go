@@ -0,0 +1,87 @@
+package main
+
+// QR codes of event pages. The admin event page shows the QR code of the
+// event's public link, with PNG and SVG downloads for posters and flyers —
+// SVG stays sharp at any print size. The code holds the event's short link,
+// marked so that scans are counted apart, on the event's own domain when it
+// has one.
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// QR code PNG sizes in pixels: the default, and the bounds of ?size=.
+const (
+	eventQRSize    = 512
+	eventQRMinSize = 128
+	eventQRMaxSize = 2048
+)
+
+// qrSVG draws the QR code of content as an SVG, one unit per module, quiet
+// zone included.
+func qrSVG(content string) ([]byte, error) {
+	q, err := qrcode.New(content, qrcode.Medium)
+	if err != nil {
+		return nil, err
+	}
+	bitmap := q.Bitmap()
+	var path strings.Builder
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&path, "M%d %dh1v1h-1z", x, y)
+			}
+		}
+	}
+	n := len(bitmap)
+	return fmt.Appendf(nil, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" shape-rendering="crispEdges">`+
+		`<rect width="%d" height="%d" fill="#fff"/><path d="%s" fill="#000"/></svg>`, n, n, n, n, path.String()), nil
+}
+
+// handleAdminEventQR serves the QR code of an event's public link, as an
+// SVG (format=svg) or a PNG, as a download when download=1.
+func (app *App) handleAdminEventQR(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	event, err := GetEvent(app.DB, id)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	short, err := EnsureShortLink(app.DB, event.ID)
+	if err != nil {
+		log.Printf("short link of event %d: %v", event.ID, err)
+		http.Error(w, T("error_server", LangFromRequest(r)), 500)
+		return
+	}
+	link := app.shortLinkURL(r, event, short.Code) + "?qr"
+	format := "png"
+	var img []byte
+	if r.URL.Query().Get("format") == "svg" {
+		format = "svg"
+		img, err = qrSVG(link)
+		w.Header().Set("Content-Type", "image/svg+xml")
+	} else {
+		size, convErr := strconv.Atoi(r.URL.Query().Get("size"))
+		if convErr != nil {
+			size = eventQRSize
+		}
+		img, err = qrcode.Encode(link, qrcode.Medium, min(max(size, eventQRMinSize), eventQRMaxSize))
+		w.Header().Set("Content-Type", "image/png")
+	}
+	if err != nil {
+		log.Printf("qr code of event %d: %v", event.ID, err)
+		http.Error(w, T("error_server", LangFromRequest(r)), 500)
+		return
+	}
+	if r.URL.Query().Get("download") == "1" {
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-qr.%s"`, event.Slug, format))
+	}
+	w.Header().Set("Cache-Control", "private, no-cache")
+	w.Write(img)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestEventQR(t *testing.T) {
+	app := testApp(t)
+	mux := newMux(app)
+	cookie := adminCookie(app)
+	event := seedEvent(t, app.DB)
+	eventID := event.ID
+
+	if w := getRequest(mux, fmt.Sprintf("/admin/event/qr?id=%d", eventID)); w.Code == 200 {
+		t.Fatal("QR code served without login")
+	}
+
+	w := getRequest(mux, fmt.Sprintf("/admin/event/qr?id=%d&size=300&download=1", eventID), cookie)
+	if w.Code != 200 || w.Header().Get("Content-Type") != "image/png" {
+		t.Fatalf("png: status %d, type %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	if got, want := w.Header().Get("Content-Disposition"), `attachment; filename="`+event.Slug+`-qr.png"`; got != want {
+		t.Errorf("disposition %q, want %q", got, want)
+	}
+	img, err := png.Decode(bytes.NewReader(w.Body.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 300 {
+		t.Errorf("png is %v", b)
+	}
+
+	w = getRequest(mux, fmt.Sprintf("/admin/event/qr?id=%d&format=svg", eventID), cookie)
+	if w.Header().Get("Content-Type") != "image/svg+xml" || w.Header().Get("Content-Disposition") != "" {
+		t.Fatalf("svg: type %q, disposition %q", w.Header().Get("Content-Type"), w.Header().Get("Content-Disposition"))
+	}
+	if !strings.HasPrefix(w.Body.String(), "<svg ") || !strings.Contains(w.Body.String(), "h1v1h-1z") {
+		t.Errorf("svg is %.80s", w.Body.String())
+	}
+
+	if w := getRequest(mux, "/admin/event/qr?id=999", cookie); w.Code != 404 {
+		t.Errorf("unknown event: status %d", w.Code)
+	}
+}
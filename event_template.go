@@ -0,0 +1,204 @@
+package main
+
+// Event templates: a named copy of an event's groups and tasks, saved from
+// the event edit page and picked when creating a new event. Unlike the AI
+// and spreadsheet imports, nothing is interpreted: the tree comes back
+// exactly as it was saved, shifts and slot limits included. Templates are
+// managed on /admin/templates.
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EventTemplate is a saved group/task tree. Group ids in Groups and Tasks
+// only link the rows to each other.
+type EventTemplate struct {
+	ID        int64
+	Name      string
+	Groups    []TaskGroup
+	Tasks     []Task
+	CreatedAt time.Time
+}
+
+// templateStructure is how a template's tree is stored.
+type templateStructure struct {
+	Groups []TaskGroup `json:"groups"`
+	Tasks  []Task      `json:"tasks"`
+}
+
+// CreateEventTemplate saves the current groups and tasks of event eventID
+// as a new template.
+func CreateEventTemplate(db *sql.DB, name string, eventID int64) (*EventTemplate, error) {
+	groups, err := ListTaskGroups(db, eventID)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := ListTasks(db, eventID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range groups {
+		groups[i].EventID = 0
+	}
+	for i := range tasks {
+		tasks[i].EventID = 0
+	}
+	structure, err := json.Marshal(templateStructure{Groups: groups, Tasks: tasks})
+	if err != nil {
+		return nil, err
+	}
+	res, err := db.Exec("INSERT INTO event_templates (name, structure) VALUES (?, ?)", name, string(structure))
+	if err != nil {
+		return nil, err
+	}
+	id, _ := res.LastInsertId()
+	return &EventTemplate{ID: id, Name: name, Groups: groups, Tasks: tasks}, nil
+}
+
+func scanEventTemplate(row interface{ Scan(...any) error }) (*EventTemplate, error) {
+	var t EventTemplate
+	var structure string
+	if err := row.Scan(&t.ID, &t.Name, &structure, &t.CreatedAt); err != nil {
+		return nil, err
+	}
+	var s templateStructure
+	if err := json.Unmarshal([]byte(structure), &s); err != nil {
+		return nil, fmt.Errorf("template %d: %w", t.ID, err)
+	}
+	t.Groups, t.Tasks = s.Groups, s.Tasks
+	return &t, nil
+}
+
+func GetEventTemplate(db *sql.DB, id int64) (*EventTemplate, error) {
+	return scanEventTemplate(db.QueryRow("SELECT id, name, structure, created_at FROM event_templates WHERE id=?", id))
+}
+
+// ListEventTemplates returns the templates by name.
+func ListEventTemplates(db *sql.DB) ([]EventTemplate, error) {
+	rows, err := db.Query("SELECT id, name, structure, created_at FROM event_templates ORDER BY name COLLATE NOCASE, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []EventTemplate
+	for rows.Next() {
+		t, err := scanEventTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *t)
+	}
+	return list, rows.Err()
+}
+
+// CountEventTemplates returns how many templates are saved.
+func CountEventTemplates(db *sql.DB) int {
+	var n int
+	db.QueryRow("SELECT COUNT(*) FROM event_templates").Scan(&n)
+	return n
+}
+
+func RenameEventTemplate(db *sql.DB, id int64, name string) error {
+	_, err := db.Exec("UPDATE event_templates SET name=? WHERE id=?", name, id)
+	return err
+}
+
+func DeleteEventTemplate(db *sql.DB, id int64) error {
+	_, err := db.Exec("DELETE FROM event_templates WHERE id=?", id)
+	return err
+}
+
+// ApplyEventTemplate adds the template's groups and tasks to event eventID.
+func ApplyEventTemplate(db *sql.DB, tpl *EventTemplate, eventID int64) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := insertTaskStructure(tx, eventID, tpl.Groups, tpl.Tasks); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ---- Admin ----
+
+func (app *App) handleAdminTemplates(w http.ResponseWriter, r *http.Request) {
+	templates, err := ListEventTemplates(app.DB)
+	if err != nil {
+		log.Printf("templates: list: %v", err)
+	}
+	pd := app.newPageData(r, map[string]any{"Templates": templates})
+	pd.Success, pd.Error = takeFlash(w, r)
+	app.render(w, r, "admin_templates.html", pd)
+}
+
+// handleAdminTemplateSave saves an event's groups and tasks as a template.
+func (app *App) handleAdminTemplateSave(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/admin", http.StatusSeeOther)
+		return
+	}
+	lang := LangFromRequest(r)
+	eventID, _ := strconv.ParseInt(r.FormValue("event_id"), 10, 64)
+	editURL := fmt.Sprintf("/admin/event/edit?id=%d&lang=%s#event-template", eventID, lang)
+	if _, err := GetEvent(app.DB, eventID); err != nil {
+		http.Redirect(w, r, "/admin", http.StatusSeeOther)
+		return
+	}
+	name := strings.TrimSpace(r.FormValue("name"))
+	if name == "" {
+		setFlash(w, "error", T("template_error_name", lang))
+		http.Redirect(w, r, editURL, http.StatusSeeOther)
+		return
+	}
+	if _, err := CreateEventTemplate(app.DB, name, eventID); err != nil {
+		log.Printf("templates: save event %d: %v", eventID, err)
+		setFlash(w, "error", T("error_server", lang))
+	} else {
+		setFlash(w, "success", T("template_saved", lang))
+	}
+	http.Redirect(w, r, editURL, http.StatusSeeOther)
+}
+
+func (app *App) handleAdminTemplateRename(w http.ResponseWriter, r *http.Request) {
+	lang := LangFromRequest(r)
+	templatesURL := "/admin/templates?lang=" + lang
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, templatesURL, http.StatusSeeOther)
+		return
+	}
+	id, _ := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	name := strings.TrimSpace(r.FormValue("name"))
+	if name == "" {
+		setFlash(w, "error", T("template_error_name", lang))
+	} else if err := RenameEventTemplate(app.DB, id, name); err != nil {
+		log.Printf("templates: rename %d: %v", id, err)
+		setFlash(w, "error", T("error_server", lang))
+	}
+	http.Redirect(w, r, templatesURL, http.StatusSeeOther)
+}
+
+func (app *App) handleAdminTemplateDelete(w http.ResponseWriter, r *http.Request) {
+	lang := LangFromRequest(r)
+	templatesURL := "/admin/templates?lang=" + lang
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, templatesURL, http.StatusSeeOther)
+		return
+	}
+	id, _ := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	if err := DeleteEventTemplate(app.DB, id); err != nil {
+		log.Printf("templates: delete %d: %v", id, err)
+		setFlash(w, "error", T("error_server", lang))
+	} else {
+		setFlash(w, "success", T("template_deleted", lang))
+	}
+	http.Redirect(w, r, templatesURL, http.StatusSeeOther)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestEventTemplates(t *testing.T) {
+	app := testApp(t)
+	e := seedEvent(t, app.DB)
+	g := &TaskGroup{EventID: e.ID, TitleFR: "Cuisine", TitleEN: "Kitchen"}
+	CreateTaskGroup(app.DB, g)
+	sub := &TaskGroup{EventID: e.ID, ParentGroupID: sql.NullInt64{Int64: g.ID, Valid: true}, TitleFR: "Midi"}
+	CreateTaskGroup(app.DB, sub)
+	CreateTask(app.DB, &Task{EventID: e.ID, GroupID: sql.NullInt64{Int64: sub.ID, Valid: true}, TitleFR: "Vaisselle",
+		MaxSlots: sql.NullInt64{Int64: 3, Valid: true}, StartTime: "12:00", EndTime: "14:00"})
+	seedTask(t, app.DB, e.ID, "Accueil", nil)
+	mux := newMux(app)
+	admin := adminCookie(app)
+
+	postForm(mux, "/admin/templates/save?lang=en", url.Values{"event_id": {fmt.Sprint(e.ID)}, "name": {"  "}}, admin)
+	postForm(mux, "/admin/templates/save?lang=en", url.Values{"event_id": {fmt.Sprint(e.ID)}, "name": {"Fête annuelle"}}, admin)
+	templates, _ := ListEventTemplates(app.DB)
+	if len(templates) != 1 || templates[0].Name != "Fête annuelle" {
+		t.Fatalf("templates = %+v", templates)
+	}
+	tpl := templates[0]
+	if body := getRequest(mux, "/admin/templates?lang=en", admin).Body.String(); !strings.Contains(body, "2 groups, 2 tasks") {
+		t.Error("management page does not summarize the template")
+	}
+	// Later changes to the event don't touch the template.
+	DeleteTaskGroup(app.DB, g.ID)
+
+	if body := getRequest(mux, fmt.Sprintf("/admin/event/new?template=%d&lang=en", tpl.ID), admin).Body.String(); !strings.Contains(body, fmt.Sprintf(`<option value="%d" selected>`, tpl.ID)) {
+		t.Error("new event form does not preselect the template")
+	}
+	w := postForm(mux, "/admin/event/new?lang=en", url.Values{
+		"title_fr": {"Fête 2027"}, "event_date": {"2027-06-12"}, "event_type": {"tasks"}, "template_id": {fmt.Sprint(tpl.ID)},
+	}, admin)
+	var newID int64
+	fmt.Sscanf(w.Header().Get("Location"), "/admin/event/edit?id=%d", &newID)
+	tree, err := BuildEventTree(app.DB, newID)
+	if err != nil || len(tree) != 2 {
+		t.Fatalf("new event tree: %+v, %v", tree, err)
+	}
+	kitchen := tree[0]
+	if kitchen.Type != "group" || kitchen.Group.TitleEN != "Kitchen" || len(kitchen.Children) != 1 || len(kitchen.Children[0].Children) != 1 {
+		t.Fatalf("nesting lost: %+v", kitchen)
+	}
+	task := kitchen.Children[0].Children[0].Task
+	if task.TitleFR != "Vaisselle" || task.MaxSlots.Int64 != 3 || task.StartTime != "12:00" || task.EventID != newID {
+		t.Errorf("task = %+v", task)
+	}
+
+	postForm(mux, "/admin/templates/rename?lang=en", url.Values{"id": {fmt.Sprint(tpl.ID)}, "name": {"Fête d'été"}}, admin)
+	if got, _ := GetEventTemplate(app.DB, tpl.ID); got.Name != "Fête d'été" {
+		t.Errorf("rename: %q", got.Name)
+	}
+	postForm(mux, "/admin/templates/delete?lang=en", url.Values{"id": {fmt.Sprint(tpl.ID)}}, admin)
+	if CountEventTemplates(app.DB) != 0 {
+		t.Error("template not deleted")
+	}
+	if tasks, _ := ListTasks(app.DB, newID); len(tasks) != 2 {
+		t.Error("deleting the template touched the event made from it")
+	}
+}
@@ -0,0 +1,232 @@
+package main
+
+// Event themes. An event can have its own primary color, logo and a snippet
+// of CSS, applied to its public pages in place of the instance's, so that
+// organizations sharing one instance each keep their look. Themes live apart
+// from the events table because of the logo, stored in the database like
+// everything else so that backups carry it, and served at /e/<slug>/logo.
+
+import (
+	"database/sql"
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// EventTheme is an event's own look. Empty fields leave the instance's.
+type EventTheme struct {
+	EventID   int64
+	Color     string // "#RRGGBB"
+	CSS       string
+	LogoType  string // MIME type of the logo, "" when there is none
+	UpdatedAt string
+	// LogoURL is where the logo is served, set by pageTheme.
+	LogoURL string
+}
+
+// Limits on what a theme carries.
+const (
+	maxEventLogoSize = 1 << 20
+	maxEventCSSLen   = 20000
+)
+
+// eventLogoTypes are the image types accepted as logos. SVG is left out: it
+// can carry scripts.
+var eventLogoTypes = []string{"image/png", "image/jpeg", "image/gif", "image/webp"}
+
+// Colors returns the shades of t's color, nil when it has none.
+func (t *EventTheme) Colors() *ThemeColors {
+	if t.Color == "" {
+		return nil
+	}
+	return themeShades(t.Color)
+}
+
+// StyleSheet is t's CSS, for a <style> element. normalizeEventCSS keeps it
+// from closing the element.
+func (t *EventTheme) StyleSheet() template.CSS {
+	return template.CSS(t.CSS)
+}
+
+// normalizeEventCSS readies CSS typed by an admin for a <style> element: "<"
+// has no use in CSS outside strings, where its escape means the same. Too
+// long CSS is cut on a character boundary before escaping, so the cut can
+// split neither a character nor an escape.
+func normalizeEventCSS(css string) string {
+	css = strings.TrimSpace(css)
+	if len(css) > maxEventCSSLen {
+		cut := maxEventCSSLen
+		for cut > 0 && !utf8.RuneStart(css[cut]) {
+			cut--
+		}
+		css = css[:cut]
+	}
+	return strings.ReplaceAll(css, "<", `\3c `)
+}
+
+// GetEventTheme returns event eventID's theme, sql.ErrNoRows when it has
+// none.
+func GetEventTheme(db *sql.DB, eventID int64) (*EventTheme, error) {
+	t := &EventTheme{}
+	err := db.QueryRow("SELECT event_id, color, css, logo_type, updated_at FROM event_themes WHERE event_id=?", eventID).
+		Scan(&t.EventID, &t.Color, &t.CSS, &t.LogoType, &t.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// SaveEventTheme sets the color and CSS of event eventID's theme, keeping
+// its logo.
+func SaveEventTheme(db *sql.DB, eventID int64, color, css string) error {
+	_, err := db.Exec(
+		`INSERT INTO event_themes (event_id, color, css) VALUES (?, ?, ?)
+			ON CONFLICT(event_id) DO UPDATE SET color=excluded.color, css=excluded.css, updated_at=CURRENT_TIMESTAMP`,
+		eventID, color, css,
+	)
+	return err
+}
+
+// SetEventLogo gives event eventID the logo data of type mimeType, or takes
+// its logo away when data is nil.
+func SetEventLogo(db *sql.DB, eventID int64, data []byte, mimeType string) error {
+	if data == nil {
+		mimeType = ""
+	}
+	_, err := db.Exec(
+		`INSERT INTO event_themes (event_id, logo, logo_type) VALUES (?, ?, ?)
+			ON CONFLICT(event_id) DO UPDATE SET logo=excluded.logo, logo_type=excluded.logo_type, updated_at=CURRENT_TIMESTAMP`,
+		eventID, data, mimeType,
+	)
+	return err
+}
+
+// GetEventLogo returns event eventID's logo and its type, sql.ErrNoRows
+// when it has none.
+func GetEventLogo(db *sql.DB, eventID int64) ([]byte, string, error) {
+	var data []byte
+	var mimeType string
+	err := db.QueryRow("SELECT logo, logo_type FROM event_themes WHERE event_id=? AND logo_type != ''", eventID).Scan(&data, &mimeType)
+	return data, mimeType, err
+}
+
+// ReplaceEventTheme gives event toID the theme of event fromID, or none
+// when fromID has none.
+func ReplaceEventTheme(db *sql.DB, fromID, toID int64) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec("DELETE FROM event_themes WHERE event_id=?", toID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(
+		"INSERT INTO event_themes (event_id, color, css, logo, logo_type) SELECT ?, color, css, logo, logo_type FROM event_themes WHERE event_id=?",
+		toID, fromID,
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// pageTheme returns the theme event's public pages show, nil when it has
+// none.
+func (app *App) pageTheme(event *Event) *EventTheme {
+	t, err := GetEventTheme(app.DB, event.ID)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Printf("theme of event %d: %v", event.ID, err)
+		}
+		return nil
+	}
+	if t.LogoType != "" {
+		// The save time in the URL lets browsers keep the logo until it
+		// changes.
+		t.LogoURL = "/e/" + event.Slug + "/logo?v=" + strings.NewReplacer(" ", "", ":", "", "-", "").Replace(t.UpdatedAt)
+	}
+	return t
+}
+
+// handleEventLogo serves event's logo.
+func (app *App) handleEventLogo(w http.ResponseWriter, r *http.Request, event *Event) {
+	data, mimeType, err := GetEventLogo(app.DB, event.ID)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", mimeType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	w.Write(data)
+}
+
+// handleAdminEventTheme saves an event's theme from its edit page.
+func (app *App) handleAdminEventTheme(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/admin", http.StatusSeeOther)
+		return
+	}
+	lang := LangFromRequest(r)
+	id, _ := strconv.ParseInt(r.FormValue("event_id"), 10, 64)
+	event, err := GetEvent(app.DB, id)
+	if err != nil {
+		http.Redirect(w, r, "/admin", http.StatusSeeOther)
+		return
+	}
+	done := func(kind, msg string) {
+		setFlash(w, kind, msg)
+		http.Redirect(w, r, fmt.Sprintf("/admin/event/edit?id=%d&lang=%s#event-theme", event.ID, lang), http.StatusSeeOther)
+	}
+
+	// The color picker always submits a color; the instance's counts as
+	// none, so the event follows later changes to it.
+	color := strings.TrimSpace(r.FormValue("color"))
+	if strings.EqualFold(color, themeColor()) {
+		color = ""
+	}
+	if color != "" && !themeColorRe.MatchString(color) {
+		done("error", T("instance_error_theme_color", lang))
+		return
+	}
+	var logo []byte
+	var logoType string
+	if file, header, ferr := r.FormFile("file"); ferr == nil {
+		defer file.Close()
+		if header.Size > maxEventLogoSize {
+			done("error", T("event_theme_logo_too_big", lang))
+			return
+		}
+		logo, err = io.ReadAll(io.LimitReader(file, maxEventLogoSize))
+		if err != nil {
+			log.Printf("theme of event %d: read logo: %v", event.ID, err)
+			done("error", T("error_server", lang))
+			return
+		}
+		logoType = http.DetectContentType(logo)
+		if !slices.Contains(eventLogoTypes, logoType) {
+			done("error", T("event_theme_logo_bad_type", lang))
+			return
+		}
+	}
+
+	if err := SaveEventTheme(app.DB, event.ID, color, normalizeEventCSS(r.FormValue("css"))); err != nil {
+		log.Printf("theme of event %d: %v", event.ID, err)
+		done("error", T("error_server", lang))
+		return
+	}
+	if logo != nil || r.FormValue("logo_remove") != "" {
+		if err := SetEventLogo(app.DB, event.ID, logo, logoType); err != nil {
+			log.Printf("theme of event %d: logo: %v", event.ID, err)
+			done("error", T("error_server", lang))
+			return
+		}
+	}
+	done("success", T("event_theme_saved", lang))
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"net/url"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestEventTheme(t *testing.T) {
+	app := testApp(t)
+	mux := newMux(app)
+	admin := adminCookie(app)
+	e := seedEvent(t, app.DB)
+	other := &Event{TitleFR: "Autre", EventDate: "2026-07-01"}
+	CreateEvent(app.DB, other)
+
+	var logo bytes.Buffer
+	png.Encode(&logo, image.NewRGBA(image.Rect(0, 0, 40, 20)))
+	w := postMultipart(mux, "/admin/event/theme?lang=en", "logo.png", logo.String(), map[string]string{
+		"event_id": fmt.Sprint(e.ID),
+		"color":    "#0f766e",
+		"css":      ".event-header { color: red; }</style><script>alert(1)</script>",
+	}, admin)
+	if w.Code != 303 {
+		t.Fatalf("save: status %d", w.Code)
+	}
+
+	body := getRequest(mux, "/e/"+e.Slug+"?lang=en").Body.String()
+	theme, err := GetEventTheme(app.DB, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"--color-primary: #0f766e;", `<meta name="theme-color" content="#0f766e">`, ".event-header { color: red; }", "/e/" + e.Slug + "/logo?v="} {
+		if !strings.Contains(body, want) {
+			t.Errorf("public page misses %s", want)
+		}
+	}
+	if strings.Contains(body, "<script>alert(1)") || strings.Contains(body, "</style><script>") {
+		t.Error("custom CSS closed its style element")
+	}
+	if theme.LogoType != "image/png" {
+		t.Errorf("logo type %q", theme.LogoType)
+	}
+	if w := getRequest(mux, "/e/"+e.Slug+"/logo"); w.Code != 200 || w.Header().Get("Content-Type") != "image/png" || w.Body.Len() != logo.Len() {
+		t.Errorf("logo: status %d, type %q", w.Code, w.Header().Get("Content-Type"))
+	}
+
+	// Other events keep the instance's look.
+	if body := getRequest(mux, "/e/"+other.Slug).Body.String(); strings.Contains(body, "#0f766e") || strings.Contains(body, "/logo?v=") {
+		t.Error("theme leaked to another event")
+	}
+	if w := getRequest(mux, "/e/"+other.Slug+"/logo"); w.Code != 404 {
+		t.Errorf("logo of an event without one: status %d", w.Code)
+	}
+
+	// Only images are taken as logos; a duplicate keeps the theme.
+	postMultipart(mux, "/admin/event/theme?lang=en", "logo.svg", `<svg xmlns="http://www.w3.org/2000/svg"><script>alert(1)</script></svg>`,
+		map[string]string{"event_id": fmt.Sprint(other.ID)}, admin)
+	if _, _, err := GetEventLogo(app.DB, other.ID); err == nil {
+		t.Error("SVG taken as a logo")
+	}
+	postForm(mux, "/admin/event/duplicate?lang=en", url.Values{"id": {fmt.Sprint(e.ID)}, "event_date": {"2026-09-01"}}, admin)
+	var dupID int64
+	app.DB.QueryRow("SELECT id FROM events WHERE event_date='2026-09-01'").Scan(&dupID)
+	if dup, err := GetEventTheme(app.DB, dupID); err != nil || dup.Color != "#0f766e" || dup.LogoType != "image/png" {
+		t.Errorf("duplicate theme = %+v, %v", dup, err)
+	}
+
+	// Removing the logo and picking the instance's color clear them.
+	postMultipart(mux, "/admin/event/theme?lang=en", "", "", map[string]string{
+		"event_id": fmt.Sprint(e.ID), "color": themeColor(), "logo_remove": "1",
+	}, admin)
+	if theme, _ := GetEventTheme(app.DB, e.ID); theme.Color != "" || theme.LogoType != "" {
+		t.Errorf("after clearing: %+v", theme)
+	}
+}
+
+func TestNormalizeEventCSS(t *testing.T) {
+	// Two-byte characters from an odd offset: the limit falls inside one.
+	long := "/* " + strings.Repeat("é", maxEventCSSLen) + " */"
+	if css := normalizeEventCSS(long); !utf8.ValidString(css) || len(css) != maxEventCSSLen-1 {
+		t.Errorf("cut to %d bytes, valid UTF-8: %v", len(css), utf8.ValidString(css))
+	}
+	// A "<" right at the limit is escaped whole.
+	long = long[:maxEventCSSLen-1] + "<" + long[maxEventCSSLen-1:]
+	if css := normalizeEventCSS(long); !strings.HasSuffix(css, `\3c `) || strings.Contains(css, "<") {
+		t.Errorf("cut ends with %q", css[len(css)-8:])
+	}
+	if css := normalizeEventCSS(" a::after { content: '<' } "); css != `a::after { content: '\3c ' }` {
+		t.Errorf("short CSS = %q", css)
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+// Family signup. On events that allow it, whoever signs up can add other
+// people — typically their family — each with a task of their own. They are
+// registered under the contact's email and phone, told apart by their member
+// number, so each has their own limits (tasks per person, task rules) and
+// counts once toward the event's participant cap. Everything goes to the
+// contact's address: a confirmation per person, each with its cancel link.
+// When one of them can't be signed up, none of the signup is kept.
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// maxFamilyMembers bounds how many people one signup adds.
+const maxFamilyMembers = 8
+
+// FamilyMember is someone added to a signup, with the task they chose.
+type FamilyMember struct {
+	FirstName string
+	LastName  string
+	TaskID    int64
+}
+
+// MemberSignup is a family member's registration, with its task.
+type MemberSignup struct {
+	Reg  *Registration
+	Task *Task
+}
+
+// parseFamilyMembers reads the people added to a signup form: rows left
+// without a first name are skipped, a missing last name is the contact's.
+// ok is false when a row has none of tasks, or there are too many.
+func parseFamilyMembers(r *http.Request, tasks []Task, contactLastName string) (members []FamilyMember, ok bool) {
+	firsts, lasts, taskIDs := r.Form["member_first_name"], r.Form["member_last_name"], r.Form["member_task_id"]
+	for i, first := range firsts {
+		first = strings.TrimSpace(first)
+		if first == "" {
+			continue
+		}
+		m := FamilyMember{FirstName: first, LastName: contactLastName}
+		if i < len(lasts) && strings.TrimSpace(lasts[i]) != "" {
+			m.LastName = strings.TrimSpace(lasts[i])
+		}
+		if i < len(taskIDs) {
+			m.TaskID, _ = strconv.ParseInt(taskIDs[i], 10, 64)
+		}
+		known := false
+		for _, t := range tasks {
+			known = known || t.ID == m.TaskID
+		}
+		if !known {
+			return nil, false
+		}
+		members = append(members, m)
+	}
+	return members, len(members) <= maxFamilyMembers
+}
+
+// RegisterFamilyMember signs member number member of email's family up for
+// task taskID; with reserved, they can take its reserved slots.
+func RegisterFamilyMember(db *sql.DB, taskID int64, member int, reserved bool, firstName, lastName, email, phone, lang string) (*Registration, error) {
+	access := publicAccess
+	if reserved {
+		access = reservedAccess
+	}
+	return registerGroupForTask(db, taskID, 0, member, access, firstName, lastName, email, phone, lang)
+}
+
+// nextFamilyMember returns the first member number after those email's
+// registrations for event eventID use.
+func nextFamilyMember(db *sql.DB, eventID int64, email string) int {
+	var n int
+	db.QueryRow("SELECT COALESCE(MAX(member), 0) + 1 FROM registrations WHERE event_id=? AND LOWER(email)=LOWER(?) AND trash_id IS NULL", eventID, email).Scan(&n)
+	return n
+}
+
+// registerFamily signs members up along with contact. When one of them
+// can't be, it takes the whole signup back, contact included, and errMsg
+// tells why.
+func (app *App) registerFamily(event *Event, contact *Registration, members []FamilyMember, reserved bool, lang string) (signups []MemberSignup, errMsg string) {
+	next := nextFamilyMember(app.DB, event.ID, contact.Email)
+	for i, m := range members {
+		reg, err := RegisterFamilyMember(app.DB, m.TaskID, next+i, reserved, m.FirstName, m.LastName, contact.Email, contact.Phone, contact.Lang)
+		if err != nil {
+			DeleteRegistrationByToken(app.DB, contact.Token)
+			for _, s := range signups {
+				DeleteRegistrationByToken(app.DB, s.Reg.Token)
+			}
+			return nil, fmt.Sprintf(T("family_member_error", lang), m.FirstName, memberSignupError(err, lang))
+		}
+		task, _ := GetTask(app.DB, m.TaskID)
+		signups = append(signups, MemberSignup{Reg: reg, Task: task})
+	}
+	return signups, ""
+}
+
+// memberSignupError tells why a family member couldn't be signed up.
+func memberSignupError(err error, lang string) string {
+	var ruleErr *taskRuleError
+	switch {
+	case errors.As(err, &ruleErr):
+		return ruleErr.message(lang)
+	case errors.Is(err, errEventFull):
+		return T("error_event_full", lang)
+	case errors.Is(err, errGroupFull):
+		return T("error_group_capacity", lang)
+	case errors.Is(err, errAlreadyRegistered), errors.Is(err, errTaskLimit):
+		return T("already_registered", lang)
+	case strings.Contains(err.Error(), "task_full"):
+		return T("error_full", lang)
+	}
+	log.Printf("family signup error: %v", err)
+	return T("error_server", lang)
+}
+
+// memberIDs returns the registration IDs of signups.
+func memberIDs(signups []MemberSignup) []int64 {
+	ids := make([]int64, len(signups))
+	for i, s := range signups {
+		ids[i] = s.Reg.ID
+	}
+	return ids
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestFamilySignup(t *testing.T) {
+	app := testApp(t)
+	mux := newMux(app)
+	e := &Event{TitleFR: "Fête", EventDate: "2026-06-15", MaxTasksPerPerson: 1, FamilySignup: true}
+	if err := CreateEvent(app.DB, e); err != nil {
+		t.Fatal(err)
+	}
+	bar := seedTask(t, app.DB, e.ID, "Buvette", nil)
+	grill := &Task{EventID: e.ID, TitleFR: "Grill", MaxSlots: sql.NullInt64{Int64: 1, Valid: true}}
+	CreateTask(app.DB, grill)
+
+	signup := func(email string, members ...string) string {
+		v := url.Values{
+			"task_id": {fmt.Sprint(bar.ID)}, "first_name": {"Alice"}, "last_name": {"Dupont"},
+			"email": {email}, "phone": {"0601"},
+		}
+		for i := 0; i < len(members); i += 2 {
+			v.Add("member_first_name", members[i])
+			v.Add("member_last_name", "")
+			v.Add("member_task_id", members[i+1])
+		}
+		return postForm(mux, "/signup?lang=en", v).Body.String()
+	}
+	signup("alice@test.com", "Léo", fmt.Sprint(bar.ID), "Zoé", fmt.Sprint(grill.ID))
+	if n := CountEventParticipants(app.DB, e.ID); n != 3 {
+		t.Fatalf("participants = %d, want 3", n)
+	}
+	var last string
+	app.DB.QueryRow("SELECT last_name FROM registrations WHERE first_name='Léo'").Scan(&last)
+	if last != "Dupont" {
+		t.Errorf("member last name = %q, want the contact's", last)
+	}
+
+	// Nobody from a family is kept when one of them can't be signed up.
+	body := signup("bob@test.com", "Tom", fmt.Sprint(bar.ID), "Lou", fmt.Sprint(grill.ID))
+	if !strings.Contains(body, "Lou: "+T("error_full", "en")) {
+		t.Error("member on a full task not reported")
+	}
+	if n := CountEventParticipants(app.DB, e.ID); n != 3 {
+		t.Errorf("participants after a failed family signup = %d, want 3", n)
+	}
+	if body := signup("carol@test.com", "Max", "0"); !strings.Contains(body, T("error_invalid_form", "en")) {
+		t.Error("member without a task accepted")
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+// Fill levels. Tasks and groups with a slot limit show how full they are as
+// a bar, on the public page and the admin tree. The level follows the spots
+// left rather than MaxSlots alone, so a task held back by its group's cap or
+// by slots reserved for access code holders reads full when nobody can take
+// it anymore. A group without a cap of its own adds up those of its tasks
+// and subgroups.
+
+import "database/sql"
+
+// Fill is how far a task or group is taken.
+type Fill struct {
+	Taken    int `json:"taken"`
+	Capacity int `json:"capacity"`
+	Percent  int `json:"percent"`
+}
+
+// newFill returns the fill level of taken spots out of capacity.
+func newFill(taken, capacity int) *Fill {
+	f := &Fill{Taken: taken, Capacity: capacity, Percent: 100}
+	if capacity > 0 {
+		f.Percent = min(100, taken*100/capacity)
+	}
+	return f
+}
+
+// fillCapacity is the spots of a task or group with taken people and left
+// spots free, never more than its limit, even when an admin overbooked it.
+func fillCapacity(taken, left int, limit sql.NullInt64) int {
+	capacity := taken + left
+	if limit.Valid {
+		capacity = min(capacity, int(limit.Int64))
+	}
+	return capacity
+}
+
+// Fill returns v's fill level, nil when it has no limit.
+func (v *TaskView) Fill() *Fill {
+	if v.SlotsLeft < 0 {
+		return nil
+	}
+	return newFill(v.RegCount, fillCapacity(v.RegCount, v.SlotsLeft, v.MaxSlots))
+}
+
+// Fill returns n's fill level: its task's, its group's when capped, or that
+// of the limited tasks under it. It is nil when nothing under n is limited.
+func (n TreeNode) Fill() *Fill {
+	if n.Task != nil {
+		return n.Task.Fill()
+	}
+	if n.SlotsLeft >= 0 {
+		return newFill(n.RegCount, fillCapacity(n.RegCount, n.SlotsLeft, n.Group.MaxSlots))
+	}
+	var taken, capacity int
+	limited := false
+	for _, c := range n.Children {
+		if f := c.Fill(); f != nil {
+			// A task taken beyond its limit doesn't make up for an empty one.
+			taken += min(f.Taken, f.Capacity)
+			capacity += f.Capacity
+			limited = true
+		}
+	}
+	if !limited {
+		return nil
+	}
+	return newFill(taken, capacity)
+}
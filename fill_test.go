@@ -0,0 +1,64 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestFill(t *testing.T) {
+	app := testApp(t)
+	mux := newMux(app)
+	e := seedEvent(t, app.DB)
+	g := &TaskGroup{EventID: e.ID, TitleFR: "Cuisine", TitleEN: "Kitchen"}
+	CreateTaskGroup(app.DB, g)
+	in := sql.NullInt64{Int64: g.ID, Valid: true}
+	dishes := &Task{EventID: e.ID, GroupID: in, TitleFR: "Vaisselle", MaxSlots: sql.NullInt64{Int64: 4, Valid: true}, ReservedSlots: 2}
+	CreateTask(app.DB, dishes)
+	cooks := &Task{EventID: e.ID, GroupID: in, TitleFR: "Cuisiniers", MaxSlots: sql.NullInt64{Int64: 2, Valid: true}}
+	CreateTask(app.DB, cooks)
+	seedTask(t, app.DB, e.ID, "Buvette", nil)
+	for i := range 3 {
+		AddManualRegistration(app.DB, cooks.ID, 0, true, "Cook", fmt.Sprint(i), fmt.Sprintf("cook%d@test.com", i), "0601", "fr")
+	}
+	RegisterForTask(app.DB, dishes.ID, "Alice", "Dupont", "alice@test.com", "0601", "fr")
+
+	tree, err := BuildEventTree(app.DB, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	group, bar := tree[0], tree[1]
+	// The overbooked cooks count as full, not as making up for the dishes.
+	if f := group.Fill(); f == nil || *f != (Fill{Taken: 3, Capacity: 6, Percent: 50}) {
+		t.Errorf("group fill = %+v", f)
+	}
+	if f := group.Children[0].Fill(); f == nil || *f != (Fill{Taken: 1, Capacity: 4, Percent: 25}) {
+		t.Errorf("dishes fill = %+v", f)
+	}
+	if f := bar.Fill(); f != nil {
+		t.Errorf("unlimited task fill = %+v", f)
+	}
+
+	// The public page holds the reserved slots back.
+	body := getRequest(mux, "/e/"+e.Slug+"?lang=en").Body.String()
+	for _, want := range []string{`aria-valuenow="75" aria-valuemin="0" aria-valuemax="100" aria-label="3 of 4 spots taken"`, `aria-label="1 of 2 spots taken"`, `class="fill-bar fill-bar-full"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("public page misses %s", want)
+		}
+	}
+
+	// A cap on the group fills its tasks along with it.
+	g.MaxSlots = sql.NullInt64{Int64: 5, Valid: true}
+	UpdateTaskGroup(app.DB, g)
+	tree, _ = BuildEventTree(app.DB, e.ID)
+	if f := tree[0].Fill(); *f != (Fill{Taken: 4, Capacity: 5, Percent: 80}) {
+		t.Errorf("capped group fill = %+v", f)
+	}
+	if f := tree[0].Children[0].Fill(); *f != (Fill{Taken: 1, Capacity: 2, Percent: 50}) {
+		t.Errorf("dishes fill under the cap = %+v", f)
+	}
+	if w := getRequest(mux, fmt.Sprintf("/api/slots?event_id=%d", e.ID)); !strings.Contains(w.Body.String(), `"fill":{"taken":1,"capacity":2,"percent":50}`) {
+		t.Errorf("slots API: %s", w.Body.String())
+	}
+}
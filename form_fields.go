@@ -0,0 +1,350 @@
+package main
+
+// Custom signup fields: extra questions an event asks on its signup form,
+// after the name, email and phone every registration has — a T-shirt size,
+// a dietary restriction, whether someone has a car. Admins build the list
+// on the event edit page; the answers are stored per registration and shown
+// on the registrations page and in the CSV export. A field can also belong to
+// one task, like "Do you have a car?" for the transport task: it is asked
+// only of the people who pick that task.
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Kinds of signup fields.
+const (
+	fieldText     = "text"
+	fieldSelect   = "select"
+	fieldCheckbox = "checkbox"
+)
+
+// maxAnswerLen bounds the length of a text answer.
+const maxAnswerLen = 1000
+
+// FormField is a question added to an event's signup form.
+type FormField struct {
+	ID       int64
+	EventID  int64
+	TaskID   sql.NullInt64 // the task whose registrants are asked, if not all
+	Kind     string        // fieldText, fieldSelect or fieldCheckbox
+	LabelFR  string
+	LabelEN  string
+	Options  string // choices of a select, one per line
+	Required bool   // a required checkbox must be checked
+	Position int
+}
+
+// Choices returns the choices of a select field.
+func (f FormField) Choices() []string {
+	var choices []string
+	for _, line := range strings.Split(f.Options, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			choices = append(choices, line)
+		}
+	}
+	return choices
+}
+
+// InputName is the name of the field's input on the signup form.
+func (f FormField) InputName() string { return fmt.Sprintf("field_%d", f.ID) }
+
+// Display spells out an answer to the field.
+func (f FormField) Display(answer string) string {
+	if f.Kind == fieldCheckbox && answer != "" {
+		return "✓"
+	}
+	return answer
+}
+
+const formFieldCols = "id, event_id, task_id, kind, label_fr, label_en, options, required, position"
+
+func scanFormField(row interface{ Scan(...any) error }) (*FormField, error) {
+	var f FormField
+	if err := row.Scan(&f.ID, &f.EventID, &f.TaskID, &f.Kind, &f.LabelFR, &f.LabelEN, &f.Options, &f.Required, &f.Position); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+func GetFormField(db *sql.DB, id int64) (*FormField, error) {
+	return scanFormField(db.QueryRow("SELECT "+formFieldCols+" FROM form_fields WHERE id=?", id))
+}
+
+// ListFormFields returns the signup fields of event eventID in form order.
+func ListFormFields(db *sql.DB, eventID int64) ([]FormField, error) {
+	rows, err := db.Query("SELECT "+formFieldCols+" FROM form_fields WHERE event_id=? ORDER BY position, id", eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []FormField
+	for rows.Next() {
+		f, err := scanFormField(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *f)
+	}
+	return list, rows.Err()
+}
+
+// SaveFormField updates f, or adds it at the end of its event's form when
+// it has no id yet.
+func SaveFormField(db *sql.DB, f *FormField) error {
+	if f.ID > 0 {
+		_, err := db.Exec("UPDATE form_fields SET task_id=?, kind=?, label_fr=?, label_en=?, options=?, required=? WHERE id=?",
+			f.TaskID, f.Kind, f.LabelFR, f.LabelEN, f.Options, f.Required, f.ID)
+		return err
+	}
+	db.QueryRow("SELECT COALESCE(MAX(position), -1) + 1 FROM form_fields WHERE event_id=?", f.EventID).Scan(&f.Position)
+	res, err := db.Exec("INSERT INTO form_fields (event_id, task_id, kind, label_fr, label_en, options, required, position) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		f.EventID, f.TaskID, f.Kind, f.LabelFR, f.LabelEN, f.Options, f.Required, f.Position)
+	if err != nil {
+		return err
+	}
+	f.ID, _ = res.LastInsertId()
+	return nil
+}
+
+// DeleteFormField removes a field along with its answers.
+func DeleteFormField(db *sql.DB, id int64) error {
+	_, err := db.Exec("DELETE FROM form_fields WHERE id=?", id)
+	return err
+}
+
+// ReplaceFormFields gives event toID a copy of the signup fields of event
+// fromID in place of its own. It runs after ReplaceTaskStructure: the tasks
+// of toID are then copies of those of fromID, in the same order, and a field
+// asked of a task goes to that task's copy. Fields whose task has no copy
+// are left out.
+func ReplaceFormFields(db *sql.DB, fromID, toID int64) error {
+	fields, err := ListFormFields(db, fromID)
+	if err != nil {
+		return err
+	}
+	fromTasks, err := ListTasks(db, fromID)
+	if err != nil {
+		return err
+	}
+	toTasks, err := ListTasks(db, toID)
+	if err != nil {
+		return err
+	}
+	taskCopies := map[int64]int64{}
+	if len(fromTasks) == len(toTasks) {
+		for i, t := range fromTasks {
+			taskCopies[t.ID] = toTasks[i].ID
+		}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec("DELETE FROM form_fields WHERE event_id=?", toID); err != nil {
+		return err
+	}
+	for _, f := range fields {
+		if f.TaskID.Valid {
+			id, ok := taskCopies[f.TaskID.Int64]
+			if !ok {
+				continue
+			}
+			f.TaskID.Int64 = id
+		}
+		if _, err := tx.Exec("INSERT INTO form_fields (event_id, task_id, kind, label_fr, label_en, options, required, position) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+			toID, f.TaskID, f.Kind, f.LabelFR, f.LabelEN, f.Options, f.Required, f.Position); err != nil {
+			return fmt.Errorf("copy field %d: %w", f.ID, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// splitFormFields separates the fields asked of every registrant from those
+// asked of one task's, which it returns by task id.
+func splitFormFields(fields []FormField) (common []FormField, byTask map[int64][]FormField) {
+	byTask = map[int64][]FormField{}
+	for _, f := range fields {
+		if f.TaskID.Valid {
+			byTask[f.TaskID.Int64] = append(byTask[f.TaskID.Int64], f)
+		} else {
+			common = append(common, f)
+		}
+	}
+	return common, byTask
+}
+
+// fieldsForTask returns the fields asked of someone signing up for task
+// taskID.
+func fieldsForTask(fields []FormField, taskID int64) []FormField {
+	var asked []FormField
+	for _, f := range fields {
+		if !f.TaskID.Valid || f.TaskID.Int64 == taskID {
+			asked = append(asked, f)
+		}
+	}
+	return asked
+}
+
+// parseFormAnswers reads the answers to fields from a signup form. When one
+// is missing or invalid, it returns that field.
+func parseFormAnswers(r *http.Request, fields []FormField) (map[int64]string, *FormField) {
+	answers := make(map[int64]string)
+	for i, f := range fields {
+		v := strings.TrimSpace(r.FormValue(f.InputName()))
+		switch f.Kind {
+		case fieldCheckbox:
+			if v != "" {
+				v = "1"
+			}
+		case fieldSelect:
+			if v != "" && !slices.Contains(f.Choices(), v) {
+				return nil, &fields[i]
+			}
+		default:
+			if utf8.RuneCountInString(v) > maxAnswerLen {
+				return nil, &fields[i]
+			}
+		}
+		if v == "" && f.Required {
+			return nil, &fields[i]
+		}
+		if v != "" {
+			answers[f.ID] = v
+		}
+	}
+	return answers, nil
+}
+
+// SaveRegistrationAnswers stores the answers of registration regID, keyed by
+// field id.
+func SaveRegistrationAnswers(db *sql.DB, regID int64, answers map[int64]string) error {
+	if len(answers) == 0 {
+		return nil
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for fieldID, value := range answers {
+		if _, err := tx.Exec("INSERT OR REPLACE INTO registration_answers (registration_id, field_id, value) VALUES (?, ?, ?)",
+			regID, fieldID, value); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ListRegistrationAnswers returns the answers of event eventID's
+// registrations, by registration id and then field id.
+func ListRegistrationAnswers(db *sql.DB, eventID int64) (map[int64]map[int64]string, error) {
+	rows, err := db.Query(`SELECT a.registration_id, a.field_id, a.value
+		FROM registration_answers a JOIN form_fields f ON f.id = a.field_id
+		WHERE f.event_id = ?`, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	answers := make(map[int64]map[int64]string)
+	for rows.Next() {
+		var regID, fieldID int64
+		var value string
+		if err := rows.Scan(&regID, &fieldID, &value); err != nil {
+			return nil, err
+		}
+		if answers[regID] == nil {
+			answers[regID] = make(map[int64]string)
+		}
+		answers[regID][fieldID] = value
+	}
+	return answers, rows.Err()
+}
+
+// ---- Admin ----
+
+func (app *App) handleAdminFormFieldSave(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/admin", http.StatusSeeOther)
+		return
+	}
+	lang := LangFromRequest(r)
+	eventID, _ := strconv.ParseInt(r.FormValue("event_id"), 10, 64)
+	id, _ := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	editURL := fmt.Sprintf("/admin/event/edit?id=%d&lang=%s#form-fields", eventID, lang)
+	if _, err := GetEvent(app.DB, eventID); err != nil {
+		http.Redirect(w, r, "/admin", http.StatusSeeOther)
+		return
+	}
+	if id > 0 {
+		if f, err := GetFormField(app.DB, id); err != nil || f.EventID != eventID {
+			http.Redirect(w, r, editURL, http.StatusSeeOther)
+			return
+		}
+	}
+	var taskID sql.NullInt64
+	if tid, _ := strconv.ParseInt(r.FormValue("task_id"), 10, 64); tid > 0 {
+		if t, err := GetTask(app.DB, tid); err != nil || t.EventID != eventID {
+			http.Redirect(w, r, editURL, http.StatusSeeOther)
+			return
+		}
+		taskID = sql.NullInt64{Int64: tid, Valid: true}
+	}
+	f := &FormField{
+		ID: id, EventID: eventID, TaskID: taskID,
+		Kind:     r.FormValue("kind"),
+		LabelFR:  strings.TrimSpace(r.FormValue("label_fr")),
+		LabelEN:  strings.TrimSpace(r.FormValue("label_en")),
+		Options:  strings.TrimSpace(strings.ReplaceAll(r.FormValue("options"), "\r\n", "\n")),
+		Required: r.FormValue("required") != "",
+	}
+	if f.Kind != fieldSelect {
+		f.Options = ""
+	}
+	switch {
+	case f.Kind != fieldText && f.Kind != fieldSelect && f.Kind != fieldCheckbox:
+		http.Redirect(w, r, editURL, http.StatusSeeOther)
+		return
+	case f.LabelFR == "":
+		setFlash(w, "error", T("form_field_error_label", lang))
+		http.Redirect(w, r, editURL, http.StatusSeeOther)
+		return
+	case f.Kind == fieldSelect && len(f.Choices()) == 0:
+		setFlash(w, "error", T("form_field_error_options", lang))
+		http.Redirect(w, r, editURL, http.StatusSeeOther)
+		return
+	}
+	if err := SaveFormField(app.DB, f); err != nil {
+		log.Printf("form fields: save for event %d: %v", eventID, err)
+		setFlash(w, "error", T("error_server", lang))
+	} else {
+		setFlash(w, "success", T("form_field_saved", lang))
+	}
+	http.Redirect(w, r, editURL, http.StatusSeeOther)
+}
+
+func (app *App) handleAdminFormFieldDelete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/admin", http.StatusSeeOther)
+		return
+	}
+	lang := LangFromRequest(r)
+	eventID, _ := strconv.ParseInt(r.FormValue("event_id"), 10, 64)
+	id, _ := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	if f, err := GetFormField(app.DB, id); err == nil && f.EventID == eventID {
+		if err := DeleteFormField(app.DB, id); err != nil {
+			log.Printf("form fields: delete %d: %v", id, err)
+			setFlash(w, "error", T("error_server", lang))
+		}
+	}
+	http.Redirect(w, r, fmt.Sprintf("/admin/event/edit?id=%d&lang=%s#form-fields", eventID, lang), http.StatusSeeOther)
+}
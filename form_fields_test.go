@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestFormFields(t *testing.T) {
+	app := testApp(t)
+	mux := newMux(app)
+	admin := adminCookie(app)
+	e := seedEvent(t, app.DB)
+	tk := seedTask(t, app.DB, e.ID, "Cuisine", nil)
+
+	add := func(kind, label, options, required string) {
+		t.Helper()
+		postForm(mux, "/admin/event/fields/save?lang=en", url.Values{
+			"event_id": {fmt.Sprint(e.ID)}, "kind": {kind}, "label_fr": {label},
+			"options": {options}, "required": {required},
+		}, admin)
+	}
+	add("select", "Taille de T-shirt", "S\r\nM\r\nL", "1")
+	add("text", "Régime alimentaire", "", "")
+	add("checkbox", "J'ai une voiture", "", "")
+	add("select", "Vide", "", "") // a choice list without choices is refused
+	fields, _ := ListFormFields(app.DB, e.ID)
+	if len(fields) != 3 || fields[0].Kind != fieldSelect || len(fields[0].Choices()) != 3 {
+		t.Fatalf("fields = %+v", fields)
+	}
+	size, diet, car := fields[0], fields[1], fields[2]
+	edit := getRequest(mux, fmt.Sprintf("/admin/event/edit?id=%d&lang=en", e.ID), admin).Body.String()
+	if !strings.Contains(edit, `name="label_fr" value="Taille de T-shirt"`) {
+		t.Error("edit page lacks the fields")
+	}
+
+	body := getRequest(mux, "/e/"+e.Slug).Body.String()
+	if !strings.Contains(body, `name="`+size.InputName()+`"`) || !strings.Contains(body, "<option>M</option>") {
+		t.Error("signup form lacks the extra fields")
+	}
+
+	signup := func(answers map[string]string) *httptest.ResponseRecorder {
+		form := url.Values{
+			"task_id": {fmt.Sprint(tk.ID)}, "first_name": {"Alice"}, "last_name": {"Dupont"},
+			"email": {"alice@test.com"}, "phone": {"0601"},
+		}
+		for k, v := range answers {
+			form.Set(k, v)
+		}
+		return postForm(mux, "/signup?lang=fr", form)
+	}
+	want := template.HTMLEscapeString(fmt.Sprintf(T("form_field_error_answer", "fr"), size.LabelFR))
+	if body := signup(nil).Body.String(); !strings.Contains(body, want) {
+		t.Error("missing required answer accepted")
+	}
+	if body := signup(map[string]string{size.InputName(): "XXL"}).Body.String(); !strings.Contains(body, want) {
+		t.Error("answer outside the choices accepted")
+	}
+	signup(map[string]string{size.InputName(): "M", diet.InputName(): "Végétarien", car.InputName(): "on"})
+	if CountRegistrations(app.DB, e.ID) != 1 {
+		t.Fatal("registration with answers refused")
+	}
+
+	w := httptest.NewRecorder()
+	app.handleAdminRegistrations(w, httptest.NewRequest("GET", fmt.Sprintf("/admin/event/registrations?id=%d", e.ID), nil))
+	for _, s := range []string{"Taille de T-shirt", "Végétarien", "✓"} {
+		if !strings.Contains(w.Body.String(), s) {
+			t.Errorf("registrations page lacks %q", s)
+		}
+	}
+	csv := getRequest(mux, fmt.Sprintf("/admin/export?event_id=%d", e.ID), admin).Body.String()
+	if !strings.Contains(csv, "Régime alimentaire,J'ai une voiture") || !strings.Contains(csv, ",M,Végétarien,✓") {
+		t.Errorf("CSV lacks the answers:\n%s", csv)
+	}
+
+	// Duplicates get the questions, not the answers.
+	postForm(mux, "/admin/event/duplicate?lang=en", url.Values{"id": {fmt.Sprint(e.ID)}, "event_date": {"2026-07-01"}}, admin)
+	events, _ := ListEvents(app.DB)
+	var dup *Event
+	for i := range events {
+		if events[i].ID != e.ID {
+			dup = &events[i]
+		}
+	}
+	if dup == nil {
+		t.Fatal("event not duplicated")
+	}
+	if copied, _ := ListFormFields(app.DB, dup.ID); len(copied) != 3 || copied[1].LabelFR != "Régime alimentaire" {
+		t.Errorf("duplicate fields = %+v", copied)
+	}
+
+	AnonymizeEvent(app.DB, e.ID)
+	if answers, _ := ListRegistrationAnswers(app.DB, e.ID); len(answers) != 0 {
+		t.Errorf("answers left after anonymization: %v", answers)
+	}
+
+	postForm(mux, "/admin/event/fields/delete?lang=en", url.Values{"event_id": {fmt.Sprint(e.ID)}, "id": {fmt.Sprint(diet.ID)}}, admin)
+	if fields, _ := ListFormFields(app.DB, e.ID); len(fields) != 2 {
+		t.Errorf("%d fields after deleting one", len(fields))
+	}
+}
+
+func TestTaskFormFields(t *testing.T) {
+	app := testApp(t)
+	mux := newMux(app)
+	admin := adminCookie(app)
+	e := seedEvent(t, app.DB)
+	kitchen := seedTask(t, app.DB, e.ID, "Cuisine", nil)
+	transport := seedTask(t, app.DB, e.ID, "Transport", nil)
+
+	postForm(mux, "/admin/event/fields/save?lang=en", url.Values{
+		"event_id": {fmt.Sprint(e.ID)}, "task_id": {fmt.Sprint(transport.ID)},
+		"kind": {"checkbox"}, "label_fr": {"J'ai une voiture"}, "required": {"1"},
+	}, admin)
+	fields, _ := ListFormFields(app.DB, e.ID)
+	if len(fields) != 1 || fields[0].TaskID.Int64 != transport.ID {
+		t.Fatalf("fields = %+v", fields)
+	}
+	car := fields[0]
+	body := getRequest(mux, "/e/"+e.Slug).Body.String()
+	if !strings.Contains(body, fmt.Sprintf(`data-questions-for="%d"`, transport.ID)) || !strings.Contains(body, car.InputName()) {
+		t.Error("signup form lacks the task question")
+	}
+
+	signup := func(taskID int64, email string, answers url.Values) {
+		form := url.Values{
+			"task_id": {fmt.Sprint(taskID)}, "first_name": {"Alice"}, "last_name": {"Dupont"},
+			"email": {email}, "phone": {"0601"},
+		}
+		for k, v := range answers {
+			form[k] = v
+		}
+		postForm(mux, "/signup?lang=fr", form)
+	}
+	signup(kitchen.ID, "a@test.com", nil)
+	if CountRegistrations(app.DB, e.ID) != 1 {
+		t.Fatal("another task's question was required")
+	}
+	signup(transport.ID, "b@test.com", nil)
+	if CountRegistrations(app.DB, e.ID) != 1 {
+		t.Fatal("task question left unanswered")
+	}
+	signup(transport.ID, "b@test.com", url.Values{car.InputName(): {"1"}})
+	if CountRegistrations(app.DB, e.ID) != 2 {
+		t.Fatal("answered task question refused")
+	}
+
+	// Fields of a task follow it to the copies of the event.
+	dup := &Event{EventDate: "2026-07-01", Slug: "copy"}
+	if err := CreateEvent(app.DB, dup); err != nil {
+		t.Fatal(err)
+	}
+	ReplaceTaskStructure(app.DB, e.ID, dup.ID)
+	if err := ReplaceFormFields(app.DB, e.ID, dup.ID); err != nil {
+		t.Fatal(err)
+	}
+	tasks, _ := ListTasks(app.DB, dup.ID)
+	copied, _ := ListFormFields(app.DB, dup.ID)
+	if len(copied) != 1 || len(tasks) != 2 || copied[0].TaskID.Int64 != tasks[1].ID {
+		t.Errorf("copied fields = %+v, tasks = %+v", copied, tasks)
+	}
+}
@@ -0,0 +1,975 @@
+package main
+
+// Read-only GraphQL endpoint for reports. /admin/api/graphql answers queries
+// over events, their groups, tasks, registrations and attendances, so a
+// report of any shape is one query rather than a new endpoint. It
+// implements the part of GraphQL such queries use: fields, aliases,
+// arguments, variables, fragments and __typename. There are no mutations —
+// the REST API (api_v1.go) writes — so read tokens may POST here too.
+//
+//	{ events(from: "2026-01-01", type: "tasks") {
+//	    titleFr date peopleCount
+//	    tasks { titleFr peopleCount slotsLeft registrations { firstName email } } } }
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ---- Parsing ----
+
+// gqlSelection is a field of a selection set, or a fragment in it.
+type gqlSelection struct {
+	alias    string
+	name     string
+	args     map[string]any // literal values, gqlVariable for $variables
+	children []gqlSelection
+	// spread names a fragment (...Name); inline marks an inline fragment
+	// (... on Type { }), whose children are its selection.
+	spread string
+	inline bool
+	on     string
+}
+
+// key is the name of the selection's entry in the result.
+func (s gqlSelection) key() string {
+	if s.alias != "" {
+		return s.alias
+	}
+	return s.name
+}
+
+// gqlVariable is a $variable used as an argument value.
+type gqlVariable string
+
+// gqlDocument is a parsed query document.
+type gqlDocument struct {
+	operations map[string][]gqlSelection // by name, "" for an anonymous one
+	fragments  map[string]gqlSelection
+}
+
+type gqlParser struct {
+	src string
+	pos int
+	tok string // the current token; "" at the end
+	str bool   // whether tok is a string literal, unquoted
+}
+
+// parseGraphQL parses a query document.
+func parseGraphQL(src string) (*gqlDocument, error) {
+	p := &gqlParser{src: src}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	doc := &gqlDocument{operations: map[string][]gqlSelection{}, fragments: map[string]gqlSelection{}}
+	for p.tok != "" {
+		if err := p.definition(doc); err != nil {
+			return nil, err
+		}
+	}
+	if len(doc.operations) == 0 {
+		return nil, fmt.Errorf("no query in the document")
+	}
+	if err := doc.checkFragmentCycles(); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// checkFragmentCycles fails when fragments spread each other in a cycle:
+// their selection would never end.
+func (doc *gqlDocument) checkFragmentCycles() error {
+	const walking, done = 1, 2
+	state := map[string]int{}
+	var walk func(name string) error
+	var walkSels func(sels []gqlSelection) error
+	walk = func(name string) error {
+		switch state[name] {
+		case walking:
+			return fmt.Errorf("fragment %s spreads itself", name)
+		case done:
+			return nil
+		}
+		state[name] = walking
+		if err := walkSels(doc.fragments[name].children); err != nil {
+			return err
+		}
+		state[name] = done
+		return nil
+	}
+	walkSels = func(sels []gqlSelection) error {
+		for _, s := range sels {
+			if _, ok := doc.fragments[s.spread]; ok {
+				if err := walk(s.spread); err != nil {
+					return err
+				}
+			}
+			if err := walkSels(s.children); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for name := range doc.fragments {
+		if err := walk(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *gqlParser) definition(doc *gqlDocument) error {
+	switch {
+	case p.tok == "{":
+		sel, err := p.selectionSet()
+		if err != nil {
+			return err
+		}
+		doc.operations[""] = sel
+		return nil
+	case p.tok == "query" && !p.str:
+		if err := p.next(); err != nil {
+			return err
+		}
+		name := ""
+		if isGQLName(p.tok) && !p.str {
+			name = p.tok
+			if err := p.next(); err != nil {
+				return err
+			}
+		}
+		if p.tok == "(" {
+			// Variable definitions: their values come with the request,
+			// and are checked where used.
+			if err := p.skipBalanced("(", ")"); err != nil {
+				return err
+			}
+		}
+		if p.tok == "@" {
+			return fmt.Errorf("directives are not supported")
+		}
+		sel, err := p.selectionSet()
+		if err != nil {
+			return err
+		}
+		doc.operations[name] = sel
+		return nil
+	case p.tok == "fragment" && !p.str:
+		if err := p.next(); err != nil {
+			return err
+		}
+		name := p.tok
+		if !isGQLName(name) || p.str {
+			return fmt.Errorf("expected a fragment name, found %q", name)
+		}
+		if err := p.next(); err != nil {
+			return err
+		}
+		if p.tok != "on" {
+			return fmt.Errorf("expected \"on\" after fragment %s", name)
+		}
+		if err := p.next(); err != nil {
+			return err
+		}
+		on := p.tok
+		if err := p.next(); err != nil {
+			return err
+		}
+		sel, err := p.selectionSet()
+		if err != nil {
+			return err
+		}
+		doc.fragments[name] = gqlSelection{inline: true, on: on, children: sel}
+		return nil
+	case p.tok == "mutation" || p.tok == "subscription":
+		return fmt.Errorf("only queries are supported, not %ss", p.tok)
+	}
+	return fmt.Errorf("unexpected %q", p.tok)
+}
+
+func (p *gqlParser) expect(tok string) error {
+	if p.tok != tok || p.str {
+		if p.tok == "" {
+			return fmt.Errorf("expected %q, found the end of the query", tok)
+		}
+		return fmt.Errorf("expected %q, found %q", tok, p.tok)
+	}
+	return p.next()
+}
+
+func (p *gqlParser) skipBalanced(open, close string) error {
+	depth := 0
+	for {
+		switch {
+		case p.tok == "":
+			return fmt.Errorf("unbalanced %q", open)
+		case p.tok == open && !p.str:
+			depth++
+		case p.tok == close && !p.str:
+			depth--
+		}
+		if err := p.next(); err != nil {
+			return err
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+func (p *gqlParser) selectionSet() ([]gqlSelection, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	var sels []gqlSelection
+	for p.tok != "}" || p.str {
+		if p.tok == "" {
+			return nil, fmt.Errorf("expected \"}\", found the end of the query")
+		}
+		s, err := p.selection()
+		if err != nil {
+			return nil, err
+		}
+		sels = append(sels, s)
+	}
+	if len(sels) == 0 {
+		return nil, fmt.Errorf("empty selection")
+	}
+	return sels, p.next()
+}
+
+func (p *gqlParser) selection() (gqlSelection, error) {
+	var s gqlSelection
+	if p.tok == "..." && !p.str {
+		if err := p.next(); err != nil {
+			return s, err
+		}
+		switch {
+		case p.tok == "on" && !p.str:
+			if err := p.next(); err != nil {
+				return s, err
+			}
+			s.on = p.tok
+			if err := p.next(); err != nil {
+				return s, err
+			}
+			fallthrough
+		case p.tok == "{" && !p.str:
+			children, err := p.selectionSet()
+			s.inline, s.children = true, children
+			return s, err
+		case isGQLName(p.tok) && !p.str:
+			s.spread = p.tok
+			return s, p.next()
+		}
+		return s, fmt.Errorf("unexpected %q after \"...\"", p.tok)
+	}
+	if !isGQLName(p.tok) || p.str {
+		return s, fmt.Errorf("expected a field, found %q", p.tok)
+	}
+	s.name = p.tok
+	if err := p.next(); err != nil {
+		return s, err
+	}
+	if p.tok == ":" && !p.str {
+		if err := p.next(); err != nil {
+			return s, err
+		}
+		if !isGQLName(p.tok) || p.str {
+			return s, fmt.Errorf("expected a field after alias %s, found %q", s.name, p.tok)
+		}
+		s.alias, s.name = s.name, p.tok
+		if err := p.next(); err != nil {
+			return s, err
+		}
+	}
+	if p.tok == "(" && !p.str {
+		if err := p.next(); err != nil {
+			return s, err
+		}
+		s.args = map[string]any{}
+		for p.tok != ")" || p.str {
+			name := p.tok
+			if !isGQLName(name) || p.str {
+				return s, fmt.Errorf("expected an argument of %s, found %q", s.name, name)
+			}
+			if err := p.next(); err != nil {
+				return s, err
+			}
+			if err := p.expect(":"); err != nil {
+				return s, err
+			}
+			v, err := p.value()
+			if err != nil {
+				return s, err
+			}
+			s.args[name] = v
+		}
+		if err := p.next(); err != nil {
+			return s, err
+		}
+	}
+	if p.tok == "@" && !p.str {
+		return s, fmt.Errorf("directives are not supported")
+	}
+	if p.tok == "{" && !p.str {
+		children, err := p.selectionSet()
+		if err != nil {
+			return s, err
+		}
+		s.children = children
+	}
+	return s, nil
+}
+
+func (p *gqlParser) value() (any, error) {
+	tok, str := p.tok, p.str
+	if tok == "" {
+		return nil, fmt.Errorf("expected a value, found the end of the query")
+	}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	switch {
+	case str:
+		return tok, nil
+	case tok == "$":
+		name := p.tok
+		if !isGQLName(name) || p.str {
+			return nil, fmt.Errorf("expected a variable name, found %q", name)
+		}
+		return gqlVariable(name), p.next()
+	case tok == "true", tok == "false":
+		return tok == "true", nil
+	case tok == "null":
+		return nil, nil
+	case tok == "[":
+		list := []any{}
+		for p.tok != "]" || p.str {
+			v, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, p.next()
+	}
+	if n, err := strconv.ParseInt(tok, 10, 64); err == nil {
+		return n, nil
+	}
+	if f, err := strconv.ParseFloat(tok, 64); err == nil {
+		return f, nil
+	}
+	if isGQLName(tok) {
+		return tok, nil // an enum value
+	}
+	return nil, fmt.Errorf("unexpected %q", tok)
+}
+
+func isGQLName(s string) bool {
+	if s == "" || !(s[0] == '_' || unicode.IsLetter(rune(s[0]))) {
+		return false
+	}
+	for _, c := range s {
+		if c != '_' && !unicode.IsLetter(c) && !unicode.IsDigit(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// next reads the next token, skipping white space, commas and comments.
+func (p *gqlParser) next() error {
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if c == '#' {
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		} else if c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',' {
+			p.pos++
+		} else {
+			break
+		}
+	}
+	p.str = false
+	if p.pos >= len(p.src) {
+		p.tok = ""
+		return nil
+	}
+	start := p.pos
+	c := p.src[p.pos]
+	switch {
+	case strings.HasPrefix(p.src[p.pos:], "..."):
+		p.pos += 3
+	case strings.ContainsRune("{}():$![]=@", rune(c)):
+		p.pos++
+	case c == '"':
+		p.pos++
+		for p.pos < len(p.src) && p.src[p.pos] != '"' {
+			if p.src[p.pos] == '\\' {
+				p.pos++
+			}
+			p.pos++
+		}
+		if p.pos >= len(p.src) {
+			return fmt.Errorf("unterminated string")
+		}
+		p.pos++
+		s, err := strconv.Unquote(p.src[start:p.pos])
+		if err != nil {
+			return fmt.Errorf("invalid string %s", p.src[start:p.pos])
+		}
+		p.tok, p.str = s, true
+		return nil
+	case c == '-' || c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+		// Numbers may hold a decimal point, an exponent and its sign.
+		number := c == '-' || c >= '0' && c <= '9'
+		p.pos++
+		for p.pos < len(p.src) {
+			c := p.src[p.pos]
+			if !(c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z') && !(number && (c == '.' || c == '-' || c == '+')) {
+				break
+			}
+			p.pos++
+		}
+	default:
+		return fmt.Errorf("unexpected character %q", c)
+	}
+	p.tok = p.src[start:p.pos]
+	return nil
+}
+
+// ---- Schema ----
+
+// gqlType is an object type of the schema.
+type gqlType struct {
+	name   string
+	fields map[string]gqlField
+}
+
+// gqlField is a field of an object type. Resolving one of type typ returns
+// a value of that type or a []any of them; with no typ, a JSON scalar.
+type gqlField struct {
+	typ     *gqlType
+	args    []string
+	resolve func(x *gqlExec, src any, args map[string]any) (any, error)
+}
+
+// gqlScalar is a field read straight off a T.
+func gqlScalar[T any](get func(T) any) gqlField {
+	return gqlField{resolve: func(_ *gqlExec, src any, _ map[string]any) (any, error) {
+		return get(src.(T)), nil
+	}}
+}
+
+// gqlNullable is the value of a sql.Null* field, nil when it is NULL.
+func gqlNullable(n driver.Valuer) any {
+	v, _ := n.Value()
+	return v
+}
+
+var gqlQuery, gqlEvent, gqlGroup, gqlTask, gqlRegistration, gqlAttendance = &gqlType{name: "Query"}, &gqlType{name: "Event"}, &gqlType{name: "Group"},
+	&gqlType{name: "Task"}, &gqlType{name: "Registration"}, &gqlType{name: "Attendance"}
+
+func init() {
+	gqlQuery.fields = map[string]gqlField{
+		// events lists events, newest first, optionally those of one type
+		// or dated within [from, to].
+		"events": {typ: gqlEvent, args: []string{"type", "from", "to"}, resolve: func(x *gqlExec, _ any, args map[string]any) (any, error) {
+			typ, _ := args["type"].(string)
+			from, _ := args["from"].(string)
+			to, _ := args["to"].(string)
+			events, err := ListEvents(x.app.DB)
+			if err != nil {
+				return nil, err
+			}
+			var list []any
+			for _, e := range events {
+				if typ != "" && e.EventType != typ || from != "" && e.EventDate < from || to != "" && e.EventDate > to {
+					continue
+				}
+				list = append(list, e)
+			}
+			return list, nil
+		}},
+		"event": {typ: gqlEvent, args: []string{"id", "slug"}, resolve: func(x *gqlExec, _ any, args map[string]any) (any, error) {
+			var e *Event
+			var err error
+			if id, ok := args["id"].(int64); ok {
+				e, err = GetEvent(x.app.DB, id)
+			} else if slug, ok := args["slug"].(string); ok {
+				e, err = GetEventBySlug(x.app.DB, slug)
+			} else {
+				return nil, fmt.Errorf("event needs an id or a slug")
+			}
+			if err != nil {
+				return nil, nil
+			}
+			return *e, nil
+		}},
+	}
+
+	gqlEvent.fields = map[string]gqlField{
+		"id":              gqlScalar(func(e Event) any { return e.ID }),
+		"slug":            gqlScalar(func(e Event) any { return e.Slug }),
+		"titleFr":         gqlScalar(func(e Event) any { return e.TitleFR }),
+		"titleEn":         gqlScalar(func(e Event) any { return e.TitleEN }),
+		"type":            gqlScalar(func(e Event) any { return e.EventType }),
+		"date":            gqlScalar(func(e Event) any { return e.EventDate }),
+		"time":            gqlScalar(func(e Event) any { return e.EventTime }),
+		"endDate":         gqlScalar(func(e Event) any { return e.EndDay() }),
+		"endTime":         gqlScalar(func(e Event) any { return e.EndTime }),
+		"timezone":        gqlScalar(func(e Event) any { return e.Timezone }),
+		"draft":           gqlScalar(func(e Event) any { return e.Draft }),
+		"maxParticipants": gqlScalar(func(e Event) any { return gqlNullable(e.MaxParticipants) }),
+		"createdAt":       gqlScalar(func(e Event) any { return e.CreatedAt }),
+		// People signed up, guests included; RSVPs saying yes.
+		"peopleCount": {resolve: func(x *gqlExec, src any, _ map[string]any) (any, error) {
+			return CountRegistrations(x.app.DB, src.(Event).ID), nil
+		}},
+		"attendingCount": {resolve: func(x *gqlExec, src any, _ map[string]any) (any, error) {
+			yes, _ := CountAttendances(x.app.DB, src.(Event).ID)
+			return yes, nil
+		}},
+		// People coming to an attendance event, plus-ones included.
+		"attendingPeople": {resolve: func(x *gqlExec, src any, _ map[string]any) (any, error) {
+			return AttendanceHeadcount(x.app.DB, src.(Event).ID).Total(), nil
+		}},
+		"groups": {typ: gqlGroup, resolve: func(x *gqlExec, src any, _ map[string]any) (any, error) {
+			groups, err := x.groups(src.(Event).ID)
+			return anySlice(groups), err
+		}},
+		"tasks": {typ: gqlTask, resolve: func(x *gqlExec, src any, _ map[string]any) (any, error) {
+			tasks, err := x.tasks(src.(Event).ID)
+			return anySlice(tasks), err
+		}},
+		"registrations": {typ: gqlRegistration, resolve: func(x *gqlExec, src any, _ map[string]any) (any, error) {
+			regs, err := x.registrations(src.(Event).ID)
+			return anySlice(regs), err
+		}},
+		"attendances": {typ: gqlAttendance, resolve: func(x *gqlExec, src any, _ map[string]any) (any, error) {
+			attendances, err := ListAttendances(x.app.DB, src.(Event).ID)
+			return anySlice(attendances), err
+		}},
+	}
+
+	gqlGroup.fields = map[string]gqlField{
+		"id":       gqlScalar(func(g TaskGroup) any { return g.ID }),
+		"eventId":  gqlScalar(func(g TaskGroup) any { return g.EventID }),
+		"parentId": gqlScalar(func(g TaskGroup) any { return gqlNullable(g.ParentGroupID) }),
+		"titleFr":  gqlScalar(func(g TaskGroup) any { return g.TitleFR }),
+		"titleEn":  gqlScalar(func(g TaskGroup) any { return g.TitleEN }),
+		"position": gqlScalar(func(g TaskGroup) any { return g.Position }),
+		"maxSlots": gqlScalar(func(g TaskGroup) any { return gqlNullable(g.MaxSlots) }),
+		"color":    gqlScalar(func(g TaskGroup) any { return g.Color }),
+		"icon":     gqlScalar(func(g TaskGroup) any { return g.Icon }),
+		// tasks are those directly in the group, not in its subgroups.
+		"tasks": {typ: gqlTask, resolve: func(x *gqlExec, src any, _ map[string]any) (any, error) {
+			g := src.(TaskGroup)
+			tasks, err := x.tasks(g.EventID)
+			var list []any
+			for _, t := range tasks {
+				if t.GroupID.Valid && t.GroupID.Int64 == g.ID {
+					list = append(list, t)
+				}
+			}
+			return list, err
+		}},
+	}
+
+	gqlTask.fields = map[string]gqlField{
+		"id":            gqlScalar(func(t Task) any { return t.ID }),
+		"eventId":       gqlScalar(func(t Task) any { return t.EventID }),
+		"groupId":       gqlScalar(func(t Task) any { return gqlNullable(t.GroupID) }),
+		"titleFr":       gqlScalar(func(t Task) any { return t.TitleFR }),
+		"titleEn":       gqlScalar(func(t Task) any { return t.TitleEN }),
+		"descriptionFr": gqlScalar(func(t Task) any { return t.DescriptionFR }),
+		"descriptionEn": gqlScalar(func(t Task) any { return t.DescriptionEN }),
+		"startTime":     gqlScalar(func(t Task) any { return t.StartTime }),
+		"endTime":       gqlScalar(func(t Task) any { return t.EndTime }),
+		"position":      gqlScalar(func(t Task) any { return t.Position }),
+		"maxSlots":      gqlScalar(func(t Task) any { return gqlNullable(t.MaxSlots) }),
+		"minSlots":      gqlScalar(func(t Task) any { return t.MinSlots }),
+		"maxGuests":     gqlScalar(func(t Task) any { return t.MaxGuests }),
+		"reservedSlots": gqlScalar(func(t Task) any { return t.ReservedSlots }),
+		"skills":        gqlScalar(func(t Task) any { return t.Skills }),
+		"group": {typ: gqlGroup, resolve: func(x *gqlExec, src any, _ map[string]any) (any, error) {
+			t := src.(Task)
+			groups, err := x.groups(t.EventID)
+			for _, g := range groups {
+				if t.GroupID.Valid && g.ID == t.GroupID.Int64 {
+					return g, err
+				}
+			}
+			return nil, err
+		}},
+		"registrations": {typ: gqlRegistration, resolve: func(x *gqlExec, src any, _ map[string]any) (any, error) {
+			regs, err := x.taskRegistrations(src.(Task))
+			return anySlice(regs), err
+		}},
+		// People signed up, guests included, and the spots left: null when
+		// the task has no maximum.
+		"peopleCount": {resolve: func(x *gqlExec, src any, _ map[string]any) (any, error) {
+			regs, err := x.taskRegistrations(src.(Task))
+			return countPeopleIn(regs), err
+		}},
+		"slotsLeft": {resolve: func(x *gqlExec, src any, _ map[string]any) (any, error) {
+			t := src.(Task)
+			if !t.MaxSlots.Valid {
+				return nil, nil
+			}
+			regs, err := x.taskRegistrations(t)
+			return max(int(t.MaxSlots.Int64)-countPeopleIn(regs), 0), err
+		}},
+	}
+
+	gqlRegistration.fields = map[string]gqlField{
+		"id":          gqlScalar(func(r RegistrationExport) any { return r.ID }),
+		"taskId":      gqlScalar(func(r RegistrationExport) any { return r.TaskID }),
+		"firstName":   gqlScalar(func(r RegistrationExport) any { return r.FirstName }),
+		"lastName":    gqlScalar(func(r RegistrationExport) any { return r.LastName }),
+		"email":       gqlScalar(func(r RegistrationExport) any { return r.Email }),
+		"phone":       gqlScalar(func(r RegistrationExport) any { return r.Phone }),
+		"lang":        gqlScalar(func(r RegistrationExport) any { return r.Lang }),
+		"guests":      gqlScalar(func(r RegistrationExport) any { return r.Guests }),
+		"comment":     gqlScalar(func(r RegistrationExport) any { return r.Comment }),
+		"diet":        gqlScalar(func(r RegistrationExport) any { return r.Diet }),
+		"allergies":   gqlScalar(func(r RegistrationExport) any { return r.Allergies }),
+		"checkedIn":   gqlScalar(func(r RegistrationExport) any { return r.CheckedInAt.Valid }),
+		"checkedInAt": gqlScalar(func(r RegistrationExport) any { return gqlNullable(r.CheckedInAt) }),
+		"createdAt":   gqlScalar(func(r RegistrationExport) any { return r.CreatedAt }),
+		"task": {typ: gqlTask, resolve: func(x *gqlExec, src any, _ map[string]any) (any, error) {
+			t, err := GetTask(x.app.DB, src.(RegistrationExport).TaskID)
+			if err != nil {
+				return nil, nil
+			}
+			return *t, nil
+		}},
+	}
+
+	gqlAttendance.fields = map[string]gqlField{
+		"id":          gqlScalar(func(a Attendance) any { return a.ID }),
+		"eventId":     gqlScalar(func(a Attendance) any { return a.EventID }),
+		"firstName":   gqlScalar(func(a Attendance) any { return a.FirstName }),
+		"lastName":    gqlScalar(func(a Attendance) any { return a.LastName }),
+		"email":       gqlScalar(func(a Attendance) any { return a.Email }),
+		"phone":       gqlScalar(func(a Attendance) any { return a.Phone }),
+		"attending":   gqlScalar(func(a Attendance) any { return a.Attending }),
+		"adultGuests": gqlScalar(func(a Attendance) any { return a.AdultGuests }),
+		"childGuests": gqlScalar(func(a Attendance) any { return a.ChildGuests }),
+		"message":     gqlScalar(func(a Attendance) any { return a.Message }),
+		"lang":        gqlScalar(func(a Attendance) any { return a.Lang }),
+		"createdAt":   gqlScalar(func(a Attendance) any { return a.CreatedAt }),
+		"updatedAt":   gqlScalar(func(a Attendance) any { return a.UpdatedAt }),
+	}
+}
+
+func anySlice[T any](items []T) []any {
+	list := make([]any, len(items))
+	for i, item := range items {
+		list[i] = item
+	}
+	return list
+}
+
+func countPeopleIn(regs []RegistrationExport) int {
+	n := 0
+	for _, r := range regs {
+		n += 1 + r.Guests
+	}
+	return n
+}
+
+// ---- Execution ----
+
+// gqlExec runs one query. It loads each event's groups, tasks and
+// registrations once, however many fields read them.
+type gqlExec struct {
+	app       *App
+	vars      map[string]any
+	fragments map[string]gqlSelection
+	errors    []gqlError
+
+	groupsOf map[int64][]TaskGroup
+	tasksOf  map[int64][]Task
+	regsOf   map[int64][]RegistrationExport
+}
+
+type gqlError struct {
+	Message string `json:"message"`
+	Path    []any  `json:"path,omitempty"`
+}
+
+func (x *gqlExec) groups(eventID int64) ([]TaskGroup, error) {
+	if groups, ok := x.groupsOf[eventID]; ok {
+		return groups, nil
+	}
+	groups, err := ListTaskGroups(x.app.DB, eventID)
+	if err == nil {
+		x.groupsOf[eventID] = groups
+	}
+	return groups, err
+}
+
+func (x *gqlExec) tasks(eventID int64) ([]Task, error) {
+	if tasks, ok := x.tasksOf[eventID]; ok {
+		return tasks, nil
+	}
+	tasks, err := ListTasks(x.app.DB, eventID)
+	if err == nil {
+		x.tasksOf[eventID] = tasks
+	}
+	return tasks, err
+}
+
+func (x *gqlExec) registrations(eventID int64) ([]RegistrationExport, error) {
+	if regs, ok := x.regsOf[eventID]; ok {
+		return regs, nil
+	}
+	regs, err := ListAllRegistrations(x.app.DB, eventID)
+	if err == nil {
+		x.regsOf[eventID] = regs
+	}
+	return regs, err
+}
+
+func (x *gqlExec) taskRegistrations(t Task) ([]RegistrationExport, error) {
+	regs, err := x.registrations(t.EventID)
+	var list []RegistrationExport
+	for _, r := range regs {
+		if r.TaskID == t.ID {
+			list = append(list, r)
+		}
+	}
+	return list, err
+}
+
+// gqlObject is a result object, its keys in the order the query asked.
+type gqlObject struct {
+	keys   []string
+	values map[string]any
+}
+
+func (o *gqlObject) set(key string, v any) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = v
+}
+
+func (o *gqlObject) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, k := range o.keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, _ := json.Marshal(k)
+		b.Write(key)
+		b.WriteByte(':')
+		v, err := json.Marshal(o.values[k])
+		if err != nil {
+			return nil, err
+		}
+		b.Write(v)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// selectionError is a mistake in the query itself, which stops it.
+type selectionError struct{ msg string }
+
+func (e selectionError) Error() string { return e.msg }
+
+// maxGQLDepth bounds how deep a query nests, objects and fragments alike.
+const maxGQLDepth = 12
+
+// object resolves sels on src, of type typ, depth levels into the query.
+func (x *gqlExec) object(typ *gqlType, src any, sels []gqlSelection, path []any, depth int) (*gqlObject, error) {
+	out := &gqlObject{values: map[string]any{}}
+	if err := x.selectInto(out, typ, src, sels, path, depth); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (x *gqlExec) selectInto(out *gqlObject, typ *gqlType, src any, sels []gqlSelection, path []any, depth int) error {
+	if depth > maxGQLDepth {
+		return selectionError{"the query nests too deep"}
+	}
+	for _, s := range sels {
+		if s.spread != "" {
+			f, ok := x.fragments[s.spread]
+			if !ok {
+				return selectionError{fmt.Sprintf("unknown fragment %q", s.spread)}
+			}
+			s = f
+		}
+		if s.inline {
+			if s.on != "" && s.on != typ.name {
+				continue
+			}
+			if err := x.selectInto(out, typ, src, s.children, path, depth+1); err != nil {
+				return err
+			}
+			continue
+		}
+		if s.name == "__typename" {
+			out.set(s.key(), typ.name)
+			continue
+		}
+		field, ok := typ.fields[s.name]
+		if !ok {
+			return selectionError{fmt.Sprintf("cannot query field %q on type %s", s.name, typ.name)}
+		}
+		args, err := x.arguments(typ, s, field)
+		if err != nil {
+			return err
+		}
+		if field.typ == nil && s.children != nil {
+			return selectionError{fmt.Sprintf("field %q of type %s has no subfields", s.name, typ.name)}
+		}
+		if field.typ != nil && s.children == nil {
+			return selectionError{fmt.Sprintf("field %q of type %s needs a selection of subfields", s.name, typ.name)}
+		}
+		fieldPath := append(append([]any{}, path...), s.key())
+		v, err := field.resolve(x, src, args)
+		if err != nil {
+			log.Printf("graphql: %s.%s: %v", typ.name, s.name, err)
+			x.errors = append(x.errors, gqlError{Message: "server error", Path: fieldPath})
+			out.set(s.key(), nil)
+			continue
+		}
+		if field.typ == nil || v == nil {
+			out.set(s.key(), v)
+			continue
+		}
+		if list, ok := v.([]any); ok {
+			items := make([]any, len(list))
+			for i, item := range list {
+				o, err := x.object(field.typ, item, s.children, append(append([]any{}, fieldPath...), i), depth+1)
+				if err != nil {
+					return err
+				}
+				items[i] = o
+			}
+			out.set(s.key(), items)
+			continue
+		}
+		o, err := x.object(field.typ, v, s.children, fieldPath, depth+1)
+		if err != nil {
+			return err
+		}
+		out.set(s.key(), o)
+	}
+	return nil
+}
+
+// arguments resolves the arguments of s, variables included, turning whole
+// numbers into int64.
+func (x *gqlExec) arguments(typ *gqlType, s gqlSelection, field gqlField) (map[string]any, error) {
+	args := map[string]any{}
+	for name, v := range s.args {
+		known := false
+		for _, a := range field.args {
+			known = known || a == name
+		}
+		if !known {
+			return nil, selectionError{fmt.Sprintf("unknown argument %q on field %s.%s", name, typ.name, s.name)}
+		}
+		if ref, ok := v.(gqlVariable); ok {
+			value, ok := x.vars[string(ref)]
+			if !ok {
+				return nil, selectionError{fmt.Sprintf("variable $%s is not given", ref)}
+			}
+			v = value
+		}
+		if f, ok := v.(float64); ok && f == float64(int64(f)) {
+			v = int64(f)
+		}
+		if v != nil {
+			args[name] = v
+		}
+	}
+	return args, nil
+}
+
+// gqlResponse is what the endpoint answers.
+type gqlResponse struct {
+	Data   *gqlObject `json:"data,omitempty"`
+	Errors []gqlError `json:"errors,omitempty"`
+}
+
+// RunGraphQL runs a query document with its variables.
+func (app *App) RunGraphQL(query string, vars map[string]any, operation string) (gqlResponse, bool) {
+	doc, err := parseGraphQL(query)
+	if err != nil {
+		return gqlResponse{Errors: []gqlError{{Message: "syntax error: " + err.Error()}}}, false
+	}
+	sels, ok := doc.operations[operation]
+	if !ok && operation == "" && len(doc.operations) == 1 {
+		for _, s := range doc.operations {
+			sels, ok = s, true
+		}
+	}
+	if !ok {
+		return gqlResponse{Errors: []gqlError{{Message: fmt.Sprintf("no operation %q in the document", operation)}}}, false
+	}
+	x := &gqlExec{
+		app: app, vars: vars, fragments: doc.fragments,
+		groupsOf: map[int64][]TaskGroup{}, tasksOf: map[int64][]Task{}, regsOf: map[int64][]RegistrationExport{},
+	}
+	data, err := x.object(gqlQuery, nil, sels, nil, 0)
+	if err != nil {
+		return gqlResponse{Errors: []gqlError{{Message: err.Error()}}}, false
+	}
+	return gqlResponse{Data: data, Errors: x.errors}, true
+}
+
+// maxGQLBody bounds the size of a query sent by POST.
+const maxGQLBody = 64 << 10
+
+// handleAPIGraphQL answers a GraphQL query sent as JSON
+// {"query", "variables", "operationName"}, or as the query string of a GET.
+func (app *App) handleAPIGraphQL(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Query         string         `json:"query"`
+		Variables     map[string]any `json:"variables"`
+		OperationName string         `json:"operationName"`
+	}
+	switch r.Method {
+	case http.MethodGet:
+		q := r.URL.Query()
+		req.Query, req.OperationName = q.Get("query"), q.Get("operationName")
+		if v := q.Get("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+				apiError(w, http.StatusBadRequest, "bad_request")
+				return
+			}
+		}
+	case http.MethodPost:
+		r.Body = http.MaxBytesReader(w, r.Body, maxGQLBody)
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			apiError(w, http.StatusBadRequest, "bad_request")
+			return
+		}
+	default:
+		methodNotAllowed(w)
+		return
+	}
+	res, ok := app.RunGraphQL(req.Query, req.Variables, req.OperationName)
+	status := http.StatusOK
+	if !ok {
+		status = http.StatusBadRequest
+	}
+	writeJSON(w, status, res)
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseGraphQL(t *testing.T) {
+	doc, err := parseGraphQL(`# Tasks that still need people
+		query Staffing($id: Int!) {
+			e: event(id: $id) { ...Basics tasks { titleFr, slotsLeft } }
+		}
+		fragment Basics on Event { id titleFr }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sels := doc.operations["Staffing"]
+	if len(sels) != 1 || sels[0].alias != "e" || sels[0].name != "event" || sels[0].args["id"] != gqlVariable("id") {
+		t.Fatalf("operation = %+v", sels)
+	}
+	if children := sels[0].children; len(children) != 2 || children[0].spread != "Basics" || len(children[1].children) != 2 {
+		t.Errorf("selection = %+v", children)
+	}
+	if f := doc.fragments["Basics"]; f.on != "Event" || len(f.children) != 2 {
+		t.Errorf("fragment = %+v", f)
+	}
+
+	for _, bad := range []string{`{ events { id }`, `mutation { x }`, `{ events(type: ) { id } }`, `{ }`, `{ id @skip(if: true) }`,
+		`{ events { ...A } } fragment A on Event { ...B } fragment B on Event { tasks { ...A } }`} {
+		if _, err := parseGraphQL(bad); err == nil {
+			t.Errorf("%q parsed", bad)
+		}
+	}
+}
+
+func TestGraphQL(t *testing.T) {
+	app := testApp(t)
+	e := seedEvent(t, app.DB)
+	full := seedTask(t, app.DB, e.ID, "Vaisselle", int64Ptr(3))
+	seedTask(t, app.DB, e.ID, "Buvette", nil)
+	RegisterForTask(app.DB, full.ID, "Alice", "Dupont", "alice@test.com", "0601", "fr")
+	RegisterForTask(app.DB, full.ID, "Bob", "Martin", "bob@test.com", "0602", "fr")
+	token, _ := CreateAPIToken(app.DB, "Reports", "read")
+	h := app.csrfProtect(newMux(app))
+
+	query := func(body string) (int, map[string]any) {
+		req := httptest.NewRequest(http.MethodPost, "/admin/api/graphql", bytes.NewReader([]byte(body)))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		var res map[string]any
+		if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+			t.Fatalf("status %d, %q", w.Code, w.Body.String())
+		}
+		return w.Code, res
+	}
+
+	// A read token may POST a query.
+	code, res := query(`{"query": "query($id: Int) { event(id: $id) { __typename titleFr peopleCount tasks { titleFr slotsLeft registrations { firstName guests } } } }", "variables": {"id": ` + fmt.Sprint(e.ID) + `}}`)
+	if code != http.StatusOK || res["errors"] != nil {
+		t.Fatalf("status %d, %v", code, res)
+	}
+	event := res["data"].(map[string]any)["event"].(map[string]any)
+	if event["__typename"] != "Event" || event["peopleCount"] != 2.0 {
+		t.Errorf("event = %v", event)
+	}
+	tasks := event["tasks"].([]any)
+	if len(tasks) != 2 {
+		t.Fatalf("tasks = %v", tasks)
+	}
+	first, second := tasks[0].(map[string]any), tasks[1].(map[string]any)
+	if first["slotsLeft"] != 1.0 || len(first["registrations"].([]any)) != 2 || second["slotsLeft"] != nil {
+		t.Errorf("tasks = %v", tasks)
+	}
+
+	// Keys come back in the order asked.
+	req := httptest.NewRequest(http.MethodGet, "/admin/api/graphql?query="+strings.ReplaceAll("{ events { type id n: titleFr } }", " ", "+"), nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if !strings.Contains(w.Body.String(), `{"type":"tasks","id":`) || !strings.Contains(w.Body.String(), `"n":"Test Event"`) {
+		t.Errorf("GET: %s", w.Body.String())
+	}
+
+	if code, res := query(`{"query": "{ events { nope } }"}`); code != http.StatusBadRequest || res["data"] != nil {
+		t.Errorf("unknown field: status %d, %v", code, res)
+	}
+	if code, _ := query(`{"query": "{ events { tasks } }"}`); code != http.StatusBadRequest {
+		t.Errorf("object without selection: status %d", code)
+	}
+
+	// Tasks and groups point at each other: a fragment spreading itself
+	// would select forever, and deep nesting is bounded too.
+	if code, res := query(`{"query": "{ events { tasks { ...T } } } fragment T on Task { group { tasks { ...T } } }"}`); code != http.StatusBadRequest || res["errors"] == nil {
+		t.Errorf("cyclic fragment: status %d, %v", code, res)
+	}
+	deep := "{ events { tasks { " + strings.Repeat("... { ", maxGQLDepth) + "id" + strings.Repeat(" }", maxGQLDepth) + " } } }"
+	if code, res := query(`{"query": "` + deep + `"}`); code != http.StatusBadRequest || res["errors"] == nil {
+		t.Errorf("deep query: status %d, %v", code, res)
+	}
+	if code, _ := query(`{"query": "` + strings.Repeat(" ", maxGQLBody) + `{ events { id } }"}`); code != http.StatusBadRequest {
+		t.Errorf("oversized body: status %d", code)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+// Group colors and icons. A group can have a color and an emoji, shown next
+// to its title in the admin tree and on the public page, so a long list of
+// groups is easier to scan. The admin picks the color from groupColors; the
+// API takes any "#rrggbb".
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// GroupColor is a color offered for groups, named by its i18n key.
+type GroupColor struct {
+	Hex string
+	Key string
+}
+
+// groupColors are the colors offered on the admin tree, those the public
+// page already cycles through for groups without one first.
+var groupColors = []GroupColor{
+	{"#6366f1", "color_indigo"},
+	{"#059669", "color_green"},
+	{"#d97706", "color_amber"},
+	{"#db2777", "color_pink"},
+	{"#0284c7", "color_blue"},
+	{"#7c3aed", "color_violet"},
+	{"#dc2626", "color_red"},
+	{"#6b7280", "color_grey"},
+}
+
+var hexColorRe = regexp.MustCompile(`^#([0-9a-f]{3}|[0-9a-f]{6})$`)
+
+// maxGroupIconLen caps an icon in runes: enough for emoji joined into one,
+// like families or flags, not for a word.
+const maxGroupIconLen = 8
+
+// normalizeGroupStyle keeps g's color to a "#rrggbb" and its icon short,
+// clearing either when it isn't one.
+func normalizeGroupStyle(g *TaskGroup) {
+	g.Color = strings.ToLower(strings.TrimSpace(g.Color))
+	if !hexColorRe.MatchString(g.Color) {
+		g.Color = ""
+	} else if len(g.Color) == 4 {
+		g.Color = "#" + strings.Repeat(g.Color[1:2], 2) + strings.Repeat(g.Color[2:3], 2) + strings.Repeat(g.Color[3:4], 2)
+	}
+	g.Icon = strings.TrimSpace(g.Icon)
+	if utf8.RuneCountInString(g.Icon) > maxGroupIconLen {
+		g.Icon = string([]rune(g.Icon)[:maxGroupIconLen])
+	}
+}
+
+// groupColorChoices returns the colors to offer for a group colored
+// current: groupColors, plus current when set through the API to another.
+func groupColorChoices(current string) []GroupColor {
+	if current == "" {
+		return groupColors
+	}
+	for _, c := range groupColors {
+		if c.Hex == current {
+			return groupColors
+		}
+	}
+	return append(groupColors[:len(groupColors):len(groupColors)], GroupColor{Hex: current})
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeGroupStyle(t *testing.T) {
+	for _, c := range []struct{ color, icon, wantColor, wantIcon string }{
+		{"#DB2777", " 🍰 ", "#db2777", "🍰"},
+		{"#f0a", "", "#ff00aa", ""},
+		{"red", "🍰🍰🍰🍰🍰🍰🍰🍰🍰", "", "🍰🍰🍰🍰🍰🍰🍰🍰"},
+		{"#12345g", "👨‍👩‍👧", "", "👨‍👩‍👧"},
+	} {
+		g := &TaskGroup{Color: c.color, Icon: c.icon}
+		normalizeGroupStyle(g)
+		if g.Color != c.wantColor || g.Icon != c.wantIcon {
+			t.Errorf("%q %q → %q %q", c.color, c.icon, g.Color, g.Icon)
+		}
+	}
+}
+
+func TestGroupStyle(t *testing.T) {
+	app := testApp(t)
+	mux := newMux(app)
+	e := seedEvent(t, app.DB)
+	g := &TaskGroup{EventID: e.ID, TitleFR: "Cuisine"}
+	CreateTaskGroup(app.DB, g)
+	CreateTask(app.DB, &Task{EventID: e.ID, GroupID: sql.NullInt64{Int64: g.ID, Valid: true}, TitleFR: "Vaisselle"})
+
+	body, _ := json.Marshal(map[string]any{"id": g.ID, "title_fr": "Cuisine", "color": "#059669", "icon": "🍳"})
+	req := httptest.NewRequest(http.MethodPost, "/admin/api/group/save", bytes.NewReader(body))
+	req.AddCookie(adminCookie(app))
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != 200 {
+		t.Fatalf("save: status %d", w.Code)
+	}
+	if got, _ := GetTaskGroup(app.DB, g.ID); got.Color != "#059669" || got.Icon != "🍳" {
+		t.Fatalf("group = %+v", got)
+	}
+
+	page := getRequest(mux, "/e/"+e.Slug+"?lang=fr").Body.String()
+	if !strings.Contains(page, `style="--group-color: #059669"`) || !strings.Contains(page, `<span class="group-icon" aria-hidden="true">🍳</span> Cuisine`) {
+		t.Error("public page misses the group's color or icon")
+	}
+	admin := getRequest(mux, fmt.Sprintf("/admin/event/edit?id=%d&lang=en", e.ID), adminCookie(app)).Body.String()
+	if !strings.Contains(admin, `<option value="#059669" selected>Green</option>`) || !strings.Contains(admin, `value="🍳"`) {
+		t.Error("admin tree misses the group's color or icon")
+	}
+}
@@ -1,9 +1,12 @@
 package main
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
 	"database/sql"
 	"encoding/base64"
 	"encoding/csv"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -11,31 +14,89 @@ import (
 	"io"
 	"log"
 	"math/rand"
+	"net"
 	"net/http"
+	"net/mail"
+	"net/url"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 type App struct {
-	DB            *sql.DB
+	DB *sql.DB
+	// AdminPassword seeds the stored admin password on first use (see
+	// password.go); empty leaves it to the /admin/setup page.
 	AdminPassword string
 	AnthropicKey  string
+	// OIDC enables single sign-on (see oidc.go); nil means password only.
+	OIDC *OIDCProvider
+	// Captcha guards the public signup and RSVP forms (see spam.go); nil
+	// leaves them to the honeypot and the submit timer.
+	Captcha *Captcha
+	// AutoBackup enables scheduled backups (see autobackup.go); nil means
+	// none.
+	AutoBackup *AutoBackup
+	// Replica enables continuous replication (see replica.go); nil means
+	// none.
+	Replica *Replica
 
 	Email          EmailSender
 	EmailSendDelay time.Duration // pause between reveal emails (rate limiting)
 	AsyncEmail     bool          // true in production: reveal emails sent in a goroutine
 	sending        sync.Map      // event ID -> bool, guards concurrent reveal sends
+	outboxMu       sync.Mutex    // serializes outbox delivery passes
+	outboxWake     chan struct{} // wakes the outbox worker; nil in tests
 	SNSSkipVerify  bool          // true in tests: skip SNS signature verification
+	SkipFormStamp  bool          // true in tests: accept public forms without a render stamp
 }
 
-// baseURLFor derives the public base URL (scheme://host) from the incoming
-// request. Honors X-Forwarded-Proto / X-Forwarded-Host when set by a reverse
-// proxy. Used to build links that point back to the server the user is
-// actually hitting — so a developer on localhost gets localhost links, and a
-// user on the production domain gets production links.
-func baseURLFor(r *http.Request) string {
+// adminBaseURLKey is the setting holding the base URL the admin last logged
+// in from, the instance's own address when no base URL is configured.
+const adminBaseURLKey = "admin_base_url"
+
+// baseURLFor returns the public base URL (scheme://host) for links back to
+// the server. A base URL set in the instance settings takes precedence.
+// Otherwise it follows the request — so a developer on localhost gets
+// localhost links, and an event's vanity domain gets its own links — but
+// only for a host the server knows: the one the admin logs in from, an
+// event's vanity domain or a loopback address. Host and X-Forwarded-Host
+// are anyone's to forge, so any other host gets the configured base URL.
+func (app *App) baseURLFor(r *http.Request) string {
+	configured := app.configuredBaseURL()
+	if currentInstance().BaseURL != "" {
+		return configured
+	}
+	host := strings.ToLower(requestHost(r))
+	switch {
+	case configured == "" || isLoopbackHost(host):
+		// A fresh instance: nobody has logged in to tell the address yet.
+		return requestBaseURL(r)
+	case hostOf(configured) == host:
+		return configured
+	}
+	if event, err := GetEventByHost(app.DB, host); err == nil {
+		return event.BaseURL
+	}
+	return configured
+}
+
+// configuredBaseURL is the base URL of the instance as set up rather than
+// as asked for by a request: the one in the instance settings, or else the
+// one the admin last logged in from. Empty until either is known.
+func (app *App) configuredBaseURL() string {
+	if base := currentInstance().BaseURL; base != "" {
+		return base
+	}
+	return GetSetting(app.DB, adminBaseURLKey)
+}
+
+// requestBaseURL is the base URL the visitor asked for. Honors
+// X-Forwarded-Proto / X-Forwarded-Host when set by a reverse proxy.
+func requestBaseURL(r *http.Request) string {
 	scheme := "http"
 	if r.TLS != nil {
 		scheme = "https"
@@ -43,11 +104,134 @@ func baseURLFor(r *http.Request) string {
 	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
 		scheme = proto
 	}
-	host := r.Host
+	return scheme + "://" + requestHost(r)
+}
+
+// requestHost is the host the visitor asked for, honoring X-Forwarded-Host.
+func requestHost(r *http.Request) string {
 	if h := r.Header.Get("X-Forwarded-Host"); h != "" {
+		return h
+	}
+	return r.Host
+}
+
+// hostOf returns the lowercased host[:port] of a base URL.
+func hostOf(baseURL string) string {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Host)
+}
+
+// isLoopbackHost reports whether host[:port] names this machine.
+func isLoopbackHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
 		host = h
 	}
-	return scheme + "://" + host
+	host = strings.Trim(host, "[]")
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// handleRoot sends visitors of an event's vanity domain straight to that
+// event's page; on any other host the root leads to the admin.
+func (app *App) handleRoot(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	if event, err := GetEventByHost(app.DB, requestHost(r)); err == nil {
+		http.Redirect(w, r, "/e/"+event.Slug, http.StatusSeeOther)
+		return
+	}
+	http.Redirect(w, r, "/admin", http.StatusSeeOther)
+}
+
+// eventBaseURL is the base URL for links about one event: its vanity domain
+// when the admin set one, otherwise the instance's; see baseURLFor.
+func (app *App) eventBaseURL(r *http.Request, e *Event) string {
+	return e.BaseURLOr(app.baseURLFor(r))
+}
+
+// eventConfiguredBaseURL is the base URL for links about one event emailed
+// to an address nobody has verified: its vanity domain, otherwise the
+// instance's configured one — never anything taken from the request.
+func (app *App) eventConfiguredBaseURL(e *Event) string {
+	return e.BaseURLOr(app.configuredBaseURL())
+}
+
+// normalizeBaseURL validates an admin-entered vanity base URL and reduces it
+// to scheme://host[:port]. Empty input is allowed and means "no override".
+func normalizeBaseURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("base url must use http or https")
+	}
+	if u.Host == "" || u.User != nil || (u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.Fragment != "" {
+		return "", fmt.Errorf("base url must be scheme://host only")
+	}
+	return u.Scheme + "://" + strings.ToLower(u.Host), nil
+}
+
+// normalizeTimezone validates an admin-entered IANA time zone name. Empty
+// input is allowed and means "unspecified".
+func normalizeTimezone(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", nil
+	}
+	// LoadLocation also accepts "Local", which would silently follow the
+	// server's zone.
+	if _, err := time.LoadLocation(raw); err != nil || raw == "Local" {
+		return "", fmt.Errorf("unknown time zone %q", raw)
+	}
+	return raw, nil
+}
+
+// normalizeEventEnd validates the optional end of e: the end date must not
+// precede the start date, and an end date equal to it is dropped since that
+// is the default.
+func normalizeEventEnd(e *Event) error {
+	if e.EndDate == e.EventDate {
+		e.EndDate = ""
+	}
+	if e.EndDate != "" {
+		if _, err := time.Parse("2006-01-02", e.EndDate); err != nil || e.EndDate < e.EventDate {
+			return fmt.Errorf("invalid end date %q", e.EndDate)
+		}
+	}
+	if !validTaskTime(e.EndTime) {
+		return fmt.Errorf("invalid end time %q", e.EndTime)
+	}
+	return nil
+}
+
+// normalizeNotifyEmails validates the admin notification recipients (comma,
+// semicolon or whitespace separated) and joins them with ", ".
+func normalizeNotifyEmails(raw string) (string, error) {
+	fields := strings.FieldsFunc(raw, func(r rune) bool {
+		return r == ',' || r == ';' || unicode.IsSpace(r)
+	})
+	addrs := make([]string, 0, len(fields))
+	for _, f := range fields {
+		a, err := mail.ParseAddress(f)
+		if err != nil {
+			return "", err
+		}
+		addrs = append(addrs, a.Address)
+	}
+	return strings.Join(addrs, ", "), nil
 }
 
 type PageData struct {
@@ -57,10 +241,20 @@ type PageData struct {
 	Data      any
 	Error     string
 	Success   string
+	// Theme is the look of the event a public page is about, nil on other
+	// pages and for events without one. See event_theme.go.
+	Theme *EventTheme
+	// NoIndex asks search engines to leave the page out. See sitemap.go.
+	NoIndex bool
 }
 
 func (app *App) newPageData(r *http.Request, data any) PageData {
-	lang := LangFromRequest(r)
+	return app.newPageDataLang(r, LangFromRequest(r), data)
+}
+
+// newPageDataLang is newPageData with an explicit page language, for pages
+// that default to a stored preference rather than the instance language.
+func (app *App) newPageDataLang(r *http.Request, lang string, data any) PageData {
 	other := SwitchLang(lang)
 	langURL := r.URL.Path + "?lang=" + other
 	if r.URL.RawQuery != "" {
@@ -77,9 +271,29 @@ func (app *App) newPageData(r *http.Request, data any) PageData {
 }
 
 func (app *App) render(w http.ResponseWriter, r *http.Request, tmpl string, data PageData) {
+	if m, ok := data.Data.(map[string]any); ok && !strings.HasPrefix(tmpl, "admin_") {
+		if event, ok := m["Event"].(*Event); ok && event != nil {
+			if data.Theme == nil {
+				data.Theme = app.pageTheme(event)
+			}
+			// A single-language event's pages show in its language, with
+			// no switch to the other.
+			if event.Lang != "" {
+				data.Lang, data.LangURL = event.Lang, ""
+			}
+			data.NoIndex = data.NoIndex || event.NoIndex || event.InviteOnly
+		}
+	}
+	if data.NoIndex {
+		w.Header().Set("X-Robots-Tag", "noindex")
+	}
 	lang := data.Lang
 	funcs := app.buildFuncs(lang)
 	funcs["isAdmin"] = func() bool { return strings.HasPrefix(tmpl, "admin_") }
+	funcs["csrfToken"] = func() string { return csrfToken(r) }
+	funcs["csrfField"] = func() template.HTML { return csrfField(r) }
+	// invite carries an invite-only event's invite along its forms and links.
+	funcs["invite"] = func() string { return strings.TrimSpace(r.FormValue("invite")) }
 
 	t, err := template.New("").Funcs(funcs).ParseFS(templatesFS, "templates/layout.html", "templates/"+tmpl)
 	if err != nil {
@@ -94,38 +308,110 @@ func (app *App) render(w http.ResponseWriter, r *http.Request, tmpl string, data
 	}
 }
 
+// formatEventDate spells out an event's YYYY-MM-DD date in lang, falling
+// back to the raw value when it doesn't parse.
+func formatEventDate(s, lang string) string {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return s
+	}
+	if lang == LangFR {
+		days := []string{"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"}
+		months := []string{"", "janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"}
+		return fmt.Sprintf("%s %d %s %d", days[t.Weekday()], t.Day(), months[t.Month()], t.Year())
+	}
+	return t.Format("Monday, January 2, 2006")
+}
+
+// formatEventTime formats an event's HH:MM time in lang ("" stays "").
+func formatEventTime(s, lang string) string {
+	if s == "" {
+		return ""
+	}
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return s
+	}
+	if lang == LangFR {
+		return t.Format("15h04")
+	}
+	return t.Format("3:04 PM")
+}
+
+// formatEventTimeZoned formats the event's time in lang followed by its zone
+// and, for remote participants, the same instant in UTC
+// ("14h30 CEST · 12h30 UTC"). Without a zone it is formatEventTime.
+func formatEventTimeZoned(e Event, lang string) string {
+	start, ok := e.StartTime()
+	if !ok {
+		return formatEventTime(e.EventTime, lang)
+	}
+	local := formatEventTime(e.EventTime, lang) + " " + start.Format("MST")
+	if _, offset := start.Zone(); offset == 0 {
+		return local
+	}
+	utc := start.UTC()
+	hint := formatEventTime(utc.Format("15:04"), lang) + " UTC"
+	if day := utc.Format("2006-01-02"); day < e.EventDate {
+		hint += " " + T("time_utc_day_before", lang)
+	} else if day > e.EventDate {
+		hint += " " + T("time_utc_day_after", lang)
+	}
+	return local + " · " + hint
+}
+
+// formatEventEnd formats when the event ends in lang: the end time with the
+// event's length for a same-day event ("16h30 (2 h)"), or the end date for
+// one running over several days. It is "" when no end is set.
+func formatEventEnd(e Event, lang string) string {
+	if e.EndDate != "" && e.EndDate != e.EventDate {
+		s := formatEventDate(e.EndDate, lang)
+		if e.EndTime != "" {
+			s += " " + T("public_event_at", lang) + " " + formatEventTime(e.EndTime, lang)
+		}
+		return s
+	}
+	s := formatEventTime(e.EndTime, lang)
+	if d, ok := e.Duration(); ok && s != "" {
+		s += " (" + formatDuration(d) + ")"
+	}
+	return s
+}
+
+// formatDuration formats d in hours and minutes ("45 min", "2 h", "1 h 30").
+func formatDuration(d time.Duration) string {
+	h, m := int(d.Hours()), int(d.Minutes())%60
+	switch {
+	case h == 0:
+		return fmt.Sprintf("%d min", m)
+	case m == 0:
+		return fmt.Sprintf("%d h", h)
+	}
+	return fmt.Sprintf("%d h %02d", h, m)
+}
+
 func (app *App) buildFuncs(lang string) template.FuncMap {
 	funcs := TemplateFuncs(lang)
 	funcs["buildID"] = func() string { return staticBuildID }
+	funcs["siteTitle"] = func() string { return siteTitle(lang) }
+	funcs["themeColor"] = themeColor
+	funcs["themeColors"] = themeColors
 	funcs["safeHTML"] = func(s string) template.HTML { return template.HTML(s) }
+	funcs["markdown"] = markdownHTML
 	funcs["nl2br"] = func(s string) template.HTML {
 		return template.HTML(strings.ReplaceAll(template.HTMLEscapeString(s), "\n", "<br>"))
 	}
-	funcs["formatDate"] = func(s string) string {
-		t, err := time.Parse("2006-01-02", s)
-		if err != nil {
-			return s
-		}
-		if lang == LangFR {
-			days := []string{"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"}
-			months := []string{"", "janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"}
-			return fmt.Sprintf("%s %d %s %d", days[t.Weekday()], t.Day(), months[t.Month()], t.Year())
-		}
-		return t.Format("Monday, January 2, 2006")
-	}
-	funcs["formatTime"] = func(s string) string {
-		if s == "" {
-			return ""
-		}
-		t, err := time.Parse("15:04", s)
-		if err != nil {
-			return s
-		}
-		if lang == LangFR {
-			return t.Format("15h04")
-		}
-		return t.Format("3:04 PM")
-	}
+	funcs["formatDate"] = func(s string) string { return formatEventDate(s, lang) }
+	funcs["formatTime"] = func(s string) string { return formatEventTime(s, lang) }
+	funcs["eventTime"] = func(e Event) string { return formatEventTimeZoned(e, lang) }
+	funcs["eventEnd"] = func(e Event) string { return formatEventEnd(e, lang) }
+	funcs["schedule"] = BuildSchedule
+	funcs["spamGuard"] = func() template.HTML { return app.spamGuard(lang) }
+	funcs["taskTimes"] = func(t Task) string { return formatTaskTimes(t, lang) }
+	funcs["telURL"] = telURL
+	funcs["groupColors"] = groupColorChoices
+	funcs["dietLabels"] = func(diet string) string { return dietLabels(diet, lang) }
+	funcs["formatSize"] = func(n int64) string { return formatSize(n, lang) }
 	funcs["formatDateTime"] = func(t time.Time) string {
 		if lang == LangFR {
 			return t.Format("02/01/2006 15:04")
@@ -170,8 +456,7 @@ func (app *App) buildFuncs(lang string) template.FuncMap {
 
 func (app *App) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		cookie, err := r.Cookie("admin_session")
-		if err != nil || cookie.Value != app.adminSessionValue() {
+		if _, ok := app.touchSession(w, r); !ok {
 			if r.Header.Get("Content-Type") == "application/json" {
 				http.Error(w, `{"error":"unauthorized"}`, 401)
 				return
@@ -183,8 +468,88 @@ func (app *App) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
-func (app *App) adminSessionValue() string {
-	return fmt.Sprintf("%x", sha256Sum([]byte(app.AdminPassword)))
+// passwordTag identifies the current admin password hash. Sessions record
+// the tag they were opened under and stop matching once the password changes.
+func (app *App) passwordTag() (string, error) {
+	hash, err := app.adminPasswordHash()
+	if err != nil {
+		return "", err
+	}
+	if hash == "" {
+		return "", errNoAdminPassword
+	}
+	secret, err := SettingSecret(app.DB, "session_secret")
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(hash))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// adminSession returns the live session r's cookie points to.
+func (app *App) adminSession(r *http.Request) (Session, bool) {
+	cookie, err := r.Cookie("admin_session")
+	if err != nil || cookie.Value == "" {
+		return Session{}, false
+	}
+	tag, err := app.passwordTag()
+	if err != nil {
+		log.Printf("session: password tag: %v", err)
+		return Session{}, false
+	}
+	s, err := LookupSession(app.DB, cookie.Value, tag)
+	return s, err == nil
+}
+
+// touchSession is adminSession for a request about to be served: it also
+// hands a remembered device its next token when the current one is due.
+func (app *App) touchSession(w http.ResponseWriter, r *http.Request) (Session, bool) {
+	s, ok := app.adminSession(r)
+	if !ok || !s.RotationDue() {
+		return s, ok
+	}
+	cookie, _ := r.Cookie("admin_session")
+	token, rotated, err := RotateSession(app.DB, s.ID, cookie.Value)
+	if err != nil {
+		log.Printf("session %d: rotate: %v", s.ID, err)
+	} else if rotated {
+		setSessionCookie(w, token, true)
+	}
+	return s, true
+}
+
+// setSessionCookie stores a session token. A plain session's cookie lasts
+// as long as the browser stays open; a remembered one outlives it.
+func setSessionCookie(w http.ResponseWriter, token string, remember bool) {
+	c := &http.Cookie{
+		Name:     "admin_session",
+		Value:    token,
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+	if remember {
+		c.MaxAge = int(rememberIdleTimeout.Seconds())
+	}
+	http.SetCookie(w, c)
+}
+
+// startSession logs r's browser in and redirects to next. remember asks for
+// a long-lived session ("remember this device").
+func (app *App) startSession(w http.ResponseWriter, r *http.Request, next string, remember bool) {
+	token, err := app.openSession(r, remember)
+	if err != nil {
+		log.Printf("session: create: %v", err)
+		http.Error(w, T("error_server", LangFromRequest(r)), 500)
+		return
+	}
+	setSessionCookie(w, token, remember)
+	// Only the admin gets here, so the address they use is the instance's.
+	if err := SetSetting(app.DB, adminBaseURLKey, requestBaseURL(r)); err != nil {
+		log.Printf("session: base url: %v", err)
+	}
+	http.Redirect(w, r, next, http.StatusSeeOther)
 }
 
 func (app *App) handleLangSwitch(w http.ResponseWriter, r *http.Request) {
@@ -203,18 +568,15 @@ func (app *App) handleLangSwitch(w http.ResponseWriter, r *http.Request) {
 // ---- Admin Login ----
 
 func (app *App) handleAdminLogin(w http.ResponseWriter, r *http.Request) {
-	pd := app.newPageData(r, nil)
+	pd := app.newPageData(r, map[string]any{"EmailLogin": len(adminLoginEmails(app)) > 0, "OIDC": app.OIDC})
+	pd.Success, pd.Error = takeFlash(w, r)
+	if h, err := app.adminPasswordHash(); err == nil && h == "" {
+		http.Redirect(w, r, "/admin/setup?lang="+pd.Lang, http.StatusSeeOther)
+		return
+	}
 	if r.Method == http.MethodPost {
-		if r.FormValue("password") == app.AdminPassword {
-			http.SetCookie(w, &http.Cookie{
-				Name:     "admin_session",
-				Value:    app.adminSessionValue(),
-				Path:     "/",
-				MaxAge:   24 * 60 * 60,
-				HttpOnly: true,
-				SameSite: http.SameSiteLaxMode,
-			})
-			http.Redirect(w, r, "/admin?lang="+pd.Lang, http.StatusSeeOther)
+		if app.checkAdminPassword(r.FormValue("password")) {
+			app.startSession(w, r, "/admin?lang="+pd.Lang, r.FormValue("remember") != "")
 			return
 		}
 		pd.Error = T("admin_login_error", pd.Lang)
@@ -222,11 +584,63 @@ func (app *App) handleAdminLogin(w http.ResponseWriter, r *http.Request) {
 	app.render(w, r, "admin_login.html", pd)
 }
 
+// openSession records a new admin session for r's browser, clearing out
+// expired ones on the way, and returns its cookie token.
+func (app *App) openSession(r *http.Request, remember bool) (string, error) {
+	tag, err := app.passwordTag()
+	if err != nil {
+		return "", err
+	}
+	if err := PurgeSessions(app.DB, tag); err != nil {
+		log.Printf("session: purge: %v", err)
+	}
+	return CreateSession(app.DB, tag, r.UserAgent(), remember)
+}
+
 func (app *App) handleAdminLogout(w http.ResponseWriter, r *http.Request) {
+	if s, ok := app.adminSession(r); ok {
+		if err := DeleteSession(app.DB, s.ID); err != nil {
+			log.Printf("session %d: delete: %v", s.ID, err)
+		}
+	}
 	http.SetCookie(w, &http.Cookie{Name: "admin_session", Value: "", Path: "/", MaxAge: -1})
 	http.Redirect(w, r, "/admin/login", http.StatusSeeOther)
 }
 
+// handleAdminLogoutAll ends every admin session, this one included — for a
+// lost phone or a shared computer.
+func (app *App) handleAdminLogoutAll(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/admin/settings", http.StatusSeeOther)
+		return
+	}
+	if err := DeleteAllSessions(app.DB); err != nil {
+		log.Printf("session: delete all: %v", err)
+		setFlash(w, "error", T("error_server", LangFromRequest(r)))
+		http.Redirect(w, r, "/admin/settings?lang="+LangFromRequest(r), http.StatusSeeOther)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{Name: "admin_session", Value: "", Path: "/", MaxAge: -1})
+	http.Redirect(w, r, "/admin/login?lang="+LangFromRequest(r), http.StatusSeeOther)
+}
+
+// handleAdminSessionRevoke ends one other session listed on the settings page.
+func (app *App) handleAdminSessionRevoke(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/admin/settings", http.StatusSeeOther)
+		return
+	}
+	lang := LangFromRequest(r)
+	id, _ := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	if err := DeleteSession(app.DB, id); err != nil {
+		log.Printf("session %d: delete: %v", id, err)
+		setFlash(w, "error", T("error_server", lang))
+	} else {
+		setFlash(w, "success", T("sessions_revoked", lang))
+	}
+	http.Redirect(w, r, "/admin/settings?lang="+lang, http.StatusSeeOther)
+}
+
 // setFlash stores a one-shot message in a short-lived cookie, shown once on the
 // next page render. kind is "success" or "error". This backs the
 // Post/Redirect/Get pattern: a handler redirects after a POST so a browser
@@ -274,7 +688,16 @@ func (app *App) santaRedirect(w http.ResponseWriter, r *http.Request, eventID in
 // ---- Admin Events List ----
 
 func (app *App) handleAdminEvents(w http.ResponseWriter, r *http.Request) {
-	events, _ := ListEvents(app.DB)
+	all, _ := ListEvents(app.DB)
+	lang := LangFromRequest(r)
+	filter := parseEventListFilter(r)
+	events, tabCounts := filterEvents(all, filter, time.Now().Format("2006-01-02"), lang)
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	pagination := newPagination("/admin", filter.values(lang), max(page, 1), len(events), eventsPerPage)
+	if pagination.Page > pagination.Pages {
+		pagination = newPagination("/admin", filter.values(lang), pagination.Pages, len(events), eventsPerPage)
+	}
+	events = events[max(pagination.From-1, 0):pagination.To]
 	for i := range events {
 		if events[i].EventType == "attendance" {
 			yesCount, totalCount := CountAttendances(app.DB, events[i].ID)
@@ -289,9 +712,24 @@ func (app *App) handleAdminEvents(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 	pd := app.newPageData(r, map[string]any{
-		"Events":  events,
-		"BaseURL": baseURLFor(r),
+		"Events":       events,
+		"HasEvents":    len(all) > 0,
+		"Filter":       filter,
+		"Tabs":         eventTabs,
+		"TabCounts":    tabCounts,
+		"Sorts":        eventSorts,
+		"Views":        eventViews,
+		"Pagination":   pagination,
+		"BaseURL":      app.baseURLFor(r),
+		"FailedEmails": CountFailedEmails(app.DB),
+		"HeldSignups":  CountSignupReviews(app.DB),
+		"Understaffed": CountUnderstaffedTasks(app.DB, time.Now().Format("2006-01-02")),
+		"Trash":        CountTrash(app.DB),
+		"Templates":    CountEventTemplates(app.DB),
+		"AutoBackup":   app.backupStatus(),
+		"Maintenance":  lastMaintenance(app.DB),
 	})
+	pd.Success, pd.Error = takeFlash(w, r)
 	app.render(w, r, "admin_events.html", pd)
 }
 
@@ -303,6 +741,7 @@ func (app *App) handleAdminEventNew(w http.ResponseWriter, r *http.Request) {
 		if eventType != "attendance" && eventType != "secret_santa" {
 			eventType = "tasks"
 		}
+		timezone, tzErr := normalizeTimezone(r.FormValue("timezone"))
 		e := &Event{
 			TitleFR:       r.FormValue("title_fr"),
 			TitleEN:       r.FormValue("title_en"),
@@ -310,28 +749,51 @@ func (app *App) handleAdminEventNew(w http.ResponseWriter, r *http.Request) {
 			DescriptionEN: sanitizeEventDescription(r.FormValue("description_en")),
 			EventDate:     r.FormValue("event_date"),
 			EventTime:     r.FormValue("event_time"),
+			EndDate:       r.FormValue("end_date"),
+			EndTime:       r.FormValue("end_time"),
 			EventType:     eventType,
+			Timezone:      timezone,
+			Draft:         true,
 		}
-		if e.TitleFR == "" || e.EventDate == "" {
-			pd := app.newPageData(r, map[string]any{"Event": e, "IsNew": true})
+		templateID, _ := strconv.ParseInt(r.FormValue("template_id"), 10, 64)
+		if e.TitleFR == "" || e.EventDate == "" || tzErr != nil || normalizeEventEnd(e) != nil {
+			pd := app.newPageData(r, app.eventNewData(e, templateID))
 			pd.Error = T("error_invalid_form", pd.Lang)
 			app.render(w, r, "admin_event_edit.html", pd)
 			return
 		}
 		if err := CreateEvent(app.DB, e); err != nil {
 			log.Printf("create event error: %v", err)
-			pd := app.newPageData(r, map[string]any{"Event": e, "IsNew": true})
+			pd := app.newPageData(r, app.eventNewData(e, templateID))
 			pd.Error = T("error_server", pd.Lang)
 			app.render(w, r, "admin_event_edit.html", pd)
 			return
 		}
+		if templateID > 0 && eventType == "tasks" {
+			if tpl, err := GetEventTemplate(app.DB, templateID); err != nil {
+				log.Printf("create event: template %d: %v", templateID, err)
+			} else if err := ApplyEventTemplate(app.DB, tpl, e.ID); err != nil {
+				log.Printf("create event: apply template %d to event %d: %v", templateID, e.ID, err)
+			}
+		}
 		http.Redirect(w, r, fmt.Sprintf("/admin/event/edit?id=%d&lang=%s", e.ID, LangFromRequest(r)), http.StatusSeeOther)
 		return
 	}
-	pd := app.newPageData(r, map[string]any{"Event": &Event{}, "IsNew": true})
+	templateID, _ := strconv.ParseInt(r.URL.Query().Get("template"), 10, 64)
+	pd := app.newPageData(r, app.eventNewData(&Event{}, templateID))
 	app.render(w, r, "admin_event_edit.html", pd)
 }
 
+// eventNewData is the page data of the new-event form, which can start from
+// a saved template.
+func (app *App) eventNewData(e *Event, templateID int64) map[string]any {
+	templates, err := ListEventTemplates(app.DB)
+	if err != nil {
+		log.Printf("templates: list: %v", err)
+	}
+	return map[string]any{"Event": e, "IsNew": true, "Templates": templates, "TemplateID": templateID}
+}
+
 // ---- Admin Event Edit (UNIFIED PAGE) ----
 
 func (app *App) handleAdminEventEdit(w http.ResponseWriter, r *http.Request) {
@@ -350,8 +812,10 @@ func (app *App) handleAdminEventEdit(w http.ResponseWriter, r *http.Request) {
 		event.DescriptionEN = sanitizeEventDescription(r.FormValue("description_en"))
 		event.EventDate = r.FormValue("event_date")
 		event.EventTime = r.FormValue("event_time")
+		event.EndDate = r.FormValue("end_date")
+		event.EndTime = r.FormValue("end_time")
 
-		if event.TitleFR == "" || event.EventDate == "" {
+		if event.TitleFR == "" || event.EventDate == "" || normalizeEventEnd(event) != nil {
 			pd := app.newPageData(r, app.eventEditData(r, event))
 			pd.Error = T("error_invalid_form", lang)
 			app.render(w, r, "admin_event_edit.html", pd)
@@ -373,13 +837,42 @@ func (app *App) eventEditData(r *http.Request, event *Event) map[string]any {
 	data := map[string]any{
 		"Event":   event,
 		"IsNew":   false,
-		"BaseURL": baseURLFor(r),
+		"BaseURL": app.eventBaseURL(r, event),
+		// RequestBaseURL lets the edit page recompute the public link when
+		// the custom domain is cleared without a reload.
+		"RequestBaseURL": app.baseURLFor(r),
+		// The retention period applying when the event sets none.
+		"InstanceRetention": instanceRetentionMonths(app.DB),
+	}
+	if event.SeriesID.Valid {
+		if series, err := GetSeries(app.DB, event.SeriesID.Int64); err == nil {
+			data["Series"] = series
+		}
+	}
+	if oldSlugs, err := ListOldSlugs(app.DB, event.ID); err == nil {
+		data["OldSlugs"] = oldSlugs
+	}
+	if theme := app.pageTheme(event); theme != nil {
+		data["Theme"] = theme
+	}
+	data["Shares"] = app.eventShares(r, event)
+	if n, err := CountOpeningNotices(app.DB, event.ID); err == nil {
+		data["OpeningNotices"] = n
+	}
+	if invites, err := ListInvites(app.DB, event.ID); err == nil {
+		data["Invites"] = invites
+	}
+	if short, err := EnsureShortLink(app.DB, event.ID); err == nil {
+		data["ShortLink"] = short
+	} else {
+		log.Printf("short link of event %d: %v", event.ID, err)
 	}
 
 	if event.EventType == "attendance" {
 		yesCount, totalCount := CountAttendances(app.DB, event.ID)
 		data["AttendanceYes"] = yesCount
 		data["AttendanceTotal"] = totalCount
+		data["Headcount"] = AttendanceHeadcount(app.DB, event.ID)
 	} else if event.EventType == "secret_santa" {
 		for k, v := range app.santaAdminData(event) {
 			data[k] = v
@@ -394,7 +887,11 @@ func (app *App) eventEditData(r *http.Request, event *Event) map[string]any {
 		data["FlatGroups"] = flatGroups
 		data["AllTasks"] = allTasks
 		data["TotalRegs"] = totalRegs
-		data["HasAI"] = app.AnthropicKey != ""
+		data["HasAI"] = app.anthropicKey() != ""
+		data["Fields"], _ = ListFormFields(app.DB, event.ID)
+		rules, _ := ListTaskRules(app.DB, event.ID)
+		data["TaskRules"] = rules
+		data["RulesByTask"] = rulesByTask(rules)
 	}
 
 	return data
@@ -422,6 +919,53 @@ func (app *App) handleAdminEventDelete(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, "/admin?lang="+LangFromRequest(r), http.StatusSeeOther)
 }
 
+// handleAdminEventDuplicate copies an event and its groups and tasks, without
+// registrations, to a new date, then opens the copy for editing.
+func (app *App) handleAdminEventDuplicate(w http.ResponseWriter, r *http.Request) {
+	lang := LangFromRequest(r)
+	adminURL := "/admin?lang=" + lang
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, adminURL, http.StatusSeeOther)
+		return
+	}
+	id, _ := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	event, err := GetEvent(app.DB, id)
+	if err != nil {
+		http.Redirect(w, r, adminURL, http.StatusSeeOther)
+		return
+	}
+	date := r.FormValue("event_date")
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		setFlash(w, "error", T("event_duplicate_error_date", lang))
+		http.Redirect(w, r, adminURL, http.StatusSeeOther)
+		return
+	}
+
+	dup := &Event{EventDate: date, Slug: GenerateSlug(event.TitleFR + " " + date)}
+	copySeriesFields(dup, *event)
+	dup.Draft = true
+	if err := CreateEvent(app.DB, dup); err != nil {
+		log.Printf("duplicate event %d: %v", event.ID, err)
+		setFlash(w, "error", T("error_server", lang))
+		http.Redirect(w, r, adminURL, http.StatusSeeOther)
+		return
+	}
+	if err := ReplaceTaskStructure(app.DB, event.ID, dup.ID); err != nil {
+		log.Printf("duplicate event %d: copy tasks to event %d: %v", event.ID, dup.ID, err)
+	}
+	if err := ReplaceFormFields(app.DB, event.ID, dup.ID); err != nil {
+		log.Printf("duplicate event %d: copy signup fields to event %d: %v", event.ID, dup.ID, err)
+	}
+	if err := ReplaceTaskRules(app.DB, event.ID, dup.ID); err != nil {
+		log.Printf("duplicate event %d: copy task rules to event %d: %v", event.ID, dup.ID, err)
+	}
+	if err := ReplaceEventTheme(app.DB, event.ID, dup.ID); err != nil {
+		log.Printf("duplicate event %d: copy theme to event %d: %v", event.ID, dup.ID, err)
+	}
+	setFlash(w, "success", T("event_duplicated", lang))
+	http.Redirect(w, r, fmt.Sprintf("/admin/event/edit?id=%d&lang=%s", dup.ID, lang), http.StatusSeeOther)
+}
+
 // ---- Admin Group CRUD (form-based, redirects back to event edit) ----
 
 func (app *App) handleAdminGroupSave(w http.ResponseWriter, r *http.Request) {
@@ -433,16 +977,22 @@ func (app *App) handleAdminGroupSave(w http.ResponseWriter, r *http.Request) {
 	eventID, _ := strconv.ParseInt(r.FormValue("event_id"), 10, 64)
 	id, _ := strconv.ParseInt(r.FormValue("id"), 10, 64)
 
-	g := &TaskGroup{ID: id, EventID: eventID, TitleFR: r.FormValue("title_fr"), TitleEN: r.FormValue("title_en")}
+	g := &TaskGroup{ID: id, EventID: eventID, TitleFR: r.FormValue("title_fr"), TitleEN: r.FormValue("title_en"),
+		Color: r.FormValue("color"), Icon: r.FormValue("icon")}
 	if pid := r.FormValue("parent_group_id"); pid != "" && pid != "0" {
 		v, _ := strconv.ParseInt(pid, 10, 64)
 		g.ParentGroupID = sql.NullInt64{Int64: v, Valid: true}
 	}
+	if v, err := strconv.ParseInt(r.FormValue("max_slots"), 10, 64); err == nil && v > 0 {
+		g.MaxSlots = sql.NullInt64{Int64: v, Valid: true}
+	}
+	normalizeGroupStyle(g)
 	if id > 0 {
 		UpdateTaskGroup(app.DB, g)
 	} else {
 		CreateTaskGroup(app.DB, g)
 	}
+	app.snapshotStructure(eventID, "edit")
 	http.Redirect(w, r, fmt.Sprintf("/admin/event/edit?id=%d&lang=%s#groups-tasks", eventID, lang), http.StatusSeeOther)
 }
 
@@ -455,6 +1005,7 @@ func (app *App) handleAdminGroupDelete(w http.ResponseWriter, r *http.Request) {
 	eventID, _ := strconv.ParseInt(r.FormValue("event_id"), 10, 64)
 	id, _ := strconv.ParseInt(r.FormValue("id"), 10, 64)
 	DeleteTaskGroup(app.DB, id)
+	app.snapshotStructure(eventID, "delete")
 	http.Redirect(w, r, fmt.Sprintf("/admin/event/edit?id=%d&lang=%s#groups-tasks", eventID, lang), http.StatusSeeOther)
 }
 
@@ -474,6 +1025,9 @@ func (app *App) handleAdminTaskSave(w http.ResponseWriter, r *http.Request) {
 		TitleFR: r.FormValue("title_fr"), TitleEN: r.FormValue("title_en"),
 		DescriptionFR: r.FormValue("description_fr"), DescriptionEN: r.FormValue("description_en"),
 	}
+	if start, end := r.FormValue("start_time"), r.FormValue("end_time"); validTaskTime(start) && validTaskTime(end) {
+		t.StartTime, t.EndTime = start, end
+	}
 
 	if ms := r.FormValue("max_slots"); ms != "" {
 		v, _ := strconv.ParseInt(ms, 10, 64)
@@ -481,6 +1035,13 @@ func (app *App) handleAdminTaskSave(w http.ResponseWriter, r *http.Request) {
 			t.MaxSlots = sql.NullInt64{Int64: v, Valid: true}
 		}
 	}
+	t.MaxGuests = parseMaxGuests(r.FormValue("max_guests"))
+	t.MinSlots, _ = strconv.Atoi(strings.TrimSpace(r.FormValue("min_slots")))
+	t.Skills = normalizeSkills(r.FormValue("skills"))
+	t.ReservedSlots, _ = strconv.Atoi(strings.TrimSpace(r.FormValue("reserved_slots")))
+	t.Notes = strings.TrimSpace(r.FormValue("notes"))
+	normalizeMinSlots(t)
+	normalizeReservedSlots(t)
 
 	if id > 0 {
 		// group_id is managed by drag-and-drop reorder, not inline edits
@@ -493,9 +1054,31 @@ func (app *App) handleAdminTaskSave(w http.ResponseWriter, r *http.Request) {
 		}
 		CreateTask(app.DB, t)
 	}
+	app.snapshotStructure(eventID, "edit")
 	http.Redirect(w, r, fmt.Sprintf("/admin/event/edit?id=%d&lang=%s#groups-tasks", eventID, lang), http.StatusSeeOther)
 }
 
+// maxTaskGuests bounds how many people a registrant, or someone answering
+// an RSVP, may bring along.
+const maxTaskGuests = 20
+
+// parseMaxGuests reads a task's or an attendance event's guest limit typed
+// in the admin.
+func parseMaxGuests(raw string) int {
+	n, _ := strconv.Atoi(strings.TrimSpace(raw))
+	return min(max(n, 0), maxTaskGuests)
+}
+
+// maxTasksPerPerson bounds Event.MaxTasksPerPerson.
+const maxTasksPerPerson = 50
+
+// parseMaxTasksPerPerson reads an event's per-person task limit typed in the
+// admin; empty means one.
+func parseMaxTasksPerPerson(raw string) int {
+	n, _ := strconv.Atoi(strings.TrimSpace(raw))
+	return min(max(n, 1), maxTasksPerPerson)
+}
+
 func (app *App) handleAdminTaskDelete(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Redirect(w, r, "/admin", http.StatusSeeOther)
@@ -505,6 +1088,7 @@ func (app *App) handleAdminTaskDelete(w http.ResponseWriter, r *http.Request) {
 	eventID, _ := strconv.ParseInt(r.FormValue("event_id"), 10, 64)
 	id, _ := strconv.ParseInt(r.FormValue("id"), 10, 64)
 	DeleteTask(app.DB, id)
+	app.snapshotStructure(eventID, "delete")
 	http.Redirect(w, r, fmt.Sprintf("/admin/event/edit?id=%d&lang=%s#groups-tasks", eventID, lang), http.StatusSeeOther)
 }
 
@@ -522,6 +1106,8 @@ func (app *App) handleAdminRegistrationDelete(w http.ResponseWriter, r *http.Req
 	http.Redirect(w, r, fmt.Sprintf("/admin/event/registrations?id=%d&lang=%s", eventID, lang), http.StatusSeeOther)
 }
 
+// handleAdminClearAll moves an event's whole tree to the trash, once the
+// admin has typed the event's title to confirm.
 func (app *App) handleAdminClearAll(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Redirect(w, r, "/admin", http.StatusSeeOther)
@@ -529,9 +1115,27 @@ func (app *App) handleAdminClearAll(w http.ResponseWriter, r *http.Request) {
 	}
 	lang := LangFromRequest(r)
 	eventID, _ := strconv.ParseInt(r.FormValue("event_id"), 10, 64)
-	app.DB.Exec("DELETE FROM tasks WHERE event_id=?", eventID)
-	app.DB.Exec("DELETE FROM task_groups WHERE event_id=?", eventID)
-	http.Redirect(w, r, fmt.Sprintf("/admin/event/edit?id=%d&lang=%s#groups-tasks", eventID, lang), http.StatusSeeOther)
+	event, err := GetEvent(app.DB, eventID)
+	if err != nil {
+		http.Redirect(w, r, "/admin", http.StatusSeeOther)
+		return
+	}
+	back := fmt.Sprintf("/admin/event/edit?id=%d&lang=%s#groups-tasks", event.ID, lang)
+	typed := strings.TrimSpace(r.FormValue("confirm_title"))
+	if typed == "" || !strings.EqualFold(typed, strings.TrimSpace(event.TitleFR)) && !strings.EqualFold(typed, strings.TrimSpace(event.TitleEN)) {
+		setFlash(w, "error", T("group_clear_mismatch", lang))
+		http.Redirect(w, r, back, http.StatusSeeOther)
+		return
+	}
+	app.snapshotStructure(event.ID, "before_clear")
+	if err := ClearEventStructure(app.DB, event); err != nil {
+		log.Printf("clear structure of event %d: %v", event.ID, err)
+		setFlash(w, "error", T("error_server", lang))
+	} else {
+		app.snapshotStructure(event.ID, "clear")
+		setFlash(w, "success", T("group_cleared", lang))
+	}
+	http.Redirect(w, r, back, http.StatusSeeOther)
 }
 
 func (app *App) handleAPIUpdateMaxSlots(w http.ResponseWriter, r *http.Request) {
@@ -552,10 +1156,42 @@ func (app *App) handleAPIUpdateMaxSlots(w http.ResponseWriter, r *http.Request)
 		ms = sql.NullInt64{Int64: *req.MaxSlots, Valid: true}
 	}
 	app.DB.Exec("UPDATE tasks SET max_slots=? WHERE id=?", ms, req.TaskID)
+	app.snapshotStructure(structureEventID(app.DB, "task", req.TaskID), "edit")
 	w.Header().Set("Content-Type", "application/json")
 	w.Write([]byte(`{"ok":true}`))
 }
 
+// handleAPIBulkMaxSlots sets the max slots of several tasks of an event at
+// once: {"event_id", "task_ids", "max_slots"}, null or 0 for no limit.
+func (app *App) handleAPIBulkMaxSlots(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", 405)
+		return
+	}
+	var req struct {
+		EventID  int64   `json:"event_id"`
+		TaskIDs  []int64 `json:"task_ids"`
+		MaxSlots *int64  `json:"max_slots"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"bad request"}`, 400)
+		return
+	}
+	var ms sql.NullInt64
+	if req.MaxSlots != nil && *req.MaxSlots > 0 {
+		ms = sql.NullInt64{Int64: *req.MaxSlots, Valid: true}
+	}
+	n, err := SetTasksMaxSlots(app.DB, req.EventID, req.TaskIDs, ms)
+	if err != nil {
+		log.Printf("bulk max slots error (event %d): %v", req.EventID, err)
+		http.Error(w, `{"error":"server error"}`, 500)
+		return
+	}
+	app.snapshotStructure(req.EventID, "edit")
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"ok": true, "updated": n})
+}
+
 // ---- Admin CSV Export ----
 
 func (app *App) handleAdminExportCSV(w http.ResponseWriter, r *http.Request) {
@@ -570,15 +1206,37 @@ func (app *App) handleAdminExportCSV(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	regs, _ := ListAllRegistrations(app.DB, eventID)
+	app.writeRegistrationsCSV(w, event, regs, event.Slug+"-inscriptions.csv")
+}
+
+// writeRegistrationsCSV sends regs, registrations of event, as a CSV file
+// download named filename.
+func (app *App) writeRegistrationsCSV(w http.ResponseWriter, event *Event, regs []RegistrationExport, filename string) {
+	fields, _ := ListFormFields(app.DB, event.ID)
+	answers, _ := ListRegistrationAnswers(app.DB, event.ID)
 
 	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
-	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-inscriptions.csv"`, event.Slug))
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
 	w.Write([]byte{0xEF, 0xBB, 0xBF})
 
 	cw := csv.NewWriter(w)
-	cw.Write([]string{"Groupe", "Tâche", "Prénom", "Nom", "Email", "Téléphone", "Date inscription"})
+	header := []string{"Groupe", "Tâche", "Prénom", "Nom", "Email", "Téléphone", "Accompagnants", "Commentaire", "Langue", "Date inscription"}
+	if event.CollectDiet {
+		header = append(header, "Régime", "Allergies")
+	}
+	for _, f := range fields {
+		header = append(header, f.LabelFR)
+	}
+	cw.Write(header)
 	for _, reg := range regs {
-		cw.Write([]string{reg.GroupTitle, reg.TaskTitle, reg.FirstName, reg.LastName, reg.Email, reg.Phone, reg.CreatedAt.Format("2006-01-02 15:04")})
+		row := []string{reg.GroupTitle, reg.TaskTitle, reg.FirstName, reg.LastName, reg.Email, reg.Phone, strconv.Itoa(reg.Guests), reg.Comment, reg.Lang, event.InZone(reg.CreatedAt).Format("2006-01-02 15:04")}
+		if event.CollectDiet {
+			row = append(row, dietLabels(reg.Diet, LangFR), reg.Allergies)
+		}
+		for _, f := range fields {
+			row = append(row, f.Display(answers[reg.ID][f.ID]))
+		}
+		cw.Write(row)
 	}
 	cw.Flush()
 }
@@ -600,6 +1258,9 @@ func (app *App) handleAPIReorder(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, `{"error":"server error"}`, 500)
 		return
 	}
+	if len(nodes) > 0 {
+		app.snapshotStructure(structureEventID(app.DB, nodes[0].Type, nodes[0].ID), "reorder")
+	}
 	w.Header().Set("Content-Type", "application/json")
 	w.Write([]byte(`{"ok":true}`))
 }
@@ -619,7 +1280,30 @@ func (app *App) handleAPIEventSave(w http.ResponseWriter, r *http.Request) {
 		DescriptionEN     string `json:"description_en"`
 		EventDate         string `json:"event_date"`
 		EventTime         string `json:"event_time"`
+		EndDate           string `json:"end_date"`
+		EndTime           string `json:"end_time"`
 		EventType         string `json:"event_type"`
+		BaseURL           string `json:"base_url"`
+		TermsFR           string `json:"terms_fr"`
+		TermsEN           string `json:"terms_en"`
+		NotifyEmails      string `json:"notify_emails"`
+		OrganizerEmail    string `json:"organizer_email"`
+		Timezone          string `json:"timezone"`
+		CancelDays        string `json:"cancel_days"`
+		RetentionMonths   string `json:"retention_months"`
+		OpensAt           string `json:"registration_opens_at"`
+		ClosesAt          string `json:"registration_closes_at"`
+		AccessCode        string `json:"access_code"`
+		MaxTasks          string `json:"max_tasks_per_person"`
+		MaxParticipants   string `json:"max_participants"`
+		CollectDiet       bool   `json:"collect_diet"`
+		CountsOnly        bool   `json:"counts_only"`
+		CheckIn           bool   `json:"checkin"`
+		AssignMode        bool   `json:"assign_mode"`
+		FamilySignup      bool   `json:"family_signup"`
+		Lang              string `json:"lang"`
+		NoIndex           bool   `json:"noindex"`
+		MaxGuests         string `json:"max_guests"`
 		EmailHookFR       string `json:"email_hook_fr"`
 		EmailHookEN       string `json:"email_hook_en"`
 		EmailHowTitleFR   string `json:"email_how_title_fr"`
@@ -634,11 +1318,57 @@ func (app *App) handleAPIEventSave(w http.ResponseWriter, r *http.Request) {
 		EmailButtonEN     string `json:"email_button_en"`
 		EmailDisclaimerFR string `json:"email_disclaimer_fr"`
 		EmailDisclaimerEN string `json:"email_disclaimer_en"`
+		AfterInfoFR       string `json:"after_info_fr"`
+		AfterInfoEN       string `json:"after_info_en"`
+		AfterBringFR      string `json:"after_bring_fr"`
+		AfterBringEN      string `json:"after_bring_en"`
+		AfterContactFR    string `json:"after_contact_fr"`
+		AfterContactEN    string `json:"after_contact_en"`
+		Notes             string `json:"notes"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, `{"error":"bad request"}`, 400)
 		return
 	}
+	baseURL, err := normalizeBaseURL(req.BaseURL)
+	if err != nil {
+		http.Error(w, `{"error":"invalid base url"}`, 400)
+		return
+	}
+	notifyEmails, err := normalizeNotifyEmails(req.NotifyEmails)
+	if err != nil {
+		http.Error(w, `{"error":"invalid notification email"}`, 400)
+		return
+	}
+	organizerEmail := strings.TrimSpace(req.OrganizerEmail)
+	if organizerEmail != "" {
+		a, err := mail.ParseAddress(organizerEmail)
+		if err != nil {
+			http.Error(w, `{"error":"invalid organizer email"}`, 400)
+			return
+		}
+		organizerEmail = a.Address
+	}
+	timezone, err := normalizeTimezone(req.Timezone)
+	if err != nil {
+		http.Error(w, `{"error":"invalid timezone"}`, 400)
+		return
+	}
+	cancelDays, err := parseCancelDays(req.CancelDays)
+	if err != nil {
+		http.Error(w, `{"error":"invalid cancel days"}`, 400)
+		return
+	}
+	retentionMonths, err := parseRetentionMonths(req.RetentionMonths)
+	if err != nil {
+		http.Error(w, `{"error":"invalid retention months"}`, 400)
+		return
+	}
+	maxParticipants, err := parseMaxParticipants(req.MaxParticipants)
+	if err != nil {
+		http.Error(w, `{"error":"invalid max participants"}`, 400)
+		return
+	}
 	// Preserve existing event type if not provided (auto-save doesn't send it)
 	eventType := req.EventType
 	if eventType == "" {
@@ -653,6 +1383,16 @@ func (app *App) handleAPIEventSave(w http.ResponseWriter, r *http.Request) {
 		ID: req.EventID, TitleFR: req.TitleFR, TitleEN: req.TitleEN,
 		DescriptionFR: sanitizeEventDescription(req.DescriptionFR), DescriptionEN: sanitizeEventDescription(req.DescriptionEN),
 		EventDate: req.EventDate, EventTime: req.EventTime, EventType: eventType,
+		EndDate:           req.EndDate,
+		EndTime:           req.EndTime,
+		BaseURL:           baseURL,
+		TermsFR:           strings.TrimSpace(req.TermsFR),
+		TermsEN:           strings.TrimSpace(req.TermsEN),
+		NotifyEmails:      notifyEmails,
+		OrganizerEmail:    organizerEmail,
+		Timezone:          timezone,
+		CancelDays:        cancelDays,
+		RetentionMonths:   retentionMonths,
 		EmailHookFR:       req.EmailHookFR,
 		EmailHookEN:       req.EmailHookEN,
 		EmailHowTitleFR:   req.EmailHowTitleFR,
@@ -667,6 +1407,33 @@ func (app *App) handleAPIEventSave(w http.ResponseWriter, r *http.Request) {
 		EmailButtonEN:     req.EmailButtonEN,
 		EmailDisclaimerFR: req.EmailDisclaimerFR,
 		EmailDisclaimerEN: req.EmailDisclaimerEN,
+		AfterInfoFR:       strings.TrimSpace(req.AfterInfoFR),
+		AfterInfoEN:       strings.TrimSpace(req.AfterInfoEN),
+		AfterBringFR:      strings.TrimSpace(req.AfterBringFR),
+		AfterBringEN:      strings.TrimSpace(req.AfterBringEN),
+		AfterContactFR:    strings.TrimSpace(req.AfterContactFR),
+		AfterContactEN:    strings.TrimSpace(req.AfterContactEN),
+	}
+	if err := normalizeEventEnd(e); err != nil {
+		http.Error(w, `{"error":"invalid end"}`, 400)
+		return
+	}
+	e.RegistrationOpensAt, e.RegistrationClosesAt = req.OpensAt, req.ClosesAt
+	e.AccessCode = normalizeAccessCode(req.AccessCode)
+	e.MaxTasksPerPerson = parseMaxTasksPerPerson(req.MaxTasks)
+	e.MaxParticipants = maxParticipants
+	e.CollectDiet = req.CollectDiet
+	e.CountsOnly = req.CountsOnly
+	e.CheckIn = req.CheckIn
+	e.AssignMode = req.AssignMode
+	e.FamilySignup = req.FamilySignup
+	e.Lang = normalizeEventLang(req.Lang)
+	e.NoIndex = req.NoIndex
+	e.MaxGuests = parseMaxGuests(req.MaxGuests)
+	e.Notes = strings.TrimSpace(req.Notes)
+	if err := normalizeRegistrationWindow(e); err != nil {
+		http.Error(w, `{"error":"invalid registration window"}`, 400)
+		return
 	}
 	if err := UpdateEvent(app.DB, e); err != nil {
 		http.Error(w, `{"error":"save failed"}`, 500)
@@ -734,11 +1501,11 @@ func (app *App) handleAPIEventEmailPreview(w http.ResponseWriter, r *http.Reques
 		EmailDisclaimerFR: req.EmailDisclaimerFR,
 		EmailDisclaimerEN: req.EmailDisclaimerEN,
 	}
-	base := baseURLFor(r)
+	base := app.baseURLFor(r)
 	pFR := SantaParticipant{FirstName: "Marie"}
 	pEN := SantaParticipant{FirstName: "Mary"}
-	_, frHTML := renderSantaLinkEmail("fr", pFR, e, base+"/santa/edit?token=preview")
-	_, enHTML := renderSantaLinkEmail("en", pEN, e, base+"/santa/edit?token=preview")
+	_, frHTML := renderSantaLinkEmail("fr", pFR, e, base+"/santa/edit?token=preview", "")
+	_, enHTML := renderSantaLinkEmail("en", pEN, e, base+"/santa/edit?token=preview", "")
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{"fr": frHTML, "en": enHTML})
 }
@@ -760,6 +1527,7 @@ func (app *App) handleAPIGroupCreate(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, `{"error":"create failed"}`, 500)
 		return
 	}
+	app.snapshotStructure(g.EventID, "edit")
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]int64{"id": g.ID})
 }
@@ -770,19 +1538,27 @@ func (app *App) handleAPIGroupSave(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	var req struct {
-		ID      int64  `json:"id"`
-		TitleFR string `json:"title_fr"`
-		TitleEN string `json:"title_en"`
+		ID       int64  `json:"id"`
+		TitleFR  string `json:"title_fr"`
+		TitleEN  string `json:"title_en"`
+		MaxSlots *int64 `json:"max_slots"`
+		Color    string `json:"color"`
+		Icon     string `json:"icon"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, `{"error":"bad request"}`, 400)
 		return
 	}
-	g := &TaskGroup{ID: req.ID, TitleFR: req.TitleFR, TitleEN: req.TitleEN}
+	g := &TaskGroup{ID: req.ID, TitleFR: req.TitleFR, TitleEN: req.TitleEN, Color: req.Color, Icon: req.Icon}
+	if req.MaxSlots != nil && *req.MaxSlots > 0 {
+		g.MaxSlots = sql.NullInt64{Int64: *req.MaxSlots, Valid: true}
+	}
+	normalizeGroupStyle(g)
 	if err := UpdateTaskGroup(app.DB, g); err != nil {
 		http.Error(w, `{"error":"save failed"}`, 500)
 		return
 	}
+	app.snapshotStructure(structureEventID(app.DB, "group", g.ID), "edit")
 	w.Header().Set("Content-Type", "application/json")
 	w.Write([]byte(`{"ok":true}`))
 }
@@ -800,6 +1576,7 @@ func (app *App) handleAPIGroupDelete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	DeleteTaskGroup(app.DB, req.ID)
+	app.snapshotStructure(structureEventID(app.DB, "group", req.ID), "delete")
 	w.Header().Set("Content-Type", "application/json")
 	w.Write([]byte(`{"ok":true}`))
 }
@@ -821,6 +1598,7 @@ func (app *App) handleAPITaskCreate(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, `{"error":"create failed"}`, 500)
 		return
 	}
+	app.snapshotStructure(t.EventID, "edit")
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]int64{"id": t.ID})
 }
@@ -837,22 +1615,42 @@ func (app *App) handleAPITaskSave(w http.ResponseWriter, r *http.Request) {
 		DescriptionFR string `json:"description_fr"`
 		DescriptionEN string `json:"description_en"`
 		MaxSlots      *int64 `json:"max_slots"`
+		StartTime     string `json:"start_time"`
+		EndTime       string `json:"end_time"`
+		MaxGuests     int    `json:"max_guests"`
+		MinSlots      int    `json:"min_slots"`
+		Skills        string `json:"skills"`
+		ReservedSlots int    `json:"reserved_slots"`
+		Notes         string `json:"notes"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, `{"error":"bad request"}`, 400)
 		return
 	}
+	if !validTaskTime(req.StartTime) || !validTaskTime(req.EndTime) {
+		http.Error(w, `{"error":"invalid time"}`, 400)
+		return
+	}
 	t := &Task{
 		ID: req.ID, TitleFR: req.TitleFR, TitleEN: req.TitleEN,
 		DescriptionFR: req.DescriptionFR, DescriptionEN: req.DescriptionEN,
+		StartTime: req.StartTime, EndTime: req.EndTime,
+		MaxGuests:     min(max(req.MaxGuests, 0), maxTaskGuests),
+		MinSlots:      req.MinSlots,
+		Skills:        normalizeSkills(req.Skills),
+		ReservedSlots: req.ReservedSlots,
+		Notes:         strings.TrimSpace(req.Notes),
 	}
 	if req.MaxSlots != nil && *req.MaxSlots > 0 {
 		t.MaxSlots = sql.NullInt64{Int64: *req.MaxSlots, Valid: true}
 	}
+	normalizeMinSlots(t)
+	normalizeReservedSlots(t)
 	if err := UpdateTask(app.DB, t); err != nil {
 		http.Error(w, `{"error":"save failed"}`, 500)
 		return
 	}
+	app.snapshotStructure(structureEventID(app.DB, "task", t.ID), "edit")
 	w.Header().Set("Content-Type", "application/json")
 	w.Write([]byte(`{"ok":true}`))
 }
@@ -870,6 +1668,7 @@ func (app *App) handleAPITaskDelete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	DeleteTask(app.DB, req.ID)
+	app.snapshotStructure(structureEventID(app.DB, "task", req.ID), "delete")
 	w.Header().Set("Content-Type", "application/json")
 	w.Write([]byte(`{"ok":true}`))
 }
@@ -891,14 +1690,44 @@ func (app *App) handleAdminRegistrations(w http.ResponseWriter, r *http.Request)
 		http.Redirect(w, r, fmt.Sprintf("/admin/event/edit?id=%d&lang=%s", id, LangFromRequest(r)), http.StatusSeeOther)
 		return
 	}
-	allRegs, _ := ListAllRegistrations(app.DB, event.ID)
+	filter := parseRegistrationFilter(r)
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	page = max(page, 1)
+	allRegs, matched, err := SearchRegistrations(app.DB, event.ID, filter, page)
+	if err != nil {
+		log.Printf("registrations: search event %d: %v", event.ID, err)
+	}
+	for i := range allRegs {
+		allRegs[i].CreatedAt = event.InZone(allRegs[i].CreatedAt)
+	}
 	totalRegs := CountRegistrations(app.DB, event.ID)
+	fields, _ := ListFormFields(app.DB, event.ID)
+	answers, _ := ListRegistrationAnswers(app.DB, event.ID)
+	tasks, _ := ListTasks(app.DB, event.ID)
+	groups, _ := ListTaskGroups(app.DB, event.ID)
+	var rootGroups []TaskGroup
+	for _, g := range groups {
+		if !g.ParentGroupID.Valid {
+			rootGroups = append(rootGroups, g)
+		}
+	}
+	query := filter.values()
+	query.Set("id", strconv.FormatInt(event.ID, 10))
+	query.Set("lang", LangFromRequest(r))
 
 	pd := app.newPageData(r, map[string]any{
-		"Event":     event,
-		"AllRegs":   allRegs,
-		"TotalRegs": totalRegs,
+		"Event":      event,
+		"AllRegs":    allRegs,
+		"TotalRegs":  totalRegs,
+		"Fields":     fields,
+		"Answers":    answers,
+		"HasSkills":  len(eventSkills(tasks)) > 0,
+		"Filter":     filter,
+		"Groups":     rootGroups,
+		"Tasks":      tasks,
+		"Pagination": newPagination("/admin/event/registrations", query, page, matched, registrationsPerPage),
 	})
+	pd.Success, pd.Error = takeFlash(w, r)
 	app.render(w, r, "admin_registrations.html", pd)
 }
 
@@ -915,14 +1744,30 @@ func (app *App) handleAPISlots(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, `{"error":"not found"}`, 404)
 		return
 	}
+	event, err := GetEvent(app.DB, eventID)
+	if err == nil && (app.hiddenDraft(r, event) || app.inviteDenied(r, event)) {
+		http.Error(w, `{"error":"not found"}`, 404)
+		return
+	}
+	if err != nil || !event.HasAccess(r.URL.Query().Get("access_code")) {
+		for i := range views {
+			holdReservedSlots(&views[i])
+		}
+	}
 	type slotInfo struct {
-		ID        int64 `json:"id"`
-		SlotsLeft int   `json:"slots_left"`
-		IsFull    bool  `json:"is_full"`
+		ID          int64    `json:"id"`
+		SlotsLeft   int      `json:"slots_left"`
+		IsFull      bool     `json:"is_full"`
+		StillNeeded int      `json:"still_needed"`
+		Taken       int      `json:"taken"`
+		MaxSlots    int64    `json:"max_slots,omitempty"`
+		Fill        *Fill    `json:"fill,omitempty"`
+		Names       []string `json:"names,omitempty"` // empty on counts-only events
 	}
 	result := make([]slotInfo, len(views))
 	for i, v := range views {
-		result[i] = slotInfo{ID: v.ID, SlotsLeft: v.SlotsLeft, IsFull: v.IsFull}
+		result[i] = slotInfo{ID: v.ID, SlotsLeft: v.SlotsLeft, IsFull: v.IsFull, StillNeeded: v.StillNeeded,
+			Taken: v.RegCount, MaxSlots: v.MaxSlots.Int64, Fill: v.Fill(), Names: v.PublicNames}
 	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(result)
@@ -933,27 +1778,119 @@ func (app *App) handleAPISlots(w http.ResponseWriter, r *http.Request) {
 func (app *App) handlePublicEvent(w http.ResponseWriter, r *http.Request) {
 	slug := strings.TrimPrefix(r.URL.Path, "/e/")
 	slug = strings.TrimSuffix(slug, "/")
+	slug, calendar := strings.CutSuffix(slug, "/calendar.ics")
+	slug, ogImage := strings.CutSuffix(slug, "/og.png")
+	slug, logo := strings.CutSuffix(slug, "/logo")
 	if slug == "" {
 		http.NotFound(w, r)
 		return
 	}
 	event, err := GetEventBySlug(app.DB, slug)
-	if err != nil {
+	if err == sql.ErrNoRows {
+		suffix := ""
+		if calendar {
+			suffix = "/calendar.ics"
+		} else if ogImage {
+			suffix = "/og.png"
+		} else if logo {
+			suffix = "/logo"
+		}
+		app.redirectOldSlug(w, r, slug, suffix)
+		return
+	} else if err != nil {
 		http.NotFound(w, r)
 		return
 	}
+	// The logo shows on the "coming soon" page of drafts too.
+	if logo {
+		app.handleEventLogo(w, r, event)
+		return
+	}
+	if app.hiddenDraft(r, event) {
+		if calendar || ogImage {
+			http.NotFound(w, r)
+		} else {
+			app.renderComingSoon(w, r, event)
+		}
+		return
+	}
+	// Link previews show invite-only events too.
+	if !ogImage && app.inviteDenied(r, event) {
+		if calendar {
+			http.NotFound(w, r)
+		} else {
+			app.renderInviteRequired(w, r, event)
+		}
+		return
+	}
+	if calendar {
+		app.handleEventICS(w, r, event)
+		return
+	}
+	if ogImage {
+		app.handleEventOGImage(w, r, event)
+		return
+	}
 	if event.EventType == "attendance" {
-		pd := app.newPageData(r, map[string]any{"Event": event})
+		lang := eventLang(r, event)
+		pd := app.newPageData(r, map[string]any{"Event": event, "OpenGraph": app.eventOpenGraph(r, event, lang), "Share": app.eventShare(r, event, lang)})
 		app.render(w, r, "public_attendance.html", pd)
 		return
 	}
 	if event.EventType == "secret_santa" {
-		pd := app.newPageData(r, map[string]any{"Event": event})
+		pd := app.newPageData(r, map[string]any{"Event": event, "OpenGraph": app.eventOpenGraph(r, event, eventLang(r, event))})
 		app.render(w, r, "public_santa.html", pd)
 		return
 	}
+	if event.AssignMode {
+		app.renderPreferenceForm(w, r, event, false, "")
+		return
+	}
+	app.renderSignupForm(w, r, event, "")
+}
+
+// renderSignupForm shows the signup page of event, with errMsg when the
+// signup failed.
+func (app *App) renderSignupForm(w http.ResponseWriter, r *http.Request, event *Event, errMsg string) {
 	tree, _ := BuildEventTree(app.DB, event.ID)
-	pd := app.newPageData(r, map[string]any{"Event": event, "Tree": tree})
+	code := r.FormValue("access_code")
+	access := event.HasAccess(code)
+	if !access {
+		holdReservedTree(tree)
+		code = ""
+	}
+	fields, _ := ListFormFields(app.DB, event.ID)
+	common, byTask := splitFormFields(fields)
+	rules, _ := ListTaskRules(app.DB, event.ID)
+	tasks, _ := ListTasks(app.DB, event.ID)
+	closes, _ := event.RegistrationCloses()
+	// A browser that signed up sees its registration, unless it asked for
+	// the form to change it or sign up again, or the form came back with an
+	// error.
+	returning := app.returningRegistration(r, event)
+	returningForm := ""
+	if returning != nil {
+		if f := r.FormValue("returning"); f == "change" || f == "another" {
+			returningForm = f
+		}
+	}
+	lang := eventLang(r, event)
+	var remembered map[string]any
+	if returning != nil {
+		remembered = returning.Stored(lang)
+	}
+	pd := app.newPageData(r, map[string]any{
+		"Event": event, "Tree": tree, "Fields": common, "TaskFields": byTask, "TaskRules": rulesByTask(rules),
+		"Registration": accessSignupState(app.DB, event, time.Now(), access), "RegistrationCloses": closes,
+		"AccessCode": code, "AccessCodeInvalid": !access && r.FormValue("access_code") != "",
+		"Opening": eventOpening(r, event, time.Now()), "MaxCommentLen": maxCommentLen,
+		"DietOptions": dietOptions, "MaxAllergiesLen": maxAllergiesLen, "Skills": eventSkills(tasks),
+		"FamilyTasks": openTaskViews(tree), "MaxFamilyMembers": maxFamilyMembers,
+		"OpenGraph": app.eventOpenGraph(r, event, lang), "Share": app.eventShare(r, event, lang),
+		"Returning": returning, "ReturningForm": returningForm, "Remembered": remembered,
+		"ShowRegistered": returning != nil && returningForm == "" && errMsg == "",
+	})
+	pd.Error = errMsg
 	app.render(w, r, "public_event.html", pd)
 }
 
@@ -974,97 +1911,312 @@ func (app *App) handlePublicSignup(w http.ResponseWriter, r *http.Request) {
 		http.NotFound(w, r)
 		return
 	}
+	if app.hiddenDraft(r, event) {
+		app.renderComingSoon(w, r, event)
+		return
+	}
+	if app.inviteDenied(r, event) {
+		app.renderInviteRequired(w, r, event)
+		return
+	}
+	// In assign mode people give preferences; organizers make the signups.
+	if event.AssignMode {
+		http.Redirect(w, r, "/e/"+event.Slug+"?lang="+lang, http.StatusSeeOther)
+		return
+	}
+
+	if errKey := app.checkSpam(r); errKey != "" {
+		app.renderSignupForm(w, r, event, T(errKey, lang))
+		return
+	}
+	access := event.HasAccess(r.FormValue("access_code"))
+	switch accessSignupState(app.DB, event, time.Now(), access) {
+	case registrationNotYetOpen:
+		app.renderSignupForm(w, r, event, T("registration_not_open", lang))
+		return
+	case registrationClosed:
+		app.renderSignupForm(w, r, event, T("registration_closed", lang))
+		return
+	case registrationFull:
+		app.renderSignupForm(w, r, event, T("registration_full", lang))
+		return
+	}
 
 	firstName := strings.TrimSpace(r.FormValue("first_name"))
 	lastName := strings.TrimSpace(r.FormValue("last_name"))
 	email := strings.TrimSpace(r.FormValue("email"))
 	phone := strings.TrimSpace(r.FormValue("phone"))
+	comment := strings.TrimSpace(r.FormValue("comment"))
+	showName := r.FormValue("show_name") != "" && !event.CountsOnly
 
 	if firstName == "" || lastName == "" || email == "" || phone == "" {
-		tree, _ := BuildEventTree(app.DB, event.ID)
-		pd := app.newPageData(r, map[string]any{"Event": event, "Tree": tree})
-		pd.Error = T("error_invalid_form", lang)
-		app.render(w, r, "public_event.html", pd)
+		app.renderSignupForm(w, r, event, T("error_invalid_form", lang))
+		return
+	}
+	if errKey := app.checkSignupEmail(r.Context(), email); errKey != "" {
+		app.renderSignupForm(w, r, event, T(errKey, lang))
+		return
+	}
+	inv, refused := app.claimInvite(r, event, email)
+	if refused {
+		app.renderSignupForm(w, r, event, T("invite_used", lang))
 		return
 	}
+	defer app.releaseInvite(inv, email)
+	if utf8.RuneCountInString(comment) > maxCommentLen {
+		app.renderSignupForm(w, r, event, fmt.Sprintf(T("error_comment_too_long", lang), maxCommentLen))
+		return
+	}
+	var diet, allergies string
+	if event.CollectDiet {
+		diet = parseDiet(r.Form["diet"])
+		allergies = strings.TrimSpace(r.FormValue("allergies"))
+		if utf8.RuneCountInString(allergies) > maxAllergiesLen {
+			app.renderSignupForm(w, r, event, fmt.Sprintf(T("error_allergies_too_long", lang), maxAllergiesLen))
+			return
+		}
+	}
+	// Each task with room for guests has its own guests field.
+	guests, _ := strconv.Atoi(r.FormValue(fmt.Sprintf("guests_%d", taskID)))
+	if guests < 0 || guests > task.MaxGuests {
+		app.renderSignupForm(w, r, event, T("error_too_many_guests", lang))
+		return
+	}
+	var members []FamilyMember
+	if event.FamilySignup {
+		tasks, _ := ListTasks(app.DB, event.ID)
+		var ok bool
+		if members, ok = parseFamilyMembers(r, tasks, lastName); !ok {
+			app.renderSignupForm(w, r, event, T("error_invalid_form", lang))
+			return
+		}
+	}
+	fields, _ := ListFormFields(app.DB, event.ID)
+	answers, invalid := parseFormAnswers(r, fieldsForTask(fields, task.ID))
+	if invalid != nil {
+		label := Localized(invalid.LabelFR, invalid.LabelEN, lang)
+		app.renderSignupForm(w, r, event, fmt.Sprintf(T("form_field_error_answer", lang), label))
+		return
+	}
+	// The form carries the terms version it displayed, so a page left open
+	// while the admin edits the terms can't accept text the person never saw.
+	if event.HasTerms() {
+		version, _ := strconv.Atoi(r.FormValue("terms_version"))
+		if r.FormValue("accept_terms") == "" || version != event.TermsVersion {
+			errKey := "terms_changed"
+			if r.FormValue("accept_terms") == "" {
+				errKey = "terms_required"
+			}
+			app.renderSignupForm(w, r, event, T(errKey, lang))
+			return
+		}
+	}
 
-	// Check if this is a "change" request (has cancel_token from localStorage)
-	cancelToken := strings.TrimSpace(r.FormValue("cancel_token"))
+	// Check if this is a "change" request (has cancel_token from localStorage,
+	// or from the cookie without scripts; see returning.go)
+	cancelToken, _, _ := app.parseCancelToken(strings.TrimSpace(r.FormValue("cancel_token")))
 	if cancelToken != "" {
 		existingReg, _ := GetRegistrationByToken(app.DB, cancelToken)
 		if existingReg != nil {
 			if existingReg.TaskID == taskID {
-				// Same task selected — just show confirmation again
+				// Same task selected — just show confirmation again,
+				// keeping the acceptance of the terms shown this time.
+				if event.HasTerms() {
+					RecordTermsAcceptance(app.DB, existingReg.ID, event.TermsVersion)
+				}
+				token := app.cancelToken(*existingReg, *event)
 				pd := app.newPageData(r, map[string]any{
-					"Event": event, "Task": task, "Reg": existingReg,
-					"CancelURL": fmt.Sprintf("%s/cancel/%s", baseURLFor(r), existingReg.Token),
+					"Event": event, "Task": task, "Reg": existingReg, "CancelToken": token,
 				})
+				rememberRegistration(w, event.ID, token)
 				app.render(w, r, "confirmation.html", pd)
 				return
 			}
 			// Delete old registration before creating new one
 			DeleteRegistrationByToken(app.DB, cancelToken)
 		}
-	} else {
+	} else if event.MaxTasksPerPerson <= 1 {
 		// No cancel_token — check for duplicate email (different device case)
-		existingReg, _ := GetRegistrationByEmailAndEvent(app.DB, email, event.ID)
-		if existingReg != nil {
-			existingTask, _ := GetTask(app.DB, existingReg.TaskID)
-			pd := app.newPageData(r, map[string]any{
-				"Event": event, "Task": existingTask, "Reg": existingReg,
-				"CancelURL": fmt.Sprintf("%s/cancel/%s", baseURLFor(r), existingReg.Token),
-			})
-			pd.Success = T("already_registered", lang)
-			app.render(w, r, "confirmation.html", pd)
+		if app.renderAlreadyRegistered(w, r, event, email) {
 			return
 		}
 	}
 
-	reg, err := RegisterForTask(app.DB, taskID, firstName, lastName, email, phone)
+	register := RegisterGroupForTask
+	if access {
+		register = RegisterWithAccessCode
+	}
+	reg, err := register(app.DB, taskID, guests, firstName, lastName, email, phone, lang)
+	if errors.Is(err, errAlreadyRegistered) && app.renderAlreadyRegistered(w, r, event, email) {
+		// Another submission with the same email got in first.
+		return
+	}
+	if errors.Is(err, errTaskLimit) {
+		app.renderSignupForm(w, r, event, fmt.Sprintf(T("error_task_limit", lang), event.MaxTasksPerPerson))
+		return
+	}
+	if errors.Is(err, errGroupFull) {
+		errKey := "error_group_capacity"
+		if guests > 0 {
+			errKey = "error_full_group"
+		}
+		app.renderSignupForm(w, r, event, T(errKey, lang))
+		return
+	}
+	if errors.Is(err, errEventFull) {
+		app.renderSignupForm(w, r, event, T("error_event_full", lang))
+		return
+	}
+	var ruleErr *taskRuleError
+	if errors.As(err, &ruleErr) {
+		app.renderSignupForm(w, r, event, ruleErr.message(lang))
+		return
+	}
 	if err != nil {
 		if strings.Contains(err.Error(), "task_full") {
-			tree, _ := BuildEventTree(app.DB, event.ID)
-			pd := app.newPageData(r, map[string]any{"Event": event, "Tree": tree})
-			pd.Error = T("error_full", lang)
-			app.render(w, r, "public_event.html", pd)
+			errKey := "error_full"
+			if guests > 0 {
+				errKey = "error_full_group"
+			}
+			app.renderSignupForm(w, r, event, T(errKey, lang))
 			return
 		}
 		log.Printf("registration error: %v", err)
 		http.Error(w, T("error_server", lang), 500)
 		return
 	}
+	memberSignups, errMsg := app.registerFamily(event, reg, members, access, lang)
+	if errMsg != "" {
+		app.renderSignupForm(w, r, event, errMsg)
+		return
+	}
+	app.useInvite(inv, email)
+	if event.HasTerms() {
+		for _, id := range append([]int64{reg.ID}, memberIDs(memberSignups)...) {
+			if err := RecordTermsAcceptance(app.DB, id, event.TermsVersion); err != nil {
+				log.Printf("terms acceptance error (registration %d): %v", id, err)
+			}
+		}
+	}
+	if err := SaveRegistrationAnswers(app.DB, reg.ID, answers); err != nil {
+		log.Printf("signup answers error (registration %d): %v", reg.ID, err)
+	}
+	if comment != "" {
+		if err := SetRegistrationComment(app.DB, reg.ID, comment); err != nil {
+			log.Printf("signup comment error (registration %d): %v", reg.ID, err)
+		}
+		reg.Comment = comment
+	}
+	if showName {
+		if err := SetRegistrationShowName(app.DB, reg.ID, true); err != nil {
+			log.Printf("signup show name error (registration %d): %v", reg.ID, err)
+		}
+	}
+	if diet != "" || allergies != "" {
+		if err := SetRegistrationDiet(app.DB, reg.ID, diet, allergies); err != nil {
+			log.Printf("signup diet error (registration %d): %v", reg.ID, err)
+		}
+		reg.Diet, reg.Allergies = diet, allergies
+	}
+	// Over the signup limits, the registration keeps its place but its
+	// emails wait for an organizer's approval (see abuse.go).
+	held := false
+	ip := clientIP(r)
+	if reason := app.checkSignupAbuse(ip, email); reason != "" {
+		if err := FlagRegistration(app.DB, reg.ID, ip, reason); err != nil {
+			log.Printf("signup abuse: flag registration %d: %v", reg.ID, err)
+		} else {
+			log.Printf("signup abuse: registration %d from %s held for review (%s)", reg.ID, ip, reason)
+			held = true
+		}
+		for _, id := range memberIDs(memberSignups) {
+			if err := FlagRegistration(app.DB, id, ip, reason); err != nil {
+				log.Printf("signup abuse: flag registration %d: %v", id, err)
+			}
+		}
+	}
+	if !held {
+		if err := SaveVolunteer(app.DB, email, firstName, lastName, phone); err != nil {
+			log.Printf("signup volunteer error (registration %d): %v", reg.ID, err)
+		} else if err := saveSignupSkills(app.DB, event.ID, email, r.Form["skills"]); err != nil {
+			log.Printf("signup volunteer skills error (registration %d): %v", reg.ID, err)
+		}
+		for _, s := range append([]MemberSignup{{Reg: reg, Task: task}}, memberSignups...) {
+			app.dispatchSignupConfirmation(*s.Reg, *s.Task, *event, app.eventBaseURL(r, event))
+			if len(event.NotifyAddresses()) > 0 {
+				subject, html := renderRegistrationNotificationEmail(instanceLang(), *s.Reg, *s.Task, CountTaskRegistrations(app.DB, s.Task.ID), *event, app.eventBaseURL(r, event))
+				app.dispatchAdminNotification(*event, subject, html)
+			}
+		}
+	}
+
+	token := app.cancelToken(*reg, *event)
+	pd := app.newPageData(r, map[string]any{
+		"Event": event, "Task": task, "Reg": reg, "Members": memberSignups,
+		"CancelToken": token,
+		"Held":        held,
+	})
+	if held {
+		pd.Success = T("registered_held", lang)
+	}
+	rememberRegistration(w, event.ID, token)
+	app.render(w, r, "confirmation.html", pd)
+}
 
+// renderAlreadyRegistered shows the confirmation of email's registration for
+// event, if there is one, and reports whether it did.
+func (app *App) renderAlreadyRegistered(w http.ResponseWriter, r *http.Request, event *Event, email string) bool {
+	existingReg, _ := GetRegistrationByEmailAndEvent(app.DB, email, event.ID)
+	if existingReg == nil {
+		return false
+	}
+	existingTask, _ := GetTask(app.DB, existingReg.TaskID)
+	token := app.cancelToken(*existingReg, *event)
 	pd := app.newPageData(r, map[string]any{
-		"Event": event, "Task": task, "Reg": reg,
-		"CancelURL": fmt.Sprintf("%s/cancel/%s", baseURLFor(r), reg.Token),
+		"Event": event, "Task": existingTask, "Reg": existingReg, "CancelToken": token,
 	})
+	pd.Success = T("already_registered", pd.Lang)
+	rememberRegistration(w, event.ID, token)
 	app.render(w, r, "confirmation.html", pd)
+	return true
 }
 
 func (app *App) handlePublicCancel(w http.ResponseWriter, r *http.Request) {
 	token := strings.TrimPrefix(r.URL.Path, "/cancel/")
 	token = strings.TrimSuffix(token, "/")
-	lang := LangFromRequest(r)
 
-	reg, err := GetRegistrationByToken(app.DB, token)
-	if err != nil {
+	regToken, expires, ok := app.parseCancelToken(token)
+	reg, err := GetRegistrationByToken(app.DB, regToken)
+	if !ok || err != nil {
 		pd := app.newPageData(r, nil)
-		pd.Error = T("cancel_not_found", lang)
+		pd.Error = T("cancel_not_found", pd.Lang)
 		app.render(w, r, "cancel.html", pd)
 		return
 	}
+	// Cancel links come from follow-up emails; open them in the language
+	// the person registered in unless they have picked one explicitly.
+	lang := LangFromRequestOr(r, reg.Lang)
 	task, _ := GetTask(app.DB, reg.TaskID)
 	event, _ := GetEvent(app.DB, task.EventID)
 
+	if !cancelOpen(*event, expires) {
+		pd := app.newPageDataLang(r, lang, map[string]any{"Event": event, "Expired": true})
+		pd.Error = T("cancel_expired", lang)
+		w.WriteHeader(http.StatusGone)
+		app.render(w, r, "cancel.html", pd)
+		return
+	}
+
 	if r.Method == http.MethodPost {
-		DeleteRegistrationByToken(app.DB, token)
-		pd := app.newPageData(r, map[string]any{"Event": event, "Task": task, "Success": true})
+		DeleteRegistrationByToken(app.DB, regToken)
+		forgetRegistration(w, event.ID)
+		pd := app.newPageDataLang(r, lang, map[string]any{"Event": event, "Task": task, "Success": true})
 		pd.Success = T("cancel_success", lang)
 		app.render(w, r, "cancel.html", pd)
 		return
 	}
 
-	pd := app.newPageData(r, map[string]any{"Event": event, "Task": task, "Reg": reg, "Token": token})
+	pd := app.newPageDataLang(r, lang, map[string]any{"Event": event, "Task": task, "Reg": reg, "Token": token})
 	app.render(w, r, "cancel.html", pd)
 }
 
@@ -1082,6 +2234,21 @@ func (app *App) handlePublicRSVP(w http.ResponseWriter, r *http.Request) {
 		http.NotFound(w, r)
 		return
 	}
+	if app.hiddenDraft(r, event) {
+		app.renderComingSoon(w, r, event)
+		return
+	}
+	if app.inviteDenied(r, event) {
+		app.renderInviteRequired(w, r, event)
+		return
+	}
+
+	if errKey := app.checkSpam(r); errKey != "" {
+		pd := app.newPageData(r, map[string]any{"Event": event})
+		pd.Error = T(errKey, lang)
+		app.render(w, r, "public_attendance.html", pd)
+		return
+	}
 
 	firstName := strings.TrimSpace(r.FormValue("first_name"))
 	lastName := strings.TrimSpace(r.FormValue("last_name"))
@@ -1097,9 +2264,30 @@ func (app *App) handlePublicRSVP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	inv, refused := app.claimInvite(r, event, email)
+	if refused {
+		pd := app.newPageData(r, map[string]any{"Event": event})
+		pd.Error = T("invite_used", lang)
+		app.render(w, r, "public_attendance.html", pd)
+		return
+	}
+	defer app.releaseInvite(inv, email)
+
 	attending := attendingStr == "yes"
+	// Plus-ones come with a yes, within the event's limit.
+	var adultGuests, childGuests int
+	if attending && event.MaxGuests > 0 {
+		adultGuests, _ = strconv.Atoi(r.FormValue("adult_guests"))
+		childGuests, _ = strconv.Atoi(r.FormValue("child_guests"))
+		if adultGuests < 0 || childGuests < 0 || adultGuests+childGuests > event.MaxGuests {
+			pd := app.newPageData(r, map[string]any{"Event": event})
+			pd.Error = fmt.Sprintf(T("rsvp_error_guests", lang), event.MaxGuests)
+			app.render(w, r, "public_attendance.html", pd)
+			return
+		}
+	}
 
-	att, err := UpsertAttendance(app.DB, event.ID, firstName, lastName, email, phone, attending, message)
+	att, err := UpsertAttendance(app.DB, event.ID, firstName, lastName, email, phone, attending, adultGuests, childGuests, message, lang)
 	if err != nil {
 		log.Printf("rsvp error: %v", err)
 		pd := app.newPageData(r, map[string]any{"Event": event})
@@ -1107,6 +2295,15 @@ func (app *App) handlePublicRSVP(w http.ResponseWriter, r *http.Request) {
 		app.render(w, r, "public_attendance.html", pd)
 		return
 	}
+	app.useInvite(inv, email)
+	if err := SaveVolunteer(app.DB, email, firstName, lastName, phone); err != nil {
+		log.Printf("rsvp volunteer error (attendance %d): %v", att.ID, err)
+	}
+	if len(event.NotifyAddresses()) > 0 {
+		yes, total := CountAttendances(app.DB, event.ID)
+		subject, html := renderAttendanceNotificationEmail(instanceLang(), *att, yes, total, AttendanceHeadcount(app.DB, event.ID), *event, app.eventBaseURL(r, event))
+		app.dispatchAdminNotification(*event, subject, html)
+	}
 
 	pd := app.newPageData(r, map[string]any{
 		"Event":      event,
@@ -1141,13 +2338,15 @@ func (app *App) handlePublicRSVPLookup(w http.ResponseWriter, r *http.Request) {
 	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]any{
-		"found":      true,
-		"first_name": att.FirstName,
-		"last_name":  att.LastName,
-		"email":      att.Email,
-		"phone":      att.Phone,
-		"attending":  att.Attending,
-		"message":    att.Message,
+		"found":        true,
+		"first_name":   att.FirstName,
+		"last_name":    att.LastName,
+		"email":        att.Email,
+		"phone":        att.Phone,
+		"attending":    att.Attending,
+		"adult_guests": att.AdultGuests,
+		"child_guests": att.ChildGuests,
+		"message":      att.Message,
 	})
 }
 
@@ -1161,6 +2360,9 @@ func (app *App) handleAdminAttendances(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	attendances, _ := ListAttendances(app.DB, event.ID)
+	for i := range attendances {
+		attendances[i].CreatedAt = event.InZone(attendances[i].CreatedAt)
+	}
 	yesCount, totalCount := CountAttendances(app.DB, event.ID)
 
 	pd := app.newPageData(r, map[string]any{
@@ -1169,6 +2371,7 @@ func (app *App) handleAdminAttendances(w http.ResponseWriter, r *http.Request) {
 		"YesCount":    yesCount,
 		"NoCount":     totalCount - yesCount,
 		"TotalCount":  totalCount,
+		"Headcount":   AttendanceHeadcount(app.DB, event.ID),
 	})
 	app.render(w, r, "admin_attendances.html", pd)
 }
@@ -1206,19 +2409,120 @@ func (app *App) handleAdminExportAttendanceCSV(w http.ResponseWriter, r *http.Re
 		T("registration_email", lang),
 		T("registration_phone", lang),
 		T("attendance_attending", lang),
+		T("attendance_adult_guests", lang),
+		T("attendance_child_guests", lang),
+		T("attendance_people", lang),
 		T("attendance_message", lang),
+		T("registration_lang", lang),
 		T("registration_date", lang),
 	})
+	var adults, children, people int
 	for _, a := range attendances {
 		attending := T("attendance_no", lang)
 		if a.Attending {
 			attending = T("attendance_yes", lang)
 		}
-		cw.Write([]string{a.LastName, a.FirstName, a.Email, a.Phone, attending, a.Message, a.CreatedAt.Format("2006-01-02 15:04")})
+		adults, children, people = adults+a.AdultGuests, children+a.ChildGuests, people+a.People()
+		cw.Write([]string{
+			a.LastName, a.FirstName, a.Email, a.Phone, attending,
+			strconv.Itoa(a.AdultGuests), strconv.Itoa(a.ChildGuests), strconv.Itoa(a.People()),
+			a.Message, a.Lang, event.InZone(a.CreatedAt).Format("2006-01-02 15:04"),
+		})
 	}
+	// The totals are what the caterer needs.
+	cw.Write([]string{T("attendance_total", lang), "", "", "", "", strconv.Itoa(adults), strconv.Itoa(children), strconv.Itoa(people)})
 	cw.Flush()
 }
 
+// ---- Terms acceptance ----
+
+// handleAdminTerms reports which version of the event terms each registrant
+// accepted, alongside the text of every version.
+func (app *App) handleAdminTerms(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	event, err := GetEvent(app.DB, id)
+	if err != nil {
+		http.Redirect(w, r, "/admin", http.StatusSeeOther)
+		return
+	}
+	acceptances, _ := ListTermsAcceptances(app.DB, event.ID)
+	versions, _ := ListTermsVersions(app.DB, event.ID)
+	current := 0
+	for _, a := range acceptances {
+		if event.HasTerms() && a.Version == event.TermsVersion {
+			current++
+		}
+	}
+	pd := app.newPageData(r, map[string]any{
+		"Event":        event,
+		"Acceptances":  acceptances,
+		"Versions":     versions,
+		"CurrentCount": current,
+	})
+	app.render(w, r, "admin_terms.html", pd)
+}
+
+// ---- Shared stats dashboard ----
+
+// handleAdminStatsLink turns the event's read-only stats link on (or
+// regenerates it, revoking the old one) or off.
+func (app *App) handleAdminStatsLink(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/admin", http.StatusSeeOther)
+		return
+	}
+	lang := LangFromRequest(r)
+	eventID, _ := strconv.ParseInt(r.FormValue("event_id"), 10, 64)
+	if _, err := GetEvent(app.DB, eventID); err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	token, msg := GenerateToken(), "stats_link_enabled"
+	if r.FormValue("action") == "disable" {
+		token, msg = "", "stats_link_disabled"
+	}
+	if err := SetStatsToken(app.DB, eventID, token); err != nil {
+		log.Printf("stats link error: %v", err)
+		setFlash(w, "error", T("error_server", lang))
+	} else {
+		setFlash(w, "success", T(msg, lang))
+	}
+	http.Redirect(w, r, fmt.Sprintf("/admin/event/edit?id=%d&lang=%s", eventID, lang), http.StatusSeeOther)
+}
+
+// handlePublicStats serves the tokenized dashboard: aggregate counts only,
+// never names or contact details, so it can be shared beyond the admins.
+func (app *App) handlePublicStats(w http.ResponseWriter, r *http.Request) {
+	token := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/stats/"), "/")
+	event, err := GetEventByStatsToken(app.DB, token)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	data := map[string]any{"Event": event}
+	switch event.EventType {
+	case "attendance":
+		yes, total := CountAttendances(app.DB, event.ID)
+		data["AttendanceYes"] = yes
+		data["AttendanceNo"] = total - yes
+		data["Headcount"] = AttendanceHeadcount(app.DB, event.ID)
+	case "secret_santa":
+		total, completed := CountSantaParticipants(app.DB, event.ID)
+		data["SantaTotal"] = total
+		data["SantaCompleted"] = completed
+	default:
+		stats, err := BuildEventStats(app.DB, event.ID)
+		if err != nil {
+			log.Printf("stats error: %v", err)
+			http.Error(w, T("error_server", LangFromRequest(r)), 500)
+			return
+		}
+		data["Stats"] = stats
+	}
+	w.Header().Set("X-Robots-Tag", "noindex")
+	app.render(w, r, "public_stats.html", app.newPageData(r, data))
+}
+
 // ---- Secret Santa: public ----
 
 func (app *App) handleSantaRegister(w http.ResponseWriter, r *http.Request) {
@@ -1233,6 +2537,14 @@ func (app *App) handleSantaRegister(w http.ResponseWriter, r *http.Request) {
 		http.NotFound(w, r)
 		return
 	}
+	if app.hiddenDraft(r, event) {
+		app.renderComingSoon(w, r, event)
+		return
+	}
+	if app.inviteDenied(r, event) {
+		app.renderInviteRequired(w, r, event)
+		return
+	}
 	if event.SantaDrawnAt.Valid {
 		pd := app.newPageData(r, map[string]any{"Event": event, "Closed": true})
 		app.render(w, r, "public_santa.html", pd)
@@ -1247,6 +2559,14 @@ func (app *App) handleSantaRegister(w http.ResponseWriter, r *http.Request) {
 		app.render(w, r, "public_santa.html", pd)
 		return
 	}
+	inv, refused := app.claimInvite(r, event, email)
+	if refused {
+		pd := app.newPageData(r, map[string]any{"Event": event})
+		pd.Error = T("invite_used", lang)
+		app.render(w, r, "public_santa.html", pd)
+		return
+	}
+	defer app.releaseInvite(inv, email)
 	p, err := UpsertSantaParticipant(app.DB, event.ID, firstName, lastName, email, lang)
 	if err != nil {
 		log.Printf("santa register error: %v", err)
@@ -1255,8 +2575,9 @@ func (app *App) handleSantaRegister(w http.ResponseWriter, r *http.Request) {
 		app.render(w, r, "public_santa.html", pd)
 		return
 	}
-	editURL := fmt.Sprintf("%s/santa/edit?token=%s&lang=%s", baseURLFor(r), p.Token, lang)
-	subject, htmlBody := renderSantaLinkEmail(lang, *p, *event, editURL)
+	app.useInvite(inv, email)
+	editURL := fmt.Sprintf("%s/santa/edit?token=%s&lang=%s", app.eventBaseURL(r, event), p.Token, lang)
+	subject, htmlBody := renderSantaLinkEmail(lang, *p, *event, editURL, app.unsubscribeURL(app.eventBaseURL(r, event), p.Email, lang))
 	if htmlBody == "" {
 		log.Printf("santa link email render returned empty body for event %d", event.ID)
 		pd := app.newPageData(r, map[string]any{"Event": event})
@@ -1264,7 +2585,7 @@ func (app *App) handleSantaRegister(w http.ResponseWriter, r *http.Request) {
 		app.render(w, r, "public_santa.html", pd)
 		return
 	}
-	messageID, err := app.Email.Send(r.Context(), p.Email, subject, htmlBody, invitationAttachments()...)
+	messageID, err := app.Email.Send(r.Context(), p.Email, event.OrganizerEmail, subject, htmlBody, invitationAttachments()...)
 	if err != nil {
 		log.Printf("santa link email error: %v", err)
 		pd := app.newPageData(r, map[string]any{"Event": event})
@@ -1412,7 +2733,7 @@ func (app *App) handleAdminSantaDraw(w http.ResponseWriter, r *http.Request) {
 		app.santaRedirect(w, r, event.ID)
 		return
 	}
-	app.dispatchRevealEmails(event.ID, baseURLFor(r))
+	app.dispatchRevealEmails(event.ID, app.eventBaseURL(r, event))
 	setFlash(w, "success", T("santa_admin_draw_done", lang))
 	app.santaRedirect(w, r, event.ID)
 }
@@ -1430,7 +2751,7 @@ func (app *App) handleAdminSantaResend(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if event.SantaDrawnAt.Valid {
-		app.dispatchRevealEmails(event.ID, baseURLFor(r))
+		app.dispatchRevealEmails(event.ID, app.eventBaseURL(r, event))
 		setFlash(w, "success", T("santa_admin_resend_done", lang))
 	}
 	app.santaRedirect(w, r, event.ID)
@@ -1526,7 +2847,7 @@ func (app *App) handleAdminSantaInvite(w http.ResponseWriter, r *http.Request) {
 		app.santaRedirect(w, r, event.ID)
 		return
 	}
-	app.dispatchInviteEmails(event.ID, baseURLFor(r))
+	app.dispatchInviteEmails(event.ID, app.eventBaseURL(r, event))
 	setFlash(w, "success", T("santa_invite_done", lang))
 	app.santaRedirect(w, r, event.ID)
 }
@@ -1570,31 +2891,39 @@ func normalizeSantaLang(v string) string {
 	return "fr"
 }
 
-// parseSantaCSV reads a participant CSV. It strips a leading UTF-8 BOM,
-// auto-detects a ',' or ';' delimiter, and maps columns by header name. Rows
-// whose email is empty or has no '@' are dropped and counted in skipped. It
-// returns errSantaCSVNoEmail when no email column is present (or the file is
-// empty), or a wrapped error when the input cannot be read or parsed.
-func parseSantaCSV(r io.Reader) (rows []santaCSVRow, skipped int, err error) {
+// readCSVRecords reads a spreadsheet export: it strips a leading UTF-8 BOM,
+// auto-detects a ',' or ';' delimiter from the first line that has either,
+// and tolerates ragged rows.
+func readCSVRecords(r io.Reader) ([][]string, error) {
 	raw, err := io.ReadAll(r)
 	if err != nil {
-		return nil, 0, fmt.Errorf("read santa csv: %w", err)
+		return nil, err
 	}
 	content := strings.TrimPrefix(string(raw), "\xef\xbb\xbf") // UTF-8 BOM
 
-	firstLine := content
-	if i := strings.IndexAny(content, "\r\n"); i >= 0 {
-		firstLine = content[:i]
-	}
 	comma := ','
-	if strings.Count(firstLine, ";") > strings.Count(firstLine, ",") {
-		comma = ';'
+	for _, line := range strings.FieldsFunc(content, func(c rune) bool { return c == '\r' || c == '\n' }) {
+		if semi, com := strings.Count(line, ";"), strings.Count(line, ","); semi+com > 0 {
+			if semi > com {
+				comma = ';'
+			}
+			break
+		}
 	}
 
 	cr := csv.NewReader(strings.NewReader(content))
 	cr.Comma = comma
 	cr.FieldsPerRecord = -1 // tolerate ragged rows
-	records, err := cr.ReadAll()
+	return cr.ReadAll()
+}
+
+// parseSantaCSV reads a participant CSV via readCSVRecords and maps columns
+// by header name. Rows whose email is empty or has no '@' are dropped and
+// counted in skipped. It returns errSantaCSVNoEmail when no email column is
+// present (or the file is empty), or a wrapped error when the input cannot
+// be read or parsed.
+func parseSantaCSV(r io.Reader) (rows []santaCSVRow, skipped int, err error) {
+	records, err := readCSVRecords(r)
 	if err != nil {
 		return nil, 0, fmt.Errorf("parse santa csv: %w", err)
 	}
@@ -2,14 +2,18 @@ package main
 
 import (
 	"bytes"
+	"database/sql"
 	"encoding/json"
+	"html/template"
 	"fmt"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"regexp"
 	"strings"
 	"testing"
+	"time"
 )
 
 // ---- helpers ----
@@ -17,15 +21,113 @@ import (
 // newMux wires up the routes the same way main.go does, minus static files.
 func newMux(app *App) *http.ServeMux {
 	mux := http.NewServeMux()
+	mux.HandleFunc("/manifest.webmanifest", app.handleManifest)
+	mux.HandleFunc("/sw.js", app.handleServiceWorker)
+	mux.HandleFunc("/robots.txt", app.handleRobots)
+	mux.HandleFunc("/sitemap.xml", app.handleSitemap)
 	mux.HandleFunc("/admin/login", app.handleAdminLogin)
+	mux.HandleFunc("/admin/setup", app.handleAdminSetup)
+	mux.HandleFunc("/admin/login/email", app.handleAdminLoginEmail)
+	mux.HandleFunc("/admin/login/link", app.handleAdminLoginLink)
+	mux.HandleFunc("/admin/login/oidc", app.handleAdminLoginOIDC)
+	mux.HandleFunc("/admin/login/oidc/callback", app.handleAdminLoginOIDCCallback)
+	mux.HandleFunc("/admin/logout", app.handleAdminLogout)
+	mux.HandleFunc("/admin/settings/password", app.requireAdmin(app.handleAdminPasswordChange))
+	mux.HandleFunc("/admin/settings/login-emails", app.requireAdmin(app.handleAdminSettingsLoginEmails))
+	mux.HandleFunc("/admin/settings/signup-limits", app.requireAdmin(app.handleAdminSettingsSignupLimits))
+	mux.HandleFunc("/admin/settings/retention", app.requireAdmin(app.handleAdminSettingsRetention))
+	mux.HandleFunc("/admin/settings/instance", app.requireAdmin(app.handleAdminSettingsInstance))
+	mux.HandleFunc("/admin/backup", app.requireAdmin(app.handleAdminBackup))
+	mux.HandleFunc("/admin/backup/download", app.requireAdmin(app.handleAdminBackupDownload))
+	mux.HandleFunc("/admin/backup/restore", app.requireAdmin(app.handleAdminBackupRestore))
+	mux.HandleFunc("/admin/backup/run", app.requireAdmin(app.handleAdminBackupRun))
+	mux.HandleFunc("/admin/maintenance/run", app.requireAdmin(app.handleAdminMaintenanceRun))
+	mux.HandleFunc("/admin/settings/api-tokens", app.requireAdmin(app.handleAdminAPITokenCreate))
+	mux.HandleFunc("/admin/settings/api-tokens/revoke", app.requireAdmin(app.handleAdminAPITokenRevoke))
+	mux.HandleFunc("/admin/logout/all", app.requireAdmin(app.handleAdminLogoutAll))
+	mux.HandleFunc("/admin/sessions/revoke", app.requireAdmin(app.handleAdminSessionRevoke))
 	mux.HandleFunc("/admin", app.requireAdmin(app.handleAdminEvents))
+	mux.HandleFunc("/admin/export", app.requireAdmin(app.handleAdminExportCSV))
+	mux.HandleFunc("/admin/export/pdf", app.requireAdmin(app.handleAdminExportPDF))
 	mux.HandleFunc("/api/slots", app.handleAPISlots)
+	mux.HandleFunc("/api/email-check", app.handleAPIEmailCheck)
+	mux.HandleFunc("/api/volunteer", app.handleAPIVolunteer)
+	mux.HandleFunc("/api/event", app.handleAPIPublicEvent)
 	mux.HandleFunc("/e/", app.handlePublicEvent)
+	mux.HandleFunc("/s/", app.handleShortLink)
 	mux.HandleFunc("/signup", app.handlePublicSignup)
+	mux.HandleFunc("/preferences", app.handlePublicPreferences)
+	mux.HandleFunc("/notify-opening", app.handleOpeningNotice)
 	mux.HandleFunc("/cancel/", app.handlePublicCancel)
+	mux.HandleFunc("/ics/", app.handlePublicICS)
+	mux.HandleFunc("/qr/", app.handleRegistrationQR)
+	mux.HandleFunc("/my", app.handleMyRegistrations)
+	mux.HandleFunc("/my/", app.handleMyRegistrationsLink)
+	mux.HandleFunc("/unsubscribe", app.handleUnsubscribe)
+	mux.HandleFunc("/rsvp", app.handlePublicRSVP)
+	mux.HandleFunc("/stats/", app.handlePublicStats)
+	mux.HandleFunc("/admin/event/terms", app.requireAdmin(app.handleAdminTerms))
+	mux.HandleFunc("/admin/outbox", app.requireAdmin(app.handleAdminOutbox))
+	mux.HandleFunc("/admin/outbox/retry", app.requireAdmin(app.handleAdminOutboxRetry))
+	mux.HandleFunc("/admin/review", app.requireAdmin(app.handleAdminReview))
+	mux.HandleFunc("/admin/understaffed", app.requireAdmin(app.handleAdminUnderstaffed))
+	mux.HandleFunc("/admin/volunteers", app.requireAdmin(app.handleAdminVolunteerReport))
+	mux.HandleFunc("/admin/event/dietary", app.requireAdmin(app.handleAdminDietary))
+	mux.HandleFunc("/admin/event/registrations", app.requireAdmin(app.handleAdminRegistrations))
+	mux.HandleFunc("/admin/event/attendances", app.requireAdmin(app.handleAdminAttendances))
+	mux.HandleFunc("/admin/event/skills", app.requireAdmin(app.handleAdminSkills))
+	mux.HandleFunc("/admin/event/assign", app.requireAdmin(app.handleAdminAssign))
+	mux.HandleFunc("/admin/event/checkin", app.requireAdmin(app.handleAdminCheckIn))
+	mux.HandleFunc("/admin/event/checkin/scan", app.requireAdmin(app.handleAdminCheckInScan))
+	mux.HandleFunc("/admin/event/checkin/status", app.requireAdmin(app.handleAdminCheckInStatus))
+	mux.HandleFunc("/admin/event/checkin/list", app.requireAdmin(app.handleAdminCheckInList))
+	mux.HandleFunc("/admin/event/today", app.requireAdmin(app.handleAdminDayOf))
+	mux.HandleFunc("/admin/event/today/checkin", app.requireAdmin(app.handleAdminDayOfCheckIn))
+	mux.HandleFunc("/admin/event/today/covered", app.requireAdmin(app.handleAdminDayOfCovered))
+	mux.HandleFunc("/admin/event/today/status", app.requireAdmin(app.handleAdminDayOfStatus))
+	mux.HandleFunc("/admin/review/approve", app.requireAdmin(app.handleAdminReviewApprove))
+	mux.HandleFunc("/admin/review/reject", app.requireAdmin(app.handleAdminReviewReject))
+	mux.HandleFunc("/admin/registrations/delete", app.requireAdmin(app.handleAdminRegistrationDelete))
+	mux.HandleFunc("/admin/registrations/bulk", app.requireAdmin(app.handleAdminRegistrationsBulk))
+	mux.HandleFunc("/admin/registrations/move", app.requireAdmin(app.handleAdminRegistrationMove))
+	mux.HandleFunc("/admin/registrations/add", app.requireAdmin(app.handleAdminRegistrationAdd))
+	mux.HandleFunc("/admin/trash", app.requireAdmin(app.handleAdminTrash))
+	mux.HandleFunc("/admin/trash/restore", app.requireAdmin(app.handleAdminTrashRestore))
+	mux.HandleFunc("/admin/trash/purge", app.requireAdmin(app.handleAdminTrashPurge))
+	mux.HandleFunc("/admin/settings", app.requireAdmin(app.handleAdminSettings))
+	mux.HandleFunc("/admin/settings/test", app.requireAdmin(app.handleAdminSettingsTest))
+	mux.HandleFunc("/admin/settings/feed", app.requireAdmin(app.handleAdminSettingsFeed))
+	// Token-protected instead of session-protected: calendar apps can't log in.
+	mux.HandleFunc("/admin/feed.ics", app.handleAdminFeed)
+	mux.HandleFunc("/admin/event/stats-link", app.requireAdmin(app.handleAdminStatsLink))
+	mux.HandleFunc("/admin/series", app.requireAdmin(app.handleAdminSeries))
+	mux.HandleFunc("/admin/series/create", app.requireAdmin(app.handleAdminSeriesCreate))
+	mux.HandleFunc("/admin/series/propagate", app.requireAdmin(app.handleAdminSeriesPropagate))
+	mux.HandleFunc("/admin/event/poll-import", app.requireAdmin(app.handleAdminPollImport))
+	mux.HandleFunc("/admin/event/sheet-import", app.requireAdmin(app.handleAdminSheetImport))
+	mux.HandleFunc("/admin/event/sheet-import/apply", app.requireAdmin(app.handleAdminSheetImportApply))
+	mux.HandleFunc("/admin/clear-all", app.requireAdmin(app.handleAdminClearAll))
+	mux.HandleFunc("/admin/event/history", app.requireAdmin(app.handleAdminStructureHistory))
+	mux.HandleFunc("/admin/event/history/rollback", app.requireAdmin(app.handleAdminStructureRollback))
 	mux.HandleFunc("/santa/register", app.handleSantaRegister)
 	mux.HandleFunc("/santa/edit", app.handleSantaEdit)
+	mux.HandleFunc("/admin/event/new", app.requireAdmin(app.handleAdminEventNew))
 	mux.HandleFunc("/admin/event/edit", app.requireAdmin(app.handleAdminEventEdit))
+	mux.HandleFunc("/admin/event/duplicate", app.requireAdmin(app.handleAdminEventDuplicate))
+	mux.HandleFunc("/admin/event/publish", app.requireAdmin(app.handleAdminEventPublish))
+	mux.HandleFunc("/admin/event/slug", app.requireAdmin(app.handleAdminEventSlug))
+	mux.HandleFunc("/admin/event/qr", app.requireAdmin(app.handleAdminEventQR))
+	mux.HandleFunc("/admin/event/shortlink", app.requireAdmin(app.handleAdminShortLink))
+	mux.HandleFunc("/admin/event/theme", app.requireAdmin(app.handleAdminEventTheme))
+	mux.HandleFunc("/admin/event/invites", app.requireAdmin(app.handleAdminInvites))
+	mux.HandleFunc("/admin/templates", app.requireAdmin(app.handleAdminTemplates))
+	mux.HandleFunc("/admin/templates/save", app.requireAdmin(app.handleAdminTemplateSave))
+	mux.HandleFunc("/admin/templates/rename", app.requireAdmin(app.handleAdminTemplateRename))
+	mux.HandleFunc("/admin/templates/delete", app.requireAdmin(app.handleAdminTemplateDelete))
+	mux.HandleFunc("/admin/event/fields/save", app.requireAdmin(app.handleAdminFormFieldSave))
+	mux.HandleFunc("/admin/event/fields/delete", app.requireAdmin(app.handleAdminFormFieldDelete))
+	mux.HandleFunc("/admin/event/rules/save", app.requireAdmin(app.handleAdminTaskRuleSave))
+	mux.HandleFunc("/admin/event/rules/delete", app.requireAdmin(app.handleAdminTaskRuleDelete))
 	mux.HandleFunc("/admin/santa/draw", app.requireAdmin(app.handleAdminSantaDraw))
 	mux.HandleFunc("/admin/santa/resend", app.requireAdmin(app.handleAdminSantaResend))
 	mux.HandleFunc("/admin/santa/participant/delete", app.requireAdmin(app.handleAdminSantaParticipantDelete))
@@ -34,12 +136,28 @@ func newMux(app *App) *http.ServeMux {
 	mux.HandleFunc("/dev/emails", app.requireAdmin(app.handleDevEmailIndex))
 	mux.HandleFunc("/dev/emails/santa-link", app.requireAdmin(app.handleDevEmailSantaLink))
 	mux.HandleFunc("/dev/emails/santa-reveal", app.requireAdmin(app.handleDevEmailSantaReveal))
+	mux.HandleFunc("/dev/emails/signup-confirmation", app.requireAdmin(app.handleDevEmailSignupConfirmation))
 	mux.HandleFunc("/webhooks/ses", app.handleSESWebhook)
+	mux.HandleFunc("/admin/api/events", app.requireAPI(app.handleAPIEvents))
+	mux.HandleFunc("/admin/api/registrations", app.requireAPI(app.handleAPIRegistrations))
+	mux.HandleFunc("/admin/api/registration/move", app.requireAPI(app.handleAPIRegistrationMove))
+	mux.HandleFunc("/admin/api/event/save", app.requireAPI(app.handleAPIEventSave))
+	mux.HandleFunc("/admin/api/group/save", app.requireAPI(app.handleAPIGroupSave))
+	mux.HandleFunc("/admin/api/task/save", app.requireAPI(app.handleAPITaskSave))
+	mux.HandleFunc("/admin/api/group/duplicate", app.requireAPI(app.handleAPIDuplicate("group")))
+	mux.HandleFunc("/admin/api/task/duplicate", app.requireAPI(app.handleAPIDuplicate("task")))
+	mux.HandleFunc("/admin/api/max-slots/bulk", app.requireAPI(app.handleAPIBulkMaxSlots))
+	mux.HandleFunc(apiV1Prefix, app.requireAPI(app.handleAPIV1()))
+	mux.HandleFunc("/admin/api/graphql", app.requireReadAPI(app.handleAPIGraphQL))
+	mux.HandleFunc("/", app.handleRoot)
 	return mux
 }
 
+// adminCookie opens a fresh admin session and returns its cookie.
 func adminCookie(app *App) *http.Cookie {
-	return &http.Cookie{Name: "admin_session", Value: app.adminSessionValue()}
+	tag, _ := app.passwordTag()
+	token, _ := CreateSession(app.DB, tag, "test", false)
+	return &http.Cookie{Name: "admin_session", Value: token}
 }
 
 // postForm sends a POST with form data and returns the response.
@@ -202,7 +320,7 @@ func TestSignupTaskFull(t *testing.T) {
 	tk := seedTask(t, app.DB, e.ID, "Limited", int64Ptr(1))
 
 	// Fill the task
-	RegisterForTask(app.DB, tk.ID, "First", "Person", "first@t.com", "01")
+	RegisterForTask(app.DB, tk.ID, "First", "Person", "first@t.com", "01", "fr")
 
 	mux := newMux(app)
 	w := postForm(mux, "/signup?lang=fr", url.Values{
@@ -219,6 +337,118 @@ func TestSignupTaskFull(t *testing.T) {
 	}
 }
 
+func TestSignupWithGuests(t *testing.T) {
+	app := testApp(t)
+	e := seedEvent(t, app.DB)
+	tk := seedTask(t, app.DB, e.ID, "Task", int64Ptr(3))
+	tk.MaxGuests = 1
+	UpdateTask(app.DB, tk)
+	mux := newMux(app)
+
+	body := getRequest(mux, "/e/"+e.Slug).Body.String()
+	if !strings.Contains(body, fmt.Sprintf(`name="guests_%d"`, tk.ID)) {
+		t.Error("no guests field for the task")
+	}
+	signup := func(email, guests string) *httptest.ResponseRecorder {
+		form := url.Values{
+			"task_id":    {fmt.Sprint(tk.ID)},
+			"first_name": {"Alice"},
+			"last_name":  {"Dupont"},
+			"email":      {email},
+			"phone":      {"0601"},
+		}
+		form.Set(fmt.Sprintf("guests_%d", tk.ID), guests)
+		return postForm(mux, "/signup?lang=en", form)
+	}
+	if body := signup("alice@test.com", "2").Body.String(); !strings.Contains(body, template.HTMLEscapeString(T("error_too_many_guests", "en"))) {
+		t.Error("expected too-many-guests error")
+	}
+	signup("alice@test.com", "1")
+	if n := CountTaskRegistrations(app.DB, tk.ID); n != 2 {
+		t.Fatalf("%d people signed up, want 2", n)
+	}
+	if body := signup("bob@test.com", "1").Body.String(); !strings.Contains(body, template.HTMLEscapeString(T("error_full_group", "en"))) {
+		t.Error("expected not-enough-room error for the group")
+	}
+}
+
+func TestSignupWithComment(t *testing.T) {
+	app := testApp(t)
+	e := seedEvent(t, app.DB)
+	tk := seedTask(t, app.DB, e.ID, "Task", nil)
+	mux := newMux(app)
+	admin := adminCookie(app)
+
+	signup := func(email, comment string) *httptest.ResponseRecorder {
+		return postForm(mux, "/signup?lang=en", url.Values{
+			"task_id": {fmt.Sprint(tk.ID)}, "first_name": {"Alice"}, "last_name": {"Dupont"},
+			"email": {email}, "phone": {"0601"}, "comment": {comment},
+		})
+	}
+	want := template.HTMLEscapeString(fmt.Sprintf(T("error_comment_too_long", "en"), maxCommentLen))
+	if body := signup("alice@test.com", strings.Repeat("é", maxCommentLen+1)).Body.String(); !strings.Contains(body, want) {
+		t.Error("expected comment-too-long error")
+	}
+	signup("alice@test.com", "  Arrives at noon\nNo gluten  ")
+	regs, _ := ListAllRegistrations(app.DB, e.ID)
+	if len(regs) != 1 || regs[0].Comment != "Arrives at noon\nNo gluten" {
+		t.Fatalf("registrations = %+v", regs)
+	}
+
+	w := httptest.NewRecorder()
+	app.handleAdminRegistrations(w, httptest.NewRequest("GET", fmt.Sprintf("/admin/event/registrations?id=%d", e.ID), nil))
+	if !strings.Contains(w.Body.String(), "Arrives at noon<br>No gluten") {
+		t.Error("registrations page lacks the comment")
+	}
+	csv := getRequest(mux, fmt.Sprintf("/admin/export?event_id=%d", e.ID), admin).Body.String()
+	if !strings.Contains(csv, "Accompagnants,Commentaire") || !strings.Contains(csv, `,0,"Arrives at noon`) {
+		t.Errorf("CSV lacks the comment:\n%s", csv)
+	}
+
+	AnonymizeEvent(app.DB, e.ID)
+	if regs, _ := ListAllRegistrations(app.DB, e.ID); regs[0].Comment != "" {
+		t.Error("comment left after anonymization")
+	}
+}
+
+func TestSignupShowName(t *testing.T) {
+	app := testApp(t)
+	e := seedEvent(t, app.DB)
+	tk := seedTask(t, app.DB, e.ID, "Task", nil)
+	mux := newMux(app)
+
+	for _, p := range []struct{ first, email, show string }{{"Alice", "a@test.com", "1"}, {"Bob", "b@test.com", ""}, {"Chloé", "c@test.com", "1"}} {
+		postForm(mux, "/signup?lang=en", url.Values{
+			"task_id": {fmt.Sprint(tk.ID)}, "first_name": {p.first}, "last_name": {"Dupont"},
+			"email": {p.email}, "phone": {"0601"}, "show_name": {p.show},
+		})
+	}
+	body := getRequest(mux, "/e/"+e.Slug).Body.String()
+	if !strings.Contains(body, "Alice, Chloé</span>") {
+		t.Error("consenting first names not shown under the task")
+	}
+	if strings.Contains(body, "Bob") {
+		t.Error("first name shown without consent")
+	}
+	if api := getRequest(mux, fmt.Sprintf("/api/slots?event_id=%d", e.ID)).Body.String(); !strings.Contains(api, `"names":["Alice","Chloé"]`) {
+		t.Errorf("slots API lacks the names: %s", api)
+	}
+
+	// Counts-only events show how many, never who.
+	e.CountsOnly = true
+	UpdateEvent(app.DB, e)
+	body = getRequest(mux, "/e/"+e.Slug+"?lang=en").Body.String()
+	if strings.Contains(body, "Alice") || strings.Contains(body, `name="show_name"`) {
+		t.Error("counts-only page shows names or asks to")
+	}
+	if !strings.Contains(body, "3 "+T("task_taken", "en")) {
+		t.Error("counts-only page lacks the count")
+	}
+	if api := getRequest(mux, fmt.Sprintf("/api/slots?event_id=%d", e.ID)).Body.String(); strings.Contains(api, "Alice") || !strings.Contains(api, `"taken":3`) {
+		t.Errorf("counts-only slots API = %s", api)
+	}
+}
+
 // ---- Duplicate email (different device) ----
 
 func TestSignupDuplicateEmail(t *testing.T) {
@@ -228,7 +458,7 @@ func TestSignupDuplicateEmail(t *testing.T) {
 	tk2 := seedTask(t, app.DB, e.ID, "Task B", int64Ptr(5))
 
 	// First registration
-	RegisterForTask(app.DB, tk1.ID, "Alice", "Dupont", "alice@test.com", "0601")
+	RegisterForTask(app.DB, tk1.ID, "Alice", "Dupont", "alice@test.com", "0601", "fr")
 
 	// Try to register same email for different task (no cancel_token)
 	mux := newMux(app)
@@ -260,7 +490,7 @@ func TestSignupDuplicateEmailCaseInsensitive(t *testing.T) {
 	e := seedEvent(t, app.DB)
 	tk := seedTask(t, app.DB, e.ID, "Task", int64Ptr(5))
 
-	RegisterForTask(app.DB, tk.ID, "Alice", "Dupont", "alice@test.com", "0601")
+	RegisterForTask(app.DB, tk.ID, "Alice", "Dupont", "alice@test.com", "0601", "fr")
 
 	mux := newMux(app)
 	w := postForm(mux, "/signup?lang=en", url.Values{
@@ -286,7 +516,7 @@ func TestSignupChangeTask(t *testing.T) {
 	tk2 := seedTask(t, app.DB, e.ID, "Task B", int64Ptr(5))
 
 	// Initial registration
-	reg, _ := RegisterForTask(app.DB, tk1.ID, "Alice", "Dupont", "alice@test.com", "0601")
+	reg, _ := RegisterForTask(app.DB, tk1.ID, "Alice", "Dupont", "alice@test.com", "0601", "fr")
 
 	// Change to task B by providing cancel_token
 	mux := newMux(app)
@@ -327,7 +557,7 @@ func TestSignupChangeSameTask(t *testing.T) {
 	e := seedEvent(t, app.DB)
 	tk := seedTask(t, app.DB, e.ID, "Task A", int64Ptr(5))
 
-	reg, _ := RegisterForTask(app.DB, tk.ID, "Alice", "Dupont", "alice@test.com", "0601")
+	reg, _ := RegisterForTask(app.DB, tk.ID, "Alice", "Dupont", "alice@test.com", "0601", "fr")
 
 	// "Change" to same task — should just show confirmation, not create a new registration
 	mux := newMux(app)
@@ -393,12 +623,12 @@ func TestCancelFlow(t *testing.T) {
 	app := testApp(t)
 	e := seedEvent(t, app.DB)
 	tk := seedTask(t, app.DB, e.ID, "Task", int64Ptr(5))
-	reg, _ := RegisterForTask(app.DB, tk.ID, "Alice", "Dupont", "alice@test.com", "0601")
+	reg, _ := RegisterForTask(app.DB, tk.ID, "Alice", "Dupont", "alice@test.com", "0601", "fr")
 
 	mux := newMux(app)
 
 	// GET cancel page — should show confirmation prompt
-	w := getRequest(mux, "/cancel/"+reg.Token+"?lang=fr")
+	w := getRequest(mux, "/cancel/"+app.cancelToken(*reg, *e)+"?lang=fr")
 	if w.Code != 200 {
 		t.Fatalf("GET cancel status = %d", w.Code)
 	}
@@ -408,7 +638,7 @@ func TestCancelFlow(t *testing.T) {
 	}
 
 	// POST cancel — should delete
-	w2 := postForm(mux, "/cancel/"+reg.Token+"?lang=fr", url.Values{})
+	w2 := postForm(mux, "/cancel/"+app.cancelToken(*reg, *e)+"?lang=fr", url.Values{})
 	if w2.Code != 200 {
 		t.Fatalf("POST cancel status = %d", w2.Code)
 	}
@@ -424,6 +654,122 @@ func TestCancelFlow(t *testing.T) {
 	}
 }
 
+func TestSignupStoresLanguage(t *testing.T) {
+	app := testApp(t)
+	e := seedEvent(t, app.DB)
+	tk := seedTask(t, app.DB, e.ID, "Task", int64Ptr(5))
+
+	mux := newMux(app)
+	postForm(mux, "/signup?lang=en", url.Values{
+		"task_id":    {fmt.Sprint(tk.ID)},
+		"first_name": {"Alice"},
+		"last_name":  {"Dupont"},
+		"email":      {"alice@test.com"},
+		"phone":      {"0601"},
+	})
+
+	reg, err := GetRegistrationByEmailAndEvent(app.DB, "alice@test.com", e.ID)
+	if err != nil {
+		t.Fatalf("registration not stored: %v", err)
+	}
+	if reg.Lang != LangEN {
+		t.Errorf("lang = %q, want %q", reg.Lang, LangEN)
+	}
+
+	// Without ?lang the cancel page opens in the registrant's language.
+	w := getRequest(mux, "/cancel/"+app.cancelToken(*reg, *e))
+	if !strings.Contains(w.Body.String(), `<html lang="en">`) {
+		t.Error("cancel page should default to the registration language")
+	}
+	// An explicit choice still wins.
+	w = getRequest(mux, "/cancel/"+app.cancelToken(*reg, *e)+"?lang=fr")
+	if !strings.Contains(w.Body.String(), `<html lang="fr">`) {
+		t.Error("explicit ?lang should override the registration language")
+	}
+}
+
+func TestSignupSendsConfirmationEmail(t *testing.T) {
+	app := testApp(t)
+	e := seedEvent(t, app.DB)
+	tk := seedTask(t, app.DB, e.ID, "Vaisselle", nil)
+	mux := newMux(app)
+
+	postForm(mux, "/signup?lang=fr", url.Values{
+		"task_id":    {fmt.Sprint(tk.ID)},
+		"first_name": {"Alice"},
+		"last_name":  {"Dupont"},
+		"email":      {"alice@test.com"},
+		"phone":      {"0601"},
+	})
+	reg, err := GetRegistrationByEmailAndEvent(app.DB, "alice@test.com", e.ID)
+	if err != nil {
+		t.Fatalf("registration not stored: %v", err)
+	}
+	fake := app.Email.(*fakeEmailSender)
+	if fake.count() != 1 {
+		t.Fatalf("expected 1 email, got %d", fake.count())
+	}
+	sent := fake.sent[0]
+	if sent.To != "alice@test.com" || !strings.Contains(sent.HTML, "/cancel/"+reg.Token) || !strings.Contains(sent.HTML, "Vaisselle") {
+		t.Errorf("confirmation email = %+v", sent)
+	}
+}
+
+func TestSignupRequiresTermsAcceptance(t *testing.T) {
+	app := testApp(t)
+	e := &Event{TitleFR: "Chantier", EventDate: "2026-06-14", TermsFR: "Port des gants obligatoire."}
+	CreateEvent(app.DB, e)
+	tk := seedTask(t, app.DB, e.ID, "Débroussaillage", nil)
+	mux := newMux(app)
+
+	form := func(extra url.Values) url.Values {
+		v := url.Values{
+			"task_id":    {fmt.Sprint(tk.ID)},
+			"first_name": {"Alice"},
+			"last_name":  {"Dupont"},
+			"email":      {"alice@test.com"},
+			"phone":      {"0601"},
+		}
+		for k, vals := range extra {
+			v[k] = vals
+		}
+		return v
+	}
+
+	w := getRequest(mux, "/e/"+e.Slug+"?lang=fr")
+	if !strings.Contains(w.Body.String(), "Port des gants obligatoire.") || !strings.Contains(w.Body.String(), `name="accept_terms"`) {
+		t.Fatal("public page should show the terms and an acceptance checkbox")
+	}
+
+	postForm(mux, "/signup?lang=fr", form(url.Values{"terms_version": {"1"}}))
+	if _, err := GetRegistrationByEmailAndEvent(app.DB, "alice@test.com", e.ID); err == nil {
+		t.Fatal("signup without accepting the terms should be refused")
+	}
+
+	// The admin revises the terms after someone accepted them: a form still
+	// showing version 1 must be sent back.
+	reg, _ := RegisterForTask(app.DB, tk.ID, "Bob", "Martin", "bob@test.com", "0602", "fr")
+	RecordTermsAcceptance(app.DB, reg.ID, 1)
+	e.TermsFR = "Port des gants et des chaussures de sécurité obligatoire."
+	UpdateEvent(app.DB, e)
+	w = postForm(mux, "/signup?lang=fr", form(url.Values{"terms_version": {"1"}, "accept_terms": {"1"}}))
+	if !strings.Contains(w.Body.String(), T("terms_changed", LangFR)) {
+		t.Error("stale terms version should ask the person to re-read them")
+	}
+
+	postForm(mux, "/signup?lang=fr", form(url.Values{"terms_version": {"2"}, "accept_terms": {"1"}}))
+	list, _ := ListTermsAcceptances(app.DB, e.ID)
+	if len(list) != 2 || list[0].Email != "alice@test.com" || list[0].Version != 2 || !list[0].AcceptedAt.Valid {
+		t.Fatalf("acceptances = %+v", list)
+	}
+
+	w = getRequest(mux, fmt.Sprintf("/admin/event/terms?id=%d&lang=fr", e.ID), adminCookie(app))
+	body := w.Body.String()
+	if !strings.Contains(body, "bob@test.com") || !strings.Contains(body, T("terms_outdated", LangFR)) {
+		t.Error("report should list Bob as having accepted an outdated version")
+	}
+}
+
 func TestCancelInvalidToken(t *testing.T) {
 	app := testApp(t)
 	mux := newMux(app)
@@ -446,7 +792,7 @@ func TestAPISlotsEndpoint(t *testing.T) {
 	tk1 := seedTask(t, app.DB, e.ID, "Limited", int64Ptr(3))
 	tk2 := seedTask(t, app.DB, e.ID, "Unlimited", nil)
 
-	RegisterForTask(app.DB, tk1.ID, "A", "A", "a@t.com", "01")
+	RegisterForTask(app.DB, tk1.ID, "A", "A", "a@t.com", "01", "fr")
 
 	mux := newMux(app)
 	w := getRequest(mux, fmt.Sprintf("/api/slots?event_id=%d", e.ID))
@@ -555,6 +901,210 @@ func TestAdminLoginWrongPassword(t *testing.T) {
 	}
 }
 
+func TestAdminSessions(t *testing.T) {
+	app := testApp(t)
+	mux := newMux(app)
+	loggedIn := func(c *http.Cookie) bool {
+		return getRequest(mux, "/admin", c).Code == http.StatusOK
+	}
+
+	var phone *http.Cookie
+	for _, c := range postForm(mux, "/admin/login?lang=fr", url.Values{"password": {"testpass"}}).Result().Cookies() {
+		if c.Name == "admin_session" {
+			phone = c
+		}
+	}
+	if phone == nil || !loggedIn(phone) {
+		t.Fatal("login did not open a session")
+	}
+	var stored int
+	app.DB.QueryRow("SELECT COUNT(*) FROM sessions WHERE token_hash=?", phone.Value).Scan(&stored)
+	if stored != 0 {
+		t.Error("session token stored in clear")
+	}
+
+	laptop := adminCookie(app)
+	if w := getRequest(mux, "/admin/settings?lang=en", laptop); !strings.Contains(w.Body.String(), "This session") {
+		t.Error("settings page does not list the sessions")
+	}
+	getRequest(mux, "/admin/logout", laptop)
+	if loggedIn(laptop) || !loggedIn(phone) {
+		t.Error("logout should end only its own session")
+	}
+
+	// Idle sessions expire.
+	app.DB.Exec("UPDATE sessions SET last_seen=datetime('now', '-2 days')")
+	if loggedIn(phone) {
+		t.Error("idle session still valid")
+	}
+
+	a, b := adminCookie(app), adminCookie(app)
+	postForm(mux, "/admin/logout/all?lang=fr", url.Values{}, a)
+	if loggedIn(a) || loggedIn(b) {
+		t.Error("log out everywhere left a session open")
+	}
+
+	c, other := adminCookie(app), adminCookie(app)
+	w := postForm(mux, "/admin/settings/password?lang=en", url.Values{
+		"current_password": {"testpass"}, "new_password": {"changed-pass"}, "new_password_confirm": {"changed-pass"},
+	}, c)
+	if loggedIn(c) || loggedIn(other) {
+		t.Error("session survived a password change")
+	}
+	var renewed *http.Cookie
+	for _, ck := range w.Result().Cookies() {
+		if ck.Name == "admin_session" {
+			renewed = ck
+		}
+	}
+	if renewed == nil || !loggedIn(renewed) {
+		t.Error("the browser changing the password should stay logged in")
+	}
+}
+
+func TestAdminPassword(t *testing.T) {
+	app := testApp(t)
+	app.AdminPassword = ""
+	mux := newMux(app)
+
+	// A fresh install without EVENT_SIGNUP_ADMIN_PASSWORD asks for one.
+	if w := getRequest(mux, "/admin/login?lang=en"); w.Code != http.StatusSeeOther || !strings.HasPrefix(w.Header().Get("Location"), "/admin/setup") {
+		t.Fatalf("login without a password: %d %s", w.Code, w.Header().Get("Location"))
+	}
+	if w := postForm(mux, "/admin/setup?lang=en", url.Values{"password": {"short"}, "password_confirm": {"short"}}); !strings.Contains(w.Body.String(), "at least 8") {
+		t.Error("short password accepted")
+	}
+	postForm(mux, "/admin/setup?lang=en", url.Values{"password": {"first-pass"}, "password_confirm": {"first-pass"}})
+	hash := GetSetting(app.DB, "admin_password_hash")
+	if !strings.HasPrefix(hash, "$2") {
+		t.Fatalf("stored password = %q, want a bcrypt hash", hash)
+	}
+	// Setup is closed once a password exists.
+	postForm(mux, "/admin/setup?lang=en", url.Values{"password": {"hijacked!"}, "password_confirm": {"hijacked!"}})
+	if GetSetting(app.DB, "admin_password_hash") != hash {
+		t.Error("setup overwrote an existing password")
+	}
+	if w := postForm(mux, "/admin/login?lang=en", url.Values{"password": {"first-pass"}}); w.Code != http.StatusSeeOther {
+		t.Errorf("login with the chosen password: status %d", w.Code)
+	}
+
+	c := adminCookie(app)
+	postForm(mux, "/admin/settings/password?lang=en", url.Values{
+		"current_password": {"wrong"}, "new_password": {"second-pass"}, "new_password_confirm": {"second-pass"},
+	}, c)
+	if !app.checkAdminPassword("first-pass") {
+		t.Error("password changed without the current one")
+	}
+}
+
+func TestRememberedSessions(t *testing.T) {
+	app := testApp(t)
+	mux := newMux(app)
+	login := func(remember string) *http.Cookie {
+		w := postForm(mux, "/admin/login?lang=fr", url.Values{"password": {"testpass"}, "remember": {remember}})
+		for _, c := range w.Result().Cookies() {
+			if c.Name == "admin_session" {
+				return c
+			}
+		}
+		t.Fatal("login did not open a session")
+		return nil
+	}
+	// responds reports whether c is logged in, and the cookie the response
+	// replaced it with, if any.
+	responds := func(c *http.Cookie) (bool, *http.Cookie) {
+		w := getRequest(mux, "/admin", c)
+		for _, nc := range w.Result().Cookies() {
+			if nc.Name == "admin_session" {
+				return w.Code == http.StatusOK, nc
+			}
+		}
+		return w.Code == http.StatusOK, nil
+	}
+
+	plain, kept := login(""), login("1")
+	if plain.MaxAge != 0 || kept.MaxAge <= 0 {
+		t.Errorf("cookie lifetimes: plain %d, remembered %d", plain.MaxAge, kept.MaxAge)
+	}
+	app.DB.Exec("UPDATE sessions SET last_seen=datetime('now', '-31 minutes')")
+	if ok, _ := responds(plain); ok {
+		t.Error("plain session survived 31 idle minutes")
+	}
+	if ok, _ := responds(kept); !ok {
+		t.Fatal("remembered session expired after 31 idle minutes")
+	}
+
+	// A day later the remembered token is replaced; the old one lingers
+	// just long enough for requests already in flight.
+	app.DB.Exec("UPDATE sessions SET rotated_at=datetime('now', '-25 hours')")
+	ok, next := responds(kept)
+	if !ok || next == nil || next.Value == kept.Value {
+		t.Fatalf("token not rotated: ok=%v cookie=%v", ok, next)
+	}
+	if ok, _ := responds(kept); !ok {
+		t.Error("replaced token refused within the grace period")
+	}
+	app.DB.Exec("UPDATE sessions SET rotated_at=datetime('now', '-2 minutes')")
+	if ok, _ := responds(kept); ok {
+		t.Error("replaced token still valid after the grace period")
+	}
+	if ok, _ := responds(next); !ok {
+		t.Error("rotated token refused")
+	}
+}
+
+func TestAdminLoginLink(t *testing.T) {
+	app := testApp(t)
+	mux := newMux(app)
+	fake := app.Email.(*fakeEmailSender)
+
+	postForm(mux, "/admin/settings/login-emails?lang=fr", url.Values{"admin_emails": {"Alice@Test.com"}}, adminCookie(app))
+	if got := GetSetting(app.DB, "admin_emails"); got != "alice@test.com" {
+		t.Fatalf("admin_emails = %q", got)
+	}
+	if !strings.Contains(getRequest(mux, "/admin/login?lang=fr").Body.String(), "/admin/login/email") {
+		t.Error("login page lacks the email form")
+	}
+
+	// Unknown addresses get the same answer but no email.
+	w := postForm(mux, "/admin/login/email?lang=fr", url.Values{"email": {"mallory@test.com"}})
+	if w.Code != http.StatusSeeOther || fake.count() != 0 {
+		t.Fatalf("unknown address: status %d, %d emails sent", w.Code, fake.count())
+	}
+	known := postForm(mux, "/admin/login/email?lang=fr", url.Values{"email": {"alice@test.com"}})
+	again := postForm(mux, "/admin/login/email?lang=fr", url.Values{"email": {"alice@test.com"}})
+	for _, got := range []*httptest.ResponseRecorder{known, again} {
+		if got.Code != w.Code || got.Header().Get("Location") != w.Header().Get("Location") ||
+			got.Header().Get("Set-Cookie") != w.Header().Get("Set-Cookie") {
+			t.Errorf("answer differs from the unknown address's: %d %v", got.Code, got.Header())
+		}
+	}
+	if fake.count() != 1 || fake.sent[0].To != "alice@test.com" {
+		t.Fatalf("sent %+v, want a single link to alice", fake.sent)
+	}
+	m := regexp.MustCompile(`token=([0-9a-f]+)`).FindStringSubmatch(fake.sent[0].HTML)
+	if m == nil {
+		t.Fatal("no token in the login email")
+	}
+
+	// Opening the link only asks for confirmation.
+	if body := getRequest(mux, "/admin/login/link?token="+m[1]).Body.String(); !strings.Contains(body, "alice@test.com") {
+		t.Error("confirmation page does not name the address")
+	}
+	w = postForm(mux, "/admin/login/link?lang=fr", url.Values{"token": {m[1]}})
+	if w.Code != http.StatusSeeOther || !strings.Contains(w.Header().Get("Set-Cookie"), "admin_session=") {
+		t.Fatalf("using the link: status %d, cookie %q", w.Code, w.Header().Get("Set-Cookie"))
+	}
+	if w := postForm(mux, "/admin/login/link?lang=fr", url.Values{"token": {m[1]}}); w.Header().Get("Set-Cookie") != "" {
+		t.Error("a login link worked twice")
+	}
+
+	expired, _ := CreateLoginLink(app.DB, "alice@test.com", -time.Minute)
+	if w := postForm(mux, "/admin/login/link?lang=fr", url.Values{"token": {expired}}); w.Header().Get("Set-Cookie") != "" {
+		t.Error("an expired login link worked")
+	}
+}
+
 func TestAdminWithAuth(t *testing.T) {
 	app := testApp(t)
 	seedEvent(t, app.DB)
@@ -577,3 +1127,563 @@ func TestSignupGetRedirects(t *testing.T) {
 		t.Errorf("GET /signup status = %d, want %d", w.Code, http.StatusSeeOther)
 	}
 }
+
+// ---- Per-event base URL ----
+
+func TestNormalizeBaseURL(t *testing.T) {
+	cases := []struct {
+		in, want string
+		ok       bool
+	}{
+		{"", "", true},
+		{"https://Fete.Example.org", "https://fete.example.org", true},
+		{"https://fete.example.org/", "https://fete.example.org", true},
+		{"http://localhost:8090", "http://localhost:8090", true},
+		{"ftp://fete.example.org", "", false},
+		{"fete.example.org", "", false},
+		{"https://fete.example.org/e/noel", "", false},
+		{"https://user@fete.example.org", "", false},
+	}
+	for _, c := range cases {
+		got, err := normalizeBaseURL(c.in)
+		if (err == nil) != c.ok || got != c.want {
+			t.Errorf("normalizeBaseURL(%q) = %q, %v; want %q, ok=%v", c.in, got, err, c.want, c.ok)
+		}
+	}
+}
+
+func TestEventSaveRejectsInvalidBaseURL(t *testing.T) {
+	app := testApp(t)
+	e := seedEvent(t, app.DB)
+	mux := newMux(app)
+
+	save := func(baseURL string) int {
+		body, _ := json.Marshal(map[string]any{
+			"event_id": e.ID, "title_fr": e.TitleFR, "event_date": e.EventDate, "base_url": baseURL,
+		})
+		req := httptest.NewRequest(http.MethodPost, "/admin/api/event/save", bytes.NewReader(body))
+		req.AddCookie(adminCookie(app))
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := save("javascript:alert(1)"); code != 400 {
+		t.Errorf("invalid base url: status = %d, want 400", code)
+	}
+	if code := save("https://Fete.Example.org/"); code != 200 {
+		t.Fatalf("valid base url: status = %d", code)
+	}
+	got, _ := GetEvent(app.DB, e.ID)
+	if got.BaseURL != "https://fete.example.org" {
+		t.Errorf("base url = %q", got.BaseURL)
+	}
+}
+
+func TestOrganizerEmailIsReplyTo(t *testing.T) {
+	app := testApp(t)
+	e := seedEvent(t, app.DB)
+	tk := seedTask(t, app.DB, e.ID, "Vaisselle", nil)
+	mux := newMux(app)
+
+	save := func(organizer string) int {
+		body, _ := json.Marshal(map[string]any{
+			"event_id": e.ID, "title_fr": e.TitleFR, "event_date": e.EventDate, "organizer_email": organizer,
+		})
+		req := httptest.NewRequest(http.MethodPost, "/admin/api/event/save", bytes.NewReader(body))
+		req.AddCookie(adminCookie(app))
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w.Code
+	}
+	if code := save("not an address"); code != 400 {
+		t.Errorf("invalid organizer email: status = %d, want 400", code)
+	}
+	if code := save(" Orga <orga@test.com> "); code != 200 {
+		t.Fatalf("valid organizer email: status = %d", code)
+	}
+	if got, _ := GetEvent(app.DB, e.ID); got.OrganizerEmail != "orga@test.com" {
+		t.Fatalf("organizer email = %q", got.OrganizerEmail)
+	}
+
+	postForm(mux, "/signup?lang=fr", url.Values{
+		"task_id":    {fmt.Sprint(tk.ID)},
+		"first_name": {"Alice"},
+		"last_name":  {"Dupont"},
+		"email":      {"alice@test.com"},
+		"phone":      {"0601"},
+	})
+	fake := app.Email.(*fakeEmailSender)
+	if fake.count() != 1 || fake.sent[0].ReplyTo != "orga@test.com" {
+		t.Errorf("confirmation email = %+v", fake.sent)
+	}
+}
+
+func TestNormalizeNotifyEmails(t *testing.T) {
+	got, err := normalizeNotifyEmails(" a@test.com;b@test.com,\nc@test.com ")
+	if err != nil || got != "a@test.com, b@test.com, c@test.com" {
+		t.Errorf("got %q, %v", got, err)
+	}
+	if got, err := normalizeNotifyEmails(""); err != nil || got != "" {
+		t.Errorf("empty: got %q, %v", got, err)
+	}
+	if _, err := normalizeNotifyEmails("a@test.com, not-an-address"); err == nil {
+		t.Error("expected an error for an invalid address")
+	}
+}
+
+func TestFormatEventTimeZoned(t *testing.T) {
+	cases := []struct {
+		e    Event
+		lang string
+		want string
+	}{
+		{Event{EventDate: "2026-06-14", EventTime: "14:30"}, "fr", "14h30"},
+		{Event{EventDate: "2026-06-14", EventTime: "14:30", Timezone: "Europe/Paris"}, "fr", "14h30 CEST · 12h30 UTC"},
+		{Event{EventDate: "2026-01-14", EventTime: "00:30", Timezone: "Europe/Paris"}, "en", "12:30 AM CET · 11:30 PM UTC (the day before)"},
+		{Event{EventDate: "2026-06-14", EventTime: "20:00", Timezone: "America/New_York"}, "en", "8:00 PM EDT · 12:00 AM UTC (the next day)"},
+		{Event{EventDate: "2026-06-14", EventTime: "14:30", Timezone: "UTC"}, "fr", "14h30 UTC"},
+	}
+	for _, c := range cases {
+		if got := formatEventTimeZoned(c.e, c.lang); got != c.want {
+			t.Errorf("%s %s in %q: got %q, want %q", c.e.EventDate, c.e.EventTime, c.e.Timezone, got, c.want)
+		}
+	}
+}
+
+func TestEventTimezone(t *testing.T) {
+	app := testApp(t)
+	e := seedEvent(t, app.DB)
+	mux := newMux(app)
+
+	save := func(tz string) int {
+		body, _ := json.Marshal(map[string]any{
+			"event_id": e.ID, "title_fr": e.TitleFR, "event_date": e.EventDate, "event_time": "14:30", "timezone": tz,
+		})
+		req := httptest.NewRequest(http.MethodPost, "/admin/api/event/save", bytes.NewReader(body))
+		req.AddCookie(adminCookie(app))
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w.Code
+	}
+	for _, bad := range []string{"Mars/Olympus", "Local"} {
+		if code := save(bad); code != 400 {
+			t.Errorf("timezone %q: status = %d, want 400", bad, code)
+		}
+	}
+	if code := save("Europe/Paris"); code != 200 {
+		t.Fatalf("valid timezone: status = %d", code)
+	}
+
+	if w := getRequest(mux, "/e/"+e.Slug+"?lang=fr"); !strings.Contains(w.Body.String(), "14h30 CEST · 12h30 UTC") {
+		t.Error("public page lacks the zone and UTC hint")
+	}
+	if w := getRequest(mux, "/e/"+e.Slug+"/calendar.ics"); !strings.Contains(w.Body.String(), "DTSTART:20260615T123000Z\r\n") {
+		t.Errorf("calendar file not pinned to UTC:\n%s", w.Body.String())
+	}
+
+	tk := seedTask(t, app.DB, e.ID, "Vaisselle", nil)
+	reg, _ := RegisterForTask(app.DB, tk.ID, "Alice", "Dupont", "alice@test.com", "0601", "fr")
+	app.DB.Exec("UPDATE registrations SET created_at='2026-06-01 10:00:00' WHERE id=?", reg.ID)
+	w := getRequest(mux, fmt.Sprintf("/admin/export?event_id=%d", e.ID), adminCookie(app))
+	if !strings.Contains(w.Body.String(), "2026-06-01 12:00") {
+		t.Errorf("CSV signup time not in the event's zone:\n%s", w.Body.String())
+	}
+}
+
+func TestEventEnd(t *testing.T) {
+	app := testApp(t)
+	e := seedEvent(t, app.DB)
+	mux := newMux(app)
+
+	save := func(endDate, endTime string) int {
+		body, _ := json.Marshal(map[string]any{
+			"event_id": e.ID, "title_fr": e.TitleFR, "event_date": e.EventDate, "event_time": "14:30",
+			"end_date": endDate, "end_time": endTime,
+		})
+		req := httptest.NewRequest(http.MethodPost, "/admin/api/event/save", bytes.NewReader(body))
+		req.AddCookie(adminCookie(app))
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w.Code
+	}
+	if code := save("2026-06-14", ""); code != 400 {
+		t.Errorf("end before start: status = %d, want 400", code)
+	}
+	if code := save("", "16h"); code != 400 {
+		t.Errorf("malformed end time: status = %d, want 400", code)
+	}
+	if code := save("2026-06-15", "16:30"); code != 200 {
+		t.Fatalf("valid end: status = %d", code)
+	}
+	got, _ := GetEvent(app.DB, e.ID)
+	if got.EndDate != "" || got.EndTime != "16:30" {
+		t.Fatalf("end = %q %q, want same-day end date dropped", got.EndDate, got.EndTime)
+	}
+	if w := getRequest(mux, "/e/"+e.Slug+"?lang=fr"); !strings.Contains(w.Body.String(), "16h30 (2 h)") {
+		t.Error("public page lacks the end time and duration")
+	}
+	if w := getRequest(mux, "/e/"+e.Slug+"/calendar.ics"); !strings.Contains(w.Body.String(), "DTEND:20260615T163000\r\n") {
+		t.Errorf("calendar file lacks the end:\n%s", w.Body.String())
+	}
+	if ctx := aiEventContext(*got); !strings.Contains(ctx, "2026-06-15 14:30 to 16:30 (2 h)") {
+		t.Errorf("AI context = %q", ctx)
+	}
+
+	// An all-day event over a weekend spans both days.
+	got.EventTime, got.EndTime, got.EndDate = "", "", "2026-06-16"
+	UpdateEvent(app.DB, got)
+	if w := getRequest(mux, "/e/"+e.Slug+"/calendar.ics"); !strings.Contains(w.Body.String(), "DTEND;VALUE=DATE:20260617\r\n") {
+		t.Errorf("multi-day calendar file:\n%s", w.Body.String())
+	}
+	if ctx := aiEventContext(*got); !strings.Contains(ctx, "2026-06-15 to 2026-06-16, all day") {
+		t.Errorf("AI context = %q", ctx)
+	}
+}
+
+func TestAdminNotificationEmails(t *testing.T) {
+	app := testApp(t)
+	e := seedEvent(t, app.DB)
+	e.NotifyEmails = "orga@test.com, chef@test.com"
+	UpdateEvent(app.DB, e)
+	tk := seedTask(t, app.DB, e.ID, "Vaisselle", int64Ptr(4))
+	mux := newMux(app)
+
+	postForm(mux, "/signup?lang=en", url.Values{
+		"task_id":    {fmt.Sprint(tk.ID)},
+		"first_name": {"Alice"},
+		"last_name":  {"Dupont"},
+		"email":      {"alice@test.com"},
+		"phone":      {"0601"},
+	})
+	fake := app.Email.(*fakeEmailSender)
+	var toAdmins []sentEmail
+	for _, m := range fake.sent {
+		if m.To != "alice@test.com" {
+			toAdmins = append(toAdmins, m)
+		}
+	}
+	if len(toAdmins) != 2 || toAdmins[0].To != "orga@test.com" || toAdmins[1].To != "chef@test.com" {
+		t.Fatalf("admin notifications = %+v", toAdmins)
+	}
+	if !strings.Contains(toAdmins[0].HTML, "1 / 4") || !strings.Contains(toAdmins[0].HTML, "alice@test.com") {
+		t.Error("notification should carry the registrant's details and the task fill level")
+	}
+
+	// RSVPs notify too; events without recipients stay silent.
+	att := &Event{TitleFR: "AG", EventDate: "2026-06-14", EventType: "attendance", NotifyEmails: "orga@test.com"}
+	CreateEvent(app.DB, att)
+	quiet := &Event{TitleFR: "Pique-nique", EventDate: "2026-06-14", EventType: "attendance"}
+	CreateEvent(app.DB, quiet)
+	before := fake.count()
+	for _, id := range []int64{att.ID, quiet.ID} {
+		postForm(mux, "/rsvp?lang=fr", url.Values{
+			"event_id":   {fmt.Sprint(id)},
+			"first_name": {"Bob"},
+			"last_name":  {"Martin"},
+			"email":      {"bob@test.com"},
+			"attending":  {"yes"},
+		})
+	}
+	if fake.count() != before+1 {
+		t.Fatalf("expected exactly one RSVP notification, got %d", fake.count()-before)
+	}
+	if last := fake.sent[len(fake.sent)-1]; last.To != "orga@test.com" || !strings.Contains(last.HTML, "1 / 1") {
+		t.Errorf("rsvp notification = %+v", last)
+	}
+}
+
+func TestEmailLinksUseEventBaseURL(t *testing.T) {
+	app := testApp(t)
+	e := seedSantaEvent(t, app.DB)
+	e.BaseURL = "https://fete.example.org"
+	UpdateEvent(app.DB, e)
+
+	mux := newMux(app)
+	postForm(mux, "/santa/register?lang=fr", url.Values{
+		"event_id":   {fmt.Sprint(e.ID)},
+		"first_name": {"Alice"},
+		"last_name":  {"Dupont"},
+		"email":      {"alice@test.com"},
+	})
+	fake := app.Email.(*fakeEmailSender)
+	if fake.count() != 1 {
+		t.Fatalf("expected 1 link email, got %d", fake.count())
+	}
+	if !strings.Contains(fake.sent[0].HTML, "https://fete.example.org/santa/edit?token=") {
+		t.Error("magic link should use the event's custom domain")
+	}
+}
+
+func TestBaseURLTrustsKnownHostsOnly(t *testing.T) {
+	app := testApp(t)
+	e := seedEvent(t, app.DB)
+	e.BaseURL = "https://fete.example.org"
+	UpdateEvent(app.DB, e)
+	other := seedEvent(t, app.DB)
+	req := func(host, forwarded string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Host = host
+		if forwarded != "" {
+			r.Header.Set("X-Forwarded-Host", forwarded)
+		}
+		return r
+	}
+
+	// A fresh instance knows no address but the one asked for.
+	if got := app.baseURLFor(req("example.com", "")); got != "http://example.com" {
+		t.Errorf("fresh instance: %q", got)
+	}
+	SetSetting(app.DB, adminBaseURLKey, "https://events.example.com")
+	cases := []struct{ host, forwarded, want string }{
+		{"events.example.com", "", "https://events.example.com"},
+		{"proxy", "fete.example.org", "https://fete.example.org"},
+		{"localhost:8090", "", "http://localhost:8090"},
+		{"evil.example.net", "", "https://events.example.com"},
+		{"events.example.com", "evil.example.net", "https://events.example.com"},
+	}
+	for _, c := range cases {
+		if got := app.baseURLFor(req(c.host, c.forwarded)); got != c.want {
+			t.Errorf("baseURLFor(%s, %s) = %q, want %q", c.host, c.forwarded, got, c.want)
+		}
+	}
+	if got := app.eventBaseURL(req("x", "evil.example.net"), other); got != "https://events.example.com" {
+		t.Errorf("eventBaseURL with forged host = %q", got)
+	}
+	if got := app.eventBaseURL(req("x", "evil.example.net"), e); got != "https://fete.example.org" {
+		t.Errorf("eventBaseURL of vanity event = %q", got)
+	}
+}
+
+func TestRootRedirectsVanityHostToEvent(t *testing.T) {
+	app := testApp(t)
+	e := seedEvent(t, app.DB)
+	e.BaseURL = "https://fete.example.org"
+	UpdateEvent(app.DB, e)
+	mux := newMux(app)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Host = "fete.example.org"
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if loc := w.Header().Get("Location"); loc != "/e/"+e.Slug {
+		t.Errorf("vanity host: Location = %q, want /e/%s", loc, e.Slug)
+	}
+
+	w = getRequest(mux, "/")
+	if loc := w.Header().Get("Location"); loc != "/admin" {
+		t.Errorf("default host: Location = %q, want /admin", loc)
+	}
+}
+
+// ---- Shared stats dashboard ----
+
+func TestStatsDashboardLink(t *testing.T) {
+	app := testApp(t)
+	e := seedEvent(t, app.DB)
+	tk := seedTask(t, app.DB, e.ID, "Cuisine", int64Ptr(3))
+	RegisterForTask(app.DB, tk.ID, "Alice", "Dupont", "alice@test.com", "0601", "fr")
+	mux := newMux(app)
+
+	enable := func() string {
+		postForm(mux, "/admin/event/stats-link", url.Values{"event_id": {fmt.Sprint(e.ID)}}, adminCookie(app))
+		got, _ := GetEvent(app.DB, e.ID)
+		return got.StatsToken
+	}
+
+	token := enable()
+	if token == "" {
+		t.Fatal("expected a stats token after enabling")
+	}
+	w := getRequest(mux, "/stats/"+token+"?lang=fr")
+	if w.Code != 200 {
+		t.Fatalf("status = %d", w.Code)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, "Cuisine") || !strings.Contains(body, "1 / 3") {
+		t.Error("dashboard should show per-task fill")
+	}
+	if strings.Contains(body, "alice@test.com") || strings.Contains(body, "Dupont") {
+		t.Error("dashboard must not expose personal data")
+	}
+
+	// Regenerating revokes the previous link.
+	if newToken := enable(); newToken == token {
+		t.Error("regenerate should issue a new token")
+	}
+	if w := getRequest(mux, "/stats/"+token); w.Code != 404 {
+		t.Errorf("old token: status = %d, want 404", w.Code)
+	}
+
+	postForm(mux, "/admin/event/stats-link", url.Values{"event_id": {fmt.Sprint(e.ID)}, "action": {"disable"}}, adminCookie(app))
+	if w := getRequest(mux, "/stats/"); w.Code != 404 {
+		t.Errorf("empty token: status = %d, want 404", w.Code)
+	}
+	got, _ := GetEvent(app.DB, e.ID)
+	if got.StatsToken != "" {
+		t.Error("disable should clear the token")
+	}
+}
+
+func TestAdminEventDuplicate(t *testing.T) {
+	app := testApp(t)
+	e := seedEvent(t, app.DB)
+	g := &TaskGroup{EventID: e.ID, TitleFR: "Cuisine", TitleEN: "Kitchen"}
+	CreateTaskGroup(app.DB, g)
+	tk := &Task{EventID: e.ID, GroupID: sql.NullInt64{Int64: g.ID, Valid: true}, TitleFR: "Vaisselle", MaxSlots: sql.NullInt64{Int64: 2, Valid: true}}
+	CreateTask(app.DB, tk)
+	RegisterForTask(app.DB, tk.ID, "Alice", "Dupont", "alice@test.com", "0601", "fr")
+	mux := newMux(app)
+	admin := adminCookie(app)
+
+	if body := getRequest(mux, "/admin?tab=past&lang=en", admin).Body.String(); !strings.Contains(body, `value="2027-06-15"`) {
+		t.Error("duplicate form does not suggest the same date next year")
+	}
+	w := postForm(mux, "/admin/event/duplicate?lang=en", url.Values{"id": {fmt.Sprint(e.ID)}, "event_date": {"2027-06-12"}}, admin)
+	loc := w.Header().Get("Location")
+	var dupID int64
+	if _, err := fmt.Sscanf(loc, "/admin/event/edit?id=%d", &dupID); err != nil || dupID == e.ID {
+		t.Fatalf("redirected to %q", loc)
+	}
+	dup, err := GetEvent(app.DB, dupID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dup.TitleFR != e.TitleFR || dup.EventDate != "2027-06-12" || dup.Slug == e.Slug {
+		t.Errorf("copy = %q on %s at /e/%s", dup.TitleFR, dup.EventDate, dup.Slug)
+	}
+	groups, _ := ListTaskGroups(app.DB, dupID)
+	tasks, _ := ListTasks(app.DB, dupID)
+	if len(groups) != 1 || len(tasks) != 1 || tasks[0].GroupID.Int64 != groups[0].ID || tasks[0].MaxSlots.Int64 != 2 {
+		t.Errorf("copied tree: %+v, %+v", groups, tasks)
+	}
+	if CountRegistrations(app.DB, dupID) != 0 || CountRegistrations(app.DB, e.ID) != 1 {
+		t.Error("registrations were copied or lost")
+	}
+
+	w = postForm(mux, "/admin/event/duplicate?lang=en", url.Values{"id": {fmt.Sprint(e.ID)}, "event_date": {"next year"}}, admin)
+	if body := followRedirect(mux, w, admin).Body.String(); !strings.Contains(body, T("event_duplicate_error_date", "en")) {
+		t.Error("an invalid date was accepted")
+	}
+}
+
+func TestAPIBulkMaxSlots(t *testing.T) {
+	app := testApp(t)
+	mux := newMux(app)
+	e := seedEvent(t, app.DB)
+	dishes := seedTask(t, app.DB, e.ID, "Vaisselle", nil)
+	cooking := &Task{EventID: e.ID, TitleFR: "Cuisine", MaxSlots: sql.NullInt64{Int64: 6, Valid: true}, MinSlots: 4, ReservedSlots: 3}
+	CreateTask(app.DB, cooking)
+	bar := seedTask(t, app.DB, e.ID, "Buvette", nil)
+	other := &Event{TitleFR: "Brocante", EventDate: "2026-07-01"}
+	CreateEvent(app.DB, other)
+	stranger := seedTask(t, app.DB, other.ID, "Accueil", nil)
+
+	body, _ := json.Marshal(map[string]any{"event_id": e.ID, "task_ids": []int64{dishes.ID, cooking.ID, stranger.ID}, "max_slots": 2})
+	req := httptest.NewRequest(http.MethodPost, "/admin/api/max-slots/bulk", bytes.NewReader(body))
+	req.AddCookie(adminCookie(app))
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != 200 || !strings.Contains(w.Body.String(), `"updated":2`) {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	for _, id := range []int64{dishes.ID, cooking.ID} {
+		if got, _ := GetTask(app.DB, id); got.MaxSlots.Int64 != 2 {
+			t.Errorf("task %d: max slots %v, want 2", id, got.MaxSlots)
+		}
+	}
+	if got, _ := GetTask(app.DB, cooking.ID); got.MinSlots != 2 || got.ReservedSlots != 2 {
+		t.Errorf("min %d, reserved %d not brought within the new max", got.MinSlots, got.ReservedSlots)
+	}
+	if got, _ := GetTask(app.DB, bar.ID); got.MaxSlots.Valid {
+		t.Error("unselected task changed")
+	}
+	if got, _ := GetTask(app.DB, stranger.ID); got.MaxSlots.Valid {
+		t.Error("task of another event changed")
+	}
+}
+
+func TestOrganizerNotes(t *testing.T) {
+	app := testApp(t)
+	mux := newMux(app)
+	admin := adminCookie(app)
+	e := seedEvent(t, app.DB)
+	tk := seedTask(t, app.DB, e.ID, "Cuisine", nil)
+
+	post := func(path string, data map[string]any) {
+		t.Helper()
+		body, _ := json.Marshal(data)
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+		req.AddCookie(admin)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		if w.Code != 200 {
+			t.Fatalf("%s: status %d: %s", path, w.Code, w.Body)
+		}
+	}
+	post("/admin/api/event/save", map[string]any{"event_id": e.ID, "title_fr": e.TitleFR, "event_date": e.EventDate, "notes": "Code du local : 4821"})
+	post("/admin/api/task/save", map[string]any{"id": tk.ID, "title_fr": "Cuisine", "notes": "Traiteur : 06 12 34 56 78"})
+
+	if got, _ := GetEvent(app.DB, e.ID); got.Notes != "Code du local : 4821" {
+		t.Errorf("event notes = %q", got.Notes)
+	}
+	if got, _ := GetTask(app.DB, tk.ID); got.Notes != "Traiteur : 06 12 34 56 78" {
+		t.Errorf("task notes = %q", got.Notes)
+	}
+
+	w := getRequest(mux, fmt.Sprintf("/admin/event/edit?id=%d", e.ID), admin)
+	for _, note := range []string{"4821", "06 12 34 56 78"} {
+		if !strings.Contains(w.Body.String(), note) {
+			t.Errorf("edit page lacks note %q", note)
+		}
+	}
+	w = getRequest(mux, "/e/"+e.Slug)
+	if strings.Contains(w.Body.String(), "4821") || strings.Contains(w.Body.String(), "06 12 34 56 78") {
+		t.Error("private notes shown on the public page")
+	}
+}
+
+func TestRSVPPlusOnes(t *testing.T) {
+	app := testApp(t)
+	mux := newMux(app)
+	e := &Event{TitleFR: "Fête", EventDate: "2026-06-14", EventType: "attendance", MaxGuests: 3}
+	CreateEvent(app.DB, e)
+	rsvp := func(email, attending, adults, children string) string {
+		return postForm(mux, "/rsvp?lang=en", url.Values{
+			"event_id": {fmt.Sprint(e.ID)}, "first_name": {"Bob"}, "last_name": {"Martin"},
+			"email": {email}, "attending": {attending}, "adult_guests": {adults}, "child_guests": {children},
+		}).Body.String()
+	}
+
+	if body := getRequest(mux, "/e/"+e.Slug+"?lang=en").Body.String(); !strings.Contains(body, `name="child_guests"`) {
+		t.Fatal("RSVP form lacks the guest fields")
+	}
+	if body := rsvp("bob@test.com", "yes", "2", "2"); !strings.Contains(body, fmt.Sprintf(T("rsvp_error_guests", LangEN), 3)) {
+		t.Error("more guests than allowed accepted")
+	}
+	if body := rsvp("bob@test.com", "yes", "1", "2"); !strings.Contains(body, fmt.Sprintf(T("rsvp_party", LangEN), 4)) {
+		t.Error("confirmation misses the party size")
+	}
+	rsvp("carol@test.com", "yes", "0", "1")
+	rsvp("dan@test.com", "no", "2", "0")
+	if h := AttendanceHeadcount(app.DB, e.ID); h.Adults != 3 || h.Children != 3 {
+		t.Errorf("headcount = %+v, want 3 adults and 3 children", h)
+	}
+
+	admin := adminCookie(app)
+	if body := getRequest(mux, fmt.Sprintf("/admin/event/attendances?id=%d&lang=en", e.ID), admin).Body.String(); !strings.Contains(body, fmt.Sprintf(T("attendance_headcount", LangEN), 6, 3, 3)) {
+		t.Error("attendances page misses the headcount")
+	}
+	csv := getRequest(mux, fmt.Sprintf("/admin/export?event_id=%d&lang=en", e.ID), admin).Body.String()
+	if !strings.Contains(csv, "Martin,Bob,bob@test.com,,Yes,1,2,4,") || !strings.Contains(csv, "total,,,,,1,3,6") {
+		t.Errorf("export = %s", csv)
+	}
+
+	// Without plus-ones allowed, guest counts are ignored.
+	e.MaxGuests = 0
+	UpdateEvent(app.DB, e)
+	rsvp("bob@test.com", "yes", "2", "0")
+	if a, _ := GetAttendanceByEmail(app.DB, "bob@test.com", e.ID); a.People() != 1 {
+		t.Errorf("people = %d, want 1", a.People())
+	}
+}
@@ -43,53 +43,168 @@ var translations = map[string]Translations{
 	"event_new":            {"fr": "Nouvel événement", "en": "New Event"},
 	"event_no_events":      {"fr": "Aucun événement créé.", "en": "No events yet."},
 	"event_create_first":   {"fr": "Créez votre premier événement", "en": "Create your first event"},
-	"event_delete_confirm": {"fr": "Supprimer cet événement et toutes ses données ?", "en": "Delete this event and all its data?"},
+	"event_delete_confirm": {"fr": "Mettre cet événement et toutes ses données à la corbeille ?", "en": "Move this event and all its data to the trash?"},
+
+	// Event duplication
+	"event_duplicate":            {"fr": "Dupliquer", "en": "Duplicate"},
+	"event_duplicate_date":       {"fr": "Date de la copie", "en": "Date of the copy"},
+	"event_duplicate_submit":     {"fr": "Créer la copie", "en": "Create the copy"},
+	"event_duplicated":           {"fr": "Événement dupliqué avec ses groupes et tâches, sans les inscriptions.", "en": "Event duplicated with its groups and tasks, without the registrations."},
+	"event_duplicate_error_date": {"fr": "Choisissez une date valide pour la copie.", "en": "Choose a valid date for the copy."},
+
+	// Event templates
+	"templates":                {"fr": "Modèles", "en": "Templates"},
+	"templates_title":          {"fr": "Modèles d'événement", "en": "Event templates"},
+	"templates_hint":           {"fr": "Un modèle garde les groupes et tâches d'un événement (horaires et nombre de places compris) pour créer les suivants. Enregistrez-en un depuis la page d'un événement.", "en": "A template keeps an event's groups and tasks (shifts and slot limits included) to create the next ones. Save one from an event's page."},
+	"templates_empty":          {"fr": "Aucun modèle enregistré.", "en": "No templates saved."},
+	"templates_contents":       {"fr": "Contenu", "en": "Contents"},
+	"templates_groups":         {"fr": "groupes", "en": "groups"},
+	"templates_tasks":          {"fr": "tâches", "en": "tasks"},
+	"templates_created":        {"fr": "Créé le", "en": "Created"},
+	"templates_rename":         {"fr": "Renommer", "en": "Rename"},
+	"templates_use":            {"fr": "Nouvel événement", "en": "New event"},
+	"templates_delete_confirm": {"fr": "Supprimer ce modèle ? Les événements créés avec restent inchangés.", "en": "Delete this template? Events created from it are not affected."},
+	"template_name":            {"fr": "Nom du modèle", "en": "Template name"},
+	"template_manage":          {"fr": "Gérer les modèles", "en": "Manage templates"},
+	"template_save_title":      {"fr": "Enregistrer comme modèle", "en": "Save as template"},
+	"template_save_hint":       {"fr": "Enregistrez les groupes et tâches ci-dessus pour créer d'autres événements avec la même organisation.", "en": "Save the groups and tasks above to create other events with the same structure."},
+	"template_save":            {"fr": "Enregistrer le modèle", "en": "Save template"},
+	"template_saved":           {"fr": "Modèle enregistré.", "en": "Template saved."},
+	"template_deleted":         {"fr": "Modèle supprimé.", "en": "Template deleted."},
+	"template_error_name":      {"fr": "Donnez un nom au modèle.", "en": "Give the template a name."},
+	"template_start_from":      {"fr": "Partir d'un modèle", "en": "Start from a template"},
+	"template_none":            {"fr": "Aucun (événement vide)", "en": "None (empty event)"},
+	"template_start_hint":      {"fr": "Les groupes et tâches du modèle sont ajoutés à l'événement créé (événements à tâches uniquement).", "en": "The template's groups and tasks are added to the new event (task events only)."},
 
 	// Event edit
-	"event_edit":        {"fr": "Modifier l'événement", "en": "Edit Event"},
-	"event_details":     {"fr": "Détails de l'événement", "en": "Event Details"},
-	"event_title_fr":    {"fr": "Titre (français)", "en": "Title (French)"},
-	"event_title_en":    {"fr": "Titre (anglais)", "en": "Title (English)"},
-	"event_desc_fr":     {"fr": "Description (français)", "en": "Description (French)"},
-	"event_desc_en":     {"fr": "Description (anglais)", "en": "Description (English)"},
-	"event_date":        {"fr": "Date", "en": "Date"},
-	"event_time":        {"fr": "Heure", "en": "Time"},
-	"event_public_link": {"fr": "Lien public", "en": "Public link"},
-	"event_copy_link":   {"fr": "Copier", "en": "Copy"},
-	"event_copied":      {"fr": "Copié !", "en": "Copied!"},
+	"event_edit":         {"fr": "Modifier l'événement", "en": "Edit Event"},
+	"event_details":      {"fr": "Détails de l'événement", "en": "Event Details"},
+	"event_title_fr":     {"fr": "Titre (français)", "en": "Title (French)"},
+	"event_title_en":     {"fr": "Titre (anglais)", "en": "Title (English)"},
+	"event_title_single": {"fr": "Titre", "en": "Title"},
+	"event_desc_fr":      {"fr": "Description (français)", "en": "Description (French)"},
+	"event_desc_en":      {"fr": "Description (anglais)", "en": "Description (English)"},
+	"event_desc_single":  {"fr": "Description", "en": "Description"},
+	"event_date":         {"fr": "Date", "en": "Date"},
+	"event_time":         {"fr": "Heure", "en": "Time"},
+	"event_public_link":  {"fr": "Lien public", "en": "Public link"},
+	"event_copy_link":    {"fr": "Copier", "en": "Copy"},
+	"event_copied":       {"fr": "Copié !", "en": "Copied!"},
+	"event_qr":           {"fr": "QR code", "en": "QR code"},
+	"event_qr_hint":      {"fr": "Pour les affiches et les flyers ; le SVG reste net à toutes les tailles. Il mène au lien court.", "en": "For posters and flyers; the SVG stays sharp at any size. It leads to the short link."},
+
+	// Per-event vanity domain
+	"event_base_url":      {"fr": "Domaine personnalisé", "en": "Custom domain"},
+	"event_base_url_hint": {"fr": "Optionnel. Adresse (https://…) sous laquelle l'événement est diffusé ; utilisée pour tous les liens envoyés. Le domaine doit pointer vers ce serveur.", "en": "Optional. Address (https://…) the event is promoted under; used for every link sent out. The domain must point at this server."},
+	"event_lang":          {"fr": "Langues", "en": "Languages"},
+	"event_lang_both":     {"fr": "Français et anglais", "en": "French and English"},
+	"event_lang_fr":       {"fr": "Français seulement", "en": "French only"},
+	"event_lang_en":       {"fr": "Anglais seulement", "en": "English only"},
+	"event_lang_hint":     {"fr": "Un événement en une seule langue n'a qu'un jeu de textes, et ses pages publiques s'affichent dans cette langue, sans bouton pour changer.", "en": "A single-language event has one set of texts, and its public pages show in that language, with no button to switch."},
+	"event_noindex":       {"fr": "Décourager l'indexation par les moteurs de recherche", "en": "Discourage search engine indexing"},
+	"event_noindex_hint":  {"fr": "Pour un événement non répertorié : ses pages demandent aux moteurs de recherche de ne pas les afficher, et il reste hors du plan du site. Le lien marche toujours pour qui l'a.", "en": "For an unlisted event: its pages ask search engines not to show them, and it stays off the sitemap. The link still works for anyone who has it."},
+
+	// Event time zone
+	"event_timezone":      {"fr": "Fuseau horaire", "en": "Time zone"},
+	"event_timezone_hint": {"fr": "Optionnel. Fuseau (ex. Europe/Paris) de la date et de l'heure ; les pages, emails et calendriers l'affichent avec l'heure UTC correspondante.", "en": "Optional. Zone (e.g. Europe/Paris) the date and time are in; pages, emails and calendars show it along with the matching UTC time."},
+	"time_utc_day_before": {"fr": "(la veille)", "en": "(the day before)"},
+	"time_utc_day_after":  {"fr": "(le lendemain)", "en": "(the next day)"},
+
+	// Event end
+	"event_end_date": {"fr": "Date de fin", "en": "End date"},
+	"event_end_time": {"fr": "Heure de fin", "en": "End time"},
+	"event_end_hint": {"fr": "Optionnel. Sans date de fin, l'événement se termine le jour même ; sans heure de fin, il dure toute la journée ou reste ouvert.", "en": "Optional. Without an end date the event ends the same day; without an end time it lasts all day or stays open-ended."},
+	"event_ends":     {"fr": "Fin :", "en": "Ends"},
+
+	// Organizer (Reply-To) address
+	"event_organizer_email":      {"fr": "Email de l'organisateur", "en": "Organizer email"},
+	"event_organizer_email_hint": {"fr": "Optionnel. Les réponses aux emails envoyés pour cet événement arrivent à cette adresse.", "en": "Optional. Replies to emails sent for this event go to this address."},
+
+	// Cancellation link window
+	"event_cancel_days":      {"fr": "Validité des liens d'annulation (jours après l'événement)", "en": "Cancellation links valid for (days after the event)"},
+	"event_cancel_days_hint": {"fr": "Vide : les liens n'expirent jamais. 0 : ils cessent de fonctionner à la fin du dernier jour de l'événement.", "en": "Empty: links never expire. 0: they stop working at the end of the event's last day."},
+
+	// Recurring events
+	"series_title":             {"fr": "Série d'événements", "en": "Event series"},
+	"series_repeat":            {"fr": "Répéter cet événement", "en": "Repeat this event"},
+	"series_repeat_hint":       {"fr": "Crée un événement par date, avec les mêmes détails et les mêmes tâches. Chacun a ses propres inscriptions.", "en": "Creates one event per date, with the same details and tasks. Each keeps its own signups."},
+	"series_frequency":         {"fr": "Fréquence", "en": "Frequency"},
+	"series_weekly":            {"fr": "Chaque semaine", "en": "Weekly"},
+	"series_monthly":           {"fr": "Chaque mois", "en": "Monthly"},
+	"series_until":             {"fr": "Jusqu'au", "en": "Until"},
+	"series_create":            {"fr": "Créer la série", "en": "Create series"},
+	"series_created":           {"fr": "%d occurrences créées.", "en": "%d occurrences created."},
+	"series_error_dates":       {"fr": "Choisissez une fréquence et une date de fin après l'événement (%d occurrences au plus).", "en": "Choose a frequency and an end date after the event (at most %d occurrences)."},
+	"series_every_weekly":      {"fr": "Chaque semaine jusqu'au", "en": "Weekly until"},
+	"series_every_monthly":     {"fr": "Chaque mois jusqu'au", "en": "Monthly until"},
+	"series_occurrences":       {"fr": "occurrences", "en": "occurrences"},
+	"series_event":             {"fr": "Événement", "en": "Event"},
+	"series_past":              {"fr": "Passé", "en": "Past"},
+	"series_badge":             {"fr": "Série", "en": "Series"},
+	"series_part_of":           {"fr": "Cet événement fait partie d'une série.", "en": "This event is part of a series."},
+	"series_view":              {"fr": "Voir la série", "en": "View series"},
+	"series_propagate":         {"fr": "Appliquer aux occurrences suivantes", "en": "Apply to later occurrences"},
+	"series_propagate_hint":    {"fr": "Depuis une occurrence, « Appliquer aux occurrences suivantes » recopie ses détails et ses tâches sur toutes les dates suivantes. Les tâches des occurrences qui ont déjà des inscrits ne sont pas modifiées.", "en": "From an occurrence, “Apply to later occurrences” copies its details and tasks to every later date. Tasks of occurrences that already have signups are left unchanged."},
+	"series_propagate_confirm": {"fr": "Remplacer les détails et les tâches de toutes les occurrences suivantes ?", "en": "Replace the details and tasks of every later occurrence?"},
+	"series_propagated":        {"fr": "%d occurrences suivantes mises à jour.", "en": "%d later occurrences updated."},
+	"series_tasks_kept":        {"fr": "Tâches conservées sur %d occurrences qui ont déjà des inscrits.", "en": "Tasks kept on %d occurrences that already have signups."},
+
+	// Admin notifications
+	"event_notify_emails":             {"fr": "Notifications par email", "en": "Email notifications"},
+	"event_notify_emails_hint":        {"fr": "Optionnel. Adresses (séparées par des virgules) prévenues à chaque nouvelle inscription ou réponse.", "en": "Optional. Addresses (comma-separated) notified of every new signup or response."},
+	"notify_email_registration_intro": {"fr": "%s vient de s'inscrire.", "en": "%s just signed up."},
+	"notify_email_attendance_intro":   {"fr": "%s vient de répondre.", "en": "%s just responded."},
+	"notify_email_fill":               {"fr": "Inscrits", "en": "Signed up"},
+	"notify_email_attendance_count":   {"fr": "Présents / réponses", "en": "Attending / responses"},
+	"notify_email_attendance_people":  {"fr": "Personnes attendues", "en": "People expected"},
+	"notify_email_link":               {"fr": "Voir toutes les inscriptions", "en": "See all registrations"},
 
 	// Sections
 	"section_groups_tasks":  {"fr": "Groupes et tâches", "en": "Groups & Tasks"},
 	"section_registrations": {"fr": "Inscriptions", "en": "Registrations"},
 
 	// Groups
-	"group_new":           {"fr": "Nouveau groupe", "en": "New Group"},
-	"group_title_fr":      {"fr": "Nom du groupe (FR)", "en": "Group name (FR)"},
-	"group_title_en":      {"fr": "Nom du groupe (EN)", "en": "Group name (EN)"},
-	"group_ungrouped":     {"fr": "Sans groupe", "en": "Ungrouped"},
-	"group_drop_here":     {"fr": "Déposer ici", "en": "Drop here"},
-	"group_clear_all":     {"fr": "Tout supprimer", "en": "Clear all"},
-	"group_clear_confirm": {"fr": "Supprimer tous les groupes et tâches de cet événement ?", "en": "Delete all groups and tasks for this event?"},
-	"group_parent":        {"fr": "Groupe parent", "en": "Parent group"},
-	"group_root_level":    {"fr": "— Racine —", "en": "— Root level —"},
+	"group_new":            {"fr": "Nouveau groupe", "en": "New Group"},
+	"group_title_fr":       {"fr": "Nom du groupe (FR)", "en": "Group name (FR)"},
+	"group_title_en":       {"fr": "Nom du groupe (EN)", "en": "Group name (EN)"},
+	"group_title_single":   {"fr": "Nom du groupe", "en": "Group name"},
+	"group_ungrouped":      {"fr": "Sans groupe", "en": "Ungrouped"},
+	"group_drop_here":      {"fr": "Déposer ici", "en": "Drop here"},
+	"group_clear_all":      {"fr": "Tout supprimer", "en": "Clear all"},
+	"group_clear_confirm":  {"fr": "Tous les groupes et tâches de cet événement, avec leurs inscriptions, iront à la corbeille, d'où ils pourront être restaurés ensemble. Pour confirmer, tapez le titre de l'événement :", "en": "All of this event's groups and tasks, with their registrations, will go to the trash, where they can be restored together. To confirm, type the event's title:"},
+	"group_clear_mismatch": {"fr": "Le titre tapé ne correspond pas à celui de l'événement : rien n'a été supprimé.", "en": "The title typed doesn't match the event's: nothing was deleted."},
+	"group_cleared":        {"fr": "Groupes et tâches mis à la corbeille.", "en": "Groups and tasks moved to the trash."},
+	"group_parent":         {"fr": "Groupe parent", "en": "Parent group"},
+	"group_root_level":     {"fr": "— Racine —", "en": "— Root level —"},
 
 	// Tasks
 	"task_new":              {"fr": "Nouvelle tâche", "en": "New Task"},
 	"task_title_fr":         {"fr": "Titre (FR)", "en": "Title (FR)"},
 	"task_title_en":         {"fr": "Titre (EN)", "en": "Title (EN)"},
+	"task_title_single":     {"fr": "Titre", "en": "Title"},
 	"task_desc_fr":          {"fr": "Description (FR)", "en": "Description (FR)"},
 	"task_desc_en":          {"fr": "Description (EN)", "en": "Description (EN)"},
+	"task_desc_single":      {"fr": "Description", "en": "Description"},
+	"markdown_hint":         {"fr": "Markdown accepté : **gras**, *italique*, [lien](https://…), listes en « - »", "en": "Markdown works: **bold**, *italics*, [link](https://…), lists with \"- \""},
 	"task_max_slots":        {"fr": "Places max (vide = illimité)", "en": "Max slots (empty = unlimited)"},
 	"task_no_tasks":         {"fr": "Aucune tâche. Ajoutez-en une ci-dessous.", "en": "No tasks yet. Add one below."},
 	"task_unlimited":        {"fr": "illimité", "en": "unlimited"},
 	"task_slots_remaining":  {"fr": "places restantes", "en": "spots remaining"},
 	"task_slots_taken":      {"fr": "inscriptions", "en": "registrations"},
 	"task_full":             {"fr": "Complet", "en": "Full"},
+	"fill_label":            {"fr": "%d / %d places prises", "en": "%d of %d spots taken"},
 	"task_max_people":       {"fr": "pers. max", "en": "max people"},
 	"task_drag_hint":        {"fr": "Glisser pour réorganiser", "en": "Drag to reorder"},
 	"task_add_description":  {"fr": "ajouter une description", "en": "add description"},
 	"task_hide_description": {"fr": "masquer la description", "en": "hide description"},
 
+	// Task shifts and schedule
+	"task_start_time":   {"fr": "Début", "en": "Start"},
+	"task_end_time":     {"fr": "Fin", "en": "End"},
+	"task_times_hint":   {"fr": "Horaire du créneau (optionnel)", "en": "Shift times (optional)"},
+	"schedule_title":    {"fr": "Programme", "en": "Schedule"},
+	"schedule_timeline": {"fr": "Planning des créneaux", "en": "Shift timeline"},
+
 	// Registrations
 	"registrations":               {"fr": "Inscriptions", "en": "Registrations"},
 	"registration_first_name":     {"fr": "Prénom", "en": "First name"},
@@ -97,6 +212,7 @@ var translations = map[string]Translations{
 	"registration_email":          {"fr": "Email", "en": "Email"},
 	"registration_phone":          {"fr": "Téléphone", "en": "Phone"},
 	"registration_date":           {"fr": "Date", "en": "Date"},
+	"registration_lang":           {"fr": "Langue", "en": "Language"},
 	"registration_signup":         {"fr": "S'inscrire", "en": "Sign up"},
 	"registration_no_regs":        {"fr": "Aucune inscription.", "en": "No registrations."},
 	"registration_export_csv":     {"fr": "Exporter CSV", "en": "Export CSV"},
@@ -114,6 +230,7 @@ var translations = map[string]Translations{
 	"public_required_fields": {"fr": "Tous les champs sont obligatoires.", "en": "All fields are required."},
 	"public_signup_btn":      {"fr": "Confirmer l'inscription", "en": "Confirm Registration"},
 	"public_back_to_event":   {"fr": "Retour à l'événement", "en": "Back to event"},
+	"offline_notice":         {"fr": "Vous êtes hors ligne. Les places affichées seront actualisées dès le retour de la connexion.", "en": "You are offline. Remaining spots will refresh as soon as the connection is back."},
 
 	// Confirmation
 	"confirmation_title":       {"fr": "Inscription confirmée", "en": "Registration Confirmed"},
@@ -127,13 +244,36 @@ var translations = map[string]Translations{
 	"confirmation_cancel_info": {"fr": "Conservez ce lien pour vous désinscrire si nécessaire.", "en": "Keep this link to unregister if needed."},
 	"confirmation_back":        {"fr": "Retour à l'événement", "en": "Back to event"},
 
+	// Signup confirmation email
+	"signup_email_subject":       {"fr": "Inscription confirmée :", "en": "Signup confirmed:"},
+	"signup_email_intro":         {"fr": "Merci pour votre inscription ! Voici un récapitulatif.", "en": "Thank you for signing up! Here is a summary."},
+	"signup_email_when":          {"fr": "Quand", "en": "When"},
+	"signup_email_cancel_intro":  {"fr": "Un empêchement ? Ce lien vous permet d'annuler votre inscription depuis n'importe quel appareil.", "en": "Can't make it after all? This link lets you cancel your signup from any device."},
+	"signup_email_cancel_button": {"fr": "Annuler mon inscription", "en": "Cancel my signup"},
+	"registered_email_hint":      {"fr": "Ce lien vous a aussi été envoyé par email.", "en": "This link was also sent to you by email."},
+	"calendar_add":               {"fr": "Ajouter à mon agenda", "en": "Add to calendar"},
+
 	// Cancellation
 	"cancel_title":       {"fr": "Désinscription", "en": "Cancellation"},
 	"cancel_confirm_msg": {"fr": "Voulez-vous annuler votre inscription à cette tâche ?", "en": "Do you want to cancel your registration for this task?"},
 	"cancel_success":     {"fr": "Votre inscription a été annulée.", "en": "Your registration has been cancelled."},
 	"cancel_not_found":   {"fr": "Inscription introuvable.", "en": "Registration not found."},
+	"cancel_expired":     {"fr": "Ce lien d'annulation a expiré. Contactez l'organisateur pour vous désinscrire.", "en": "This cancellation link has expired. Please contact the organizer to cancel."},
 	"cancel_btn":         {"fr": "Confirmer la désinscription", "en": "Confirm Cancellation"},
 
+	// Email preferences (unsubscribe)
+	"unsubscribe_title":           {"fr": "Préférences email", "en": "Email preferences"},
+	"unsubscribe_for":             {"fr": "Adresse : %s", "en": "Address: %s"},
+	"unsubscribe_state_on":        {"fr": "Vous recevez les invitations et rappels.", "en": "You receive invitations and reminders."},
+	"unsubscribe_state_off":       {"fr": "Vous ne recevez plus les invitations ni les rappels.", "en": "You no longer receive invitations or reminders."},
+	"unsubscribe_hint":            {"fr": "Les confirmations de vos propres inscriptions sont toujours envoyées.", "en": "Confirmations of your own signups are always sent."},
+	"unsubscribe_btn":             {"fr": "Ne plus recevoir ces emails", "en": "Stop these emails"},
+	"unsubscribe_resubscribe_btn": {"fr": "Recevoir à nouveau ces emails", "en": "Receive these emails again"},
+	"unsubscribe_done":            {"fr": "C'est noté : vous ne recevrez plus d'invitations ni de rappels.", "en": "Done: you will no longer receive invitations or reminders."},
+	"unsubscribe_resubscribed":    {"fr": "Vous recevrez à nouveau les invitations et rappels.", "en": "You will receive invitations and reminders again."},
+	"unsubscribe_invalid":         {"fr": "Ce lien n'est pas valide.", "en": "This link is not valid."},
+	"unsubscribe_link":            {"fr": "Gérer mes préférences email", "en": "Manage my email preferences"},
+
 	// AI Import
 	"ai_section":     {"fr": "Structurer avec l'IA", "en": "Structure with AI"},
 	"ai_subtitle":    {"fr": "Décrivez les tâches et groupes en texte libre. L'IA créera ou mettra à jour la structure automatiquement.", "en": "Describe tasks and groups in free text. AI will create or update the structure automatically."},
@@ -267,6 +407,13 @@ var translations = map[string]Translations{
 	"email_field_lang_en":       {"fr": "Anglais", "en": "English"},
 	"email_preview_title":       {"fr": "Aperçu de l'email", "en": "Email preview"},
 	"email_preview_intro":       {"fr": "Aperçu mis à jour en direct selon ce que vous tapez. Affiché à gauche en français, à droite en anglais.", "en": "Live preview that updates as you type. French on the left, English on the right."},
+
+	// Admin: after-signup texts
+	"after_signup_title":   {"fr": "Après l'inscription", "en": "After signing up"},
+	"after_signup_intro":   {"fr": "Ce que les inscrits doivent savoir, affiché sous la confirmation d'inscription et dans l'email de confirmation. Laissez vide ce qui ne sert pas ; sans anglais, le français est repris.", "en": "What people need to know once signed up, shown under the signup confirmation and in the confirmation email. Leave unused blocks empty; without English, the French text is used."},
+	"after_signup_info":    {"fr": "Infos pratiques", "en": "Practical information"},
+	"after_signup_bring":   {"fr": "À apporter", "en": "What to bring"},
+	"after_signup_contact": {"fr": "Contact", "en": "Contact"},
 	"santa_email_reveal_intro":   {"fr": "Vous offrez un cadeau à :", "en": "You are giving a gift to:"},
 	"santa_email_reveal_wishes":  {"fr": "Voici ses souhaits :", "en": "Here are their wishes:"},
 
@@ -295,12 +442,813 @@ var translations = map[string]Translations{
 	"attendance_no_responses":   {"fr": "Aucune réponse.", "en": "No responses yet."},
 	"attendance_summary":        {"fr": "présent(e)s", "en": "attending"},
 
+	// RSVP plus-ones
+	"event_max_guests":        {"fr": "Accompagnants par réponse", "en": "Guests per response"},
+	"event_max_guests_hint":   {"fr": "Adultes et enfants que chacun peut amener en répondant oui, comptés à part pour les repas. 0 : pas d'accompagnants.", "en": "Adults and children each person answering yes may bring, counted apart for catering. 0: no guests."},
+	"rsvp_guests_adults":      {"fr": "Adultes qui m'accompagnent", "en": "Adults coming with me"},
+	"rsvp_guests_children":    {"fr": "Enfants qui m'accompagnent", "en": "Children coming with me"},
+	"rsvp_guests_hint":        {"fr": "Jusqu'à %d personnes en plus de vous.", "en": "Up to %d people besides you."},
+	"rsvp_error_guests":       {"fr": "Vous pouvez venir avec %d personnes au plus.", "en": "You can bring at most %d people."},
+	"rsvp_party":              {"fr": "Vous serez %d en tout.", "en": "You will be %d in all."},
+	"attendance_adult_guests": {"fr": "Adultes accompagnants", "en": "Adult guests"},
+	"attendance_child_guests": {"fr": "Enfants accompagnants", "en": "Child guests"},
+	"attendance_people":       {"fr": "Personnes", "en": "People"},
+	"attendance_headcount":    {"fr": "%d personnes attendues : %d adultes, %d enfants", "en": "%d people expected: %d adults, %d children"},
+
+	// Spreadsheet import
+	"sheet_import_title":        {"fr": "Importer un tableur", "en": "Import a spreadsheet"},
+	"sheet_import_hint":         {"fr": "Fichier XLSX ou CSV (par exemple le tableau de l'an dernier), ou cellules copiées depuis un tableur et collées ci-dessous — groupe, tâche, description, places. Vous choisirez les colonnes à l'étape suivante ; les groupes et tâches existants sont complétés, jamais supprimés.", "en": "XLSX or CSV file (e.g. last year's sheet), or cells copied from a spreadsheet and pasted below — group, task, description, slots. You will map the columns in the next step; existing groups and tasks are merged, never deleted."},
+	"sheet_import_btn":          {"fr": "Aperçu", "en": "Preview"},
+	"sheet_import_no_file":      {"fr": "Veuillez choisir un fichier XLSX ou CSV, ou coller un tableau.", "en": "Please choose an XLSX or CSV file, or paste a table."},
+	"sheet_import_paste":        {"fr": "… ou collez ici des cellules de tableur", "en": "… or paste spreadsheet cells here"},
+	"sheet_import_bad_file":     {"fr": "Impossible de lire ce fichier (XLSX ou CSV attendu).", "en": "Could not read this file (XLSX or CSV expected)."},
+	"sheet_import_no_task_col":  {"fr": "Choisissez la colonne contenant les tâches.", "en": "Choose the column holding the tasks."},
+	"sheet_import_mapping":      {"fr": "Correspondance des colonnes", "en": "Column mapping"},
+	"sheet_import_mapping_hint": {"fr": "Indiquez quelle colonne contient quoi. Une cellule de groupe vide reprend le groupe de la ligne précédente (cellules fusionnées).", "en": "Tell which column holds what. An empty group cell reuses the previous row's group (merged cells)."},
+	"sheet_import_has_header":   {"fr": "La première ligne contient les en-têtes", "en": "The first row holds headers"},
+	"sheet_import_apply":        {"fr": "Importer", "en": "Import"},
+	"sheet_import_preview":      {"fr": "Aperçu des premières lignes", "en": "First rows preview"},
+	"sheet_import_done":         {"fr": "%d éléments créés, %d tâches mises à jour.", "en": "%d items created, %d tasks updated."},
+	"sheet_col_group":           {"fr": "Groupe", "en": "Group"},
+	"sheet_col_subgroup":        {"fr": "Sous-groupe", "en": "Subgroup"},
+	"sheet_col_task_fr":         {"fr": "Tâche (français)", "en": "Task (French)"},
+	"sheet_col_task_en":         {"fr": "Tâche (anglais)", "en": "Task (English)"},
+	"sheet_col_description":     {"fr": "Description", "en": "Description"},
+	"sheet_col_max_slots":       {"fr": "Nombre de places", "en": "Number of spots"},
+
+	// Availability poll import (Framadate / Doodle)
+	"poll_import_title":           {"fr": "Importer un sondage", "en": "Import a poll"},
+	"poll_import_hint_tasks":      {"fr": "Export CSV d'un sondage Framadate ou Doodle : chaque option devient une tâche.", "en": "CSV export of a Framadate or Doodle poll: each option becomes a task."},
+	"poll_import_hint_attendance": {"fr": "Export CSV d'un sondage Framadate ou Doodle : les réponses à l'option la plus choisie deviennent des réponses de présence.", "en": "CSV export of a Framadate or Doodle poll: answers to the most popular option become attendance responses."},
+	"poll_import_btn":             {"fr": "Importer", "en": "Import"},
+	"poll_import_no_file":         {"fr": "Veuillez choisir un fichier CSV.", "en": "Please choose a CSV file."},
+	"poll_import_bad_file":        {"fr": "Ce fichier ne ressemble pas à un export de sondage (aucune option trouvée).", "en": "This file does not look like a poll export (no options found)."},
+	"poll_import_tasks_done":      {"fr": "%d tâches créées depuis le sondage.", "en": "%d tasks created from the poll."},
+	"poll_import_attendance_done": {"fr": "%d réponses ajoutées, %d mises à jour, pour l'option « %s ».", "en": "%d responses added, %d updated, for option \"%s\"."},
+	"poll_answer_maybe":           {"fr": "Si nécessaire (sondage)", "en": "If need be (poll)"},
+
+	// Shared stats dashboard
+	"stats_title":           {"fr": "Tableau de bord", "en": "Dashboard"},
+	"stats_overall":         {"fr": "Remplissage global", "en": "Overall progress"},
+	"stats_slots_filled":    {"fr": "places pourvues", "en": "spots filled"},
+	"stats_regs_total":      {"fr": "inscriptions au total", "en": "registrations in total"},
+	"stats_understaffed":    {"fr": "Tâches à pourvoir", "en": "Understaffed tasks"},
+	"stats_all_staffed":     {"fr": "Toutes les tâches sont pourvues.", "en": "Every task is staffed."},
+	"stats_by_task":         {"fr": "Détail par tâche", "en": "By task"},
+	"stats_no_tasks":        {"fr": "Aucune tâche pour le moment.", "en": "No tasks yet."},
+	"stats_santa_total":     {"fr": "participant(e)s", "en": "participants"},
+	"stats_auto_refresh":    {"fr": "Mis à jour automatiquement chaque minute.", "en": "Refreshes automatically every minute."},
+	"stats_link_title":      {"fr": "Tableau de bord partagé", "en": "Shared dashboard"},
+	"stats_link_intro":      {"fr": "Un lien en lecture seule montrant l'avancement des inscriptions, sans aucune donnée personnelle. Pratique pour tenir l'équipe informée sans donner l'accès admin.", "en": "A read-only link showing signup progress, with no personal data. Handy for keeping the wider team informed without giving admin access."},
+	"stats_link_enable":     {"fr": "Créer le lien", "en": "Create link"},
+	"stats_link_regenerate": {"fr": "Régénérer", "en": "Regenerate"},
+	"stats_link_regen_hint": {"fr": "L'ancien lien cessera de fonctionner. Continuer ?", "en": "The old link will stop working. Continue?"},
+	"stats_link_disable":    {"fr": "Désactiver", "en": "Disable"},
+	"stats_link_enabled":    {"fr": "Lien de tableau de bord créé.", "en": "Dashboard link created."},
+	"stats_link_disabled":   {"fr": "Lien de tableau de bord désactivé.", "en": "Dashboard link disabled."},
+
+	// Terms / waiver
+	"terms_title":            {"fr": "Conditions de participation", "en": "Terms of participation"},
+	"terms_hint":             {"fr": "Décharge ou règlement à accepter pour s'inscrire (outils, conduite…). Laisser vide si aucun. Toute modification après une première acceptation crée une nouvelle version.", "en": "Waiver or rules people must accept to sign up (tools, driving…). Leave empty for none. Any change after someone has accepted creates a new version."},
+	"terms_fr":               {"fr": "Texte (français)", "en": "Text (French)"},
+	"terms_en":               {"fr": "Texte (anglais)", "en": "Text (English)"},
+	"terms_single":           {"fr": "Texte", "en": "Text"},
+	"terms_version":          {"fr": "Version", "en": "Version"},
+	"terms_accept":           {"fr": "J'ai lu et j'accepte les conditions ci-dessus.", "en": "I have read and accept the terms above."},
+	"terms_required":         {"fr": "Vous devez accepter les conditions de participation pour vous inscrire.", "en": "You must accept the terms of participation to sign up."},
+	"terms_changed":          {"fr": "Les conditions de participation ont été modifiées. Merci de les relire avant de vous inscrire.", "en": "The terms of participation have changed. Please read them again before signing up."},
+	"terms_report":           {"fr": "Acceptations", "en": "Acceptances"},
+	"terms_report_title":     {"fr": "Acceptation des conditions", "en": "Terms acceptance"},
+	"terms_current_count":    {"fr": "ont accepté la version actuelle", "en": "accepted the current version"},
+	"terms_accepted_at":      {"fr": "Acceptée le", "en": "Accepted on"},
+	"terms_not_accepted":     {"fr": "Aucune", "en": "None"},
+	"terms_outdated":         {"fr": "ancienne version", "en": "outdated"},
+	"terms_none":             {"fr": "Aucune condition n'est définie pour cet événement.", "en": "No terms are set for this event."},
+	"terms_history":          {"fr": "Historique des versions", "en": "Version history"},
+	"terms_no_registrations": {"fr": "Aucune inscription pour le moment.", "en": "No registrations yet."},
+
+	// Outbox (undelivered emails)
+	"outbox":                          {"fr": "Emails en échec", "en": "Failed emails"},
+	"outbox_title":                    {"fr": "Emails non remis", "en": "Undelivered emails"},
+	"outbox_hint":                     {"fr": "Les confirmations et notifications sont renvoyées automatiquement en cas d'erreur d'envoi. Après plusieurs échecs, elles apparaissent ici.", "en": "Confirmations and notifications are retried automatically when sending fails. After repeated failures they show up here."},
+	"outbox_empty":                    {"fr": "Tous les emails ont été envoyés.", "en": "All emails have been sent."},
+	"outbox_to":                       {"fr": "Destinataire", "en": "Recipient"},
+	"outbox_subject":                  {"fr": "Objet", "en": "Subject"},
+	"outbox_attempts":                 {"fr": "Tentatives", "en": "Attempts"},
+	"outbox_last_error":               {"fr": "Dernière erreur", "en": "Last error"},
+	"outbox_next_attempt":             {"fr": "Prochain essai", "en": "Next attempt"},
+	"outbox_created":                  {"fr": "Créé le", "en": "Created"},
+	"outbox_retry":                    {"fr": "Renvoyer", "en": "Retry"},
+	"outbox_delete_confirm":           {"fr": "Supprimer cet email sans l'envoyer ?", "en": "Discard this email without sending it?"},
+	"outbox_status_pending":           {"fr": "En attente", "en": "Pending"},
+	"outbox_status_failed":            {"fr": "Échec", "en": "Failed"},
+	"outbox_kind_signup_confirmation": {"fr": "Confirmation d'inscription", "en": "Signup confirmation"},
+	"outbox_kind_admin_notification":  {"fr": "Notification admin", "en": "Admin notification"},
+	"outbox_kind_assignment_unplaced": {"fr": "Aucune tâche attribuée", "en": "No task assigned"},
+	"outbox_kind_bulk_message":        {"fr": "Message aux inscrits", "en": "Message to registrants"},
+	"outbox_kind_opening_notice":      {"fr": "Ouverture des inscriptions", "en": "Registration opening"},
+	"outbox_kind_login_link":          {"fr": "Lien de connexion admin", "en": "Admin login link"},
+	"outbox_kind_registrant_link":     {"fr": "Lien vers ses inscriptions", "en": "Link to registrations"},
+
+	// Instance settings
+	"instance_title":                {"fr": "Instance", "en": "Instance"},
+	"instance_hint":                 {"fr": "Réglages généraux du site. Un champ laissé vide reprend la configuration du serveur (variables d'environnement) ou la valeur par défaut.", "en": "General site settings. A field left empty falls back to the server configuration (environment variables) or the default."},
+	"instance_site_title":           {"fr": "Titre du site", "en": "Site title"},
+	"instance_default_lang":         {"fr": "Langue par défaut", "en": "Default language"},
+	"instance_default_lang_builtin": {"fr": "Par défaut (français)", "en": "Default (French)"},
+	"instance_base_url":             {"fr": "Adresse publique", "en": "Public base URL"},
+	"instance_base_url_hint":        {"fr": "Utilisée pour les liens des emails et des flux. Vide : l'adresse par laquelle le site est consulté.", "en": "Used for links in emails and feeds. Empty: the address the site is visited at."},
+	"instance_ai_key":               {"fr": "Clé API Anthropic", "en": "Anthropic API key"},
+	"instance_ai_key_clear":         {"fr": "Supprimer la clé enregistrée", "en": "Remove the saved key"},
+	"instance_ai_key_env":           {"fr": "Aucune clé enregistrée : celle de la configuration du serveur est utilisée.", "en": "No key saved: the one from the server configuration is used."},
+	"instance_theme_color":          {"fr": "Couleur du thème", "en": "Theme color"},
+	"instance_error_lang":           {"fr": "Langue inconnue.", "en": "Unknown language."},
+	"instance_error_base_url":       {"fr": "L'adresse publique doit être de la forme https://exemple.org, sans chemin.", "en": "The public base URL must look like https://example.org, with no path."},
+	"instance_error_theme_color":    {"fr": "Couleur invalide.", "en": "Invalid color."},
+
+	// Database maintenance
+	"maintenance_last":        {"fr": "Dernière maintenance de la base :", "en": "Last database maintenance:"},
+	"maintenance_size":        {"fr": "taille", "en": "size"},
+	"maintenance_size_before": {"fr": "avant", "en": "was"},
+	"maintenance_run":         {"fr": "Lancer la maintenance", "en": "Run maintenance now"},
+	"maintenance_done":        {"fr": "Maintenance terminée : la base est intacte.", "en": "Maintenance done: the database is intact."},
+	"maintenance_failed":      {"fr": "La maintenance de la base a signalé un problème :", "en": "Database maintenance reported a problem:"},
+	"maintenance_problem":     {"fr": "La maintenance de la base a signalé un problème, voir le tableau de bord.", "en": "Database maintenance reported a problem, see the dashboard."},
+
+	// Data retention
+	"retention_title":        {"fr": "Conservation des données", "en": "Data retention"},
+	"retention_hint":         {"fr": "Les noms, emails, téléphones, messages et souhaits des participants sont effacés ce nombre de mois après la fin de chaque événement ; les nombres d'inscrits restent dans les statistiques. Chaque événement peut fixer sa propre durée.", "en": "Participants' names, emails, phone numbers, messages and wishes are erased this many months after each event ends; signup counts stay in the statistics. Each event can set its own period."},
+	"retention_months":       {"fr": "Conserver les données personnelles (mois)", "en": "Keep personal data for (months)"},
+	"retention_kept":         {"fr": "Indéfiniment", "en": "Indefinitely"},
+	"retention_invalid":      {"fr": "La durée doit être un nombre de mois entre 0 et 120.", "en": "The period must be a number of months between 0 and 120."},
+	"retention_anonymized":   {"fr": "Données personnelles des participants effacées (conservation des données) le", "en": "Participants' personal data erased under the retention policy on"},
+	"event_retention_months": {"fr": "Conserver les données personnelles (mois après l'événement)", "en": "Keep personal data for (months after the event)"},
+	"event_retention_hint":   {"fr": "Vide : le réglage général s'applique. 0 : conservées indéfiniment.", "en": "Empty: the instance setting applies. 0: kept indefinitely."},
+
+	// Group registrations
+	"task_guests_label":     {"fr": "Personnes qui m'accompagnent", "en": "People coming with me"},
+	"task_max_guests":       {"fr": "Accompagnants max par inscription", "en": "Max guests per signup"},
+	"registration_guests":   {"fr": "Accompagnants", "en": "Guests"},
+	"error_too_many_guests": {"fr": "Vous ne pouvez pas venir avec autant de personnes pour cette tâche.", "en": "You can't bring that many people for this task."},
+	"error_full_group":      {"fr": "Il ne reste pas assez de places pour tout votre groupe.", "en": "There aren't enough spots left for your whole group."},
+
+	// Signup form fields
+	"form_fields_title":         {"fr": "Questions supplémentaires", "en": "Extra questions"},
+	"form_fields_hint":          {"fr": "Ajoutez au formulaire d'inscription vos propres questions : les réponses apparaissent sur la page des inscriptions et dans l'export CSV. Une question liée à une tâche n'est posée qu'à ceux qui la choisissent.", "en": "Add your own questions to the signup form: the answers show on the registrations page and in the CSV export. A question tied to a task is only asked of those who pick it."},
+	"form_field_new":            {"fr": "Nouvelle question", "en": "New question"},
+	"form_field_add":            {"fr": "Ajouter", "en": "Add"},
+	"form_field_kind":           {"fr": "Type", "en": "Type"},
+	"form_field_kind_text":      {"fr": "Texte", "en": "Text"},
+	"form_field_kind_select":    {"fr": "Liste de choix", "en": "Choice list"},
+	"form_field_kind_checkbox":  {"fr": "Case à cocher", "en": "Checkbox"},
+	"form_field_label_fr":       {"fr": "Question (FR)", "en": "Question (FR)"},
+	"form_field_label_en":       {"fr": "Question (EN)", "en": "Question (EN)"},
+	"form_field_label_single":   {"fr": "Question", "en": "Question"},
+	"form_field_options":        {"fr": "Choix de la liste, un par ligne", "en": "List choices, one per line"},
+	"form_field_required":       {"fr": "Obligatoire", "en": "Required"},
+	"form_field_task":           {"fr": "Posée à", "en": "Asked of"},
+	"form_field_task_all":       {"fr": "Tous les inscrits", "en": "Everyone"},
+	"form_field_saved":          {"fr": "Question enregistrée.", "en": "Question saved."},
+	"form_field_delete_confirm": {"fr": "Supprimer cette question et ses réponses ?", "en": "Delete this question and its answers?"},
+	"form_field_error_label":    {"fr": "La question en français est obligatoire.", "en": "The French question is required."},
+	"form_field_error_options":  {"fr": "Une liste de choix a besoin d'au moins un choix.", "en": "A choice list needs at least one choice."},
+	"form_field_error_answer":   {"fr": "Veuillez répondre à « %s ».", "en": "Please answer “%s”."},
+
+	// Registration window
+	"event_registration_opens":       {"fr": "Ouverture des inscriptions", "en": "Registration opens"},
+	"event_registration_closes":      {"fr": "Clôture des inscriptions", "en": "Registration closes"},
+	"event_registration_window_hint": {"fr": "À l'heure du fuseau de l'événement. Vide : pas de limite.", "en": "In the event's time zone. Empty: no limit."},
+	"registration_opens_on":          {"fr": "Les inscriptions ouvriront le", "en": "Registration opens on"},
+	"registration_countdown":         {"fr": "ouverture dans", "en": "opens in"},
+	"registration_countdown_days":    {"fr": "j", "en": "d"},
+	"registration_closes_on":         {"fr": "Inscriptions jusqu'au", "en": "Registration until"},
+	"registration_not_open":          {"fr": "Les inscriptions ne sont pas encore ouvertes.", "en": "Registration is not open yet."},
+	"registration_closed":            {"fr": "Les inscriptions sont closes.", "en": "Registration is closed."},
+	"opening_notice_label":           {"fr": "Recevoir un email à l'ouverture des inscriptions", "en": "Get an email when registration opens"},
+	"opening_notice_submit":          {"fr": "Me prévenir", "en": "Notify me"},
+	"opening_notice_saved":           {"fr": "C'est noté : vous recevrez un email à l'ouverture des inscriptions.", "en": "Done: you will get an email when registration opens."},
+	"opening_notice_subject":         {"fr": "Les inscriptions sont ouvertes : %s", "en": "Registration is open: %s"},
+	"opening_notice_intro":           {"fr": "Les inscriptions à « %s » viennent d'ouvrir. Vous avez demandé à en être prévenu.", "en": "Registration for “%s” just opened. You asked to be told."},
+	"opening_notice_button":          {"fr": "S'inscrire", "en": "Sign up"},
+	"opening_notices_waiting":        {"fr": "Personnes à prévenir par email à l'ouverture : %d", "en": "People to email when registration opens: %d"},
+
+	// Per-person task limit
+	"event_max_tasks_per_person":      {"fr": "Tâches par bénévole", "en": "Tasks per volunteer"},
+	"event_max_tasks_per_person_hint": {"fr": "Nombre de tâches auxquelles une même adresse email peut s'inscrire.", "en": "How many tasks one email address can sign up for."},
+	"error_task_limit":                {"fr": "Vous êtes déjà inscrit à %d tâches, le maximum pour cet événement.", "en": "You are already signed up for %d tasks, the most this event allows."},
+	"registered_another":              {"fr": "M'inscrire à une autre tâche", "en": "Sign up for another task"},
+
+	// Staffing minimums
+	"task_min_slots":         {"fr": "Personnes nécessaires au minimum", "en": "Minimum people needed"},
+	"task_still_needed":      {"fr": "Encore nécessaires :", "en": "Still needed:"},
+	"understaffed_title":     {"fr": "Tâches en sous-effectif", "en": "Understaffed tasks"},
+	"understaffed_hint":      {"fr": "Tâches des événements à venir qui n'ont pas encore atteint leur nombre minimum de personnes.", "en": "Tasks of upcoming events that haven't reached their minimum number of people yet."},
+	"understaffed_empty":     {"fr": "Toutes les tâches ont atteint leur minimum.", "en": "Every task has reached its minimum."},
+	"understaffed_event":     {"fr": "Événement", "en": "Event"},
+	"understaffed_task":      {"fr": "Tâche", "en": "Task"},
+	"understaffed_signed_up": {"fr": "Inscrits / minimum", "en": "Signed up / minimum"},
+
+	// Registration comments
+	"registration_comment":             {"fr": "Commentaire", "en": "Comment"},
+	"registration_comment_optional":    {"fr": "Commentaire (optionnel)", "en": "Comment (optional)"},
+	"registration_comment_placeholder": {"fr": "Une précision, une question pour les organisateurs…", "en": "Anything the organizers should know, a question…"},
+	"error_comment_too_long":           {"fr": "Le commentaire ne doit pas dépasser %d caractères.", "en": "The comment can't be longer than %d characters."},
+
+	// Dietary needs
+	"event_collect_diet":         {"fr": "Demander le régime alimentaire", "en": "Ask for dietary needs"},
+	"event_collect_diet_hint":    {"fr": "Les inscrits indiquent leur régime et leurs allergies ; l'équipe cuisine en a le récapitulatif.", "en": "Registrants give their diet and allergies; the kitchen team gets the summary."},
+	"diet_label":                 {"fr": "Régime alimentaire", "en": "Diet"},
+	"diet_vegetarian":            {"fr": "Végétarien", "en": "Vegetarian"},
+	"diet_vegan":                 {"fr": "Végétalien", "en": "Vegan"},
+	"diet_gluten_free":           {"fr": "Sans gluten", "en": "Gluten-free"},
+	"diet_allergies":             {"fr": "Allergies", "en": "Allergies"},
+	"diet_allergies_placeholder": {"fr": "Arachides, fruits de mer…", "en": "Peanuts, shellfish…"},
+	"diet_summary":               {"fr": "Récapitulatif cuisine", "en": "Kitchen summary"},
+	"diet_guests_hint":           {"fr": "Plus %d accompagnant(s), dont le régime n'est pas connu.", "en": "Plus %d guest(s), whose diet isn't known."},
+	"diet_no_allergies":          {"fr": "Aucune allergie signalée.", "en": "No allergies reported."},
+	"error_allergies_too_long":   {"fr": "Les allergies ne doivent pas dépasser %d caractères.", "en": "Allergies can't be longer than %d characters."},
+
+	// My registrations
+	"my_title":             {"fr": "Mes inscriptions", "en": "My registrations"},
+	"my_hint":              {"fr": "Recevez par email un lien vers toutes vos inscriptions, pour les modifier ou les annuler depuis n'importe quel appareil.", "en": "Get a link by email to all your registrations, to change or cancel them from any device."},
+	"my_link_btn":          {"fr": "Recevoir le lien", "en": "Send me the link"},
+	"my_link_sent":         {"fr": "Si des inscriptions à venir correspondent à cette adresse, un lien vient d'y être envoyé.", "en": "If this address has upcoming registrations, a link has just been sent to it."},
+	"my_link_invalid":      {"fr": "Ce lien n'est pas valide ou a expiré. Demandez-en un nouveau.", "en": "This link is invalid or has expired. Ask for a new one."},
+	"my_link_subject":      {"fr": "Vos inscriptions", "en": "Your registrations"},
+	"my_link_email_intro":  {"fr": "Voici le lien vers toutes vos inscriptions à venir. Il est valable %d heures.", "en": "Here is the link to all your upcoming registrations. It is valid for %d hours."},
+	"my_link_email_button": {"fr": "Voir mes inscriptions", "en": "See my registrations"},
+	"my_empty":             {"fr": "Aucune inscription à venir.", "en": "No upcoming registrations."},
+	"my_event":             {"fr": "Événement", "en": "Event"},
+	"my_find":              {"fr": "Déjà inscrit depuis un autre appareil ? Retrouvez vos inscriptions", "en": "Signed up from another device? Find your registrations"},
+
+	// Public names
+	"registration_show_name": {"fr": "Afficher mon prénom publiquement sous la tâche choisie", "en": "Show my first name publicly under the task I pick"},
+
+	// Counts-only display
+	"event_counts_only":      {"fr": "Afficher seulement le nombre d'inscrits", "en": "Show only how many signed up"},
+	"event_counts_only_hint": {"fr": "La page publique indique « 3 / 5 prises » pour chaque tâche et n'affiche jamais de noms, même avec l'accord des inscrits.", "en": "The public page shows \"3 / 5 taken\" for each task and never shows names, even with registrants' consent."},
+	"task_taken":             {"fr": "places prises", "en": "taken"},
+
+	// Email checks
+	"email_did_you_mean":     {"fr": "Vouliez-vous dire", "en": "Did you mean"},
+	"email_did_you_mean_end": {"fr": " ?", "en": "?"},
+	"error_email_invalid":    {"fr": "Cette adresse email n'est pas valide.", "en": "This email address is not valid."},
+	"error_email_domain":     {"fr": "Le domaine de cette adresse email ne reçoit pas de courrier. Vérifiez l'adresse.", "en": "The domain of this email address doesn't receive mail. Please check the address."},
+	"signup_check_mx":        {"fr": "Vérifier que le domaine des adresses email reçoit du courrier", "en": "Check that email domains receive mail"},
+	"signup_check_mx_hint":   {"fr": "Interroge le DNS à chaque inscription ; les adresses dont le domaine n'a pas de serveur de courrier sont refusées.", "en": "Queries DNS on each signup; addresses whose domain has no mail server are refused."},
+
+	// Check-in
+	"event_checkin":        {"fr": "Contrôle des arrivées par QR code", "en": "QR-code check-in at the door"},
+	"event_checkin_hint":   {"fr": "Chaque inscrit reçoit un QR code à présenter en arrivant ; l'équipe le scanne depuis la page « Arrivées » des inscriptions.", "en": "Each registrant gets a QR code to show on arrival; the team scans it from the Check-in page of the registrations."},
+	"checkin_title":        {"fr": "Arrivées", "en": "Check-in"},
+	"checkin_scan":         {"fr": "Scanner les QR codes", "en": "Scan QR codes"},
+	"checkin_start":        {"fr": "Ouvrir la caméra", "en": "Open the camera"},
+	"checkin_unsupported":  {"fr": "Ce navigateur ne sait pas lire les QR codes : scannez-les avec l'appareil photo du téléphone, le lien ouvre cette page.", "en": "This browser can't read QR codes: scan them with the phone's camera app, the link opens this page."},
+	"checkin_camera_error": {"fr": "Impossible d'accéder à la caméra.", "en": "Couldn't access the camera."},
+	"checkin_arrivals":     {"fr": "Arrivées par tâche", "en": "Arrivals by task"},
+	"checkin_arrived":      {"fr": "Arrivé·e", "en": "Arrived"},
+	"checkin_total":        {"fr": "Total", "en": "Total"},
+	"checkin_confirm":      {"fr": "Marquer comme arrivé·e", "en": "Check in"},
+	"checkin_done":         {"fr": "%s est arrivé·e (%s).", "en": "%s checked in (%s)."},
+	"checkin_already":      {"fr": "%s est déjà arrivé·e, à %s.", "en": "%s already checked in, at %s."},
+	"checkin_unknown":      {"fr": "Ce code ne correspond à aucune inscription à cet événement.", "en": "This code doesn't match any registration for this event."},
+	"checkin_qr_hint":      {"fr": "Présentez ce QR code à votre arrivée.", "en": "Show this QR code when you arrive."},
+	"checkin_qr_alt":       {"fr": "QR code d'arrivée", "en": "Check-in QR code"},
+
+	// Group capacity
+	"group_max_slots":      {"fr": "Places max pour tout le groupe (vide = illimité)", "en": "Max people across the group (empty = unlimited)"},
+	"error_group_capacity": {"fr": "Cette partie de l'événement est complète : choisissez une autre tâche.", "en": "This part of the event is full: please pick another task."},
+
+	// Group colors and icons
+	"group_icon":       {"fr": "Icône (emoji)", "en": "Icon (emoji)"},
+	"group_color":      {"fr": "Couleur", "en": "Color"},
+	"group_color_none": {"fr": "Sans couleur", "en": "No color"},
+	"color_indigo":     {"fr": "Indigo", "en": "Indigo"},
+	"color_green":      {"fr": "Vert", "en": "Green"},
+	"color_amber":      {"fr": "Ambre", "en": "Amber"},
+	"color_pink":       {"fr": "Rose", "en": "Pink"},
+	"color_blue":       {"fr": "Bleu", "en": "Blue"},
+	"color_violet":     {"fr": "Violet", "en": "Violet"},
+	"color_red":        {"fr": "Rouge", "en": "Red"},
+	"color_grey":       {"fr": "Gris", "en": "Grey"},
+
+	// Event capacity
+	"event_max_participants":      {"fr": "Participants maximum", "en": "Maximum participants"},
+	"event_max_participants_hint": {"fr": "Nombre de personnes pouvant participer, toutes tâches confondues et accompagnants compris (une personne inscrite à plusieurs tâches compte une fois). Vide : pas de limite.", "en": "How many people can take part, all tasks together and guests included (someone signed up for several tasks counts once). Empty: no limit."},
+	"registration_full":           {"fr": "L'événement est complet.", "en": "The event is full."},
+	"error_event_full":            {"fr": "Il ne reste plus assez de places pour cet événement.", "en": "There aren't enough places left at this event."},
+
+	// Task rules
+	"task_rules_title":          {"fr": "Règles entre tâches", "en": "Task rules"},
+	"task_rules_hint":           {"fr": "Une tâche peut en exiger une autre — seules les personnes déjà inscrites à celle-ci peuvent la choisir, ce qui suppose plusieurs tâches par bénévole — ou l'exclure, quand une même personne ne peut pas faire les deux.", "en": "A task can require another — only people already signed up for it can pick the task, which takes several tasks per volunteer — or exclude it, when the same person can't do both."},
+	"task_rule_task":            {"fr": "Tâche", "en": "Task"},
+	"task_rule_kind":            {"fr": "Règle", "en": "Rule"},
+	"task_rule_other_task":      {"fr": "Autre tâche", "en": "Other task"},
+	"task_rule_requires":        {"fr": "exige", "en": "requires"},
+	"task_rule_excludes":        {"fr": "exclut", "en": "excludes"},
+	"task_rule_add":             {"fr": "Ajouter la règle", "en": "Add rule"},
+	"task_rule_saved":           {"fr": "Règle enregistrée.", "en": "Rule saved."},
+	"task_rule_error_same":      {"fr": "Une tâche ne peut pas avoir de règle avec elle-même.", "en": "A task can't have a rule with itself."},
+	"task_rule_requires_public": {"fr": "Réservé aux inscrits à :", "en": "Only for those signed up for:"},
+	"task_rule_excludes_public": {"fr": "Incompatible avec :", "en": "Can't be combined with:"},
+	"error_task_requires":       {"fr": "Cette tâche est réservée aux personnes inscrites à « %s ». Inscrivez-vous d'abord à celle-ci.", "en": "This task is only for people signed up for \"%s\". Sign up for that one first."},
+	"error_task_excludes":       {"fr": "Cette tâche ne peut pas être combinée avec « %s », à laquelle vous êtes déjà inscrit·e.", "en": "This task can't be combined with \"%s\", which you're already signed up for."},
+
+	// Skills
+	"task_skills":             {"fr": "Compétences nécessaires", "en": "Skills needed"},
+	"task_skills_placeholder": {"fr": "Compétences (séparées par des virgules)", "en": "Skills (comma-separated)"},
+	"task_skills_needed":      {"fr": "Compétences :", "en": "Skills:"},
+	"skills_label":            {"fr": "Vos compétences", "en": "Your skills"},
+	"skills_hint":             {"fr": "Facultatif : cela aide les organisateurs à répartir les tâches. Retenu pour vos prochaines inscriptions.", "en": "Optional: it helps organizers share out the tasks. Remembered for your next signups."},
+	"skills_title":            {"fr": "Compétences", "en": "Skills"},
+	"skills_has":              {"fr": "A", "en": "Has"},
+	"skills_missing":          {"fr": "N'a pas", "en": "Lacks"},
+	"skills_gap":              {"fr": "Aucun inscrit n'a cette compétence", "en": "No one signed up has this skill"},
+	"skills_gaps":             {"fr": "Compétences manquantes :", "en": "Missing skills:"},
+	"skills_candidates":       {"fr": "Autres inscrits à l'événement qui les ont :", "en": "Others signed up for the event who have them:"},
+	"skills_no_registrants":   {"fr": "Personne n'est encore inscrit à cette tâche.", "en": "No one has signed up for this task yet."},
+	"skills_none":             {"fr": "Aucune tâche de cet événement ne demande de compétences.", "en": "None of this event's tasks needs skills."},
+
+	// Assignments
+	"event_assign_mode":       {"fr": "Attribution par préférences", "en": "Assign by preferences"},
+	"event_assign_mode_hint":  {"fr": "Les participants classent jusqu'à trois tâches au lieu de s'inscrire directement ; vous lancez ensuite l'attribution depuis la liste des inscriptions.", "en": "People rank up to three tasks instead of signing up directly; you then run the assignment from the registrations list."},
+	"preferences_title":       {"fr": "Vos préférences", "en": "Your preferences"},
+	"preferences_hint":        {"fr": "Classez les tâches qui vous intéressent. Les organisateurs attribueront les places en respectant au mieux les choix de chacun, et vous recevrez un email.", "en": "Rank the tasks you'd like. The organizers will assign places following everyone's choices as best they can, and you'll get an email."},
+	"preferences_choice_1":    {"fr": "1er choix", "en": "1st choice"},
+	"preferences_choice_2":    {"fr": "2e choix", "en": "2nd choice"},
+	"preferences_choice_3":    {"fr": "3e choix", "en": "3rd choice"},
+	"preferences_submit":      {"fr": "Envoyer mes préférences", "en": "Send my preferences"},
+	"preferences_saved":       {"fr": "Préférences enregistrées", "en": "Preferences saved"},
+	"preferences_saved_hint":  {"fr": "Vous recevrez un email quand les tâches auront été attribuées. Vous pouvez renvoyer le formulaire d'ici là pour changer vos choix.", "en": "You'll get an email once tasks are assigned. Until then you can send the form again to change your choices."},
+	"preferences_resolved":    {"fr": "Les tâches ont déjà été attribuées pour cette adresse email.", "en": "Tasks have already been assigned for this email address."},
+	"assign_title":            {"fr": "Attribution", "en": "Assignments"},
+	"assign_hint":             {"fr": "Chacun reçoit si possible son 1er choix, sinon son 2e, puis son 3e, par ordre d'envoi. Les personnes placées reçoivent leur confirmation d'inscription, les autres un email les prévenant.", "en": "Everyone gets their 1st choice if possible, else their 2nd, then their 3rd, in the order they sent them. People placed get their signup confirmation, the others an email letting them know."},
+	"assign_resolve":          {"fr": "Attribuer les tâches", "en": "Assign tasks"},
+	"assign_resolve_confirm":  {"fr": "Attribuer les tâches aux préférences en attente et envoyer les emails ?", "en": "Assign tasks to the pending preferences and send the emails?"},
+	"assign_resolved":         {"fr": "%d personne(s) placée(s), %d sans place.", "en": "%d people placed, %d without a place."},
+	"assign_status":           {"fr": "Statut", "en": "Status"},
+	"assign_pending":          {"fr": "En attente", "en": "Pending"},
+	"assign_unplaced":         {"fr": "Sans place", "en": "No place"},
+	"assign_none":             {"fr": "Aucune préférence pour l'instant.", "en": "No preferences yet."},
+	"assign_unplaced_subject": {"fr": "%s : pas de place sur vos choix", "en": "%s: no place on your choices"},
+	"assign_unplaced_intro":   {"fr": "Merci pour votre intérêt pour « %s ». Malheureusement, aucune des tâches que vous avez choisies n'avait encore de place lors de l'attribution.", "en": "Thank you for your interest in \"%s\". Unfortunately none of the tasks you picked had room left when places were assigned."},
+	"assign_unplaced_button":  {"fr": "Voir l'événement", "en": "See the event"},
+
+	// Access codes
+	"event_access_code":      {"fr": "Code d'accès anticipé", "en": "Early-access code"},
+	"event_access_code_hint": {"fr": "Avec ce code, on peut s'inscrire avant l'ouverture et prendre les places réservées des tâches (🔑).", "en": "With this code, people can sign up before registration opens and take the tasks' reserved slots (🔑)."},
+	"event_access_code_link": {"fr": "Lien à partager avec votre équipe :", "en": "Link to share with your team:"},
+	"task_reserved_slots":    {"fr": "Places réservées aux détenteurs du code d'accès", "en": "Slots reserved for access code holders"},
+	"access_code_active":     {"fr": "Accès anticipé : vous pouvez vous inscrire dès maintenant, places réservées comprises.", "en": "Early access: you can sign up now, reserved slots included."},
+	"access_code_invalid":    {"fr": "Ce code d'accès n'est pas valide pour cet événement.", "en": "This access code isn't valid for this event."},
+
+	// Family signup
+	"event_family_signup":      {"fr": "Inscriptions en famille", "en": "Family signups"},
+	"event_family_signup_hint": {"fr": "Permet d'inscrire plusieurs personnes (une famille) en une fois, chacune sur sa tâche, avec l'email du contact.", "en": "Lets people sign up several others (a family) at once, each on their own task, under the contact's email."},
+	"family_title":             {"fr": "Autres personnes", "en": "Other people"},
+	"family_hint":              {"fr": "Vous venez en famille ? Ajoutez chaque personne avec sa tâche : tout est envoyé à votre adresse email.", "en": "Coming as a family? Add each person with their task: everything is sent to your email address."},
+	"family_add":               {"fr": "Ajouter une personne", "en": "Add a person"},
+	"family_task":              {"fr": "Tâche…", "en": "Task…"},
+	"family_member_error":      {"fr": "%s : %s", "en": "%s: %s"},
+
+	// Registrations search
+	"registration_all_groups":   {"fr": "Tous les groupes", "en": "All groups"},
+	"registration_all_tasks":    {"fr": "Toutes les tâches", "en": "All tasks"},
+	"registration_filter":       {"fr": "Filtrer", "en": "Filter"},
+	"registration_filter_clear": {"fr": "Effacer les filtres", "en": "Clear filters"},
+	"registration_no_match":     {"fr": "Aucune inscription ne correspond à ces filtres.", "en": "No registration matches these filters."},
+	"pagination_prev":           {"fr": "Précédent", "en": "Previous"},
+	"pagination_next":           {"fr": "Suivant", "en": "Next"},
+	"pagination_status":         {"fr": "%d–%d sur %d", "en": "%d–%d of %d"},
+
+	// Bulk actions
+	"bulk_selected":        {"fr": "%d sélectionnée(s)", "en": "%d selected"},
+	"bulk_select_all":      {"fr": "Tout sélectionner", "en": "Select all"},
+	"bulk_action":          {"fr": "Action groupée…", "en": "Bulk action…"},
+	"bulk_apply":           {"fr": "Appliquer", "en": "Apply"},
+	"bulk_move":            {"fr": "Déplacer vers une tâche", "en": "Move to a task"},
+	"bulk_move_to":         {"fr": "Tâche de destination", "en": "Target task"},
+	"bulk_export":          {"fr": "Exporter la sélection (CSV)", "en": "Export selection (CSV)"},
+	"bulk_email":           {"fr": "Envoyer un email", "en": "Send an email"},
+	"bulk_email_subject":   {"fr": "Objet", "en": "Subject"},
+	"bulk_email_message":   {"fr": "Message", "en": "Message"},
+	"bulk_delete_confirm":  {"fr": "Supprimer les %d inscriptions sélectionnées ?", "en": "Delete the %d selected registrations?"},
+	"bulk_move_confirm":    {"fr": "Déplacer les %d inscriptions sélectionnées ? Les places disponibles ne sont pas vérifiées.", "en": "Move the %d selected registrations? Available slots are not checked."},
+	"bulk_email_confirm":   {"fr": "Envoyer ce message aux %d inscriptions sélectionnées ?", "en": "Send this message to the %d selected registrations?"},
+	"bulk_none_selected":   {"fr": "Aucune inscription sélectionnée.", "en": "No registration selected."},
+	"bulk_no_action":       {"fr": "Choisissez une action.", "en": "Choose an action."},
+	"bulk_deleted":         {"fr": "%d inscription(s) supprimée(s).", "en": "%d registration(s) deleted."},
+	"bulk_moved":           {"fr": "%d inscription(s) déplacée(s), %d déjà sur cette tâche.", "en": "%d registration(s) moved, %d already on that task."},
+	"bulk_move_no_task":    {"fr": "Choisissez la tâche de destination.", "en": "Choose the target task."},
+	"bulk_email_invalid":   {"fr": "Indiquez un objet et un message.", "en": "Enter a subject and a message."},
+	"bulk_emailed":         {"fr": "%d email(s) en cours d'envoi.", "en": "%d email(s) queued."},
+	"bulk_emailed_skipped": {"fr": "%d email(s) en cours d'envoi, %d adresse(s) désabonnée(s) ignorée(s).", "en": "%d email(s) queued, %d unsubscribed address(es) skipped."},
+
+	// Moving registrations
+	"move_to":              {"fr": "Déplacer vers…", "en": "Move to…"},
+	"move_submit":          {"fr": "Déplacer", "en": "Move"},
+	"move_notify":          {"fr": "Prévenir la personne", "en": "Tell the person"},
+	"move_done":            {"fr": "%s a été déplacé(e).", "en": "%s has been moved."},
+	"move_already_on_task": {"fr": "Cette personne est déjà inscrite à cette tâche.", "en": "This person is already signed up for that task."},
+	"move_task_full":       {"fr": "Cette tâche n'a plus assez de places.", "en": "That task doesn't have enough spots left."},
+	"move_group_full":      {"fr": "Cette partie de l'événement n'a plus assez de places.", "en": "That part of the event doesn't have enough spots left."},
+	"move_not_found":       {"fr": "Inscription ou tâche introuvable.", "en": "Registration or task not found."},
+
+	// Manual registrations
+	"manual_title":              {"fr": "Ajouter une inscription manuellement", "en": "Add registration manually"},
+	"manual_intro":              {"fr": "Pour les personnes inscrites sur papier ou par téléphone. L'email est facultatif ; les places de la tâche, réservées comprises, restent limitées.", "en": "For people who signed up on paper or by phone. The email address is optional; the task's spots, reserved ones included, still apply."},
+	"manual_lang":               {"fr": "Langue des emails", "en": "Email language"},
+	"manual_notify":             {"fr": "Envoyer la confirmation d'inscription (si un email est indiqué)", "en": "Send the signup confirmation (when an email address is given)"},
+	"manual_override":           {"fr": "Ignorer les limites (places, tâches par personne, règles)", "en": "Ignore limits (spots, tasks per person, rules)"},
+	"manual_submit":             {"fr": "Ajouter", "en": "Add"},
+	"manual_added":              {"fr": "%s a été inscrit(e).", "en": "%s has been signed up."},
+	"manual_already_registered": {"fr": "Cette personne est déjà inscrite à cette tâche, ou à autant de tâches que l'événement le permet.", "en": "This person is already signed up for that task, or for as many tasks as the event allows."},
+	"manual_too_many_guests":    {"fr": "Cette tâche n'accepte pas autant d'accompagnants.", "en": "That task doesn't allow that many guests."},
+	"manual_event_full":         {"fr": "L'événement a atteint son nombre maximum de participants.", "en": "The event has reached its participant limit."},
+	"manual_task_rule":          {"fr": "Une règle de tâche empêche cette inscription.", "en": "A task rule prevents this registration."},
+	"manual_override_hint":      {"fr": "Cochez « Ignorer les limites » pour l'inscrire quand même.", "en": "Tick “Ignore limits” to sign them up anyway."},
+
+	// Task sheets
+	"sheets_pdf":    {"fr": "Feuilles de tâches (PDF)", "en": "Task sheets (PDF)"},
+	"sheets_nobody": {"fr": "Personne pour l'instant", "en": "Nobody yet"},
+	"sheets_page":   {"fr": "Page %d/%d", "en": "Page %d/%d"},
+
+	// Check-in list
+	"checkin_list_title": {"fr": "Liste d'accueil", "en": "Check-in list"},
+	"checkin_list_pdf":   {"fr": "Liste d'accueil (PDF)", "en": "Check-in list (PDF)"},
+	"checkin_list_count": {"fr": "%d inscription(s)", "en": "%d registration(s)"},
+
+	// Day-of console
+	"dayof_title":    {"fr": "Jour J", "en": "Event day"},
+	"dayof_arrived":  {"fr": "arrivé·e·s", "en": "arrived"},
+	"dayof_covered":  {"fr": "Tâche assurée", "en": "Task covered"},
+	"dayof_call":     {"fr": "Appeler", "en": "Call"},
+	"dayof_nobody":   {"fr": "Personne d'inscrit.", "en": "Nobody signed up."},
+	"dayof_no_tasks": {"fr": "Cet événement n'a pas encore de tâches.", "en": "This event has no tasks yet."},
+
+	// Structure history
+	"history_title":                  {"fr": "Historique", "en": "History"},
+	"history_hint":                   {"fr": "Chaque modification des groupes et des tâches enregistre une version. Pour chacune, la liste montre ce qui a changé depuis ; la restaurer annule ces changements : les éléments supprimés reviennent avec leurs inscriptions, les éléments ajoutés partent à la corbeille.", "en": "Each change to the groups and tasks saves a version. For each one, the list shows what changed since; restoring it undoes those changes: deleted items come back with their registrations, added items go to the trash."},
+	"history_empty":                  {"fr": "Aucune version enregistrée pour l'instant.", "en": "No version saved yet."},
+	"history_saved_at":               {"fr": "Enregistrée le", "en": "Saved"},
+	"history_reason":                 {"fr": "Origine", "en": "Source"},
+	"history_changes":                {"fr": "Changements depuis", "en": "Changes since"},
+	"history_counts":                 {"fr": "%d groupes, %d tâches", "en": "%d groups, %d tasks"},
+	"history_current":                {"fr": "Version actuelle", "en": "Current version"},
+	"history_reordered":              {"fr": "Ordre des éléments", "en": "Order of items"},
+	"history_change_added":           {"fr": "Ajouté", "en": "Added"},
+	"history_change_deleted":         {"fr": "Supprimé", "en": "Deleted"},
+	"history_change_changed":         {"fr": "Modifié", "en": "Changed"},
+	"history_change_moved":           {"fr": "Déplacé", "en": "Moved"},
+	"history_reason_edit":            {"fr": "Modification", "en": "Edit"},
+	"history_reason_delete":          {"fr": "Suppression", "en": "Deletion"},
+	"history_reason_reorder":         {"fr": "Réorganisation", "en": "Reorder"},
+	"history_reason_ai":              {"fr": "Import IA", "en": "AI import"},
+	"history_reason_import":          {"fr": "Import de tableur", "en": "Spreadsheet import"},
+	"history_reason_clear":           {"fr": "Tout supprimer", "en": "Clear all"},
+	"history_reason_rollback":        {"fr": "Restauration", "en": "Rollback"},
+	"history_reason_before_ai":       {"fr": "Avant un import IA", "en": "Before an AI import"},
+	"history_reason_before_import":   {"fr": "Avant un import de tableur", "en": "Before a spreadsheet import"},
+	"history_reason_before_clear":    {"fr": "Avant « Tout supprimer »", "en": "Before “Clear all”"},
+	"history_reason_before_rollback": {"fr": "Avant une restauration", "en": "Before a rollback"},
+	"history_rollback":               {"fr": "Restaurer", "en": "Restore"},
+	"history_rollback_confirm":       {"fr": "Remettre les groupes et les tâches dans cet état ?", "en": "Put the groups and tasks back as they were?"},
+	"history_rolled_back":            {"fr": "Groupes et tâches restaurés.", "en": "Groups and tasks restored."},
+
+	// Duplicating groups and tasks
+	"tree_duplicate": {"fr": "Dupliquer (sans les inscrits)", "en": "Duplicate (without the registrations)"},
+
+	// Bulk max slots
+	"tree_select":       {"fr": "Sélectionner", "en": "Select"},
+	"tree_select_group": {"fr": "Sélectionner toutes les tâches du groupe", "en": "Select all the group's tasks"},
+	"tree_select_task":  {"fr": "Sélectionner la tâche", "en": "Select the task"},
+	"tree_select_count": {"fr": "%d tâche(s) sélectionnée(s)", "en": "%d task(s) selected"},
+	"tree_select_max":   {"fr": "Places max :", "en": "Max slots:"},
+	"tree_select_apply": {"fr": "Appliquer", "en": "Apply"},
+
+	// Draft events
+	"event_draft":             {"fr": "Brouillon", "en": "Draft"},
+	"event_draft_hint":        {"fr": "Cet événement est un brouillon : sa page publique affiche « Bientôt disponible » et n'accepte aucune inscription. Publiez-le quand il est prêt.", "en": "This event is a draft: its public page shows \"Coming soon\" and takes no signups. Publish it when it is ready."},
+	"event_draft_preview":     {"fr": "Aperçu : cet événement est un brouillon, visible des seuls administrateurs.", "en": "Preview: this event is a draft, only admins can see it."},
+	"event_publish":           {"fr": "Publier", "en": "Publish"},
+	"event_unpublish":         {"fr": "Repasser en brouillon", "en": "Back to draft"},
+	"event_unpublish_confirm": {"fr": "Retirer cet événement du site public ? Sa page affichera « Bientôt disponible ».", "en": "Take this event off the public site? Its page will show \"Coming soon\"."},
+	"event_published":         {"fr": "Événement publié.", "en": "Event published."},
+	"event_unpublished":       {"fr": "L'événement est repassé en brouillon.", "en": "The event is a draft again."},
+	"coming_soon":             {"fr": "Bientôt disponible.", "en": "Coming soon."},
+	"coming_soon_hint":        {"fr": "Les inscriptions à cet événement ne sont pas encore ouvertes. Revenez un peu plus tard !", "en": "Signups for this event are not open yet. Check back a little later!"},
+
+	// Event slugs
+	"event_slug_edit":    {"fr": "Changer l'adresse", "en": "Change address"},
+	"event_slug_hint":    {"fr": "Lettres minuscules, chiffres et tirets. L'ancienne adresse continuera de rediriger ici.", "en": "Lowercase letters, digits and dashes. The old address will keep redirecting here."},
+	"event_slug_old":     {"fr": "Anciennes adresses, toujours redirigées :", "en": "Former addresses, still redirected:"},
+	"event_slug_invalid": {"fr": "Indiquez une adresse.", "en": "Enter an address."},
+	"event_slug_taken":   {"fr": "L'adresse /e/%s est déjà utilisée par un autre événement.", "en": "The address /e/%s is already used by another event."},
+	"event_slug_changed": {"fr": "Adresse publique changée : /e/%s", "en": "Public address changed: /e/%s"},
+
+	// Event themes
+	"event_theme_title":         {"fr": "Apparence des pages publiques", "en": "Public pages look"},
+	"event_theme_intro":         {"fr": "Couleur, logo et CSS propres à cet événement, à la place de ceux du site.", "en": "Color, logo and CSS of this event's own, in place of the site's."},
+	"event_theme_logo":          {"fr": "Logo", "en": "Logo"},
+	"event_theme_logo_hint":     {"fr": "PNG, JPEG, GIF ou WebP, 1 Mo au plus. Affiché dans l'en-tête et l'aperçu des liens partagés.", "en": "PNG, JPEG, GIF or WebP, 1 MB at most. Shown in the header and shared link previews."},
+	"event_theme_logo_remove":   {"fr": "Retirer le logo", "en": "Remove the logo"},
+	"event_theme_logo_too_big":  {"fr": "Le logo dépasse 1 Mo.", "en": "The logo is over 1 MB."},
+	"event_theme_logo_bad_type": {"fr": "Le logo doit être une image PNG, JPEG, GIF ou WebP.", "en": "The logo must be a PNG, JPEG, GIF or WebP image."},
+	"event_theme_css":           {"fr": "CSS personnalisé", "en": "Custom CSS"},
+	"event_theme_css_hint":      {"fr": "Optionnel. Ajouté aux pages publiques de l'événement, après la feuille de style du site.", "en": "Optional. Added to the event's public pages, after the site's stylesheet."},
+	"event_theme_saved":         {"fr": "Apparence enregistrée.", "en": "Look saved."},
+
+	// Short links
+	"short_link":         {"fr": "Lien court", "en": "Short link"},
+	"short_link_stats":   {"fr": "%d clics · %d scans du QR code", "en": "%d clicks · %d QR code scans"},
+	"short_link_edit":    {"fr": "Changer le code", "en": "Change code"},
+	"short_link_hint":    {"fr": "Lettres minuscules, chiffres et tirets. L'ancien lien court, et les QR codes déjà imprimés, mèneront toujours à l'événement ; les compteurs repartent de zéro.", "en": "Lowercase letters, digits and dashes. The old short link, and QR codes already printed, will still lead to the event; the counters start over."},
+	"short_link_invalid": {"fr": "Le code doit faire de 2 à 32 lettres, chiffres ou tirets.", "en": "The code must be 2 to 32 letters, digits or dashes."},
+	"short_link_taken":   {"fr": "Le lien court /s/%s est déjà utilisé par un autre événement.", "en": "The short link /s/%s is already used by another event."},
+	"short_link_changed": {"fr": "Lien court changé : /s/%s", "en": "Short link changed: /s/%s"},
+
+	// Invite-only events
+	"invites_title":             {"fr": "Sur invitation", "en": "Invite only"},
+	"invites_intro":             {"fr": "Réservez l'événement à une liste connue : ses pages publiques ne s'ouvrent alors qu'avec un lien d'invitation. Les administrateurs connectés les voient toujours.", "en": "Keep the event to a known list: its public pages then only open with an invite link. Logged-in admins always see them."},
+	"invites_on":                {"fr": "Sur invitation seulement", "en": "Invite only"},
+	"invites_restrict":          {"fr": "Réserver aux invités", "en": "Restrict to invitees"},
+	"invites_open":              {"fr": "Ouvrir à tous", "en": "Open to all"},
+	"invites_restricted":        {"fr": "L'événement est maintenant sur invitation.", "en": "The event is now invite only."},
+	"invites_opened":            {"fr": "L'événement est maintenant ouvert à tous.", "en": "The event is now open to all."},
+	"invites_label":             {"fr": "Pour", "en": "For"},
+	"invites_label_placeholder": {"fr": "Chorale, famille Martin…", "en": "Choir, the Martins…"},
+	"invites_link":              {"fr": "Lien", "en": "Link"},
+	"invites_uses":              {"fr": "Utilisations", "en": "Uses"},
+	"invites_last_used":         {"fr": "Dernière utilisation", "en": "Last used"},
+	"invites_single":            {"fr": "usage unique", "en": "single use"},
+	"invites_new":               {"fr": "Nouvelles invitations", "en": "New invites"},
+	"invites_kind":              {"fr": "Type", "en": "Kind"},
+	"invites_shared":            {"fr": "Un lien partagé", "en": "One shared link"},
+	"invites_single_batch":      {"fr": "Des liens à usage unique", "en": "Single-use links"},
+	"invites_count":             {"fr": "Nombre de liens à usage unique", "en": "Number of single-use links"},
+	"invites_create":            {"fr": "Créer", "en": "Create"},
+	"invites_created":           {"fr": "%d invitation(s) créée(s).", "en": "%d invite(s) created."},
+	"invites_delete":            {"fr": "Révoquer", "en": "Revoke"},
+	"invites_delete_confirm":    {"fr": "Révoquer cette invitation ? Son lien ne marchera plus.", "en": "Revoke this invite? Its link will stop working."},
+	"invites_deleted":           {"fr": "Invitation révoquée.", "en": "Invite revoked."},
+	"invites_hint":              {"fr": "Un lien partagé sert à tout un groupe et compte ses utilisations. Un lien à usage unique appartient à la première personne qui s'inscrit avec, qui peut encore modifier son inscription ; il ne marche plus pour personne d'autre.", "en": "A shared link serves a whole group and counts its uses. A single-use link belongs to the first person who signs up with it, who can still change their signup; it stops working for anyone else."},
+	"invite_required":           {"fr": "Cet événement est sur invitation.", "en": "This event is invite only."},
+	"invite_required_hint":      {"fr": "Ouvrez le lien d'invitation que les organisateurs vous ont envoyé.", "en": "Open the invite link the organizers sent you."},
+	"invite_used":               {"fr": "Cette invitation a déjà servi à quelqu'un d'autre. Demandez la vôtre aux organisateurs.", "en": "This invite was already used by someone else. Ask the organizers for your own."},
+
+	// Share buttons
+	"share":        {"fr": "Partager", "en": "Share"},
+	"share_email":  {"fr": "Email", "en": "Email"},
+	"share_copy":   {"fr": "Copier le message", "en": "Copy the message"},
+	"share_copied": {"fr": "Message copié !", "en": "Message copied!"},
+	"share_text":   {"fr": "%s, le %s. Infos et inscription : %s", "en": "%s on %s. Details and sign-up: %s"},
+	"share_in_fr":  {"fr": "En français", "en": "In French"},
+	"share_in_en":  {"fr": "En anglais", "en": "In English"},
+	"share_hint":   {"fr": "Les mêmes boutons sont sur la page publique, dans la langue du visiteur.", "en": "The same buttons are on the public page, in the visitor's language."},
+
+	// Organizer notes
+	"event_notes":       {"fr": "Notes des organisateurs", "en": "Organizer notes"},
+	"notes_hint":        {"fr": "Visibles seulement ici, jamais sur le site public : contacts des fournisseurs, codes d'accès, rappels…", "en": "Only shown here, never on the public site: supplier contacts, key codes, reminders…"},
+	"notes_placeholder": {"fr": "Notes privées", "en": "Private notes"},
+	"task_notes":        {"fr": "Notes privées de la tâche", "en": "Private task notes"},
+	"task_add_notes":    {"fr": "ajouter une note privée", "en": "add private note"},
+	"task_hide_notes":   {"fr": "masquer la note privée", "en": "hide private note"},
+
+	// Dashboard event list
+	"event_tab_upcoming":       {"fr": "À venir", "en": "Upcoming"},
+	"event_tab_past":           {"fr": "Passés", "en": "Past"},
+	"event_tab_archived":       {"fr": "Archivés", "en": "Archived"},
+	"event_tab_upcoming_empty": {"fr": "Aucun événement à venir.", "en": "No upcoming events."},
+	"event_tab_past_empty":     {"fr": "Aucun événement passé.", "en": "No past events."},
+	"event_tab_archived_empty": {"fr": "Aucun événement archivé.", "en": "No archived events."},
+	"event_tab_archived_hint":  {"fr": "Les événements passés dont les inscriptions ont été anonymisées par la durée de conservation.", "en": "Past events whose registrations the retention period has anonymized."},
+	"event_search":             {"fr": "Rechercher un titre", "en": "Search titles"},
+	"event_no_match":           {"fr": "Aucun événement ne correspond à cette recherche.", "en": "No event matches this search."},
+	"event_sort":               {"fr": "Trier par", "en": "Sort by"},
+	"event_sort_date":          {"fr": "Date de l'événement", "en": "Event date"},
+	"event_sort_title":         {"fr": "Titre", "en": "Title"},
+	"event_sort_created":       {"fr": "Derniers créés", "en": "Recently created"},
+
+	// Dashboard event views
+	"event_title":      {"fr": "Événement", "en": "Event"},
+	"event_view_cards": {"fr": "Afficher en cartes", "en": "Show as cards"},
+	"event_view_table": {"fr": "Afficher en tableau", "en": "Show as a table"},
+
+	// Volunteer report
+	"volunteer_report_title":      {"fr": "Bénévoles", "en": "Volunteers"},
+	"volunteer_report_hint":       {"fr": "Toutes les personnes inscrites à des tâches d'événements de la période, avec le nombre d'événements et de tâches auxquels elles ont participé. Pratique pour les remerciements de fin d'année.", "en": "Everyone who signed up for tasks of events in the period, with how many events and tasks they helped with. Handy for end-of-year thank-yous."},
+	"volunteer_report_from":       {"fr": "Du", "en": "From"},
+	"volunteer_report_to":         {"fr": "au", "en": "to"},
+	"volunteer_report_name":       {"fr": "Nom", "en": "Name"},
+	"volunteer_report_events":     {"fr": "Événements", "en": "Events"},
+	"volunteer_report_tasks":      {"fr": "Tâches", "en": "Tasks"},
+	"volunteer_report_last_event": {"fr": "Dernier événement", "en": "Latest event"},
+	"volunteer_report_empty":      {"fr": "Personne ne s'est inscrit sur cette période.", "en": "Nobody signed up in this period."},
+	"volunteer_report_total":      {"fr": "%d bénévoles.", "en": "%d volunteers."},
+
+	// Settings
+	"settings_title":              {"fr": "Réglages", "en": "Settings"},
+	"settings_smtp":               {"fr": "Serveur d'envoi (SMTP)", "en": "Outgoing mail server (SMTP)"},
+	"settings_smtp_hint":          {"fr": "Serveur utilisé pour tous les emails envoyés par l'application. Laisser tous les champs vides pour revenir à la configuration du serveur (variables d'environnement).", "en": "Server used for every email the app sends. Leave all fields empty to fall back to the server configuration (environment variables)."},
+	"settings_smtp_host":          {"fr": "Serveur", "en": "Host"},
+	"settings_smtp_port":          {"fr": "Port", "en": "Port"},
+	"settings_smtp_username":      {"fr": "Identifiant", "en": "Username"},
+	"settings_smtp_password":      {"fr": "Mot de passe", "en": "Password"},
+	"settings_smtp_password_kept": {"fr": "Inchangé", "en": "Unchanged"},
+	"settings_smtp_from":          {"fr": "Adresse d'expédition", "en": "From address"},
+	"settings_smtp_from_name":     {"fr": "Nom d'expéditeur", "en": "From name"},
+	"settings_smtp_env":           {"fr": "Aucun serveur enregistré : les emails partent selon la configuration du serveur.", "en": "No server saved: emails go out through the server configuration."},
+	"settings_smtp_env_short":     {"fr": "configuration du serveur", "en": "server configuration"},
+	"settings_saved":              {"fr": "Réglages enregistrés.", "en": "Settings saved."},
+	"settings_error_host":         {"fr": "Indiquez un nom de serveur valide (sans « smtp:// » ni port).", "en": "Enter a valid host name (no \"smtp://\" or port)."},
+	"settings_error_port":         {"fr": "Le port doit être un nombre entre 1 et 65535.", "en": "The port must be a number between 1 and 65535."},
+	"settings_error_from":         {"fr": "L'adresse d'expédition n'est pas une adresse email valide.", "en": "The from address is not a valid email address."},
+	"settings_error_auth":         {"fr": "Un mot de passe nécessite un identifiant.", "en": "A password needs a username."},
+	"settings_error_test_to":      {"fr": "Adresse de test invalide.", "en": "Invalid test address."},
+	"settings_test":               {"fr": "Email de test", "en": "Test email"},
+	"settings_test_hint":          {"fr": "Envoie immédiatement un email avec les réglages enregistrés.", "en": "Sends an email right away using the saved settings."},
+	"settings_test_to":            {"fr": "Destinataire", "en": "Recipient"},
+	"settings_test_send":          {"fr": "Envoyer un email de test", "en": "Send test email"},
+	"settings_test_subject":       {"fr": "Email de test", "en": "Test email"},
+	"settings_test_intro":         {"fr": "Si vous lisez ceci, l'envoi d'emails fonctionne.", "en": "If you are reading this, sending email works."},
+	"settings_test_sent":          {"fr": "Email de test envoyé à %s.", "en": "Test email sent to %s."},
+	"settings_test_failed":        {"fr": "Échec de l'envoi : %v", "en": "Sending failed: %v"},
+
+	// Organizers' calendar feed
+	"feed_title":              {"fr": "Agenda des événements", "en": "Events calendar"},
+	"feed_intro":              {"fr": "Un lien d'abonnement (Google Agenda, Apple Calendrier…) listant tous les événements à venir avec leur nombre d'inscrits. Quiconque a le lien peut voir ces informations.", "en": "A subscription link (Google Calendar, Apple Calendar…) listing every upcoming event with its signup count. Anyone with the link can see this."},
+	"feed_enable":             {"fr": "Créer le lien d'abonnement", "en": "Create subscription link"},
+	"feed_enabled":            {"fr": "Lien d'abonnement créé.", "en": "Subscription link created."},
+	"feed_disabled":           {"fr": "Lien d'abonnement désactivé.", "en": "Subscription link disabled."},
+	"feed_registration_count": {"fr": "%d inscrits", "en": "%d signed up"},
+	"feed_attendance_count":   {"fr": "%d présents sur %d réponses", "en": "%d attending out of %d responses"},
+	"feed_santa_count":        {"fr": "%d participants", "en": "%d participants"},
+
+	// Admin password
+	"setup_title":             {"fr": "Bienvenue", "en": "Welcome"},
+	"setup_intro":             {"fr": "Choisissez le mot de passe de l'administration (8 caractères minimum).", "en": "Choose the admin password (at least 8 characters)."},
+	"setup_btn":               {"fr": "Enregistrer et se connecter", "en": "Save and log in"},
+	"password_title":          {"fr": "Mot de passe administrateur", "en": "Admin password"},
+	"password_hint":           {"fr": "Changer le mot de passe déconnecte toutes les autres sessions.", "en": "Changing the password logs out every other session."},
+	"password_current":        {"fr": "Mot de passe actuel", "en": "Current password"},
+	"password_new":            {"fr": "Nouveau mot de passe", "en": "New password"},
+	"password_confirm":        {"fr": "Confirmer le mot de passe", "en": "Confirm password"},
+	"password_change":         {"fr": "Changer le mot de passe", "en": "Change password"},
+	"password_changed":        {"fr": "Mot de passe changé. Les autres sessions ont été déconnectées.", "en": "Password changed. Other sessions were logged out."},
+	"password_error_current":  {"fr": "Mot de passe actuel incorrect.", "en": "Current password is incorrect."},
+	"password_error_short":    {"fr": "Le mot de passe doit faire au moins 8 caractères.", "en": "The password must be at least 8 characters."},
+	"password_error_mismatch": {"fr": "Les deux mots de passe ne correspondent pas.", "en": "The two passwords don't match."},
+
+	// Admin sessions
+	"sessions_title":              {"fr": "Sessions ouvertes", "en": "Logged-in browsers"},
+	"sessions_intro":              {"fr": "Navigateurs connectés à l'administration. Une session expire après 30 minutes d'inactivité, ou 30 jours sur un appareil mémorisé ; changer le mot de passe les ferme toutes.", "en": "Browsers logged in to the admin. A session expires after 30 minutes of inactivity, or 30 days on a remembered device; changing the password ends them all."},
+	"sessions_browser":            {"fr": "Navigateur", "en": "Browser"},
+	"sessions_created":            {"fr": "Connexion", "en": "Logged in"},
+	"sessions_last_seen":          {"fr": "Dernière activité", "en": "Last active"},
+	"sessions_current":            {"fr": "Cette session", "en": "This session"},
+	"sessions_revoke":             {"fr": "Déconnecter", "en": "Log out"},
+	"sessions_revoked":            {"fr": "Session fermée.", "en": "Session ended."},
+	"sessions_logout_all":         {"fr": "Se déconnecter partout", "en": "Log out everywhere"},
+	"sessions_logout_all_confirm": {"fr": "Fermer toutes les sessions, y compris celle-ci ?", "en": "End every session, including this one?"},
+	"session_remember":            {"fr": "Se souvenir de cet appareil", "en": "Remember this device"},
+	"session_remembered":          {"fr": "Mémorisé", "en": "Remembered"},
+
+	// Admin email login
+	"login_link_hint":         {"fr": "Ou recevez un lien de connexion par e-mail.", "en": "Or get a login link by email."},
+	"login_link_email":        {"fr": "Adresse e-mail", "en": "Email address"},
+	"login_link_btn":          {"fr": "Recevoir un lien", "en": "Email me a link"},
+	"login_link_sent":         {"fr": "Si cette adresse est autorisée, un lien de connexion vient de lui être envoyé.", "en": "If this address is allowed, a login link has just been sent to it."},
+	"login_link_subject":      {"fr": "Votre lien de connexion", "en": "Your login link"},
+	"login_link_email_intro":  {"fr": "Cliquez sur le bouton ci-dessous pour vous connecter à l'administration. Le lien n'est valable qu'une fois, pendant %d minutes.", "en": "Click the button below to log in to the admin. The link works once, for %d minutes."},
+	"login_link_email_button": {"fr": "Se connecter", "en": "Log in"},
+	"login_link_confirm":      {"fr": "Se connecter en tant que", "en": "Log in as"},
+	"login_link_invalid":      {"fr": "Ce lien de connexion a expiré ou a déjà été utilisé.", "en": "This login link has expired or was already used."},
+	"login_link_back":         {"fr": "Retour à la connexion", "en": "Back to login"},
+	"login_emails_title":      {"fr": "Connexion par e-mail", "en": "Email login"},
+	"login_emails_hint":       {"fr": "Ces adresses peuvent se connecter sans mot de passe, avec un lien à usage unique reçu par e-mail ou via l'authentification unique si elle est configurée. Les liens pointent vers l'adresse de ce site telle que vue à l'enregistrement.", "en": "These addresses can log in without the password, with a one-time link sent by email or through single sign-on when it is configured. Links point to this site's address as seen when saving."},
+	"login_emails_label":      {"fr": "Adresses autorisées", "en": "Allowed addresses"},
+	"login_emails_invalid":    {"fr": "Adresse e-mail invalide.", "en": "Invalid email address."},
+
+	// Admin single sign-on
+	"oidc_login_btn":   {"fr": "Se connecter avec", "en": "Log in with"},
+	"oidc_error":       {"fr": "La connexion via le fournisseur d'identité a échoué. Réessayez.", "en": "Logging in through the identity provider failed. Please try again."},
+	"oidc_not_allowed": {"fr": "Ce compte n'est pas autorisé à accéder à l'administration.", "en": "This account is not allowed to access the admin."},
+
+	// Signup limits
+	"signup_limits_title":    {"fr": "Limites d'inscription", "en": "Signup limits"},
+	"signup_limits_hint":     {"fr": "Au-delà de ces limites, les inscriptions venant d'une même adresse IP sont mises en attente de vérification au lieu d'être confirmées. Laissez vide pour ne pas limiter. Pensez aux familles et aux réseaux partagés.", "en": "Beyond these limits, signups from the same IP address are held for review instead of being confirmed. Leave empty for no limit. Keep families and shared networks in mind."},
+	"signup_limits_per_hour": {"fr": "Inscriptions par IP et par heure", "en": "Signups per IP per hour"},
+	"signup_limits_emails":   {"fr": "Emails différents par IP sur 24 h", "en": "Different emails per IP per 24 hours"},
+	"signup_limits_none":     {"fr": "Aucune limite", "en": "No limit"},
+	"signup_limits_invalid":  {"fr": "Les limites doivent être des nombres entre 0 et 1000.", "en": "Limits must be numbers between 0 and 1000."},
+
+	// Backup and restore
+	"backup_title":               {"fr": "Sauvegarde", "en": "Backup"},
+	"backup_settings_hint":       {"fr": "Téléchargez une copie complète des données avant un gros changement, ou restaurez une copie précédente.", "en": "Download a full copy of the data before a big change, or restore an earlier copy."},
+	"backup_open":                {"fr": "Sauvegarder ou restaurer", "en": "Back up or restore"},
+	"backup_download_title":      {"fr": "Télécharger une sauvegarde", "en": "Download a backup"},
+	"backup_download_hint":       {"fr": "Le fichier contient tous les événements, inscriptions et paramètres. Gardez-le en lieu sûr : il contient des données personnelles.", "en": "The file holds every event, registration and setting. Keep it somewhere safe: it contains personal data."},
+	"backup_contents":            {"fr": "Actuellement :", "en": "Currently:"},
+	"backup_events":              {"fr": "événements", "en": "events"},
+	"backup_registrations":       {"fr": "inscriptions", "en": "registrations"},
+	"backup_attendances":         {"fr": "réponses de présence", "en": "RSVPs"},
+	"backup_download":            {"fr": "Télécharger", "en": "Download"},
+	"backup_restore_title":       {"fr": "Restaurer une sauvegarde", "en": "Restore a backup"},
+	"backup_restore_hint":        {"fr": "Choisissez un fichier téléchargé depuis cette page. Son contenu vous sera présenté avant toute modification.", "en": "Choose a file downloaded from this page. You will see what it holds before anything changes."},
+	"backup_upload":              {"fr": "Envoyer", "en": "Upload"},
+	"backup_restore_contents":    {"fr": "Cette sauvegarde contient :", "en": "This backup holds:"},
+	"backup_restore_warning":     {"fr": "La restauration remplace toutes les données actuelles par celles de la sauvegarde. Les inscriptions reçues depuis seront perdues.", "en": "Restoring replaces all current data with the backup's. Signups received since it was taken will be lost."},
+	"backup_restore_confirm":     {"fr": "Je comprends que les données actuelles seront remplacées", "en": "I understand the current data will be replaced"},
+	"backup_restore":             {"fr": "Restaurer", "en": "Restore"},
+	"backup_restored":            {"fr": "Sauvegarde restaurée.", "en": "Backup restored."},
+	"backup_no_file":             {"fr": "Choisissez un fichier de sauvegarde.", "en": "Choose a backup file."},
+	"backup_bad_file":            {"fr": "Ce fichier n'est pas une sauvegarde valide.", "en": "This file is not a valid backup."},
+	"backup_restore_expired":     {"fr": "Cet envoi a expiré. Envoyez à nouveau le fichier.", "en": "This upload has expired. Upload the file again."},
+	"backup_restore_unconfirmed": {"fr": "Cochez la case de confirmation pour restaurer.", "en": "Tick the confirmation box to restore."},
+	"backup_restore_failed":      {"fr": "La restauration a échoué. Le détail est dans le journal du serveur.", "en": "The restore failed. The server log has the details."},
+
+	// Scheduled backups
+	"autobackup_title":         {"fr": "Sauvegardes automatiques", "en": "Scheduled backups"},
+	"autobackup_off":           {"fr": "Désactivées. Définissez EVENT_SIGNUP_BACKUP_DIR ou EVENT_SIGNUP_BACKUP_S3_BUCKET sur le serveur pour les activer.", "en": "Off. Set EVENT_SIGNUP_BACKUP_DIR or EVENT_SIGNUP_BACKUP_S3_BUCKET on the server to turn them on."},
+	"autobackup_destination":   {"fr": "Destination :", "en": "Destination:"},
+	"autobackup_schedule":      {"fr": "Planification (cron) :", "en": "Schedule (cron):"},
+	"autobackup_keep":          {"fr": "copies conservées :", "en": "copies kept:"},
+	"autobackup_last":          {"fr": "Dernière sauvegarde :", "en": "Last backup:"},
+	"autobackup_never":         {"fr": "jamais", "en": "never"},
+	"autobackup_next":          {"fr": "prochaine :", "en": "next:"},
+	"autobackup_run":           {"fr": "Sauvegarder maintenant", "en": "Back up now"},
+	"autobackup_done":          {"fr": "Sauvegarde effectuée.", "en": "Backup written."},
+	"autobackup_failed":        {"fr": "La sauvegarde a échoué. Le détail est dans le journal du serveur.", "en": "The backup failed. The server log has the details."},
+	"autobackup_failing":       {"fr": "La dernière sauvegarde a échoué le", "en": "The latest backup failed on"},
+	"autobackup_failing_short": {"fr": "Sauvegarde en échec", "en": "Backup failing"},
+
+	// API tokens
+	"api_tokens_title":            {"fr": "Jetons d'API", "en": "API tokens"},
+	"api_tokens_intro":            {"fr": "Pour les scripts qui appellent l'API JSON (par exemple GET /admin/api/registrations?event_id=…) avec l'en-tête « Authorization: Bearer <jeton> ». Un jeton en lecture seule ne peut rien modifier.", "en": "For scripts calling the JSON API (e.g. GET /admin/api/registrations?event_id=…) with an \"Authorization: Bearer <token>\" header. A read-only token can't change anything."},
+	"api_tokens_new":              {"fr": "Nouveau jeton — copiez-le maintenant, il ne sera plus affiché", "en": "New token — copy it now, it won't be shown again"},
+	"api_tokens_name":             {"fr": "Nom", "en": "Name"},
+	"api_tokens_name_placeholder": {"fr": "ex. Export tableur", "en": "e.g. Spreadsheet export"},
+	"api_tokens_scope":            {"fr": "Accès", "en": "Access"},
+	"api_tokens_scope_read":       {"fr": "Lecture seule", "en": "Read-only"},
+	"api_tokens_scope_write":      {"fr": "Lecture et écriture", "en": "Read and write"},
+	"api_tokens_last_used":        {"fr": "Dernière utilisation", "en": "Last used"},
+	"api_tokens_create":           {"fr": "Créer un jeton", "en": "Create token"},
+	"api_tokens_created":          {"fr": "Jeton créé.", "en": "Token created."},
+	"api_tokens_revoke":           {"fr": "Révoquer", "en": "Revoke"},
+	"api_tokens_revoke_confirm":   {"fr": "Révoquer ce jeton ? Les scripts qui l'utilisent cesseront de fonctionner.", "en": "Revoke this token? Scripts using it will stop working."},
+	"api_tokens_revoked":          {"fr": "Jeton révoqué.", "en": "Token revoked."},
+	"api_tokens_error_name":       {"fr": "Donnez un nom au jeton.", "en": "Give the token a name."},
+
+	// Signup review queue
+	"review":                {"fr": "Inscriptions à vérifier", "en": "Signups to review"},
+	"review_title":          {"fr": "Inscriptions à vérifier", "en": "Signups to review"},
+	"review_hint":           {"fr": "Ces inscriptions dépassent les limites par adresse IP fixées dans les paramètres. Elles gardent leur place, mais aucun email n'est envoyé avant votre accord.", "en": "These signups went over the per-IP limits set in the settings. They keep their place, but no email goes out until you approve them."},
+	"review_empty":          {"fr": "Aucune inscription en attente.", "en": "No signups waiting."},
+	"review_person":         {"fr": "Personne", "en": "Person"},
+	"review_signed_up_for":  {"fr": "Inscrit pour", "en": "Signed up for"},
+	"review_reason":         {"fr": "Motif", "en": "Reason"},
+	"review_reason_rate":    {"fr": "Trop d'inscriptions depuis cette adresse en une heure", "en": "Too many signups from this address within an hour"},
+	"review_reason_emails":  {"fr": "Trop d'emails différents depuis cette adresse en 24 h", "en": "Too many different emails from this address within 24 hours"},
+	"review_flagged_at":     {"fr": "Le", "en": "When"},
+	"review_approve":        {"fr": "Accepter", "en": "Approve"},
+	"review_reject":         {"fr": "Refuser", "en": "Reject"},
+	"review_reject_confirm": {"fr": "Refuser et supprimer cette inscription ?", "en": "Reject and delete this signup?"},
+	"review_approved":       {"fr": "Inscription acceptée, la confirmation est envoyée.", "en": "Signup approved; the confirmation is on its way."},
+	"review_rejected":       {"fr": "Inscription refusée et supprimée.", "en": "Signup rejected and deleted."},
+	"registered_held":       {"fr": "Votre inscription doit être vérifiée par les organisateurs. Vous recevrez l'email de confirmation dès qu'elle sera acceptée.", "en": "Your signup needs to be checked by the organizers. You will get the confirmation email as soon as it is approved."},
+
+	// Trash
+	"trash":                   {"fr": "Corbeille", "en": "Trash"},
+	"trash_title":             {"fr": "Corbeille", "en": "Trash"},
+	"trash_hint":              {"fr": "Les événements, groupes, tâches et inscriptions supprimés restent ici 30 jours avant d'être effacés définitivement. Un événement restauré revient avec ses tâches et ses inscriptions.", "en": "Deleted events, groups, tasks and registrations stay here for 30 days before being erased for good. A restored event comes back with its tasks and registrations."},
+	"trash_empty":             {"fr": "La corbeille est vide.", "en": "The trash is empty."},
+	"trash_item":              {"fr": "Élément", "en": "Item"},
+	"trash_event":             {"fr": "Événement", "en": "Event"},
+	"trash_deleted_at":        {"fr": "Supprimé le", "en": "Deleted"},
+	"trash_kind_event":        {"fr": "Événement", "en": "Event"},
+	"trash_kind_group":        {"fr": "Groupe", "en": "Group"},
+	"trash_kind_task":         {"fr": "Tâche", "en": "Task"},
+	"trash_kind_registration": {"fr": "Inscription", "en": "Registration"},
+	"trash_kind_structure":    {"fr": "Groupes et tâches", "en": "Groups and tasks"},
+	"trash_restore":           {"fr": "Restaurer", "en": "Restore"},
+	"trash_purge":             {"fr": "Supprimer définitivement", "en": "Delete for good"},
+	"trash_purge_confirm":     {"fr": "Supprimer définitivement ? Cette action est irréversible.", "en": "Delete for good? This cannot be undone."},
+	"trash_restored":          {"fr": "Élément restauré.", "en": "Item restored."},
+	"trash_purged":            {"fr": "Élément supprimé définitivement.", "en": "Item deleted for good."},
+	"trash_parent_deleted":    {"fr": "Son événement ou sa tâche est aussi dans la corbeille : restaurez-le d'abord.", "en": "Its event or task is in the trash too: restore that first."},
+	"trash_email_taken":       {"fr": "Une inscription avec la même adresse e-mail existe déjà pour cet événement.", "en": "Someone with the same email address is already registered for this event."},
+
+	// Spam protection
+	"spam_rejected":  {"fr": "Votre envoi n'a pas pu être accepté. Patientez quelques secondes et réessayez.", "en": "Your submission could not be accepted. Wait a few seconds and try again."},
+	"captcha_failed": {"fr": "Veuillez compléter la vérification anti-spam.", "en": "Please complete the anti-spam check."},
+
 	// Errors
 	"error_title":        {"fr": "Erreur", "en": "Error"},
 	"error_not_found":    {"fr": "Page introuvable.", "en": "Page not found."},
 	"error_full":         {"fr": "Cette tâche est complète, il n'y a plus de places disponibles.", "en": "This task is full, no spots available."},
 	"error_invalid_form": {"fr": "Veuillez remplir tous les champs obligatoires.", "en": "Please fill in all required fields."},
 	"error_server":       {"fr": "Erreur interne du serveur.", "en": "Internal server error."},
+	"error_csrf":         {"fr": "La page a expiré. Rechargez-la et réessayez.", "en": "This page has expired. Reload it and try again."},
 }
 
 func T(key, lang string) string {
@@ -316,6 +1264,13 @@ func T(key, lang string) string {
 }
 
 func LangFromRequest(r *http.Request) string {
+	return LangFromRequestOr(r, instanceLang())
+}
+
+// LangFromRequestOr is LangFromRequest with a caller-chosen fallback, such as
+// the language a registrant signed up in. An explicit ?lang= or lang cookie
+// still wins.
+func LangFromRequestOr(r *http.Request, fallback string) string {
 	if q := r.URL.Query().Get("lang"); q != "" {
 		for _, l := range SupportedLangs {
 			if q == l {
@@ -330,7 +1285,12 @@ func LangFromRequest(r *http.Request) string {
 			}
 		}
 	}
-	return DefaultLang
+	for _, l := range SupportedLangs {
+		if fallback == l {
+			return l
+		}
+	}
+	return instanceLang()
 }
 
 func SetLangCookie(w http.ResponseWriter, lang string) {
@@ -0,0 +1,254 @@
+package main
+
+// Calendar (.ics) file for a task registration, attached to the signup
+// confirmation email and downloadable from /ics/<registration token>.
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// icsEscape escapes a TEXT value (RFC 5545 §3.3.11).
+func icsEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// icsFold writes one content line, folded at 75 octets without splitting a
+// UTF-8 sequence (RFC 5545 §3.1).
+func icsFold(b *strings.Builder, line string) {
+	const limit = 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		b.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+	}
+	b.WriteString(line + "\r\n")
+}
+
+// icsEntry is one calendar entry. Events without a time become all-day
+// entries; timed ones are pinned in UTC when the event has a time zone and
+// otherwise use floating local time (the event's own wall clock). The end
+// is left open unless given; without an EndDate, an end time not after the
+// start falls on the next day. All-day entries span Date through EndDate.
+type icsEntry struct {
+	UID, Summary, Description, URL string
+	Date, Time, End                string // Event.EventDate, "HH:MM", "HH:MM"
+	EndDate                        string // Event.EndDate, "" for Date
+	Zone                           *time.Location
+}
+
+func buildICS(e icsEntry, lang string) []byte {
+	var b strings.Builder
+	icsBegin(&b, lang)
+	icsWriteEvent(&b, e)
+	icsFold(&b, "END:VCALENDAR")
+	return []byte(b.String())
+}
+
+func icsBegin(b *strings.Builder, lang string) {
+	icsFold(b, "BEGIN:VCALENDAR")
+	icsFold(b, "VERSION:2.0")
+	icsFold(b, "PRODID:-//event-signup//"+strings.ToUpper(lang))
+	icsFold(b, "CALSCALE:GREGORIAN")
+	icsFold(b, "METHOD:PUBLISH")
+}
+
+func icsWriteEvent(b *strings.Builder, e icsEntry) {
+	icsFold(b, "BEGIN:VEVENT")
+	icsFold(b, "UID:"+e.UID)
+	icsFold(b, "DTSTAMP:"+time.Now().UTC().Format("20060102T150405Z"))
+	day, err := time.Parse("2006-01-02", e.Date)
+	if err != nil {
+		day = time.Now()
+	}
+	endDay, err := time.Parse("2006-01-02", e.EndDate)
+	if err != nil || endDay.Before(day) {
+		endDay = day
+	}
+	if at, err := time.Parse("15:04", e.Time); err == nil {
+		stamp := func(day time.Time, at time.Time) string {
+			if e.Zone != nil {
+				t := time.Date(day.Year(), day.Month(), day.Day(), at.Hour(), at.Minute(), 0, 0, e.Zone)
+				return t.UTC().Format("20060102T150405Z")
+			}
+			return time.Date(day.Year(), day.Month(), day.Day(), at.Hour(), at.Minute(), 0, 0, time.UTC).Format("20060102T150405")
+		}
+		icsFold(b, "DTSTART:"+stamp(day, at))
+		if end, err := time.Parse("15:04", e.End); err == nil {
+			if endDay.Equal(day) && !end.After(at) {
+				endDay = day.AddDate(0, 0, 1)
+			}
+			icsFold(b, "DTEND:"+stamp(endDay, end))
+		}
+	} else {
+		icsFold(b, "DTSTART;VALUE=DATE:"+day.Format("20060102"))
+		icsFold(b, "DTEND;VALUE=DATE:"+endDay.AddDate(0, 0, 1).Format("20060102"))
+	}
+	icsFold(b, "SUMMARY:"+icsEscape(e.Summary))
+	if e.Description != "" {
+		icsFold(b, "DESCRIPTION:"+icsEscape(e.Description))
+	}
+	icsFold(b, "URL:"+e.URL)
+	icsFold(b, "END:VEVENT")
+}
+
+// registrationICS builds the calendar entry for one registration.
+func registrationICS(reg Registration, task Task, event Event, baseURL, cancelURL, lang string) []byte {
+	eventTitle := Localized(event.TitleFR, event.TitleEN, lang)
+	taskTitle := Localized(task.TitleFR, task.TitleEN, lang)
+	// A task shift is more precise than the event's own times.
+	startTime, endTime, endDate := event.EventTime, event.EndTime, event.EndDate
+	if task.StartTime != "" {
+		startTime, endTime, endDate = task.StartTime, task.EndTime, ""
+	}
+	return buildICS(icsEntry{
+		UID:     reg.Token + "@event-signup",
+		Summary: eventTitle + " — " + taskTitle,
+		Description: emailLabel(T("confirmation_task", lang), lang) + " " + taskTitle +
+			"\n\n" + emailLabel(T("confirmation_cancel_link", lang), lang) + "\n" + cancelURL,
+		URL:     baseURL + "/e/" + event.Slug + "?lang=" + lang,
+		Date:    event.EventDate,
+		Time:    startTime,
+		End:     endTime,
+		EndDate: endDate,
+		Zone:    event.Location(),
+	}, lang)
+}
+
+// eventICS builds the calendar entry for the event itself, offered on its
+// public page. The description is the event's rich text flattened to plain
+// text.
+func eventICS(event Event, baseURL, lang string) []byte {
+	return buildICS(icsEntry{
+		UID:         fmt.Sprintf("event-%d@event-signup", event.ID),
+		Summary:     Localized(event.TitleFR, event.TitleEN, lang),
+		Description: htmlToText(Localized(event.DescriptionFR, event.DescriptionEN, lang)),
+		URL:         baseURL + "/e/" + event.Slug + "?lang=" + lang,
+		Date:        event.EventDate,
+		Time:        event.EventTime,
+		End:         event.EndTime,
+		EndDate:     event.EndDate,
+		Zone:        event.Location(),
+	}, lang)
+}
+
+// registrationICSAttachment wraps registrationICS for an email.
+func registrationICSAttachment(reg Registration, task Task, event Event, baseURL, cancelURL, lang string) emailAttachment {
+	return emailAttachment{
+		Filename:    event.Slug + ".ics",
+		ContentType: "text/calendar; charset=utf-8; method=PUBLISH",
+		Data:        registrationICS(reg, task, event, baseURL, cancelURL, lang),
+	}
+}
+
+// handlePublicICS serves the calendar file for the registration whose cancel
+// token is in the path. The token is the only credential, as for /cancel/.
+func (app *App) handlePublicICS(w http.ResponseWriter, r *http.Request) {
+	regToken, _, ok := app.parseCancelToken(strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/ics/"), ".ics"))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	reg, err := GetRegistrationByToken(app.DB, regToken)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	task, err := GetTask(app.DB, reg.TaskID)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	event, err := GetEvent(app.DB, task.EventID)
+	if err != nil {
+		log.Printf("ics: event %d: %v", task.EventID, err)
+		http.NotFound(w, r)
+		return
+	}
+	lang := LangFromRequestOr(r, reg.Lang)
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.ics"`, event.Slug))
+	baseURL := app.eventBaseURL(r, event)
+	w.Write(registrationICS(*reg, *task, *event, baseURL, app.cancelURL(baseURL, *reg, *event, lang), lang))
+}
+
+// handleEventICS serves /e/<slug>/calendar.ics.
+func (app *App) handleEventICS(w http.ResponseWriter, r *http.Request, event *Event) {
+	lang := LangFromRequest(r)
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.ics"`, event.Slug))
+	w.Write(eventICS(*event, app.eventBaseURL(r, event), lang))
+}
+
+// eventsFeedICS builds the organizers' calendar of every event on or after
+// today, each described by its current signup count.
+func (app *App) eventsFeedICS(baseURL, lang string) ([]byte, error) {
+	events, err := ListEvents(app.DB)
+	if err != nil {
+		return nil, err
+	}
+	today := time.Now().Format("2006-01-02")
+	var b strings.Builder
+	icsBegin(&b, lang)
+	icsFold(&b, "X-WR-CALNAME:"+icsEscape(T("events", lang)))
+	for _, e := range events {
+		if e.EventDate < today {
+			continue
+		}
+		var count string
+		switch e.EventType {
+		case "attendance":
+			yes, total := CountAttendances(app.DB, e.ID)
+			count = fmt.Sprintf(T("feed_attendance_count", lang), yes, total)
+		case "secret_santa":
+			total, _ := CountSantaParticipants(app.DB, e.ID)
+			count = fmt.Sprintf(T("feed_santa_count", lang), total)
+		default:
+			count = fmt.Sprintf(T("feed_registration_count", lang), CountRegistrations(app.DB, e.ID))
+		}
+		icsWriteEvent(&b, icsEntry{
+			UID:         fmt.Sprintf("event-%d@event-signup", e.ID),
+			Summary:     Localized(e.TitleFR, e.TitleEN, lang),
+			Description: count + "\n" + e.BaseURLOr(baseURL) + "/e/" + e.Slug + "?lang=" + lang,
+			URL:         fmt.Sprintf("%s/admin/event/edit?id=%d&lang=%s", baseURL, e.ID, lang),
+			Date:        e.EventDate,
+			Time:        e.EventTime,
+			End:         e.EndTime,
+			EndDate:     e.EndDate,
+			Zone:        e.Location(),
+		})
+	}
+	icsFold(&b, "END:VCALENDAR")
+	return []byte(b.String()), nil
+}
+
+// handleAdminFeed serves /admin/feed.ics?token=… . Calendar apps can't log
+// in, so the feed token (set on the settings page) replaces the admin
+// session; with no token set the feed is off.
+func (app *App) handleAdminFeed(w http.ResponseWriter, r *http.Request) {
+	want := GetSetting(app.DB, "feed_token")
+	got := r.URL.Query().Get("token")
+	if want == "" || subtle.ConstantTimeCompare([]byte(want), []byte(got)) != 1 {
+		http.NotFound(w, r)
+		return
+	}
+	lang := r.URL.Query().Get("lang")
+	if lang != LangFR && lang != LangEN {
+		lang = instanceLang()
+	}
+	feed, err := app.eventsFeedICS(app.baseURLFor(r), lang)
+	if err != nil {
+		log.Printf("feed: %v", err)
+		http.Error(w, "server error", 500)
+		return
+	}
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Write(feed)
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRegistrationICS(t *testing.T) {
+	event := Event{Slug: "fete", TitleFR: "Fête, été", EventDate: "2026-06-14", EventTime: "14:30"}
+	task := Task{TitleFR: "Vaisselle; bar"}
+	reg := Registration{Token: "tok123"}
+
+	ics := string(registrationICS(reg, task, event, "https://fete.example.org", "https://fete.example.org/cancel/tok123?lang=fr", LangFR))
+	for _, want := range []string{
+		"BEGIN:VEVENT\r\n",
+		"UID:tok123@event-signup\r\n",
+		"DTSTART:20260614T143000\r\n",
+		`SUMMARY:Fête\, été — Vaisselle\; bar`,
+	} {
+		if !strings.Contains(ics, want) {
+			t.Errorf("ics missing %q:\n%s", want, ics)
+		}
+	}
+	// The cancel URL may be folded across lines; unfold before looking.
+	if !strings.Contains(strings.ReplaceAll(ics, "\r\n ", ""), "https://fete.example.org/cancel/tok123?lang=fr") {
+		t.Error("description should carry the cancel URL")
+	}
+	for _, line := range strings.Split(ics, "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("line longer than 75 octets: %q", line)
+		}
+	}
+
+	event.EventTime = ""
+	ics = string(registrationICS(reg, task, event, "https://fete.example.org", "https://fete.example.org/cancel/tok123?lang=fr", LangFR))
+	if !strings.Contains(ics, "DTSTART;VALUE=DATE:20260614\r\n") || !strings.Contains(ics, "DTEND;VALUE=DATE:20260615\r\n") {
+		t.Errorf("event without time should be all-day:\n%s", ics)
+	}
+}
+
+func TestICSDownloadAndAttachment(t *testing.T) {
+	app := testApp(t)
+	e := seedEvent(t, app.DB)
+	tk := seedTask(t, app.DB, e.ID, "Vaisselle", nil)
+	mux := newMux(app)
+
+	confirmation := postForm(mux, "/signup?lang=fr", url.Values{
+		"task_id":    {fmt.Sprint(tk.ID)},
+		"first_name": {"Alice"},
+		"last_name":  {"Dupont"},
+		"email":      {"alice@test.com"},
+		"phone":      {"0601"},
+	}).Body.String()
+	reg, err := GetRegistrationByEmailAndEvent(app.DB, "alice@test.com", e.ID)
+	if err != nil {
+		t.Fatalf("registration not stored: %v", err)
+	}
+	if !strings.Contains(confirmation, "/ics/"+app.cancelToken(*reg, *e)+"?") || strings.Contains(confirmation, "/ics/"+reg.Token+"?") {
+		t.Error("confirmation page should link the calendar file by the signed token")
+	}
+	fake := app.Email.(*fakeEmailSender)
+	if fake.count() != 1 || len(fake.sent[0].Attachments) != 1 || fake.sent[0].Attachments[0] != e.Slug+".ics" {
+		t.Errorf("confirmation email should carry the .ics file: %+v", fake.sent)
+	}
+
+	w := getRequest(mux, "/ics/"+app.cancelToken(*reg, *e))
+	if w.Code != 200 || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/calendar") {
+		t.Fatalf("status = %d, content-type = %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(w.Body.String(), "SUMMARY:Test Event — Vaisselle") {
+		t.Errorf("body = %s", w.Body.String())
+	}
+	if w := getRequest(mux, "/ics/unknown"); w.Code != 404 {
+		t.Errorf("unknown token: status = %d, want 404", w.Code)
+	}
+}
+
+func TestEventCalendarDownload(t *testing.T) {
+	app := testApp(t)
+	e := &Event{TitleFR: "Fête du village", TitleEN: "Village fair", EventDate: "2026-06-14", EventTime: "10:00",
+		DescriptionFR: "<p>Apportez un plat.</p>"}
+	CreateEvent(app.DB, e)
+	mux := newMux(app)
+
+	w := getRequest(mux, "/e/"+e.Slug+"/calendar.ics?lang=en")
+	if w.Code != 200 || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/calendar") {
+		t.Fatalf("status %d, content type %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	ics := w.Body.String()
+	for _, want := range []string{
+		fmt.Sprintf("UID:event-%d@event-signup\r\n", e.ID),
+		"DTSTART:20260614T100000\r\n",
+		"SUMMARY:Village fair\r\n",
+		"DESCRIPTION:Apportez un plat.\r\n",
+	} {
+		if !strings.Contains(ics, want) {
+			t.Errorf("ics missing %q:\n%s", want, ics)
+		}
+	}
+
+	if w := getRequest(mux, "/e/"+e.Slug+"?lang=fr"); !strings.Contains(w.Body.String(), "/e/"+e.Slug+"/calendar.ics") {
+		t.Error("public page does not link to the calendar file")
+	}
+	if w := getRequest(mux, "/e/unknown/calendar.ics"); w.Code != 404 {
+		t.Errorf("unknown event: status %d", w.Code)
+	}
+}
+
+func TestAdminCalendarFeed(t *testing.T) {
+	app := testApp(t)
+	mux := newMux(app)
+	upcoming := &Event{TitleFR: "Kermesse", TitleEN: "Fair", EventDate: time.Now().AddDate(0, 1, 0).Format("2006-01-02")}
+	past := &Event{TitleFR: "Brocante", TitleEN: "Flea market", EventDate: time.Now().AddDate(0, -1, 0).Format("2006-01-02")}
+	CreateEvent(app.DB, upcoming)
+	CreateEvent(app.DB, past)
+	tk := seedTask(t, app.DB, upcoming.ID, "Vaisselle", nil)
+	postForm(mux, "/signup?lang=en", url.Values{
+		"task_id":    {fmt.Sprint(tk.ID)},
+		"first_name": {"Alice"},
+		"last_name":  {"Dupont"},
+		"email":      {"alice@test.com"},
+		"phone":      {"0601"},
+	})
+
+	if w := getRequest(mux, "/admin/feed.ics"); w.Code != 404 {
+		t.Errorf("feed without a token set: status %d", w.Code)
+	}
+	postForm(mux, "/admin/settings/feed?lang=en", url.Values{}, adminCookie(app))
+	token := GetSetting(app.DB, "feed_token")
+	if token == "" {
+		t.Fatal("feed token not created")
+	}
+	if w := getRequest(mux, "/admin/settings?lang=en", adminCookie(app)); !strings.Contains(w.Body.String(), "/admin/feed.ics?token="+token) {
+		t.Error("settings page does not show the feed URL")
+	}
+	if w := getRequest(mux, "/admin/feed.ics?token=wrong"); w.Code != 404 {
+		t.Errorf("wrong token: status %d", w.Code)
+	}
+
+	w := getRequest(mux, "/admin/feed.ics?lang=en&token="+token)
+	if w.Code != 200 || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/calendar") {
+		t.Fatalf("status %d, content type %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	ics := strings.ReplaceAll(w.Body.String(), "\r\n ", "")
+	if !strings.Contains(ics, "SUMMARY:Fair\r\n") || !strings.Contains(ics, "1 signed up") {
+		t.Errorf("feed lacks the upcoming event and its count:\n%s", ics)
+	}
+	if strings.Contains(ics, "Flea market") {
+		t.Error("feed lists a past event")
+	}
+
+	postForm(mux, "/admin/settings/feed?lang=en", url.Values{"action": {"disable"}}, adminCookie(app))
+	if w := getRequest(mux, "/admin/feed.ics?token="+token); w.Code != 404 {
+		t.Errorf("disabled feed: status %d", w.Code)
+	}
+}
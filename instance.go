@@ -0,0 +1,221 @@
+package main
+
+// Instance settings: the site title, default language, public base URL,
+// Anthropic API key and theme color, set from the admin settings page so
+// changing them takes neither editing the environment nor a restart. Each one
+// left empty falls back to the environment or the built-in default. They are
+// needed on nearly every request, so the saved values are kept in memory and
+// reloaded whenever they change.
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// defaultThemeColor is the accent color of static/style.css.
+const defaultThemeColor = "#6366F1"
+
+var themeColorRe = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+type InstanceSettings struct {
+	SiteTitle    string
+	DefaultLang  string
+	BaseURL      string
+	AnthropicKey string
+	ThemeColor   string
+}
+
+var instanceSettingKeys = []string{"site_title", "default_lang", "base_url", "anthropic_api_key", "theme_color"}
+
+func (s *InstanceSettings) fields() []*string {
+	return []*string{&s.SiteTitle, &s.DefaultLang, &s.BaseURL, &s.AnthropicKey, &s.ThemeColor}
+}
+
+func GetInstanceSettings(db *sql.DB) (InstanceSettings, error) {
+	var s InstanceSettings
+	fields := s.fields()
+	for i, key := range instanceSettingKeys {
+		err := db.QueryRow("SELECT value FROM settings WHERE key=?", key).Scan(fields[i])
+		if err != nil && err != sql.ErrNoRows {
+			return InstanceSettings{}, err
+		}
+	}
+	return s, nil
+}
+
+func SaveInstanceSettings(db *sql.DB, s InstanceSettings) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	fields := s.fields()
+	for i, key := range instanceSettingKeys {
+		if _, err := tx.Exec("INSERT INTO settings (key, value) VALUES (?, ?) ON CONFLICT(key) DO UPDATE SET value=excluded.value",
+			key, *fields[i]); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// validateInstanceSettings checks settings about to be saved and returns the
+// i18n key of the first problem, or "" when they are valid.
+func validateInstanceSettings(s InstanceSettings) string {
+	if s.DefaultLang != "" && !slices.Contains(SupportedLangs, s.DefaultLang) {
+		return "instance_error_lang"
+	}
+	if s.BaseURL != "" {
+		u, err := url.Parse(s.BaseURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" ||
+			u.RawQuery != "" || u.Fragment != "" || strings.Trim(u.Path, "/") != "" {
+			return "instance_error_base_url"
+		}
+	}
+	if s.ThemeColor != "" && !themeColorRe.MatchString(s.ThemeColor) {
+		return "instance_error_theme_color"
+	}
+	return ""
+}
+
+// instance holds the saved instance settings; see loadInstanceSettings.
+var instance atomic.Pointer[InstanceSettings]
+
+// loadInstanceSettings reads the instance settings from db into memory.
+// Called at startup, after saving them and after restoring a backup.
+func loadInstanceSettings(db *sql.DB) error {
+	s, err := GetInstanceSettings(db)
+	if err != nil {
+		return err
+	}
+	instance.Store(&s)
+	return nil
+}
+
+func currentInstance() InstanceSettings {
+	if s := instance.Load(); s != nil {
+		return *s
+	}
+	return InstanceSettings{}
+}
+
+// instanceLang is the language of visitors who haven't picked one.
+func instanceLang() string {
+	if lang := currentInstance().DefaultLang; lang != "" {
+		return lang
+	}
+	return DefaultLang
+}
+
+// siteTitle is the name shown in the page header, the browser tab and the
+// web-app manifest.
+func siteTitle(lang string) string {
+	if title := currentInstance().SiteTitle; title != "" {
+		return title
+	}
+	return T("app_title", lang)
+}
+
+// ThemeColors are the accent shades of static/style.css, derived from the
+// configured theme color.
+type ThemeColors struct {
+	Primary, Dark, Light, Bg string
+}
+
+// themeColors returns the shades of the configured theme color, or nil when
+// none is set and the stylesheet's own apply.
+func themeColors() *ThemeColors {
+	c := currentInstance().ThemeColor
+	if c == "" {
+		return nil
+	}
+	return themeShades(c)
+}
+
+// themeShades derives the accent shades from the #RRGGBB color c.
+func themeShades(c string) *ThemeColors {
+	return &ThemeColors{
+		Primary: c,
+		Dark:    mixColor(c, "#000000", 0.15),
+		Light:   mixColor(c, "#FFFFFF", 0.5),
+		Bg:      mixColor(c, "#FFFFFF", 0.9),
+	}
+}
+
+func themeColor() string {
+	if c := currentInstance().ThemeColor; c != "" {
+		return c
+	}
+	return defaultThemeColor
+}
+
+// mixColor blends #RRGGBB color a toward b by t (0 keeps a, 1 gives b).
+func mixColor(a, b string, t float64) string {
+	out := "#"
+	for i := 1; i < 7; i += 2 {
+		x, _ := strconv.ParseUint(a[i:i+2], 16, 8)
+		y, _ := strconv.ParseUint(b[i:i+2], 16, 8)
+		out += fmt.Sprintf("%02X", int(float64(x)+(float64(y)-float64(x))*t+0.5))
+	}
+	return out
+}
+
+// anthropicKey is the API key for the AI features: the one saved in the
+// settings, else ANTHROPIC_API_KEY.
+func (app *App) anthropicKey() string {
+	if key := currentInstance().AnthropicKey; key != "" {
+		return key
+	}
+	return app.AnthropicKey
+}
+
+// handleAdminSettingsInstance saves the instance settings.
+func (app *App) handleAdminSettingsInstance(w http.ResponseWriter, r *http.Request) {
+	lang := LangFromRequest(r)
+	settingsURL := "/admin/settings?lang=" + lang + "#instance"
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, settingsURL, http.StatusSeeOther)
+		return
+	}
+	current := currentInstance()
+	s := InstanceSettings{
+		SiteTitle:    strings.TrimSpace(r.FormValue("site_title")),
+		DefaultLang:  r.FormValue("default_lang"),
+		BaseURL:      strings.TrimRight(strings.TrimSpace(r.FormValue("base_url")), "/"),
+		AnthropicKey: strings.TrimSpace(r.FormValue("anthropic_api_key")),
+		ThemeColor:   strings.TrimSpace(r.FormValue("theme_color")),
+	}
+	// Like the SMTP password, the key is never echoed back: leaving it blank
+	// keeps the saved one.
+	if s.AnthropicKey == "" && r.FormValue("anthropic_api_key_clear") == "" {
+		s.AnthropicKey = current.AnthropicKey
+	}
+	// The color picker always submits a color; the stylesheet's own counts as
+	// none, so later changes to it still apply.
+	if strings.EqualFold(s.ThemeColor, defaultThemeColor) {
+		s.ThemeColor = ""
+	}
+	if errKey := validateInstanceSettings(s); errKey != "" {
+		setFlash(w, "error", T(errKey, lang))
+		http.Redirect(w, r, settingsURL, http.StatusSeeOther)
+		return
+	}
+	if err := SaveInstanceSettings(app.DB, s); err != nil {
+		log.Printf("settings: instance: %v", err)
+		setFlash(w, "error", T("error_server", lang))
+	} else if err := loadInstanceSettings(app.DB); err != nil {
+		log.Printf("settings: instance: %v", err)
+		setFlash(w, "error", T("error_server", lang))
+	} else {
+		setFlash(w, "success", T("settings_saved", lang))
+	}
+	http.Redirect(w, r, settingsURL, http.StatusSeeOther)
+}
@@ -0,0 +1,272 @@
+package main
+
+// Invite-only events. An event meant for a known list — a choir, a
+// confirmation class — without accounts for each person can require an
+// invite: its public pages, and every form on them, then need one of its
+// invite links, /e/<slug>?invite=<token>, which admins hand out. An invite
+// is either shared by a whole group, counting how often it was used, or
+// for a single person, drawn in batches: it then belongs to the email of
+// the first signup made with it, who can still change their reply, and
+// stops working for anyone else. Logged-in admins see the pages without an
+// invite, as with drafts.
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxInviteBatch bounds how many single-use invites one batch draws.
+const maxInviteBatch = 200
+
+var errInviteUsed = errors.New("invite used by someone else")
+
+// Invite is one invite link of an event.
+type Invite struct {
+	ID        int64
+	EventID   int64
+	Token     string
+	Label     string // who it was made for, as the admin put it
+	SingleUse bool
+	// Uses counts the signups and replies made with the invite; a
+	// single-use one has at most one, by UsedBy.
+	Uses       int
+	UsedBy     string
+	LastUsedAt sql.NullTime
+	CreatedAt  time.Time
+}
+
+const inviteCols = "id, event_id, token, label, single_use, uses, used_by, last_used_at, created_at"
+
+func scanInvite(s interface{ Scan(...any) error }) (*Invite, error) {
+	inv := &Invite{}
+	err := s.Scan(&inv.ID, &inv.EventID, &inv.Token, &inv.Label, &inv.SingleUse, &inv.Uses, &inv.UsedBy, &inv.LastUsedAt, &inv.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return inv, nil
+}
+
+// CreateInvites draws n invites for event eventID under label: one shared
+// invite, or n single-use ones.
+func CreateInvites(db *sql.DB, eventID int64, label string, singleUse bool, n int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for range n {
+		if _, err := tx.Exec("INSERT INTO event_invites (event_id, token, label, single_use) VALUES (?, ?, ?, ?)",
+			eventID, GenerateToken(), label, singleUse); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ListInvites returns the invites of event eventID, oldest first.
+func ListInvites(db *sql.DB, eventID int64) ([]Invite, error) {
+	rows, err := db.Query("SELECT "+inviteCols+" FROM event_invites WHERE event_id=? ORDER BY id", eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var invites []Invite
+	for rows.Next() {
+		inv, err := scanInvite(rows)
+		if err != nil {
+			return nil, err
+		}
+		invites = append(invites, *inv)
+	}
+	return invites, rows.Err()
+}
+
+// GetInvite returns the invite of event eventID with token.
+func GetInvite(db *sql.DB, eventID int64, token string) (*Invite, error) {
+	return scanInvite(db.QueryRow("SELECT "+inviteCols+" FROM event_invites WHERE event_id=? AND token=?", eventID, token))
+}
+
+// DeleteInvite revokes invite id of event eventID.
+func DeleteInvite(db *sql.DB, eventID, id int64) error {
+	_, err := db.Exec("DELETE FROM event_invites WHERE id=? AND event_id=?", id, eventID)
+	return err
+}
+
+// ClaimInvite holds single-use invite inv for email while a signup is made
+// with it, so two people signing up at once cannot both get it; it returns
+// errInviteUsed when someone else holds it. Shared invites need no claim.
+func ClaimInvite(db *sql.DB, inv *Invite, email string) error {
+	if !inv.SingleUse {
+		return nil
+	}
+	res, err := db.Exec("UPDATE event_invites SET used_by=? WHERE id=? AND (used_by='' OR used_by=? COLLATE NOCASE)",
+		email, inv.ID, email)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return errInviteUsed
+	}
+	return nil
+}
+
+// ReleaseInvite gives up email's claim on inv unless a signup was counted
+// with it.
+func ReleaseInvite(db *sql.DB, inv *Invite, email string) error {
+	_, err := db.Exec("UPDATE event_invites SET used_by='' WHERE id=? AND uses=0 AND used_by=? COLLATE NOCASE", inv.ID, email)
+	return err
+}
+
+// UseInvite counts a signup made with inv by email. A single-use invite
+// becomes email's, counting once; it returns errInviteUsed when someone
+// else got it first.
+func UseInvite(db *sql.DB, inv *Invite, email string) error {
+	query := "UPDATE event_invites SET uses=uses+1, last_used_at=CURRENT_TIMESTAMP WHERE id=?"
+	args := []any{inv.ID}
+	if inv.SingleUse {
+		query = `UPDATE event_invites SET uses=1, used_by=?, last_used_at=CURRENT_TIMESTAMP
+			WHERE id=? AND (used_by='' OR used_by=? COLLATE NOCASE)`
+		args = []any{email, inv.ID, email}
+	}
+	res, err := db.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return errInviteUsed
+	}
+	return nil
+}
+
+// SetEventInviteOnly makes event id require an invite, or not.
+func SetEventInviteOnly(db *sql.DB, id int64, inviteOnly bool) error {
+	_, err := db.Exec("UPDATE events SET invite_only=? WHERE id=?", inviteOnly, id)
+	return err
+}
+
+// requestInvite returns the invite of invite-only event that r carries as
+// ?invite=, nil when none.
+func (app *App) requestInvite(r *http.Request, event *Event) *Invite {
+	token := strings.TrimSpace(r.FormValue("invite"))
+	if !event.InviteOnly || token == "" {
+		return nil
+	}
+	inv, err := GetInvite(app.DB, event.ID, token)
+	if err != nil {
+		return nil
+	}
+	return inv
+}
+
+// inviteDenied reports whether event is invite-only and r has no invite:
+// anyone but a logged-in admin without one of its invites.
+func (app *App) inviteDenied(r *http.Request, event *Event) bool {
+	if !event.InviteOnly || app.requestInvite(r, event) != nil {
+		return false
+	}
+	_, admin := app.adminSession(r)
+	return !admin
+}
+
+// claimInvite claims for email the invite r carries for event, nil when
+// none; refused is true when it is a single-use one someone else holds.
+// Callers defer releaseInvite, which keeps the claim once useInvite counted
+// the signup.
+func (app *App) claimInvite(r *http.Request, event *Event, email string) (inv *Invite, refused bool) {
+	inv = app.requestInvite(r, event)
+	if inv == nil {
+		return nil, false
+	}
+	if err := ClaimInvite(app.DB, inv, email); err != nil {
+		if !errors.Is(err, errInviteUsed) {
+			log.Printf("claim invite %d of event %d: %v", inv.ID, event.ID, err)
+		}
+		return nil, true
+	}
+	return inv, false
+}
+
+// releaseInvite gives up a claim made by claimInvite when the signup did
+// not go through.
+func (app *App) releaseInvite(inv *Invite, email string) {
+	if inv == nil {
+		return
+	}
+	if err := ReleaseInvite(app.DB, inv, email); err != nil {
+		log.Printf("release invite %d: %v", inv.ID, err)
+	}
+}
+
+// useInvite counts a signup by email on inv, the invite claimed for it.
+func (app *App) useInvite(inv *Invite, email string) {
+	if inv == nil {
+		return
+	}
+	if err := UseInvite(app.DB, inv, email); err != nil {
+		log.Printf("use invite %d of event %d: %v", inv.ID, inv.EventID, err)
+	}
+}
+
+// renderInviteRequired answers for an invite-only event's pages without an
+// invite.
+func (app *App) renderInviteRequired(w http.ResponseWriter, r *http.Request, event *Event) {
+	w.Header().Set("X-Robots-Tag", "noindex")
+	w.WriteHeader(http.StatusForbidden)
+	app.render(w, r, "invite_required.html", app.newPageData(r, map[string]any{"Event": event}))
+}
+
+// handleAdminInvites makes an event invite-only or open to all, draws
+// invites, or revokes one, from its edit page.
+func (app *App) handleAdminInvites(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/admin", http.StatusSeeOther)
+		return
+	}
+	lang := LangFromRequest(r)
+	id, _ := strconv.ParseInt(r.FormValue("event_id"), 10, 64)
+	event, err := GetEvent(app.DB, id)
+	if err != nil {
+		http.Redirect(w, r, "/admin", http.StatusSeeOther)
+		return
+	}
+	var msg string
+	switch r.FormValue("action") {
+	case "restrict", "open":
+		inviteOnly := r.FormValue("action") == "restrict"
+		err = SetEventInviteOnly(app.DB, event.ID, inviteOnly)
+		msg = T("invites_opened", lang)
+		if inviteOnly {
+			msg = T("invites_restricted", lang)
+		}
+	case "create":
+		singleUse := r.FormValue("kind") == "single"
+		n := 1
+		if singleUse {
+			n, _ = strconv.Atoi(r.FormValue("count"))
+			n = min(max(n, 1), maxInviteBatch)
+		}
+		label := strings.TrimSpace(r.FormValue("label"))
+		err = CreateInvites(app.DB, event.ID, label, singleUse, n)
+		msg = fmt.Sprintf(T("invites_created", lang), n)
+	case "delete":
+		inviteID, _ := strconv.ParseInt(r.FormValue("invite_id"), 10, 64)
+		err = DeleteInvite(app.DB, event.ID, inviteID)
+		msg = T("invites_deleted", lang)
+	default:
+		http.Error(w, T("error_invalid_form", lang), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Printf("invites of event %d: %v", event.ID, err)
+		setFlash(w, "error", T("error_server", lang))
+	} else {
+		setFlash(w, "success", msg)
+	}
+	http.Redirect(w, r, fmt.Sprintf("/admin/event/edit?id=%d&lang=%s#invites", event.ID, lang), http.StatusSeeOther)
+}
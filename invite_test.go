@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestInviteOnlyEvent(t *testing.T) {
+	app := testApp(t)
+	mux := newMux(app)
+	e := seedEvent(t, app.DB)
+	task := seedTask(t, app.DB, e.ID, "Cuisine", nil)
+	admin := adminCookie(app)
+
+	form := func(action string, extra url.Values) *url.Values {
+		v := url.Values{"event_id": {fmt.Sprint(e.ID)}, "action": {action}}
+		for k, vs := range extra {
+			v[k] = vs
+		}
+		return &v
+	}
+	for _, v := range []*url.Values{
+		form("restrict", nil),
+		form("create", url.Values{"kind": {"shared"}, "label": {"Chorale"}}),
+		form("create", url.Values{"kind": {"single"}, "count": {"2"}}),
+	} {
+		if w := postForm(mux, "/admin/event/invites", *v, admin); w.Code != 303 {
+			t.Fatalf("%s: status %d", v.Get("action"), w.Code)
+		}
+	}
+	invites, _ := ListInvites(app.DB, e.ID)
+	if len(invites) != 3 || invites[0].Label != "Chorale" || invites[0].SingleUse || !invites[1].SingleUse {
+		t.Fatalf("invites = %+v", invites)
+	}
+	shared, single := invites[0], invites[1]
+
+	if w := getRequest(mux, "/e/"+e.Slug); w.Code != 403 || !strings.Contains(w.Body.String(), "sur invitation") {
+		t.Errorf("page without invite: status %d", w.Code)
+	}
+	if w := getRequest(mux, "/e/"+e.Slug+"?invite=forged"); w.Code != 403 {
+		t.Errorf("page with a forged invite: status %d", w.Code)
+	}
+	if w := getRequest(mux, "/e/"+e.Slug, admin); w.Code != 200 {
+		t.Errorf("page for admins: status %d", w.Code)
+	}
+	page := getRequest(mux, "/e/"+e.Slug+"?invite="+shared.Token).Body.String()
+	if !strings.Contains(page, `name="invite" value="`+shared.Token+`"`) {
+		t.Error("signup form does not carry the invite")
+	}
+
+	signup := func(email, invite string) string {
+		t.Helper()
+		return postForm(mux, "/signup?lang=en", url.Values{
+			"task_id": {fmt.Sprint(task.ID)}, "first_name": {"Alice"}, "last_name": {"Martin"},
+			"email": {email}, "phone": {"0601"}, "invite": {invite},
+		}).Body.String()
+	}
+	if body := signup("nobody@test.com", ""); !strings.Contains(body, "invite only") {
+		t.Error("signup without invite accepted")
+	}
+	signup("alice@test.com", shared.Token)
+	signup("bob@test.com", shared.Token)
+	signup("carol@test.com", single.Token)
+	if body := signup("dave@test.com", single.Token); !strings.Contains(body, "already used by someone else") {
+		t.Error("single-use invite used twice")
+	}
+	if n := CountRegistrations(app.DB, e.ID); n != 3 {
+		t.Errorf("registrations = %d, want 3", n)
+	}
+	invites, _ = ListInvites(app.DB, e.ID)
+	if invites[0].Uses != 2 || !invites[0].LastUsedAt.Valid {
+		t.Errorf("shared invite = %+v", invites[0])
+	}
+	if invites[1].Uses != 1 || invites[1].UsedBy != "carol@test.com" {
+		t.Errorf("single-use invite = %+v", invites[1])
+	}
+
+	// Two people signing up at once: the first claim holds the invite until
+	// the signup is counted or given up.
+	other := &invites[2]
+	if err := ClaimInvite(app.DB, other, "erin@test.com"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ClaimInvite(app.DB, other, "frank@test.com"); err != errInviteUsed {
+		t.Errorf("second claim: %v, want errInviteUsed", err)
+	}
+	ReleaseInvite(app.DB, other, "erin@test.com")
+	if err := ClaimInvite(app.DB, other, "frank@test.com"); err != nil {
+		t.Errorf("claim after release: %v", err)
+	}
+	ReleaseInvite(app.DB, &single, "carol@test.com")
+	if inv, _ := GetInvite(app.DB, e.ID, single.Token); inv.UsedBy != "carol@test.com" {
+		t.Error("releasing dropped a counted signup")
+	}
+
+	edit := getRequest(mux, fmt.Sprintf("/admin/event/edit?id=%d&lang=en", e.ID), admin).Body.String()
+	if !strings.Contains(edit, "/e/"+e.Slug+"?invite="+shared.Token) || !strings.Contains(edit, "carol@test.com") {
+		t.Error("admin page misses the invites and their use")
+	}
+
+	// Revoking an invite closes its link; opening the event lets everyone in.
+	postForm(mux, "/admin/event/invites", *form("delete", url.Values{"invite_id": {fmt.Sprint(shared.ID)}}), admin)
+	if w := getRequest(mux, "/e/"+e.Slug+"?invite="+shared.Token); w.Code != 403 {
+		t.Errorf("revoked invite: status %d", w.Code)
+	}
+	postForm(mux, "/admin/event/invites", *form("open", nil), admin)
+	if w := getRequest(mux, "/e/"+e.Slug); w.Code != 200 {
+		t.Errorf("opened event: status %d", w.Code)
+	}
+}
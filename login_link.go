@@ -0,0 +1,110 @@
+package main
+
+// Passwordless admin login. Addresses listed in the admin_emails setting can
+// ask for a one-time link, valid for 15 minutes, that opens an admin session
+// — handy for co-organizers who don't keep the password. Links are built on
+// the base URL the list was saved from rather than the requesting Host
+// header, so a forged Host can't send a link to another site.
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const loginLinkTTL = 15 * time.Minute
+
+// adminLoginEmails returns the addresses allowed to log in by email.
+func adminLoginEmails(app *App) []string {
+	raw := GetSetting(app.DB, "admin_emails")
+	if raw == "" {
+		return nil
+	}
+	return strings.Split(raw, ", ")
+}
+
+// handleAdminLoginEmail emails a login link to an allowed address. The reply
+// is the same whether or not the address is allowed, and the email goes
+// through the outbox rather than being sent while the visitor waits, so
+// neither the answer nor its timing reveals who the admins are.
+func (app *App) handleAdminLoginEmail(w http.ResponseWriter, r *http.Request) {
+	lang := LangFromRequest(r)
+	loginURL := "/admin/login?lang=" + lang
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, loginURL, http.StatusSeeOther)
+		return
+	}
+	email := strings.ToLower(strings.TrimSpace(r.FormValue("email")))
+	setFlash(w, "success", T("login_link_sent", lang))
+	defer http.Redirect(w, r, loginURL, http.StatusSeeOther)
+
+	allowed := false
+	for _, a := range adminLoginEmails(app) {
+		allowed = allowed || strings.EqualFold(a, email)
+	}
+	if !allowed || RecentLoginLink(app.DB, email, time.Minute) {
+		return
+	}
+	if err := PurgeLoginLinks(app.DB); err != nil {
+		log.Printf("login link: purge: %v", err)
+	}
+	token, err := CreateLoginLink(app.DB, email, loginLinkTTL)
+	if err != nil {
+		log.Printf("login link: create: %v", err)
+		return
+	}
+	baseURL := GetSetting(app.DB, "admin_login_base_url")
+	subject, htmlBody := renderLoginLinkEmail(lang, baseURL+"/admin/login/link?token="+token+"&lang="+lang, baseURL)
+	if htmlBody == "" {
+		return
+	}
+	app.queueEmail("login_link", 0, email, "", subject, htmlBody)
+}
+
+// handleAdminLoginLink redeems a login link. The link itself only shows a
+// button: mail scanners that prefetch links would otherwise use it up before
+// its recipient clicks.
+func (app *App) handleAdminLoginLink(w http.ResponseWriter, r *http.Request) {
+	lang := LangFromRequest(r)
+	token := r.FormValue("token")
+	if r.Method == http.MethodPost {
+		if email, err := UseLoginLink(app.DB, token); err == nil {
+			log.Printf("login link: %s logged in", email)
+			app.startSession(w, r, "/admin?lang="+lang, r.FormValue("remember") != "")
+			return
+		}
+	}
+	email, err := LoginLinkEmail(app.DB, token)
+	pd := app.newPageData(r, map[string]any{"Token": token, "Email": email})
+	if err != nil {
+		pd.Error = T("login_link_invalid", lang)
+	}
+	app.render(w, r, "admin_login_link.html", pd)
+}
+
+// handleAdminSettingsLoginEmails saves who may log in by email.
+func (app *App) handleAdminSettingsLoginEmails(w http.ResponseWriter, r *http.Request) {
+	lang := LangFromRequest(r)
+	settingsURL := "/admin/settings?lang=" + lang
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, settingsURL, http.StatusSeeOther)
+		return
+	}
+	emails, err := normalizeNotifyEmails(strings.ToLower(r.FormValue("admin_emails")))
+	if err != nil {
+		setFlash(w, "error", T("login_emails_invalid", lang))
+		http.Redirect(w, r, settingsURL, http.StatusSeeOther)
+		return
+	}
+	if err := SetSetting(app.DB, "admin_emails", emails); err != nil {
+		log.Printf("settings: admin emails: %v", err)
+		setFlash(w, "error", T("error_server", lang))
+	} else if err := SetSetting(app.DB, "admin_login_base_url", app.baseURLFor(r)); err != nil {
+		log.Printf("settings: login base url: %v", err)
+		setFlash(w, "error", T("error_server", lang))
+	} else {
+		setFlash(w, "success", T("settings_saved", lang))
+	}
+	http.Redirect(w, r, settingsURL, http.StatusSeeOther)
+}
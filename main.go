@@ -11,6 +11,8 @@ import (
 	"os"
 	"strconv"
 	"time"
+	// Event time zones must resolve even on hosts without a zoneinfo database.
+	_ "time/tzdata"
 )
 
 //go:embed templates/*.html
@@ -58,10 +60,9 @@ func computeStaticBuildID() string {
 }
 
 func main() {
+	// Only seeds the stored password; without it the first visit to the
+	// admin asks for one.
 	adminPassword := os.Getenv("EVENT_SIGNUP_ADMIN_PASSWORD")
-	if adminPassword == "" {
-		log.Fatal("EVENT_SIGNUP_ADMIN_PASSWORD environment variable is required")
-	}
 
 	dbPath := os.Getenv("EVENT_SIGNUP_DATABASE_PATH")
 	if dbPath == "" {
@@ -75,11 +76,114 @@ func main() {
 
 	anthropicKey := os.Getenv("ANTHROPIC_API_KEY")
 
+	var oidc *OIDCProvider
+	if issuer := os.Getenv("EVENT_SIGNUP_OIDC_ISSUER"); issuer != "" {
+		oidc = &OIDCProvider{
+			Issuer:       issuer,
+			ClientID:     os.Getenv("EVENT_SIGNUP_OIDC_CLIENT_ID"),
+			ClientSecret: os.Getenv("EVENT_SIGNUP_OIDC_CLIENT_SECRET"),
+			Name:         os.Getenv("EVENT_SIGNUP_OIDC_NAME"),
+		}
+		if oidc.ClientID == "" {
+			log.Fatal("EVENT_SIGNUP_OIDC_CLIENT_ID is required when EVENT_SIGNUP_OIDC_ISSUER is set")
+		}
+		if oidc.Name == "" {
+			oidc.Name = "SSO"
+		}
+		log.Printf("Admin SSO: %s", issuer)
+	}
+
+	var captcha *Captcha
+	if provider := os.Getenv("EVENT_SIGNUP_CAPTCHA"); provider != "" {
+		if _, ok := captchaProviders[provider]; !ok {
+			log.Fatalf("EVENT_SIGNUP_CAPTCHA must be hcaptcha or turnstile, not %q", provider)
+		}
+		captcha = &Captcha{
+			Provider: provider,
+			SiteKey:  os.Getenv("EVENT_SIGNUP_CAPTCHA_SITE_KEY"),
+			Secret:   os.Getenv("EVENT_SIGNUP_CAPTCHA_SECRET"),
+		}
+		if captcha.SiteKey == "" || captcha.Secret == "" {
+			log.Fatal("EVENT_SIGNUP_CAPTCHA_SITE_KEY and EVENT_SIGNUP_CAPTCHA_SECRET are required when EVENT_SIGNUP_CAPTCHA is set")
+		}
+		log.Printf("Public forms CAPTCHA: %s", provider)
+	}
+
+	// For S3-compatible services without bucket subdomains, such as MinIO.
+	s3PathStyle := os.Getenv("EVENT_SIGNUP_S3_PATH_STYLE") != ""
+
+	var autoBackup *AutoBackup
+	backupDir, backupBucket := os.Getenv("EVENT_SIGNUP_BACKUP_DIR"), os.Getenv("EVENT_SIGNUP_BACKUP_S3_BUCKET")
+	if backupDir != "" || backupBucket != "" {
+		schedule := os.Getenv("EVENT_SIGNUP_BACKUP_SCHEDULE")
+		if schedule == "" {
+			schedule = defaultBackupSchedule
+		}
+		cron, err := ParseCron(schedule)
+		if err != nil {
+			log.Fatalf("EVENT_SIGNUP_BACKUP_SCHEDULE: %v", err)
+		}
+		keep, err := parseBackupKeep(os.Getenv("EVENT_SIGNUP_BACKUP_KEEP"))
+		if err != nil {
+			log.Fatalf("EVENT_SIGNUP_BACKUP_KEEP: %v", err)
+		}
+		autoBackup = &AutoBackup{Schedule: cron, Keep: keep}
+		if backupBucket != "" {
+			store, err := NewS3Store(context.Background(), backupBucket, os.Getenv("EVENT_SIGNUP_BACKUP_S3_PREFIX"), s3PathStyle)
+			if err != nil {
+				log.Fatalf("Failed to initialize S3 backups: %v", err)
+			}
+			autoBackup.Store = store
+		} else {
+			autoBackup.Store = DirStore{Dir: backupDir}
+		}
+		log.Printf("Backups: %s at %q, keeping %d", autoBackup.Store, schedule, keep)
+	}
+
+	var replica *Replica
+	if bucket := os.Getenv("EVENT_SIGNUP_REPLICA_S3_BUCKET"); bucket != "" {
+		interval := defaultReplicaInterval
+		if raw := os.Getenv("EVENT_SIGNUP_REPLICA_INTERVAL"); raw != "" {
+			d, err := time.ParseDuration(raw)
+			if err != nil || d < time.Second {
+				log.Fatalf("EVENT_SIGNUP_REPLICA_INTERVAL: invalid duration %q", raw)
+			}
+			interval = d
+		}
+		store, err := NewS3Store(context.Background(), bucket, os.Getenv("EVENT_SIGNUP_REPLICA_S3_PREFIX"), s3PathStyle)
+		if err != nil {
+			log.Fatalf("Failed to initialize S3 replication: %v", err)
+		}
+		replica = &Replica{Store: store, Interval: interval}
+		// Before the database is opened, which would create an empty one.
+		restored, err := restoreReplica(context.Background(), store, dbPath)
+		if err != nil {
+			log.Fatalf("Failed to restore %s from the replica in %s: %v", dbPath, store, err)
+		}
+		if restored {
+			log.Printf("Replication: %s was missing, restored from %s", dbPath, store)
+		}
+		log.Printf("Replication: to %s, checking for changes every %s", store, interval)
+	}
+
 	emailFrom := os.Getenv("EVENT_SIGNUP_EMAIL_FROM")
 	emailFromName := os.Getenv("EVENT_SIGNUP_EMAIL_FROM_NAME")
 	emailConfigSet := os.Getenv("EVENT_SIGNUP_SES_CONFIGURATION_SET")
+	smtpHost := os.Getenv("EVENT_SIGNUP_SMTP_HOST")
 	var emailSender EmailSender
-	if emailFrom != "" {
+	if smtpHost != "" {
+		if emailFrom == "" {
+			log.Fatal("EVENT_SIGNUP_EMAIL_FROM is required when EVENT_SIGNUP_SMTP_HOST is set")
+		}
+		smtpPort := os.Getenv("EVENT_SIGNUP_SMTP_PORT")
+		if smtpPort == "" {
+			smtpPort = "587"
+		}
+		emailSender = NewSMTPSender(smtpHost, smtpPort,
+			os.Getenv("EVENT_SIGNUP_SMTP_USERNAME"), os.Getenv("EVENT_SIGNUP_SMTP_PASSWORD"),
+			emailFrom, emailFromName)
+		log.Printf("Email: SMTP %s:%s (from %s)", smtpHost, smtpPort, emailFrom)
+	} else if emailFrom != "" {
 		s, err := NewSESSender(context.Background(), emailFrom, emailFromName, emailConfigSet)
 		if err != nil {
 			log.Fatalf("Failed to initialize SES: %v", err)
@@ -100,26 +204,54 @@ func main() {
 		}
 	}
 
-	// LogSender writes to stdout — no remote rate limit to respect, so fire
-	// instantly. Speeds up local dev when sending invites to a long list.
-	var emailDelay time.Duration
-	if emailFrom != "" {
-		emailDelay = time.Second / time.Duration(emailRate)
-	}
-
 	db, err := InitDB(dbPath)
 	if err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
 	defer db.Close()
 
+	// An SMTP relay saved on the admin settings page overrides the above.
+	smtpSettings, _ := GetSMTPSettings(db)
+	if smtpSettings.Configured() {
+		log.Printf("Email: SMTP %s:%s from admin settings (overrides environment)", smtpSettings.Host, smtpSettings.Port)
+	}
+	emailSender = &SettingsSender{DB: db, Fallback: emailSender}
+
+	// So do the site title, language, base URL, AI key and theme saved there.
+	if err := loadInstanceSettings(db); err != nil {
+		log.Fatalf("Failed to load instance settings: %v", err)
+	}
+
+	// LogSender writes to stdout — no remote rate limit to respect, so fire
+	// instantly. Speeds up local dev when sending invites to a long list.
+	var emailDelay time.Duration
+	if emailFrom != "" || smtpSettings.Configured() {
+		emailDelay = time.Second / time.Duration(emailRate)
+	}
+
 	app := &App{
 		DB:             db,
 		AdminPassword:  adminPassword,
 		AnthropicKey:   anthropicKey,
+		OIDC:           oidc,
+		Captcha:        captcha,
+		AutoBackup:     autoBackup,
+		Replica:        replica,
 		Email:          emailSender,
 		EmailSendDelay: emailDelay,
 		AsyncEmail:     true,
+		outboxWake:     make(chan struct{}, 1),
+	}
+	go app.runOutboxWorker(context.Background())
+	go app.runTrashWorker(context.Background())
+	go app.runMaintenanceWorker(context.Background())
+	go app.runRetentionWorker(context.Background())
+	go app.runOpeningNoticeWorker(context.Background())
+	if autoBackup != nil {
+		go app.runBackupWorker(context.Background())
+	}
+	if app.Replica != nil {
+		go app.runReplicaWorker(context.Background())
 	}
 
 	mux := http.NewServeMux()
@@ -128,53 +260,157 @@ func main() {
 	staticSub, _ := fs.Sub(staticFS, "static")
 	mux.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.FS(staticSub))))
 
+	// Offline support (PWA) — served from the root so the worker's scope
+	// covers the public event pages.
+	mux.HandleFunc("/manifest.webmanifest", app.handleManifest)
+	mux.HandleFunc("/sw.js", app.handleServiceWorker)
+
+	// Search engines
+	mux.HandleFunc("/robots.txt", app.handleRobots)
+	mux.HandleFunc("/sitemap.xml", app.handleSitemap)
+
 	// Language switch
 	mux.HandleFunc("/lang", app.handleLangSwitch)
 
 	// Admin routes
 	mux.HandleFunc("/admin/login", app.handleAdminLogin)
+	mux.HandleFunc("/admin/setup", app.handleAdminSetup)
+	mux.HandleFunc("/admin/login/email", app.handleAdminLoginEmail)
+	mux.HandleFunc("/admin/login/link", app.handleAdminLoginLink)
+	mux.HandleFunc("/admin/login/oidc", app.handleAdminLoginOIDC)
+	mux.HandleFunc("/admin/login/oidc/callback", app.handleAdminLoginOIDCCallback)
 	mux.HandleFunc("/admin/logout", app.handleAdminLogout)
+	mux.HandleFunc("/admin/logout/all", app.requireAdmin(app.handleAdminLogoutAll))
+	mux.HandleFunc("/admin/sessions/revoke", app.requireAdmin(app.handleAdminSessionRevoke))
 	mux.HandleFunc("/admin", app.requireAdmin(app.handleAdminEvents))
 	mux.HandleFunc("/admin/event/new", app.requireAdmin(app.handleAdminEventNew))
 	mux.HandleFunc("/admin/event/edit", app.requireAdmin(app.handleAdminEventEdit))
 	mux.HandleFunc("/admin/event/delete", app.requireAdmin(app.handleAdminEventDelete))
+	mux.HandleFunc("/admin/event/duplicate", app.requireAdmin(app.handleAdminEventDuplicate))
+	mux.HandleFunc("/admin/event/publish", app.requireAdmin(app.handleAdminEventPublish))
+	mux.HandleFunc("/admin/event/slug", app.requireAdmin(app.handleAdminEventSlug))
+	mux.HandleFunc("/admin/event/qr", app.requireAdmin(app.handleAdminEventQR))
+	mux.HandleFunc("/admin/event/shortlink", app.requireAdmin(app.handleAdminShortLink))
+	mux.HandleFunc("/admin/event/theme", app.requireAdmin(app.handleAdminEventTheme))
+	mux.HandleFunc("/admin/event/invites", app.requireAdmin(app.handleAdminInvites))
+	mux.HandleFunc("/admin/templates", app.requireAdmin(app.handleAdminTemplates))
+	mux.HandleFunc("/admin/templates/save", app.requireAdmin(app.handleAdminTemplateSave))
+	mux.HandleFunc("/admin/templates/rename", app.requireAdmin(app.handleAdminTemplateRename))
+	mux.HandleFunc("/admin/templates/delete", app.requireAdmin(app.handleAdminTemplateDelete))
+	mux.HandleFunc("/admin/event/fields/save", app.requireAdmin(app.handleAdminFormFieldSave))
+	mux.HandleFunc("/admin/event/fields/delete", app.requireAdmin(app.handleAdminFormFieldDelete))
+	mux.HandleFunc("/admin/event/rules/save", app.requireAdmin(app.handleAdminTaskRuleSave))
+	mux.HandleFunc("/admin/event/rules/delete", app.requireAdmin(app.handleAdminTaskRuleDelete))
 	mux.HandleFunc("/admin/groups/save", app.requireAdmin(app.handleAdminGroupSave))
 	mux.HandleFunc("/admin/groups/delete", app.requireAdmin(app.handleAdminGroupDelete))
 	mux.HandleFunc("/admin/tasks/save", app.requireAdmin(app.handleAdminTaskSave))
 	mux.HandleFunc("/admin/tasks/delete", app.requireAdmin(app.handleAdminTaskDelete))
 	mux.HandleFunc("/admin/registrations/delete", app.requireAdmin(app.handleAdminRegistrationDelete))
+	mux.HandleFunc("/admin/registrations/bulk", app.requireAdmin(app.handleAdminRegistrationsBulk))
+	mux.HandleFunc("/admin/registrations/move", app.requireAdmin(app.handleAdminRegistrationMove))
+	mux.HandleFunc("/admin/registrations/add", app.requireAdmin(app.handleAdminRegistrationAdd))
 	mux.HandleFunc("/admin/export", app.requireAdmin(app.handleAdminExportCSV))
+	mux.HandleFunc("/admin/export/pdf", app.requireAdmin(app.handleAdminExportPDF))
 
 	mux.HandleFunc("/admin/clear-all", app.requireAdmin(app.handleAdminClearAll))
+	mux.HandleFunc("/admin/event/history", app.requireAdmin(app.handleAdminStructureHistory))
+	mux.HandleFunc("/admin/event/history/rollback", app.requireAdmin(app.handleAdminStructureRollback))
 
 	// Registrations page
 	mux.HandleFunc("/admin/event/registrations", app.requireAdmin(app.handleAdminRegistrations))
+	mux.HandleFunc("/admin/event/dietary", app.requireAdmin(app.handleAdminDietary))
+	mux.HandleFunc("/admin/event/skills", app.requireAdmin(app.handleAdminSkills))
+	mux.HandleFunc("/admin/event/assign", app.requireAdmin(app.handleAdminAssign))
+	mux.HandleFunc("/admin/event/checkin", app.requireAdmin(app.handleAdminCheckIn))
+	mux.HandleFunc("/admin/event/checkin/scan", app.requireAdmin(app.handleAdminCheckInScan))
+	mux.HandleFunc("/admin/event/checkin/status", app.requireAdmin(app.handleAdminCheckInStatus))
+	mux.HandleFunc("/admin/event/checkin/list", app.requireAdmin(app.handleAdminCheckInList))
+	mux.HandleFunc("/admin/event/today", app.requireAdmin(app.handleAdminDayOf))
+	mux.HandleFunc("/admin/event/today/checkin", app.requireAdmin(app.handleAdminDayOfCheckIn))
+	mux.HandleFunc("/admin/event/today/covered", app.requireAdmin(app.handleAdminDayOfCovered))
+	mux.HandleFunc("/admin/event/today/status", app.requireAdmin(app.handleAdminDayOfStatus))
 	mux.HandleFunc("/admin/event/attendances", app.requireAdmin(app.handleAdminAttendances))
 	mux.HandleFunc("/admin/attendances/delete", app.requireAdmin(app.handleAdminAttendanceDelete))
+	mux.HandleFunc("/admin/event/terms", app.requireAdmin(app.handleAdminTerms))
+	mux.HandleFunc("/admin/outbox", app.requireAdmin(app.handleAdminOutbox))
+	mux.HandleFunc("/admin/outbox/retry", app.requireAdmin(app.handleAdminOutboxRetry))
+	mux.HandleFunc("/admin/review", app.requireAdmin(app.handleAdminReview))
+	mux.HandleFunc("/admin/understaffed", app.requireAdmin(app.handleAdminUnderstaffed))
+	mux.HandleFunc("/admin/volunteers", app.requireAdmin(app.handleAdminVolunteerReport))
+	mux.HandleFunc("/admin/review/approve", app.requireAdmin(app.handleAdminReviewApprove))
+	mux.HandleFunc("/admin/review/reject", app.requireAdmin(app.handleAdminReviewReject))
+	mux.HandleFunc("/admin/trash", app.requireAdmin(app.handleAdminTrash))
+	mux.HandleFunc("/admin/trash/restore", app.requireAdmin(app.handleAdminTrashRestore))
+	mux.HandleFunc("/admin/trash/purge", app.requireAdmin(app.handleAdminTrashPurge))
+	mux.HandleFunc("/admin/settings", app.requireAdmin(app.handleAdminSettings))
+	mux.HandleFunc("/admin/settings/test", app.requireAdmin(app.handleAdminSettingsTest))
+	mux.HandleFunc("/admin/settings/feed", app.requireAdmin(app.handleAdminSettingsFeed))
+	mux.HandleFunc("/admin/settings/password", app.requireAdmin(app.handleAdminPasswordChange))
+	mux.HandleFunc("/admin/settings/login-emails", app.requireAdmin(app.handleAdminSettingsLoginEmails))
+	mux.HandleFunc("/admin/settings/signup-limits", app.requireAdmin(app.handleAdminSettingsSignupLimits))
+	mux.HandleFunc("/admin/settings/retention", app.requireAdmin(app.handleAdminSettingsRetention))
+	mux.HandleFunc("/admin/settings/instance", app.requireAdmin(app.handleAdminSettingsInstance))
+	mux.HandleFunc("/admin/backup", app.requireAdmin(app.handleAdminBackup))
+	mux.HandleFunc("/admin/backup/download", app.requireAdmin(app.handleAdminBackupDownload))
+	mux.HandleFunc("/admin/backup/restore", app.requireAdmin(app.handleAdminBackupRestore))
+	mux.HandleFunc("/admin/backup/run", app.requireAdmin(app.handleAdminBackupRun))
+	mux.HandleFunc("/admin/maintenance/run", app.requireAdmin(app.handleAdminMaintenanceRun))
+	mux.HandleFunc("/admin/settings/api-tokens", app.requireAdmin(app.handleAdminAPITokenCreate))
+	mux.HandleFunc("/admin/settings/api-tokens/revoke", app.requireAdmin(app.handleAdminAPITokenRevoke))
+	// Token-protected instead of session-protected: calendar apps can't log in.
+	mux.HandleFunc("/admin/feed.ics", app.handleAdminFeed)
+	mux.HandleFunc("/admin/outbox/delete", app.requireAdmin(app.handleAdminOutboxDelete))
+	mux.HandleFunc("/admin/event/stats-link", app.requireAdmin(app.handleAdminStatsLink))
+	mux.HandleFunc("/admin/series", app.requireAdmin(app.handleAdminSeries))
+	mux.HandleFunc("/admin/series/create", app.requireAdmin(app.handleAdminSeriesCreate))
+	mux.HandleFunc("/admin/series/propagate", app.requireAdmin(app.handleAdminSeriesPropagate))
+	mux.HandleFunc("/admin/event/poll-import", app.requireAdmin(app.handleAdminPollImport))
+	mux.HandleFunc("/admin/event/sheet-import", app.requireAdmin(app.handleAdminSheetImport))
+	mux.HandleFunc("/admin/event/sheet-import/apply", app.requireAdmin(app.handleAdminSheetImportApply))
 
 	// JSON APIs
-	mux.HandleFunc("/admin/api/reorder", app.requireAdmin(app.handleAPIReorder))
-	mux.HandleFunc("/admin/api/max-slots", app.requireAdmin(app.handleAPIUpdateMaxSlots))
-	mux.HandleFunc("/admin/api/ai-parse", app.requireAdmin(app.handleAdminAIParse))
-	mux.HandleFunc("/admin/api/event/save", app.requireAdmin(app.handleAPIEventSave))
-	mux.HandleFunc("/admin/api/event/email-preview", app.requireAdmin(app.handleAPIEventEmailPreview))
-	mux.HandleFunc("/admin/api/group/create", app.requireAdmin(app.handleAPIGroupCreate))
-	mux.HandleFunc("/admin/api/group/save", app.requireAdmin(app.handleAPIGroupSave))
-	mux.HandleFunc("/admin/api/group/delete", app.requireAdmin(app.handleAPIGroupDelete))
-	mux.HandleFunc("/admin/api/task/create", app.requireAdmin(app.handleAPITaskCreate))
-	mux.HandleFunc("/admin/api/task/save", app.requireAdmin(app.handleAPITaskSave))
-	mux.HandleFunc("/admin/api/task/delete", app.requireAdmin(app.handleAPITaskDelete))
+	mux.HandleFunc("/admin/api/events", app.requireAPI(app.handleAPIEvents))
+	mux.HandleFunc("/admin/api/registrations", app.requireAPI(app.handleAPIRegistrations))
+	mux.HandleFunc("/admin/api/registration/move", app.requireAPI(app.handleAPIRegistrationMove))
+	mux.HandleFunc("/admin/api/reorder", app.requireAPI(app.handleAPIReorder))
+	mux.HandleFunc("/admin/api/max-slots", app.requireAPI(app.handleAPIUpdateMaxSlots))
+	mux.HandleFunc("/admin/api/max-slots/bulk", app.requireAPI(app.handleAPIBulkMaxSlots))
+	mux.HandleFunc("/admin/api/ai-parse", app.requireAPI(app.handleAdminAIParse))
+	mux.HandleFunc("/admin/api/event/save", app.requireAPI(app.handleAPIEventSave))
+	mux.HandleFunc("/admin/api/event/email-preview", app.requireAPI(app.handleAPIEventEmailPreview))
+	mux.HandleFunc("/admin/api/group/create", app.requireAPI(app.handleAPIGroupCreate))
+	mux.HandleFunc("/admin/api/group/save", app.requireAPI(app.handleAPIGroupSave))
+	mux.HandleFunc("/admin/api/group/delete", app.requireAPI(app.handleAPIGroupDelete))
+	mux.HandleFunc("/admin/api/group/duplicate", app.requireAPI(app.handleAPIDuplicate("group")))
+	mux.HandleFunc("/admin/api/task/create", app.requireAPI(app.handleAPITaskCreate))
+	mux.HandleFunc("/admin/api/task/save", app.requireAPI(app.handleAPITaskSave))
+	mux.HandleFunc("/admin/api/task/delete", app.requireAPI(app.handleAPITaskDelete))
+	mux.HandleFunc("/admin/api/task/duplicate", app.requireAPI(app.handleAPIDuplicate("task")))
+	mux.HandleFunc(apiV1Prefix, app.requireAPI(app.handleAPIV1()))
+	mux.HandleFunc("/admin/api/graphql", app.requireReadAPI(app.handleAPIGraphQL))
 
 	// Public API
 	mux.HandleFunc("/api/slots", app.handleAPISlots)
+	mux.HandleFunc("/api/email-check", app.handleAPIEmailCheck)
+	mux.HandleFunc("/api/volunteer", app.handleAPIVolunteer)
+	mux.HandleFunc("/api/event", app.handleAPIPublicEvent)
 
 	// Public routes
 	mux.HandleFunc("/webhooks/ses", app.handleSESWebhook)
 	mux.HandleFunc("/e/", app.handlePublicEvent)
+	mux.HandleFunc("/s/", app.handleShortLink)
 	mux.HandleFunc("/signup", app.handlePublicSignup)
+	mux.HandleFunc("/preferences", app.handlePublicPreferences)
+	mux.HandleFunc("/notify-opening", app.handleOpeningNotice)
 	mux.HandleFunc("/rsvp", app.handlePublicRSVP)
 	mux.HandleFunc("/rsvp/lookup", app.handlePublicRSVPLookup)
 	mux.HandleFunc("/cancel/", app.handlePublicCancel)
+	mux.HandleFunc("/ics/", app.handlePublicICS)
+	mux.HandleFunc("/qr/", app.handleRegistrationQR)
+	mux.HandleFunc("/my", app.handleMyRegistrations)
+	mux.HandleFunc("/my/", app.handleMyRegistrationsLink)
+	mux.HandleFunc("/unsubscribe", app.handleUnsubscribe)
+	mux.HandleFunc("/stats/", app.handlePublicStats)
 	mux.HandleFunc("/santa/register", app.handleSantaRegister)
 	mux.HandleFunc("/santa/edit", app.handleSantaEdit)
 	mux.HandleFunc("/admin/santa/draw", app.requireAdmin(app.handleAdminSantaDraw))
@@ -187,20 +423,15 @@ func main() {
 	mux.HandleFunc("/dev/emails", app.requireAdmin(app.handleDevEmailIndex))
 	mux.HandleFunc("/dev/emails/santa-link", app.requireAdmin(app.handleDevEmailSantaLink))
 	mux.HandleFunc("/dev/emails/santa-reveal", app.requireAdmin(app.handleDevEmailSantaReveal))
+	mux.HandleFunc("/dev/emails/signup-confirmation", app.requireAdmin(app.handleDevEmailSignupConfirmation))
 
-	// Root redirect
-	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Path == "/" {
-			http.Redirect(w, r, "/admin", http.StatusSeeOther)
-			return
-		}
-		http.NotFound(w, r)
-	})
+	// Root redirect — to the event page on a vanity domain, else the admin
+	mux.HandleFunc("/", app.handleRoot)
 
 	addr := ":" + port
 	log.Printf("Starting server on %s", addr)
 	log.Printf("Admin: http://localhost:%s/admin", port)
-	if err := http.ListenAndServe(addr, mux); err != nil {
+	if err := http.ListenAndServe(addr, app.csrfProtect(mux)); err != nil {
 		log.Fatalf("Server error: %v", err)
 	}
 }
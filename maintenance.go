@@ -0,0 +1,153 @@
+package main
+
+// Database maintenance. Once a day, and from the dashboard on demand, the
+// write-ahead log is checkpointed into the database file, the file is
+// compacted with VACUUM, the query planner's statistics are refreshed with
+// ANALYZE and the whole database is checked with integrity_check. The report
+// of the last run is kept in the settings table for the dashboard.
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	maintenanceInterval      = 24 * time.Hour
+	maintenanceCheckInterval = time.Hour
+)
+
+// MaintenanceReport is the outcome of a maintenance run.
+type MaintenanceReport struct {
+	At         time.Time `json:"at"`
+	SizeBefore int64     `json:"size_before"` // bytes
+	SizeAfter  int64     `json:"size_after"`
+	Problems   []string  `json:"problems,omitempty"` // integrity_check findings
+	Error      string    `json:"error,omitempty"`    // the step that failed
+}
+
+// OK reports whether every step ran and the database is intact.
+func (m MaintenanceReport) OK() bool { return m.Error == "" && len(m.Problems) == 0 }
+
+func dbSize(ctx context.Context, db *sql.DB) int64 {
+	var pages, pageSize int64
+	db.QueryRowContext(ctx, "PRAGMA page_count").Scan(&pages)
+	db.QueryRowContext(ctx, "PRAGMA page_size").Scan(&pageSize)
+	return pages * pageSize
+}
+
+// RunMaintenance runs the maintenance steps on db. The log is checkpointed
+// again at the end, VACUUM having written the whole database through it.
+func RunMaintenance(ctx context.Context, db *sql.DB) MaintenanceReport {
+	m := MaintenanceReport{At: time.Now().UTC(), SizeBefore: dbSize(ctx, db)}
+	for _, q := range []string{"PRAGMA wal_checkpoint(TRUNCATE)", "VACUUM", "ANALYZE", "PRAGMA wal_checkpoint(TRUNCATE)"} {
+		if _, err := db.ExecContext(ctx, q); err != nil {
+			m.Error = fmt.Sprintf("%s: %v", q, err)
+			return m
+		}
+	}
+	m.SizeAfter = dbSize(ctx, db)
+	rows, err := db.QueryContext(ctx, "PRAGMA integrity_check")
+	if err != nil {
+		m.Error = fmt.Sprintf("PRAGMA integrity_check: %v", err)
+		return m
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			m.Error = fmt.Sprintf("PRAGMA integrity_check: %v", err)
+			return m
+		}
+		if line != "ok" {
+			m.Problems = append(m.Problems, line)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		m.Error = fmt.Sprintf("PRAGMA integrity_check: %v", err)
+	}
+	return m
+}
+
+// runMaintenance runs maintenance and saves its report.
+func (app *App) runMaintenance(ctx context.Context) MaintenanceReport {
+	m := RunMaintenance(ctx, app.DB)
+	switch {
+	case m.Error != "":
+		log.Printf("maintenance: %s", m.Error)
+	case len(m.Problems) > 0:
+		log.Printf("maintenance: integrity check: %s", strings.Join(m.Problems, "; "))
+	default:
+		log.Printf("maintenance: done, %d bytes → %d", m.SizeBefore, m.SizeAfter)
+	}
+	if b, err := json.Marshal(m); err == nil {
+		if err := SetSetting(app.DB, "maintenance_last", string(b)); err != nil {
+			log.Printf("maintenance: save report: %v", err)
+		}
+	}
+	return m
+}
+
+// lastMaintenance returns the report of the last run, or nil if there was
+// none.
+func lastMaintenance(db *sql.DB) *MaintenanceReport {
+	var m MaintenanceReport
+	if err := json.Unmarshal([]byte(GetSetting(db, "maintenance_last")), &m); err != nil {
+		return nil
+	}
+	m.At = m.At.Local()
+	return &m
+}
+
+// runMaintenanceWorker runs maintenance once a day until ctx is done. The
+// time of the last run is checked every hour rather than timing a whole day,
+// so restarts don't keep putting it off.
+func (app *App) runMaintenanceWorker(ctx context.Context) {
+	ticker := time.NewTicker(maintenanceCheckInterval)
+	defer ticker.Stop()
+	for {
+		if last := lastMaintenance(app.DB); last == nil || time.Since(last.At) >= maintenanceInterval {
+			app.runMaintenance(ctx)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// formatSize spells out a size in bytes, in kB or MB.
+func formatSize(n int64, lang string) string {
+	unit, v := "kB", float64(n)/1000
+	if v >= 1000 {
+		unit, v = "MB", v/1000
+	}
+	s := fmt.Sprintf("%.1f", v)
+	if lang == LangFR {
+		s = strings.Replace(s, ".", ",", 1)
+		unit = map[string]string{"kB": "ko", "MB": "Mo"}[unit]
+	}
+	return s + " " + unit
+}
+
+// handleAdminMaintenanceRun runs maintenance right away.
+func (app *App) handleAdminMaintenanceRun(w http.ResponseWriter, r *http.Request) {
+	lang := LangFromRequest(r)
+	adminURL := "/admin?lang=" + lang
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, adminURL, http.StatusSeeOther)
+		return
+	}
+	if m := app.runMaintenance(r.Context()); m.OK() {
+		setFlash(w, "success", T("maintenance_done", lang))
+	} else {
+		setFlash(w, "error", T("maintenance_problem", lang))
+	}
+	http.Redirect(w, r, adminURL, http.StatusSeeOther)
+}
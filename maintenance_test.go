@@ -0,0 +1,51 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMaintenance(t *testing.T) {
+	app := testApp(t)
+	seedEvent(t, app.DB)
+	mux := newMux(app)
+	admin := adminCookie(app)
+	if body := getRequest(mux, "/admin?lang=en", admin).Body.String(); !strings.Contains(body, T("maintenance_last", "en")+" "+T("autobackup_never", "en")) {
+		t.Error("dashboard does not say maintenance never ran")
+	}
+
+	w := postForm(mux, "/admin/maintenance/run?lang=en", nil, admin)
+	body := followRedirect(mux, w, admin).Body.String()
+	if !strings.Contains(body, T("maintenance_done", "en")) {
+		t.Error("running maintenance did not report success")
+	}
+	m := lastMaintenance(app.DB)
+	if m == nil || !m.OK() || m.SizeAfter == 0 {
+		t.Fatalf("report = %+v", m)
+	}
+	if !strings.Contains(body, formatSize(m.SizeAfter, "en")) {
+		t.Error("dashboard does not show the database size")
+	}
+
+	// A failed run shows its problems on the dashboard.
+	SetSetting(app.DB, "maintenance_last", `{"at":"2026-10-16T03:00:00Z","problems":["row 3 missing from index idx_tasks_event"]}`)
+	if body := getRequest(mux, "/admin?lang=en", admin).Body.String(); !strings.Contains(body, "row 3 missing from index idx_tasks_event") {
+		t.Error("dashboard does not show integrity problems")
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	cases := []struct {
+		n          int64
+		lang, want string
+	}{
+		{4096, LangEN, "4.1 kB"},
+		{2_500_000, LangEN, "2.5 MB"},
+		{2_500_000, LangFR, "2,5 Mo"},
+	}
+	for _, c := range cases {
+		if got := formatSize(c.n, c.lang); got != c.want {
+			t.Errorf("formatSize(%d, %s) = %q, want %q", c.n, c.lang, got, c.want)
+		}
+	}
+}
@@ -4,15 +4,18 @@ import (
 	"crypto/rand"
 	"database/sql"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
+	"math"
 	mrand "math/rand"
 	"regexp"
 	"sort"
 	"strings"
 	"time"
 
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/mattn/go-sqlite3"
 )
 
 // htmlTagPattern matches the start of an HTML tag (`<` immediately followed
@@ -29,8 +32,79 @@ type Event struct {
 	DescriptionEN string
 	EventDate     string
 	EventTime     string
-	EventType     string // "tasks", "attendance" or "secret_santa"
-	SantaDrawnAt  sql.NullString
+	// EndDate and EndTime are the optional end; an empty EndDate means the
+	// event ends on EventDate. See EndDay and Duration.
+	EndDate      string
+	EndTime      string
+	EventType    string // "tasks", "attendance" or "secret_santa"
+	SantaDrawnAt sql.NullString
+	// BaseURL is the vanity scheme://host the event is promoted under, used
+	// for every absolute link generated for it. Empty means "the host the
+	// request came in on".
+	BaseURL string
+	// StatsToken unlocks the read-only stats dashboard at /stats/<token>.
+	// Empty means sharing is off.
+	StatsToken string
+	// Terms or liability waiver volunteers must accept to sign up. Empty
+	// in both languages means none. TermsVersion increases once the text
+	// changes after someone accepted it; see UpdateEvent.
+	TermsFR      string
+	TermsEN      string
+	TermsVersion int
+	// NotifyEmails is a comma-separated list of admin addresses emailed on
+	// every new registration or RSVP. Empty means notifications are off.
+	NotifyEmails string
+	// OrganizerEmail is set as Reply-To on every email sent for the event,
+	// so registrants' replies reach the organizer. Empty means no Reply-To.
+	OrganizerEmail string
+	// Timezone is the IANA zone (e.g. "Europe/Paris") EventDate/EventTime
+	// are expressed in. Empty means unspecified: times are shown as typed.
+	Timezone string
+	// CancelDays is how many days after the event's last day cancellation
+	// links keep working (0: until the end of that day). NULL means they
+	// never expire. See cancel.go.
+	CancelDays sql.NullInt64
+	// RetentionMonths is how many months after the event's last day its
+	// registrants' personal data is erased, overriding the instance setting
+	// (0: kept). NULL means the instance setting applies. See retention.go.
+	RetentionMonths sql.NullInt64
+	// AnonymizedAt is when that personal data was erased.
+	AnonymizedAt sql.NullString
+	// RegistrationOpensAt and RegistrationClosesAt bound when people can sign
+	// up, as "2006-01-02T15:04" in the event's time zone. Empty means no
+	// bound. See registration_window.go.
+	RegistrationOpensAt  string
+	RegistrationClosesAt string
+	// AccessCode lets those who have it sign up before registration opens
+	// and take the slots tasks reserve. Empty means none. See access_code.go.
+	AccessCode string
+	// MaxTasksPerPerson is how many of the event's tasks one email address
+	// can sign up for.
+	MaxTasksPerPerson int
+	// MaxParticipants caps how many people take part in the event, all
+	// tasks together. NULL means no cap. See event_capacity.go.
+	MaxParticipants sql.NullInt64
+	// CollectDiet asks registrants for their diet and allergies. See
+	// dietary.go.
+	CollectDiet bool
+	// CountsOnly shows only how many people signed up for each task on the
+	// public page and API, never their names.
+	CountsOnly bool
+	// CheckIn gives each registration a QR code that organizers scan at the
+	// door. See checkin.go.
+	CheckIn bool
+	// AssignMode has people rank the tasks they'd like instead of picking
+	// one; organizers then assign them all at once. See assignment.go.
+	AssignMode bool
+	// FamilySignup lets one signup add other people — a family — each with
+	// their own task, under the contact's email. See family.go.
+	FamilySignup bool
+	// Draft keeps the event off the public site, which shows "coming soon"
+	// at its address, until an organizer publishes it. See publish.go.
+	Draft bool
+	// Notes are the organizers' own — supplier contacts, key codes,
+	// reminders — shown in the admin only, never on the public site.
+	Notes string
 	// Per-event overrides for the magic-link email. Empty means "use the
 	// i18n default" for the participant's language.
 	EmailHookFR       string
@@ -47,10 +121,33 @@ type Event struct {
 	EmailButtonEN     string
 	EmailDisclaimerFR string
 	EmailDisclaimerEN string
-	CreatedAt         time.Time
-	RegCount          int
-	AttendanceYes     int
-	AttendanceNo      int
+	// Lang is the only language of a single-language event, whose texts are
+	// the French fields and whose public pages show in Lang alone. Empty
+	// means both languages. See event_lang.go.
+	Lang string
+	// NoIndex asks search engines to leave the event's public pages out of
+	// their results, and keeps it off the sitemap. See sitemap.go.
+	NoIndex bool
+	// InviteOnly keeps the event's public pages to those with one of its
+	// invite links. See invite.go.
+	InviteOnly bool
+	// MaxGuests is how many people, adults and children together, someone
+	// answering yes to an attendance event may bring along; 0 means none.
+	MaxGuests int
+	// Texts shown to people once signed up, on the page and in their
+	// confirmation email, in Markdown. Empty means none. See after_signup.go.
+	AfterInfoFR    string
+	AfterInfoEN    string
+	AfterBringFR   string
+	AfterBringEN   string
+	AfterContactFR string
+	AfterContactEN string
+	// SeriesID is set on every occurrence of a recurring event.
+	SeriesID      sql.NullInt64
+	CreatedAt     time.Time
+	RegCount      int
+	AttendanceYes int
+	AttendanceNo  int
 }
 
 type TaskGroup struct {
@@ -60,6 +157,13 @@ type TaskGroup struct {
 	TitleFR       string
 	TitleEN       string
 	Position      int
+	// MaxSlots caps the people signed up across all the group's tasks,
+	// subgroups' included.
+	MaxSlots sql.NullInt64
+	// Color ("#rrggbb") and Icon (an emoji) mark the group out, both empty
+	// when unset. See group_style.go.
+	Color string
+	Icon  string
 }
 
 type Task struct {
@@ -71,7 +175,25 @@ type Task struct {
 	DescriptionFR string
 	DescriptionEN string
 	MaxSlots      sql.NullInt64
-	Position      int
+	// StartTime and EndTime ("HH:MM", empty when unset) make the task a
+	// shift on the event's day.
+	StartTime string
+	EndTime   string
+	Position  int
+	// MaxGuests is how many people someone signing up may bring along; 0
+	// keeps signups to one person.
+	MaxGuests int
+	// MinSlots is how many people the task needs to go ahead, guests
+	// included; 0 means no minimum. See staffing.go.
+	MinSlots int
+	// Skills are the tags of what the task needs, comma-separated. See
+	// skills.go.
+	Skills string
+	// ReservedSlots are those of MaxSlots kept for people with the event's
+	// access code. See access_code.go.
+	ReservedSlots int
+	// Notes are the organizers' own, shown in the admin only.
+	Notes string
 }
 
 type Registration struct {
@@ -81,15 +203,23 @@ type Registration struct {
 	LastName  string
 	Email     string
 	Phone     string
+	Lang      string // language the person registered in; drives follow-up emails
 	Token     string
+	Guests    int    // people coming along, each taking a slot too
+	Comment   string // optional note left for the organizers
+	Diet      string // see dietOptions
+	Allergies string
 	CreatedAt time.Time
 }
 
 type TaskView struct {
 	Task
-	RegCount      int
+	RegCount      int // people signed up, guests included
 	SlotsLeft     int // -1 means unlimited
 	IsFull        bool
+	GuestsLeft    int      // how many guests a new registrant can still bring
+	StillNeeded   int      // people missing to reach MinSlots
+	PublicNames   []string // first names of those who agreed to show them
 	Registrations []Registration
 }
 
@@ -99,6 +229,10 @@ type TreeNode struct {
 	Group    *TaskGroup // non-nil if Type == "group"
 	Task     *TaskView  // non-nil if Type == "task"
 	Children []TreeNode // children (only meaningful for groups)
+	// For groups: people signed up across the group, and spots left when it
+	// has MaxSlots (-1 otherwise).
+	RegCount  int
+	SlotsLeft int
 }
 
 // FlatGroup is used for parent-selection dropdowns.
@@ -116,22 +250,81 @@ type ReorderNode struct {
 	Children []ReorderNode `json:"children,omitempty"`
 }
 
+// dbMaxConns caps the connection pool. In WAL mode readers neither wait for
+// nor block the writer, so page views don't queue behind writes.
+const dbMaxConns = 8
+
+// InitDB opens the database. Writers still take turns, SQLite allowing only
+// one at a time: _txlock=immediate makes a transaction take the write lock
+// when it begins, so two read-then-write transactions can't deadlock while
+// upgrading their locks, and a writer finding the lock taken waits up to
+// _busy_timeout for it instead of failing.
 func InitDB(dbPath string) (*sql.DB, error) {
-	db, err := sql.Open("sqlite3", dbPath+"?_journal_mode=WAL&_busy_timeout=5000&_foreign_keys=ON")
+	db, err := sql.Open("sqlite3", dbPath+"?_journal_mode=WAL&_busy_timeout=5000&_foreign_keys=ON&_txlock=immediate")
 	if err != nil {
 		return nil, err
 	}
-	db.SetMaxOpenConns(1)
+	db.SetMaxOpenConns(dbMaxConns)
+	db.SetMaxIdleConns(dbMaxConns)
+	if err := migrateDB(db); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
 
+// migrateDB brings db up to the current schema: on startup, and after a
+// backup is restored over it.
+func migrateDB(db *sql.DB) error {
 	// Migrations run first so that existing tables gain new columns
 	// before schema.sql tries to create indexes on them.
 	// For new DBs, migrateColumn safely no-ops when the table doesn't exist yet.
 	migrateColumn(db, "events", "event_time", "ALTER TABLE events ADD COLUMN event_time TEXT NOT NULL DEFAULT ''")
 	migrateColumn(db, "task_groups", "parent_group_id", "ALTER TABLE task_groups ADD COLUMN parent_group_id INTEGER REFERENCES task_groups(id) ON DELETE SET NULL")
+	migrateColumn(db, "tasks", "start_time", "ALTER TABLE tasks ADD COLUMN start_time TEXT NOT NULL DEFAULT ''")
+	migrateColumn(db, "tasks", "end_time", "ALTER TABLE tasks ADD COLUMN end_time TEXT NOT NULL DEFAULT ''")
+	migrateColumn(db, "tasks", "max_guests", "ALTER TABLE tasks ADD COLUMN max_guests INTEGER NOT NULL DEFAULT 0")
+	migrateColumn(db, "tasks", "min_slots", "ALTER TABLE tasks ADD COLUMN min_slots INTEGER NOT NULL DEFAULT 0")
+	migrateColumn(db, "tasks", "skills", "ALTER TABLE tasks ADD COLUMN skills TEXT NOT NULL DEFAULT ''")
+	migrateColumn(db, "volunteers", "skills", "ALTER TABLE volunteers ADD COLUMN skills TEXT NOT NULL DEFAULT ''")
 
 	// Migrate registrations: name → first_name + last_name
 	migrateColumn(db, "events", "event_type", "ALTER TABLE events ADD COLUMN event_type TEXT NOT NULL DEFAULT 'tasks'")
 	migrateColumn(db, "events", "santa_drawn_at", "ALTER TABLE events ADD COLUMN santa_drawn_at TEXT")
+	migrateColumn(db, "events", "base_url", "ALTER TABLE events ADD COLUMN base_url TEXT NOT NULL DEFAULT ''")
+	migrateColumn(db, "events", "stats_token", "ALTER TABLE events ADD COLUMN stats_token TEXT NOT NULL DEFAULT ''")
+	migrateColumn(db, "events", "terms_fr", "ALTER TABLE events ADD COLUMN terms_fr TEXT NOT NULL DEFAULT ''")
+	migrateColumn(db, "events", "terms_en", "ALTER TABLE events ADD COLUMN terms_en TEXT NOT NULL DEFAULT ''")
+	migrateColumn(db, "events", "terms_version", "ALTER TABLE events ADD COLUMN terms_version INTEGER NOT NULL DEFAULT 0")
+	migrateColumn(db, "events", "notify_emails", "ALTER TABLE events ADD COLUMN notify_emails TEXT NOT NULL DEFAULT ''")
+	migrateColumn(db, "events", "organizer_email", "ALTER TABLE events ADD COLUMN organizer_email TEXT NOT NULL DEFAULT ''")
+	migrateColumn(db, "events", "timezone", "ALTER TABLE events ADD COLUMN timezone TEXT NOT NULL DEFAULT ''")
+	migrateColumn(db, "events", "end_date", "ALTER TABLE events ADD COLUMN end_date TEXT NOT NULL DEFAULT ''")
+	migrateColumn(db, "events", "end_time", "ALTER TABLE events ADD COLUMN end_time TEXT NOT NULL DEFAULT ''")
+	migrateColumn(db, "events", "cancel_days", "ALTER TABLE events ADD COLUMN cancel_days INTEGER")
+	migrateColumn(db, "events", "series_id", "ALTER TABLE events ADD COLUMN series_id INTEGER REFERENCES event_series(id) ON DELETE SET NULL")
+	migrateColumn(db, "events", "retention_months", "ALTER TABLE events ADD COLUMN retention_months INTEGER")
+	migrateColumn(db, "events", "anonymized_at", "ALTER TABLE events ADD COLUMN anonymized_at TEXT")
+	migrateColumn(db, "events", "registration_opens_at", "ALTER TABLE events ADD COLUMN registration_opens_at TEXT NOT NULL DEFAULT ''")
+	migrateColumn(db, "events", "registration_closes_at", "ALTER TABLE events ADD COLUMN registration_closes_at TEXT NOT NULL DEFAULT ''")
+	migrateColumn(db, "events", "max_tasks_per_person", "ALTER TABLE events ADD COLUMN max_tasks_per_person INTEGER NOT NULL DEFAULT 1")
+	migrateColumn(db, "events", "collect_diet", "ALTER TABLE events ADD COLUMN collect_diet INTEGER NOT NULL DEFAULT 0")
+	migrateColumn(db, "events", "counts_only", "ALTER TABLE events ADD COLUMN counts_only INTEGER NOT NULL DEFAULT 0")
+	migrateColumn(db, "events", "checkin", "ALTER TABLE events ADD COLUMN checkin INTEGER NOT NULL DEFAULT 0")
+	migrateColumn(db, "events", "max_participants", "ALTER TABLE events ADD COLUMN max_participants INTEGER")
+	migrateColumn(db, "events", "assign_mode", "ALTER TABLE events ADD COLUMN assign_mode INTEGER NOT NULL DEFAULT 0")
+	migrateColumn(db, "events", "access_code", "ALTER TABLE events ADD COLUMN access_code TEXT NOT NULL DEFAULT ''")
+	migrateColumn(db, "events", "family_signup", "ALTER TABLE events ADD COLUMN family_signup INTEGER NOT NULL DEFAULT 0")
+	migrateColumn(db, "events", "draft", "ALTER TABLE events ADD COLUMN draft INTEGER NOT NULL DEFAULT 0")
+	migrateColumn(db, "events", "notes", "ALTER TABLE events ADD COLUMN notes TEXT NOT NULL DEFAULT ''")
+	migrateColumn(db, "events", "lang", "ALTER TABLE events ADD COLUMN lang TEXT NOT NULL DEFAULT ''")
+	migrateColumn(db, "events", "noindex", "ALTER TABLE events ADD COLUMN noindex INTEGER NOT NULL DEFAULT 0")
+	migrateColumn(db, "events", "invite_only", "ALTER TABLE events ADD COLUMN invite_only INTEGER NOT NULL DEFAULT 0")
+	migrateColumn(db, "events", "max_guests", "ALTER TABLE events ADD COLUMN max_guests INTEGER NOT NULL DEFAULT 0")
+	migrateColumn(db, "attendances", "adult_guests", "ALTER TABLE attendances ADD COLUMN adult_guests INTEGER NOT NULL DEFAULT 0")
+	migrateColumn(db, "attendances", "child_guests", "ALTER TABLE attendances ADD COLUMN child_guests INTEGER NOT NULL DEFAULT 0")
+	migrateColumn(db, "tasks", "reserved_slots", "ALTER TABLE tasks ADD COLUMN reserved_slots INTEGER NOT NULL DEFAULT 0")
+	migrateColumn(db, "tasks", "notes", "ALTER TABLE tasks ADD COLUMN notes TEXT NOT NULL DEFAULT ''")
+	migrateColumn(db, "tasks", "covered_at", "ALTER TABLE tasks ADD COLUMN covered_at TEXT")
 
 	// Per-event overrides for the magic-link email shell (empty = i18n default).
 	for _, col := range []string{
@@ -146,6 +339,15 @@ func InitDB(dbPath string) (*sql.DB, error) {
 		migrateColumn(db, "events", col,
 			fmt.Sprintf("ALTER TABLE events ADD COLUMN %s TEXT NOT NULL DEFAULT ''", col))
 	}
+	// After-signup texts (empty = none).
+	for _, col := range []string{
+		"after_info_fr", "after_info_en",
+		"after_bring_fr", "after_bring_en",
+		"after_contact_fr", "after_contact_en",
+	} {
+		migrateColumn(db, "events", col,
+			fmt.Sprintf("ALTER TABLE events ADD COLUMN %s TEXT NOT NULL DEFAULT ''", col))
+	}
 
 	migrateColumn(db, "registrations", "first_name", "ALTER TABLE registrations ADD COLUMN first_name TEXT NOT NULL DEFAULT ''")
 	migrateColumn(db, "registrations", "last_name", "ALTER TABLE registrations ADD COLUMN last_name TEXT NOT NULL DEFAULT ''")
@@ -154,16 +356,70 @@ func InitDB(dbPath string) (*sql.DB, error) {
 	// Drop the old name column so its NOT NULL constraint doesn't block new INSERTs
 	migrateDropColumn(db, "registrations", "name")
 
+	// Language each person signed up in, so follow-ups reach them in it.
+	migrateColumn(db, "registrations", "lang", "ALTER TABLE registrations ADD COLUMN lang TEXT NOT NULL DEFAULT 'fr'")
+	migrateColumn(db, "attendances", "lang", "ALTER TABLE attendances ADD COLUMN lang TEXT NOT NULL DEFAULT 'fr'")
+
+	// Which version of the event terms each registrant accepted, and when.
+	migrateColumn(db, "registrations", "terms_version", "ALTER TABLE registrations ADD COLUMN terms_version INTEGER NOT NULL DEFAULT 0")
+	migrateColumn(db, "registrations", "terms_accepted_at", "ALTER TABLE registrations ADD COLUMN terms_accepted_at TEXT")
+	migrateColumn(db, "registrations", "guests", "ALTER TABLE registrations ADD COLUMN guests INTEGER NOT NULL DEFAULT 0")
+	migrateColumn(db, "registrations", "comment", "ALTER TABLE registrations ADD COLUMN comment TEXT NOT NULL DEFAULT ''")
+	migrateColumn(db, "registrations", "diet", "ALTER TABLE registrations ADD COLUMN diet TEXT NOT NULL DEFAULT ''")
+	migrateColumn(db, "registrations", "allergies", "ALTER TABLE registrations ADD COLUMN allergies TEXT NOT NULL DEFAULT ''")
+	migrateColumn(db, "registrations", "show_name", "ALTER TABLE registrations ADD COLUMN show_name INTEGER NOT NULL DEFAULT 0")
+	migrateColumn(db, "registrations", "checked_in_at", "ALTER TABLE registrations ADD COLUMN checked_in_at TEXT")
+	migrateColumn(db, "outbox", "reply_to", "ALTER TABLE outbox ADD COLUMN reply_to TEXT NOT NULL DEFAULT ''")
+	migrateColumn(db, "outbox", "event_id", "ALTER TABLE outbox ADD COLUMN event_id INTEGER NOT NULL DEFAULT 0")
+	migrateColumn(db, "sessions", "remember", "ALTER TABLE sessions ADD COLUMN remember INTEGER NOT NULL DEFAULT 0")
+	migrateColumn(db, "sessions", "prev_token_hash", "ALTER TABLE sessions ADD COLUMN prev_token_hash TEXT NOT NULL DEFAULT ''")
+	migrateColumn(db, "sessions", "rotated_at", "ALTER TABLE sessions ADD COLUMN rotated_at DATETIME")
+	migrateColumn(db, "task_groups", "max_slots", "ALTER TABLE task_groups ADD COLUMN max_slots INTEGER")
+	migrateColumn(db, "task_groups", "color", "ALTER TABLE task_groups ADD COLUMN color TEXT NOT NULL DEFAULT ''")
+	migrateColumn(db, "task_groups", "icon", "ALTER TABLE task_groups ADD COLUMN icon TEXT NOT NULL DEFAULT ''")
+	migrateColumn(db, "form_fields", "task_id", "ALTER TABLE form_fields ADD COLUMN task_id INTEGER REFERENCES tasks(id) ON DELETE CASCADE")
+
+	// Rows in the admin trash.
+	for _, table := range []string{"events", "task_groups", "tasks", "registrations"} {
+		migrateColumn(db, table, "trash_id", "ALTER TABLE "+table+" ADD COLUMN trash_id INTEGER")
+	}
+
+	// Registrations carry their task's event for the unique index on event
+	// and email. Where an address was signed up twice before the index
+	// existed, only the first registration gets it: the others stay as they
+	// are, outside the index, and get it once the first one is gone.
+	migrateColumn(db, "registrations", "event_id", "ALTER TABLE registrations ADD COLUMN event_id INTEGER")
+	// The index now has a slot per registration an address may hold.
+	migrateColumn(db, "registrations", "person_slot", "ALTER TABLE registrations ADD COLUMN person_slot INTEGER NOT NULL DEFAULT 1")
+	db.Exec("DROP INDEX IF EXISTS idx_registrations_event_email")
+	db.Exec(`UPDATE registrations SET event_id = (SELECT event_id FROM tasks WHERE id = registrations.task_id)
+		WHERE event_id IS NULL AND (trash_id IS NOT NULL OR email = '' OR NOT EXISTS (
+			SELECT 1 FROM registrations r JOIN tasks t ON r.task_id = t.id
+			WHERE t.event_id = (SELECT event_id FROM tasks WHERE id = registrations.task_id)
+				AND LOWER(r.email) = LOWER(registrations.email) AND r.trash_id IS NULL
+				AND r.id != registrations.id AND (r.event_id IS NOT NULL OR r.id < registrations.id)))`)
+	// Family members share the email, each with their own slots.
+	migrateColumn(db, "registrations", "member", "ALTER TABLE registrations ADD COLUMN member INTEGER NOT NULL DEFAULT 0")
+	db.Exec("DROP INDEX IF EXISTS idx_registrations_event_email_slot")
+	// Registrations already there when bare tokens stopped cancelling keep
+	// theirs working: links to them went out unsigned.
+	migrateColumn(db, "registrations", "legacy_token", "ALTER TABLE registrations ADD COLUMN legacy_token INTEGER NOT NULL DEFAULT 0; UPDATE registrations SET legacy_token = 1")
+
+	// Opening notices link to the configured base URL, no longer to the
+	// host they were asked from.
+	migrateDropColumn(db, "opening_notices", "base_url")
+
 	if _, err := db.Exec(schemaSQL); err != nil {
-		return nil, fmt.Errorf("schema init: %w", err)
+		return fmt.Errorf("schema init: %w", err)
 	}
 
 	// One-off data migrations for the event description field, now that it
 	// stores HTML (Trix-edited) instead of plain text. Idempotent — safe to
 	// run on every startup.
 	migrateEventDescriptions(db)
+	migrateVolunteers(db)
 
-	return db, nil
+	return nil
 }
 
 // migrateEventDescriptions backfills event description data after the
@@ -336,12 +592,11 @@ func EnsureUniqueSlug(db *sql.DB, slug string, excludeID int64) (string, error)
 		if i > 0 {
 			candidate = fmt.Sprintf("%s-%d", base, i)
 		}
-		var count int
-		err := db.QueryRow("SELECT COUNT(*) FROM events WHERE slug = ? AND id != ?", candidate, excludeID).Scan(&count)
+		taken, err := slugInUse(db, candidate, excludeID)
 		if err != nil {
 			return "", err
 		}
-		if count == 0 {
+		if !taken {
 			return candidate, nil
 		}
 	}
@@ -349,13 +604,15 @@ func EnsureUniqueSlug(db *sql.DB, slug string, excludeID int64) (string, error)
 
 // ---- Event CRUD ----
 
-const eventCols = "id, slug, title_fr, title_en, description_fr, description_en, event_date, event_time, event_type, santa_drawn_at, email_hook_fr, email_hook_en, email_how_title_fr, email_how_title_en, email_how_step1_fr, email_how_step1_en, email_how_step2_fr, email_how_step2_en, email_how_step3_fr, email_how_step3_en, email_button_fr, email_button_en, email_disclaimer_fr, email_disclaimer_en, created_at"
+const eventCols = "id, slug, title_fr, title_en, description_fr, description_en, event_date, event_time, end_date, end_time, event_type, santa_drawn_at, base_url, stats_token, terms_fr, terms_en, terms_version, notify_emails, organizer_email, timezone, cancel_days, retention_months, anonymized_at, registration_opens_at, registration_closes_at, access_code, max_tasks_per_person, max_participants, collect_diet, counts_only, checkin, assign_mode, family_signup, draft, notes, lang, noindex, invite_only, max_guests, email_hook_fr, email_hook_en, email_how_title_fr, email_how_title_en, email_how_step1_fr, email_how_step1_en, email_how_step2_fr, email_how_step2_en, email_how_step3_fr, email_how_step3_en, email_button_fr, email_button_en, email_disclaimer_fr, email_disclaimer_en, after_info_fr, after_info_en, after_bring_fr, after_bring_en, after_contact_fr, after_contact_en, series_id, created_at"
 
 func scanEvent(row interface{ Scan(...any) error }) (*Event, error) {
 	e := &Event{}
 	err := row.Scan(
 		&e.ID, &e.Slug, &e.TitleFR, &e.TitleEN, &e.DescriptionFR, &e.DescriptionEN,
-		&e.EventDate, &e.EventTime, &e.EventType, &e.SantaDrawnAt,
+		&e.EventDate, &e.EventTime, &e.EndDate, &e.EndTime, &e.EventType, &e.SantaDrawnAt, &e.BaseURL, &e.StatsToken,
+		&e.TermsFR, &e.TermsEN, &e.TermsVersion, &e.NotifyEmails, &e.OrganizerEmail, &e.Timezone, &e.CancelDays,
+		&e.RetentionMonths, &e.AnonymizedAt, &e.RegistrationOpensAt, &e.RegistrationClosesAt, &e.AccessCode, &e.MaxTasksPerPerson, &e.MaxParticipants, &e.CollectDiet, &e.CountsOnly, &e.CheckIn, &e.AssignMode, &e.FamilySignup, &e.Draft, &e.Notes, &e.Lang, &e.NoIndex, &e.InviteOnly, &e.MaxGuests,
 		&e.EmailHookFR, &e.EmailHookEN,
 		&e.EmailHowTitleFR, &e.EmailHowTitleEN,
 		&e.EmailHowStep1FR, &e.EmailHowStep1EN,
@@ -363,13 +620,22 @@ func scanEvent(row interface{ Scan(...any) error }) (*Event, error) {
 		&e.EmailHowStep3FR, &e.EmailHowStep3EN,
 		&e.EmailButtonFR, &e.EmailButtonEN,
 		&e.EmailDisclaimerFR, &e.EmailDisclaimerEN,
-		&e.CreatedAt,
+		&e.AfterInfoFR, &e.AfterInfoEN,
+		&e.AfterBringFR, &e.AfterBringEN,
+		&e.AfterContactFR, &e.AfterContactEN,
+		&e.SeriesID, &e.CreatedAt,
 	)
 	return e, err
 }
 
+// CreateEvent inserts e. The slug is derived from e.Slug when one is preset,
+// otherwise from the French title, and made unique either way.
 func CreateEvent(db *sql.DB, e *Event) error {
-	slug, err := EnsureUniqueSlug(db, GenerateSlug(e.TitleFR), 0)
+	base := e.Slug
+	if base == "" {
+		base = GenerateSlug(e.TitleFR)
+	}
+	slug, err := EnsureUniqueSlug(db, base, 0)
 	if err != nil {
 		return err
 	}
@@ -377,20 +643,33 @@ func CreateEvent(db *sql.DB, e *Event) error {
 	if e.EventType == "" {
 		e.EventType = "tasks"
 	}
+	if e.MaxTasksPerPerson < 1 {
+		e.MaxTasksPerPerson = 1
+	}
+	if e.HasTerms() && e.TermsVersion == 0 {
+		e.TermsVersion = 1
+	}
 	res, err := db.Exec(
 		`INSERT INTO events (
 			slug, title_fr, title_en, description_fr, description_en,
-			event_date, event_time, event_type,
+			event_date, event_time, end_date, end_time, event_type, base_url,
+			terms_fr, terms_en, terms_version, notify_emails, organizer_email, timezone, cancel_days, retention_months,
+			registration_opens_at, registration_closes_at, access_code, max_tasks_per_person, max_participants, collect_diet, counts_only, checkin, assign_mode, family_signup, draft, notes, lang, noindex, invite_only, max_guests,
 			email_hook_fr, email_hook_en,
 			email_how_title_fr, email_how_title_en,
 			email_how_step1_fr, email_how_step1_en,
 			email_how_step2_fr, email_how_step2_en,
 			email_how_step3_fr, email_how_step3_en,
 			email_button_fr, email_button_en,
-			email_disclaimer_fr, email_disclaimer_en
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			email_disclaimer_fr, email_disclaimer_en,
+			after_info_fr, after_info_en,
+			after_bring_fr, after_bring_en,
+			after_contact_fr, after_contact_en
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		e.Slug, e.TitleFR, e.TitleEN, e.DescriptionFR, e.DescriptionEN,
-		e.EventDate, e.EventTime, e.EventType,
+		e.EventDate, e.EventTime, e.EndDate, e.EndTime, e.EventType, e.BaseURL,
+		e.TermsFR, e.TermsEN, e.TermsVersion, e.NotifyEmails, e.OrganizerEmail, e.Timezone, e.CancelDays, e.RetentionMonths,
+		e.RegistrationOpensAt, e.RegistrationClosesAt, e.AccessCode, e.MaxTasksPerPerson, e.MaxParticipants, e.CollectDiet, e.CountsOnly, e.CheckIn, e.AssignMode, e.FamilySignup, e.Draft, e.Notes, e.Lang, e.NoIndex, e.InviteOnly, e.MaxGuests,
 		e.EmailHookFR, e.EmailHookEN,
 		e.EmailHowTitleFR, e.EmailHowTitleEN,
 		e.EmailHowStep1FR, e.EmailHowStep1EN,
@@ -398,29 +677,60 @@ func CreateEvent(db *sql.DB, e *Event) error {
 		e.EmailHowStep3FR, e.EmailHowStep3EN,
 		e.EmailButtonFR, e.EmailButtonEN,
 		e.EmailDisclaimerFR, e.EmailDisclaimerEN,
+		e.AfterInfoFR, e.AfterInfoEN,
+		e.AfterBringFR, e.AfterBringEN,
+		e.AfterContactFR, e.AfterContactEN,
 	)
 	if err != nil {
 		return err
 	}
 	e.ID, _ = res.LastInsertId()
-	return nil
+	return saveTermsVersion(db, e)
 }
 
+// UpdateEvent saves e and sets e.TermsVersion. Terms edits made before anyone
+// accepted the current version revise it in place (autosave sends every
+// keystroke); once someone has accepted it, any change starts a new version
+// so each acceptance keeps pointing at the text that person agreed to.
 func UpdateEvent(db *sql.DB, e *Event) error {
+	var curFR, curEN string
+	var curVersion int
+	if err := db.QueryRow("SELECT terms_fr, terms_en, terms_version FROM events WHERE id=?", e.ID).Scan(&curFR, &curEN, &curVersion); err != nil {
+		return err
+	}
+	e.TermsVersion = curVersion
+	if (e.TermsFR != curFR || e.TermsEN != curEN) && e.HasTerms() {
+		var accepted int
+		db.QueryRow(
+			`SELECT COUNT(*) FROM registrations r JOIN tasks t ON r.task_id = t.id
+			WHERE t.event_id = ? AND r.terms_version = ?`, e.ID, curVersion,
+		).Scan(&accepted)
+		if curVersion == 0 || accepted > 0 {
+			e.TermsVersion = curVersion + 1
+		}
+	}
+
 	_, err := db.Exec(
 		`UPDATE events SET
 			title_fr=?, title_en=?, description_fr=?, description_en=?,
-			event_date=?, event_time=?, event_type=?,
+			event_date=?, event_time=?, end_date=?, end_time=?, event_type=?, base_url=?,
+			terms_fr=?, terms_en=?, terms_version=?, notify_emails=?, organizer_email=?, timezone=?, cancel_days=?, retention_months=?,
+			registration_opens_at=?, registration_closes_at=?, access_code=?, max_tasks_per_person=?, max_participants=?, collect_diet=?, counts_only=?, checkin=?, assign_mode=?, family_signup=?, notes=?, lang=?, noindex=?, max_guests=?,
 			email_hook_fr=?, email_hook_en=?,
 			email_how_title_fr=?, email_how_title_en=?,
 			email_how_step1_fr=?, email_how_step1_en=?,
 			email_how_step2_fr=?, email_how_step2_en=?,
 			email_how_step3_fr=?, email_how_step3_en=?,
 			email_button_fr=?, email_button_en=?,
-			email_disclaimer_fr=?, email_disclaimer_en=?
+			email_disclaimer_fr=?, email_disclaimer_en=?,
+			after_info_fr=?, after_info_en=?,
+			after_bring_fr=?, after_bring_en=?,
+			after_contact_fr=?, after_contact_en=?
 		WHERE id=?`,
 		e.TitleFR, e.TitleEN, e.DescriptionFR, e.DescriptionEN,
-		e.EventDate, e.EventTime, e.EventType,
+		e.EventDate, e.EventTime, e.EndDate, e.EndTime, e.EventType, e.BaseURL,
+		e.TermsFR, e.TermsEN, e.TermsVersion, e.NotifyEmails, e.OrganizerEmail, e.Timezone, e.CancelDays, e.RetentionMonths,
+		e.RegistrationOpensAt, e.RegistrationClosesAt, e.AccessCode, e.MaxTasksPerPerson, e.MaxParticipants, e.CollectDiet, e.CountsOnly, e.CheckIn, e.AssignMode, e.FamilySignup, e.Notes, e.Lang, e.NoIndex, e.MaxGuests,
 		e.EmailHookFR, e.EmailHookEN,
 		e.EmailHowTitleFR, e.EmailHowTitleEN,
 		e.EmailHowStep1FR, e.EmailHowStep1EN,
@@ -428,26 +738,265 @@ func UpdateEvent(db *sql.DB, e *Event) error {
 		e.EmailHowStep3FR, e.EmailHowStep3EN,
 		e.EmailButtonFR, e.EmailButtonEN,
 		e.EmailDisclaimerFR, e.EmailDisclaimerEN,
+		e.AfterInfoFR, e.AfterInfoEN,
+		e.AfterBringFR, e.AfterBringEN,
+		e.AfterContactFR, e.AfterContactEN,
 		e.ID,
 	)
+	if err != nil {
+		return err
+	}
+	return saveTermsVersion(db, e)
+}
+
+// NotifyAddresses returns the admin addresses to email on each new
+// registration or RSVP; none when notifications are off.
+func (e Event) NotifyAddresses() []string {
+	var addrs []string
+	for _, a := range strings.Split(e.NotifyEmails, ",") {
+		if a = strings.TrimSpace(a); a != "" {
+			addrs = append(addrs, a)
+		}
+	}
+	return addrs
+}
+
+// HasTerms reports whether volunteers must accept terms to sign up.
+func (e Event) HasTerms() bool {
+	return strings.TrimSpace(e.TermsFR) != "" || strings.TrimSpace(e.TermsEN) != ""
+}
+
+// saveTermsVersion keeps the text of every terms version in event_terms, so
+// the acceptance report can show what an earlier registrant agreed to.
+func saveTermsVersion(db *sql.DB, e *Event) error {
+	if e.TermsVersion == 0 || !e.HasTerms() {
+		return nil
+	}
+	_, err := db.Exec(
+		"INSERT OR REPLACE INTO event_terms (event_id, version, terms_fr, terms_en, updated_at) VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)",
+		e.ID, e.TermsVersion, e.TermsFR, e.TermsEN,
+	)
 	return err
 }
 
-func DeleteEvent(db *sql.DB, id int64) error {
-	_, err := db.Exec("DELETE FROM events WHERE id=?", id)
+// BaseURLOr returns the event's vanity base URL, or fallback when none is set.
+func (e Event) BaseURLOr(fallback string) string {
+	if e.BaseURL != "" {
+		return e.BaseURL
+	}
+	return fallback
+}
+
+// Location returns the event's time zone, or nil when none is set (or the
+// stored name is no longer known).
+func (e Event) Location() *time.Location {
+	if e.Timezone == "" {
+		return nil
+	}
+	loc, err := time.LoadLocation(e.Timezone)
+	if err != nil {
+		return nil
+	}
+	return loc
+}
+
+// StartTime returns the instant the event starts; ok is false when it has no
+// time or no time zone, since the wall clock alone doesn't pin an instant.
+func (e Event) StartTime() (start time.Time, ok bool) {
+	loc := e.Location()
+	if loc == nil || e.EventTime == "" {
+		return time.Time{}, false
+	}
+	start, err := time.ParseInLocation("2006-01-02 15:04", e.EventDate+" "+e.EventTime, loc)
+	return start, err == nil
+}
+
+// EndDay returns the date the event ends on.
+func (e Event) EndDay() string {
+	if e.EndDate != "" {
+		return e.EndDate
+	}
+	return e.EventDate
+}
+
+// DateNextYear returns the event's date a year on, the usual date of its
+// next edition.
+func (e Event) DateNextYear() string {
+	d, err := time.Parse("2006-01-02", e.EventDate)
+	if err != nil {
+		return ""
+	}
+	return d.AddDate(1, 0, 0).Format("2006-01-02")
+}
+
+// Duration returns how long a timed event lasts; ok is false unless it has
+// both a start and an end time. An end time not after the start on the same
+// day runs past midnight, as for task shifts.
+func (e Event) Duration() (d time.Duration, ok bool) {
+	if e.EventTime == "" || e.EndTime == "" {
+		return 0, false
+	}
+	loc := e.Location()
+	if loc == nil {
+		loc = time.UTC
+	}
+	start, err := time.ParseInLocation("2006-01-02 15:04", e.EventDate+" "+e.EventTime, loc)
+	if err != nil {
+		return 0, false
+	}
+	end, err := time.ParseInLocation("2006-01-02 15:04", e.EndDay()+" "+e.EndTime, loc)
+	if err != nil {
+		return 0, false
+	}
+	if !end.After(start) {
+		end = end.AddDate(0, 0, 1)
+	}
+	return end.Sub(start), true
+}
+
+// CancelDeadline returns when the event's cancellation links stop working:
+// midnight at the end of its last day plus CancelDays, in its time zone (UTC
+// when it has none). ok is false when they never expire.
+func (e Event) CancelDeadline() (deadline time.Time, ok bool) {
+	if !e.CancelDays.Valid {
+		return time.Time{}, false
+	}
+	loc := e.Location()
+	if loc == nil {
+		loc = time.UTC
+	}
+	day, err := time.ParseInLocation("2006-01-02", e.EndDay(), loc)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return day.AddDate(0, 0, int(e.CancelDays.Int64)+1), true
+}
+
+// InZone converts a stored timestamp (signup time and the like) to the
+// event's time zone, leaving it unchanged when none is set.
+func (e Event) InZone(t time.Time) time.Time {
+	if loc := e.Location(); loc != nil {
+		return t.In(loc)
+	}
+	return t
+}
+
+// GetEventByHost returns the most recent event whose vanity base URL points
+// at host, so a bare visit to that domain can land on the event page.
+func GetEventByHost(db *sql.DB, host string) (*Event, error) {
+	host = strings.ToLower(host)
+	return scanEvent(db.QueryRow(
+		"SELECT "+eventCols+" FROM events WHERE base_url IN (?, ?) AND trash_id IS NULL ORDER BY created_at DESC, id DESC LIMIT 1",
+		"https://"+host, "http://"+host,
+	))
+}
+
+// SetStatsToken turns the shared stats dashboard on (a fresh token, which
+// also invalidates any previously shared link) or off (empty token).
+func SetStatsToken(db *sql.DB, eventID int64, token string) error {
+	_, err := db.Exec("UPDATE events SET stats_token=? WHERE id=?", token, eventID)
 	return err
 }
 
+func GetEventByStatsToken(db *sql.DB, token string) (*Event, error) {
+	if token == "" {
+		return nil, sql.ErrNoRows
+	}
+	return scanEvent(db.QueryRow("SELECT "+eventCols+" FROM events WHERE stats_token=? AND trash_id IS NULL", token))
+}
+
+// DeleteEvent moves an event to the trash with its groups, tasks and
+// registrations.
+func DeleteEvent(db *sql.DB, id int64) error {
+	e, err := GetEvent(db, id)
+	if err == sql.ErrNoRows {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return moveToTrash(db, "event", id, id, e.TitleFR, e.TitleEN,
+		"UPDATE events SET trash_id=? WHERE id=? AND trash_id IS NULL",
+		"UPDATE task_groups SET trash_id=? WHERE event_id=? AND trash_id IS NULL",
+		"UPDATE tasks SET trash_id=? WHERE event_id=? AND trash_id IS NULL",
+		"UPDATE registrations SET trash_id=? WHERE task_id IN (SELECT id FROM tasks WHERE event_id=?) AND trash_id IS NULL",
+	)
+}
+
+// ClearEventStructure moves all of event e's groups and tasks, with their
+// registrations, to the trash as one entry, so that one restore brings the
+// whole tree back.
+func ClearEventStructure(db *sql.DB, e *Event) error {
+	var groups, tasks int
+	db.QueryRow("SELECT COUNT(*) FROM task_groups WHERE event_id=? AND trash_id IS NULL", e.ID).Scan(&groups)
+	db.QueryRow("SELECT COUNT(*) FROM tasks WHERE event_id=? AND trash_id IS NULL", e.ID).Scan(&tasks)
+	return moveToTrash(db, "structure", e.ID, e.ID,
+		fmt.Sprintf("%d groupe(s), %d tâche(s)", groups, tasks), fmt.Sprintf("%d group(s), %d task(s)", groups, tasks),
+		"UPDATE registrations SET trash_id=? WHERE task_id IN (SELECT id FROM tasks WHERE event_id=? AND trash_id IS NULL) AND trash_id IS NULL",
+		"UPDATE tasks SET trash_id=? WHERE event_id=? AND trash_id IS NULL",
+		"UPDATE task_groups SET trash_id=? WHERE event_id=? AND trash_id IS NULL",
+	)
+}
+
 func GetEvent(db *sql.DB, id int64) (*Event, error) {
-	return scanEvent(db.QueryRow("SELECT "+eventCols+" FROM events WHERE id=?", id))
+	return scanEvent(db.QueryRow("SELECT "+eventCols+" FROM events WHERE id=? AND trash_id IS NULL", id))
 }
 
 func GetEventBySlug(db *sql.DB, slug string) (*Event, error) {
-	return scanEvent(db.QueryRow("SELECT "+eventCols+" FROM events WHERE slug=?", slug))
+	return scanEvent(db.QueryRow("SELECT "+eventCols+" FROM events WHERE slug=? AND trash_id IS NULL", slug))
 }
 
 func ListEvents(db *sql.DB) ([]Event, error) {
-	rows, err := db.Query("SELECT " + eventCols + " FROM events ORDER BY event_date DESC")
+	rows, err := db.Query("SELECT " + eventCols + " FROM events WHERE trash_id IS NULL ORDER BY event_date DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var events []Event
+	for rows.Next() {
+		e, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, *e)
+	}
+	return events, rows.Err()
+}
+
+// ---- Event series ----
+
+// EventSeries repeats an event weekly or monthly until UntilDate. The
+// occurrences are ordinary events, each with its own tasks and signups.
+type EventSeries struct {
+	ID        int64
+	Frequency string // "weekly" or "monthly"
+	UntilDate string
+	CreatedAt time.Time
+}
+
+func CreateSeries(db *sql.DB, s *EventSeries) error {
+	res, err := db.Exec("INSERT INTO event_series (frequency, until_date) VALUES (?, ?)", s.Frequency, s.UntilDate)
+	if err != nil {
+		return err
+	}
+	s.ID, _ = res.LastInsertId()
+	return nil
+}
+
+func GetSeries(db *sql.DB, id int64) (*EventSeries, error) {
+	s := &EventSeries{}
+	err := db.QueryRow("SELECT id, frequency, until_date, created_at FROM event_series WHERE id=?", id).
+		Scan(&s.ID, &s.Frequency, &s.UntilDate, &s.CreatedAt)
+	return s, err
+}
+
+func SetEventSeries(db *sql.DB, eventID, seriesID int64) error {
+	_, err := db.Exec("UPDATE events SET series_id=? WHERE id=?", seriesID, eventID)
+	return err
+}
+
+// ListSeriesEvents returns the occurrences of a series, earliest first.
+func ListSeriesEvents(db *sql.DB, seriesID int64) ([]Event, error) {
+	rows, err := db.Query("SELECT "+eventCols+" FROM events WHERE series_id=? AND trash_id IS NULL ORDER BY event_date, id", seriesID)
 	if err != nil {
 		return nil, err
 	}
@@ -463,13 +1012,92 @@ func ListEvents(db *sql.DB) ([]Event, error) {
 	return events, rows.Err()
 }
 
+// ReplaceTaskStructure makes the groups and tasks of event toID a copy of
+// those of event fromID, nesting and order included. Any existing groups and
+// tasks of toID are deleted first, together with their registrations.
+func ReplaceTaskStructure(db *sql.DB, fromID, toID int64) error {
+	groups, err := ListTaskGroups(db, fromID)
+	if err != nil {
+		return err
+	}
+	tasks, err := ListTasks(db, fromID)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec("DELETE FROM tasks WHERE event_id=?", toID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM task_groups WHERE event_id=?", toID); err != nil {
+		return err
+	}
+	if err := insertTaskStructure(tx, toID, groups, tasks); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// insertTaskStructure adds copies of groups and tasks to event eventID,
+// keeping their nesting and order. Group ids only link the given rows to
+// each other; the copies get new ones.
+func insertTaskStructure(tx *sql.Tx, eventID int64, groups []TaskGroup, tasks []Task) error {
+	// Parents must exist before their children, so walk the tree from the top.
+	newIDs := map[int64]int64{}
+	var copyGroups func(parent sql.NullInt64) error
+	copyGroups = func(parent sql.NullInt64) error {
+		for _, g := range groups {
+			if g.ParentGroupID != parent {
+				continue
+			}
+			newParent := sql.NullInt64{}
+			if parent.Valid {
+				newParent = sql.NullInt64{Int64: newIDs[parent.Int64], Valid: true}
+			}
+			res, err := tx.Exec(
+				"INSERT INTO task_groups (event_id, parent_group_id, title_fr, title_en, position, max_slots, color, icon) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+				eventID, newParent, g.TitleFR, g.TitleEN, g.Position, g.MaxSlots, g.Color, g.Icon,
+			)
+			if err != nil {
+				return fmt.Errorf("copy group %d: %w", g.ID, err)
+			}
+			newIDs[g.ID], _ = res.LastInsertId()
+			if err := copyGroups(sql.NullInt64{Int64: g.ID, Valid: true}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := copyGroups(sql.NullInt64{}); err != nil {
+		return err
+	}
+
+	for _, t := range tasks {
+		group := sql.NullInt64{}
+		if id, ok := newIDs[t.GroupID.Int64]; t.GroupID.Valid && ok {
+			group = sql.NullInt64{Int64: id, Valid: true}
+		}
+		if _, err := tx.Exec(
+			"INSERT INTO tasks (event_id, group_id, title_fr, title_en, description_fr, description_en, max_slots, start_time, end_time, position, max_guests, min_slots, skills, reserved_slots, notes) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			eventID, group, t.TitleFR, t.TitleEN, t.DescriptionFR, t.DescriptionEN, t.MaxSlots, t.StartTime, t.EndTime, t.Position, t.MaxGuests, t.MinSlots, t.Skills, t.ReservedSlots, t.Notes,
+		); err != nil {
+			return fmt.Errorf("copy task %d: %w", t.ID, err)
+		}
+	}
+	return nil
+}
+
 // ---- TaskGroup CRUD ----
 
-const groupCols = "id, event_id, parent_group_id, title_fr, title_en, position"
+const groupCols = "id, event_id, parent_group_id, title_fr, title_en, position, max_slots, color, icon"
 
 func scanGroup(row interface{ Scan(...any) error }) (*TaskGroup, error) {
 	g := &TaskGroup{}
-	err := row.Scan(&g.ID, &g.EventID, &g.ParentGroupID, &g.TitleFR, &g.TitleEN, &g.Position)
+	err := row.Scan(&g.ID, &g.EventID, &g.ParentGroupID, &g.TitleFR, &g.TitleEN, &g.Position, &g.MaxSlots, &g.Color, &g.Icon)
 	return g, err
 }
 
@@ -494,8 +1122,8 @@ func CreateTaskGroup(db *sql.DB, g *TaskGroup) error {
 	g.Position = maxPos + 1
 
 	res, err := db.Exec(
-		"INSERT INTO task_groups (event_id, parent_group_id, title_fr, title_en, position) VALUES (?, ?, ?, ?, ?)",
-		g.EventID, g.ParentGroupID, g.TitleFR, g.TitleEN, g.Position,
+		"INSERT INTO task_groups (event_id, parent_group_id, title_fr, title_en, position, max_slots, color, icon) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		g.EventID, g.ParentGroupID, g.TitleFR, g.TitleEN, g.Position, g.MaxSlots, g.Color, g.Icon,
 	)
 	if err != nil {
 		return err
@@ -506,31 +1134,38 @@ func CreateTaskGroup(db *sql.DB, g *TaskGroup) error {
 
 func UpdateTaskGroup(db *sql.DB, g *TaskGroup) error {
 	_, err := db.Exec(
-		"UPDATE task_groups SET title_fr=?, title_en=? WHERE id=?",
-		g.TitleFR, g.TitleEN, g.ID,
+		"UPDATE task_groups SET title_fr=?, title_en=?, max_slots=?, color=?, icon=? WHERE id=?",
+		g.TitleFR, g.TitleEN, g.MaxSlots, g.Color, g.Icon, g.ID,
 	)
 	return err
 }
 
+// DeleteTaskGroup moves a group to the trash. Its tasks and subgroups stay,
+// moved up to the group's parent.
 func DeleteTaskGroup(db *sql.DB, id int64) error {
-	// Get parent of this group to promote children
-	var parentID sql.NullInt64
-	db.QueryRow("SELECT parent_group_id FROM task_groups WHERE id=?", id).Scan(&parentID)
+	g, err := GetTaskGroup(db, id)
+	if err == sql.ErrNoRows {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	parentID := g.ParentGroupID
 
 	// Promote child tasks and child groups to the deleted group's parent
 	db.Exec("UPDATE tasks SET group_id=? WHERE group_id=?", parentID, id)
 	db.Exec("UPDATE task_groups SET parent_group_id=? WHERE parent_group_id=?", parentID, id)
 
-	_, err := db.Exec("DELETE FROM task_groups WHERE id=?", id)
-	return err
+	return moveToTrash(db, "group", id, g.EventID, g.TitleFR, g.TitleEN,
+		"UPDATE task_groups SET trash_id=? WHERE id=? AND trash_id IS NULL",
+	)
 }
 
 func GetTaskGroup(db *sql.DB, id int64) (*TaskGroup, error) {
-	return scanGroup(db.QueryRow("SELECT " + groupCols + " FROM task_groups WHERE id=?", id))
+	return scanGroup(db.QueryRow("SELECT " + groupCols + " FROM task_groups WHERE id=? AND trash_id IS NULL", id))
 }
 
 func ListTaskGroups(db *sql.DB, eventID int64) ([]TaskGroup, error) {
-	rows, err := db.Query("SELECT "+groupCols+" FROM task_groups WHERE event_id=? ORDER BY position", eventID)
+	rows, err := db.Query("SELECT "+groupCols+" FROM task_groups WHERE event_id=? AND trash_id IS NULL ORDER BY position", eventID)
 	if err != nil {
 		return nil, err
 	}
@@ -569,8 +1204,8 @@ func CreateTask(db *sql.DB, t *Task) error {
 	t.Position = maxPos + 1
 
 	res, err := db.Exec(
-		"INSERT INTO tasks (event_id, group_id, title_fr, title_en, description_fr, description_en, max_slots, position) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
-		t.EventID, t.GroupID, t.TitleFR, t.TitleEN, t.DescriptionFR, t.DescriptionEN, t.MaxSlots, t.Position,
+		"INSERT INTO tasks (event_id, group_id, title_fr, title_en, description_fr, description_en, max_slots, start_time, end_time, position, max_guests, min_slots, skills, reserved_slots, notes) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		t.EventID, t.GroupID, t.TitleFR, t.TitleEN, t.DescriptionFR, t.DescriptionEN, t.MaxSlots, t.StartTime, t.EndTime, t.Position, t.MaxGuests, t.MinSlots, t.Skills, t.ReservedSlots, t.Notes,
 	)
 	if err != nil {
 		return err
@@ -582,28 +1217,65 @@ func CreateTask(db *sql.DB, t *Task) error {
 func UpdateTask(db *sql.DB, t *Task) error {
 	// group_id is managed exclusively by the reorder API (drag-and-drop)
 	_, err := db.Exec(
-		"UPDATE tasks SET title_fr=?, title_en=?, description_fr=?, description_en=?, max_slots=? WHERE id=?",
-		t.TitleFR, t.TitleEN, t.DescriptionFR, t.DescriptionEN, t.MaxSlots, t.ID,
+		"UPDATE tasks SET title_fr=?, title_en=?, description_fr=?, description_en=?, max_slots=?, start_time=?, end_time=?, max_guests=?, min_slots=?, skills=?, reserved_slots=?, notes=? WHERE id=?",
+		t.TitleFR, t.TitleEN, t.DescriptionFR, t.DescriptionEN, t.MaxSlots, t.StartTime, t.EndTime, t.MaxGuests, t.MinSlots, t.Skills, t.ReservedSlots, t.Notes, t.ID,
 	)
 	return err
 }
 
+// SetTasksMaxSlots gives the tasks ids of event eventID the same maxSlots,
+// bringing their minimum and reserved slots within it, and returns how many
+// it changed. Tasks of other events are left alone.
+func SetTasksMaxSlots(db *sql.DB, eventID int64, ids []int64, maxSlots sql.NullInt64) (int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	n := 0
+	for _, id := range ids {
+		t := Task{ID: id, MaxSlots: maxSlots}
+		err := tx.QueryRow("SELECT min_slots, reserved_slots FROM tasks WHERE id=? AND event_id=? AND trash_id IS NULL", id, eventID).Scan(&t.MinSlots, &t.ReservedSlots)
+		if err == sql.ErrNoRows {
+			continue
+		} else if err != nil {
+			return 0, err
+		}
+		normalizeMinSlots(&t)
+		normalizeReservedSlots(&t)
+		if _, err := tx.Exec("UPDATE tasks SET max_slots=?, min_slots=?, reserved_slots=? WHERE id=?", t.MaxSlots, t.MinSlots, t.ReservedSlots, t.ID); err != nil {
+			return 0, err
+		}
+		n++
+	}
+	return n, tx.Commit()
+}
+
+// DeleteTask moves a task to the trash with its registrations.
 func DeleteTask(db *sql.DB, id int64) error {
-	_, err := db.Exec("DELETE FROM tasks WHERE id=?", id)
-	return err
+	t, err := GetTask(db, id)
+	if err == sql.ErrNoRows {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return moveToTrash(db, "task", id, t.EventID, t.TitleFR, t.TitleEN,
+		"UPDATE tasks SET trash_id=? WHERE id=? AND trash_id IS NULL",
+		"UPDATE registrations SET trash_id=? WHERE task_id=? AND trash_id IS NULL",
+	)
 }
 
 func GetTask(db *sql.DB, id int64) (*Task, error) {
 	t := &Task{}
 	err := db.QueryRow(
-		"SELECT id, event_id, group_id, title_fr, title_en, description_fr, description_en, max_slots, position FROM tasks WHERE id=?", id,
-	).Scan(&t.ID, &t.EventID, &t.GroupID, &t.TitleFR, &t.TitleEN, &t.DescriptionFR, &t.DescriptionEN, &t.MaxSlots, &t.Position)
+		"SELECT id, event_id, group_id, title_fr, title_en, description_fr, description_en, max_slots, start_time, end_time, position, max_guests, min_slots, skills, reserved_slots, notes FROM tasks WHERE id=? AND trash_id IS NULL", id,
+	).Scan(&t.ID, &t.EventID, &t.GroupID, &t.TitleFR, &t.TitleEN, &t.DescriptionFR, &t.DescriptionEN, &t.MaxSlots, &t.StartTime, &t.EndTime, &t.Position, &t.MaxGuests, &t.MinSlots, &t.Skills, &t.ReservedSlots, &t.Notes)
 	return t, err
 }
 
 func ListTasks(db *sql.DB, eventID int64) ([]Task, error) {
 	rows, err := db.Query(
-		"SELECT id, event_id, group_id, title_fr, title_en, description_fr, description_en, max_slots, position FROM tasks WHERE event_id=? ORDER BY position",
+		"SELECT id, event_id, group_id, title_fr, title_en, description_fr, description_en, max_slots, start_time, end_time, position, max_guests, min_slots, skills, reserved_slots, notes FROM tasks WHERE event_id=? AND trash_id IS NULL ORDER BY position, id",
 		eventID,
 	)
 	if err != nil {
@@ -613,7 +1285,7 @@ func ListTasks(db *sql.DB, eventID int64) ([]Task, error) {
 	var tasks []Task
 	for rows.Next() {
 		var t Task
-		rows.Scan(&t.ID, &t.EventID, &t.GroupID, &t.TitleFR, &t.TitleEN, &t.DescriptionFR, &t.DescriptionEN, &t.MaxSlots, &t.Position)
+		rows.Scan(&t.ID, &t.EventID, &t.GroupID, &t.TitleFR, &t.TitleEN, &t.DescriptionFR, &t.DescriptionEN, &t.MaxSlots, &t.StartTime, &t.EndTime, &t.Position, &t.MaxGuests, &t.MinSlots, &t.Skills, &t.ReservedSlots, &t.Notes)
 		tasks = append(tasks, t)
 	}
 	return tasks, rows.Err()
@@ -626,25 +1298,66 @@ func GetTaskViews(db *sql.DB, eventID int64) ([]TaskView, error) {
 	if err != nil {
 		return nil, err
 	}
-	var views []TaskView
-	for _, t := range tasks {
-		var count int
-		db.QueryRow("SELECT COUNT(*) FROM registrations WHERE task_id=?", t.ID).Scan(&count)
-		v := TaskView{Task: t, RegCount: count}
-		if t.MaxSlots.Valid {
-			v.SlotsLeft = int(t.MaxSlots.Int64) - count
-			if v.SlotsLeft < 0 {
-				v.SlotsLeft = 0
+	names, err := ListPublicNames(db, eventID)
+	if err != nil {
+		return nil, err
+	}
+	groups, err := ListTaskGroups(db, eventID)
+	if err != nil {
+		return nil, err
+	}
+	views := make([]TaskView, len(tasks))
+	for i, t := range tasks {
+		views[i] = TaskView{Task: t, RegCount: CountTaskRegistrations(db, t.ID)}
+	}
+	groupsByID := map[int64]TaskGroup{}
+	for _, g := range groups {
+		groupsByID[g.ID] = g
+	}
+	groupCounts := countGroupPeople(groupsByID, views)
+	for i := range views {
+		v := &views[i]
+		left := -1
+		if v.MaxSlots.Valid {
+			left = max(0, int(v.MaxSlots.Int64)-v.RegCount)
+		}
+		// Every enclosing group with a cap can leave fewer spots.
+		for id := v.GroupID; id.Valid; id = groupsByID[id.Int64].ParentGroupID {
+			if g := groupsByID[id.Int64]; g.MaxSlots.Valid {
+				groupLeft := max(0, int(g.MaxSlots.Int64)-groupCounts[g.ID])
+				if left < 0 || groupLeft < left {
+					left = groupLeft
+				}
 			}
-			v.IsFull = v.SlotsLeft == 0
+		}
+		v.SlotsLeft = left
+		if left >= 0 {
+			v.IsFull = left == 0
+			v.GuestsLeft = max(0, min(v.MaxGuests, left-1))
 		} else {
-			v.SlotsLeft = -1
+			v.GuestsLeft = v.MaxGuests
 		}
-		views = append(views, v)
+		v.StillNeeded = max(0, v.MinSlots-v.RegCount)
+		v.PublicNames = names[v.ID]
 	}
 	return views, nil
 }
 
+// countGroupPeople returns how many people are signed up across each
+// group's tasks, subgroups' included.
+func countGroupPeople(groupsByID map[int64]TaskGroup, views []TaskView) map[int64]int {
+	counts := map[int64]int{}
+	for _, v := range views {
+		for id := v.GroupID; id.Valid; id = groupsByID[id.Int64].ParentGroupID {
+			if _, ok := groupsByID[id.Int64]; !ok {
+				break
+			}
+			counts[id.Int64] += v.RegCount
+		}
+	}
+	return counts
+}
+
 // BuildEventTree builds a mixed tree of groups and tasks for an event.
 func BuildEventTree(db *sql.DB, eventID int64) ([]TreeNode, error) {
 	groups, err := ListTaskGroups(db, eventID)
@@ -656,6 +1369,12 @@ func BuildEventTree(db *sql.DB, eventID int64) ([]TreeNode, error) {
 		return nil, err
 	}
 
+	groupsByID := map[int64]TaskGroup{}
+	for _, g := range groups {
+		groupsByID[g.ID] = g
+	}
+	groupCounts := countGroupPeople(groupsByID, views)
+
 	// Index by parent
 	groupsByParent := map[int64][]TaskGroup{}
 	for _, g := range groups {
@@ -685,7 +1404,10 @@ func BuildEventTree(db *sql.DB, eventID int64) ([]TreeNode, error) {
 
 		for _, g := range groupsByParent[parentID] {
 			gCopy := g
-			node := TreeNode{Type: "group", Group: &gCopy, Children: build(g.ID)}
+			node := TreeNode{Type: "group", Group: &gCopy, Children: build(g.ID), RegCount: groupCounts[g.ID], SlotsLeft: -1}
+			if g.MaxSlots.Valid {
+				node.SlotsLeft = max(0, int(g.MaxSlots.Int64)-node.RegCount)
+			}
 			items = append(items, posItem{g.Position, node})
 		}
 		for _, t := range tasksByParent[parentID] {
@@ -706,6 +1428,75 @@ func BuildEventTree(db *sql.DB, eventID int64) ([]TreeNode, error) {
 	return build(0), nil
 }
 
+// TaskStat is one task's fill level on the shared stats dashboard. It
+// deliberately carries no registrant data.
+type TaskStat struct {
+	GroupFR  string // ancestor group titles, joined with " › "
+	GroupEN  string
+	TitleFR  string
+	TitleEN  string
+	RegCount int
+	MaxSlots int // 0 = unlimited
+	Percent  int // fill percentage; 0 for unlimited tasks
+}
+
+// EventStats aggregates fill progress for a tasks event. Filled, Capacity
+// and Percent only count tasks with a slot limit.
+type EventStats struct {
+	Tasks        []TaskStat
+	Understaffed []TaskStat // open tasks, emptiest first
+	TotalRegs    int
+	Filled       int
+	Capacity     int
+	Percent      int
+}
+
+// BuildEventStats walks the event tree in display order and summarizes each
+// task. A task is understaffed when it still has free slots, or when it is
+// unlimited and nobody has signed up yet.
+func BuildEventStats(db *sql.DB, eventID int64) (*EventStats, error) {
+	tree, err := BuildEventTree(db, eventID)
+	if err != nil {
+		return nil, err
+	}
+	stats := &EventStats{}
+	var walk func(nodes []TreeNode, pathFR, pathEN []string)
+	walk = func(nodes []TreeNode, pathFR, pathEN []string) {
+		for _, n := range nodes {
+			if n.Type == "group" {
+				walk(n.Children, append(pathFR, n.Group.TitleFR), append(pathEN, Localized(n.Group.TitleFR, n.Group.TitleEN, LangEN)))
+				continue
+			}
+			ts := TaskStat{
+				GroupFR:  strings.Join(pathFR, " › "),
+				GroupEN:  strings.Join(pathEN, " › "),
+				TitleFR:  n.Task.TitleFR,
+				TitleEN:  n.Task.TitleEN,
+				RegCount: n.Task.RegCount,
+			}
+			stats.TotalRegs += ts.RegCount
+			if n.Task.MaxSlots.Valid && n.Task.MaxSlots.Int64 > 0 {
+				ts.MaxSlots = int(n.Task.MaxSlots.Int64)
+				ts.Percent = min(100, ts.RegCount*100/ts.MaxSlots)
+				stats.Filled += min(ts.RegCount, ts.MaxSlots)
+				stats.Capacity += ts.MaxSlots
+			}
+			stats.Tasks = append(stats.Tasks, ts)
+			if (ts.MaxSlots > 0 && ts.RegCount < ts.MaxSlots) || (ts.MaxSlots == 0 && ts.RegCount == 0) {
+				stats.Understaffed = append(stats.Understaffed, ts)
+			}
+		}
+	}
+	walk(tree, nil, nil)
+	sort.SliceStable(stats.Understaffed, func(i, j int) bool {
+		return stats.Understaffed[i].Percent < stats.Understaffed[j].Percent
+	})
+	if stats.Capacity > 0 {
+		stats.Percent = stats.Filled * 100 / stats.Capacity
+	}
+	return stats, nil
+}
+
 // BuildFlatGroupList returns groups in tree order with depth info for dropdowns.
 func BuildFlatGroupList(db *sql.DB, eventID int64) ([]FlatGroup, error) {
 	groups, err := ListTaskGroups(db, eventID)
@@ -761,76 +1552,383 @@ func ApplyReorder(db *sql.DB, nodes []ReorderNode, parentGroupID sql.NullInt64)
 
 // ---- Registration ----
 
-func RegisterForTask(db *sql.DB, taskID int64, firstName, lastName, email, phone string) (*Registration, error) {
+// errAlreadyRegistered is returned by RegisterForTask when the email address
+// is already signed up for the task, or for the task's event when it allows
+// one task per person.
+var errAlreadyRegistered = errors.New("already_registered")
+
+// errTaskLimit is returned by RegisterForTask when the email address is
+// already signed up for as many of the event's tasks as it allows, more than
+// one.
+var errTaskLimit = errors.New("task_limit")
+
+// isUniqueViolation reports whether err is a failed UNIQUE constraint.
+func isUniqueViolation(err error) bool {
+	var se sqlite3.Error
+	return errors.As(err, &se) && se.ExtendedCode == sqlite3.ErrConstraintUnique
+}
+
+// errTooManyGuests is returned by RegisterGroupForTask when someone brings
+// more people along than the task allows.
+var errTooManyGuests = errors.New("too_many_guests")
+
+// errGroupFull is returned by RegisterGroupForTask when the task's group, or
+// one of its parents, has no room left.
+var errGroupFull = errors.New("group_full")
+
+// RegisterForTask signs someone up for a task. One email address gets up to
+// the event's MaxTasksPerPerson registrations, each in its own person_slot:
+// the unique index on registrations settles it, so two submissions racing
+// each other can't both take the last one.
+func RegisterForTask(db *sql.DB, taskID int64, firstName, lastName, email, phone, lang string) (*Registration, error) {
+	return RegisterGroupForTask(db, taskID, 0, firstName, lastName, email, phone, lang)
+}
+
+// RegisterGroupForTask is RegisterForTask for someone bringing guests other
+// people along, who take a slot each.
+func RegisterGroupForTask(db *sql.DB, taskID int64, guests int, firstName, lastName, email, phone, lang string) (*Registration, error) {
+	return registerGroupForTask(db, taskID, guests, 0, publicAccess, firstName, lastName, email, phone, lang)
+}
+
+// RegisterWithAccessCode is RegisterGroupForTask for someone holding the
+// event's access code, who can take the task's reserved slots too.
+func RegisterWithAccessCode(db *sql.DB, taskID int64, guests int, firstName, lastName, email, phone, lang string) (*Registration, error) {
+	return registerGroupForTask(db, taskID, guests, 0, reservedAccess, firstName, lastName, email, phone, lang)
+}
+
+// signupAccess is how far a registration may go past the limits of its task.
+type signupAccess int
+
+const (
+	publicAccess    signupAccess = iota // within the slots open to everyone
+	reservedAccess                      // into the task's reserved slots too
+	organizerAccess                     // past every limit and task rule
+)
+
+func registerGroupForTask(db *sql.DB, taskID int64, guests, member int, access signupAccess, firstName, lastName, email, phone, lang string) (*Registration, error) {
 	tx, err := db.Begin()
 	if err != nil {
 		return nil, err
 	}
 	defer tx.Rollback()
 
-	var maxSlots sql.NullInt64
-	err = tx.QueryRow("SELECT max_slots FROM tasks WHERE id=?", taskID).Scan(&maxSlots)
+	var eventID int64
+	var groupID, maxSlots, maxParticipants sql.NullInt64
+	var maxGuests, maxTasks, reservedSlots int
+	err = tx.QueryRow(
+		"SELECT t.event_id, t.group_id, t.max_slots, t.max_guests, t.reserved_slots, e.max_tasks_per_person, e.max_participants FROM tasks t JOIN events e ON e.id = t.event_id WHERE t.id=? AND t.trash_id IS NULL", taskID,
+	).Scan(&eventID, &groupID, &maxSlots, &maxGuests, &reservedSlots, &maxTasks, &maxParticipants)
 	if err != nil {
 		return nil, fmt.Errorf("task not found: %w", err)
 	}
+	limited := access != organizerAccess
+	if guests < 0 || limited && guests > maxGuests {
+		return nil, errTooManyGuests
+	}
+	taskLimit := maxTasks
+	if !limited {
+		taskLimit = math.MaxInt32
+	}
+	slot, err := freePersonSlot(tx, eventID, taskID, email, member, taskLimit)
+	if err != nil {
+		return nil, err
+	}
 
-	if maxSlots.Valid {
+	if maxSlots.Valid && limited {
+		limit := int(maxSlots.Int64)
+		if access == publicAccess {
+			limit -= reservedSlots
+		}
 		var count int
-		tx.QueryRow("SELECT COUNT(*) FROM registrations WHERE task_id=?", taskID).Scan(&count)
-		if count >= int(maxSlots.Int64) {
+		tx.QueryRow("SELECT "+countPeople+" FROM registrations r WHERE r.task_id=? AND r.trash_id IS NULL", taskID).Scan(&count)
+		if count+1+guests > limit {
 			return nil, fmt.Errorf("task_full")
 		}
 	}
+	if limited {
+		if err := checkGroupCapacity(tx, groupID, 1+guests); err != nil {
+			return nil, err
+		}
+		if err := checkTaskRules(tx, taskID, email, member); err != nil {
+			return nil, err
+		}
+	}
 
 	token := GenerateToken()
 	res, err := tx.Exec(
-		"INSERT INTO registrations (task_id, first_name, last_name, email, phone, token) VALUES (?, ?, ?, ?, ?, ?)",
-		taskID, firstName, lastName, email, phone, token,
+		"INSERT INTO registrations (task_id, event_id, first_name, last_name, email, phone, lang, token, guests, person_slot, member) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		taskID, eventID, firstName, lastName, email, phone, lang, token, guests, slot, member,
 	)
+	if isUniqueViolation(err) {
+		// The random token can't realistically collide: it's the email's
+		// slot, taken in the meantime.
+		if maxTasks > 1 {
+			return nil, errTaskLimit
+		}
+		return nil, errAlreadyRegistered
+	}
 	if err != nil {
 		return nil, err
 	}
+	// Counted once in: the same person's other tasks don't add to it.
+	if limited {
+		if err := checkEventCapacity(tx, eventID, maxParticipants); err != nil {
+			return nil, err
+		}
+	}
 	if err := tx.Commit(); err != nil {
 		return nil, err
 	}
 
 	id, _ := res.LastInsertId()
-	return &Registration{ID: id, TaskID: taskID, FirstName: firstName, LastName: lastName, Email: email, Phone: phone, Token: token}, nil
+	return &Registration{ID: id, TaskID: taskID, FirstName: firstName, LastName: lastName, Email: email, Phone: phone, Lang: lang, Token: token, Guests: guests}, nil
+}
+
+// checkGroupCapacity returns errGroupFull when group groupID, or one of its
+// parents, has fewer than people spots left.
+func checkGroupCapacity(tx *sql.Tx, groupID sql.NullInt64, people int) error {
+	for groupID.Valid {
+		var parentID, maxSlots sql.NullInt64
+		err := tx.QueryRow("SELECT parent_group_id, max_slots FROM task_groups WHERE id=? AND trash_id IS NULL", groupID.Int64).Scan(&parentID, &maxSlots)
+		if err == sql.ErrNoRows {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if maxSlots.Valid {
+			var count int
+			tx.QueryRow(`WITH RECURSIVE sub(id) AS (
+					SELECT ? UNION ALL
+					SELECT g.id FROM task_groups g JOIN sub ON g.parent_group_id = sub.id WHERE g.trash_id IS NULL
+				)
+				SELECT `+countPeople+` FROM registrations r JOIN tasks t ON t.id = r.task_id
+				WHERE t.group_id IN (SELECT id FROM sub) AND t.trash_id IS NULL AND r.trash_id IS NULL`, groupID.Int64).Scan(&count)
+			if count+people > int(maxSlots.Int64) {
+				return errGroupFull
+			}
+		}
+		groupID = parentID
+	}
+	return nil
 }
 
-func GetRegistrationByToken(db *sql.DB, token string) (*Registration, error) {
-	r := &Registration{}
-	err := db.QueryRow(
-		"SELECT id, task_id, first_name, last_name, email, phone, token, created_at FROM registrations WHERE token=?", token,
-	).Scan(&r.ID, &r.TaskID, &r.FirstName, &r.LastName, &r.Email, &r.Phone, &r.Token, &r.CreatedAt)
-	return r, err
-}
+// freePersonSlot returns the lowest person_slot, up to limit, that the
+// registrations of email's member for event eventID leave free.
+func freePersonSlot(tx *sql.Tx, eventID, taskID int64, email string, member, limit int) (int, error) {
+	if email == "" {
+		return 1, nil
+	}
+	rows, err := tx.Query("SELECT task_id, person_slot FROM registrations WHERE event_id=? AND LOWER(email)=LOWER(?) AND member=? AND trash_id IS NULL", eventID, email, member)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	used := map[int]bool{}
+	for rows.Next() {
+		var regTaskID int64
+		var slot int
+		if err := rows.Scan(&regTaskID, &slot); err != nil {
+			return 0, err
+		}
+		if regTaskID == taskID {
+			return 0, errAlreadyRegistered
+		}
+		used[slot] = true
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	for slot := 1; slot <= limit; slot++ {
+		if !used[slot] {
+			return slot, nil
+		}
+	}
+	if limit > 1 {
+		return 0, errTaskLimit
+	}
+	return 0, errAlreadyRegistered
+}
+
+func GetRegistrationByToken(db *sql.DB, token string) (*Registration, error) {
+	r := &Registration{}
+	err := db.QueryRow(
+		"SELECT id, task_id, first_name, last_name, email, phone, lang, token, guests, created_at FROM registrations WHERE token=? AND trash_id IS NULL", token,
+	).Scan(&r.ID, &r.TaskID, &r.FirstName, &r.LastName, &r.Email, &r.Phone, &r.Lang, &r.Token, &r.Guests, &r.CreatedAt)
+	return r, err
+}
+
+// LegacyRegistrationToken reports whether the registration with token was
+// made before cancel links were signed, so that its bare token still works
+// as one.
+func LegacyRegistrationToken(db *sql.DB, token string) bool {
+	var legacy bool
+	db.QueryRow("SELECT legacy_token FROM registrations WHERE token=? AND trash_id IS NULL", token).Scan(&legacy)
+	return legacy
+}
 
 func GetRegistrationByEmailAndEvent(db *sql.DB, email string, eventID int64) (*Registration, error) {
 	r := &Registration{}
 	err := db.QueryRow(
-		`SELECT r.id, r.task_id, r.first_name, r.last_name, r.email, r.phone, r.token, r.created_at
+		`SELECT r.id, r.task_id, r.first_name, r.last_name, r.email, r.phone, r.lang, r.token, r.guests, r.created_at
 		FROM registrations r JOIN tasks t ON r.task_id = t.id
-		WHERE LOWER(r.email) = LOWER(?) AND t.event_id = ?`, email, eventID,
-	).Scan(&r.ID, &r.TaskID, &r.FirstName, &r.LastName, &r.Email, &r.Phone, &r.Token, &r.CreatedAt)
+		WHERE LOWER(r.email) = LOWER(?) AND t.event_id = ? AND r.trash_id IS NULL
+		ORDER BY r.member, r.id LIMIT 1`, email, eventID,
+	).Scan(&r.ID, &r.TaskID, &r.FirstName, &r.LastName, &r.Email, &r.Phone, &r.Lang, &r.Token, &r.Guests, &r.CreatedAt)
 	if err != nil {
 		return nil, err
 	}
 	return r, nil
 }
 
+// DeleteRegistration moves a registration to the trash.
 func DeleteRegistration(db *sql.DB, id int64) error {
-	_, err := db.Exec("DELETE FROM registrations WHERE id=?", id)
-	return err
+	var eventID int64
+	var name, email string
+	err := db.QueryRow(
+		`SELECT t.event_id, TRIM(r.first_name || ' ' || r.last_name), r.email
+		FROM registrations r JOIN tasks t ON r.task_id = t.id
+		WHERE r.id = ? AND r.trash_id IS NULL`, id,
+	).Scan(&eventID, &name, &email)
+	if err == sql.ErrNoRows {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	label := email
+	if name != "" {
+		label = name + " <" + email + ">"
+	}
+	return moveToTrash(db, "registration", id, eventID, label, label,
+		"UPDATE registrations SET trash_id=? WHERE id=? AND trash_id IS NULL",
+	)
 }
 
+// DeleteRegistrationByToken removes a registration for good: the person
+// cancelled it themselves, so there is nothing for an admin to restore.
 func DeleteRegistrationByToken(db *sql.DB, token string) error {
 	_, err := db.Exec("DELETE FROM registrations WHERE token=?", token)
 	return err
 }
 
+// UpdateRegistrationContact corrects the name, email address and phone of
+// registration id.
+func UpdateRegistrationContact(db *sql.DB, id int64, firstName, lastName, email, phone string) error {
+	_, err := db.Exec("UPDATE registrations SET first_name=?, last_name=?, email=?, phone=? WHERE id=? AND trash_id IS NULL",
+		firstName, lastName, email, phone, id)
+	return err
+}
+
+// RecordTermsAcceptance stamps a registration with the terms version the
+// person accepted at signup.
+func RecordTermsAcceptance(db *sql.DB, regID int64, version int) error {
+	_, err := db.Exec("UPDATE registrations SET terms_version=?, terms_accepted_at=CURRENT_TIMESTAMP WHERE id=?", version, regID)
+	return err
+}
+
+// SetRegistrationShowName records whether the registrant agreed to have
+// their first name shown under the task on the public page.
+func SetRegistrationShowName(db *sql.DB, regID int64, show bool) error {
+	_, err := db.Exec("UPDATE registrations SET show_name=? WHERE id=?", show, regID)
+	return err
+}
+
+// ListPublicNames returns, by task, the first names of the event's
+// registrants who agreed to show them, earliest first. Registrations held
+// for review stay hidden, and counts-only events show no names at all.
+func ListPublicNames(db *sql.DB, eventID int64) (map[int64][]string, error) {
+	rows, err := db.Query(`SELECT r.task_id, r.first_name FROM registrations r
+		JOIN tasks t ON t.id = r.task_id
+		JOIN events e ON e.id = t.event_id
+		WHERE t.event_id = ? AND e.counts_only = 0 AND r.show_name = 1 AND r.first_name != '' AND r.trash_id IS NULL
+			AND r.id NOT IN (SELECT registration_id FROM signup_reviews)
+		ORDER BY r.created_at, r.id`, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	names := map[int64][]string{}
+	for rows.Next() {
+		var taskID int64
+		var name string
+		if err := rows.Scan(&taskID, &name); err != nil {
+			return nil, err
+		}
+		names[taskID] = append(names[taskID], name)
+	}
+	return names, rows.Err()
+}
+
+// maxCommentLen bounds the comment a registrant can leave, in characters.
+const maxCommentLen = 1000
+
+// SetRegistrationComment stores the comment left with a registration.
+func SetRegistrationComment(db *sql.DB, regID int64, comment string) error {
+	_, err := db.Exec("UPDATE registrations SET comment=? WHERE id=?", comment, regID)
+	return err
+}
+
+// TermsAcceptance is one row of the admin terms report.
+type TermsAcceptance struct {
+	FirstName   string
+	LastName    string
+	Email       string
+	TaskTitleFR string
+	TaskTitleEN string
+	Version     int            // 0 when the person signed up before terms existed
+	AcceptedAt  sql.NullString // SQLite CURRENT_TIMESTAMP string
+}
+
+// ListTermsAcceptances returns every registration of the event with the
+// terms version it accepted, latest version first.
+func ListTermsAcceptances(db *sql.DB, eventID int64) ([]TermsAcceptance, error) {
+	rows, err := db.Query(
+		`SELECT r.first_name, r.last_name, r.email, t.title_fr, t.title_en, r.terms_version, r.terms_accepted_at
+		FROM registrations r JOIN tasks t ON r.task_id = t.id
+		WHERE t.event_id = ? AND r.trash_id IS NULL
+		ORDER BY r.terms_version DESC, r.last_name, r.first_name`, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []TermsAcceptance
+	for rows.Next() {
+		var a TermsAcceptance
+		if err := rows.Scan(&a.FirstName, &a.LastName, &a.Email, &a.TaskTitleFR, &a.TaskTitleEN, &a.Version, &a.AcceptedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, a)
+	}
+	return list, rows.Err()
+}
+
+// TermsVersion is the text of one version of an event's terms.
+type TermsVersion struct {
+	Version   int
+	TermsFR   string
+	TermsEN   string
+	UpdatedAt time.Time
+}
+
+// ListTermsVersions returns the event's terms history, latest first.
+func ListTermsVersions(db *sql.DB, eventID int64) ([]TermsVersion, error) {
+	rows, err := db.Query("SELECT version, terms_fr, terms_en, updated_at FROM event_terms WHERE event_id=? ORDER BY version DESC", eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []TermsVersion
+	for rows.Next() {
+		var v TermsVersion
+		if err := rows.Scan(&v.Version, &v.TermsFR, &v.TermsEN, &v.UpdatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, v)
+	}
+	return list, rows.Err()
+}
+
 func ListRegistrations(db *sql.DB, taskID int64) ([]Registration, error) {
-	rows, err := db.Query("SELECT id, task_id, first_name, last_name, email, phone, token, created_at FROM registrations WHERE task_id=? ORDER BY created_at", taskID)
+	rows, err := db.Query("SELECT id, task_id, first_name, last_name, email, phone, lang, token, guests, created_at FROM registrations WHERE task_id=? AND trash_id IS NULL ORDER BY created_at", taskID)
 	if err != nil {
 		return nil, err
 	}
@@ -838,7 +1936,7 @@ func ListRegistrations(db *sql.DB, taskID int64) ([]Registration, error) {
 	var regs []Registration
 	for rows.Next() {
 		var r Registration
-		rows.Scan(&r.ID, &r.TaskID, &r.FirstName, &r.LastName, &r.Email, &r.Phone, &r.Token, &r.CreatedAt)
+		rows.Scan(&r.ID, &r.TaskID, &r.FirstName, &r.LastName, &r.Email, &r.Phone, &r.Lang, &r.Token, &r.Guests, &r.CreatedAt)
 		regs = append(regs, r)
 	}
 	return regs, rows.Err()
@@ -846,6 +1944,7 @@ func ListRegistrations(db *sql.DB, taskID int64) ([]Registration, error) {
 
 type RegistrationExport struct {
 	ID           int64
+	TaskID       int64
 	GroupTitle   string
 	GroupTitleEN string
 	TaskTitle    string
@@ -854,41 +1953,71 @@ type RegistrationExport struct {
 	LastName     string
 	Email        string
 	Phone        string
+	Lang         string
+	Guests       int
+	Comment      string
+	Diet         string
+	Allergies    string
+	CheckedInAt  sql.NullString
 	CreatedAt    time.Time
 }
 
-func ListAllRegistrations(db *sql.DB, eventID int64) ([]RegistrationExport, error) {
-	rows, err := db.Query(`
-		WITH RECURSIVE root_group AS (
-			SELECT id, id AS root_id, title_fr, title_en
-			FROM task_groups WHERE parent_group_id IS NULL
-			UNION ALL
-			SELECT tg.id, rg.root_id, rg.title_fr, rg.title_en
-			FROM task_groups tg JOIN root_group rg ON tg.parent_group_id = rg.id
-		)
-		SELECT r.id, COALESCE(rg.title_fr, ''), COALESCE(rg.title_en, ''), t.title_fr, t.title_en, r.first_name, r.last_name, r.email, r.phone, r.created_at
-		FROM registrations r
-		JOIN tasks t ON r.task_id = t.id
-		LEFT JOIN root_group rg ON t.group_id = rg.id
-		WHERE t.event_id = ?
-		ORDER BY CASE WHEN rg.title_fr IS NOT NULL THEN 0 ELSE 1 END, rg.title_fr, r.last_name, r.first_name
-	`, eventID)
-	if err != nil {
-		return nil, err
-	}
+// registrationExportSQL selects the live registrations of an event, with
+// the top-level group of their task, for scanRegistrationExports. Callers
+// add their conditions and order.
+const registrationExportSQL = `
+	WITH RECURSIVE root_group AS (
+		SELECT id, id AS root_id, title_fr, title_en
+		FROM task_groups WHERE parent_group_id IS NULL
+		UNION ALL
+		SELECT tg.id, rg.root_id, rg.title_fr, rg.title_en
+		FROM task_groups tg JOIN root_group rg ON tg.parent_group_id = rg.id
+	)
+	SELECT r.id, r.task_id, COALESCE(rg.title_fr, ''), COALESCE(rg.title_en, ''), t.title_fr, t.title_en, r.first_name, r.last_name, r.email, r.phone, r.lang, r.guests, r.comment, r.diet, r.allergies, r.checked_in_at, r.created_at
+	FROM registrations r
+	JOIN tasks t ON r.task_id = t.id
+	LEFT JOIN root_group rg ON t.group_id = rg.id
+	WHERE t.event_id = ? AND r.trash_id IS NULL`
+
+// registrationExportOrder sorts registrations by top-level group, then name.
+const registrationExportOrder = `
+	ORDER BY CASE WHEN rg.title_fr IS NOT NULL THEN 0 ELSE 1 END, rg.title_fr, r.last_name, r.first_name`
+
+func scanRegistrationExports(rows *sql.Rows) ([]RegistrationExport, error) {
 	defer rows.Close()
 	var exports []RegistrationExport
 	for rows.Next() {
 		var e RegistrationExport
-		rows.Scan(&e.ID, &e.GroupTitle, &e.GroupTitleEN, &e.TaskTitle, &e.TaskTitleEN, &e.FirstName, &e.LastName, &e.Email, &e.Phone, &e.CreatedAt)
+		rows.Scan(&e.ID, &e.TaskID, &e.GroupTitle, &e.GroupTitleEN, &e.TaskTitle, &e.TaskTitleEN, &e.FirstName, &e.LastName, &e.Email, &e.Phone, &e.Lang, &e.Guests, &e.Comment, &e.Diet, &e.Allergies, &e.CheckedInAt, &e.CreatedAt)
 		exports = append(exports, e)
 	}
 	return exports, rows.Err()
 }
 
+func ListAllRegistrations(db *sql.DB, eventID int64) ([]RegistrationExport, error) {
+	rows, err := db.Query(registrationExportSQL+registrationExportOrder, eventID)
+	if err != nil {
+		return nil, err
+	}
+	return scanRegistrationExports(rows)
+}
+
+// countPeople sums registrations r with their guests.
+const countPeople = "COALESCE(SUM(1 + r.guests), 0)"
+
+// CountTaskRegistrations returns how many people are signed up for a task,
+// guests included.
+func CountTaskRegistrations(db *sql.DB, taskID int64) int {
+	var count int
+	db.QueryRow("SELECT "+countPeople+" FROM registrations r WHERE r.task_id=? AND r.trash_id IS NULL", taskID).Scan(&count)
+	return count
+}
+
+// CountRegistrations returns how many people are signed up for an event,
+// guests included.
 func CountRegistrations(db *sql.DB, eventID int64) int {
 	var count int
-	db.QueryRow("SELECT COUNT(*) FROM registrations r JOIN tasks t ON r.task_id=t.id WHERE t.event_id=?", eventID).Scan(&count)
+	db.QueryRow("SELECT "+countPeople+" FROM registrations r JOIN tasks t ON r.task_id=t.id WHERE t.event_id=? AND r.trash_id IS NULL", eventID).Scan(&count)
 	return count
 }
 
@@ -906,6 +2035,17 @@ func CollectTaskViews(tree []TreeNode) []TaskView {
 	return result
 }
 
+// openTaskViews returns the tasks of tree that aren't full.
+func openTaskViews(tree []TreeNode) []TaskView {
+	var open []TaskView
+	for _, v := range CollectTaskViews(tree) {
+		if !v.IsFull {
+			open = append(open, v)
+		}
+	}
+	return open
+}
+
 // ---- Attendance (RSVP) ----
 
 type Attendance struct {
@@ -916,15 +2056,42 @@ type Attendance struct {
 	Email     string
 	Phone     string
 	Attending bool
-	Message   string
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	// AdultGuests and ChildGuests are the people coming along with a yes,
+	// up to the event's MaxGuests.
+	AdultGuests int
+	ChildGuests int
+	Message     string
+	Lang        string // language of the latest response; drives follow-up emails
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// People is how many people an answer brings: nobody for a no.
+func (a Attendance) People() int {
+	if !a.Attending {
+		return 0
+	}
+	return 1 + a.AdultGuests + a.ChildGuests
+}
+
+const attendanceCols = "id, event_id, first_name, last_name, email, phone, attending, adult_guests, child_guests, message, lang, created_at, updated_at"
+
+func scanAttendance(row interface{ Scan(...any) error }) (*Attendance, error) {
+	a := &Attendance{}
+	var attendingInt int
+	err := row.Scan(&a.ID, &a.EventID, &a.FirstName, &a.LastName, &a.Email, &a.Phone, &attendingInt, &a.AdultGuests, &a.ChildGuests, &a.Message, &a.Lang, &a.CreatedAt, &a.UpdatedAt)
+	a.Attending = attendingInt != 0
+	return a, err
 }
 
-func UpsertAttendance(db *sql.DB, eventID int64, firstName, lastName, email, phone string, attending bool, message string) (*Attendance, error) {
+// UpsertAttendance records the answer of email to event eventID, replacing
+// an earlier one. A no brings no guests.
+func UpsertAttendance(db *sql.DB, eventID int64, firstName, lastName, email, phone string, attending bool, adultGuests, childGuests int, message, lang string) (*Attendance, error) {
 	attendingInt := 0
 	if attending {
 		attendingInt = 1
+	} else {
+		adultGuests, childGuests = 0, 0
 	}
 	// Try to find existing attendance by email for this event
 	var existingID int64
@@ -932,8 +2099,8 @@ func UpsertAttendance(db *sql.DB, eventID int64, firstName, lastName, email, pho
 	if err == nil {
 		// Update existing
 		_, err = db.Exec(
-			"UPDATE attendances SET first_name=?, last_name=?, phone=?, attending=?, message=?, updated_at=CURRENT_TIMESTAMP WHERE id=?",
-			firstName, lastName, phone, attendingInt, message, existingID,
+			"UPDATE attendances SET first_name=?, last_name=?, phone=?, attending=?, adult_guests=?, child_guests=?, message=?, lang=?, updated_at=CURRENT_TIMESTAMP WHERE id=?",
+			firstName, lastName, phone, attendingInt, adultGuests, childGuests, message, lang, existingID,
 		)
 		if err != nil {
 			return nil, err
@@ -942,8 +2109,8 @@ func UpsertAttendance(db *sql.DB, eventID int64, firstName, lastName, email, pho
 	}
 	// Insert new
 	res, err := db.Exec(
-		"INSERT INTO attendances (event_id, first_name, last_name, email, phone, attending, message) VALUES (?, ?, ?, ?, ?, ?, ?)",
-		eventID, firstName, lastName, email, phone, attendingInt, message,
+		"INSERT INTO attendances (event_id, first_name, last_name, email, phone, attending, adult_guests, child_guests, message, lang) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		eventID, firstName, lastName, email, phone, attendingInt, adultGuests, childGuests, message, lang,
 	)
 	if err != nil {
 		return nil, err
@@ -952,44 +2119,59 @@ func UpsertAttendance(db *sql.DB, eventID int64, firstName, lastName, email, pho
 	return GetAttendance(db, id)
 }
 
+// ImportAttendance records an answer imported from an availability poll.
+// Polls carry no email, so re-imports match on the name among email-less
+// rows. created reports whether a new row was inserted.
+func ImportAttendance(db *sql.DB, eventID int64, firstName, lastName string, attending bool, message string) (created bool, err error) {
+	attendingInt := 0
+	if attending {
+		attendingInt = 1
+	}
+	var existingID int64
+	err = db.QueryRow(
+		"SELECT id FROM attendances WHERE event_id=? AND email='' AND LOWER(first_name)=LOWER(?) AND LOWER(last_name)=LOWER(?)",
+		eventID, firstName, lastName,
+	).Scan(&existingID)
+	if err == nil {
+		_, err = db.Exec("UPDATE attendances SET attending=?, message=?, updated_at=CURRENT_TIMESTAMP WHERE id=?", attendingInt, message, existingID)
+		return false, err
+	}
+	_, err = db.Exec(
+		"INSERT INTO attendances (event_id, first_name, last_name, email, attending, message) VALUES (?, ?, ?, '', ?, ?)",
+		eventID, firstName, lastName, attendingInt, message,
+	)
+	return err == nil, err
+}
+
 func GetAttendance(db *sql.DB, id int64) (*Attendance, error) {
-	a := &Attendance{}
-	var attendingInt int
-	err := db.QueryRow(
-		"SELECT id, event_id, first_name, last_name, email, phone, attending, message, created_at, updated_at FROM attendances WHERE id=?", id,
-	).Scan(&a.ID, &a.EventID, &a.FirstName, &a.LastName, &a.Email, &a.Phone, &attendingInt, &a.Message, &a.CreatedAt, &a.UpdatedAt)
-	a.Attending = attendingInt != 0
-	return a, err
+	return scanAttendance(db.QueryRow("SELECT "+attendanceCols+" FROM attendances WHERE id=?", id))
 }
 
 func GetAttendanceByEmail(db *sql.DB, email string, eventID int64) (*Attendance, error) {
-	a := &Attendance{}
-	var attendingInt int
-	err := db.QueryRow(
-		"SELECT id, event_id, first_name, last_name, email, phone, attending, message, created_at, updated_at FROM attendances WHERE LOWER(email)=LOWER(?) AND event_id=?", email, eventID,
-	).Scan(&a.ID, &a.EventID, &a.FirstName, &a.LastName, &a.Email, &a.Phone, &attendingInt, &a.Message, &a.CreatedAt, &a.UpdatedAt)
+	// Imported poll answers have no email; never let a blank lookup hit them.
+	if email == "" {
+		return nil, sql.ErrNoRows
+	}
+	a, err := scanAttendance(db.QueryRow("SELECT "+attendanceCols+" FROM attendances WHERE LOWER(email)=LOWER(?) AND event_id=?", email, eventID))
 	if err != nil {
 		return nil, err
 	}
-	a.Attending = attendingInt != 0
 	return a, nil
 }
 
 func ListAttendances(db *sql.DB, eventID int64) ([]Attendance, error) {
-	rows, err := db.Query(
-		"SELECT id, event_id, first_name, last_name, email, phone, attending, message, created_at, updated_at FROM attendances WHERE event_id=? ORDER BY last_name, first_name", eventID,
-	)
+	rows, err := db.Query("SELECT "+attendanceCols+" FROM attendances WHERE event_id=? ORDER BY last_name, first_name", eventID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 	var attendances []Attendance
 	for rows.Next() {
-		var a Attendance
-		var attendingInt int
-		rows.Scan(&a.ID, &a.EventID, &a.FirstName, &a.LastName, &a.Email, &a.Phone, &attendingInt, &a.Message, &a.CreatedAt, &a.UpdatedAt)
-		a.Attending = attendingInt != 0
-		attendances = append(attendances, a)
+		a, err := scanAttendance(rows)
+		if err != nil {
+			return nil, err
+		}
+		attendances = append(attendances, *a)
 	}
 	return attendances, rows.Err()
 }
@@ -1000,6 +2182,35 @@ func CountAttendances(db *sql.DB, eventID int64) (yesCount, totalCount int) {
 	return
 }
 
+// Headcount is how many people are coming to an attendance event: those
+// who answered yes and the adults among their guests, and the children.
+type Headcount struct {
+	Adults   int
+	Children int
+}
+
+// Total is everyone coming.
+func (h Headcount) Total() int { return h.Adults + h.Children }
+
+// AttendanceHeadcount counts the people coming to event eventID.
+func AttendanceHeadcount(db *sql.DB, eventID int64) Headcount {
+	var h Headcount
+	db.QueryRow("SELECT COALESCE(SUM(1 + adult_guests), 0), COALESCE(SUM(child_guests), 0) FROM attendances WHERE event_id=? AND attending=1", eventID).Scan(&h.Adults, &h.Children)
+	return h
+}
+
+// UpdateAttendance saves an organizer's edit of attendance a.
+func UpdateAttendance(db *sql.DB, a *Attendance) error {
+	if !a.Attending {
+		a.AdultGuests, a.ChildGuests = 0, 0
+	}
+	_, err := db.Exec(
+		"UPDATE attendances SET first_name=?, last_name=?, email=?, phone=?, attending=?, adult_guests=?, child_guests=?, message=?, lang=?, updated_at=CURRENT_TIMESTAMP WHERE id=?",
+		a.FirstName, a.LastName, a.Email, a.Phone, a.Attending, a.AdultGuests, a.ChildGuests, a.Message, a.Lang, a.ID,
+	)
+	return err
+}
+
 func DeleteAttendance(db *sql.DB, id int64) error {
 	_, err := db.Exec("DELETE FROM attendances WHERE id=?", id)
 	return err
@@ -1257,3 +2468,577 @@ func ListEmailMessages(db *sql.DB, eventID int64) ([]EmailMessage, error) {
 	}
 	return msgs, rows.Err()
 }
+
+// ---- Outbox ----
+
+// OutboxEmail is a queued outgoing email. Rows start "pending", become "sent"
+// once the sender accepts them, or "failed" after outboxMaxAttempts tries.
+type OutboxEmail struct {
+	ID            int64
+	Kind          string // "signup_confirmation", "admin_notification"
+	ToEmail       string
+	ReplyTo       string
+	Subject       string
+	HTMLBody      string
+	Attachments   []emailAttachment
+	Status        string // pending | sent | failed
+	Attempts      int
+	NextAttemptAt time.Time
+	LastError     string
+	MessageID     string
+	CreatedAt     time.Time
+	SentAt        sql.NullString
+}
+
+const outboxCols = "id, kind, to_email, reply_to, subject, html_body, attachments, status, attempts, next_attempt_at, last_error, message_id, created_at, sent_at"
+
+// outboxTime formats t the way SQLite's CURRENT_TIMESTAMP does, so stored
+// times compare correctly as text.
+func outboxTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04:05")
+}
+
+func scanOutboxEmail(row interface{ Scan(...any) error }) (*OutboxEmail, error) {
+	m := &OutboxEmail{}
+	var attachments string
+	err := row.Scan(&m.ID, &m.Kind, &m.ToEmail, &m.ReplyTo, &m.Subject, &m.HTMLBody, &attachments,
+		&m.Status, &m.Attempts, &m.NextAttemptAt, &m.LastError, &m.MessageID, &m.CreatedAt, &m.SentAt)
+	if err != nil {
+		return m, err
+	}
+	if attachments != "" {
+		if err := json.Unmarshal([]byte(attachments), &m.Attachments); err != nil {
+			return m, fmt.Errorf("outbox %d: attachments: %w", m.ID, err)
+		}
+	}
+	return m, nil
+}
+
+// EnqueueEmail stores an email about event eventID (0 for none) for delivery
+// by the outbox worker, due now.
+func EnqueueEmail(db *sql.DB, kind string, eventID int64, to, replyTo, subject, htmlBody string, attachments []emailAttachment) (int64, error) {
+	var encoded string
+	if len(attachments) > 0 {
+		b, err := json.Marshal(attachments)
+		if err != nil {
+			return 0, err
+		}
+		encoded = string(b)
+	}
+	res, err := db.Exec(`INSERT INTO outbox (kind, event_id, to_email, reply_to, subject, html_body, attachments) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		kind, eventID, to, replyTo, subject, htmlBody, encoded)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+func GetOutboxEmail(db *sql.DB, id int64) (*OutboxEmail, error) {
+	return scanOutboxEmail(db.QueryRow("SELECT "+outboxCols+" FROM outbox WHERE id=?", id))
+}
+
+// DueOutboxEmails returns pending emails whose next attempt is at or before now,
+// oldest first.
+func DueOutboxEmails(db *sql.DB, now time.Time, limit int) ([]OutboxEmail, error) {
+	return queryOutbox(db, "SELECT "+outboxCols+" FROM outbox WHERE status='pending' AND next_attempt_at <= ? ORDER BY next_attempt_at, id LIMIT ?",
+		outboxTime(now), limit)
+}
+
+// ListUndeliveredEmails returns failed and still-pending emails, newest first,
+// for the admin outbox page.
+func ListUndeliveredEmails(db *sql.DB) ([]OutboxEmail, error) {
+	return queryOutbox(db, "SELECT "+outboxCols+" FROM outbox WHERE status != 'sent' ORDER BY status, created_at DESC, id DESC")
+}
+
+// CountFailedEmails returns how many outbox emails have been given up on.
+func CountFailedEmails(db *sql.DB) int {
+	var n int
+	db.QueryRow("SELECT COUNT(*) FROM outbox WHERE status='failed'").Scan(&n)
+	return n
+}
+
+func queryOutbox(db *sql.DB, query string, args ...any) ([]OutboxEmail, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var msgs []OutboxEmail
+	for rows.Next() {
+		m, err := scanOutboxEmail(rows)
+		if err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, *m)
+	}
+	return msgs, rows.Err()
+}
+
+// MarkOutboxSent records a successful delivery attempt.
+func MarkOutboxSent(db *sql.DB, id int64, messageID string) error {
+	_, err := db.Exec(`UPDATE outbox SET status='sent', attempts=attempts+1, message_id=?, last_error='', sent_at=CURRENT_TIMESTAMP WHERE id=?`,
+		messageID, id)
+	return err
+}
+
+// MarkOutboxAttemptFailed records a failed delivery attempt. The email is
+// retried at next, or marked "failed" for good when giveUp is set.
+func MarkOutboxAttemptFailed(db *sql.DB, id int64, errMsg string, next time.Time, giveUp bool) error {
+	status := "pending"
+	if giveUp {
+		status = "failed"
+	}
+	_, err := db.Exec(`UPDATE outbox SET status=?, attempts=attempts+1, last_error=?, next_attempt_at=? WHERE id=?`,
+		status, errMsg, outboxTime(next), id)
+	return err
+}
+
+// RequeueOutboxEmail puts a failed email back in the queue with a fresh
+// attempt budget, due now.
+func RequeueOutboxEmail(db *sql.DB, id int64) error {
+	_, err := db.Exec(`UPDATE outbox SET status='pending', attempts=0, next_attempt_at=CURRENT_TIMESTAMP WHERE id=? AND status != 'sent'`, id)
+	return err
+}
+
+func DeleteOutboxEmail(db *sql.DB, id int64) error {
+	_, err := db.Exec("DELETE FROM outbox WHERE id=?", id)
+	return err
+}
+
+// ---- Settings ----
+
+// SMTPSettings is the outgoing mail relay entered on the admin settings page.
+// An empty Host means none is set and the environment configuration applies.
+type SMTPSettings struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+	FromName string
+}
+
+func (s SMTPSettings) Configured() bool { return s.Host != "" }
+
+var smtpSettingKeys = []string{"smtp_host", "smtp_port", "smtp_username", "smtp_password", "smtp_from", "smtp_from_name"}
+
+func (s *SMTPSettings) fields() []*string {
+	return []*string{&s.Host, &s.Port, &s.Username, &s.Password, &s.From, &s.FromName}
+}
+
+func GetSMTPSettings(db *sql.DB) (SMTPSettings, error) {
+	var s SMTPSettings
+	fields := s.fields()
+	for i, key := range smtpSettingKeys {
+		err := db.QueryRow("SELECT value FROM settings WHERE key=?", key).Scan(fields[i])
+		if err != nil && err != sql.ErrNoRows {
+			return SMTPSettings{}, err
+		}
+	}
+	return s, nil
+}
+
+// GetSetting returns the value stored under key, or "" if none.
+func GetSetting(db *sql.DB, key string) string {
+	var v string
+	db.QueryRow("SELECT value FROM settings WHERE key=?", key).Scan(&v)
+	return v
+}
+
+func SetSetting(db *sql.DB, key, value string) error {
+	_, err := db.Exec("INSERT INTO settings (key, value) VALUES (?, ?) ON CONFLICT(key) DO UPDATE SET value=excluded.value", key, value)
+	return err
+}
+
+// InitSetting stores value under key unless a value is already there, and
+// returns the stored one.
+func InitSetting(db *sql.DB, key, value string) (string, error) {
+	if _, err := db.Exec("INSERT OR IGNORE INTO settings (key, value) VALUES (?, ?)", key, value); err != nil {
+		return "", err
+	}
+	var stored string
+	err := db.QueryRow("SELECT value FROM settings WHERE key=?", key).Scan(&stored)
+	return stored, err
+}
+
+// SettingSecret returns the random secret stored under key, creating it on
+// first use. Used to sign links that must not be forgeable.
+func SettingSecret(db *sql.DB, key string) (string, error) {
+	return InitSetting(db, key, GenerateToken()+GenerateToken())
+}
+
+func SaveSMTPSettings(db *sql.DB, s SMTPSettings) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	fields := s.fields()
+	for i, key := range smtpSettingKeys {
+		if _, err := tx.Exec("INSERT INTO settings (key, value) VALUES (?, ?) ON CONFLICT(key) DO UPDATE SET value=excluded.value",
+			key, *fields[i]); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ---- Email preferences ----
+
+// IsUnsubscribed reports whether the address opted out of non-essential
+// email (invitations, reminders). Confirmations are always sent.
+func IsUnsubscribed(db *sql.DB, email string) bool {
+	var at sql.NullString
+	db.QueryRow("SELECT unsubscribed_at FROM email_preferences WHERE email=?", strings.ToLower(strings.TrimSpace(email))).Scan(&at)
+	return at.Valid
+}
+
+func SetUnsubscribed(db *sql.DB, email string, unsubscribed bool) error {
+	var at any
+	if unsubscribed {
+		at = time.Now().UTC().Format("2006-01-02 15:04:05")
+	}
+	_, err := db.Exec(`INSERT INTO email_preferences (email, unsubscribed_at, updated_at) VALUES (?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(email) DO UPDATE SET unsubscribed_at=excluded.unsubscribed_at, updated_at=CURRENT_TIMESTAMP`,
+		strings.ToLower(strings.TrimSpace(email)), at)
+	return err
+}
+
+// ---- Admin sessions ----
+
+// Sessions end after 30 minutes without activity. A remembered device lasts
+// 30 days without activity instead, its token changing every day; every
+// session ends after 90 days regardless.
+const (
+	sessionIdleTimeout  = 30 * time.Minute
+	rememberIdleTimeout = 30 * 24 * time.Hour
+	sessionMaxAge       = 90 * 24 * time.Hour
+	sessionRotateEvery  = 24 * time.Hour
+	// sessionRotateGrace keeps a replaced token valid for requests that
+	// were already in flight when it changed.
+	sessionRotateGrace = time.Minute
+)
+
+// Session is one logged-in admin browser.
+type Session struct {
+	ID        int64
+	UserAgent string
+	Remember  bool // "remember this device"
+	CreatedAt time.Time
+	LastSeen  time.Time
+	RotatedAt time.Time // when the token last changed
+}
+
+// RotationDue reports whether a remembered session's token should change.
+func (s Session) RotationDue() bool {
+	return s.Remember && time.Since(s.RotatedAt) >= sessionRotateEvery
+}
+
+// sessionTokenHash is what the sessions table stores in place of the cookie
+// value, so reading the database doesn't hand out live sessions.
+func sessionTokenHash(token string) string {
+	return hex.EncodeToString(sha256Sum([]byte(token)))
+}
+
+// sessionActive is the SQL condition for a session that has not expired.
+func sessionActive() (string, []any) {
+	now := time.Now().UTC()
+	return "last_seen > CASE WHEN remember THEN ? ELSE ? END AND created_at > ?", []any{
+		now.Add(-rememberIdleTimeout).Format("2006-01-02 15:04:05"),
+		now.Add(-sessionIdleTimeout).Format("2006-01-02 15:04:05"),
+		now.Add(-sessionMaxAge).Format("2006-01-02 15:04:05"),
+	}
+}
+
+const sessionSelectCols = "id, user_agent, remember, created_at, last_seen, rotated_at"
+
+func scanSession(row interface{ Scan(...any) error }) (Session, error) {
+	var s Session
+	var rotated sql.NullTime
+	err := row.Scan(&s.ID, &s.UserAgent, &s.Remember, &s.CreatedAt, &s.LastSeen, &rotated)
+	s.RotatedAt = s.CreatedAt
+	if rotated.Valid {
+		s.RotatedAt = rotated.Time
+	}
+	return s, err
+}
+
+// CreateSession opens a session bound to passwordTag and returns the token
+// for the cookie.
+func CreateSession(db *sql.DB, passwordTag, userAgent string, remember bool) (string, error) {
+	token := GenerateToken() + GenerateToken()
+	_, err := db.Exec("INSERT INTO sessions (token_hash, password_tag, user_agent, remember) VALUES (?, ?, ?, ?)",
+		sessionTokenHash(token), passwordTag, userAgent, remember)
+	return token, err
+}
+
+// LookupSession returns the active session for token, refreshing its last
+// activity. Sessions opened under another password don't match; a token
+// replaced by RotateSession still does for sessionRotateGrace.
+func LookupSession(db *sql.DB, token, passwordTag string) (Session, error) {
+	cond, args := sessionActive()
+	hash := sessionTokenHash(token)
+	grace := time.Now().UTC().Add(-sessionRotateGrace).Format("2006-01-02 15:04:05")
+	s, err := scanSession(db.QueryRow("SELECT "+sessionSelectCols+" FROM sessions WHERE (token_hash=? OR (prev_token_hash=? AND rotated_at > ?)) AND password_tag=? AND "+cond,
+		append([]any{hash, hash, grace, passwordTag}, args...)...))
+	if err != nil {
+		return Session{}, err
+	}
+	_, err = db.Exec("UPDATE sessions SET last_seen=CURRENT_TIMESTAMP WHERE id=?", s.ID)
+	return s, err
+}
+
+// RotateSession gives session id a new token in place of token and returns
+// it. ok is false when token is no longer the session's current one, as
+// when a concurrent request rotated it first.
+func RotateSession(db *sql.DB, id int64, token string) (newToken string, ok bool, err error) {
+	newToken = GenerateToken() + GenerateToken()
+	res, err := db.Exec("UPDATE sessions SET prev_token_hash=token_hash, token_hash=?, rotated_at=CURRENT_TIMESTAMP WHERE id=? AND token_hash=?",
+		sessionTokenHash(newToken), id, sessionTokenHash(token))
+	if err != nil {
+		return "", false, err
+	}
+	n, _ := res.RowsAffected()
+	return newToken, n == 1, nil
+}
+
+// ListSessions returns the active sessions under passwordTag, most recently
+// used first.
+func ListSessions(db *sql.DB, passwordTag string) ([]Session, error) {
+	cond, args := sessionActive()
+	rows, err := db.Query("SELECT "+sessionSelectCols+" FROM sessions WHERE password_tag=? AND "+cond+" ORDER BY last_seen DESC, id DESC",
+		append([]any{passwordTag}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var sessions []Session
+	for rows.Next() {
+		s, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+func DeleteSession(db *sql.DB, id int64) error {
+	_, err := db.Exec("DELETE FROM sessions WHERE id=?", id)
+	return err
+}
+
+// DeleteAllSessions logs every browser out.
+func DeleteAllSessions(db *sql.DB) error {
+	_, err := db.Exec("DELETE FROM sessions")
+	return err
+}
+
+// PurgeSessions drops expired sessions and those opened under a previous
+// password.
+func PurgeSessions(db *sql.DB, passwordTag string) error {
+	cond, args := sessionActive()
+	_, err := db.Exec("DELETE FROM sessions WHERE password_tag != ? OR NOT ("+cond+")", append([]any{passwordTag}, args...)...)
+	return err
+}
+
+// ---- Admin login links ----
+
+// CreateLoginLink stores a one-time login link for email, valid for ttl, and
+// returns its token. Like session tokens, only the hash is stored.
+func CreateLoginLink(db *sql.DB, email string, ttl time.Duration) (string, error) {
+	token := GenerateToken() + GenerateToken()
+	expires := time.Now().UTC().Add(ttl).Format("2006-01-02 15:04:05")
+	_, err := db.Exec("INSERT INTO login_links (token_hash, email, expires_at) VALUES (?, ?, ?)",
+		sessionTokenHash(token), email, expires)
+	return token, err
+}
+
+// LoginLinkEmail returns the address an unused, unexpired link was sent to.
+func LoginLinkEmail(db *sql.DB, token string) (string, error) {
+	var email string
+	err := db.QueryRow("SELECT email FROM login_links WHERE token_hash=? AND used_at IS NULL AND expires_at > ?",
+		sessionTokenHash(token), time.Now().UTC().Format("2006-01-02 15:04:05")).Scan(&email)
+	return email, err
+}
+
+// UseLoginLink marks a link used and returns its address; it fails with
+// sql.ErrNoRows if the link is unknown, expired or already used.
+func UseLoginLink(db *sql.DB, token string) (string, error) {
+	email, err := LoginLinkEmail(db, token)
+	if err != nil {
+		return "", err
+	}
+	res, err := db.Exec("UPDATE login_links SET used_at=CURRENT_TIMESTAMP WHERE token_hash=? AND used_at IS NULL", sessionTokenHash(token))
+	if err != nil {
+		return "", err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return "", sql.ErrNoRows
+	}
+	return email, nil
+}
+
+// RecentLoginLink reports whether a link was sent to email within d, to keep
+// the login form from being used to flood someone's inbox.
+func RecentLoginLink(db *sql.DB, email string, d time.Duration) bool {
+	var n int
+	db.QueryRow("SELECT COUNT(*) FROM login_links WHERE email=? AND created_at > ?",
+		email, time.Now().UTC().Add(-d).Format("2006-01-02 15:04:05")).Scan(&n)
+	return n > 0
+}
+
+// PurgeLoginLinks drops expired links.
+func PurgeLoginLinks(db *sql.DB) error {
+	_, err := db.Exec("DELETE FROM login_links WHERE expires_at <= ?", time.Now().UTC().Format("2006-01-02 15:04:05"))
+	return err
+}
+
+// ---- API tokens ----
+
+// APIToken lets a script call the JSON endpoints without a session.
+type APIToken struct {
+	ID        int64
+	Name      string
+	Scope     string // "read" or "write"
+	CreatedAt time.Time
+	LastUsed  sql.NullTime
+}
+
+// CreateAPIToken stores a new token and returns its value, which can't be
+// recovered afterwards.
+func CreateAPIToken(db *sql.DB, name, scope string) (string, error) {
+	token := GenerateToken() + GenerateToken()
+	_, err := db.Exec("INSERT INTO api_tokens (name, token_hash, scope) VALUES (?, ?, ?)", name, sessionTokenHash(token), scope)
+	return token, err
+}
+
+// LookupAPIToken finds the token with value token and records its use.
+func LookupAPIToken(db *sql.DB, token string) (*APIToken, error) {
+	t := &APIToken{}
+	err := db.QueryRow("SELECT id, name, scope, created_at, last_used FROM api_tokens WHERE token_hash=?", sessionTokenHash(token)).
+		Scan(&t.ID, &t.Name, &t.Scope, &t.CreatedAt, &t.LastUsed)
+	if err != nil {
+		return nil, err
+	}
+	_, err = db.Exec("UPDATE api_tokens SET last_used=CURRENT_TIMESTAMP WHERE id=?", t.ID)
+	return t, err
+}
+
+func ListAPITokens(db *sql.DB) ([]APIToken, error) {
+	rows, err := db.Query("SELECT id, name, scope, created_at, last_used FROM api_tokens ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var tokens []APIToken
+	for rows.Next() {
+		var t APIToken
+		if err := rows.Scan(&t.ID, &t.Name, &t.Scope, &t.CreatedAt, &t.LastUsed); err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, t)
+	}
+	return tokens, rows.Err()
+}
+
+func DeleteAPIToken(db *sql.DB, id int64) error {
+	_, err := db.Exec("DELETE FROM api_tokens WHERE id=?", id)
+	return err
+}
+
+// ---- Signup abuse ----
+
+// LogSignup records where a public signup came from.
+func LogSignup(db *sql.DB, ip, email string) error {
+	_, err := db.Exec("INSERT INTO signup_log (ip, email) VALUES (?, ?)", ip, strings.ToLower(email))
+	return err
+}
+
+// CountSignupsFrom returns how many signups came from ip within d, and with
+// how many distinct email addresses.
+func CountSignupsFrom(db *sql.DB, ip string, d time.Duration) (signups, emails int) {
+	db.QueryRow("SELECT COUNT(*), COUNT(DISTINCT email) FROM signup_log WHERE ip=? AND created_at > ?",
+		ip, time.Now().UTC().Add(-d).Format("2006-01-02 15:04:05")).Scan(&signups, &emails)
+	return signups, emails
+}
+
+// PurgeSignupLog drops entries older than d.
+func PurgeSignupLog(db *sql.DB, d time.Duration) error {
+	_, err := db.Exec("DELETE FROM signup_log WHERE created_at <= ?", time.Now().UTC().Add(-d).Format("2006-01-02 15:04:05"))
+	return err
+}
+
+// SignupReview is a registration held for review, with what it was signed
+// up for.
+type SignupReview struct {
+	Registration
+	EventID      int64
+	EventTitleFR string
+	EventTitleEN string
+	TaskTitleFR  string
+	TaskTitleEN  string
+	IP           string
+	Reason       string // "rate" or "emails", see abuse.go
+	FlaggedAt    time.Time
+}
+
+// FlagRegistration puts a registration in the review queue.
+func FlagRegistration(db *sql.DB, regID int64, ip, reason string) error {
+	_, err := db.Exec("INSERT OR REPLACE INTO signup_reviews (registration_id, ip, reason) VALUES (?, ?, ?)", regID, ip, reason)
+	return err
+}
+
+// ClearSignupReview takes a registration out of the review queue.
+func ClearSignupReview(db *sql.DB, regID int64) error {
+	_, err := db.Exec("DELETE FROM signup_reviews WHERE registration_id=?", regID)
+	return err
+}
+
+const signupReviewQuery = `SELECT r.id, r.task_id, r.first_name, r.last_name, r.email, r.phone, r.lang, r.token, r.created_at,
+		e.id, e.title_fr, e.title_en, t.title_fr, t.title_en, sr.ip, sr.reason, sr.created_at
+	FROM signup_reviews sr
+	JOIN registrations r ON r.id = sr.registration_id
+	JOIN tasks t ON t.id = r.task_id
+	JOIN events e ON e.id = t.event_id
+	WHERE r.trash_id IS NULL`
+
+func scanSignupReview(row interface{ Scan(...any) error }) (*SignupReview, error) {
+	var s SignupReview
+	err := row.Scan(&s.ID, &s.TaskID, &s.FirstName, &s.LastName, &s.Email, &s.Phone, &s.Lang, &s.Token, &s.CreatedAt,
+		&s.EventID, &s.EventTitleFR, &s.EventTitleEN, &s.TaskTitleFR, &s.TaskTitleEN, &s.IP, &s.Reason, &s.FlaggedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// GetSignupReview returns the queued registration regID.
+func GetSignupReview(db *sql.DB, regID int64) (*SignupReview, error) {
+	return scanSignupReview(db.QueryRow(signupReviewQuery+" AND sr.registration_id=?", regID))
+}
+
+// ListSignupReviews returns the review queue, oldest first.
+func ListSignupReviews(db *sql.DB) ([]SignupReview, error) {
+	rows, err := db.Query(signupReviewQuery + " ORDER BY sr.created_at, sr.registration_id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []SignupReview
+	for rows.Next() {
+		s, err := scanSignupReview(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *s)
+	}
+	return list, rows.Err()
+}
+
+// CountSignupReviews returns how many registrations wait for review.
+func CountSignupReviews(db *sql.DB) int {
+	var n int
+	db.QueryRow("SELECT COUNT(*) FROM signup_reviews sr JOIN registrations r ON r.id = sr.registration_id WHERE r.trash_id IS NULL").Scan(&n)
+	return n
+}
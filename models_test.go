@@ -2,8 +2,11 @@ package main
 
 import (
 	"database/sql"
+	"fmt"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 // ---- Slug generation ----
@@ -170,7 +173,7 @@ func TestRegistration(t *testing.T) {
 	tk := seedTask(t, db, e.ID, "Cuisine", int64Ptr(2))
 
 	// Register
-	reg, err := RegisterForTask(db, tk.ID, "Alice", "Dupont", "alice@test.com", "0601")
+	reg, err := RegisterForTask(db, tk.ID, "Alice", "Dupont", "alice@test.com", "0601", "fr")
 	if err != nil {
 		t.Fatalf("register: %v", err)
 	}
@@ -186,6 +189,9 @@ func TestRegistration(t *testing.T) {
 	if got.Email != "alice@test.com" {
 		t.Errorf("email = %q", got.Email)
 	}
+	if got.Lang != "fr" {
+		t.Errorf("lang = %q, want fr", got.Lang)
+	}
 
 	// List
 	regs, _ := ListRegistrations(db, tk.ID)
@@ -212,12 +218,12 @@ func TestRegistrationSlotLimit(t *testing.T) {
 	e := seedEvent(t, db)
 	tk := seedTask(t, db, e.ID, "Limited", int64Ptr(1))
 
-	_, err := RegisterForTask(db, tk.ID, "Alice", "Dupont", "alice@test.com", "0601")
+	_, err := RegisterForTask(db, tk.ID, "Alice", "Dupont", "alice@test.com", "0601", "fr")
 	if err != nil {
 		t.Fatalf("first registration: %v", err)
 	}
 
-	_, err = RegisterForTask(db, tk.ID, "Bob", "Martin", "bob@test.com", "0602")
+	_, err = RegisterForTask(db, tk.ID, "Bob", "Martin", "bob@test.com", "0602", "fr")
 	if err == nil {
 		t.Fatal("expected task_full error")
 	}
@@ -232,7 +238,7 @@ func TestRegistrationUnlimited(t *testing.T) {
 	tk := seedTask(t, db, e.ID, "Unlimited", nil)
 
 	for i := 0; i < 10; i++ {
-		_, err := RegisterForTask(db, tk.ID, "User", "Test", "user@test.com", "0600")
+		_, err := RegisterForTask(db, tk.ID, "User", "Test", fmt.Sprintf("user%d@test.com", i), "0600", "fr")
 		if err != nil {
 			t.Fatalf("registration %d: %v", i, err)
 		}
@@ -253,7 +259,7 @@ func TestGetRegistrationByEmailAndEvent(t *testing.T) {
 	}
 
 	// Register
-	reg, _ := RegisterForTask(db, tk.ID, "Alice", "Dupont", "alice@test.com", "0601")
+	reg, _ := RegisterForTask(db, tk.ID, "Alice", "Dupont", "alice@test.com", "0601", "fr")
 
 	// Find by exact email
 	found, err := GetRegistrationByEmailAndEvent(db, "alice@test.com", e.ID)
@@ -282,6 +288,165 @@ func TestGetRegistrationByEmailAndEvent(t *testing.T) {
 	}
 }
 
+func TestRegisterGroupForTask(t *testing.T) {
+	db := testDB(t)
+	e := seedEvent(t, db)
+	tk := seedTask(t, db, e.ID, "Task", int64Ptr(4))
+
+	if _, err := RegisterGroupForTask(db, tk.ID, 1, "Alice", "Dupont", "alice@test.com", "0601", "fr"); err != errTooManyGuests {
+		t.Errorf("guests on a task without any: err = %v", err)
+	}
+	tk.MaxGuests = 2
+	UpdateTask(db, tk)
+	reg, err := RegisterGroupForTask(db, tk.ID, 2, "Alice", "Dupont", "alice@test.com", "0601", "fr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := GetRegistrationByToken(db, reg.Token); got.Guests != 2 {
+		t.Errorf("guests = %d, want 2", got.Guests)
+	}
+	if _, err := RegisterGroupForTask(db, tk.ID, 1, "Bob", "Martin", "bob@test.com", "0602", "fr"); err == nil || err.Error() != "task_full" {
+		t.Errorf("group bigger than the slots left: err = %v", err)
+	}
+
+	views, _ := GetTaskViews(db, e.ID)
+	if v := views[0]; v.RegCount != 3 || v.SlotsLeft != 1 || v.GuestsLeft != 0 {
+		t.Errorf("view: %d signed up, %d left, %d guests left", v.RegCount, v.SlotsLeft, v.GuestsLeft)
+	}
+	if n := CountRegistrations(db, e.ID); n != 3 {
+		t.Errorf("CountRegistrations = %d, want 3", n)
+	}
+}
+
+func TestRegisterForTaskOncePerEvent(t *testing.T) {
+	db := testDB(t)
+	e := seedEvent(t, db)
+	tk1 := seedTask(t, db, e.ID, "Task A", nil)
+	tk2 := seedTask(t, db, e.ID, "Task B", nil)
+
+	if _, err := RegisterForTask(db, tk1.ID, "Alice", "Dupont", "alice@test.com", "0601", "fr"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RegisterForTask(db, tk2.ID, "Alice", "Dupont", "ALICE@test.com", "0601", "fr"); err != errAlreadyRegistered {
+		t.Errorf("second registration in the event: err = %v", err)
+	}
+	e2 := &Event{TitleFR: "Other", EventDate: "2026-07-01"}
+	CreateEvent(db, e2)
+	other := seedTask(t, db, e2.ID, "Task", nil)
+	if _, err := RegisterForTask(db, other.ID, "Alice", "Dupont", "alice@test.com", "0601", "fr"); err != nil {
+		t.Errorf("registration in another event: %v", err)
+	}
+
+	// Anonymized registrations don't hold on to anything.
+	AnonymizeEvent(db, e.ID)
+	if _, err := RegisterForTask(db, tk1.ID, "", "", "", "", "fr"); err != nil {
+		t.Errorf("registration after anonymization: %v", err)
+	}
+}
+
+func TestRegisterForTaskPerPersonLimit(t *testing.T) {
+	db := testDB(t)
+	e := seedEvent(t, db)
+	e.MaxTasksPerPerson = 2
+	UpdateEvent(db, e)
+	tk1 := seedTask(t, db, e.ID, "Task A", nil)
+	tk2 := seedTask(t, db, e.ID, "Task B", nil)
+	tk3 := seedTask(t, db, e.ID, "Task C", nil)
+
+	first, err := RegisterForTask(db, tk1.ID, "Alice", "Dupont", "alice@test.com", "0601", "fr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RegisterForTask(db, tk2.ID, "Alice", "Dupont", "alice@test.com", "0601", "fr"); err != nil {
+		t.Fatalf("second task: %v", err)
+	}
+	if _, err := RegisterForTask(db, tk1.ID, "Alice", "Dupont", "alice@test.com", "0601", "fr"); err != errAlreadyRegistered {
+		t.Errorf("same task twice: err = %v", err)
+	}
+	if _, err := RegisterForTask(db, tk3.ID, "Alice", "Dupont", "Alice@test.com", "0601", "fr"); err != errTaskLimit {
+		t.Errorf("third task: err = %v", err)
+	}
+
+	// Cancelling one frees its slot.
+	DeleteRegistration(db, first.ID)
+	if _, err := RegisterForTask(db, tk3.ID, "Alice", "Dupont", "alice@test.com", "0601", "fr"); err != nil {
+		t.Errorf("task after cancelling one: %v", err)
+	}
+}
+
+func TestRegisterForTaskConcurrent(t *testing.T) {
+	db, err := InitDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	e := seedEvent(t, db)
+	tk := seedTask(t, db, e.ID, "Task", nil)
+
+	const n = 8
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		go func() {
+			_, err := RegisterForTask(db, tk.ID, "Alice", "Dupont", "alice@test.com", "0601", "fr")
+			errs <- err
+		}()
+	}
+	registered := 0
+	for i := 0; i < n; i++ {
+		switch err := <-errs; err {
+		case nil:
+			registered++
+		case errAlreadyRegistered:
+		default:
+			t.Errorf("register: %v", err)
+		}
+	}
+	if registered != 1 || CountTaskRegistrations(db, tk.ID) != 1 {
+		t.Errorf("%d registrations succeeded, %d stored; want 1", registered, CountTaskRegistrations(db, tk.ID))
+	}
+}
+
+func TestMigrationKeepsDuplicateRegistrations(t *testing.T) {
+	db := testOldDB(t)
+	db.Exec("INSERT INTO events (slug, title_fr, event_date) VALUES ('test', 'Test', '2026-01-01')")
+	db.Exec("INSERT INTO tasks (event_id, title_fr, position) VALUES (1, 'Task', 0)")
+	db.Exec("INSERT INTO registrations (task_id, name, email, phone, token) VALUES (1, 'Alice', 'alice@test.com', '01', 'a1')")
+	db.Exec("INSERT INTO registrations (task_id, name, email, phone, token) VALUES (1, 'Alice', 'Alice@test.com', '01', 'a2')")
+
+	if err := migrateDB(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if n := CountTaskRegistrations(db, 1); n != 2 {
+		t.Errorf("%d registrations after migration, want 2", n)
+	}
+	if _, err := RegisterForTask(db, 1, "Alice", "", "alice@test.com", "01", "fr"); err != errAlreadyRegistered {
+		t.Errorf("register again: err = %v", err)
+	}
+}
+
+// ---- Attendance ----
+
+func TestUpsertAttendanceKeepsLatestLanguage(t *testing.T) {
+	db := testDB(t)
+	e := seedEvent(t, db)
+
+	a, err := UpsertAttendance(db, e.ID, "Alice", "Dupont", "alice@test.com", "0601", true, 0, 0, "", "fr")
+	if err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	if _, err := UpsertAttendance(db, e.ID, "Alice", "Dupont", "ALICE@test.com", "0601", false, 0, 0, "", "en"); err != nil {
+		t.Fatalf("re-upsert: %v", err)
+	}
+
+	got, err := GetAttendance(db, a.ID)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if got.Lang != "en" || got.Attending {
+		t.Errorf("got lang=%q attending=%v, want en/false", got.Lang, got.Attending)
+	}
+}
+
 // ---- TaskView / slots ----
 
 func TestGetTaskViews(t *testing.T) {
@@ -290,8 +455,8 @@ func TestGetTaskViews(t *testing.T) {
 	tk1 := seedTask(t, db, e.ID, "Limited", int64Ptr(2))
 	tk2 := seedTask(t, db, e.ID, "Unlimited", nil)
 
-	RegisterForTask(db, tk1.ID, "A", "A", "a@t.com", "01")
-	RegisterForTask(db, tk1.ID, "B", "B", "b@t.com", "02")
+	RegisterForTask(db, tk1.ID, "A", "A", "a@t.com", "01", "fr")
+	RegisterForTask(db, tk1.ID, "B", "B", "b@t.com", "02", "fr")
 
 	views, err := GetTaskViews(db, e.ID)
 	if err != nil {
@@ -329,6 +494,82 @@ func TestGetTaskViews(t *testing.T) {
 	}
 }
 
+func TestGroupCapacity(t *testing.T) {
+	db := testDB(t)
+	e := seedEvent(t, db)
+	kitchen := &TaskGroup{EventID: e.ID, TitleFR: "Cuisine", MaxSlots: sql.NullInt64{Int64: 3, Valid: true}}
+	CreateTaskGroup(db, kitchen)
+	prep := &TaskGroup{EventID: e.ID, ParentGroupID: sql.NullInt64{Int64: kitchen.ID, Valid: true}, TitleFR: "Préparation"}
+	CreateTaskGroup(db, prep)
+	dishes := &Task{EventID: e.ID, GroupID: sql.NullInt64{Int64: kitchen.ID, Valid: true}, TitleFR: "Vaisselle", MaxGuests: 2}
+	CreateTask(db, dishes)
+	peeling := &Task{EventID: e.ID, GroupID: sql.NullInt64{Int64: prep.ID, Valid: true}, TitleFR: "Épluchage", MaxSlots: sql.NullInt64{Int64: 5, Valid: true}, MaxGuests: 1}
+	CreateTask(db, peeling)
+	bar := seedTask(t, db, e.ID, "Buvette", nil)
+
+	if _, err := RegisterGroupForTask(db, dishes.ID, 1, "A", "A", "a@t.com", "01", "fr"); err != nil {
+		t.Fatal(err)
+	}
+	views, _ := GetTaskViews(db, e.ID)
+	for _, v := range views {
+		want := map[int64]int{dishes.ID: 1, peeling.ID: 1, bar.ID: -1}[v.ID]
+		if v.SlotsLeft != want {
+			t.Errorf("%s: %d spots left, want %d", v.TitleFR, v.SlotsLeft, want)
+		}
+	}
+	if _, err := RegisterGroupForTask(db, peeling.ID, 1, "B", "B", "b@t.com", "02", "fr"); err != errGroupFull {
+		t.Errorf("two more in a subgroup with one spot left: %v", err)
+	}
+	RegisterForTask(db, peeling.ID, "C", "C", "c@t.com", "03", "fr")
+	if _, err := RegisterForTask(db, dishes.ID, "D", "D", "d@t.com", "04", "fr"); err != errGroupFull {
+		t.Errorf("signup in a full group: %v", err)
+	}
+	if _, err := RegisterForTask(db, bar.ID, "D", "D", "d@t.com", "04", "fr"); err != nil {
+		t.Errorf("signup outside the group: %v", err)
+	}
+
+	tree, _ := BuildEventTree(db, e.ID)
+	for _, node := range tree {
+		if node.Type == "group" && (node.RegCount != 3 || node.SlotsLeft != 0) {
+			t.Errorf("kitchen node: %d signed up, %d left", node.RegCount, node.SlotsLeft)
+		}
+	}
+	views, _ = GetTaskViews(db, e.ID)
+	for _, v := range views {
+		if v.IsFull != (v.ID != bar.ID) {
+			t.Errorf("%s: full = %v", v.TitleFR, v.IsFull)
+		}
+	}
+}
+
+// ---- Stats dashboard ----
+
+func TestBuildEventStats(t *testing.T) {
+	db := testDB(t)
+	e := seedEvent(t, db)
+	full := seedTask(t, db, e.ID, "Full", int64Ptr(1))
+	half := seedTask(t, db, e.ID, "Half", int64Ptr(4))
+	seedTask(t, db, e.ID, "Open", nil)
+	RegisterForTask(db, full.ID, "A", "A", "a@t.com", "01", "fr")
+	RegisterForTask(db, half.ID, "B", "B", "b@t.com", "02", "fr")
+	RegisterForTask(db, half.ID, "C", "C", "c@t.com", "03", "fr")
+
+	stats, err := BuildEventStats(db, e.ID)
+	if err != nil {
+		t.Fatalf("stats: %v", err)
+	}
+	if stats.TotalRegs != 3 || stats.Filled != 3 || stats.Capacity != 5 || stats.Percent != 60 {
+		t.Errorf("totals = %d regs, %d/%d (%d%%)", stats.TotalRegs, stats.Filled, stats.Capacity, stats.Percent)
+	}
+	if len(stats.Tasks) != 3 {
+		t.Fatalf("expected 3 tasks, got %d", len(stats.Tasks))
+	}
+	// Emptiest first: the unlimited task nobody took, then the half-full one.
+	if len(stats.Understaffed) != 2 || stats.Understaffed[0].TitleFR != "Open" || stats.Understaffed[1].TitleFR != "Half" {
+		t.Errorf("understaffed = %+v", stats.Understaffed)
+	}
+}
+
 // ---- Tree building ----
 
 func TestBuildEventTree(t *testing.T) {
@@ -370,6 +611,45 @@ func TestBuildEventTree(t *testing.T) {
 	}
 }
 
+// ---- Terms versioning ----
+
+func TestTermsVersioning(t *testing.T) {
+	db := testDB(t)
+	e := &Event{TitleFR: "Chantier", EventDate: "2026-06-14", TermsFR: "v1"}
+	CreateEvent(db, e)
+	if e.TermsVersion != 1 {
+		t.Fatalf("new event with terms should start at version 1, got %d", e.TermsVersion)
+	}
+
+	// Nobody accepted yet: edits revise version 1 in place.
+	e.TermsFR = "v1 (typo fixed)"
+	UpdateEvent(db, e)
+	if e.TermsVersion != 1 {
+		t.Errorf("unaccepted edit bumped version to %d", e.TermsVersion)
+	}
+
+	tk := seedTask(t, db, e.ID, "Task", nil)
+	reg, _ := RegisterForTask(db, tk.ID, "A", "B", "a@test.com", "0600", "fr")
+	RecordTermsAcceptance(db, reg.ID, e.TermsVersion)
+
+	// Saving unchanged text keeps the version; changing it starts a new one.
+	UpdateEvent(db, e)
+	if e.TermsVersion != 1 {
+		t.Errorf("unchanged save bumped version to %d", e.TermsVersion)
+	}
+	e.TermsFR = "v2"
+	UpdateEvent(db, e)
+	got, _ := GetEvent(db, e.ID)
+	if got.TermsVersion != 2 {
+		t.Errorf("accepted terms edited: version = %d, want 2", got.TermsVersion)
+	}
+
+	versions, _ := ListTermsVersions(db, e.ID)
+	if len(versions) != 2 || versions[0].TermsFR != "v2" || versions[1].TermsFR != "v1 (typo fixed)" {
+		t.Errorf("versions = %+v", versions)
+	}
+}
+
 // ---- Migration: old DB with name column ----
 
 func TestMigrationFromOldSchema(t *testing.T) {
@@ -385,6 +665,17 @@ func TestMigrationFromOldSchema(t *testing.T) {
 	migrateColumn(db, "registrations", "last_name", "ALTER TABLE registrations ADD COLUMN last_name TEXT NOT NULL DEFAULT ''")
 	db.Exec("UPDATE registrations SET last_name = name WHERE last_name = '' AND name IS NOT NULL AND name != ''")
 	migrateDropColumn(db, "registrations", "name")
+	migrateColumn(db, "registrations", "lang", "ALTER TABLE registrations ADD COLUMN lang TEXT NOT NULL DEFAULT 'fr'")
+	migrateColumn(db, "tasks", "trash_id", "ALTER TABLE tasks ADD COLUMN trash_id INTEGER")
+	migrateColumn(db, "registrations", "trash_id", "ALTER TABLE registrations ADD COLUMN trash_id INTEGER")
+	migrateColumn(db, "registrations", "event_id", "ALTER TABLE registrations ADD COLUMN event_id INTEGER")
+	migrateColumn(db, "registrations", "guests", "ALTER TABLE registrations ADD COLUMN guests INTEGER NOT NULL DEFAULT 0")
+	migrateColumn(db, "tasks", "max_guests", "ALTER TABLE tasks ADD COLUMN max_guests INTEGER NOT NULL DEFAULT 0")
+	migrateColumn(db, "tasks", "reserved_slots", "ALTER TABLE tasks ADD COLUMN reserved_slots INTEGER NOT NULL DEFAULT 0")
+	migrateColumn(db, "registrations", "person_slot", "ALTER TABLE registrations ADD COLUMN person_slot INTEGER NOT NULL DEFAULT 1")
+	migrateColumn(db, "registrations", "member", "ALTER TABLE registrations ADD COLUMN member INTEGER NOT NULL DEFAULT 0")
+	migrateColumn(db, "events", "max_tasks_per_person", "ALTER TABLE events ADD COLUMN max_tasks_per_person INTEGER NOT NULL DEFAULT 1")
+	migrateColumn(db, "events", "max_participants", "ALTER TABLE events ADD COLUMN max_participants INTEGER")
 
 	// Re-apply schema (CREATE TABLE IF NOT EXISTS is a no-op for existing tables)
 	if _, err := db.Exec(schemaSQL); err != nil {
@@ -401,7 +692,7 @@ func TestMigrationFromOldSchema(t *testing.T) {
 	}
 
 	// Now register a new user — this is what was failing with NOT NULL on name
-	newReg, err := RegisterForTask(db, 1, "New", "User", "new@test.com", "0601")
+	newReg, err := RegisterForTask(db, 1, "New", "User", "new@test.com", "0601", "fr")
 	if err != nil {
 		t.Fatalf("register after migration: %v", err)
 	}
@@ -409,3 +700,58 @@ func TestMigrationFromOldSchema(t *testing.T) {
 		t.Errorf("new reg = %q %q", newReg.FirstName, newReg.LastName)
 	}
 }
+
+// ---- Concurrent access ----
+
+func TestInitDBReadsDontWaitForWrites(t *testing.T) {
+	db, err := InitDB(filepath.Join(t.TempDir(), "events.db"))
+	if err != nil {
+		t.Fatalf("InitDB: %v", err)
+	}
+	defer db.Close()
+	e := seedEvent(t, db)
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	if _, err := tx.Exec("UPDATE events SET title_en='Renamed' WHERE id=?", e.ID); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	read := make(chan string, 1)
+	go func() {
+		got, err := GetEvent(db, e.ID)
+		if err != nil {
+			read <- err.Error()
+			return
+		}
+		read <- got.TitleEN
+	}()
+	select {
+	case title := <-read:
+		if title != "Test Event" {
+			t.Errorf("read during write = %q, want the committed title", title)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("read blocked behind an open write transaction")
+	}
+
+	// A second writer waits for the first instead of failing.
+	wrote := make(chan error, 1)
+	go func() {
+		_, err := db.Exec("UPDATE events SET title_fr='Renommé' WHERE id=?", e.ID)
+		wrote <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	if err := <-wrote; err != nil {
+		t.Fatalf("second writer: %v", err)
+	}
+	got, _ := GetEvent(db, e.ID)
+	if got.TitleEN != "Renamed" || got.TitleFR != "Renommé" {
+		t.Errorf("after both writes: %q / %q", got.TitleFR, got.TitleEN)
+	}
+}
@@ -0,0 +1,212 @@
+package main
+
+// Registrations across events. Someone who signed up on another device, or
+// for several events, asks /my for a link by email; the link lists all their
+// registrations for upcoming events, each with a link to cancel it and one
+// that opens the event page on it — from there it can be changed as on the
+// device it was made on. A link works for registrantLinkTTL and is only
+// sent to addresses with upcoming registrations, at most once a minute.
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const registrantLinkTTL = 24 * time.Hour
+
+// CreateRegistrantLink stores a link to email's registrations, valid for ttl,
+// and returns its token. Only the hash is stored.
+func CreateRegistrantLink(db *sql.DB, email string, ttl time.Duration) (string, error) {
+	token := GenerateToken() + GenerateToken()
+	expires := time.Now().UTC().Add(ttl).Format("2006-01-02 15:04:05")
+	_, err := db.Exec("INSERT INTO registrant_links (token_hash, email, expires_at) VALUES (?, ?, ?)",
+		sessionTokenHash(token), email, expires)
+	return token, err
+}
+
+// RegistrantLinkEmail returns the address an unexpired link was sent to.
+func RegistrantLinkEmail(db *sql.DB, token string) (string, error) {
+	var email string
+	err := db.QueryRow("SELECT email FROM registrant_links WHERE token_hash=? AND expires_at > ?",
+		sessionTokenHash(token), time.Now().UTC().Format("2006-01-02 15:04:05")).Scan(&email)
+	return email, err
+}
+
+// RecentRegistrantLink reports whether a link was sent to email within d.
+func RecentRegistrantLink(db *sql.DB, email string, d time.Duration) bool {
+	var n int
+	db.QueryRow("SELECT COUNT(*) FROM registrant_links WHERE email=? AND created_at > ?",
+		email, time.Now().UTC().Add(-d).Format("2006-01-02 15:04:05")).Scan(&n)
+	return n > 0
+}
+
+// PurgeRegistrantLinks drops expired links.
+func PurgeRegistrantLinks(db *sql.DB) error {
+	_, err := db.Exec("DELETE FROM registrant_links WHERE expires_at <= ?", time.Now().UTC().Format("2006-01-02 15:04:05"))
+	return err
+}
+
+// MyRegistration is one of a person's registrations, with its task and
+// event.
+type MyRegistration struct {
+	Reg         Registration
+	Task        Task
+	Event       Event
+	CancelToken string
+	CanCancel   bool
+}
+
+// ListUpcomingRegistrationTokens returns the tokens of email's registrations
+// for events not over by day (YYYY-MM-DD), soonest first.
+func ListUpcomingRegistrationTokens(db *sql.DB, email, day string) ([]string, error) {
+	rows, err := db.Query(`SELECT r.token FROM registrations r
+		JOIN tasks t ON t.id = r.task_id
+		JOIN events e ON e.id = t.event_id
+		WHERE LOWER(r.email) = LOWER(?) AND r.email != '' AND r.trash_id IS NULL AND t.trash_id IS NULL AND e.trash_id IS NULL
+			AND COALESCE(NULLIF(e.end_date, ''), e.event_date) >= ?
+		ORDER BY e.event_date, e.event_time, t.start_time, t.position, r.id`, email, day)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var tokens []string
+	for rows.Next() {
+		var token string
+		if err := rows.Scan(&token); err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, rows.Err()
+}
+
+// myRegistrations returns email's upcoming registrations.
+func (app *App) myRegistrations(email string) ([]MyRegistration, error) {
+	tokens, err := ListUpcomingRegistrationTokens(app.DB, email, time.Now().Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	var list []MyRegistration
+	for _, token := range tokens {
+		reg, err := GetRegistrationByToken(app.DB, token)
+		if err != nil {
+			continue
+		}
+		task, err := GetTask(app.DB, reg.TaskID)
+		if err != nil {
+			continue
+		}
+		event, err := GetEvent(app.DB, task.EventID)
+		if err != nil {
+			continue
+		}
+		list = append(list, MyRegistration{
+			Reg: *reg, Task: *task, Event: *event,
+			CancelToken: app.cancelToken(*reg, *event),
+			CanCancel:   cancelOpen(*event, time.Time{}),
+		})
+	}
+	return list, nil
+}
+
+// handleMyRegistrations shows the form asking for a link and emails it. The
+// reply is the same whatever the address, so the form doesn't tell who
+// signed up.
+func (app *App) handleMyRegistrations(w http.ResponseWriter, r *http.Request) {
+	lang := LangFromRequest(r)
+	myURL := "/my?lang=" + lang
+	if r.Method != http.MethodPost {
+		pd := app.newPageData(r, nil)
+		pd.Success, pd.Error = takeFlash(w, r)
+		app.render(w, r, "my_registrations.html", pd)
+		return
+	}
+	if errKey := app.checkSpam(r); errKey != "" {
+		setFlash(w, "error", T(errKey, lang))
+		http.Redirect(w, r, myURL, http.StatusSeeOther)
+		return
+	}
+	a, err := mail.ParseAddress(strings.TrimSpace(r.FormValue("email")))
+	if err != nil {
+		setFlash(w, "error", T("error_invalid_form", lang))
+		http.Redirect(w, r, myURL, http.StatusSeeOther)
+		return
+	}
+	email := strings.ToLower(a.Address)
+	setFlash(w, "success", T("my_link_sent", lang))
+	defer http.Redirect(w, r, myURL, http.StatusSeeOther)
+
+	if RecentRegistrantLink(app.DB, email, time.Minute) {
+		return
+	}
+	regs, err := app.myRegistrations(email)
+	if err != nil {
+		log.Printf("my registrations: list for %s: %v", email, err)
+		return
+	}
+	if len(regs) == 0 {
+		return
+	}
+	if err := PurgeRegistrantLinks(app.DB); err != nil {
+		log.Printf("my registrations: purge links: %v", err)
+	}
+	token, err := CreateRegistrantLink(app.DB, email, registrantLinkTTL)
+	if err != nil {
+		log.Printf("my registrations: create link: %v", err)
+		return
+	}
+	baseURL := app.eventConfiguredBaseURL(&regs[0].Event)
+	if baseURL == "" {
+		log.Printf("my registrations: no base url configured for the link to %s", email)
+		return
+	}
+	subject, htmlBody := renderRegistrantLinkEmail(lang, baseURL+"/my/"+token+"?lang="+lang, baseURL)
+	if htmlBody == "" {
+		return
+	}
+	app.queueEmail("registrant_link", 0, email, "", subject, htmlBody)
+}
+
+// handleMyRegistrationsLink lists the registrations of the address a link
+// was sent to. With ?open=<id>, it opens the event page on that
+// registration, by way of the signup confirmation which remembers it on
+// this device.
+func (app *App) handleMyRegistrationsLink(w http.ResponseWriter, r *http.Request) {
+	token := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/my/"), "/")
+	email, err := RegistrantLinkEmail(app.DB, token)
+	if err != nil {
+		pd := app.newPageData(r, nil)
+		pd.Error = T("my_link_invalid", pd.Lang)
+		app.render(w, r, "my_registrations.html", pd)
+		return
+	}
+	regs, err := app.myRegistrations(email)
+	if err != nil {
+		log.Printf("my registrations: list for %s: %v", email, err)
+		http.Error(w, T("error_server", LangFromRequest(r)), 500)
+		return
+	}
+	if open := r.URL.Query().Get("open"); open != "" {
+		id, _ := strconv.ParseInt(open, 10, 64)
+		for _, m := range regs {
+			if m.Reg.ID == id {
+				pd := app.newPageData(r, map[string]any{
+					"Event": &m.Event, "Task": &m.Task, "Reg": &m.Reg, "CancelToken": m.CancelToken,
+				})
+				rememberRegistration(w, m.Event.ID, m.CancelToken)
+				app.render(w, r, "confirmation.html", pd)
+				return
+			}
+		}
+		http.Redirect(w, r, fmt.Sprintf("/my/%s?lang=%s", token, LangFromRequest(r)), http.StatusSeeOther)
+		return
+	}
+	pd := app.newPageData(r, map[string]any{"Email": email, "Token": token, "Registrations": regs})
+	app.render(w, r, "my_registrations.html", pd)
+}
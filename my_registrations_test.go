@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMyRegistrations(t *testing.T) {
+	app := testApp(t)
+	mux := newMux(app)
+	fake := app.Email.(*fakeEmailSender)
+	soon := time.Now().AddDate(0, 1, 0).Format("2006-01-02")
+
+	var tasks []*Task
+	for _, ev := range []struct{ title, date string }{{"Fête", soon}, {"Brocante", soon}, {"Passé", "2020-01-01"}} {
+		e := &Event{TitleFR: ev.title, EventDate: ev.date}
+		if err := CreateEvent(app.DB, e); err != nil {
+			t.Fatal(err)
+		}
+		tk := seedTask(t, app.DB, e.ID, "Tâche "+ev.title, nil)
+		if _, err := RegisterForTask(app.DB, tk.ID, "Alice", "Dupont", "Alice@Test.com", "0601", "fr"); err != nil {
+			t.Fatal(err)
+		}
+		tasks = append(tasks, tk)
+	}
+
+	// Addresses without upcoming registrations get the same answer but no
+	// email, and a second request within the minute sends nothing more.
+	w := postForm(mux, "/my?lang=fr", url.Values{"email": {"bob@test.com"}})
+	if w.Code != 303 || fake.count() != 0 {
+		t.Fatalf("unknown address: status %d, %d emails sent", w.Code, fake.count())
+	}
+	// The link is built on the configured base URL, whatever host the
+	// request claims.
+	SetSetting(app.DB, adminBaseURLKey, "https://events.example.com")
+	ask := func() {
+		req := httptest.NewRequest(http.MethodPost, "/my?lang=fr", strings.NewReader(url.Values{"email": {"alice@test.com"}}.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("X-Forwarded-Host", "evil.example.net")
+		mux.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	ask()
+	ask()
+	if fake.count() != 1 || fake.sent[0].To != "alice@test.com" {
+		t.Fatalf("sent %+v, want a single link to alice", fake.sent)
+	}
+	if html := fake.sent[0].HTML; strings.Contains(html, "evil.example.net") || !strings.Contains(html, "https://events.example.com/my/") {
+		t.Errorf("link not on the configured base URL: %s", html)
+	}
+	m := regexp.MustCompile(`/my/([0-9a-f]+)`).FindStringSubmatch(fake.sent[0].HTML)
+	if m == nil {
+		t.Fatal("no link in the email")
+	}
+
+	body := getRequest(mux, "/my/"+m[1]+"?lang=fr").Body.String()
+	for _, s := range []string{"Tâche Fête", "Tâche Brocante", "/cancel/"} {
+		if !strings.Contains(body, s) {
+			t.Errorf("dashboard lacks %q", s)
+		}
+	}
+	// Its calendar links carry signed tokens and work.
+	ics := regexp.MustCompile(`/ics/([^?"]+)`).FindStringSubmatch(body)
+	if ics == nil || !strings.Contains(ics[1], ".") {
+		t.Fatalf("no signed calendar link in %s", body)
+	}
+	if w := getRequest(mux, "/ics/"+ics[1]); w.Code != 200 || !strings.Contains(w.Body.String(), "BEGIN:VCALENDAR") {
+		t.Errorf("calendar link: status %d", w.Code)
+	}
+	if strings.Contains(body, "Tâche Passé") {
+		t.Error("dashboard lists a past event")
+	}
+
+	reg, _ := GetRegistrationByEmailAndEvent(app.DB, "alice@test.com", tasks[1].EventID)
+	body = getRequest(mux, fmt.Sprintf("/my/%s?open=%d&lang=fr", m[1], reg.ID)).Body.String()
+	if !strings.Contains(body, "localStorage.setItem") || !strings.Contains(body, fmt.Sprintf("taskId:  %d ", tasks[1].ID)) {
+		t.Error("opening a registration doesn't remember it on the device")
+	}
+	past, _ := GetRegistrationByEmailAndEvent(app.DB, "alice@test.com", tasks[2].EventID)
+	if w := getRequest(mux, fmt.Sprintf("/my/%s?open=%d", m[1], past.ID)); w.Code != 303 {
+		t.Errorf("opening a registration off the list: status %d", w.Code)
+	}
+
+	expired, _ := CreateRegistrantLink(app.DB, "alice@test.com", -time.Minute)
+	for _, token := range []string{expired, "nope"} {
+		if body := getRequest(mux, "/my/"+token+"?lang=en").Body.String(); !strings.Contains(body, T("my_link_invalid", "en")) {
+			t.Errorf("link %q accepted", token)
+		}
+	}
+}
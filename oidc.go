@@ -0,0 +1,259 @@
+package main
+
+// Single sign-on through an OpenID Connect provider (Google Workspace,
+// Keycloak…), as an alternative to the admin password. It is the plain
+// authorization code flow with PKCE: the ID token comes straight from the
+// provider's token endpoint over TLS, which OIDC accepts in place of checking
+// its signature, so no JOSE library is needed. Only verified addresses listed
+// in the admin_emails setting — the same list as login links — get in.
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const oidcStateCookie = "oidc_state"
+
+var oidcHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// OIDCProvider is the configured identity provider. Name labels the login
+// button ("Google").
+type OIDCProvider struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	Name         string
+
+	mu        sync.Mutex
+	discovery *oidcDiscovery // fetched on first use
+}
+
+type oidcDiscovery struct {
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+}
+
+// endpoints returns the provider's discovery document, fetching it once.
+func (p *OIDCProvider) endpoints(ctx context.Context) (*oidcDiscovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(p.Issuer, "/")+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := oidcHTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oidc discovery: HTTP %d", resp.StatusCode)
+	}
+	var d oidcDiscovery
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&d); err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+	if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" {
+		return nil, fmt.Errorf("oidc discovery: missing endpoints")
+	}
+	p.discovery = &d
+	return &d, nil
+}
+
+// oidcClaims are the ID token claims the login relies on.
+type oidcClaims struct {
+	Issuer        string          `json:"iss"`
+	Audience      json.RawMessage `json:"aud"` // a string or an array of them
+	Expiry        int64           `json:"exp"`
+	Nonce         string          `json:"nonce"`
+	Email         string          `json:"email"`
+	EmailVerified bool            `json:"email_verified"`
+}
+
+// exchange trades an authorization code for the verified address it was
+// issued to.
+func (p *OIDCProvider) exchange(ctx context.Context, code, redirectURI, verifier, nonce string) (string, error) {
+	d, err := p.endpoints(ctx)
+	if err != nil {
+		return "", err
+	}
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirectURI},
+		"code_verifier": {verifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(p.ClientID), url.QueryEscape(p.ClientSecret))
+	resp, err := oidcHTTPClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("oidc token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("oidc token: HTTP %d: %s", resp.StatusCode, body)
+	}
+	var tok struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&tok); err != nil {
+		return "", fmt.Errorf("oidc token: %w", err)
+	}
+	parts := strings.Split(tok.IDToken, ".")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("oidc token: malformed id_token")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", fmt.Errorf("oidc token: %w", err)
+	}
+	var c oidcClaims
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return "", fmt.Errorf("oidc token: %w", err)
+	}
+	return c.verify(p, nonce)
+}
+
+// verify checks the claims were issued to us, for this login, and returns
+// the verified address.
+func (c oidcClaims) verify(p *OIDCProvider, nonce string) (string, error) {
+	if strings.TrimSuffix(c.Issuer, "/") != strings.TrimSuffix(p.Issuer, "/") {
+		return "", fmt.Errorf("oidc: issuer %q", c.Issuer)
+	}
+	var audiences []string
+	if err := json.Unmarshal(c.Audience, &audiences); err != nil {
+		var aud string
+		json.Unmarshal(c.Audience, &aud)
+		audiences = []string{aud}
+	}
+	ok := false
+	for _, a := range audiences {
+		ok = ok || a == p.ClientID
+	}
+	switch {
+	case !ok:
+		return "", fmt.Errorf("oidc: audience %s", c.Audience)
+	case time.Now().Unix() >= c.Expiry:
+		return "", fmt.Errorf("oidc: id_token expired")
+	case c.Nonce != nonce:
+		return "", fmt.Errorf("oidc: nonce mismatch")
+	case c.Email == "" || !c.EmailVerified:
+		return "", fmt.Errorf("oidc: no verified email")
+	}
+	return strings.ToLower(c.Email), nil
+}
+
+func (app *App) oidcRedirectURI(r *http.Request) string {
+	return app.baseURLFor(r) + "/admin/login/oidc/callback"
+}
+
+// handleAdminLoginOIDC sends the browser to the provider. The state, nonce,
+// PKCE verifier and the "remember this device" choice wait in a short-lived
+// cookie for the callback.
+func (app *App) handleAdminLoginOIDC(w http.ResponseWriter, r *http.Request) {
+	lang := LangFromRequest(r)
+	if app.OIDC == nil {
+		http.NotFound(w, r)
+		return
+	}
+	d, err := app.OIDC.endpoints(r.Context())
+	if err != nil {
+		log.Printf("oidc: %v", err)
+		setFlash(w, "error", T("oidc_error", lang))
+		http.Redirect(w, r, "/admin/login?lang="+lang, http.StatusSeeOther)
+		return
+	}
+	state, nonce, verifier := GenerateToken(), GenerateToken(), GenerateToken()+GenerateToken()
+	remember := "0"
+	if r.FormValue("remember") != "" {
+		remember = "1"
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    strings.Join([]string{state, nonce, verifier, lang, remember}, "."),
+		Path:     "/admin/login/oidc",
+		MaxAge:   600,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	challenge := sha256.Sum256([]byte(verifier))
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {app.OIDC.ClientID},
+		"redirect_uri":          {app.oidcRedirectURI(r)},
+		"scope":                 {"openid email"},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	sep := "?"
+	if strings.Contains(d.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	http.Redirect(w, r, d.AuthorizationEndpoint+sep+q.Encode(), http.StatusFound)
+}
+
+// handleAdminLoginOIDCCallback finishes the login the provider sent back.
+func (app *App) handleAdminLoginOIDCCallback(w http.ResponseWriter, r *http.Request) {
+	if app.OIDC == nil {
+		http.NotFound(w, r)
+		return
+	}
+	var saved []string
+	if c, err := r.Cookie(oidcStateCookie); err == nil {
+		saved = strings.Split(c.Value, ".")
+	}
+	http.SetCookie(w, &http.Cookie{Name: oidcStateCookie, Value: "", Path: "/admin/login/oidc", MaxAge: -1})
+	lang := LangFromRequest(r)
+	if len(saved) == 5 {
+		lang = saved[3]
+	}
+	fail := func(key string) {
+		setFlash(w, "error", T(key, lang))
+		http.Redirect(w, r, "/admin/login?lang="+lang, http.StatusSeeOther)
+	}
+	q := r.URL.Query()
+	if len(saved) != 5 || q.Get("state") != saved[0] {
+		fail("oidc_error")
+		return
+	}
+	if e := q.Get("error"); e != "" {
+		log.Printf("oidc: provider returned %s: %s", e, q.Get("error_description"))
+		fail("oidc_error")
+		return
+	}
+	email, err := app.OIDC.exchange(r.Context(), q.Get("code"), app.oidcRedirectURI(r), saved[2], saved[1])
+	if err != nil {
+		log.Printf("oidc: %v", err)
+		fail("oidc_error")
+		return
+	}
+	for _, a := range adminLoginEmails(app) {
+		if strings.EqualFold(a, email) {
+			log.Printf("oidc: %s logged in", email)
+			app.startSession(w, r, "/admin?lang="+lang, saved[4] == "1")
+			return
+		}
+	}
+	log.Printf("oidc: %s is not an allowed admin address", email)
+	fail("oidc_not_allowed")
+}
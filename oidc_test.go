@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeOIDCProvider serves discovery and a token endpoint issuing an ID token
+// with the given claims; {nonce} is replaced by the nonce of the login.
+func fakeOIDCProvider(t *testing.T, claims map[string]any) *httptest.Server {
+	var srv *httptest.Server
+	var nonce string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"authorization_endpoint": srv.URL + "/authorize",
+			"token_endpoint":         srv.URL + "/token",
+		})
+	})
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		nonce = r.URL.Query().Get("nonce")
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if id, secret, _ := r.BasicAuth(); id != "client" || secret != "secret" || r.FormValue("code") != "good-code" || r.FormValue("code_verifier") == "" {
+			http.Error(w, `{"error":"invalid_grant"}`, 400)
+			return
+		}
+		c := map[string]any{"iss": srv.URL, "aud": "client", "exp": time.Now().Add(time.Hour).Unix(), "nonce": nonce}
+		for k, v := range claims {
+			c[k] = v
+		}
+		payload, _ := json.Marshal(c)
+		json.NewEncoder(w).Encode(map[string]string{
+			"id_token": "e30." + base64.RawURLEncoding.EncodeToString(payload) + ".sig",
+		})
+	})
+	srv = httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestAdminOIDCLogin(t *testing.T) {
+	login := func(t *testing.T, claims map[string]any, code string) *httptest.ResponseRecorder {
+		app := testApp(t)
+		SetSetting(app.DB, "admin_emails", "alice@test.com")
+		provider := fakeOIDCProvider(t, claims)
+		app.OIDC = &OIDCProvider{Issuer: provider.URL, ClientID: "client", ClientSecret: "secret", Name: "Test"}
+		mux := newMux(app)
+
+		if body := getRequest(mux, "/admin/login?lang=fr").Body.String(); !strings.Contains(body, "/admin/login/oidc") {
+			t.Fatal("login page lacks the SSO button")
+		}
+		w := getRequest(mux, "/admin/login/oidc?lang=fr")
+		authURL, _ := url.Parse(w.Header().Get("Location"))
+		if !strings.HasPrefix(authURL.String(), provider.URL+"/authorize") || authURL.Query().Get("code_challenge") == "" {
+			t.Fatalf("redirected to %q", authURL)
+		}
+		http.Get(authURL.String()) // the user logs in at the provider
+		state := w.Result().Cookies()[0]
+		return getRequest(mux, fmt.Sprintf("/admin/login/oidc/callback?code=%s&state=%s", code, authURL.Query().Get("state")), state)
+	}
+	loggedIn := func(w *httptest.ResponseRecorder) bool {
+		return strings.Contains(w.Header().Values("Set-Cookie")[len(w.Header().Values("Set-Cookie"))-1], "admin_session=")
+	}
+
+	if w := login(t, map[string]any{"email": "Alice@test.com", "email_verified": true}, "good-code"); !loggedIn(w) {
+		t.Errorf("allowed address not logged in: %d %v", w.Code, w.Header())
+	}
+	for name, claims := range map[string]map[string]any{
+		"unlisted address":   {"email": "bob@test.com", "email_verified": true},
+		"unverified address": {"email": "alice@test.com", "email_verified": false},
+		"other audience":     {"email": "alice@test.com", "email_verified": true, "aud": []string{"someone-else"}},
+		"expired token":      {"email": "alice@test.com", "email_verified": true, "exp": time.Now().Add(-time.Minute).Unix()},
+		"replayed nonce":     {"email": "alice@test.com", "email_verified": true, "nonce": "stale"},
+	} {
+		if w := login(t, claims, "good-code"); loggedIn(w) {
+			t.Errorf("%s: logged in", name)
+		}
+	}
+	if w := login(t, map[string]any{"email": "alice@test.com", "email_verified": true}, "bad-code"); loggedIn(w) {
+		t.Error("rejected code: logged in")
+	}
+}
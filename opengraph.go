@@ -0,0 +1,242 @@
+package main
+
+// Link previews. The public page of an event carries OpenGraph and Twitter
+// card tags — title, a short description, the date — so a link shared in a
+// WhatsApp or Facebook group shows up as a card. The card's image,
+// /e/<slug>/og.png, is drawn here: the site logo on the theme color next to
+// a calendar page with the event's date and time — the event's own logo and
+// color when its theme has them. It only needs digits, so a small built-in
+// pixel font does without a font file.
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif" // logos of event themes
+	_ "image/jpeg"
+	"image/png"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OpenGraph is what a page tells link previews about itself.
+type OpenGraph struct {
+	Title       string
+	Description string
+	URL         string
+	Image       string
+	ImageAlt    string
+	SiteName    string
+	Locale      string
+}
+
+// ogDescriptionLen caps the description in runes; previews cut shorter.
+const ogDescriptionLen = 200
+
+// eventOpenGraph returns the link preview of event's public page in lang.
+func (app *App) eventOpenGraph(r *http.Request, event *Event, lang string) *OpenGraph {
+	when := formatEventDate(event.EventDate, lang)
+	if event.EventTime != "" {
+		when += ", " + formatEventTime(event.EventTime, lang)
+	}
+	desc := strings.Join(strings.Fields(htmlToText(Localized(event.DescriptionFR, event.DescriptionEN, lang))), " ")
+	if runes := []rune(desc); len(runes) > ogDescriptionLen {
+		desc = strings.TrimSpace(string(runes[:ogDescriptionLen-1])) + "…"
+	}
+	if desc != "" {
+		desc = when + " — " + desc
+	} else {
+		desc = when
+	}
+	base := app.eventBaseURL(r, event) + "/e/" + event.Slug
+	locale := "fr_FR"
+	if lang == LangEN {
+		locale = "en_US"
+	}
+	return &OpenGraph{
+		Title:       Localized(event.TitleFR, event.TitleEN, lang),
+		Description: desc,
+		URL:         base + "?lang=" + lang,
+		// The date in the URL makes previews fetch the image anew once it
+		// changes.
+		Image:    fmt.Sprintf("%s/og.png?lang=%s&d=%s", base, lang, strings.ReplaceAll(event.EventDate+event.EventTime, ":", "")),
+		ImageAlt: when,
+		SiteName: siteTitle(lang),
+		Locale:   locale,
+	}
+}
+
+// handleEventOGImage serves the preview image of event.
+func (app *App) handleEventOGImage(w http.ResponseWriter, r *http.Request, event *Event) {
+	theme, logo := themeColor(), []byte(nil)
+	if t := app.pageTheme(event); t != nil {
+		if t.Color != "" {
+			theme = t.Color
+		}
+		if t.LogoType != "" {
+			logo, _, _ = GetEventLogo(app.DB, event.ID)
+		}
+	}
+	img, err := renderOGImage(event, LangFromRequest(r), theme, logo)
+	if err != nil {
+		log.Printf("og image of event %d: %v", event.ID, err)
+		http.Error(w, T("error_server", LangFromRequest(r)), 500)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	w.Write(img)
+}
+
+// Preview image layout, in pixels. 1200×630 is the size every network
+// crops to.
+const (
+	ogWidth     = 1200
+	ogHeight    = 630
+	ogTileX     = 640
+	ogTileY     = 95
+	ogTileW     = 460
+	ogTileH     = 440
+	ogTileBand  = 90 // the colored band at the top of the calendar page
+	ogLogoSize  = 380
+	ogLogoX     = 110
+	ogDateScale = 13 // pixel font scale of the day and month
+	ogSubScale  = 9  // of the year and time
+)
+
+// renderOGImage draws event's preview image as a PNG, on the #RRGGBB color
+// themeHex, with logo or else the site's.
+func renderOGImage(event *Event, lang, themeHex string, logo []byte) ([]byte, error) {
+	day, err := time.Parse("2006-01-02", event.EventDate)
+	if err != nil {
+		return nil, err
+	}
+	theme := parseHexColor(themeHex)
+	dark := parseHexColor(mixColor(themeHex, "#000000", 0.3))
+	img := image.NewRGBA(image.Rect(0, 0, ogWidth, ogHeight))
+	draw.Draw(img, img.Bounds(), image.NewUniform(theme), image.Point{}, draw.Src)
+
+	// Logo, on a white disc so it shows on any theme color. Image types Go
+	// can't decode, like WebP, get the site's.
+	src, _, err := image.Decode(bytes.NewReader(logo))
+	if err != nil {
+		siteLogo, rerr := staticFS.ReadFile("static/logo.png")
+		if rerr != nil {
+			return nil, rerr
+		}
+		src, err = png.Decode(bytes.NewReader(siteLogo))
+	}
+	if err == nil {
+		cx, cy, radius := ogLogoX+ogLogoSize/2, ogHeight/2, ogLogoSize/2+30
+		for y := cy - radius; y <= cy+radius; y++ {
+			for x := cx - radius; x <= cx+radius; x++ {
+				if (x-cx)*(x-cx)+(y-cy)*(y-cy) <= radius*radius {
+					img.Set(x, y, color.White)
+				}
+			}
+		}
+		// Fit the logo in the square, keeping its proportions.
+		w, h := ogLogoSize, ogLogoSize
+		if b := src.Bounds(); b.Dx() > b.Dy() {
+			h = ogLogoSize * b.Dy() / b.Dx()
+		} else if b.Dy() > b.Dx() {
+			w = ogLogoSize * b.Dx() / b.Dy()
+		}
+		drawScaled(img, src, image.Rect(cx-w/2, cy-h/2, cx-w/2+w, cy-h/2+h))
+	}
+
+	// Calendar page: day and month, the year below, then the time.
+	tile := image.Rect(ogTileX, ogTileY, ogTileX+ogTileW, ogTileY+ogTileH)
+	draw.Draw(img, tile, image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(tile.Min.X, tile.Min.Y, tile.Max.X, tile.Min.Y+ogTileBand), image.NewUniform(dark), image.Point{}, draw.Src)
+	dayMonth := day.Format("02/01")
+	if lang == LangEN {
+		dayMonth = day.Format("01/02")
+	}
+	ink := color.RGBA{0x1f, 0x29, 0x37, 0xff}
+	y := tile.Min.Y + ogTileBand + 45
+	drawPixelText(img, dayMonth, tile.Min.X+(ogTileW-pixelTextWidth(dayMonth, ogDateScale))/2, y, ogDateScale, ink)
+	y += pixelFontHeight*ogDateScale + 35
+	year := strconv.Itoa(day.Year())
+	drawPixelText(img, year, tile.Min.X+(ogTileW-pixelTextWidth(year, ogSubScale))/2, y, ogSubScale, dark)
+	if event.EventTime != "" {
+		y += pixelFontHeight*ogSubScale + 25
+		drawPixelText(img, event.EventTime, tile.Min.X+(ogTileW-pixelTextWidth(event.EventTime, ogSubScale))/2, y, ogSubScale, dark)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// parseHexColor reads a "#RRGGBB" color.
+func parseHexColor(s string) color.RGBA {
+	v, _ := strconv.ParseUint(strings.TrimPrefix(s, "#"), 16, 32)
+	return color.RGBA{uint8(v >> 16), uint8(v >> 8), uint8(v), 0xff}
+}
+
+// drawScaled draws src over dst's rect r, resized by nearest neighbor and
+// blended over what is there.
+func drawScaled(dst *image.RGBA, src image.Image, r image.Rectangle) {
+	sb := src.Bounds()
+	scaled := image.NewRGBA(image.Rect(0, 0, r.Dx(), r.Dy()))
+	for y := 0; y < r.Dy(); y++ {
+		for x := 0; x < r.Dx(); x++ {
+			scaled.Set(x, y, src.At(sb.Min.X+x*sb.Dx()/r.Dx(), sb.Min.Y+y*sb.Dy()/r.Dy()))
+		}
+	}
+	draw.Draw(dst, r, scaled, image.Point{}, draw.Over)
+}
+
+// pixelFont holds the glyphs the preview image needs, 5×7 pixels each.
+var pixelFont = map[rune][pixelFontHeight]string{
+	'0': {".###.", "#...#", "#..##", "#.#.#", "##..#", "#...#", ".###."},
+	'1': {"..#..", ".##..", "..#..", "..#..", "..#..", "..#..", ".###."},
+	'2': {".###.", "#...#", "....#", "...#.", "..#..", ".#...", "#####"},
+	'3': {"#####", "...#.", "..#..", "...#.", "....#", "#...#", ".###."},
+	'4': {"...#.", "..##.", ".#.#.", "#..#.", "#####", "...#.", "...#."},
+	'5': {"#####", "#....", "####.", "....#", "....#", "#...#", ".###."},
+	'6': {"..##.", ".#...", "#....", "####.", "#...#", "#...#", ".###."},
+	'7': {"#####", "....#", "...#.", "..#..", ".#...", ".#...", ".#..."},
+	'8': {".###.", "#...#", "#...#", ".###.", "#...#", "#...#", ".###."},
+	'9': {".###.", "#...#", "#...#", ".####", "....#", "...#.", ".##.."},
+	'/': {"....#", "....#", "...#.", "..#..", ".#...", "#....", "#...."},
+	':': {".....", "..#..", "..#..", ".....", "..#..", "..#..", "....."},
+}
+
+const (
+	pixelFontWidth  = 5
+	pixelFontHeight = 7
+)
+
+// pixelTextWidth is the width of s drawn at scale, a pixel between glyphs.
+func pixelTextWidth(s string, scale int) int {
+	n := len([]rune(s))
+	if n == 0 {
+		return 0
+	}
+	return (n*(pixelFontWidth+1) - 1) * scale
+}
+
+// drawPixelText draws s with its top left corner at x, y, each font pixel
+// a scale×scale square. Runes outside pixelFont leave a blank.
+func drawPixelText(img *image.RGBA, s string, x, y, scale int, c color.Color) {
+	for _, r := range s {
+		for row, line := range pixelFont[r] {
+			for col, px := range line {
+				if px == '#' {
+					rect := image.Rect(x+col*scale, y+row*scale, x+(col+1)*scale, y+(row+1)*scale)
+					draw.Draw(img, rect, image.NewUniform(c), image.Point{}, draw.Src)
+				}
+			}
+		}
+		x += (pixelFontWidth + 1) * scale
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestOpenGraph(t *testing.T) {
+	app := testApp(t)
+	mux := newMux(app)
+	e := &Event{TitleFR: "Kermesse", TitleEN: "Fair", DescriptionFR: "<p>Venez <strong>nombreux</strong> !</p>", EventDate: "2026-06-15", EventTime: "14:00"}
+	if err := CreateEvent(app.DB, e); err != nil {
+		t.Fatal(err)
+	}
+
+	body := getRequest(mux, "/e/"+e.Slug+"?lang=fr").Body.String()
+	for _, want := range []string{
+		`<meta property="og:title" content="Kermesse">`,
+		`<meta property="og:description" content="lundi 15 juin 2026, 14h00 — Venez nombreux !">`,
+		`<meta property="og:image" content="http://example.com/e/kermesse/og.png?lang=fr&amp;d=2026-06-151400">`,
+		`<meta name="twitter:card" content="summary_large_image">`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("page misses %s", want)
+		}
+	}
+
+	w := getRequest(mux, "/e/"+e.Slug+"/og.png?lang=en")
+	if w.Code != 200 || w.Header().Get("Content-Type") != "image/png" {
+		t.Fatalf("image: status %d, type %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	img, err := png.Decode(bytes.NewReader(w.Body.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 1200 || b.Dy() != 630 {
+		t.Errorf("image is %v", b)
+	}
+
+	SetEventDraft(app.DB, e.ID, true)
+	if w := getRequest(mux, "/e/"+e.Slug+"/og.png"); w.Code != 404 {
+		t.Errorf("draft image: status %d", w.Code)
+	}
+	if body := getRequest(mux, "/e/"+e.Slug).Body.String(); strings.Contains(body, "og:title") {
+		t.Error("draft page has a preview")
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+// Opening notices. Until an event's registration opens, its page counts
+// down to the opening and offers to email people when it does, so those
+// who came early don't have to remember to come back. The worker sends the
+// emails once the window opens and then forgets the addresses; notices of
+// an event whose registration closed before they went out are dropped.
+// Anyone can type any address, so the emails honor opt-outs, carry the
+// preferences link and link to the configured base URL, never to the host
+// the notice was asked from.
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const openingNoticeInterval = time.Minute
+
+// OpeningNotice is someone to email when an event's registration opens.
+type OpeningNotice struct {
+	ID      int64
+	EventID int64
+	Email   string
+	Lang    string
+	Invite  string // the invite they came with, for invite-only events
+}
+
+// pageURL is the event page the notice links to.
+func (n OpeningNotice) pageURL(baseURL string, event Event) string {
+	u := baseURL + "/e/" + event.Slug + "?lang=" + n.Lang
+	if n.Invite != "" {
+		u += "&invite=" + url.QueryEscape(n.Invite)
+	}
+	return u
+}
+
+// AddOpeningNotice asks for email to be told when registration to event
+// eventID opens. Asking again only updates the language and invite.
+func AddOpeningNotice(db *sql.DB, n OpeningNotice) error {
+	_, err := db.Exec(`INSERT INTO opening_notices (event_id, email, lang, invite) VALUES (?, ?, ?, ?)
+		ON CONFLICT(event_id, email) DO UPDATE SET lang=excluded.lang, invite=excluded.invite`,
+		n.EventID, n.Email, n.Lang, n.Invite)
+	return err
+}
+
+// ListOpeningNotices returns every pending notice, oldest first.
+func ListOpeningNotices(db *sql.DB) ([]OpeningNotice, error) {
+	rows, err := db.Query("SELECT id, event_id, email, lang, invite FROM opening_notices ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var notices []OpeningNotice
+	for rows.Next() {
+		var n OpeningNotice
+		if err := rows.Scan(&n.ID, &n.EventID, &n.Email, &n.Lang, &n.Invite); err != nil {
+			return nil, err
+		}
+		notices = append(notices, n)
+	}
+	return notices, rows.Err()
+}
+
+// CountOpeningNotices returns how many people wait for registration to
+// event eventID to open.
+func CountOpeningNotices(db *sql.DB, eventID int64) (int, error) {
+	var n int
+	err := db.QueryRow("SELECT COUNT(*) FROM opening_notices WHERE event_id=?", eventID).Scan(&n)
+	return n, err
+}
+
+func deleteOpeningNotice(db *sql.DB, id int64) error {
+	_, err := db.Exec("DELETE FROM opening_notices WHERE id=?", id)
+	return err
+}
+
+// sendOpeningNotices queues the email of every notice whose event's
+// registration is open at now, but to addresses that opted out of emails.
+// Notices of drafts and trashed events wait, as do all of them until the
+// instance's base URL is known.
+func (app *App) sendOpeningNotices(now time.Time) error {
+	notices, err := ListOpeningNotices(app.DB)
+	if err != nil {
+		return err
+	}
+	events := map[int64]*Event{}
+	for _, n := range notices {
+		event, seen := events[n.EventID]
+		if !seen {
+			if event, err = GetEvent(app.DB, n.EventID); err != nil {
+				event = nil
+			}
+			events[n.EventID] = event
+		}
+		if event == nil || event.Draft {
+			continue
+		}
+		switch event.RegistrationState(now) {
+		case registrationNotYetOpen:
+			continue
+		case registrationOpen:
+			if IsUnsubscribed(app.DB, n.Email) {
+				break // dropped unsent
+			}
+			baseURL := app.eventConfiguredBaseURL(event)
+			if baseURL == "" {
+				continue
+			}
+			subject, html := renderOpeningNoticeEmail(n.Lang, *event, n.pageURL(baseURL, *event), baseURL, app.unsubscribeURL(baseURL, n.Email, n.Lang))
+			if html == "" {
+				continue
+			}
+			app.queueEmail("opening_notice", event.ID, n.Email, event.OrganizerEmail, subject, html)
+		}
+		if err := deleteOpeningNotice(app.DB, n.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (app *App) runOpeningNoticeWorker(ctx context.Context) {
+	ticker := time.NewTicker(openingNoticeInterval)
+	defer ticker.Stop()
+	for {
+		if err := app.sendOpeningNotices(time.Now()); err != nil {
+			log.Printf("opening notices: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// registrationOpening is what the countdown panel of a page shows.
+type registrationOpening struct {
+	EventID  int64
+	Opens    time.Time
+	OpensIn  int64 // milliseconds, so a wrong device clock doesn't matter
+	Notified bool  // an opening notice was just asked for
+}
+
+// eventOpening returns the countdown panel of event's page, nil when its
+// registration isn't waiting to open.
+func eventOpening(r *http.Request, event *Event, now time.Time) *registrationOpening {
+	opens, ok := event.RegistrationOpens()
+	if !ok || !now.Before(opens) {
+		return nil
+	}
+	return &registrationOpening{
+		EventID: event.ID, Opens: opens, OpensIn: opens.Sub(now).Milliseconds(),
+		Notified: r.FormValue("notified") == "1",
+	}
+}
+
+// handleOpeningNotice records a request to be emailed when registration to
+// an event opens.
+func (app *App) handleOpeningNotice(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+		return
+	}
+	eventID, _ := strconv.ParseInt(r.FormValue("event_id"), 10, 64)
+	event, err := GetEvent(app.DB, eventID)
+	if err != nil || event.EventType != "tasks" {
+		http.NotFound(w, r)
+		return
+	}
+	if app.hiddenDraft(r, event) {
+		app.renderComingSoon(w, r, event)
+		return
+	}
+	if app.inviteDenied(r, event) {
+		app.renderInviteRequired(w, r, event)
+		return
+	}
+	lang := eventLang(r, event)
+	page := "/e/" + event.Slug + "?lang=" + lang
+	if invite := strings.TrimSpace(r.FormValue("invite")); invite != "" {
+		page += "&invite=" + url.QueryEscape(invite)
+	}
+	fail := func(msg string) {
+		if event.AssignMode {
+			app.renderPreferenceForm(w, r, event, false, msg)
+		} else {
+			app.renderSignupForm(w, r, event, msg)
+		}
+	}
+	if errKey := app.checkSpam(r); errKey != "" {
+		fail(T(errKey, lang))
+		return
+	}
+	// Registration opened meanwhile: the form is there now.
+	if event.RegistrationState(time.Now()) != registrationNotYetOpen {
+		http.Redirect(w, r, page, http.StatusSeeOther)
+		return
+	}
+	email := strings.TrimSpace(r.FormValue("email"))
+	if errKey := app.checkSignupEmail(r.Context(), email); errKey != "" {
+		fail(T(errKey, lang))
+		return
+	}
+	notice := OpeningNotice{EventID: event.ID, Email: email, Lang: lang}
+	if inv := app.requestInvite(r, event); inv != nil {
+		notice.Invite = inv.Token
+	}
+	if err := AddOpeningNotice(app.DB, notice); err != nil {
+		log.Printf("opening notice for event %d: %v", event.ID, err)
+		fail(T("error_server", lang))
+		return
+	}
+	http.Redirect(w, r, page+"&notified=1#registration-opens", http.StatusSeeOther)
+}
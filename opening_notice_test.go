@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOpeningNotice(t *testing.T) {
+	app := testApp(t)
+	mux := newMux(app)
+	e := seedEvent(t, app.DB)
+	seedTask(t, app.DB, e.ID, "Cuisine", nil)
+	opens := time.Now().UTC().Add(time.Hour).Truncate(time.Minute)
+	e.RegistrationOpensAt = opens.Format(registrationTimeLayout)
+	UpdateEvent(app.DB, e)
+
+	body := getRequest(mux, "/e/"+e.Slug+"?lang=en").Body.String()
+	if !strings.Contains(body, `action="/notify-opening?lang=en"`) {
+		t.Fatal("page before opening lacks the notify form")
+	}
+	notify := func(email string) *string {
+		w := postForm(mux, "/notify-opening?lang=en", url.Values{"event_id": {fmt.Sprint(e.ID)}, "email": {email}})
+		loc := w.Header().Get("Location")
+		if w.Code != 303 {
+			body := w.Body.String()
+			return &body
+		}
+		if !strings.Contains(loc, "notified=1") {
+			t.Errorf("redirect to %q", loc)
+		}
+		return nil
+	}
+	if body := notify("not-an-email"); body == nil || !strings.Contains(*body, T("error_email_invalid", LangEN)) {
+		t.Error("invalid email accepted")
+	}
+	if notify("alice@test.com") != nil || notify("ALICE@test.com") != nil || notify("bob@test.com") != nil {
+		t.Fatal("notice refused")
+	}
+	if n, _ := CountOpeningNotices(app.DB, e.ID); n != 2 {
+		t.Errorf("notices = %d, want 1 per address", n)
+	}
+	if !strings.Contains(getRequest(mux, "/e/"+e.Slug+"?lang=en&notified=1").Body.String(), T("opening_notice_saved", LangEN)) {
+		t.Error("page doesn't confirm the notice")
+	}
+
+	fake := app.Email.(*fakeEmailSender)
+	if err := app.sendOpeningNotices(opens.Add(-time.Minute)); err != nil || fake.count() != 0 {
+		t.Fatalf("sent before opening: %d, %v", fake.count(), err)
+	}
+	// Links go to the configured base URL, and opt-outs are honored.
+	SetSetting(app.DB, adminBaseURLKey, "https://events.example.com")
+	SetUnsubscribed(app.DB, "bob@test.com", true)
+	if err := app.sendOpeningNotices(opens); err != nil {
+		t.Fatal(err)
+	}
+	if fake.count() != 1 || fake.sent[0].To != "alice@test.com" {
+		t.Fatalf("sent = %+v", fake.sent)
+	}
+	for _, want := range []string{"https://events.example.com/e/" + e.Slug + "?lang=en", "https://events.example.com/unsubscribe?"} {
+		if !strings.Contains(fake.sent[0].HTML, want) {
+			t.Errorf("notice lacks %s", want)
+		}
+	}
+	if n, _ := CountOpeningNotices(app.DB, e.ID); n != 0 {
+		t.Error("notice kept after sending")
+	}
+	app.sendOpeningNotices(opens.Add(time.Minute))
+	if fake.count() != 1 {
+		t.Error("notice sent twice")
+	}
+
+	// Once open, the form is the signup form.
+	e.RegistrationOpensAt = ""
+	UpdateEvent(app.DB, e)
+	if strings.Contains(getRequest(mux, "/e/"+e.Slug+"?lang=en").Body.String(), "/notify-opening") {
+		t.Error("open event still offers the notify form")
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+// Outbox for transactional email (signup confirmations, admin notifications).
+// Every such email is stored before sending and delivered by a background
+// worker that retries transient failures with exponential backoff, so an
+// SMTP hiccup delays a confirmation instead of dropping it. Secret Santa
+// sends keep their own path: their delivery status is tracked per
+// participant in email_messages.
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	outboxMaxAttempts  = 8
+	outboxBaseBackoff  = 30 * time.Second
+	outboxMaxBackoff   = 6 * time.Hour
+	outboxPollInterval = 15 * time.Second
+	outboxBatchSize    = 50
+)
+
+// outboxBackoff is the wait after the given number of failed attempts:
+// 30s, 1m, 2m, 4m… capped at outboxMaxBackoff.
+func outboxBackoff(attempts int) time.Duration {
+	d := outboxBaseBackoff
+	for i := 1; i < attempts && d < outboxMaxBackoff; i++ {
+		d *= 2
+	}
+	if d > outboxMaxBackoff {
+		d = outboxMaxBackoff
+	}
+	return d
+}
+
+// queueEmail stores an email in the outbox and gets it delivered — by waking
+// the worker in production (AsyncEmail), synchronously in tests. eventID is
+// the event the email is about, 0 for none.
+func (app *App) queueEmail(kind string, eventID int64, to, replyTo, subject, htmlBody string, attachments ...emailAttachment) {
+	if _, err := EnqueueEmail(app.DB, kind, eventID, to, replyTo, subject, htmlBody, attachments); err != nil {
+		log.Printf("outbox: enqueue %s to %s: %v", kind, to, err)
+		return
+	}
+	app.kickOutbox()
+}
+
+// kickOutbox triggers a delivery pass: a non-blocking wake-up of the worker in
+// production, an immediate synchronous pass in tests.
+func (app *App) kickOutbox() {
+	if !app.AsyncEmail {
+		app.deliverOutbox(time.Now())
+		return
+	}
+	select {
+	case app.outboxWake <- struct{}{}:
+	default: // a wake-up is already pending
+	}
+}
+
+// deliverOutbox makes one attempt at every email due by now. Calls are
+// serialized so the worker and a synchronous caller never send a row twice.
+func (app *App) deliverOutbox(now time.Time) {
+	app.outboxMu.Lock()
+	defer app.outboxMu.Unlock()
+	due, err := DueOutboxEmails(app.DB, now, outboxBatchSize)
+	if err != nil {
+		log.Printf("outbox: list due emails: %v", err)
+		return
+	}
+	for i, m := range due {
+		if i > 0 {
+			time.Sleep(app.EmailSendDelay)
+		}
+		messageID, err := app.Email.Send(context.Background(), m.ToEmail, m.ReplyTo, m.Subject, m.HTMLBody, m.Attachments...)
+		if err == nil {
+			if err := MarkOutboxSent(app.DB, m.ID, messageID); err != nil {
+				log.Printf("outbox: mark %d sent: %v", m.ID, err)
+			}
+			continue
+		}
+		attempts := m.Attempts + 1
+		giveUp := attempts >= outboxMaxAttempts
+		if giveUp {
+			log.Printf("outbox: %s to %s failed after %d attempts: %v", m.Kind, m.ToEmail, attempts, err)
+		} else {
+			log.Printf("outbox: %s to %s failed (attempt %d), retrying: %v", m.Kind, m.ToEmail, attempts, err)
+		}
+		if err := MarkOutboxAttemptFailed(app.DB, m.ID, err.Error(), now.Add(outboxBackoff(attempts)), giveUp); err != nil {
+			log.Printf("outbox: mark %d failed: %v", m.ID, err)
+		}
+	}
+}
+
+// runOutboxWorker delivers queued email until ctx is done, polling for
+// retries that have come due and waking early when queueEmail adds a row.
+func (app *App) runOutboxWorker(ctx context.Context) {
+	ticker := time.NewTicker(outboxPollInterval)
+	defer ticker.Stop()
+	for {
+		app.deliverOutbox(time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-app.outboxWake:
+		}
+	}
+}
+
+// ---- Admin ----
+
+func (app *App) handleAdminOutbox(w http.ResponseWriter, r *http.Request) {
+	emails, err := ListUndeliveredEmails(app.DB)
+	if err != nil {
+		log.Printf("outbox: list: %v", err)
+	}
+	pd := app.newPageData(r, map[string]any{
+		"Emails":      emails,
+		"MaxAttempts": outboxMaxAttempts,
+	})
+	app.render(w, r, "admin_outbox.html", pd)
+}
+
+func (app *App) handleAdminOutboxRetry(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/admin/outbox", http.StatusSeeOther)
+		return
+	}
+	lang := LangFromRequest(r)
+	id, _ := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	if err := RequeueOutboxEmail(app.DB, id); err != nil {
+		log.Printf("outbox: requeue %d: %v", id, err)
+	} else {
+		app.kickOutbox()
+	}
+	http.Redirect(w, r, fmt.Sprintf("/admin/outbox?lang=%s", lang), http.StatusSeeOther)
+}
+
+func (app *App) handleAdminOutboxDelete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/admin/outbox", http.StatusSeeOther)
+		return
+	}
+	lang := LangFromRequest(r)
+	id, _ := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	DeleteOutboxEmail(app.DB, id)
+	http.Redirect(w, r, fmt.Sprintf("/admin/outbox?lang=%s", lang), http.StatusSeeOther)
+}
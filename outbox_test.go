@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOutboxBackoff(t *testing.T) {
+	cases := map[int]time.Duration{
+		1:  30 * time.Second,
+		2:  time.Minute,
+		4:  4 * time.Minute,
+		20: outboxMaxBackoff,
+	}
+	for attempts, want := range cases {
+		if got := outboxBackoff(attempts); got != want {
+			t.Errorf("outboxBackoff(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+}
+
+func TestOutboxRetriesFailedConfirmation(t *testing.T) {
+	app := testApp(t)
+	fake := app.Email.(*fakeEmailSender)
+	fake.failUntil = 1
+	e := seedEvent(t, app.DB)
+	tk := seedTask(t, app.DB, e.ID, "Vaisselle", nil)
+	mux := newMux(app)
+
+	postForm(mux, "/signup?lang=fr", url.Values{
+		"task_id":    {fmt.Sprint(tk.ID)},
+		"first_name": {"Alice"},
+		"last_name":  {"Dupont"},
+		"email":      {"alice@test.com"},
+		"phone":      {"0601"},
+	})
+	if fake.count() != 0 {
+		t.Fatalf("expected the first attempt to fail, got %d sent", fake.count())
+	}
+	pending, _ := ListUndeliveredEmails(app.DB)
+	if len(pending) != 1 || pending[0].Status != "pending" || pending[0].Attempts != 1 || pending[0].LastError == "" {
+		t.Fatalf("outbox after failure = %+v", pending)
+	}
+
+	// Not due yet: the retry waits for the backoff.
+	app.deliverOutbox(time.Now())
+	if fake.count() != 0 {
+		t.Fatalf("retried before backoff elapsed")
+	}
+	app.deliverOutbox(time.Now().Add(time.Minute))
+	if fake.count() != 1 {
+		t.Fatalf("expected retry to deliver, got %d sent", fake.count())
+	}
+	if got := fake.sent[0].Attachments; len(got) != 1 || !strings.HasSuffix(got[0], ".ics") {
+		t.Errorf("attachments lost in the outbox: %v", got)
+	}
+	m, _ := GetOutboxEmail(app.DB, pending[0].ID)
+	if m.Status != "sent" || m.MessageID == "" || !m.SentAt.Valid {
+		t.Errorf("outbox row after delivery = %+v", m)
+	}
+	if left, _ := ListUndeliveredEmails(app.DB); len(left) != 0 {
+		t.Errorf("undelivered after success = %+v", left)
+	}
+}
+
+func TestOutboxGivesUpAndAdminRetries(t *testing.T) {
+	app := testApp(t)
+	fake := app.Email.(*fakeEmailSender)
+	fake.failUntil = outboxMaxAttempts
+	mux := newMux(app)
+
+	app.queueEmail("admin_notification", 0, "admin@test.com", "", "Nouvelle inscription", "<p>hi</p>")
+	now := time.Now()
+	for i := 1; i < outboxMaxAttempts; i++ {
+		now = now.Add(outboxMaxBackoff)
+		app.deliverOutbox(now)
+	}
+	emails, _ := ListUndeliveredEmails(app.DB)
+	if len(emails) != 1 || emails[0].Status != "failed" || emails[0].Attempts != outboxMaxAttempts {
+		t.Fatalf("outbox after max attempts = %+v", emails)
+	}
+	app.deliverOutbox(now.Add(24 * time.Hour))
+	if fake.count() != 0 {
+		t.Fatalf("failed email was retried automatically")
+	}
+
+	w := getRequest(mux, "/admin?lang=fr", adminCookie(app))
+	if !strings.Contains(w.Body.String(), "/admin/outbox") {
+		t.Errorf("events page does not link to failed emails")
+	}
+	w = getRequest(mux, "/admin/outbox?lang=fr", adminCookie(app))
+	if !strings.Contains(w.Body.String(), "admin@test.com") || !strings.Contains(w.Body.String(), "fake email failure") {
+		t.Errorf("outbox page missing failed email:\n%s", w.Body.String())
+	}
+
+	postForm(mux, "/admin/outbox/retry?lang=fr", url.Values{"id": {fmt.Sprint(emails[0].ID)}}, adminCookie(app))
+	if fake.count() != 1 || fake.sent[0].To != "admin@test.com" {
+		t.Fatalf("manual retry did not deliver: %+v", fake.sent)
+	}
+	if CountFailedEmails(app.DB) != 0 {
+		t.Errorf("failed count after retry = %d", CountFailedEmails(app.DB))
+	}
+}
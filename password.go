@@ -0,0 +1,124 @@
+package main
+
+// Admin password, stored as a bcrypt hash in the settings table. A fresh
+// install asks for one at /admin/setup unless EVENT_SIGNUP_ADMIN_PASSWORD
+// seeds it; afterwards it is changed from the settings page, and the
+// environment variable is ignored.
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const minAdminPasswordLen = 8
+
+// adminPasswordCost is the bcrypt cost; tests lower it to stay fast.
+var adminPasswordCost = bcrypt.DefaultCost
+
+var errNoAdminPassword = errors.New("no admin password set")
+
+// adminPasswordHash returns the stored bcrypt hash, seeding it from
+// app.AdminPassword on first use. "" means setup hasn't happened yet.
+func (app *App) adminPasswordHash() (string, error) {
+	if h := GetSetting(app.DB, "admin_password_hash"); h != "" || app.AdminPassword == "" {
+		return h, nil
+	}
+	h, err := bcrypt.GenerateFromPassword([]byte(app.AdminPassword), adminPasswordCost)
+	if err != nil {
+		return "", err
+	}
+	// Concurrent first requests may both get here; the first hash stored wins.
+	return InitSetting(app.DB, "admin_password_hash", string(h))
+}
+
+// checkAdminPassword reports whether password is the admin password.
+func (app *App) checkAdminPassword(password string) bool {
+	h, err := app.adminPasswordHash()
+	if err != nil {
+		log.Printf("admin password: %v", err)
+		return false
+	}
+	return h != "" && bcrypt.CompareHashAndPassword([]byte(h), []byte(password)) == nil
+}
+
+func (app *App) setAdminPassword(password string) error {
+	h, err := bcrypt.GenerateFromPassword([]byte(password), adminPasswordCost)
+	if err != nil {
+		return err
+	}
+	return SetSetting(app.DB, "admin_password_hash", string(h))
+}
+
+// validateNewPassword returns the i18n key of what's wrong with a new
+// password and its confirmation, or "".
+func validateNewPassword(password, confirm string) string {
+	if len(password) < minAdminPasswordLen {
+		return "password_error_short"
+	}
+	if password != confirm {
+		return "password_error_mismatch"
+	}
+	return ""
+}
+
+// handleAdminSetup lets the first visitor of a fresh install choose the
+// admin password. Once one exists it only redirects to the login page.
+func (app *App) handleAdminSetup(w http.ResponseWriter, r *http.Request) {
+	pd := app.newPageData(r, nil)
+	if h, err := app.adminPasswordHash(); err != nil || h != "" {
+		http.Redirect(w, r, "/admin/login?lang="+pd.Lang, http.StatusSeeOther)
+		return
+	}
+	if r.Method == http.MethodPost {
+		password := r.FormValue("password")
+		if errKey := validateNewPassword(password, r.FormValue("password_confirm")); errKey != "" {
+			pd.Error = T(errKey, pd.Lang)
+			app.render(w, r, "admin_setup.html", pd)
+			return
+		}
+		if err := app.setAdminPassword(password); err != nil {
+			log.Printf("admin password: set: %v", err)
+			pd.Error = T("error_server", pd.Lang)
+			app.render(w, r, "admin_setup.html", pd)
+			return
+		}
+		app.startSession(w, r, "/admin?lang="+pd.Lang, false)
+		return
+	}
+	app.render(w, r, "admin_setup.html", pd)
+}
+
+// handleAdminPasswordChange changes the admin password from the settings
+// page. Every session ends with the old password; this browser gets a new one.
+func (app *App) handleAdminPasswordChange(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/admin/settings", http.StatusSeeOther)
+		return
+	}
+	lang := LangFromRequest(r)
+	settingsURL := "/admin/settings?lang=" + lang
+	password := r.FormValue("new_password")
+	errKey := validateNewPassword(password, r.FormValue("new_password_confirm"))
+	if !app.checkAdminPassword(r.FormValue("current_password")) {
+		errKey = "password_error_current"
+	}
+	if errKey != "" {
+		setFlash(w, "error", T(errKey, lang))
+		http.Redirect(w, r, settingsURL, http.StatusSeeOther)
+		return
+	}
+	// The new session replaces this one, remembered if this one was.
+	current, _ := app.adminSession(r)
+	remembered := current.Remember
+	if err := app.setAdminPassword(password); err != nil {
+		log.Printf("admin password: set: %v", err)
+		setFlash(w, "error", T("error_server", lang))
+		http.Redirect(w, r, settingsURL, http.StatusSeeOther)
+		return
+	}
+	setFlash(w, "success", T("password_changed", lang))
+	app.startSession(w, r, settingsURL, remembered)
+}
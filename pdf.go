@@ -0,0 +1,166 @@
+package main
+
+// A minimal PDF writer for printable exports: A4 pages of text in the
+// standard Helvetica fonts, which every reader has, and lines. Text is
+// encoded in WinAnsi, which covers French and English; other characters
+// print as '?'. Coordinates are in points from the bottom left of the page.
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+const (
+	pdfPageWidth  = 595.28
+	pdfPageHeight = 841.89
+)
+
+// pdfDoc is a PDF being written, page by page.
+type pdfDoc struct {
+	pages []*bytes.Buffer // content streams
+	page  *bytes.Buffer   // the current one
+}
+
+func (d *pdfDoc) addPage() {
+	d.page = &bytes.Buffer{}
+	d.pages = append(d.pages, d.page)
+}
+
+// text writes s at x, y, in Helvetica of size size, bold or not.
+func (d *pdfDoc) text(x, y, size float64, bold bool, s string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(d.page, "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, y, pdfEscape(winAnsi(s)))
+}
+
+// line draws a line from x1, y1 to x2, y2, width wide, in gray level gray
+// (0 black, 1 white).
+func (d *pdfDoc) line(x1, y1, x2, y2, width, gray float64) {
+	fmt.Fprintf(d.page, "%.2f G %.2f w %.2f %.2f m %.2f %.2f l S\n", gray, width, x1, y1, x2, y2)
+}
+
+// rect draws the outline of a w by h rectangle whose bottom left corner is
+// at x, y.
+func (d *pdfDoc) rect(x, y, w, h, width float64) {
+	fmt.Fprintf(d.page, "0 G %.2f w %.2f %.2f %.2f %.2f re S\n", width, x, y, w, h)
+}
+
+// bytes returns the document: catalog, page tree and fonts, then each page
+// with its content stream.
+func (d *pdfDoc) bytes() []byte {
+	var objects []string
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	objects = append(objects,
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+	)
+	for i, content := range d.pages {
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+				pdfPageWidth, pdfPageHeight, 6+2*i),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.Bytes()),
+		)
+	}
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return out.Bytes()
+}
+
+// winAnsiSpecials are the characters WinAnsi puts in 0x80–0x9F.
+var winAnsiSpecials = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87, 'ˆ': 0x88,
+	'‰': 0x89, 'Š': 0x8A, '‹': 0x8B, 'Œ': 0x8C, 'Ž': 0x8E, '‘': 0x91, '’': 0x92, '“': 0x93,
+	'”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '˜': 0x98, '™': 0x99, 'š': 0x9A, '›': 0x9B,
+	'œ': 0x9C, 'ž': 0x9E, 'Ÿ': 0x9F,
+}
+
+// winAnsi encodes s in WinAnsi, '?' standing for what it lacks.
+func winAnsi(s string) []byte {
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r >= 0x20 && r < 0x7F, r >= 0xA0 && r <= 0xFF:
+			out = append(out, byte(r))
+		case winAnsiSpecials[r] != 0:
+			out = append(out, winAnsiSpecials[r])
+		case r == '\t' || r == '\n' || r == '\r':
+			out = append(out, ' ')
+		default:
+			out = append(out, '?')
+		}
+	}
+	return out
+}
+
+// pdfEscape escapes the characters special in a PDF string.
+func pdfEscape(b []byte) []byte {
+	var out bytes.Buffer
+	for _, c := range b {
+		if c == '(' || c == ')' || c == '\\' {
+			out.WriteByte('\\')
+		}
+		out.WriteByte(c)
+	}
+	return out.Bytes()
+}
+
+// helveticaWidths are the widths of the printable ASCII characters in
+// Helvetica, in thousandths of the font size.
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+// pdfTextWidth returns about how wide s prints: other characters count as a
+// digit, and bold as a little wider than regular.
+func pdfTextWidth(s string, size float64, bold bool) float64 {
+	total := 0
+	for _, c := range winAnsi(s) {
+		if c >= 0x20 && c < 0x7F {
+			total += helveticaWidths[c-0x20]
+		} else {
+			total += 556
+		}
+	}
+	w := float64(total) * size / 1000
+	if bold {
+		w *= 1.08
+	}
+	return w
+}
+
+// pdfFit cuts s, ending it with an ellipsis, to print within width.
+func pdfFit(s string, width, size float64, bold bool) string {
+	if pdfTextWidth(s, size, bold) <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && pdfTextWidth(string(runes)+"…", size, bold) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return strings.TrimSpace(string(runes)) + "…"
+}
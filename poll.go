@@ -0,0 +1,261 @@
+package main
+
+// Import of availability polls (Framadate, Doodle) exported as CSV. Date
+// finding usually happens in such a poll before signups open here; the
+// importer turns its options into tasks, or its answers for the winning
+// option into attendance records.
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// pollAnswer is one participant's answer to one poll option.
+type pollAnswer int
+
+const (
+	pollNo pollAnswer = iota
+	pollMaybe
+	pollYes
+)
+
+type pollParticipant struct {
+	Name    string
+	Answers []pollAnswer // one per option; missing cells count as "no"
+}
+
+type pollData struct {
+	Options      []string
+	Participants []pollParticipant
+}
+
+var errPollNoOptions = errors.New("poll csv: no options found")
+
+// pollAnswerFor recognizes the answer spellings used by Framadate (FR/EN)
+// and Doodle. ok is false for cells that are not an answer at all, which is
+// how header rows are told apart from participant rows.
+func pollAnswerFor(cell string) (answer pollAnswer, ok bool) {
+	switch strings.ToLower(strings.TrimSpace(cell)) {
+	case "oui", "yes", "ok", "x", "1", "✓", "✔":
+		return pollYes, true
+	case "si nécessaire", "si necessaire", "if need be", "ifneedbe", "(ok)", "peut-être", "peut-etre", "maybe":
+		return pollMaybe, true
+	case "non", "no", "0":
+		return pollNo, true
+	}
+	return pollNo, false
+}
+
+// isPollSummaryRow reports rows Doodle and Framadate append after the
+// participants (vote counts), which must not be imported as people.
+func isPollSummaryRow(name string) bool {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "count", "total", "totaux", "nombre", "somme", "sum":
+		return true
+	}
+	return false
+}
+
+// parsePollCSV reads a Framadate or Doodle CSV export. The first column
+// holds participant names; every row before the first participant is a
+// header row, and an option's label joins its header cells top to bottom
+// (month, day, time for Doodle; date, time for Framadate). Header cells left
+// empty under a merged span inherit the value on their left, except in the
+// last header row, whose blanks are real ("no time given"). Empty answer
+// cells count as "no", as in Doodle exports.
+func parsePollCSV(r io.Reader) (*pollData, error) {
+	records, err := readCSVRecords(r)
+	if err != nil {
+		return nil, fmt.Errorf("parse poll csv: %w", err)
+	}
+
+	cell := func(rec []string, i int) string {
+		if i < len(rec) {
+			return strings.TrimSpace(rec[i])
+		}
+		return ""
+	}
+	isParticipant := func(rec []string) bool {
+		if cell(rec, 0) == "" || len(rec) < 2 {
+			return false
+		}
+		for _, c := range rec[1:] {
+			if _, ok := pollAnswerFor(c); ok {
+				return true
+			}
+		}
+		return false
+	}
+
+	first := len(records)
+	for i, rec := range records {
+		if isParticipant(rec) {
+			first = i
+			break
+		}
+	}
+	var headers [][]string
+	for _, rec := range records[:first] {
+		for _, c := range rec[1:] {
+			if strings.TrimSpace(c) != "" {
+				headers = append(headers, rec)
+				break
+			}
+		}
+	}
+
+	width := 0
+	for _, rec := range headers {
+		for i := len(rec) - 1; i >= 1; i-- {
+			if strings.TrimSpace(rec[i]) != "" {
+				width = max(width, i)
+				break
+			}
+		}
+	}
+	labels := make([][]string, width+1)
+	for h, rec := range headers {
+		carry := ""
+		for i := 1; i <= width; i++ {
+			v := cell(rec, i)
+			if v == "" && h < len(headers)-1 {
+				v = carry
+			}
+			carry = v
+			if v != "" {
+				labels[i] = append(labels[i], v)
+			}
+		}
+	}
+
+	poll := &pollData{}
+	var cols []int
+	for i := 1; i <= width; i++ {
+		if label := strings.Join(strings.Fields(strings.Join(labels[i], " ")), " "); label != "" {
+			poll.Options = append(poll.Options, label)
+			cols = append(cols, i)
+		}
+	}
+	if len(poll.Options) == 0 {
+		return nil, errPollNoOptions
+	}
+
+	for _, rec := range records[first:] {
+		name := cell(rec, 0)
+		if name == "" {
+			continue
+		}
+		if isPollSummaryRow(name) {
+			break
+		}
+		p := pollParticipant{Name: name, Answers: make([]pollAnswer, len(cols))}
+		for j, c := range cols {
+			p.Answers[j], _ = pollAnswerFor(cell(rec, c))
+		}
+		poll.Participants = append(poll.Participants, p)
+	}
+	return poll, nil
+}
+
+// bestOption picks the option most participants said yes to, counting
+// "maybe" only to break ties; the earliest option wins a full tie.
+func (p *pollData) bestOption() int {
+	best, bestYes, bestMaybe := 0, -1, -1
+	for i := range p.Options {
+		yes, maybe := 0, 0
+		for _, part := range p.Participants {
+			switch part.Answers[i] {
+			case pollYes:
+				yes++
+			case pollMaybe:
+				maybe++
+			}
+		}
+		if yes > bestYes || (yes == bestYes && maybe > bestMaybe) {
+			best, bestYes, bestMaybe = i, yes, maybe
+		}
+	}
+	return best
+}
+
+// splitPollName splits a poll display name into first and last name on the
+// first space; polls only ever ask for a single free-text name.
+func splitPollName(name string) (first, last string) {
+	first, last, _ = strings.Cut(strings.Join(strings.Fields(name), " "), " ")
+	return first, last
+}
+
+// handleAdminPollImport imports an uploaded poll CSV into a tasks event (one
+// task per option) or an attendance event (everyone's answer to the most
+// popular option).
+func (app *App) handleAdminPollImport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/admin", http.StatusSeeOther)
+		return
+	}
+	lang := LangFromRequest(r)
+	eventID, _ := strconv.ParseInt(r.FormValue("event_id"), 10, 64)
+	event, err := GetEvent(app.DB, eventID)
+	if err != nil || event.EventType == "secret_santa" {
+		http.NotFound(w, r)
+		return
+	}
+	done := func(kind, msg string) {
+		setFlash(w, kind, msg)
+		http.Redirect(w, r, fmt.Sprintf("/admin/event/edit?id=%d&lang=%s", event.ID, lang), http.StatusSeeOther)
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		done("error", T("poll_import_no_file", lang))
+		return
+	}
+	defer file.Close()
+	poll, err := parsePollCSV(file)
+	if err != nil {
+		if !errors.Is(err, errPollNoOptions) {
+			log.Printf("poll import parse error: %v", err)
+		}
+		done("error", T("poll_import_bad_file", lang))
+		return
+	}
+
+	if event.EventType == "attendance" {
+		opt := poll.bestOption()
+		created, updated := 0, 0
+		for _, p := range poll.Participants {
+			first, last := splitPollName(p.Name)
+			message := ""
+			if p.Answers[opt] == pollMaybe {
+				message = T("poll_answer_maybe", lang)
+			}
+			isNew, err := ImportAttendance(app.DB, event.ID, first, last, p.Answers[opt] != pollNo, message)
+			if err != nil {
+				log.Printf("poll import attendance error (%s): %v", p.Name, err)
+				continue
+			}
+			if isNew {
+				created++
+			} else {
+				updated++
+			}
+		}
+		done("success", fmt.Sprintf(T("poll_import_attendance_done", lang), created, updated, poll.Options[opt]))
+		return
+	}
+
+	created := 0
+	for _, label := range poll.Options {
+		if err := CreateTask(app.DB, &Task{EventID: event.ID, TitleFR: label, TitleEN: label}); err != nil {
+			log.Printf("poll import task error (%s): %v", label, err)
+			continue
+		}
+		created++
+	}
+	done("success", fmt.Sprintf(T("poll_import_tasks_done", lang), created))
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const framadateCSV = "\xef\xbb\xbf" + `"","14/06/2026","14/06/2026","21/06/2026"
+"","10h","14h",""
+"Alice Dupont","Oui","Non","Si nécessaire"
+"Bob","Non","Oui","Oui"
+"Carol Martin","Oui","Oui","Non"
+`
+
+const doodleCSV = `"Fête de l'école"
+"https://doodle.com/poll/abc"
+
+,Juin 2026,,Juillet 2026
+,Sa 14,Sa 14,Sa 5
+,10:00,14:00,
+Alice,OK,,(OK)
+Bob,,OK,OK
+Count,1,1,1
+`
+
+func TestParsePollCSVFramadate(t *testing.T) {
+	poll, err := parsePollCSV(strings.NewReader(framadateCSV))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	wantOpts := []string{"14/06/2026 10h", "14/06/2026 14h", "21/06/2026"}
+	if fmt.Sprint(poll.Options) != fmt.Sprint(wantOpts) {
+		t.Errorf("options = %q, want %q", poll.Options, wantOpts)
+	}
+	if len(poll.Participants) != 3 {
+		t.Fatalf("expected 3 participants, got %d", len(poll.Participants))
+	}
+	alice := poll.Participants[0]
+	if alice.Name != "Alice Dupont" || alice.Answers[0] != pollYes || alice.Answers[1] != pollNo || alice.Answers[2] != pollMaybe {
+		t.Errorf("alice = %+v", alice)
+	}
+	if best := poll.bestOption(); best != 0 {
+		t.Errorf("best option = %d, want 0 (tie on yes, broken by order)", best)
+	}
+}
+
+func TestParsePollCSVDoodle(t *testing.T) {
+	poll, err := parsePollCSV(strings.NewReader(doodleCSV))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	wantOpts := []string{"Juin 2026 Sa 14 10:00", "Juin 2026 Sa 14 14:00", "Juillet 2026 Sa 5"}
+	if fmt.Sprint(poll.Options) != fmt.Sprint(wantOpts) {
+		t.Errorf("options = %q, want %q", poll.Options, wantOpts)
+	}
+	if len(poll.Participants) != 2 {
+		t.Fatalf("expected 2 participants (Count row skipped), got %d", len(poll.Participants))
+	}
+	if got := poll.Participants[0].Answers; got[0] != pollYes || got[1] != pollNo || got[2] != pollMaybe {
+		t.Errorf("alice answers = %v", got)
+	}
+	// Option 3 has one yes and one maybe, beating options 1 and 2.
+	if best := poll.bestOption(); best != 2 {
+		t.Errorf("best option = %d, want 2", best)
+	}
+}
+
+func TestParsePollCSVNoOptions(t *testing.T) {
+	if _, err := parsePollCSV(strings.NewReader("Name\nAlice\n")); err == nil {
+		t.Error("expected an error for a file without options")
+	}
+}
+
+func TestPollImportTasks(t *testing.T) {
+	app := testApp(t)
+	e := seedEvent(t, app.DB)
+	mux := newMux(app)
+
+	w := postMultipart(mux, "/admin/event/poll-import?lang=fr", "poll.csv", framadateCSV,
+		map[string]string{"event_id": fmt.Sprint(e.ID)}, adminCookie(app))
+	if w.Code != 303 {
+		t.Fatalf("status = %d, want 303", w.Code)
+	}
+	tasks, _ := ListTasks(app.DB, e.ID)
+	if len(tasks) != 3 || tasks[0].TitleFR != "14/06/2026 10h" {
+		t.Errorf("tasks = %+v", tasks)
+	}
+}
+
+func TestPollImportAttendance(t *testing.T) {
+	app := testApp(t)
+	e := &Event{TitleFR: "AG", EventDate: "2026-06-14", EventType: "attendance"}
+	CreateEvent(app.DB, e)
+	mux := newMux(app)
+
+	for range 2 { // re-importing updates instead of duplicating
+		postMultipart(mux, "/admin/event/poll-import?lang=fr", "poll.csv", framadateCSV,
+			map[string]string{"event_id": fmt.Sprint(e.ID)}, adminCookie(app))
+	}
+	atts, _ := ListAttendances(app.DB, e.ID)
+	if len(atts) != 3 {
+		t.Fatalf("expected 3 attendances, got %d", len(atts))
+	}
+	yes, _ := CountAttendances(app.DB, e.ID)
+	if yes != 2 {
+		t.Errorf("attending = %d, want 2 (Alice and Carol for the first option)", yes)
+	}
+	if _, err := GetAttendanceByEmail(app.DB, "", e.ID); err == nil {
+		t.Error("blank email lookup must not match imported rows")
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+// Public event API. /api/event?slug=…&lang=… returns an event and its
+// group/task tree in one language, with slots taken and left, so community
+// sites and newsletters can show live availability. Like the public page it
+// never carries personal data — not even the first names registrants agreed
+// to show — and answers 404 for drafts. Any site may call it from a browser.
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+)
+
+// publicEvent is an event as /api/event returns it.
+type publicEvent struct {
+	Slug        string `json:"slug"`
+	URL         string `json:"url"`
+	Lang        string `json:"lang"`
+	Title       string `json:"title"`
+	Description string `json:"description"` // HTML
+	EventType   string `json:"event_type"`
+	Date        string `json:"date"`
+	Time        string `json:"time,omitempty"`
+	EndDate     string `json:"end_date,omitempty"`
+	EndTime     string `json:"end_time,omitempty"`
+	Timezone    string `json:"timezone,omitempty"`
+	// Registration is "open", "not_yet_open", "closed" or "full".
+	Registration string            `json:"registration"`
+	Tree         []publicEventNode `json:"tree"`
+}
+
+// publicEventNode is a group or a task of publicEvent's tree. SlotsLeft is
+// null when there is no limit.
+type publicEventNode struct {
+	Type        string            `json:"type"`
+	ID          int64             `json:"id"`
+	Title       string            `json:"title"`
+	Description string            `json:"description,omitempty"` // HTML
+	Color       string            `json:"color,omitempty"`
+	Icon        string            `json:"icon,omitempty"`
+	StartTime   string            `json:"start_time,omitempty"`
+	EndTime     string            `json:"end_time,omitempty"`
+	MaxSlots    *int64            `json:"max_slots"`
+	Taken       int               `json:"taken"`
+	SlotsLeft   *int              `json:"slots_left"`
+	IsFull      bool              `json:"is_full"`
+	StillNeeded int               `json:"still_needed,omitempty"`
+	Children    []publicEventNode `json:"children,omitempty"`
+}
+
+// toPublicEventTree localizes tree for publicEvent.
+func toPublicEventTree(tree []TreeNode, lang string) []publicEventNode {
+	nodes := make([]publicEventNode, 0, len(tree))
+	for _, n := range tree {
+		if n.Type == "group" {
+			node := publicEventNode{
+				Type: "group", ID: n.Group.ID, Title: Localized(n.Group.TitleFR, n.Group.TitleEN, lang),
+				Color: n.Group.Color, Icon: n.Group.Icon, MaxSlots: nullInt(n.Group.MaxSlots), Taken: n.RegCount,
+				Children: toPublicEventTree(n.Children, lang),
+			}
+			if n.SlotsLeft >= 0 {
+				left := n.SlotsLeft
+				node.SlotsLeft, node.IsFull = &left, left == 0
+			}
+			nodes = append(nodes, node)
+			continue
+		}
+		t := n.Task
+		node := publicEventNode{
+			Type: "task", ID: t.ID, Title: Localized(t.TitleFR, t.TitleEN, lang),
+			Description: renderMarkdown(Localized(t.DescriptionFR, t.DescriptionEN, lang)), StartTime: t.StartTime, EndTime: t.EndTime,
+			MaxSlots: nullInt(t.MaxSlots), Taken: t.RegCount, IsFull: t.IsFull, StillNeeded: t.StillNeeded,
+		}
+		if t.SlotsLeft >= 0 {
+			left := t.SlotsLeft
+			node.SlotsLeft = &left
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+func (app *App) handleAPIPublicEvent(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	if r.Method != http.MethodGet {
+		apiError(w, http.StatusMethodNotAllowed, "method_not_allowed")
+		return
+	}
+	slug := r.URL.Query().Get("slug")
+	event, err := GetEventBySlug(app.DB, slug)
+	if err == sql.ErrNoRows {
+		event, err = GetEventByOldSlug(app.DB, slug)
+	}
+	if err != nil || app.hiddenDraft(r, event) || app.inviteDenied(r, event) {
+		apiError(w, http.StatusNotFound, "not_found")
+		return
+	}
+	lang := LangFromRequest(r)
+	result := publicEvent{
+		Slug: event.Slug, URL: app.eventBaseURL(r, event) + "/e/" + event.Slug, Lang: lang,
+		Title: Localized(event.TitleFR, event.TitleEN, lang), Description: Localized(event.DescriptionFR, event.DescriptionEN, lang),
+		EventType: event.EventType, Date: event.EventDate, Time: event.EventTime, EndDate: event.EndDate, EndTime: event.EndTime,
+		Timezone: event.Timezone, Registration: signupState(app.DB, event, time.Now()), Tree: []publicEventNode{},
+	}
+	if event.EventType == "tasks" {
+		tree, err := BuildEventTree(app.DB, event.ID)
+		if err != nil {
+			apiServerError(w, "public tree of event "+event.Slug, err)
+			return
+		}
+		holdReservedTree(tree)
+		result.Tree = toPublicEventTree(tree, lang)
+	}
+	if !event.Draft {
+		w.Header().Set("Cache-Control", "public, max-age=30")
+	}
+	writeJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestAPIPublicEvent(t *testing.T) {
+	app := testApp(t)
+	mux := newMux(app)
+	e := seedEvent(t, app.DB)
+	g := &TaskGroup{EventID: e.ID, TitleFR: "Cuisine", TitleEN: "Kitchen", Icon: "🍳"}
+	CreateTaskGroup(app.DB, g)
+	dishes := &Task{EventID: e.ID, GroupID: sql.NullInt64{Int64: g.ID, Valid: true}, TitleFR: "Vaisselle", TitleEN: "Dishes",
+		DescriptionEN: "Bring a **towel**", MaxSlots: sql.NullInt64{Int64: 3, Valid: true}}
+	CreateTask(app.DB, dishes)
+	seedTask(t, app.DB, e.ID, "Buvette", nil)
+	RegisterForTask(app.DB, dishes.ID, "Alice", "Dupont", "alice@test.com", "0601", "fr")
+
+	w := getRequest(mux, "/api/event?slug="+e.Slug+"&lang=en")
+	if w.Code != 200 || w.Header().Get("Access-Control-Allow-Origin") != "*" {
+		t.Fatalf("status %d, headers %v", w.Code, w.Header())
+	}
+	if strings.Contains(w.Body.String(), "Alice") || strings.Contains(w.Body.String(), "alice@test.com") {
+		t.Error("personal data in the public API")
+	}
+	var res publicEvent
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Title != "Test Event" || res.Registration != registrationOpen || len(res.Tree) != 2 {
+		t.Fatalf("event = %+v", res)
+	}
+	group, bar := res.Tree[0], res.Tree[1]
+	if group.Type != "group" || group.Title != "Kitchen" || group.Icon != "🍳" || group.SlotsLeft != nil || len(group.Children) != 1 {
+		t.Errorf("group = %+v", group)
+	}
+	if task := group.Children[0]; task.Title != "Dishes" || task.Taken != 1 || task.SlotsLeft == nil || *task.SlotsLeft != 2 {
+		t.Errorf("task = %+v", task)
+	} else if task.Description != "<p>Bring a <strong>towel</strong></p>" {
+		t.Errorf("task description = %q, want HTML like the event's", task.Description)
+	}
+	if bar.Title != "Buvette" || bar.SlotsLeft != nil || bar.MaxSlots != nil {
+		t.Errorf("unlimited task = %+v", bar)
+	}
+
+	if w := getRequest(mux, "/api/event?slug=nope"); w.Code != 404 {
+		t.Errorf("unknown slug: status %d", w.Code)
+	}
+	SetEventDraft(app.DB, e.ID, true)
+	if w := getRequest(mux, "/api/event?slug="+e.Slug); w.Code != 404 {
+		t.Errorf("draft: status %d", w.Code)
+	}
+}
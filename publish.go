@@ -0,0 +1,61 @@
+package main
+
+// Draft events. An event created from the admin, or duplicated, starts as a
+// draft: organizers prepare it at leisure while its public address shows
+// "coming soon" and every public signup, RSVP or preference form turns posts
+// away. Logged-in admins see and use the real page, to try it out. One click
+// on the edit page publishes it; it can be taken back to draft the same way.
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// SetEventDraft takes event id off the public site, or puts it on.
+func SetEventDraft(db *sql.DB, id int64, draft bool) error {
+	_, err := db.Exec("UPDATE events SET draft=? WHERE id=?", draft, id)
+	return err
+}
+
+// hiddenDraft reports whether event is a draft r may not see: anyone but a
+// logged-in admin.
+func (app *App) hiddenDraft(r *http.Request, event *Event) bool {
+	if !event.Draft {
+		return false
+	}
+	_, admin := app.adminSession(r)
+	return !admin
+}
+
+// renderComingSoon answers for a draft event's public page.
+func (app *App) renderComingSoon(w http.ResponseWriter, r *http.Request, event *Event) {
+	w.Header().Set("X-Robots-Tag", "noindex")
+	app.render(w, r, "coming_soon.html", app.newPageData(r, map[string]any{"Event": event}))
+}
+
+func (app *App) handleAdminEventPublish(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/admin", http.StatusSeeOther)
+		return
+	}
+	lang := LangFromRequest(r)
+	id, _ := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	event, err := GetEvent(app.DB, id)
+	if err != nil {
+		http.Redirect(w, r, "/admin", http.StatusSeeOther)
+		return
+	}
+	draft := r.FormValue("draft") == "1"
+	if err := SetEventDraft(app.DB, event.ID, draft); err != nil {
+		log.Printf("publish event %d: %v", event.ID, err)
+		setFlash(w, "error", T("error_server", lang))
+	} else if draft {
+		setFlash(w, "success", T("event_unpublished", lang))
+	} else {
+		setFlash(w, "success", T("event_published", lang))
+	}
+	http.Redirect(w, r, fmt.Sprintf("/admin/event/edit?id=%d&lang=%s", event.ID, lang), http.StatusSeeOther)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestDraftEvent(t *testing.T) {
+	app := testApp(t)
+	mux := newMux(app)
+	admin := adminCookie(app)
+	e := seedEvent(t, app.DB)
+	tk := seedTask(t, app.DB, e.ID, "Cuisine", int64Ptr(5))
+	if err := SetEventDraft(app.DB, e.ID, true); err != nil {
+		t.Fatal(err)
+	}
+
+	w := getRequest(mux, "/e/"+e.Slug+"?lang=en")
+	if w.Code != 200 || !strings.Contains(w.Body.String(), "Coming soon") || strings.Contains(w.Body.String(), "Cuisine") {
+		t.Errorf("public draft page: status %d, want coming soon without the tasks", w.Code)
+	}
+	if w.Header().Get("X-Robots-Tag") != "noindex" {
+		t.Error("draft page should not be indexed")
+	}
+	w = getRequest(mux, "/e/"+e.Slug+"?lang=en", admin)
+	if !strings.Contains(w.Body.String(), "Cuisine") || !strings.Contains(w.Body.String(), "only admins can see it") {
+		t.Error("admin should preview the draft page")
+	}
+
+	signup := url.Values{
+		"task_id":    {fmt.Sprint(tk.ID)},
+		"first_name": {"Alice"},
+		"last_name":  {"Dupont"},
+		"email":      {"alice@test.com"},
+		"phone":      {"0601020304"},
+	}
+	postForm(mux, "/signup?lang=fr", signup)
+	if n := CountRegistrations(app.DB, e.ID); n != 0 {
+		t.Fatalf("%d registrations on a draft event, want 0", n)
+	}
+
+	w = postForm(mux, "/admin/event/publish?lang=en", url.Values{"id": {fmt.Sprint(e.ID)}}, admin)
+	if w.Code != 303 {
+		t.Fatalf("publish: status %d", w.Code)
+	}
+	if got, _ := GetEvent(app.DB, e.ID); got.Draft {
+		t.Fatal("event still a draft after publishing")
+	}
+	postForm(mux, "/signup?lang=fr", signup)
+	if n := CountRegistrations(app.DB, e.ID); n != 1 {
+		t.Errorf("%d registrations once published, want 1", n)
+	}
+
+	postForm(mux, "/admin/event/publish?lang=en", url.Values{"id": {fmt.Sprint(e.ID)}, "draft": {"1"}}, admin)
+	if got, _ := GetEvent(app.DB, e.ID); !got.Draft {
+		t.Error("event not back to draft")
+	}
+}
+
+func TestNewEventStartsAsDraft(t *testing.T) {
+	app := testApp(t)
+	mux := newMux(app)
+	admin := adminCookie(app)
+	w := postForm(mux, "/admin/event/new?lang=fr", url.Values{
+		"title_fr": {"Fête"}, "title_en": {"Party"}, "event_date": {"2026-07-01"},
+	}, admin)
+	if w.Code != 303 {
+		t.Fatalf("create: status %d", w.Code)
+	}
+	events, err := ListEvents(app.DB)
+	if err != nil || len(events) != 1 {
+		t.Fatalf("events: %v, %v", events, err)
+	}
+	if !events[0].Draft {
+		t.Error("new event should start as a draft")
+	}
+}
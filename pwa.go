@@ -0,0 +1,49 @@
+package main
+
+// Offline support for the public event pages: a web-app manifest and a
+// service worker (static/sw.js) served from the site root so its scope covers
+// /e/ and /api/slots.
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// handleManifest serves the web-app manifest, localized to the visitor's
+// language so an installed shortcut carries the right name.
+func (app *App) handleManifest(w http.ResponseWriter, r *http.Request) {
+	lang := LangFromRequest(r)
+	manifest := map[string]any{
+		"name":             siteTitle(lang),
+		"short_name":       siteTitle(lang),
+		"lang":             lang,
+		"start_url":        "/",
+		"scope":            "/",
+		"display":          "standalone",
+		"background_color": "#F8FAFC",
+		"theme_color":      themeColor(),
+		"icons": []map[string]string{
+			{"src": "/static/logo.png", "type": "image/png", "sizes": "any"},
+		},
+	}
+	w.Header().Set("Content-Type", "application/manifest+json")
+	json.NewEncoder(w).Encode(manifest)
+}
+
+// handleServiceWorker serves static/sw.js from the root path. A worker's
+// scope is limited to its own directory, so serving it under /static/ would
+// keep it from seeing /e/ pages. The cache name is stamped with the static
+// build ID so each deploy starts from a clean cache.
+func (app *App) handleServiceWorker(w http.ResponseWriter, r *http.Request) {
+	src, err := staticFS.ReadFile("static/sw.js")
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/javascript; charset=utf-8")
+	// Browsers re-check the worker on navigation; never let a stale copy
+	// linger in an HTTP cache.
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Write([]byte(strings.ReplaceAll(string(src), "__BUILD_ID__", staticBuildID)))
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestServiceWorkerServedFromRoot(t *testing.T) {
+	app := testApp(t)
+	mux := newMux(app)
+
+	w := getRequest(mux, "/sw.js")
+	if w.Code != 200 {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/javascript") {
+		t.Errorf("Content-Type = %q, want application/javascript", ct)
+	}
+	body := w.Body.String()
+	if strings.Contains(body, "__BUILD_ID__") {
+		t.Error("build ID placeholder was not substituted")
+	}
+	if !strings.Contains(body, "event-signup-"+staticBuildID) {
+		t.Error("cache name should carry the static build ID")
+	}
+}
+
+func TestManifestLocalized(t *testing.T) {
+	app := testApp(t)
+	mux := newMux(app)
+
+	w := getRequest(mux, "/manifest.webmanifest?lang=en")
+	if w.Code != 200 {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	var m map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &m); err != nil {
+		t.Fatalf("manifest is not JSON: %v", err)
+	}
+	if m["lang"] != "en" || m["name"] != T("app_title", "en") {
+		t.Errorf("manifest = %v, want English name and lang", m)
+	}
+}
+
+func TestPublicPageRegistersServiceWorker(t *testing.T) {
+	app := testApp(t)
+	e := seedEvent(t, app.DB)
+	mux := newMux(app)
+
+	body := getRequest(mux, "/e/"+e.Slug).Body.String()
+	if !strings.Contains(body, `rel="manifest"`) || !strings.Contains(body, "serviceWorker.register('/sw.js')") {
+		t.Error("public page should link the manifest and register the service worker")
+	}
+	admin := getRequest(mux, "/admin", adminCookie(app)).Body.String()
+	if strings.Contains(admin, "serviceWorker.register") {
+		t.Error("admin pages should not register the service worker")
+	}
+}
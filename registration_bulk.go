@@ -0,0 +1,174 @@
+package main
+
+// Bulk actions on registrations. On the registrations page, organizers tick
+// registrations and apply one action to all of them at once: delete them
+// (to the trash, as one by one), move them to another task, download them
+// as CSV, or email them a message. Moving is an organizer's call, like
+// editing a task's slots: capacities and task rules aren't checked, but
+// nobody ends up twice on the same task.
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// maxBulkMessageLen bounds the message of a bulk email, in bytes.
+const maxBulkMessageLen = 10000
+
+// SelectedRegistrations returns those of registrations ids that belong to
+// event eventID, in registrationExportOrder.
+func SelectedRegistrations(db *sql.DB, eventID int64, ids []int64) ([]RegistrationExport, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	args := []any{eventID}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	marks := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	rows, err := db.Query(registrationExportSQL+" AND r.id IN ("+marks+")"+registrationExportOrder, args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanRegistrationExports(rows)
+}
+
+// MoveRegistrations moves registrations ids to task taskID, skipping those
+// whose person (email address and family member) is already on it, and
+// returns how many moved.
+func MoveRegistrations(db *sql.DB, ids []int64, taskID int64) (moved int, err error) {
+	for _, id := range ids {
+		res, err := db.Exec(`UPDATE registrations SET task_id=?
+			WHERE id=? AND trash_id IS NULL AND task_id != ? AND NOT EXISTS (
+				SELECT 1 FROM registrations o WHERE o.task_id=? AND o.trash_id IS NULL
+					AND LOWER(o.email)=LOWER(registrations.email) AND o.member=registrations.member)`,
+			taskID, id, taskID, taskID)
+		if err != nil {
+			return moved, err
+		}
+		n, _ := res.RowsAffected()
+		moved += int(n)
+	}
+	return moved, nil
+}
+
+// parseIDs reads the IDs of a repeated form value, skipping invalid ones.
+func parseIDs(values []string) []int64 {
+	var ids []int64
+	for _, v := range values {
+		if id, err := strconv.ParseInt(v, 10, 64); err == nil && id > 0 {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// handleAdminRegistrationsBulk applies the action chosen on the
+// registrations page to the ticked registrations, then goes back to the
+// page with the filters it had — except for export, which downloads them.
+func (app *App) handleAdminRegistrationsBulk(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/admin", http.StatusSeeOther)
+		return
+	}
+	lang := LangFromRequest(r)
+	eventID, _ := strconv.ParseInt(r.FormValue("event_id"), 10, 64)
+	event, err := GetEvent(app.DB, eventID)
+	if err != nil {
+		http.Redirect(w, r, "/admin", http.StatusSeeOther)
+		return
+	}
+	filter := RegistrationFilter{Query: strings.TrimSpace(r.FormValue("q"))}
+	filter.GroupID, _ = strconv.ParseInt(r.FormValue("group"), 10, 64)
+	filter.TaskID, _ = strconv.ParseInt(r.FormValue("task"), 10, 64)
+	back := filter.values()
+	back.Set("id", strconv.FormatInt(event.ID, 10))
+	back.Set("lang", lang)
+	pageURL := "/admin/event/registrations?" + back.Encode()
+
+	regs, err := SelectedRegistrations(app.DB, event.ID, parseIDs(r.Form["ids"]))
+	if err != nil {
+		log.Printf("bulk registrations: select for event %d: %v", event.ID, err)
+		setFlash(w, "error", T("error_server", lang))
+		http.Redirect(w, r, pageURL, http.StatusSeeOther)
+		return
+	}
+	if len(regs) == 0 {
+		setFlash(w, "error", T("bulk_none_selected", lang))
+		http.Redirect(w, r, pageURL, http.StatusSeeOther)
+		return
+	}
+	ids := make([]int64, len(regs))
+	for i, reg := range regs {
+		ids[i] = reg.ID
+	}
+
+	switch r.FormValue("action") {
+	case "delete":
+		deleted := 0
+		for _, id := range ids {
+			if err := DeleteRegistration(app.DB, id); err != nil {
+				log.Printf("bulk registrations: delete %d: %v", id, err)
+				continue
+			}
+			deleted++
+		}
+		setFlash(w, "success", fmt.Sprintf(T("bulk_deleted", lang), deleted))
+	case "move":
+		taskID, _ := strconv.ParseInt(r.FormValue("task_id"), 10, 64)
+		task, err := GetTask(app.DB, taskID)
+		if err != nil || task.EventID != event.ID {
+			setFlash(w, "error", T("bulk_move_no_task", lang))
+			break
+		}
+		moved, err := MoveRegistrations(app.DB, ids, task.ID)
+		if err != nil {
+			log.Printf("bulk registrations: move to task %d: %v", task.ID, err)
+			setFlash(w, "error", T("error_server", lang))
+			break
+		}
+		setFlash(w, "success", fmt.Sprintf(T("bulk_moved", lang), moved, len(ids)-moved))
+	case "export":
+		app.writeRegistrationsCSV(w, event, regs, event.Slug+"-selection.csv")
+		return
+	case "email":
+		subject := strings.TrimSpace(r.FormValue("subject"))
+		message := strings.TrimSpace(r.FormValue("message"))
+		if subject == "" || message == "" || len(message) > maxBulkMessageLen {
+			setFlash(w, "error", T("bulk_email_invalid", lang))
+			break
+		}
+		baseURL := app.eventConfiguredBaseURL(event)
+		sent, skipped := map[string]bool{}, map[string]bool{}
+		for _, reg := range regs {
+			email := strings.ToLower(reg.Email)
+			if email == "" || sent[email] || skipped[email] {
+				continue
+			}
+			// Bulk mail: honor opt-outs from the preferences link.
+			if IsUnsubscribed(app.DB, email) {
+				skipped[email] = true
+				continue
+			}
+			html := renderOrganizerMessageEmail(reg.Lang, *event, subject, message, baseURL, app.unsubscribeURL(baseURL, email, reg.Lang))
+			if html == "" {
+				log.Printf("bulk registrations: empty rendered email body for registration %d, skipping", reg.ID)
+				continue
+			}
+			app.queueEmail("bulk_message", event.ID, reg.Email, event.OrganizerEmail, subject, html)
+			sent[email] = true
+		}
+		if len(skipped) > 0 {
+			setFlash(w, "success", fmt.Sprintf(T("bulk_emailed_skipped", lang), len(sent), len(skipped)))
+		} else {
+			setFlash(w, "success", fmt.Sprintf(T("bulk_emailed", lang), len(sent)))
+		}
+	default:
+		setFlash(w, "error", T("bulk_no_action", lang))
+	}
+	http.Redirect(w, r, pageURL, http.StatusSeeOther)
+}
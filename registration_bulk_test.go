@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestRegistrationsBulk(t *testing.T) {
+	app := testApp(t)
+	mux := newMux(app)
+	admin := adminCookie(app)
+	fake := app.Email.(*fakeEmailSender)
+	e := &Event{TitleFR: "Fête", EventDate: "2026-06-15", MaxTasksPerPerson: 2}
+	CreateEvent(app.DB, e)
+	bar := seedTask(t, app.DB, e.ID, "Buvette", nil)
+	kitchen := seedTask(t, app.DB, e.ID, "Cuisine", nil)
+	alice, _ := RegisterForTask(app.DB, bar.ID, "Alice", "Dupont", "alice@test.com", "0601", "fr")
+	bob, _ := RegisterForTask(app.DB, bar.ID, "Bob", "Martin", "bob@test.com", "0602", "en")
+	RegisterForTask(app.DB, kitchen.ID, "Bob", "Martin", "bob@test.com", "0602", "en")
+	other := &Event{TitleFR: "Brocante", EventDate: "2026-07-01"}
+	CreateEvent(app.DB, other)
+	stranger, _ := RegisterForTask(app.DB, seedTask(t, app.DB, other.ID, "Accueil", nil).ID, "Carol", "Durand", "carol@test.com", "0603", "fr")
+
+	onTask := func(taskID int64) (n int) {
+		app.DB.QueryRow("SELECT COUNT(*) FROM registrations WHERE task_id=? AND trash_id IS NULL", taskID).Scan(&n)
+		return n
+	}
+	bulk := func(action string, extra url.Values, ids ...int64) string {
+		v := url.Values{"event_id": {fmt.Sprint(e.ID)}, "action": {action}, "q": {"a"}}
+		for _, id := range ids {
+			v.Add("ids", fmt.Sprint(id))
+		}
+		for k, vs := range extra {
+			v[k] = vs
+		}
+		w := postForm(mux, "/admin/registrations/bulk?lang=en", v, admin)
+		if action != "export" && w.Code != 303 {
+			t.Fatalf("%s: status %d", action, w.Code)
+		}
+		if action != "export" && !strings.Contains(w.Header().Get("Location"), "q=a") {
+			t.Errorf("%s: filters lost in %q", action, w.Header().Get("Location"))
+		}
+		return w.Body.String()
+	}
+
+	// Registrations of another event are left out of the selection.
+	csv := bulk("export", nil, alice.ID, stranger.ID)
+	if !strings.Contains(csv, "alice@test.com") || strings.Contains(csv, "carol@test.com") {
+		t.Errorf("export = %q", csv)
+	}
+
+	SetSetting(app.DB, adminBaseURLKey, "https://events.example.com")
+	bulk("email", url.Values{"subject": {"Parking"}, "message": {"Park behind the hall."}}, alice.ID, bob.ID, stranger.ID)
+	if fake.count() != 2 || fake.sent[0].Subject != "Parking" || !strings.Contains(fake.sent[1].HTML, "Park behind the hall.") {
+		t.Fatalf("sent %+v", fake.sent)
+	}
+	if html := fake.sent[0].HTML; !strings.Contains(html, "https://events.example.com/unsubscribe?") || !strings.Contains(html, "https://events.example.com/e/") {
+		t.Errorf("message lacks the preferences link or the configured base URL: %s", html)
+	}
+
+	// Addresses that opted out are skipped, and the organizer is told.
+	SetUnsubscribed(app.DB, "bob@test.com", true)
+	w := postForm(mux, "/admin/registrations/bulk?lang=en", url.Values{
+		"event_id": {fmt.Sprint(e.ID)}, "action": {"email"}, "ids": {fmt.Sprint(alice.ID), fmt.Sprint(bob.ID)},
+		"subject": {"Parking"}, "message": {"Park behind the hall."},
+	}, admin)
+	if fake.count() != 3 || fake.sent[2].To != "alice@test.com" {
+		t.Errorf("sent to an unsubscribed address: %+v", fake.sent[2:])
+	}
+	if body := followRedirect(mux, w, admin).Body.String(); !strings.Contains(body, fmt.Sprintf(T("bulk_emailed_skipped", "en"), 1, 1)) {
+		t.Error("the organizer isn't told about the skipped address")
+	}
+
+	// Bob is already in the kitchen: he stays at the bar.
+	bulk("move", url.Values{"task_id": {fmt.Sprint(kitchen.ID)}}, alice.ID, bob.ID)
+	if n := onTask(kitchen.ID); n != 2 {
+		t.Errorf("kitchen has %d registrations, want 2", n)
+	}
+	if n := onTask(bar.ID); n != 1 {
+		t.Errorf("bar has %d registrations, want 1", n)
+	}
+
+	bulk("delete", nil, alice.ID, bob.ID, stranger.ID)
+	if n := CountRegistrations(app.DB, e.ID); n != 1 {
+		t.Errorf("%d registrations left, want 1", n)
+	}
+	if n := CountRegistrations(app.DB, other.ID); n != 1 {
+		t.Error("registration of another event deleted")
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+// Manual registrations. Organizers enter people who signed up on paper or by
+// phone from the registrations page. None of the public form's checks on the
+// person apply — email address optional, no spam or address checks, no
+// "already registered" email — but the task's slots, reserved ones included,
+// its group's and the event's still hold, unless the organizer chooses to
+// go past them.
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// AddManualRegistration signs someone up for task taskID on an organizer's
+// behalf; with override, past every limit of the task and the event. An
+// email address already signed up for the task, or for as many tasks as the
+// event allows, is refused even with override.
+func AddManualRegistration(db *sql.DB, taskID int64, guests int, override bool, firstName, lastName, email, phone, lang string) (*Registration, error) {
+	access := reservedAccess
+	if override {
+		access = organizerAccess
+	}
+	return registerGroupForTask(db, taskID, guests, 0, access, firstName, lastName, email, phone, lang)
+}
+
+// manualErrorKey returns the i18n key telling why AddManualRegistration
+// failed.
+func manualErrorKey(err error) string {
+	var ruleErr *taskRuleError
+	switch {
+	case errors.Is(err, errAlreadyRegistered), errors.Is(err, errTaskLimit):
+		return "manual_already_registered"
+	case errors.Is(err, errTooManyGuests):
+		return "manual_too_many_guests"
+	case errors.Is(err, errGroupFull):
+		return "move_group_full"
+	case errors.Is(err, errEventFull):
+		return "manual_event_full"
+	case errors.As(err, &ruleErr):
+		return "manual_task_rule"
+	case err.Error() == "task_full":
+		return "move_task_full"
+	}
+	return "error_server"
+}
+
+// handleAdminRegistrationAdd adds a registration from the registrations
+// page, sending its confirmation when asked to.
+func (app *App) handleAdminRegistrationAdd(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/admin", http.StatusSeeOther)
+		return
+	}
+	lang := LangFromRequest(r)
+	eventID, _ := strconv.ParseInt(r.FormValue("event_id"), 10, 64)
+	event, err := GetEvent(app.DB, eventID)
+	if err != nil {
+		http.Redirect(w, r, "/admin", http.StatusSeeOther)
+		return
+	}
+	pageURL := fmt.Sprintf("/admin/event/registrations?id=%d&lang=%s", event.ID, lang)
+	fail := func(msg string) {
+		setFlash(w, "error", msg)
+		http.Redirect(w, r, pageURL, http.StatusSeeOther)
+	}
+
+	taskID, _ := strconv.ParseInt(r.FormValue("task_id"), 10, 64)
+	task, err := GetTask(app.DB, taskID)
+	if err != nil || task.EventID != event.ID {
+		fail(T("move_not_found", lang))
+		return
+	}
+	firstName := strings.TrimSpace(r.FormValue("first_name"))
+	lastName := strings.TrimSpace(r.FormValue("last_name"))
+	email := strings.TrimSpace(r.FormValue("email"))
+	phone := strings.TrimSpace(r.FormValue("phone"))
+	comment := strings.TrimSpace(r.FormValue("comment"))
+	guests, _ := strconv.Atoi(r.FormValue("guests"))
+	regLang := r.FormValue("reg_lang")
+	if regLang != "en" {
+		regLang = "fr"
+	}
+	if firstName == "" || lastName == "" || email != "" && !validEmailSyntax(email) {
+		fail(T("error_invalid_form", lang))
+		return
+	}
+	if utf8.RuneCountInString(comment) > maxCommentLen {
+		fail(fmt.Sprintf(T("error_comment_too_long", lang), maxCommentLen))
+		return
+	}
+
+	override := r.FormValue("override") == "1"
+	reg, err := AddManualRegistration(app.DB, task.ID, guests, override, firstName, lastName, email, phone, regLang)
+	if err != nil {
+		key := manualErrorKey(err)
+		if key == "error_server" {
+			log.Printf("manual registration for task %d: %v", task.ID, err)
+			fail(T(key, lang))
+			return
+		}
+		msg := T(key, lang)
+		if !override && key != "manual_already_registered" {
+			msg += " " + T("manual_override_hint", lang)
+		}
+		fail(msg)
+		return
+	}
+	if comment != "" {
+		if err := SetRegistrationComment(app.DB, reg.ID, comment); err != nil {
+			log.Printf("manual registration comment error (registration %d): %v", reg.ID, err)
+		}
+		reg.Comment = comment
+	}
+	if email != "" {
+		if err := SaveVolunteer(app.DB, email, firstName, lastName, phone); err != nil {
+			log.Printf("manual registration volunteer error (event %d): %v", event.ID, err)
+		}
+		if r.FormValue("notify") == "1" {
+			app.dispatchSignupConfirmation(*reg, *task, *event, app.eventBaseURL(r, event))
+		}
+	}
+	setFlash(w, "success", fmt.Sprintf(T("manual_added", lang), firstName+" "+lastName))
+	http.Redirect(w, r, pageURL, http.StatusSeeOther)
+}
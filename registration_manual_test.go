@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestManualRegistration(t *testing.T) {
+	app := testApp(t)
+	mux := newMux(app)
+	admin := adminCookie(app)
+	fake := app.Email.(*fakeEmailSender)
+	e := seedEvent(t, app.DB)
+	one := int64(1)
+	kitchen := seedTask(t, app.DB, e.ID, "Cuisine", &one)
+	app.DB.Exec("UPDATE tasks SET reserved_slots=1 WHERE id=?", kitchen.ID)
+
+	add := func(values url.Values) string {
+		values.Set("event_id", fmt.Sprint(e.ID))
+		values.Set("task_id", fmt.Sprint(kitchen.ID))
+		w := postForm(mux, "/admin/registrations/add?lang=en", values, admin)
+		if w.Code != 303 {
+			t.Fatalf("status %d", w.Code)
+		}
+		return followRedirect(mux, w, admin).Body.String()
+	}
+
+	// The reserved slot is the organizers' to give, no email address needed.
+	body := add(url.Values{"first_name": {"Alice"}, "last_name": {"Dupont"}, "phone": {"0601"}})
+	if !strings.Contains(body, fmt.Sprintf(T("manual_added", "en"), "Alice Dupont")) {
+		t.Fatal("first registration not added")
+	}
+	body = add(url.Values{"first_name": {"Bob"}, "last_name": {"Martin"}, "email": {"bob@test.com"}, "notify": {"1"}})
+	if !strings.Contains(body, template.HTMLEscapeString(T("move_task_full", "en"))) || !strings.Contains(body, T("manual_override_hint", "en")) {
+		t.Error("full task accepted without override")
+	}
+	add(url.Values{"first_name": {"Bob"}, "last_name": {"Martin"}, "email": {"bob@test.com"}, "notify": {"1"}, "override": {"1"}})
+	if n := CountRegistrations(app.DB, e.ID); n != 2 {
+		t.Errorf("%d registrations, want 2", n)
+	}
+	if fake.count() != 1 || fake.sent[0].To != "bob@test.com" {
+		t.Errorf("sent %+v, want Bob's confirmation", fake.sent)
+	}
+	body = add(url.Values{"first_name": {"Bob"}, "last_name": {"Martin"}, "email": {"Bob@test.com"}, "override": {"1"}})
+	if !strings.Contains(body, T("manual_already_registered", "en")) {
+		t.Error("same person added twice to the task")
+	}
+}
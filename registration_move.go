@@ -0,0 +1,179 @@
+package main
+
+// Moving a registration. Organizers reassign someone to another task of the
+// same event — from the registrations page or the JSON API — instead of
+// deleting their registration and asking them to sign up again. The move
+// keeps the registration, its cancel link and its check-in, and holds to
+// the slots of the new task and of its groups; an organizer may use the
+// task's reserved slots. Task rules are left to the organizer's judgment.
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// errMoveOtherEvent is returned by MoveRegistration for a task of another
+// event.
+var errMoveOtherEvent = errors.New("other_event")
+
+// MoveRegistration moves registration id to task taskID, when the task has
+// room for it and its guests, and returns it as moved.
+func MoveRegistration(db *sql.DB, id, taskID int64) (*Registration, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	reg := &Registration{}
+	var eventID int64
+	var member int
+	err = tx.QueryRow(`SELECT id, task_id, event_id, first_name, last_name, email, phone, lang, token, guests, member, created_at
+		FROM registrations WHERE id=? AND trash_id IS NULL`, id,
+	).Scan(&reg.ID, &reg.TaskID, &eventID, &reg.FirstName, &reg.LastName, &reg.Email, &reg.Phone, &reg.Lang, &reg.Token, &reg.Guests, &member, &reg.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("registration not found: %w", err)
+	}
+	var taskEventID int64
+	var groupID, maxSlots sql.NullInt64
+	err = tx.QueryRow("SELECT event_id, group_id, max_slots FROM tasks WHERE id=? AND trash_id IS NULL", taskID).Scan(&taskEventID, &groupID, &maxSlots)
+	if err != nil {
+		return nil, fmt.Errorf("task not found: %w", err)
+	}
+	if taskEventID != eventID {
+		return nil, errMoveOtherEvent
+	}
+	if reg.TaskID == taskID {
+		return reg, nil
+	}
+	if reg.Email != "" {
+		var n int
+		tx.QueryRow("SELECT COUNT(*) FROM registrations WHERE task_id=? AND LOWER(email)=LOWER(?) AND member=? AND trash_id IS NULL",
+			taskID, reg.Email, member).Scan(&n)
+		if n > 0 {
+			return nil, errAlreadyRegistered
+		}
+	}
+	if maxSlots.Valid {
+		var count int
+		tx.QueryRow("SELECT "+countPeople+" FROM registrations r WHERE r.task_id=? AND r.trash_id IS NULL", taskID).Scan(&count)
+		if count+1+reg.Guests > int(maxSlots.Int64) {
+			return nil, fmt.Errorf("task_full")
+		}
+	}
+	// Moved first: groups that already counted it then count it once, not
+	// twice.
+	if _, err := tx.Exec("UPDATE registrations SET task_id=? WHERE id=?", taskID, reg.ID); err != nil {
+		return nil, err
+	}
+	if err := checkGroupCapacity(tx, groupID, 0); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	reg.TaskID = taskID
+	return reg, nil
+}
+
+// moveErrorKey returns the i18n key telling why MoveRegistration failed,
+// and its code for the JSON API.
+func moveErrorKey(err error) (key, code string) {
+	switch {
+	case errors.Is(err, errAlreadyRegistered):
+		return "move_already_on_task", "already_registered"
+	case errors.Is(err, errGroupFull):
+		return "move_group_full", "group_full"
+	case err.Error() == "task_full":
+		return "move_task_full", "task_full"
+	case errors.Is(err, errMoveOtherEvent), errors.Is(err, sql.ErrNoRows):
+		return "move_not_found", "not_found"
+	}
+	return "error_server", "server_error"
+}
+
+// notifyMove sends reg, just moved, the signup confirmation of its new task.
+func (app *App) notifyMove(r *http.Request, reg *Registration) {
+	task, err := GetTask(app.DB, reg.TaskID)
+	if err != nil {
+		return
+	}
+	event, err := GetEvent(app.DB, task.EventID)
+	if err != nil {
+		return
+	}
+	app.dispatchSignupConfirmation(*reg, *task, *event, app.eventBaseURL(r, event))
+}
+
+// handleAdminRegistrationMove moves a registration from the registrations
+// page, telling its person when asked to.
+func (app *App) handleAdminRegistrationMove(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/admin", http.StatusSeeOther)
+		return
+	}
+	lang := LangFromRequest(r)
+	eventID, _ := strconv.ParseInt(r.FormValue("event_id"), 10, 64)
+	id, _ := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	taskID, _ := strconv.ParseInt(r.FormValue("task_id"), 10, 64)
+	pageURL := fmt.Sprintf("/admin/event/registrations?id=%d&lang=%s", eventID, lang)
+
+	reg, err := MoveRegistration(app.DB, id, taskID)
+	if err != nil {
+		key, _ := moveErrorKey(err)
+		if key == "error_server" {
+			log.Printf("move registration %d to task %d: %v", id, taskID, err)
+		}
+		setFlash(w, "error", T(key, lang))
+		http.Redirect(w, r, pageURL, http.StatusSeeOther)
+		return
+	}
+	if r.FormValue("notify") == "1" {
+		app.notifyMove(r, reg)
+	}
+	setFlash(w, "success", fmt.Sprintf(T("move_done", lang), reg.FirstName+" "+reg.LastName))
+	http.Redirect(w, r, pageURL, http.StatusSeeOther)
+}
+
+// handleAPIRegistrationMove is MoveRegistration over JSON:
+// {"registration_id", "task_id", "notify"}.
+func (app *App) handleAPIRegistrationMove(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", 405)
+		return
+	}
+	var req struct {
+		RegistrationID int64 `json:"registration_id"`
+		TaskID         int64 `json:"task_id"`
+		Notify         bool  `json:"notify"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"bad request"}`, 400)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	reg, err := MoveRegistration(app.DB, req.RegistrationID, req.TaskID)
+	if err != nil {
+		_, code := moveErrorKey(err)
+		status := http.StatusConflict
+		switch code {
+		case "not_found":
+			status = http.StatusNotFound
+		case "server_error":
+			log.Printf("api: move registration %d to task %d: %v", req.RegistrationID, req.TaskID, err)
+			status = http.StatusInternalServerError
+		}
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]string{"error": code})
+		return
+	}
+	if req.Notify {
+		app.notifyMove(r, reg)
+	}
+	json.NewEncoder(w).Encode(map[string]any{"ok": true, "registration_id": reg.ID, "task_id": reg.TaskID})
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestMoveRegistration(t *testing.T) {
+	app := testApp(t)
+	e := &Event{TitleFR: "Fête", EventDate: "2026-06-15", MaxTasksPerPerson: 2}
+	CreateEvent(app.DB, e)
+	one := int64(1)
+	bar := seedTask(t, app.DB, e.ID, "Buvette", nil)
+	kitchen := seedTask(t, app.DB, e.ID, "Cuisine", &one)
+	hall := &TaskGroup{EventID: e.ID, TitleFR: "Salle", MaxSlots: sql.NullInt64{Int64: 1, Valid: true}}
+	CreateTaskGroup(app.DB, hall)
+	chairs := &Task{EventID: e.ID, TitleFR: "Chaises", GroupID: sql.NullInt64{Int64: hall.ID, Valid: true}}
+	CreateTask(app.DB, chairs)
+	tables := &Task{EventID: e.ID, TitleFR: "Tables", GroupID: sql.NullInt64{Int64: hall.ID, Valid: true}}
+	CreateTask(app.DB, tables)
+
+	alice, _ := RegisterForTask(app.DB, bar.ID, "Alice", "Dupont", "alice@test.com", "0601", "fr")
+	bob, _ := RegisterForTask(app.DB, bar.ID, "Bob", "Martin", "bob@test.com", "0602", "fr")
+	RegisterForTask(app.DB, chairs.ID, "Bob", "Martin", "bob@test.com", "0602", "fr")
+
+	if _, err := MoveRegistration(app.DB, alice.ID, kitchen.ID); err != nil {
+		t.Fatalf("move to kitchen: %v", err)
+	}
+	if reg, _ := GetRegistrationByToken(app.DB, alice.Token); reg.TaskID != kitchen.ID {
+		t.Errorf("alice on task %d, want kitchen", reg.TaskID)
+	}
+	for _, c := range []struct {
+		taskID int64
+		key    string
+	}{
+		{kitchen.ID, "move_task_full"},
+		{tables.ID, "move_group_full"},
+		{chairs.ID, "move_already_on_task"},
+	} {
+		_, err := MoveRegistration(app.DB, bob.ID, c.taskID)
+		if key, _ := moveErrorKey(err); err == nil || key != c.key {
+			t.Errorf("move to task %d: %v, want %s", c.taskID, err, c.key)
+		}
+	}
+	if reg, _ := GetRegistrationByToken(app.DB, bob.Token); reg.TaskID != bar.ID {
+		t.Errorf("refused move kept: bob on task %d", reg.TaskID)
+	}
+	other := &Event{TitleFR: "Brocante", EventDate: "2026-07-01"}
+	CreateEvent(app.DB, other)
+	if _, err := MoveRegistration(app.DB, bob.ID, seedTask(t, app.DB, other.ID, "Accueil", nil).ID); err != errMoveOtherEvent {
+		t.Errorf("move to another event: %v", err)
+	}
+}
+
+func TestMoveRegistrationHandlers(t *testing.T) {
+	app := testApp(t)
+	mux := newMux(app)
+	admin := adminCookie(app)
+	fake := app.Email.(*fakeEmailSender)
+	e := seedEvent(t, app.DB)
+	one := int64(1)
+	bar := seedTask(t, app.DB, e.ID, "Buvette", nil)
+	kitchen := seedTask(t, app.DB, e.ID, "Cuisine", &one)
+	alice, _ := RegisterForTask(app.DB, bar.ID, "Alice", "Dupont", "alice@test.com", "0601", "fr")
+	bob, _ := RegisterForTask(app.DB, bar.ID, "Bob", "Martin", "bob@test.com", "0602", "en")
+
+	w := postForm(mux, "/admin/registrations/move?lang=en", url.Values{
+		"id": {fmt.Sprint(alice.ID)}, "event_id": {fmt.Sprint(e.ID)}, "task_id": {fmt.Sprint(kitchen.ID)}, "notify": {"1"},
+	}, admin)
+	if w.Code != 303 || fake.count() != 1 || fake.sent[0].To != "alice@test.com" || !strings.Contains(fake.sent[0].HTML, "Cuisine") {
+		t.Fatalf("move: status %d, sent %+v", w.Code, fake.sent)
+	}
+
+	move := func(regID, taskID int64) *httptest.ResponseRecorder {
+		body := fmt.Sprintf(`{"registration_id":%d,"task_id":%d}`, regID, taskID)
+		req := httptest.NewRequest(http.MethodPost, "/admin/api/registration/move", bytes.NewReader([]byte(body)))
+		req.Header.Set("Content-Type", "application/json")
+		req.AddCookie(admin)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+	if w := move(bob.ID, kitchen.ID); w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), `"task_full"`) {
+		t.Errorf("full task: status %d %s", w.Code, w.Body.String())
+	}
+	if w := move(alice.ID, bar.ID); w.Code != http.StatusOK {
+		t.Errorf("move back: status %d %s", w.Code, w.Body.String())
+	}
+	if w := move(bob.ID, kitchen.ID); w.Code != http.StatusOK || fake.count() != 1 {
+		t.Errorf("freed task: status %d %s, %d emails", w.Code, w.Body.String(), fake.count())
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+// Registrations page search. Events with hundreds of signups don't fit in one
+// table: the registrations page filters them on the server — by text found
+// in names, email addresses and phone numbers, by top-level group and by
+// task — and shows them a page at a time. The CSV export still has them all.
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// registrationsPerPage is how many registrations the page shows at once.
+const registrationsPerPage = 100
+
+// RegistrationFilter narrows down the registrations of an event.
+type RegistrationFilter struct {
+	Query   string // part of a name, email address or phone number
+	GroupID int64  // top-level group; 0 for any
+	TaskID  int64  // 0 for any
+}
+
+func parseRegistrationFilter(r *http.Request) RegistrationFilter {
+	q := r.URL.Query()
+	f := RegistrationFilter{Query: strings.TrimSpace(q.Get("q"))}
+	f.GroupID, _ = strconv.ParseInt(q.Get("group"), 10, 64)
+	f.TaskID, _ = strconv.ParseInt(q.Get("task"), 10, 64)
+	return f
+}
+
+// IsZero tells whether f keeps every registration.
+func (f RegistrationFilter) IsZero() bool { return f == RegistrationFilter{} }
+
+// values returns f as query parameters of the registrations page.
+func (f RegistrationFilter) values() url.Values {
+	v := url.Values{}
+	if f.Query != "" {
+		v.Set("q", f.Query)
+	}
+	if f.GroupID != 0 {
+		v.Set("group", strconv.FormatInt(f.GroupID, 10))
+	}
+	if f.TaskID != 0 {
+		v.Set("task", strconv.FormatInt(f.TaskID, 10))
+	}
+	return v
+}
+
+// likeContains returns a LIKE pattern, escaped with '\', matching s
+// anywhere.
+func likeContains(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+	return "%" + s + "%"
+}
+
+// conditions returns the SQL conditions of f, to follow
+// registrationExportSQL, with their arguments.
+func (f RegistrationFilter) conditions() (string, []any) {
+	var sb strings.Builder
+	var args []any
+	if f.Query != "" {
+		text := likeContains(f.Query)
+		phone := likeContains(strings.Join(strings.Fields(f.Query), ""))
+		sb.WriteString(` AND (r.first_name || ' ' || r.last_name LIKE ? ESCAPE '\'
+			OR r.last_name || ' ' || r.first_name LIKE ? ESCAPE '\'
+			OR r.email LIKE ? ESCAPE '\' OR REPLACE(r.phone, ' ', '') LIKE ? ESCAPE '\')`)
+		args = append(args, text, text, text, phone)
+	}
+	if f.GroupID != 0 {
+		sb.WriteString(" AND rg.root_id = ?")
+		args = append(args, f.GroupID)
+	}
+	if f.TaskID != 0 {
+		sb.WriteString(" AND t.id = ?")
+		args = append(args, f.TaskID)
+	}
+	return sb.String(), args
+}
+
+// SearchRegistrations returns page page (from 1) of the registrations of
+// event eventID that f keeps, and how many it keeps in all.
+func SearchRegistrations(db *sql.DB, eventID int64, f RegistrationFilter, page int) (regs []RegistrationExport, total int, err error) {
+	cond, args := f.conditions()
+	args = append([]any{eventID}, args...)
+	if err := db.QueryRow("SELECT COUNT(*) FROM ("+registrationExportSQL+cond+")", args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	offset := (max(page, 1) - 1) * registrationsPerPage
+	rows, err := db.Query(registrationExportSQL+cond+registrationExportOrder+" LIMIT ? OFFSET ?",
+		append(args, registrationsPerPage, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	regs, err = scanRegistrationExports(rows)
+	return regs, total, err
+}
+
+// Pagination is where a page stands among the results, with links to its
+// neighbours ("" at either end).
+type Pagination struct {
+	Page, Pages     int
+	From, To, Total int // results shown, counted from 1, and in all
+	PrevURL         string
+	NextURL         string
+}
+
+// newPagination returns the pagination of page page out of total results,
+// perPage a page, linking to baseURL with the page number added to query.
+func newPagination(baseURL string, query url.Values, page, total, perPage int) Pagination {
+	p := Pagination{Page: page, Pages: max(1, (total+perPage-1)/perPage), Total: total}
+	if total > 0 {
+		p.From = (page-1)*perPage + 1
+		p.To = min(page*perPage, total)
+	}
+	link := func(n int) string {
+		q := url.Values{}
+		for k, v := range query {
+			q[k] = v
+		}
+		q.Set("page", strconv.Itoa(n))
+		return fmt.Sprintf("%s?%s", baseURL, q.Encode())
+	}
+	if page > 1 {
+		p.PrevURL = link(page - 1)
+	}
+	if page < p.Pages {
+		p.NextURL = link(page + 1)
+	}
+	return p
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestSearchRegistrations(t *testing.T) {
+	app := testApp(t)
+	e := seedEvent(t, app.DB)
+	kitchen := &TaskGroup{EventID: e.ID, TitleFR: "Cuisine"}
+	CreateTaskGroup(app.DB, kitchen)
+	sub := &TaskGroup{EventID: e.ID, TitleFR: "Plonge", ParentGroupID: sql.NullInt64{Int64: kitchen.ID, Valid: true}}
+	CreateTaskGroup(app.DB, sub)
+	dishes := &Task{EventID: e.ID, TitleFR: "Vaisselle", GroupID: sql.NullInt64{Int64: sub.ID, Valid: true}}
+	CreateTask(app.DB, dishes)
+	bar := seedTask(t, app.DB, e.ID, "Buvette", nil)
+
+	RegisterForTask(app.DB, dishes.ID, "Alice", "Dupont", "alice@test.com", "06 01 02 03 04", "fr")
+	RegisterForTask(app.DB, bar.ID, "Bob", "Martin", "bob_m@test.com", "0605", "fr")
+	RegisterForTask(app.DB, bar.ID, "Carol", "Bobet", "carol@test.com", "0606", "fr")
+
+	for _, c := range []struct {
+		f    RegistrationFilter
+		want int
+	}{
+		{RegistrationFilter{}, 3},
+		{RegistrationFilter{Query: "dupont alice"}, 1},
+		{RegistrationFilter{Query: "bob"}, 2},
+		{RegistrationFilter{Query: "b_m"}, 1},
+		{RegistrationFilter{Query: "%"}, 0},
+		{RegistrationFilter{Query: "0102"}, 1},
+		{RegistrationFilter{GroupID: kitchen.ID}, 1},
+		{RegistrationFilter{TaskID: bar.ID, Query: "carol"}, 1},
+	} {
+		regs, total, err := SearchRegistrations(app.DB, e.ID, c.f, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if total != c.want || len(regs) != c.want {
+			t.Errorf("%+v: %d registrations (total %d), want %d", c.f, len(regs), total, c.want)
+		}
+	}
+}
+
+func TestRegistrationsPagination(t *testing.T) {
+	app := testApp(t)
+	mux := newMux(app)
+	e := seedEvent(t, app.DB)
+	bar := seedTask(t, app.DB, e.ID, "Buvette", nil)
+	for i := range registrationsPerPage + 5 {
+		RegisterForTask(app.DB, bar.ID, "P", fmt.Sprintf("Person%03d", i), fmt.Sprintf("p%d@test.com", i), "0601", "fr")
+	}
+
+	page := func(query string) string {
+		return getRequest(mux, fmt.Sprintf("/admin/event/registrations?id=%d&lang=en&%s", e.ID, query), adminCookie(app)).Body.String()
+	}
+	first := page("")
+	if !strings.Contains(first, "Person000") || strings.Contains(first, fmt.Sprintf("Person%03d", registrationsPerPage)) {
+		t.Error("first page shows the wrong registrations")
+	}
+	next := url.Values{"id": {fmt.Sprint(e.ID)}, "lang": {"en"}, "page": {"2"}}.Encode()
+	if !strings.Contains(first, strings.ReplaceAll(next, "&", "&amp;")) {
+		t.Error("first page has no link to the next one")
+	}
+	if second := page("page=2"); !strings.Contains(second, fmt.Sprintf("Person%03d", registrationsPerPage+4)) || strings.Contains(second, "Person000") {
+		t.Error("second page shows the wrong registrations")
+	}
+	if body := page("q=nobody"); !strings.Contains(body, T("registration_no_match", "en")) {
+		t.Error("search without results not shown as such")
+	}
+}
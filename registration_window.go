@@ -0,0 +1,88 @@
+package main
+
+// Registration window. An event can open its signups at a set time, close
+// them at another, or both: outside the window the public page shows when
+// registration opens, with a countdown, or that it is closed, and /signup
+// turns posts away. Both bounds are local times in the event's time zone
+// (UTC when it has none), as typed in a datetime-local input.
+
+import (
+	"fmt"
+	"time"
+)
+
+// registrationTimeLayout is the layout of the window bounds, that of a
+// datetime-local input.
+const registrationTimeLayout = "2006-01-02T15:04"
+
+// Where the present stands relative to an event's registration window.
+const (
+	registrationNotYetOpen = "not_yet_open"
+	registrationOpen       = "open"
+	registrationClosed     = "closed"
+	// registrationFull: open, but the event reached its MaxParticipants
+	// (see event_capacity.go).
+	registrationFull = "full"
+)
+
+// normalizeRegistrationWindow checks the window bounds of e: each must be
+// empty or a datetime-local value, and the window must close after it
+// opens.
+func normalizeRegistrationWindow(e *Event) error {
+	for _, s := range []string{e.RegistrationOpensAt, e.RegistrationClosesAt} {
+		if _, err := time.Parse(registrationTimeLayout, s); s != "" && err != nil {
+			return fmt.Errorf("invalid registration time %q", s)
+		}
+	}
+	// The layout sorts like the times it spells.
+	if e.RegistrationOpensAt != "" && e.RegistrationClosesAt != "" && e.RegistrationClosesAt <= e.RegistrationOpensAt {
+		return fmt.Errorf("registration closes at %s, not after it opens at %s", e.RegistrationClosesAt, e.RegistrationOpensAt)
+	}
+	return nil
+}
+
+func (e Event) registrationTime(s string) (time.Time, bool) {
+	if s == "" {
+		return time.Time{}, false
+	}
+	loc := e.Location()
+	if loc == nil {
+		loc = time.UTC
+	}
+	t, err := time.ParseInLocation(registrationTimeLayout, s, loc)
+	return t, err == nil
+}
+
+// RegistrationOpens returns when signups open; ok is false when they are
+// open from the start.
+func (e Event) RegistrationOpens() (t time.Time, ok bool) {
+	return e.registrationTime(e.RegistrationOpensAt)
+}
+
+// RegistrationCloses returns when signups close; ok is false when they
+// never do.
+func (e Event) RegistrationCloses() (t time.Time, ok bool) {
+	return e.registrationTime(e.RegistrationClosesAt)
+}
+
+// RegistrationState tells whether signups are open at now, not open yet or
+// closed.
+func (e Event) RegistrationState(now time.Time) string {
+	if opens, ok := e.RegistrationOpens(); ok && now.Before(opens) {
+		return registrationNotYetOpen
+	}
+	if closes, ok := e.RegistrationCloses(); ok && !now.Before(closes) {
+		return registrationClosed
+	}
+	return registrationOpen
+}
+
+// shiftRegistrationTime moves a window bound by days, for a copy of the
+// event on another date.
+func shiftRegistrationTime(s string, days int) string {
+	t, err := time.Parse(registrationTimeLayout, s)
+	if err != nil {
+		return ""
+	}
+	return t.AddDate(0, 0, days).Format(registrationTimeLayout)
+}
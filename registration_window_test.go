@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRegistrationState(t *testing.T) {
+	e := Event{EventDate: "2026-06-14", Timezone: "Europe/Paris"}
+	now := time.Date(2026, 5, 1, 8, 0, 0, 0, time.UTC) // 10:00 in Paris
+	if got := e.RegistrationState(now); got != registrationOpen {
+		t.Errorf("no window: state = %s", got)
+	}
+	e.RegistrationOpensAt = "2026-05-01T10:00"
+	if got := e.RegistrationState(now.Add(-time.Second)); got != registrationNotYetOpen {
+		t.Errorf("before opening: state = %s", got)
+	}
+	if got := e.RegistrationState(now); got != registrationOpen {
+		t.Errorf("at opening: state = %s", got)
+	}
+	e.RegistrationClosesAt = "2026-06-01T00:00"
+	if got := e.RegistrationState(time.Date(2026, 5, 31, 22, 0, 0, 0, time.UTC)); got != registrationClosed {
+		t.Errorf("at closing: state = %s", got)
+	}
+
+	for _, c := range []struct {
+		opens, closes string
+		valid         bool
+	}{
+		{"", "", true},
+		{"2026-05-01T10:00", "", true},
+		{"", "2026-05-01T10:00", true},
+		{"2026-05-01T10:00", "2026-05-01T10:00", false},
+		{"2026-05-01 10:00", "", false},
+	} {
+		e := Event{RegistrationOpensAt: c.opens, RegistrationClosesAt: c.closes}
+		if err := normalizeRegistrationWindow(&e); (err == nil) != c.valid {
+			t.Errorf("window %q–%q: error = %v", c.opens, c.closes, err)
+		}
+	}
+
+	// Copies on another date keep the window as far from the event.
+	src := Event{EventDate: "2026-06-14", RegistrationOpensAt: "2026-05-01T10:00"}
+	dst := Event{EventDate: "2026-07-12"}
+	copySeriesFields(&dst, src)
+	if dst.RegistrationOpensAt != "2026-05-29T10:00" || dst.RegistrationClosesAt != "" {
+		t.Errorf("copied window = %q–%q", dst.RegistrationOpensAt, dst.RegistrationClosesAt)
+	}
+}
+
+func TestSignupOutsideWindow(t *testing.T) {
+	app := testApp(t)
+	mux := newMux(app)
+	e := seedEvent(t, app.DB)
+	tk := seedTask(t, app.DB, e.ID, "Cuisine", nil)
+	signup := func() string {
+		return postForm(mux, "/signup?lang=en", url.Values{
+			"task_id": {fmt.Sprint(tk.ID)}, "first_name": {"Alice"}, "last_name": {"Dupont"},
+			"email": {"alice@test.com"}, "phone": {"0601"},
+		}).Body.String()
+	}
+
+	e.RegistrationOpensAt = time.Now().UTC().Add(time.Hour).Format(registrationTimeLayout)
+	UpdateEvent(app.DB, e)
+	if !strings.Contains(getRequest(mux, "/e/"+e.Slug+"?lang=en").Body.String(), `id="registration-countdown"`) {
+		t.Error("page before opening lacks the countdown")
+	}
+	if body := signup(); !strings.Contains(body, T("registration_not_open", LangEN)) {
+		t.Error("signup before opening accepted")
+	}
+
+	e.RegistrationOpensAt = ""
+	e.RegistrationClosesAt = time.Now().UTC().Add(-time.Hour).Format(registrationTimeLayout)
+	UpdateEvent(app.DB, e)
+	if body := signup(); !strings.Contains(body, T("registration_closed", LangEN)) {
+		t.Error("signup after closing accepted")
+	}
+	if CountRegistrations(app.DB, e.ID) != 0 {
+		t.Fatal("registration recorded outside the window")
+	}
+
+	e.RegistrationClosesAt = time.Now().UTC().Add(time.Hour).Format(registrationTimeLayout)
+	UpdateEvent(app.DB, e)
+	signup()
+	if CountRegistrations(app.DB, e.ID) != 1 {
+		t.Error("signup inside the window refused")
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+// Continuous replication. When EVENT_SIGNUP_REPLICA_S3_BUCKET is set, the
+// database is copied to the bucket within seconds of every change, and on
+// boot a missing database file is restored from that copy before anything
+// opens it — so a server that loses its disk, or a fresh container, comes
+// back with the latest data. Unlike Litestream, which ships WAL pages, each
+// copy is a whole VACUUM INTO snapshot, cheap at this app's database sizes,
+// overwriting a single object; the scheduled backups keep the history.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	replicaName            = "event-signup-replica.db"
+	defaultReplicaInterval = 10 * time.Second
+)
+
+// ReplicaStore is where the replica is kept.
+type ReplicaStore interface {
+	// Put stores the file at path under name.
+	Put(ctx context.Context, name, path string) error
+	// Get copies what is stored under name to path, failing with an error
+	// wrapping os.ErrNotExist when there is nothing.
+	Get(ctx context.Context, name, path string) error
+	String() string
+}
+
+// Replica is the replication configuration.
+type Replica struct {
+	Store    ReplicaStore
+	Interval time.Duration // how often the database is checked for changes
+}
+
+// restoreReplica fetches the replica into dbPath when there is no database
+// there yet, and reports whether it did. No replica, as on the very first
+// boot, is not an error.
+func restoreReplica(ctx context.Context, store ReplicaStore, dbPath string) (bool, error) {
+	if _, err := os.Stat(dbPath); err == nil || !os.IsNotExist(err) {
+		return false, err
+	}
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0o700); err != nil {
+		return false, err
+	}
+	tmp := filepath.Join(filepath.Dir(dbPath), "."+filepath.Base(dbPath)+".replica")
+	os.Remove(tmp)
+	err := store.Get(ctx, replicaName, tmp)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if _, err := checkBackupFile(tmp); err != nil {
+		os.Remove(tmp)
+		return false, fmt.Errorf("replica unusable: %w", err)
+	}
+	// A write-ahead log left behind belongs to the lost database.
+	os.Remove(dbPath + "-wal")
+	os.Remove(dbPath + "-shm")
+	return true, os.Rename(tmp, dbPath)
+}
+
+// replicate uploads a snapshot of the database as the replica.
+func (app *App) replicate(ctx context.Context) error {
+	dir, err := os.MkdirTemp("", "event-signup-replica-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, replicaName)
+	if err := app.snapshotDB(ctx, path); err != nil {
+		return err
+	}
+	return app.Replica.Store.Put(ctx, replicaName, path)
+}
+
+// runReplicaWorker replicates the database whenever it has changed until ctx
+// is done. Changes are noticed through PRAGMA data_version, which moves when
+// another connection commits, so the worker keeps a connection of its own.
+func (app *App) runReplicaWorker(ctx context.Context) {
+	conn, err := app.DB.Conn(ctx)
+	if err != nil {
+		log.Printf("replica: %v", err)
+		return
+	}
+	defer conn.Close()
+	ticker := time.NewTicker(app.Replica.Interval)
+	defer ticker.Stop()
+	replicated := int64(-1)
+	failing := false
+	for {
+		var version int64
+		if err := conn.QueryRowContext(ctx, "PRAGMA data_version").Scan(&version); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("replica: data version: %v", err)
+		} else if version != replicated {
+			if err := app.replicate(ctx); err != nil {
+				// Logged once per outage; the next tick retries.
+				if !failing && ctx.Err() == nil {
+					log.Printf("replica: upload to %s failed: %v", app.Replica.Store, err)
+				}
+				failing = true
+			} else {
+				if failing {
+					log.Printf("replica: upload to %s working again", app.Replica.Store)
+				}
+				failing = false
+				replicated = version
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReplication(t *testing.T) {
+	dir := t.TempDir()
+	store := DirStore{Dir: filepath.Join(dir, "replica")}
+	dbPath := filepath.Join(dir, "events.db")
+
+	// First boot: nothing to restore.
+	if restored, err := restoreReplica(context.Background(), store, dbPath); restored || err != nil {
+		t.Fatalf("restore without a replica: %v, %v", restored, err)
+	}
+	db, err := InitDB(dbPath)
+	if err != nil {
+		t.Fatalf("InitDB: %v", err)
+	}
+	defer db.Close()
+	app := &App{DB: db, Replica: &Replica{Store: store, Interval: 10 * time.Millisecond}}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		app.runReplicaWorker(ctx)
+		close(done)
+	}()
+	seedEvent(t, db)
+	seedEvent(t, db)
+
+	replicaPath := filepath.Join(store.Dir, replicaName)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if s, err := checkBackupFile(replicaPath); err == nil && s.Events == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("replica never caught up with the database")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	// An existing database is left alone.
+	if restored, err := restoreReplica(context.Background(), store, dbPath); restored || err != nil {
+		t.Errorf("restore over an existing database: %v, %v", restored, err)
+	}
+
+	// A lost disk comes back from the replica.
+	lost := filepath.Join(dir, "new", "events.db")
+	if restored, err := restoreReplica(context.Background(), store, lost); !restored || err != nil {
+		t.Fatalf("restore: %v, %v", restored, err)
+	}
+	restoredDB, err := InitDB(lost)
+	if err != nil {
+		t.Fatalf("open restored database: %v", err)
+	}
+	defer restoredDB.Close()
+	if events, _ := ListEvents(restoredDB); len(events) != 2 {
+		t.Errorf("restored %d events, want 2", len(events))
+	}
+}
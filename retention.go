@@ -0,0 +1,185 @@
+package main
+
+// Data retention. Registrants' personal data — names, email addresses, phone
+// numbers, answers to signup fields, attendance messages and Secret Santa
+// wishes — is erased a number of months after the event's last day: the
+// retention_months setting for the whole instance, which an event can
+// override. The rows themselves stay, so signup counts, attendance tallies
+// and statistics still add up.
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// maxRetentionMonths bounds the retention periods.
+	maxRetentionMonths     = 120
+	retentionCheckInterval = time.Hour
+)
+
+// parseRetentionMonths reads a retention period typed in the admin; empty
+// means none.
+func parseRetentionMonths(raw string) (sql.NullInt64, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return sql.NullInt64{}, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 0 || n > maxRetentionMonths {
+		return sql.NullInt64{}, fmt.Errorf("invalid retention months %q", raw)
+	}
+	return sql.NullInt64{Int64: int64(n), Valid: true}, nil
+}
+
+// instanceRetentionMonths returns the retention_months setting; 0 means
+// personal data is kept.
+func instanceRetentionMonths(db *sql.DB) int {
+	n, _ := strconv.Atoi(GetSetting(db, "retention_months"))
+	return n
+}
+
+// RetentionDeadline returns when the personal data of the event's registrants
+// is due for erasure: midnight at the end of its last day plus the retention
+// period, in its time zone (UTC when it has none). instanceMonths applies
+// when the event has no period of its own. ok is false when the data is
+// kept.
+func (e Event) RetentionDeadline(instanceMonths int) (deadline time.Time, ok bool) {
+	months := instanceMonths
+	if e.RetentionMonths.Valid {
+		months = int(e.RetentionMonths.Int64)
+	}
+	if months <= 0 {
+		return time.Time{}, false
+	}
+	loc := e.Location()
+	if loc == nil {
+		loc = time.UTC
+	}
+	day, err := time.ParseInLocation("2006-01-02", e.EndDay(), loc)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return day.AddDate(0, months, 1), true
+}
+
+// AnonymizeEvent erases the personal data of event id's registrants,
+// attendees and Secret Santa participants, trashed ones included, and marks
+// the event as anonymized. The outbox emails about the event or sent to
+// them, the emails its invites were used by and its opening notices go, as
+// do volunteer profiles left without a registration or RSVP.
+func AnonymizeEvent(db *sql.DB, id int64) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, q := range []string{
+		// Before the addresses are blanked: delivered emails tied to no
+		// event, sent to a registrant, are found by address.
+		`DELETE FROM outbox WHERE event_id = ?1 OR event_id = 0 AND status != 'pending' AND to_email COLLATE NOCASE IN (
+			SELECT r.email FROM registrations r JOIN tasks t ON r.task_id = t.id WHERE t.event_id = ?1
+			UNION SELECT email FROM attendances WHERE event_id = ?1
+			UNION SELECT email FROM santa_participants WHERE event_id = ?1)`,
+		"UPDATE event_invites SET used_by='' WHERE event_id = ?",
+		"DELETE FROM opening_notices WHERE event_id = ?",
+		`DELETE FROM signup_reviews WHERE registration_id IN
+			(SELECT r.id FROM registrations r JOIN tasks t ON r.task_id = t.id WHERE t.event_id = ?)`,
+		"DELETE FROM registration_answers WHERE field_id IN (SELECT id FROM form_fields WHERE event_id = ?)",
+		`UPDATE registrations SET first_name='', last_name='', email='', phone='', comment='', diet='', allergies='', show_name=0
+			WHERE task_id IN (SELECT id FROM tasks WHERE event_id = ?)`,
+		"UPDATE attendances SET first_name='', last_name='', email='', phone='', message='' WHERE event_id = ?",
+		"DELETE FROM task_preferences WHERE event_id = ?",
+		"UPDATE email_messages SET to_email='' WHERE participant_id IN (SELECT id FROM santa_participants WHERE event_id = ?)",
+		"UPDATE santa_participants SET first_name='', last_name='', email='', wish_buy='', wish_make='', wish_free='' WHERE event_id = ?",
+		"UPDATE events SET anonymized_at=CURRENT_TIMESTAMP WHERE id = ?",
+	} {
+		if _, err := tx.Exec(q, id); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(pruneVolunteersSQL); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// PurgeExpiredPersonalData anonymizes every event whose retention period
+// has ended by now and returns how many it did.
+func PurgeExpiredPersonalData(db *sql.DB, now time.Time) (int, error) {
+	instanceMonths := instanceRetentionMonths(db)
+	rows, err := db.Query("SELECT " + eventCols + " FROM events WHERE anonymized_at IS NULL")
+	if err != nil {
+		return 0, err
+	}
+	var due []int64
+	for rows.Next() {
+		e, err := scanEvent(rows)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if deadline, ok := e.RetentionDeadline(instanceMonths); ok && !now.Before(deadline) {
+			due = append(due, e.ID)
+		}
+	}
+	rows.Close()
+	for i, id := range due {
+		if err := AnonymizeEvent(db, id); err != nil {
+			return i, fmt.Errorf("anonymize event %d: %w", id, err)
+		}
+	}
+	return len(due), nil
+}
+
+// runRetentionWorker erases expired personal data until ctx is done.
+func (app *App) runRetentionWorker(ctx context.Context) {
+	ticker := time.NewTicker(retentionCheckInterval)
+	defer ticker.Stop()
+	for {
+		n, err := PurgeExpiredPersonalData(app.DB, time.Now())
+		if err != nil {
+			log.Printf("retention: %v", err)
+		}
+		if n > 0 {
+			log.Printf("retention: personal data erased for %d event(s)", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (app *App) handleAdminSettingsRetention(w http.ResponseWriter, r *http.Request) {
+	lang := LangFromRequest(r)
+	settingsURL := "/admin/settings?lang=" + lang + "#retention"
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, settingsURL, http.StatusSeeOther)
+		return
+	}
+	months, err := parseRetentionMonths(r.FormValue("retention_months"))
+	if err != nil {
+		setFlash(w, "error", T("retention_invalid", lang))
+		http.Redirect(w, r, settingsURL, http.StatusSeeOther)
+		return
+	}
+	value := ""
+	if months.Valid && months.Int64 > 0 {
+		value = strconv.FormatInt(months.Int64, 10)
+	}
+	if err := SetSetting(app.DB, "retention_months", value); err != nil {
+		log.Printf("settings: retention: %v", err)
+		setFlash(w, "error", T("error_server", lang))
+	} else {
+		setFlash(w, "success", T("settings_saved", lang))
+	}
+	http.Redirect(w, r, settingsURL, http.StatusSeeOther)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"html/template"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRetention(t *testing.T) {
+	app := testApp(t)
+	mux := newMux(app)
+	admin := adminCookie(app)
+	postForm(mux, "/admin/settings/retention?lang=en", url.Values{"retention_months": {"3"}}, admin)
+	if instanceRetentionMonths(app.DB) != 3 {
+		t.Fatalf("retention setting = %d, want 3", instanceRetentionMonths(app.DB))
+	}
+
+	e := seedEvent(t, app.DB) // 2026-06-15
+	tk := seedTask(t, app.DB, e.ID, "Cuisine", nil)
+	if _, err := RegisterForTask(app.DB, tk.ID, "Alice", "Dupont", "alice@test.com", "0601", "fr"); err != nil {
+		t.Fatal(err)
+	}
+	party := &Event{TitleFR: "Fête", EventDate: "2026-06-15", EventType: "attendance"}
+	CreateEvent(app.DB, party)
+	UpsertAttendance(app.DB, party.ID, "Bob", "Martin", "bob@test.com", "0602", true, 0, 0, "See you!", "fr")
+	kept := &Event{TitleFR: "Archive", EventDate: "2026-01-01", RetentionMonths: sql.NullInt64{Int64: 0, Valid: true}}
+	CreateEvent(app.DB, kept)
+	tk2 := seedTask(t, app.DB, kept.ID, "Rangement", nil)
+	RegisterForTask(app.DB, tk2.ID, "Carol", "Petit", "carol@test.com", "0603", "fr")
+
+	// Emails, invites and notices keep addresses and phones too.
+	app.queueEmail("admin_notification", e.ID, "orga@test.com", "", "Nouvelle inscription", "<p>Alice Dupont, 0601</p>")
+	app.queueEmail("registrant_link", 0, "alice@test.com", "", "Vos inscriptions", "<p>Bonjour</p>")
+	app.queueEmail("registrant_link", 0, "carol@test.com", "", "Vos inscriptions", "<p>Bonjour</p>")
+	CreateInvites(app.DB, e.ID, "Amis", true, 1)
+	invites, _ := ListInvites(app.DB, e.ID)
+	if err := UseInvite(app.DB, &invites[0], "alice@test.com"); err != nil {
+		t.Fatal(err)
+	}
+	AddOpeningNotice(app.DB, OpeningNotice{EventID: e.ID, Email: "dan@test.com", Lang: "fr"})
+
+	if n, err := PurgeExpiredPersonalData(app.DB, time.Date(2026, 9, 15, 23, 0, 0, 0, time.UTC)); n != 0 || err != nil {
+		t.Fatalf("before the deadline: %d anonymized, %v", n, err)
+	}
+	if n, err := PurgeExpiredPersonalData(app.DB, time.Date(2026, 9, 16, 0, 0, 0, 0, time.UTC)); n != 2 || err != nil {
+		t.Fatalf("at the deadline: %d anonymized, %v", n, err)
+	}
+	regs, _ := ListRegistrations(app.DB, tk.ID)
+	if len(regs) != 1 || regs[0].Email != "" || regs[0].Phone != "" || regs[0].FirstName != "" {
+		t.Errorf("registration not anonymized: %+v", regs)
+	}
+	if CountRegistrations(app.DB, e.ID) != 1 {
+		t.Error("anonymizing lost the registration count")
+	}
+	atts, _ := ListAttendances(app.DB, party.ID)
+	if len(atts) != 1 || atts[0].Email != "" || atts[0].Message != "" || !atts[0].Attending {
+		t.Errorf("attendance not anonymized: %+v", atts)
+	}
+	if regs, _ := ListRegistrations(app.DB, tk2.ID); regs[0].Email != "carol@test.com" {
+		t.Error("an event keeping its data was anonymized")
+	}
+	var left []string
+	rows, _ := app.DB.Query("SELECT to_email FROM outbox")
+	for rows.Next() {
+		var to string
+		rows.Scan(&to)
+		left = append(left, to)
+	}
+	rows.Close()
+	if len(left) != 1 || left[0] != "carol@test.com" {
+		t.Errorf("outbox after anonymizing: %v, want only carol@test.com", left)
+	}
+	if invites, _ := ListInvites(app.DB, e.ID); invites[0].UsedBy != "" {
+		t.Errorf("invite still used by %q", invites[0].UsedBy)
+	}
+	if n, _ := CountOpeningNotices(app.DB, e.ID); n != 0 {
+		t.Errorf("%d opening notices left", n)
+	}
+	if n, _ := PurgeExpiredPersonalData(app.DB, time.Now().AddDate(1, 0, 0)); n != 0 {
+		t.Errorf("anonymized %d events twice", n)
+	}
+
+	body := getRequest(mux, fmt.Sprintf("/admin/event/edit?id=%d&lang=en", e.ID), admin).Body.String()
+	if !strings.Contains(body, template.HTMLEscapeString(T("retention_anonymized", "en"))) {
+		t.Error("event page does not say the data was erased")
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+// Returning visitors without JavaScript. The event page remembers a signup
+// in localStorage, which takes scripts; each signup confirmation also
+// leaves a cookie holding its cancellation token, from which the server
+// shows the registration on the event page, with plain links to change or
+// cancel it. The confirmation reached from the /my email link (see
+// my_registrations.go) leaves the same cookie, so a signup made in another
+// browser can be brought into this one.
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// returningCookieMaxAge is how long a browser remembers a signup.
+const returningCookieMaxAge = 365 * 24 * 60 * 60
+
+const returningCookiePrefix = "reg_"
+
+func returningCookieName(eventID int64) string {
+	return returningCookiePrefix + strconv.FormatInt(eventID, 10)
+}
+
+// Returning is the registration a browser made for an event.
+type Returning struct {
+	Reg         *Registration
+	Task        *Task
+	CancelToken string
+	CancelURL   string // without ?lang
+}
+
+// Stored is rt as the signup confirmation stores it in localStorage, for the
+// event page's script.
+func (rt *Returning) Stored(lang string) map[string]any {
+	return map[string]any{
+		"taskId":      rt.Task.ID,
+		"taskTitle":   Localized(rt.Task.TitleFR, rt.Task.TitleEN, lang),
+		"cancelToken": rt.CancelToken,
+		"firstName":   rt.Reg.FirstName,
+		"lastName":    rt.Reg.LastName,
+		"email":       rt.Reg.Email,
+		"phone":       rt.Reg.Phone,
+		"guests":      rt.Reg.Guests,
+	}
+}
+
+// rememberRegistration has the browser remember the registration of
+// eventID with cancelToken.
+func rememberRegistration(w http.ResponseWriter, eventID int64, cancelToken string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     returningCookieName(eventID),
+		Value:    cancelToken,
+		Path:     "/",
+		MaxAge:   returningCookieMaxAge,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// forgetRegistration has the browser forget its registration of eventID.
+func forgetRegistration(w http.ResponseWriter, eventID int64) {
+	http.SetCookie(w, &http.Cookie{Name: returningCookieName(eventID), Value: "", Path: "/", MaxAge: -1})
+}
+
+// returningRegistration returns the registration for event the browser
+// remembers, nil when it remembers none that still stands.
+func (app *App) returningRegistration(r *http.Request, event *Event) *Returning {
+	c, err := r.Cookie(returningCookieName(event.ID))
+	if err != nil {
+		return nil
+	}
+	regToken, _, ok := app.parseCancelToken(c.Value)
+	if !ok {
+		return nil
+	}
+	reg, err := GetRegistrationByToken(app.DB, regToken)
+	if err != nil {
+		return nil
+	}
+	task, err := GetTask(app.DB, reg.TaskID)
+	if err != nil || task.EventID != event.ID {
+		return nil
+	}
+	token := app.cancelToken(*reg, *event)
+	return &Returning{Reg: reg, Task: task, CancelToken: token, CancelURL: app.eventBaseURL(r, event) + "/cancel/" + token}
+}
+
+// remembersEmail reports whether the browser remembers a registration, to
+// any event, made with email.
+func (app *App) remembersEmail(r *http.Request, email string) bool {
+	for _, c := range r.Cookies() {
+		if !strings.HasPrefix(c.Name, returningCookiePrefix) {
+			continue
+		}
+		regToken, _, ok := app.parseCancelToken(c.Value)
+		if !ok {
+			continue
+		}
+		if reg, err := GetRegistrationByToken(app.DB, regToken); err == nil && strings.EqualFold(reg.Email, email) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestReturningWithoutScripts(t *testing.T) {
+	app := testApp(t)
+	mux := newMux(app)
+	e := seedEvent(t, app.DB)
+	kitchen := seedTask(t, app.DB, e.ID, "Cuisine", nil)
+	bar := seedTask(t, app.DB, e.ID, "Buvette", nil)
+	other := seedEvent(t, app.DB)
+
+	remembered := func(w interface{ Result() *http.Response }) *http.Cookie {
+		t.Helper()
+		for _, c := range w.Result().Cookies() {
+			if c.Name == returningCookieName(e.ID) {
+				return c
+			}
+		}
+		t.Fatal("no registration cookie")
+		return nil
+	}
+	signup := func(taskID int64, cancelToken string) *http.Cookie {
+		t.Helper()
+		return remembered(postForm(mux, "/signup?lang=en", url.Values{
+			"task_id": {fmt.Sprint(taskID)}, "first_name": {"Alice"}, "last_name": {"Martin"},
+			"email": {"alice@test.com"}, "phone": {"0601"}, "cancel_token": {cancelToken},
+		}))
+	}
+	cookie := signup(kitchen.ID, "")
+
+	page := getRequest(mux, "/e/"+e.Slug+"?lang=en", cookie).Body.String()
+	for _, want := range []string{
+		`<div id="registered-view">`,
+		`<span id="reg-name">Alice Martin</span>`,
+		`<strong id="reg-task-name">Cuisine</strong>`,
+		`href="/cancel/` + cookie.Value + `?lang=en"`,
+		`class="signup-unified" style="display:none"`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("event page misses %s", want)
+		}
+	}
+	// The calendar link works, as does the one the page's script builds
+	// from the token the confirmation stored.
+	ics := regexp.MustCompile(`id="reg-ics-url" href="(/ics/[^"?]+)`).FindStringSubmatch(page)
+	if ics == nil {
+		t.Fatal("event page has no calendar link")
+	}
+	stored := postForm(mux, "/signup?lang=en", url.Values{
+		"task_id": {fmt.Sprint(kitchen.ID)}, "first_name": {"Alice"}, "last_name": {"Martin"},
+		"email": {"alice@test.com"}, "phone": {"0601"}, "cancel_token": {cookie.Value},
+	}).Body.String()
+	script := regexp.MustCompile(`cancelToken: "([^"]+)"`).FindStringSubmatch(stored)
+	if script == nil {
+		t.Fatal("confirmation stores no token")
+	}
+	for _, path := range []string{ics[1], "/ics/" + script[1]} {
+		if w := getRequest(mux, path); w.Code != 200 || !strings.Contains(w.Body.String(), "BEGIN:VCALENDAR") {
+			t.Errorf("%s: status %d", path, w.Code)
+		}
+	}
+
+	// The cookie is for this event only, and must carry a valid token.
+	if page := getRequest(mux, "/e/"+other.Slug, &http.Cookie{Name: returningCookieName(other.ID), Value: cookie.Value}).Body.String(); !strings.Contains(page, `<div id="registered-view" style="display:none">`) {
+		t.Error("registration shown on another event")
+	}
+	forged := &http.Cookie{Name: cookie.Name, Value: strings.Split(cookie.Value, ".")[0] + ".0.forged"}
+	if page := getRequest(mux, "/e/"+e.Slug, forged).Body.String(); !strings.Contains(page, `<div id="registered-view" style="display:none">`) {
+		t.Error("registration shown from a forged token")
+	}
+
+	// Changing tasks: the form comes back filled in, carrying the token.
+	page = getRequest(mux, "/e/"+e.Slug+"?lang=en&returning=change", cookie).Body.String()
+	if !strings.Contains(page, `name="cancel_token" value="`+cookie.Value+`"`) || !strings.Contains(page, `value="alice@test.com"`) {
+		t.Fatal("change form not filled in")
+	}
+	cookie = signup(bar.ID, cookie.Value)
+	if CountTaskRegistrations(app.DB, kitchen.ID) != 0 || CountTaskRegistrations(app.DB, bar.ID) != 1 {
+		t.Error("registration not moved")
+	}
+
+	w := postForm(mux, "/cancel/"+cookie.Value+"?lang=en", nil, cookie)
+	if c := remembered(w); c.MaxAge >= 0 {
+		t.Errorf("cookie kept after cancelling: %+v", c)
+	}
+	if page := getRequest(mux, "/e/"+e.Slug, cookie).Body.String(); !strings.Contains(page, `<div id="registered-view" style="display:none">`) {
+		t.Error("cancelled registration still shown")
+	}
+}
@@ -1,6 +1,17 @@
 package main
 
-import "github.com/microcosm-cc/bluemonday"
+import (
+	"bytes"
+	"html"
+	"html/template"
+	"regexp"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	gmhtml "github.com/yuin/goldmark/renderer/html"
+)
 
 // descriptionPolicy is the HTML sanitization policy for event descriptions.
 // It is sized to match Trix's output set (https://trix-editor.org/): block
@@ -19,7 +30,55 @@ var descriptionPolicy = func() *bluemonday.Policy {
 
 // sanitizeEventDescription cleans HTML coming from the admin description
 // editor before it is stored. Called on the write path so the stored value
-// can be rendered straight to the page and the email as safe HTML.
+// can be rendered straight to the page and the email as safe HTML. A
+// description without any tag — sent through the API or imported — is
+// taken as Markdown.
 func sanitizeEventDescription(s string) string {
+	if !htmlTagPattern.MatchString(s) {
+		return renderMarkdown(s)
+	}
 	return descriptionPolicy.Sanitize(s)
 }
+
+// markdown renders task descriptions, typed in plain textareas. Single line
+// breaks are kept, as nl2br did before, and bare URLs become links.
+var markdown = goldmark.New(
+	goldmark.WithExtensions(extension.Strikethrough, extension.Linkify),
+	goldmark.WithRendererOptions(gmhtml.WithHardWraps()),
+)
+
+// renderMarkdown turns Markdown into HTML within descriptionPolicy. Raw HTML
+// in s is dropped rather than passed through.
+func renderMarkdown(s string) string {
+	if strings.TrimSpace(s) == "" {
+		return ""
+	}
+	var b bytes.Buffer
+	if err := markdown.Convert([]byte(s), &b); err != nil {
+		return descriptionPolicy.Sanitize(html.EscapeString(s))
+	}
+	return strings.TrimSpace(descriptionPolicy.Sanitize(b.String()))
+}
+
+// markdownHTML is renderMarkdown for templates.
+func markdownHTML(s string) template.HTML {
+	return template.HTML(renderMarkdown(s))
+}
+
+// blockBreakPattern matches the tags after which Trix content breaks a line.
+var blockBreakPattern = regexp.MustCompile(`(?i)<br\s*/?>|</(p|h1|blockquote|pre|li|div)>`)
+
+var blankLinesPattern = regexp.MustCompile(`\n{3,}`)
+
+// htmlToText flattens a stored description to plain text for contexts that
+// can't render HTML (calendar files): block ends become line breaks, tags
+// are dropped and entities decoded.
+func htmlToText(s string) string {
+	s = blockBreakPattern.ReplaceAllString(s, "\n")
+	s = html.UnescapeString(bluemonday.StrictPolicy().Sanitize(s))
+	lines := strings.Split(s, "\n")
+	for i := range lines {
+		lines[i] = strings.TrimSpace(lines[i])
+	}
+	return strings.TrimSpace(blankLinesPattern.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+}
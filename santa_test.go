@@ -253,7 +253,7 @@ func TestRenderSantaEmails(t *testing.T) {
 	receiver := SantaParticipant{FirstName: "Bob", LastName: "Martin",
 		WishBuy: "un stylo", WishMake: "un poeme", WishFree: "une surprise"}
 
-	subj, html := renderSantaLinkEmail("fr", giver, e, "http://x/santa/edit?token=abc")
+	subj, html := renderSantaLinkEmail("fr", giver, e, "http://x/santa/edit?token=abc", "")
 	if subj == "" {
 		t.Error("link email subject is empty")
 	}
@@ -277,7 +277,7 @@ func TestRenderSantaEmails(t *testing.T) {
 	eCustom.EmailHowStep1FR = "Étape personnalisée 1."
 	eCustom.EmailButtonFR = "👉 Ma liste perso"
 	eCustom.EmailDisclaimerFR = "Petit rappel personnalisé."
-	_, htmlCustom := renderSantaLinkEmail("fr", giver, eCustom, "http://x/santa/edit?token=abc")
+	_, htmlCustom := renderSantaLinkEmail("fr", giver, eCustom, "http://x/santa/edit?token=abc", "")
 	for _, want := range []string{
 		"super tirage cette année",
 		"Le déroulé",
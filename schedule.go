@@ -0,0 +1,133 @@
+package main
+
+// Schedule of shift-based events: tasks with a start time, in chronological
+// order, shown as a list on the public page and as a timeline in the admin.
+
+import (
+	"sort"
+	"time"
+)
+
+// ScheduleEntry is one timed task. Start and End are minutes after midnight
+// of the event's day; End may pass 24h for a shift that runs past midnight
+// and equals Start when the task has no end time.
+type ScheduleEntry struct {
+	Task             *TaskView
+	GroupFR, GroupEN string // enclosing group, if any
+	Start, End       int
+	// Left and Width place the entry on the admin timeline, in percent.
+	Left, Width float64
+}
+
+// ScheduleTick is an hour mark on the admin timeline.
+type ScheduleTick struct {
+	Time string // "HH:MM", for formatTime
+	Left float64
+}
+
+type Schedule struct {
+	Entries []ScheduleEntry
+	Ticks   []ScheduleTick
+}
+
+// parseClock returns minutes after midnight for an "HH:MM" time.
+func parseClock(s string) (int, bool) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, false
+	}
+	return t.Hour()*60 + t.Minute(), true
+}
+
+// validTaskTime reports whether s is empty or a valid "HH:MM" time.
+func validTaskTime(s string) bool {
+	_, ok := parseClock(s)
+	return s == "" || ok
+}
+
+// formatTaskTimes formats a task's shift in lang ("8h00–10h00"), or "" for
+// an untimed task.
+func formatTaskTimes(t Task, lang string) string {
+	if t.StartTime == "" {
+		return ""
+	}
+	s := formatEventTime(t.StartTime, lang)
+	if t.EndTime != "" {
+		s += "–" + formatEventTime(t.EndTime, lang)
+	}
+	return s
+}
+
+// BuildSchedule collects the timed tasks of an event tree, earliest first.
+// It returns nil when no task has a start time.
+func BuildSchedule(tree []TreeNode) *Schedule {
+	var entries []ScheduleEntry
+	var walk func(nodes []TreeNode, group *TaskGroup)
+	walk = func(nodes []TreeNode, group *TaskGroup) {
+		for _, n := range nodes {
+			if n.Type == "group" {
+				walk(n.Children, n.Group)
+				continue
+			}
+			start, ok := parseClock(n.Task.StartTime)
+			if !ok {
+				continue
+			}
+			end, ok := parseClock(n.Task.EndTime)
+			if !ok {
+				end = start
+			} else if end <= start {
+				end += 24 * 60
+			}
+			e := ScheduleEntry{Task: n.Task, Start: start, End: end}
+			if group != nil {
+				e.GroupFR, e.GroupEN = group.TitleFR, group.TitleEN
+			}
+			entries = append(entries, e)
+		}
+	}
+	walk(tree, nil)
+	if len(entries) == 0 {
+		return nil
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Start != entries[j].Start {
+			return entries[i].Start < entries[j].Start
+		}
+		return entries[i].End < entries[j].End
+	})
+
+	// The timeline spans whole hours around the earliest start and the
+	// latest end; open-ended tasks get half an hour so they stay visible.
+	const openEnded = 30
+	first, last := entries[0].Start/60*60, 0
+	for _, e := range entries {
+		end := e.End
+		if end == e.Start {
+			end += openEnded
+		}
+		if end > last {
+			last = end
+		}
+	}
+	last = (last + 59) / 60 * 60
+	span := float64(last - first)
+
+	s := &Schedule{Entries: entries}
+	for i := range s.Entries {
+		e := &s.Entries[i]
+		length := e.End - e.Start
+		if length == 0 {
+			length = openEnded
+		}
+		e.Left = float64(e.Start-first) / span * 100
+		e.Width = float64(length) / span * 100
+	}
+	for m := first; m <= last; m += 60 {
+		s.Ticks = append(s.Ticks, ScheduleTick{
+			Time: time.Date(0, 1, 1, 0, m, 0, 0, time.UTC).Format("15:04"),
+			Left: float64(m-first) / span * 100,
+		})
+	}
+	return s
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBuildSchedule(t *testing.T) {
+	task := func(title, start, end string) TreeNode {
+		return TreeNode{Type: "task", Task: &TaskView{Task: Task{TitleFR: title, StartTime: start, EndTime: end}}}
+	}
+	if s := BuildSchedule([]TreeNode{task("Vaisselle", "", "")}); s != nil {
+		t.Fatalf("untimed tree: schedule = %+v, want nil", s)
+	}
+
+	tree := []TreeNode{
+		task("Fermeture", "22:00", "01:00"),
+		{Type: "group", Group: &TaskGroup{TitleFR: "Cuisine", TitleEN: "Kitchen"}, Children: []TreeNode{
+			task("Épluchage", "08:30", "10:00"),
+			task("Vaisselle", "", ""),
+		}},
+		task("Accueil", "08:30", ""),
+	}
+	s := BuildSchedule(tree)
+	if s == nil || len(s.Entries) != 3 {
+		t.Fatalf("schedule = %+v, want 3 timed entries", s)
+	}
+	var order []string
+	for _, e := range s.Entries {
+		order = append(order, e.Task.TitleFR)
+	}
+	if got := strings.Join(order, ","); got != "Accueil,Épluchage,Fermeture" {
+		t.Errorf("order = %s", got)
+	}
+	if e := s.Entries[1]; e.GroupEN != "Kitchen" {
+		t.Errorf("group of Épluchage = %q", e.GroupEN)
+	}
+	if e := s.Entries[2]; e.End != 25*60 {
+		t.Errorf("overnight shift ends at %d, want %d", e.End, 25*60)
+	}
+	// 08:00 to 01:00 the next day, one tick per hour.
+	if n := len(s.Ticks); n != 18 || s.Ticks[0].Time != "08:00" || s.Ticks[n-1].Time != "01:00" {
+		t.Errorf("ticks = %+v", s.Ticks)
+	}
+	if e := s.Entries[0]; e.Width <= 0 {
+		t.Errorf("open-ended task should keep a visible width, got %v", e.Width)
+	}
+}
+
+func TestFormatTaskTimes(t *testing.T) {
+	for _, tc := range []struct {
+		start, end, lang, want string
+	}{
+		{"", "", LangFR, ""},
+		{"08:30", "", LangFR, "08h30"},
+		{"08:30", "10:00", LangFR, "08h30–10h00"},
+		{"14:00", "16:30", LangEN, "2:00 PM–4:30 PM"},
+	} {
+		if got := formatTaskTimes(Task{StartTime: tc.start, EndTime: tc.end}, tc.lang); got != tc.want {
+			t.Errorf("formatTaskTimes(%s, %s, %s) = %q, want %q", tc.start, tc.end, tc.lang, got, tc.want)
+		}
+	}
+}
+
+func TestTaskTimesSaveAndSchedule(t *testing.T) {
+	app := testApp(t)
+	e := seedEvent(t, app.DB)
+	tk := seedTask(t, app.DB, e.ID, "Vaisselle", nil)
+	mux := newMux(app)
+
+	save := func(start, end string) int {
+		body, _ := json.Marshal(map[string]any{
+			"id": tk.ID, "title_fr": tk.TitleFR, "start_time": start, "end_time": end,
+		})
+		req := httptest.NewRequest(http.MethodPost, "/admin/api/task/save", bytes.NewReader(body))
+		req.AddCookie(adminCookie(app))
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w.Code
+	}
+	if code := save("25:00", ""); code != 400 {
+		t.Errorf("invalid start time: status = %d, want 400", code)
+	}
+	if code := save("18:00", "20:30"); code != 200 {
+		t.Fatalf("valid times: status = %d", code)
+	}
+	got, _ := GetTask(app.DB, tk.ID)
+	if got.StartTime != "18:00" || got.EndTime != "20:30" {
+		t.Fatalf("times = %q–%q", got.StartTime, got.EndTime)
+	}
+
+	body := getRequest(mux, "/e/"+e.Slug+"?lang=fr").Body.String()
+	for _, want := range []string{"schedule-list", "18h00–20h30", `href="#task-`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("public page missing %q", want)
+		}
+	}
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/admin/event/edit?id=%d", e.ID), nil)
+	req.AddCookie(adminCookie(app))
+	mux.ServeHTTP(w, req)
+	if !strings.Contains(w.Body.String(), "timeline-bar") {
+		t.Errorf("admin edit page lacks the shift timeline (status %d)", w.Code)
+	}
+}
@@ -7,8 +7,47 @@ CREATE TABLE IF NOT EXISTS events (
     description_en TEXT NOT NULL DEFAULT '',
     event_date TEXT NOT NULL,
     event_time TEXT NOT NULL DEFAULT '',
+    -- Optional end. An empty end_date means the event ends on event_date.
+    end_date TEXT NOT NULL DEFAULT '',
+    end_time TEXT NOT NULL DEFAULT '',
     event_type TEXT NOT NULL DEFAULT 'tasks',
     santa_drawn_at TEXT,
+    base_url TEXT NOT NULL DEFAULT '',
+    stats_token TEXT NOT NULL DEFAULT '',
+    terms_fr TEXT NOT NULL DEFAULT '',
+    terms_en TEXT NOT NULL DEFAULT '',
+    terms_version INTEGER NOT NULL DEFAULT 0,
+    notify_emails TEXT NOT NULL DEFAULT '',
+    organizer_email TEXT NOT NULL DEFAULT '',
+    timezone TEXT NOT NULL DEFAULT '',
+    -- Days after the event's last day its cancellation links stay valid;
+    -- NULL means they never expire.
+    cancel_days INTEGER,
+    -- Months after the event's last day its registrants' personal data is
+    -- erased (0: kept); NULL means the retention_months setting applies.
+    retention_months INTEGER,
+    anonymized_at TEXT,
+    -- When signups open and close, as "YYYY-MM-DDTHH:MM" in the event's
+    -- time zone; empty means no bound.
+    registration_opens_at TEXT NOT NULL DEFAULT '',
+    registration_closes_at TEXT NOT NULL DEFAULT '',
+    access_code TEXT NOT NULL DEFAULT '', -- early access and reserved slots
+    -- How many of the event's tasks one email address can sign up for.
+    max_tasks_per_person INTEGER NOT NULL DEFAULT 1,
+    -- How many people can take part, all tasks together; NULL means no cap.
+    max_participants INTEGER,
+    collect_diet INTEGER NOT NULL DEFAULT 0, -- ask registrants for their diet
+    -- Show only how many signed up for each task, never who.
+    counts_only INTEGER NOT NULL DEFAULT 0,
+    checkin INTEGER NOT NULL DEFAULT 0, -- QR codes scanned at the door
+    assign_mode INTEGER NOT NULL DEFAULT 0, -- ranked preferences, assigned later
+    family_signup INTEGER NOT NULL DEFAULT 0, -- several people in one signup
+    draft INTEGER NOT NULL DEFAULT 0, -- not on the public site yet
+    notes TEXT NOT NULL DEFAULT '', -- organizers' private notes
+    lang TEXT NOT NULL DEFAULT '', -- only language of a single-language event
+    noindex INTEGER NOT NULL DEFAULT 0, -- asks search engines to skip it
+    invite_only INTEGER NOT NULL DEFAULT 0, -- public pages need an invite
+    max_guests INTEGER NOT NULL DEFAULT 0, -- plus-ones per yes to an RSVP
     -- Per-event overrides for the magic-link email shell. Empty means
     -- "use the i18n default" (see santa_email_* keys in i18n.go).
     email_hook_fr TEXT NOT NULL DEFAULT '',
@@ -25,16 +64,50 @@ CREATE TABLE IF NOT EXISTS events (
     email_button_en TEXT NOT NULL DEFAULT '',
     email_disclaimer_fr TEXT NOT NULL DEFAULT '',
     email_disclaimer_en TEXT NOT NULL DEFAULT '',
+    -- Texts shown once signed up, in Markdown (see after_signup.go).
+    after_info_fr TEXT NOT NULL DEFAULT '',
+    after_info_en TEXT NOT NULL DEFAULT '',
+    after_bring_fr TEXT NOT NULL DEFAULT '',
+    after_bring_en TEXT NOT NULL DEFAULT '',
+    after_contact_fr TEXT NOT NULL DEFAULT '',
+    after_contact_en TEXT NOT NULL DEFAULT '',
+    series_id INTEGER REFERENCES event_series(id) ON DELETE SET NULL,
+    -- Set while the row sits in the admin trash (see the trash table).
+    trash_id INTEGER,
     created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
 );
 
+-- A weekly or monthly repetition of an event. Each occurrence is an ordinary
+-- event row pointing here through events.series_id.
+CREATE TABLE IF NOT EXISTS event_series (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    frequency TEXT NOT NULL,
+    until_date TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Text of every version of an event's terms, kept so registrations that
+-- accepted an earlier version can still be shown what they agreed to.
+CREATE TABLE IF NOT EXISTS event_terms (
+    event_id INTEGER NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    terms_fr TEXT NOT NULL DEFAULT '',
+    terms_en TEXT NOT NULL DEFAULT '',
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (event_id, version)
+);
+
 CREATE TABLE IF NOT EXISTS task_groups (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
     event_id INTEGER NOT NULL REFERENCES events(id) ON DELETE CASCADE,
     parent_group_id INTEGER REFERENCES task_groups(id) ON DELETE SET NULL,
     title_fr TEXT NOT NULL,
     title_en TEXT NOT NULL DEFAULT '',
-    position INTEGER NOT NULL DEFAULT 0
+    position INTEGER NOT NULL DEFAULT 0,
+    max_slots INTEGER, -- across all the group's tasks; NULL is unlimited
+    color TEXT NOT NULL DEFAULT '', -- "#rrggbb" or empty (group_style.go)
+    icon TEXT NOT NULL DEFAULT '', -- an emoji or empty
+    trash_id INTEGER
 );
 
 CREATE TABLE IF NOT EXISTS tasks (
@@ -46,17 +119,48 @@ CREATE TABLE IF NOT EXISTS tasks (
     description_fr TEXT NOT NULL DEFAULT '',
     description_en TEXT NOT NULL DEFAULT '',
     max_slots INTEGER,
-    position INTEGER NOT NULL DEFAULT 0
+    -- Optional shift, as HH:MM on the event's day. An end earlier than the
+    -- start runs past midnight.
+    start_time TEXT NOT NULL DEFAULT '',
+    end_time TEXT NOT NULL DEFAULT '',
+    position INTEGER NOT NULL DEFAULT 0,
+    max_guests INTEGER NOT NULL DEFAULT 0, -- people a registrant may bring
+    min_slots INTEGER NOT NULL DEFAULT 0, -- people needed; 0: no minimum
+    skills TEXT NOT NULL DEFAULT '', -- needed, comma-separated (skills.go)
+    reserved_slots INTEGER NOT NULL DEFAULT 0, -- of max_slots, for access code holders
+    notes TEXT NOT NULL DEFAULT '', -- organizers' private notes
+    covered_at TEXT, -- marked as taken care of on the day (dayof.go)
+    trash_id INTEGER
 );
 
 CREATE TABLE IF NOT EXISTS registrations (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
     task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    event_id INTEGER, -- the task's, for idx_registrations_event_email_member_slot
     first_name TEXT NOT NULL DEFAULT '',
     last_name TEXT NOT NULL DEFAULT '',
     email TEXT NOT NULL,
     phone TEXT NOT NULL,
+    lang TEXT NOT NULL DEFAULT 'fr',
     token TEXT NOT NULL UNIQUE,
+    terms_version INTEGER NOT NULL DEFAULT 0,
+    terms_accepted_at TEXT,
+    guests INTEGER NOT NULL DEFAULT 0, -- people coming along, a slot each
+    comment TEXT NOT NULL DEFAULT '', -- left by the person at signup
+    diet TEXT NOT NULL DEFAULT '', -- comma-separated, see dietOptions
+    allergies TEXT NOT NULL DEFAULT '',
+    show_name INTEGER NOT NULL DEFAULT 0, -- first name shown on the public page
+    checked_in_at TEXT, -- arrival, scanned at the door
+    -- Which of the person's registrations for the event this is, from 1 to
+    -- the event's max_tasks_per_person.
+    person_slot INTEGER NOT NULL DEFAULT 1,
+    -- 0 for whoever owns the email; 1, 2… for the people they signed up
+    -- along with them (see family.go).
+    member INTEGER NOT NULL DEFAULT 0,
+    -- 1 when made before cancel links were signed: the bare token then
+    -- still cancels it (see cancel.go).
+    legacy_token INTEGER NOT NULL DEFAULT 0,
+    trash_id INTEGER,
     created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
 );
 
@@ -66,6 +170,11 @@ CREATE INDEX IF NOT EXISTS idx_tasks_event ON tasks(event_id);
 CREATE INDEX IF NOT EXISTS idx_tasks_group ON tasks(group_id);
 CREATE INDEX IF NOT EXISTS idx_registrations_task ON registrations(task_id);
 CREATE INDEX IF NOT EXISTS idx_registrations_token ON registrations(token);
+-- At most max_tasks_per_person live registrations per person — email
+-- address and member — and event, one per slot. Anonymized rows, whose
+-- email is empty, don't count.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_registrations_event_email_member_slot
+    ON registrations(event_id, LOWER(email), member, person_slot) WHERE trash_id IS NULL AND email != '';
 
 CREATE TABLE IF NOT EXISTS attendances (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -75,7 +184,10 @@ CREATE TABLE IF NOT EXISTS attendances (
     email TEXT NOT NULL,
     phone TEXT NOT NULL DEFAULT '',
     attending INTEGER NOT NULL DEFAULT 1,
+    adult_guests INTEGER NOT NULL DEFAULT 0, -- plus-ones coming with a yes
+    child_guests INTEGER NOT NULL DEFAULT 0,
     message TEXT NOT NULL DEFAULT '',
+    lang TEXT NOT NULL DEFAULT 'fr',
     created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
     updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
 );
@@ -117,3 +229,274 @@ CREATE TABLE IF NOT EXISTS email_messages (
 );
 
 CREATE INDEX IF NOT EXISTS idx_email_messages_ses_id ON email_messages(ses_message_id);
+
+CREATE TABLE IF NOT EXISTS outbox (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    kind TEXT NOT NULL,
+    event_id INTEGER NOT NULL DEFAULT 0,
+    to_email TEXT NOT NULL,
+    reply_to TEXT NOT NULL DEFAULT '',
+    subject TEXT NOT NULL DEFAULT '',
+    html_body TEXT NOT NULL DEFAULT '',
+    attachments TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_error TEXT NOT NULL DEFAULT '',
+    message_id TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    sent_at TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_status ON outbox(status, next_attempt_at);
+
+CREATE TABLE IF NOT EXISTS settings (
+    key TEXT PRIMARY KEY,
+    value TEXT NOT NULL DEFAULT ''
+);
+
+-- Per-address email preferences, keyed by lowercased address so one opt-out
+-- covers every registration and participant row using it.
+CREATE TABLE IF NOT EXISTS email_preferences (
+    email TEXT PRIMARY KEY,
+    unsubscribed_at TEXT,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Logged-in admin browsers. The cookie holds a random token; only its hash
+-- is stored. password_tag ties a session to the admin password it was
+-- opened with, so changing the password logs everyone out.
+CREATE TABLE IF NOT EXISTS sessions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    token_hash TEXT NOT NULL UNIQUE,
+    password_tag TEXT NOT NULL,
+    user_agent TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_seen DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    -- "Remember this device": a longer idle timeout and a token rotated
+    -- daily; prev_token_hash stays valid briefly after each rotation.
+    remember INTEGER NOT NULL DEFAULT 0,
+    prev_token_hash TEXT NOT NULL DEFAULT '',
+    rotated_at DATETIME
+);
+
+-- One-time admin login links emailed to the addresses allowed in the
+-- admin_emails setting. As for sessions, only the token's hash is stored.
+CREATE TABLE IF NOT EXISTS login_links (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    token_hash TEXT NOT NULL UNIQUE,
+    email TEXT NOT NULL,
+    expires_at DATETIME NOT NULL,
+    used_at DATETIME,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Links registrants ask for to see all their registrations (see
+-- my_registrations.go). Only the token's hash is stored.
+CREATE TABLE IF NOT EXISTS registrant_links (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    token_hash TEXT NOT NULL UNIQUE,
+    email TEXT NOT NULL,
+    expires_at DATETIME NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Tokens for scripts calling the JSON API with an Authorization: Bearer
+-- header. scope is 'read' (GET only) or 'write'; only the hash is stored.
+CREATE TABLE IF NOT EXISTS api_tokens (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    scope TEXT NOT NULL DEFAULT 'read',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_used DATETIME
+);
+
+-- Where recent public signups came from, for the per-address limits set on
+-- the settings page. Entries older than a day are purged.
+CREATE TABLE IF NOT EXISTS signup_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    ip TEXT NOT NULL,
+    email TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_signup_log_ip ON signup_log(ip, created_at);
+
+-- What is known of each address that signed up or answered an RSVP, to
+-- fill in the signup form for returning volunteers (volunteers.go).
+CREATE TABLE IF NOT EXISTS volunteers (
+    email TEXT PRIMARY KEY, -- lowercased
+    first_name TEXT NOT NULL DEFAULT '',
+    last_name TEXT NOT NULL DEFAULT '',
+    phone TEXT NOT NULL DEFAULT '',
+    skills TEXT NOT NULL DEFAULT '', -- declared, comma-separated (skills.go)
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Recent lookups of those, limited per client address.
+CREATE TABLE IF NOT EXISTS volunteer_lookups (
+    ip TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_volunteer_lookups_ip ON volunteer_lookups(ip, created_at);
+
+-- Registrations held for review because they went over those limits. The
+-- registration keeps its place; its confirmation waits for approval.
+CREATE TABLE IF NOT EXISTS signup_reviews (
+    registration_id INTEGER PRIMARY KEY REFERENCES registrations(id) ON DELETE CASCADE,
+    ip TEXT NOT NULL,
+    reason TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- What admins deleted: events, groups, tasks and registrations stay in their
+-- tables with trash_id pointing here, together with everything deleted along
+-- with them, until restored or purged. Entries are purged after 30 days.
+CREATE TABLE IF NOT EXISTS trash (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    kind TEXT NOT NULL,
+    item_id INTEGER NOT NULL,
+    event_id INTEGER NOT NULL,
+    label_fr TEXT NOT NULL DEFAULT '',
+    label_en TEXT NOT NULL DEFAULT '',
+    deleted_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Named group/task trees saved from an event and reused for new ones. The
+-- tree is stored as JSON (see event_template.go).
+CREATE TABLE IF NOT EXISTS event_templates (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    structure TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Extra questions of an event's signup form (see form_fields.go), and the
+-- registrants' answers.
+CREATE TABLE IF NOT EXISTS form_fields (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    event_id INTEGER NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    task_id INTEGER REFERENCES tasks(id) ON DELETE CASCADE, -- NULL: asked of everyone
+    kind TEXT NOT NULL DEFAULT 'text', -- text, select or checkbox
+    label_fr TEXT NOT NULL DEFAULT '',
+    label_en TEXT NOT NULL DEFAULT '',
+    options TEXT NOT NULL DEFAULT '', -- select choices, one per line
+    required INTEGER NOT NULL DEFAULT 0,
+    position INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS idx_form_fields_event ON form_fields(event_id);
+
+-- Tasks people would like, best first, on events in assign mode (see
+-- assignment.go). registration_id is the task they got once assignments
+-- ran (resolved_at), NULL when none of their choices had room.
+CREATE TABLE IF NOT EXISTS task_preferences (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    event_id INTEGER NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    first_name TEXT NOT NULL DEFAULT '',
+    last_name TEXT NOT NULL DEFAULT '',
+    email TEXT NOT NULL, -- lowercased
+    phone TEXT NOT NULL DEFAULT '',
+    lang TEXT NOT NULL DEFAULT 'fr',
+    choice1 INTEGER REFERENCES tasks(id) ON DELETE SET NULL,
+    choice2 INTEGER REFERENCES tasks(id) ON DELETE SET NULL,
+    choice3 INTEGER REFERENCES tasks(id) ON DELETE SET NULL,
+    registration_id INTEGER REFERENCES registrations(id) ON DELETE SET NULL,
+    resolved_at TEXT,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (event_id, email)
+);
+
+-- Rules between an event's tasks (see task_rules.go): task_id requires
+-- other_task_id, or the two exclude each other.
+CREATE TABLE IF NOT EXISTS task_rules (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    event_id INTEGER NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    other_task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL, -- requires or excludes
+    UNIQUE (task_id, other_task_id, kind)
+);
+CREATE INDEX IF NOT EXISTS idx_task_rules_event ON task_rules(event_id);
+
+CREATE TABLE IF NOT EXISTS registration_answers (
+    registration_id INTEGER NOT NULL REFERENCES registrations(id) ON DELETE CASCADE,
+    field_id INTEGER NOT NULL REFERENCES form_fields(id) ON DELETE CASCADE,
+    value TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (registration_id, field_id)
+);
+
+-- Copies of an event's group/task tree saved on each change, to see what
+-- changed and roll back (see structure_history.go). The tree is stored as
+-- JSON, like event templates'.
+CREATE TABLE IF NOT EXISTS structure_versions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    event_id INTEGER NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    reason TEXT NOT NULL DEFAULT '',
+    structure TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_structure_versions_event ON structure_versions(event_id);
+
+-- Slugs an event went by before being renamed, so links shared with the old
+-- address still lead to it (see slug.go).
+CREATE TABLE IF NOT EXISTS slug_history (
+    slug TEXT PRIMARY KEY,
+    event_id INTEGER NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Short addresses of events, /s/<code>, with how often they were followed:
+-- scans through the QR code, clicks otherwise (see shortlink.go).
+CREATE TABLE IF NOT EXISTS short_links (
+    code TEXT PRIMARY KEY,
+    event_id INTEGER NOT NULL UNIQUE REFERENCES events(id) ON DELETE CASCADE,
+    clicks INTEGER NOT NULL DEFAULT 0,
+    scans INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Codes events had before their short link was changed: they still lead to
+-- the event, for the QR codes already printed.
+CREATE TABLE IF NOT EXISTS short_link_aliases (
+    code TEXT PRIMARY KEY,
+    event_id INTEGER NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Events' own color, logo and CSS on their public pages (see
+-- event_theme.go). logo_type is empty when there is no logo.
+CREATE TABLE IF NOT EXISTS event_themes (
+    event_id INTEGER PRIMARY KEY REFERENCES events(id) ON DELETE CASCADE,
+    color TEXT NOT NULL DEFAULT '',
+    css TEXT NOT NULL DEFAULT '',
+    logo BLOB,
+    logo_type TEXT NOT NULL DEFAULT '',
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Invite links of invite-only events (see invite.go). A single-use invite
+-- belongs to used_by, the email of the first signup made with it.
+CREATE TABLE IF NOT EXISTS event_invites (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    event_id INTEGER NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    token TEXT NOT NULL UNIQUE,
+    label TEXT NOT NULL DEFAULT '',
+    single_use INTEGER NOT NULL DEFAULT 0,
+    uses INTEGER NOT NULL DEFAULT 0,
+    used_by TEXT NOT NULL DEFAULT '',
+    last_used_at DATETIME,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_event_invites_event ON event_invites(event_id);
+
+-- People to email when an event's registration opens (see
+-- opening_notice.go). A notice is deleted once sent.
+CREATE TABLE IF NOT EXISTS opening_notices (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    event_id INTEGER NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    email TEXT NOT NULL COLLATE NOCASE,
+    lang TEXT NOT NULL DEFAULT 'fr',
+    invite TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(event_id, email)
+);
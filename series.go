@@ -0,0 +1,242 @@
+package main
+
+// Recurring events. Repeating an event materializes one ordinary event per
+// date, each with a copy of the task structure, so signups, exports and
+// links keep working per occurrence. Later edits can be pushed from one
+// occurrence to the ones after it.
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maxSeriesOccurrences caps how many events one series may create, so a
+// mistyped end date doesn't fill the admin with thousands of events.
+const maxSeriesOccurrences = 60
+
+// seriesDates returns the dates after start, up to and including until, on
+// which a series repeats. Monthly series keep the day of the month, falling
+// back to the month's last day when it is shorter.
+func seriesDates(start, frequency, until string) ([]string, error) {
+	first, err := time.Parse("2006-01-02", start)
+	if err != nil {
+		return nil, err
+	}
+	last, err := time.Parse("2006-01-02", until)
+	if err != nil {
+		return nil, err
+	}
+	var dates []string
+	for i := 1; ; i++ {
+		var d time.Time
+		switch frequency {
+		case "weekly":
+			d = first.AddDate(0, 0, 7*i)
+		case "monthly":
+			d = first.AddDate(0, i, 0)
+			if d.Day() != first.Day() {
+				// Overflowed into the next month (e.g. Jan 31 + 1 month).
+				d = d.AddDate(0, 0, -d.Day())
+			}
+		default:
+			return nil, fmt.Errorf("unknown frequency %q", frequency)
+		}
+		if d.After(last) {
+			return dates, nil
+		}
+		if len(dates) == maxSeriesOccurrences {
+			return nil, fmt.Errorf("more than %d occurrences", maxSeriesOccurrences)
+		}
+		dates = append(dates, d.Format("2006-01-02"))
+	}
+}
+
+// copySeriesFields copies what a series shares from src onto dst: everything
+// but the date, slug, stats link and terms version, which stay per event. A
+// multi-day end date and the registration window move along with the date.
+func copySeriesFields(dst *Event, src Event) {
+	keep := *dst
+	*dst = src
+	dst.ID, dst.Slug, dst.EventDate = keep.ID, keep.Slug, keep.EventDate
+	dst.StatsToken, dst.SantaDrawnAt, dst.SeriesID = keep.StatsToken, keep.SantaDrawnAt, keep.SeriesID
+	dst.TermsVersion, dst.CreatedAt, dst.AnonymizedAt = keep.TermsVersion, keep.CreatedAt, keep.AnonymizedAt
+	from, err1 := time.Parse("2006-01-02", src.EventDate)
+	day, err2 := time.Parse("2006-01-02", dst.EventDate)
+	moved := err1 == nil && err2 == nil
+	if src.EndDate != "" {
+		to, err := time.Parse("2006-01-02", src.EndDate)
+		dst.EndDate = ""
+		if moved && err == nil {
+			dst.EndDate = day.Add(to.Sub(from)).Format("2006-01-02")
+		}
+	}
+	// Dates parse as UTC, so they are whole days apart.
+	days := int(day.Sub(from).Hours() / 24)
+	for _, bound := range []*string{&dst.RegistrationOpensAt, &dst.RegistrationClosesAt} {
+		if *bound != "" {
+			if moved {
+				*bound = shiftRegistrationTime(*bound, days)
+			} else {
+				*bound = ""
+			}
+		}
+	}
+}
+
+// handleAdminSeriesCreate turns an event into the first occurrence of a new
+// series and creates the following ones.
+func (app *App) handleAdminSeriesCreate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/admin", http.StatusSeeOther)
+		return
+	}
+	lang := LangFromRequest(r)
+	eventID, _ := strconv.ParseInt(r.FormValue("event_id"), 10, 64)
+	event, err := GetEvent(app.DB, eventID)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	editURL := fmt.Sprintf("/admin/event/edit?id=%d&lang=%s", event.ID, lang)
+	if event.SeriesID.Valid || event.EventType == "secret_santa" {
+		http.Redirect(w, r, editURL, http.StatusSeeOther)
+		return
+	}
+
+	series := &EventSeries{Frequency: r.FormValue("frequency"), UntilDate: r.FormValue("until_date")}
+	dates, err := seriesDates(event.EventDate, series.Frequency, series.UntilDate)
+	if err != nil || len(dates) == 0 {
+		setFlash(w, "error", fmt.Sprintf(T("series_error_dates", lang), maxSeriesOccurrences))
+		http.Redirect(w, r, editURL, http.StatusSeeOther)
+		return
+	}
+	if err := CreateSeries(app.DB, series); err != nil {
+		log.Printf("series: create: %v", err)
+		setFlash(w, "error", T("error_server", lang))
+		http.Redirect(w, r, editURL, http.StatusSeeOther)
+		return
+	}
+	if err := SetEventSeries(app.DB, event.ID, series.ID); err != nil {
+		log.Printf("series %d: attach event %d: %v", series.ID, event.ID, err)
+	}
+
+	for _, date := range dates {
+		occ := &Event{EventDate: date, Slug: GenerateSlug(event.TitleFR + " " + date)}
+		copySeriesFields(occ, *event)
+		if err := CreateEvent(app.DB, occ); err != nil {
+			log.Printf("series %d: create occurrence %s: %v", series.ID, date, err)
+			continue
+		}
+		if err := SetEventSeries(app.DB, occ.ID, series.ID); err != nil {
+			log.Printf("series %d: attach event %d: %v", series.ID, occ.ID, err)
+		}
+		if err := ReplaceTaskStructure(app.DB, event.ID, occ.ID); err != nil {
+			log.Printf("series %d: copy tasks to event %d: %v", series.ID, occ.ID, err)
+		}
+		if err := ReplaceFormFields(app.DB, event.ID, occ.ID); err != nil {
+			log.Printf("series %d: copy signup fields to event %d: %v", series.ID, occ.ID, err)
+		}
+		if err := ReplaceTaskRules(app.DB, event.ID, occ.ID); err != nil {
+			log.Printf("series %d: copy task rules to event %d: %v", series.ID, occ.ID, err)
+		}
+		if err := ReplaceEventTheme(app.DB, event.ID, occ.ID); err != nil {
+			log.Printf("series %d: copy theme to event %d: %v", series.ID, occ.ID, err)
+		}
+	}
+	setFlash(w, "success", fmt.Sprintf(T("series_created", lang), len(dates)))
+	http.Redirect(w, r, fmt.Sprintf("/admin/series?id=%d&lang=%s", series.ID, lang), http.StatusSeeOther)
+}
+
+// handleAdminSeries lists the occurrences of a series with their signups.
+func (app *App) handleAdminSeries(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	series, err := GetSeries(app.DB, id)
+	if err != nil {
+		http.Redirect(w, r, "/admin", http.StatusSeeOther)
+		return
+	}
+	events, err := ListSeriesEvents(app.DB, series.ID)
+	if err != nil {
+		log.Printf("series %d: list: %v", series.ID, err)
+	}
+	for i := range events {
+		if events[i].EventType == "attendance" {
+			events[i].AttendanceYes, events[i].RegCount = CountAttendances(app.DB, events[i].ID)
+		} else {
+			events[i].RegCount = CountRegistrations(app.DB, events[i].ID)
+		}
+	}
+	pd := app.newPageData(r, map[string]any{
+		"Series":  series,
+		"Events":  events,
+		"BaseURL": app.baseURLFor(r),
+		"Today":   time.Now().Format("2006-01-02"),
+	})
+	pd.Success, pd.Error = takeFlash(w, r)
+	app.render(w, r, "admin_series.html", pd)
+}
+
+// handleAdminSeriesPropagate copies an occurrence's details to every later
+// occurrence of its series. The task structure is copied too, except onto
+// occurrences that already have signups: replacing their tasks would drop
+// those registrations.
+func (app *App) handleAdminSeriesPropagate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/admin", http.StatusSeeOther)
+		return
+	}
+	lang := LangFromRequest(r)
+	eventID, _ := strconv.ParseInt(r.FormValue("event_id"), 10, 64)
+	src, err := GetEvent(app.DB, eventID)
+	if err != nil || !src.SeriesID.Valid {
+		http.NotFound(w, r)
+		return
+	}
+	events, err := ListSeriesEvents(app.DB, src.SeriesID.Int64)
+	if err != nil {
+		log.Printf("series %d: list: %v", src.SeriesID.Int64, err)
+	}
+
+	var updated, tasksKept int
+	for i := range events {
+		occ := &events[i]
+		if occ.EventDate <= src.EventDate {
+			continue
+		}
+		copySeriesFields(occ, *src)
+		if err := UpdateEvent(app.DB, occ); err != nil {
+			log.Printf("series: update event %d: %v", occ.ID, err)
+			continue
+		}
+		updated++
+		if err := ReplaceEventTheme(app.DB, src.ID, occ.ID); err != nil {
+			log.Printf("series: copy theme to event %d: %v", occ.ID, err)
+		}
+		if src.EventType != "tasks" {
+			continue
+		}
+		if CountRegistrations(app.DB, occ.ID) > 0 {
+			tasksKept++
+			continue
+		}
+		if err := ReplaceTaskStructure(app.DB, src.ID, occ.ID); err != nil {
+			log.Printf("series: copy tasks to event %d: %v", occ.ID, err)
+		}
+		if err := ReplaceFormFields(app.DB, src.ID, occ.ID); err != nil {
+			log.Printf("series: copy signup fields to event %d: %v", occ.ID, err)
+		}
+		if err := ReplaceTaskRules(app.DB, src.ID, occ.ID); err != nil {
+			log.Printf("series: copy task rules to event %d: %v", occ.ID, err)
+		}
+	}
+
+	msg := fmt.Sprintf(T("series_propagated", lang), updated)
+	if tasksKept > 0 {
+		msg += " " + fmt.Sprintf(T("series_tasks_kept", lang), tasksKept)
+	}
+	setFlash(w, "success", msg)
+	http.Redirect(w, r, fmt.Sprintf("/admin/event/edit?id=%d&lang=%s", src.ID, lang), http.StatusSeeOther)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSeriesDates(t *testing.T) {
+	got, err := seriesDates("2026-01-31", "monthly", "2026-05-01")
+	want := []string{"2026-02-28", "2026-03-31", "2026-04-30"}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("monthly = %v, %v; want %v", got, err, want)
+	}
+	got, err = seriesDates("2026-06-15", "weekly", "2026-06-29")
+	want = []string{"2026-06-22", "2026-06-29"}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("weekly = %v, %v; want %v", got, err, want)
+	}
+	if _, err := seriesDates("2026-06-15", "weekly", "2036-06-15"); err == nil {
+		t.Error("expected an error past the occurrence cap")
+	}
+	if _, err := seriesDates("2026-06-15", "daily", "2026-07-15"); err == nil {
+		t.Error("expected an error for an unknown frequency")
+	}
+}
+
+func TestCopySeriesFieldsShiftsEndDate(t *testing.T) {
+	src := Event{ID: 1, TitleFR: "Week-end", EventDate: "2026-06-13", EndDate: "2026-06-14", EndTime: "18:00"}
+	dst := Event{ID: 2, EventDate: "2026-07-11"}
+	copySeriesFields(&dst, src)
+	if dst.ID != 2 || dst.TitleFR != "Week-end" || dst.EndDate != "2026-07-12" || dst.EndTime != "18:00" {
+		t.Errorf("occurrence = %+v", dst)
+	}
+}
+
+func TestSeriesCreateAndPropagate(t *testing.T) {
+	app := testApp(t)
+	mux := newMux(app)
+	e := seedEvent(t, app.DB)
+	g := &TaskGroup{EventID: e.ID, TitleFR: "Cuisine"}
+	CreateTaskGroup(app.DB, g)
+	seedTask(t, app.DB, e.ID, "Vaisselle", nil)
+	inner := &Task{EventID: e.ID, GroupID: sql.NullInt64{Int64: g.ID, Valid: true}, TitleFR: "Épluchage"}
+	CreateTask(app.DB, inner)
+
+	postForm(mux, "/admin/series/create?lang=fr", url.Values{
+		"event_id":   {fmt.Sprint(e.ID)},
+		"frequency":  {"weekly"},
+		"until_date": {"2026-06-29"},
+	}, adminCookie(app))
+	e, _ = GetEvent(app.DB, e.ID)
+	if !e.SeriesID.Valid {
+		t.Fatal("event not attached to a series")
+	}
+	occs, _ := ListSeriesEvents(app.DB, e.SeriesID.Int64)
+	if len(occs) != 3 || occs[1].EventDate != "2026-06-22" || occs[1].Slug != "test-event-2026-06-22" {
+		t.Fatalf("occurrences = %+v", occs)
+	}
+	tree, _ := BuildEventTree(app.DB, occs[1].ID)
+	if len(tree) != 2 || tree[0].Type != "group" || len(tree[0].Children) != 1 || tree[0].Children[0].Task.TitleFR != "Épluchage" {
+		t.Fatalf("task structure not copied: %+v", tree)
+	}
+
+	// The last occurrence gets a signup, so its tasks must survive propagation.
+	last := occs[2]
+	lastTasks, _ := ListTasks(app.DB, last.ID)
+	RegisterForTask(app.DB, lastTasks[0].ID, "Alice", "Dupont", "alice@test.com", "0601", "fr")
+
+	e.TitleFR = "Atelier du samedi"
+	UpdateEvent(app.DB, e)
+	seedTask(t, app.DB, e.ID, "Rangement", nil)
+	postForm(mux, "/admin/series/propagate?lang=en", url.Values{"event_id": {fmt.Sprint(e.ID)}}, adminCookie(app))
+
+	for _, occ := range occs[1:] {
+		got, _ := GetEvent(app.DB, occ.ID)
+		if got.TitleFR != "Atelier du samedi" || got.EventDate != occ.EventDate || got.Slug != occ.Slug {
+			t.Errorf("occurrence %s after propagate = %+v", occ.EventDate, got)
+		}
+	}
+	if tasks, _ := ListTasks(app.DB, occs[1].ID); len(tasks) != 3 {
+		t.Errorf("second occurrence has %d tasks, want 3", len(tasks))
+	}
+	if tasks, _ := ListTasks(app.DB, last.ID); len(tasks) != 2 || CountRegistrations(app.DB, last.ID) != 1 {
+		t.Errorf("occurrence with signups was rewritten: %d tasks, %d registrations", len(tasks), CountRegistrations(app.DB, last.ID))
+	}
+
+	w := getRequest(mux, fmt.Sprintf("/admin/series?id=%d&lang=fr", e.SeriesID.Int64), adminCookie(app))
+	if !strings.Contains(w.Body.String(), "/e/"+last.Slug) {
+		t.Error("series page does not list the occurrences")
+	}
+	if w := getRequest(mux, "/admin?tab=past&lang=fr", adminCookie(app)); !strings.Contains(w.Body.String(), "/admin/series?id=") {
+		t.Error("events list does not link to the series")
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+// Admin settings page: an SMTP relay stored in the database, taking precedence
+// over the EVENT_SIGNUP_SMTP_* / SES environment configuration, with a
+// "send test email" button; the token of the organizers' calendar feed; and
+// the list of logged-in admin browsers.
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// validateSMTPSettings checks settings about to be saved and returns the i18n
+// key of the first problem, or "" when they are valid. All-empty settings are
+// valid: they hand sending back to the environment configuration.
+func validateSMTPSettings(s SMTPSettings) string {
+	if s == (SMTPSettings{}) {
+		return ""
+	}
+	if s.Host == "" || strings.ContainsAny(s.Host, " /:") {
+		return "settings_error_host"
+	}
+	if n, err := strconv.Atoi(s.Port); err != nil || n < 1 || n > 65535 {
+		return "settings_error_port"
+	}
+	if addr, err := mail.ParseAddress(s.From); err != nil || addr.Address != s.From {
+		return "settings_error_from"
+	}
+	if s.Password != "" && s.Username == "" {
+		return "settings_error_auth"
+	}
+	return ""
+}
+
+func (app *App) handleAdminSettings(w http.ResponseWriter, r *http.Request) {
+	lang := LangFromRequest(r)
+	current, err := GetSMTPSettings(app.DB)
+	if err != nil {
+		log.Printf("settings: load: %v", err)
+	}
+
+	if r.Method == http.MethodPost {
+		s := SMTPSettings{
+			Host:     strings.TrimSpace(r.FormValue("smtp_host")),
+			Port:     strings.TrimSpace(r.FormValue("smtp_port")),
+			Username: strings.TrimSpace(r.FormValue("smtp_username")),
+			Password: r.FormValue("smtp_password"),
+			From:     strings.TrimSpace(r.FormValue("smtp_from")),
+			FromName: strings.TrimSpace(r.FormValue("smtp_from_name")),
+		}
+		// The password is never echoed back; leaving it blank keeps the saved
+		// one unless the relay is being removed altogether.
+		if s.Password == "" && s.Host != "" && s.Username != "" {
+			s.Password = current.Password
+		}
+		if s.Host != "" && s.Port == "" {
+			s.Port = "587"
+		}
+		if errKey := validateSMTPSettings(s); errKey != "" {
+			pd := app.newPageData(r, app.settingsData(r, s, current.Password != ""))
+			pd.Error = T(errKey, lang)
+			app.render(w, r, "admin_settings.html", pd)
+			return
+		}
+		if err := SaveSMTPSettings(app.DB, s); err != nil {
+			log.Printf("settings: save: %v", err)
+			setFlash(w, "error", T("error_server", lang))
+		} else {
+			setFlash(w, "success", T("settings_saved", lang))
+		}
+		http.Redirect(w, r, "/admin/settings?lang="+lang, http.StatusSeeOther)
+		return
+	}
+
+	pd := app.newPageData(r, app.settingsData(r, current, current.Password != ""))
+	pd.Success, pd.Error = takeFlash(w, r)
+	app.render(w, r, "admin_settings.html", pd)
+}
+
+func (app *App) settingsData(r *http.Request, s SMTPSettings, hasPassword bool) map[string]any {
+	data := map[string]any{"Settings": s, "HasPassword": hasPassword, "LoginEmails": GetSetting(app.DB, "admin_emails")}
+	if tag, err := app.passwordTag(); err == nil {
+		sessions, err := ListSessions(app.DB, tag)
+		if err != nil {
+			log.Printf("settings: sessions: %v", err)
+		}
+		data["Sessions"] = sessions
+		current, _ := app.adminSession(r)
+		data["CurrentSession"] = current.ID
+	}
+	tokens, err := ListAPITokens(app.DB)
+	if err != nil {
+		log.Printf("settings: api tokens: %v", err)
+	}
+	data["APITokens"] = tokens
+	data["SignupMaxPerHour"], data["SignupMaxEmails"] = signupLimits(app)
+	data["SignupCheckMX"] = checkMailDomains(app)
+	data["RetentionMonths"] = instanceRetentionMonths(app.DB)
+	instance := currentInstance()
+	data["HasAIKey"], data["EnvAIKey"] = instance.AnthropicKey != "", app.AnthropicKey != ""
+	instance.AnthropicKey = ""
+	data["Instance"] = instance
+	data["DefaultTitle"] = T("app_title", LangFromRequest(r))
+	data["DefaultThemeColor"] = defaultThemeColor
+	if token := GetSetting(app.DB, "feed_token"); token != "" {
+		data["FeedURL"] = fmt.Sprintf("%s/admin/feed.ics?token=%s&lang=%s", app.baseURLFor(r), token, LangFromRequest(r))
+	}
+	return data
+}
+
+// handleAdminSettingsFeed turns the calendar feed on (or regenerates its
+// token, revoking the old URL) or off.
+func (app *App) handleAdminSettingsFeed(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/admin/settings", http.StatusSeeOther)
+		return
+	}
+	lang := LangFromRequest(r)
+	token, msg := GenerateToken(), "feed_enabled"
+	if r.FormValue("action") == "disable" {
+		token, msg = "", "feed_disabled"
+	}
+	if err := SetSetting(app.DB, "feed_token", token); err != nil {
+		log.Printf("settings: feed token: %v", err)
+		setFlash(w, "error", T("error_server", lang))
+	} else {
+		setFlash(w, "success", T(msg, lang))
+	}
+	http.Redirect(w, r, "/admin/settings?lang="+lang, http.StatusSeeOther)
+}
+
+// handleAdminSettingsTest sends a test email right away — bypassing the
+// outbox, so a misconfigured relay is reported on the page instead of being
+// retried in the background.
+func (app *App) handleAdminSettingsTest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/admin/settings", http.StatusSeeOther)
+		return
+	}
+	lang := LangFromRequest(r)
+	to := strings.TrimSpace(r.FormValue("to"))
+	if addr, err := mail.ParseAddress(to); err != nil || addr.Address != to {
+		setFlash(w, "error", T("settings_error_test_to", lang))
+		http.Redirect(w, r, "/admin/settings?lang="+lang, http.StatusSeeOther)
+		return
+	}
+	cfg, err := GetSMTPSettings(app.DB)
+	if err != nil {
+		log.Printf("settings: load: %v", err)
+	}
+	subject, htmlBody := renderSMTPTestEmail(lang, cfg, app.baseURLFor(r))
+	ctx, cancel := context.WithTimeout(r.Context(), 45*time.Second)
+	defer cancel()
+	if htmlBody == "" {
+		setFlash(w, "error", T("error_server", lang))
+	} else if _, err := app.Email.Send(ctx, to, "", subject, htmlBody); err != nil {
+		log.Printf("settings: test email to %s: %v", to, err)
+		setFlash(w, "error", fmt.Sprintf(T("settings_test_failed", lang), err))
+	} else {
+		setFlash(w, "success", fmt.Sprintf(T("settings_test_sent", lang), to))
+	}
+	http.Redirect(w, r, "/admin/settings?lang="+lang, http.StatusSeeOther)
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestValidateSMTPSettings(t *testing.T) {
+	ok := SMTPSettings{Host: "smtp.example.org", Port: "587", Username: "u", Password: "p", From: "events@example.org"}
+	cases := []struct {
+		name string
+		edit func(*SMTPSettings)
+		want string
+	}{
+		{"valid", func(s *SMTPSettings) {}, ""},
+		{"all empty", func(s *SMTPSettings) { *s = SMTPSettings{} }, ""},
+		{"no host", func(s *SMTPSettings) { s.Host = "" }, "settings_error_host"},
+		{"scheme in host", func(s *SMTPSettings) { s.Host = "smtp://smtp.example.org" }, "settings_error_host"},
+		{"bad port", func(s *SMTPSettings) { s.Port = "70000" }, "settings_error_port"},
+		{"bad from", func(s *SMTPSettings) { s.From = "not an address" }, "settings_error_from"},
+		{"display name in from", func(s *SMTPSettings) { s.From = "Events <events@example.org>" }, "settings_error_from"},
+		{"password without user", func(s *SMTPSettings) { s.Username = "" }, "settings_error_auth"},
+	}
+	for _, c := range cases {
+		s := ok
+		c.edit(&s)
+		if got := validateSMTPSettings(s); got != c.want {
+			t.Errorf("%s: got %q, want %q", c.name, got, c.want)
+		}
+	}
+}
+
+func TestAdminSettingsSaveKeepsPassword(t *testing.T) {
+	app := testApp(t)
+	mux := newMux(app)
+	form := url.Values{
+		"smtp_host":     {"smtp.example.org"},
+		"smtp_username": {"user"},
+		"smtp_password": {"secret"},
+		"smtp_from":     {"events@example.org"},
+	}
+	w := postForm(mux, "/admin/settings?lang=en", form, adminCookie(app))
+	if w.Code != http.StatusSeeOther {
+		t.Fatalf("save: status %d", w.Code)
+	}
+	s, _ := GetSMTPSettings(app.DB)
+	if s.Port != "587" || s.Password != "secret" {
+		t.Fatalf("saved settings = %+v", s)
+	}
+
+	w = getRequest(mux, "/admin/settings?lang=en", adminCookie(app))
+	if strings.Contains(w.Body.String(), "secret") {
+		t.Error("settings page echoes the password")
+	}
+
+	form.Set("smtp_password", "")
+	form.Set("smtp_from_name", "Events")
+	postForm(mux, "/admin/settings?lang=en", form, adminCookie(app))
+	if s, _ := GetSMTPSettings(app.DB); s.Password != "secret" || s.FromName != "Events" {
+		t.Errorf("blank password should keep the saved one: %+v", s)
+	}
+
+	form.Set("smtp_port", "abc")
+	w = postForm(mux, "/admin/settings?lang=en", form, adminCookie(app))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), T("settings_error_port", "en")) {
+		t.Errorf("invalid port: status %d, body lacks error", w.Code)
+	}
+	if s, _ := GetSMTPSettings(app.DB); s.Port != "587" {
+		t.Errorf("invalid settings were saved: %+v", s)
+	}
+}
+
+func TestSettingsSenderUsesSavedRelay(t *testing.T) {
+	app := testApp(t)
+	fake := app.Email.(*fakeEmailSender)
+	app.Email = &SettingsSender{DB: app.DB, Fallback: fake}
+	mux := newMux(app)
+
+	// Nothing saved: the environment sender is used.
+	postForm(mux, "/admin/settings/test?lang=en", url.Values{"to": {"admin@example.org"}}, adminCookie(app))
+	if fake.count() != 1 {
+		t.Fatalf("expected fallback sender to be used, got %d sent", fake.count())
+	}
+
+	addr, got := fakeSMTPServer(t)
+	host, port, _ := net.SplitHostPort(addr)
+	SaveSMTPSettings(app.DB, SMTPSettings{Host: host, Port: port, From: "events@example.org"})
+	w := postForm(mux, "/admin/settings/test?lang=en", url.Values{"to": {"admin@example.org"}}, adminCookie(app))
+	lines := <-got
+	if len(lines) != 3 || lines[0] != "MAIL FROM:<events@example.org>" || lines[1] != "RCPT TO:<admin@example.org>" {
+		t.Fatalf("relay saw %q", lines)
+	}
+	if fake.count() != 1 {
+		t.Errorf("fallback sender used despite saved relay")
+	}
+	if w.Code != http.StatusSeeOther {
+		t.Errorf("test send: status %d", w.Code)
+	}
+}
+
+func TestInstanceSettings(t *testing.T) {
+	app := testApp(t)
+	app.AnthropicKey = "env-key"
+	e := seedEvent(t, app.DB)
+	mux := newMux(app)
+	admin := adminCookie(app)
+	form := url.Values{
+		"site_title":        {"Village Fair"},
+		"default_lang":      {"en"},
+		"base_url":          {"https://events.example.org/"},
+		"anthropic_api_key": {"saved-key"},
+		"theme_color":       {"#10B981"},
+	}
+	if w := postForm(mux, "/admin/settings/instance?lang=en", form, admin); w.Code != http.StatusSeeOther {
+		t.Fatalf("save: status %d", w.Code)
+	}
+	if s, _ := GetInstanceSettings(app.DB); s.BaseURL != "https://events.example.org" {
+		t.Errorf("saved settings = %+v", s)
+	}
+
+	body := getRequest(mux, "/e/"+e.Slug).Body.String()
+	if !strings.Contains(body, "<title>Village Fair</title>") || !strings.Contains(body, `<html lang="en">`) {
+		t.Error("event page ignores the site title or default language")
+	}
+	if !strings.Contains(body, "--color-primary: #10B981") || !strings.Contains(body, `content="#10B981"`) {
+		t.Error("event page ignores the theme color")
+	}
+	r := httptest.NewRequest("GET", "http://localhost:8090/", nil)
+	if got := app.baseURLFor(r); got != "https://events.example.org" {
+		t.Errorf("baseURLFor = %q", got)
+	}
+	if app.anthropicKey() != "saved-key" {
+		t.Errorf("anthropicKey = %q, want the saved one", app.anthropicKey())
+	}
+	if strings.Contains(getRequest(mux, "/admin/settings?lang=en", admin).Body.String(), "saved-key") {
+		t.Error("settings page echoes the API key")
+	}
+
+	// A blank key keeps the saved one unless removed; the color picker's
+	// default means no override.
+	form.Set("anthropic_api_key", "")
+	form.Set("theme_color", defaultThemeColor)
+	postForm(mux, "/admin/settings/instance?lang=en", form, admin)
+	if app.anthropicKey() != "saved-key" || themeColors() != nil {
+		t.Errorf("after resave: key %q, colors %+v", app.anthropicKey(), themeColors())
+	}
+	form.Set("anthropic_api_key_clear", "1")
+	postForm(mux, "/admin/settings/instance?lang=en", form, admin)
+	if app.anthropicKey() != "env-key" {
+		t.Errorf("removed key: anthropicKey = %q, want the environment's", app.anthropicKey())
+	}
+
+	form.Set("base_url", "events.example.org/signup")
+	w := postForm(mux, "/admin/settings/instance?lang=en", form, admin)
+	if got := followRedirect(mux, w, admin).Body.String(); !strings.Contains(got, T("instance_error_base_url", "en")) {
+		t.Error("invalid base URL not reported")
+	}
+	if currentInstance().BaseURL != "https://events.example.org" {
+		t.Error("invalid settings were saved")
+	}
+}
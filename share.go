@@ -0,0 +1,53 @@
+package main
+
+// Share buttons. An event's public page, and its admin page, carry links
+// sharing it on WhatsApp, by email or on Facebook, and a button copying a
+// ready-made message — the title, the date and the link — for any other
+// app. The message is in the page's language; the admin page has one per
+// language of the event, since its organizers share to both audiences.
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Share is how to share an event in one language.
+type Share struct {
+	Lang     string
+	Text     string // the message, ending with the link
+	URL      string
+	WhatsApp string
+	Email    string // a mailto: link
+	Facebook string
+}
+
+// eventShare returns the share links of event in lang.
+func (app *App) eventShare(r *http.Request, event *Event, lang string) *Share {
+	og := app.eventOpenGraph(r, event, lang)
+	// The preview image's alt text is the event's date and time.
+	text := fmt.Sprintf(T("share_text", lang), og.Title, og.ImageAlt, og.URL)
+	return &Share{
+		Lang:     lang,
+		Text:     text,
+		URL:      og.URL,
+		WhatsApp: "https://wa.me/?text=" + url.QueryEscape(text),
+		Email:    "mailto:?subject=" + mailtoEscape(og.Title) + "&body=" + mailtoEscape(text),
+		Facebook: "https://www.facebook.com/sharer/sharer.php?u=" + url.QueryEscape(og.URL),
+	}
+}
+
+// eventShares returns the share links of event in each of its languages.
+func (app *App) eventShares(r *http.Request, event *Event) []*Share {
+	if event.Lang != "" {
+		return []*Share{app.eventShare(r, event, event.Lang)}
+	}
+	return []*Share{app.eventShare(r, event, LangFR), app.eventShare(r, event, LangEN)}
+}
+
+// mailtoEscape escapes s for a mailto: header, where mail clients read "+"
+// as itself rather than as a space.
+func mailtoEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestEventShare(t *testing.T) {
+	app := testApp(t)
+	mux := newMux(app)
+	e := seedEvent(t, app.DB)
+	e.TitleFR, e.EventTime = "Fête & kermesse", "14:30"
+	if err := UpdateEvent(app.DB, e); err != nil {
+		t.Fatal(err)
+	}
+
+	s := app.eventShare(httptest.NewRequest("GET", "/e/"+e.Slug, nil), e, LangFR)
+	want := "Fête & kermesse, le lundi 15 juin 2026, 14h30. Infos et inscription : http://example.com/e/" + e.Slug + "?lang=fr"
+	if s.Text != want {
+		t.Errorf("text = %q, want %q", s.Text, want)
+	}
+	if !strings.HasPrefix(s.Email, "mailto:?subject=F%C3%AAte%20%26%20kermesse&body=") || strings.Contains(s.Email, "+") {
+		t.Errorf("mailto = %q", s.Email)
+	}
+	if !strings.Contains(s.WhatsApp, "kermesse%2C+le+lundi") || !strings.HasSuffix(s.Facebook, "%3Flang%3Dfr") {
+		t.Errorf("links = %q, %q", s.WhatsApp, s.Facebook)
+	}
+
+	page := getRequest(mux, "/e/"+e.Slug+"?lang=en").Body.String()
+	for _, want := range []string{`class="share-links"`, `href="https://wa.me/?text=`, "Copy the message", "Details and sign-up"} {
+		if !strings.Contains(page, want) {
+			t.Errorf("event page misses %q", want)
+		}
+	}
+
+	// The admin page shares in each of the event's languages.
+	admin := getRequest(mux, fmt.Sprintf("/admin/event/edit?id=%d&lang=en", e.ID), adminCookie(app)).Body.String()
+	if !strings.Contains(admin, "In French") || !strings.Contains(admin, "In English") {
+		t.Error("admin page misses a language's share links")
+	}
+	e.Lang = LangFR
+	UpdateEvent(app.DB, e)
+	admin = getRequest(mux, fmt.Sprintf("/admin/event/edit?id=%d&lang=en", e.ID), adminCookie(app)).Body.String()
+	if !strings.Contains(admin, "In French") || strings.Contains(admin, "In English") {
+		t.Error("French-only event shared in English")
+	}
+}
@@ -0,0 +1,531 @@
+package main
+
+// Deterministic spreadsheet import: the admin uploads last year's task sheet
+// (XLSX or CSV), maps its columns in a preview step, and the rows are merged
+// into the event's group/task tree. A non-AI alternative to ai.go.
+
+import (
+	"archive/zip"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Spreadsheet limits keep the preview page and the hidden rows payload small.
+const (
+	sheetMaxRows = 2000
+	sheetMaxCols = 50
+)
+
+var errSheetEmpty = errors.New("spreadsheet: no rows")
+
+// ---- XLSX reading ----
+
+type xlsxText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+// String joins a plain or rich-text string item.
+func (x xlsxText) String() string {
+	if len(x.Runs) == 0 {
+		return x.T
+	}
+	var b strings.Builder
+	for _, r := range x.Runs {
+		b.WriteString(r.T)
+	}
+	return b.String()
+}
+
+type xlsxWorksheet struct {
+	Rows []struct {
+		R     int `xml:"r,attr"`
+		Cells []struct {
+			Ref    string   `xml:"r,attr"`
+			Type   string   `xml:"t,attr"`
+			Value  string   `xml:"v"`
+			Inline xlsxText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// readXLSX returns the cell text of the workbook's first worksheet as rows
+// of strings. Only what an import needs is supported: shared, inline and
+// plain values; styles, formulas (their cached value is used) and dates
+// (left as serial numbers) are not interpreted.
+func readXLSX(data []byte) ([][]string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("open xlsx: %w", err)
+	}
+	files := map[string]*zip.File{}
+	for _, f := range zr.File {
+		files[strings.TrimPrefix(f.Name, "/")] = f
+	}
+	decode := func(name string, v any) error {
+		f, ok := files[name]
+		if !ok {
+			return fmt.Errorf("xlsx: missing %s", name)
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		return xml.NewDecoder(io.LimitReader(rc, 64<<20)).Decode(v)
+	}
+
+	var shared struct {
+		Items []xlsxText `xml:"si"`
+	}
+	if _, ok := files["xl/sharedStrings.xml"]; ok {
+		if err := decode("xl/sharedStrings.xml", &shared); err != nil {
+			return nil, fmt.Errorf("xlsx shared strings: %w", err)
+		}
+	}
+
+	sheetPath, err := xlsxFirstSheetPath(decode)
+	if err != nil {
+		return nil, err
+	}
+	var ws xlsxWorksheet
+	if err := decode(sheetPath, &ws); err != nil {
+		return nil, fmt.Errorf("xlsx worksheet: %w", err)
+	}
+
+	var rows [][]string
+	for i, row := range ws.Rows {
+		idx := row.R - 1
+		if row.R == 0 {
+			idx = max(i, len(rows))
+		}
+		if idx >= sheetMaxRows {
+			break
+		}
+		for len(rows) <= idx {
+			rows = append(rows, nil)
+		}
+		for j, c := range row.Cells {
+			col := j
+			if c.Ref != "" {
+				col = xlsxColumn(c.Ref)
+			}
+			if col < 0 || col >= sheetMaxCols {
+				continue
+			}
+			var text string
+			switch c.Type {
+			case "s":
+				n, err := strconv.Atoi(strings.TrimSpace(c.Value))
+				if err == nil && n >= 0 && n < len(shared.Items) {
+					text = shared.Items[n].String()
+				}
+			case "inlineStr":
+				text = c.Inline.String()
+			default:
+				text = c.Value
+			}
+			for len(rows[idx]) <= col {
+				rows[idx] = append(rows[idx], "")
+			}
+			rows[idx][col] = strings.TrimSpace(text)
+		}
+	}
+	return rows, nil
+}
+
+// xlsxFirstSheetPath resolves the first <sheet> of the workbook to its part
+// name through the workbook relationships.
+func xlsxFirstSheetPath(decode func(string, any) error) (string, error) {
+	var wb struct {
+		Sheets []struct {
+			RID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := decode("xl/workbook.xml", &wb); err != nil {
+		return "", fmt.Errorf("xlsx workbook: %w", err)
+	}
+	if len(wb.Sheets) == 0 {
+		return "", errSheetEmpty
+	}
+	var rels struct {
+		Items []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := decode("xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return "", fmt.Errorf("xlsx relationships: %w", err)
+	}
+	for _, rel := range rels.Items {
+		if rel.ID == wb.Sheets[0].RID {
+			if strings.HasPrefix(rel.Target, "/") {
+				return strings.TrimPrefix(rel.Target, "/"), nil
+			}
+			return path.Join("xl", rel.Target), nil
+		}
+	}
+	return "", fmt.Errorf("xlsx: sheet relationship %q not found", wb.Sheets[0].RID)
+}
+
+// xlsxColumn turns a cell reference such as "AB12" into a 0-based column.
+func xlsxColumn(ref string) int {
+	col := 0
+	for _, c := range strings.ToUpper(ref) {
+		if c < 'A' || c > 'Z' {
+			break
+		}
+		col = col*26 + int(c-'A'+1)
+	}
+	return col - 1
+}
+
+// readSheetUpload reads an uploaded .xlsx, or anything else as CSV, and drops
+// fully blank rows.
+func readSheetUpload(filename string, r io.Reader) ([][]string, error) {
+	data, err := io.ReadAll(io.LimitReader(r, 10<<20))
+	if err != nil {
+		return nil, err
+	}
+	var rows [][]string
+	if strings.HasSuffix(strings.ToLower(filename), ".xlsx") {
+		rows, err = readXLSX(data)
+	} else {
+		rows, err = readCSVRecords(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return keepSheetRows(rows)
+}
+
+// readPastedSheet reads a table pasted in the import form: cells copied from
+// a spreadsheet come tab-separated, anything else is read as CSV.
+func readPastedSheet(text string) ([][]string, error) {
+	if !strings.Contains(text, "\t") {
+		rows, err := readCSVRecords(strings.NewReader(text))
+		if err != nil {
+			return nil, err
+		}
+		return keepSheetRows(rows)
+	}
+	var rows [][]string
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		rows = append(rows, strings.Split(strings.TrimSuffix(line, "\r"), "\t"))
+	}
+	return keepSheetRows(rows)
+}
+
+// keepSheetRows drops fully blank rows and cuts the sheet to its limits.
+func keepSheetRows(rows [][]string) ([][]string, error) {
+	var kept [][]string
+	for _, row := range rows {
+		if len(kept) == sheetMaxRows {
+			break
+		}
+		if len(row) > sheetMaxCols {
+			row = row[:sheetMaxCols]
+		}
+		for _, c := range row {
+			if strings.TrimSpace(c) != "" {
+				kept = append(kept, row)
+				break
+			}
+		}
+	}
+	if len(kept) == 0 {
+		return nil, errSheetEmpty
+	}
+	return kept, nil
+}
+
+// ---- Column mapping ----
+
+// sheetMapping holds 0-based column indexes; -1 means "not mapped".
+type sheetMapping struct {
+	Group, Subgroup, TaskFR, TaskEN, Description, MaxSlots int
+	HasHeader                                              bool
+}
+
+// guessSheetMapping pre-selects columns from header names, so the preview
+// step usually only needs a glance.
+func guessSheetMapping(header []string) sheetMapping {
+	m := sheetMapping{Group: -1, Subgroup: -1, TaskFR: -1, TaskEN: -1, Description: -1, MaxSlots: -1}
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(h))
+		set := func(field *int) {
+			if *field == -1 {
+				*field = i
+				m.HasHeader = true
+			}
+		}
+		has := func(words ...string) bool {
+			for _, w := range words {
+				if strings.Contains(h, w) {
+					return true
+				}
+			}
+			return false
+		}
+		isTask := has("task", "tâche", "tache", "poste", "mission")
+		isEN := strings.HasSuffix(h, " en") || has("(en)", "english", "anglais")
+		switch {
+		case has("sous-groupe", "subgroup", "sous-catégorie"):
+			set(&m.Subgroup)
+		case has("groupe", "group", "catégorie", "category", "pôle"):
+			set(&m.Group)
+		case has("desc"):
+			set(&m.Description)
+		case has("place", "slot", "nombre", "max") || h == "nb":
+			set(&m.MaxSlots)
+		case isTask && isEN:
+			set(&m.TaskEN)
+		case isTask:
+			set(&m.TaskFR)
+		}
+	}
+	if !m.HasHeader {
+		// Headerless sheet: assume the last non-numeric column of the first
+		// row names the task, with any columns before it as groups.
+		m.TaskFR = 0
+		for i, c := range header {
+			if _, err := strconv.Atoi(strings.TrimSpace(c)); err != nil && strings.TrimSpace(c) != "" {
+				m.TaskFR = i
+			}
+		}
+		if m.TaskFR > 0 {
+			m.Group = 0
+		}
+	}
+	return m
+}
+
+// sheetToAINodes merges spreadsheet rows into the existing tree, reusing the
+// AINode shape the AI importer applies. Groups and tasks are matched by
+// title (case-insensitive) at their level; blank group cells inherit the
+// previous row's value, as in sheets with merged cells. Matched tasks only
+// take the non-empty cells of their row.
+func sheetToAINodes(existing []AINode, rows [][]string, m sheetMapping) (nodes []AINode, created, updated int) {
+	cell := func(row []string, i int) string {
+		if i >= 0 && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+	find := func(list []AINode, typ, title string) int {
+		for i := range list {
+			if list[i].Type == typ && strings.EqualFold(strings.TrimSpace(list[i].TitleFR), title) {
+				return i
+			}
+		}
+		return -1
+	}
+	// child returns the group titled title inside *list, creating it.
+	child := func(list *[]AINode, title string) *[]AINode {
+		i := find(*list, "group", title)
+		if i == -1 {
+			*list = append(*list, AINode{Type: "group", TitleFR: title})
+			i = len(*list) - 1
+			created++
+		}
+		return &(*list)[i].Children
+	}
+
+	nodes = existing
+	if m.HasHeader && len(rows) > 0 {
+		rows = rows[1:]
+	}
+	var group, subgroup string
+	for _, row := range rows {
+		if g := cell(row, m.Group); g != "" {
+			if g != group {
+				subgroup = ""
+			}
+			group = g
+		}
+		if sg := cell(row, m.Subgroup); sg != "" {
+			subgroup = sg
+		}
+		title := cell(row, m.TaskFR)
+		if title == "" {
+			continue
+		}
+		list := &nodes
+		if group != "" {
+			list = child(list, group)
+			if subgroup != "" {
+				list = child(list, subgroup)
+			}
+		}
+
+		var task *AINode
+		if i := find(*list, "task", title); i >= 0 {
+			task = &(*list)[i]
+			updated++
+		} else {
+			*list = append(*list, AINode{Type: "task", TitleFR: title})
+			task = &(*list)[len(*list)-1]
+			created++
+		}
+		if en := cell(row, m.TaskEN); en != "" {
+			task.TitleEN = en
+		}
+		if desc := cell(row, m.Description); desc != "" {
+			task.DescriptionFR = desc
+		}
+		if n, err := strconv.ParseFloat(strings.ReplaceAll(cell(row, m.MaxSlots), ",", "."), 64); err == nil && n >= 1 {
+			slots := int64(n)
+			task.MaxSlots = &slots
+		}
+	}
+	return nodes, created, updated
+}
+
+// ---- Handlers ----
+
+// handleAdminSheetImport reads the uploaded spreadsheet, or the pasted table,
+// and renders the column-mapping preview. Nothing is written yet; the parsed
+// rows travel to the apply step in a hidden field.
+func (app *App) handleAdminSheetImport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/admin", http.StatusSeeOther)
+		return
+	}
+	lang := LangFromRequest(r)
+	eventID, _ := strconv.ParseInt(r.FormValue("event_id"), 10, 64)
+	event, err := GetEvent(app.DB, eventID)
+	if err != nil || event.EventType != "tasks" {
+		http.NotFound(w, r)
+		return
+	}
+	editURL := fmt.Sprintf("/admin/event/edit?id=%d&lang=%s", event.ID, lang)
+
+	var rows [][]string
+	if file, header, ferr := r.FormFile("file"); ferr == nil {
+		defer file.Close()
+		rows, err = readSheetUpload(header.Filename, file)
+	} else if pasted := r.FormValue("paste"); strings.TrimSpace(pasted) != "" {
+		rows, err = readPastedSheet(pasted)
+	} else {
+		setFlash(w, "error", T("sheet_import_no_file", lang))
+		http.Redirect(w, r, editURL, http.StatusSeeOther)
+		return
+	}
+	if err != nil {
+		log.Printf("sheet import read error: %v", err)
+		setFlash(w, "error", T("sheet_import_bad_file", lang))
+		http.Redirect(w, r, editURL, http.StatusSeeOther)
+		return
+	}
+
+	width := 0
+	for _, row := range rows {
+		width = max(width, len(row))
+	}
+	columns := make([]string, width)
+	for i := range columns {
+		columns[i] = xlsxColumnName(i)
+		if i < len(rows[0]) && rows[0][i] != "" {
+			columns[i] += " — " + rows[0][i]
+		}
+	}
+	payload, _ := json.Marshal(rows)
+	preview := rows[:min(len(rows), 10)]
+
+	pd := app.newPageData(r, map[string]any{
+		"Event":   event,
+		"Rows":    string(payload),
+		"Preview": preview,
+		"Width":   width,
+		"Columns": columns,
+		"Mapping": guessSheetMapping(rows[0]),
+		"Total":   len(rows),
+	})
+	app.render(w, r, "admin_sheet_import.html", pd)
+}
+
+// handleAdminSheetImportApply merges the mapped rows into the event tree.
+// Existing groups and tasks are never deleted.
+func (app *App) handleAdminSheetImportApply(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/admin", http.StatusSeeOther)
+		return
+	}
+	lang := LangFromRequest(r)
+	eventID, _ := strconv.ParseInt(r.FormValue("event_id"), 10, 64)
+	event, err := GetEvent(app.DB, eventID)
+	if err != nil || event.EventType != "tasks" {
+		http.NotFound(w, r)
+		return
+	}
+	editURL := fmt.Sprintf("/admin/event/edit?id=%d&lang=%s", event.ID, lang)
+
+	var rows [][]string
+	if err := json.Unmarshal([]byte(r.FormValue("rows")), &rows); err != nil || len(rows) == 0 {
+		setFlash(w, "error", T("sheet_import_bad_file", lang))
+		http.Redirect(w, r, editURL, http.StatusSeeOther)
+		return
+	}
+	col := func(name string) int {
+		n, err := strconv.Atoi(r.FormValue(name))
+		if err != nil {
+			return -1
+		}
+		return n
+	}
+	m := sheetMapping{
+		Group:       col("col_group"),
+		Subgroup:    col("col_subgroup"),
+		TaskFR:      col("col_task_fr"),
+		TaskEN:      col("col_task_en"),
+		Description: col("col_description"),
+		MaxSlots:    col("col_max_slots"),
+		HasHeader:   r.FormValue("has_header") != "",
+	}
+	if m.TaskFR < 0 {
+		setFlash(w, "error", T("sheet_import_no_task_col", lang))
+		http.Redirect(w, r, editURL, http.StatusSeeOther)
+		return
+	}
+
+	tree, err := BuildEventTree(app.DB, event.ID)
+	if err != nil {
+		log.Printf("sheet import tree error: %v", err)
+		setFlash(w, "error", T("error_server", lang))
+		http.Redirect(w, r, editURL, http.StatusSeeOther)
+		return
+	}
+	nodes, created, updated := sheetToAINodes(treeToAINodes(tree), rows, m)
+	app.snapshotStructure(event.ID, "before_import")
+	pos := 0
+	if err := applyAINodes(app.DB, event.ID, nodes, sql.NullInt64{}, &pos); err != nil {
+		log.Printf("sheet import apply error: %v", err)
+		setFlash(w, "error", T("error_server", lang))
+		http.Redirect(w, r, editURL, http.StatusSeeOther)
+		return
+	}
+	app.snapshotStructure(event.ID, "import")
+	setFlash(w, "success", fmt.Sprintf(T("sheet_import_done", lang), created, updated))
+	http.Redirect(w, r, editURL, http.StatusSeeOther)
+}
+
+// xlsxColumnName is the inverse of xlsxColumn: 0 → "A", 27 → "AB".
+func xlsxColumnName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
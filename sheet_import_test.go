@@ -0,0 +1,184 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// buildXLSX writes a minimal workbook: shared strings for text, plain
+// values for numbers, and one inline string to cover both cell kinds.
+func buildXLSX(t *testing.T, rows [][]string) []byte {
+	t.Helper()
+	var shared []string
+	index := map[string]int{}
+	var sheet strings.Builder
+	sheet.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for r, row := range rows {
+		fmt.Fprintf(&sheet, `<row r="%d">`, r+1)
+		for c, v := range row {
+			if v == "" {
+				continue
+			}
+			ref := fmt.Sprintf("%s%d", xlsxColumnName(c), r+1)
+			switch {
+			case strings.Trim(v, "0123456789") == "":
+				fmt.Fprintf(&sheet, `<c r="%s"><v>%s</v></c>`, ref, v)
+			case strings.HasPrefix(v, "inline:"):
+				fmt.Fprintf(&sheet, `<c r="%s" t="inlineStr"><is><t>%s</t></is></c>`, ref, strings.TrimPrefix(v, "inline:"))
+			default:
+				i, ok := index[v]
+				if !ok {
+					i = len(shared)
+					index[v] = i
+					shared = append(shared, v)
+				}
+				fmt.Fprintf(&sheet, `<c r="%s" t="s"><v>%d</v></c>`, ref, i)
+			}
+		}
+		sheet.WriteString(`</row>`)
+	}
+	sheet.WriteString(`</sheetData></worksheet>`)
+
+	var sst strings.Builder
+	sst.WriteString(`<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	for _, s := range shared {
+		fmt.Fprintf(&sst, `<si><t>%s</t></si>`, s)
+	}
+	sst.WriteString(`</sst>`)
+
+	parts := map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="Tâches" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`,
+		"xl/worksheets/sheet1.xml": sheet.String(),
+		"xl/sharedStrings.xml":     sst.String(),
+	}
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, body := range parts {
+		f, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write([]byte(body))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+var sheetRows = [][]string{
+	{"Groupe", "Tâche", "Places", "Description"},
+	{"Cuisine", "Épluchage", "3", "Dès 9h"},
+	{"", "inline:Vaisselle", "2", ""},
+	{"Accueil", "Parking", "", ""},
+}
+
+func TestReadXLSX(t *testing.T) {
+	rows, err := readXLSX(buildXLSX(t, sheetRows))
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if len(rows) != 4 {
+		t.Fatalf("expected 4 rows, got %d", len(rows))
+	}
+	if rows[1][1] != "Épluchage" || rows[1][2] != "3" || rows[2][1] != "Vaisselle" || rows[2][0] != "" {
+		t.Errorf("rows = %q", rows)
+	}
+}
+
+func TestGuessSheetMapping(t *testing.T) {
+	m := guessSheetMapping(sheetRows[0])
+	want := sheetMapping{Group: 0, Subgroup: -1, TaskFR: 1, TaskEN: -1, Description: 3, MaxSlots: 2, HasHeader: true}
+	if m != want {
+		t.Errorf("mapping = %+v, want %+v", m, want)
+	}
+}
+
+func TestSheetImportMergesIntoTree(t *testing.T) {
+	app := testApp(t)
+	e := seedEvent(t, app.DB)
+	// An existing group and task the sheet should merge into, not duplicate.
+	g := &TaskGroup{EventID: e.ID, TitleFR: "Cuisine"}
+	CreateTaskGroup(app.DB, g)
+	CreateTask(app.DB, &Task{EventID: e.ID, GroupID: sql.NullInt64{Int64: g.ID, Valid: true}, TitleFR: "Épluchage"})
+	mux := newMux(app)
+
+	w := postMultipart(mux, "/admin/event/sheet-import?lang=fr", "taches.xlsx", string(buildXLSX(t, sheetRows)),
+		map[string]string{"event_id": fmt.Sprint(e.ID)}, adminCookie(app))
+	if w.Code != 200 {
+		t.Fatalf("preview status = %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "Vaisselle") {
+		t.Error("preview should list the sheet rows")
+	}
+
+	payload, _ := json.Marshal(sheetRows)
+	w = postForm(mux, "/admin/event/sheet-import/apply?lang=fr", url.Values{
+		"event_id":        {fmt.Sprint(e.ID)},
+		"rows":            {string(payload)},
+		"has_header":      {"1"},
+		"col_group":       {"0"},
+		"col_subgroup":    {"-1"},
+		"col_task_fr":     {"1"},
+		"col_task_en":     {"-1"},
+		"col_description": {"3"},
+		"col_max_slots":   {"2"},
+	}, adminCookie(app))
+	if w.Code != 303 {
+		t.Fatalf("apply status = %d", w.Code)
+	}
+
+	groups, _ := ListTaskGroups(app.DB, e.ID)
+	tasks, _ := ListTasks(app.DB, e.ID)
+	if len(groups) != 2 || len(tasks) != 3 {
+		t.Fatalf("got %d groups, %d tasks; want 2 and 3", len(groups), len(tasks))
+	}
+	for _, tk := range tasks {
+		switch tk.TitleFR {
+		case "Épluchage":
+			if tk.MaxSlots.Int64 != 3 || tk.DescriptionFR != "Dès 9h" || tk.GroupID.Int64 != g.ID {
+				t.Errorf("merged task = %+v", tk)
+			}
+		case "Vaisselle":
+			if tk.GroupID.Int64 != g.ID {
+				t.Error("blank group cell should inherit the previous row's group")
+			}
+		case "Parking":
+			if tk.MaxSlots.Valid {
+				t.Error("empty slots cell should leave the task unlimited")
+			}
+		}
+	}
+}
+
+func TestReadPastedSheet(t *testing.T) {
+	rows, err := readPastedSheet("Groupe\tTâche\tPlaces\r\nCuisine\tÉpluchage\t3\r\n\t\t\r\n\tVaisselle\t\r\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 || rows[1][1] != "Épluchage" || rows[2][0] != "" || rows[2][1] != "Vaisselle" {
+		t.Errorf("tab-separated rows = %q", rows)
+	}
+	rows, err = readPastedSheet("Groupe;Tâche\nAccueil;\"Billets; entrées\"\n")
+	if err != nil || len(rows) != 2 || rows[1][1] != "Billets; entrées" {
+		t.Errorf("CSV rows = %q, %v", rows, err)
+	}
+
+	app := testApp(t)
+	e := seedEvent(t, app.DB)
+	w := postForm(newMux(app), "/admin/event/sheet-import?lang=fr", url.Values{
+		"event_id": {fmt.Sprint(e.ID)}, "paste": {"Groupe\tTâche\nCuisine\tVaisselle\n"},
+	}, adminCookie(app))
+	if w.Code != 200 || !strings.Contains(w.Body.String(), "Vaisselle") {
+		t.Errorf("pasted table preview: status %d", w.Code)
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+// Short links. Every event gets a short address, /s/<code>, redirecting to
+// its public page — slugs are too long to copy off a paper notice board.
+// The code is drawn when the admin first opens the event and can be changed
+// to something easier to read out; former codes keep leading to the event.
+// Visits are counted, those through the QR code (which carries ?qr) apart
+// from the others, and shown on the admin event page.
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ShortLink is an event's short address and how often it was followed.
+type ShortLink struct {
+	Code    string
+	EventID int64
+	Clicks  int
+	Scans   int
+}
+
+// shortCodeAlphabet leaves out characters easily mistaken for one another
+// on paper: 0/o, 1/l/i.
+const (
+	shortCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+	shortCodeLen      = 5
+)
+
+var shortCodeRe = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,31}$`)
+
+var errShortCodeTaken = errors.New("short code already in use")
+
+// normalizeShortCode returns the code an admin typed in as stored, "" when
+// it can't be one.
+func normalizeShortCode(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if !shortCodeRe.MatchString(s) {
+		return ""
+	}
+	return s
+}
+
+// newShortCode draws a random code.
+func newShortCode() string {
+	b := make([]byte, shortCodeLen)
+	rand.Read(b)
+	for i := range b {
+		b[i] = shortCodeAlphabet[int(b[i])%len(shortCodeAlphabet)]
+	}
+	return string(b)
+}
+
+// GetShortLink returns event eventID's short link, sql.ErrNoRows when it
+// has none yet.
+func GetShortLink(db *sql.DB, eventID int64) (*ShortLink, error) {
+	l := &ShortLink{}
+	err := db.QueryRow("SELECT code, event_id, clicks, scans FROM short_links WHERE event_id=?", eventID).
+		Scan(&l.Code, &l.EventID, &l.Clicks, &l.Scans)
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// EnsureShortLink returns event eventID's short link, drawing its code the
+// first time.
+func EnsureShortLink(db *sql.DB, eventID int64) (*ShortLink, error) {
+	l, err := GetShortLink(db, eventID)
+	if err != sql.ErrNoRows {
+		return l, err
+	}
+	for range 10 {
+		code := newShortCode()
+		_, err = db.Exec(`INSERT OR IGNORE INTO short_links (code, event_id)
+			SELECT ?1, ?2 WHERE NOT EXISTS (SELECT 1 FROM short_link_aliases WHERE code=?1)`, code, eventID)
+		if err != nil {
+			return nil, err
+		}
+		if l, err = GetShortLink(db, eventID); err != sql.ErrNoRows {
+			return l, err
+		}
+	}
+	return nil, errors.New("no free short code")
+}
+
+// ChangeShortCode gives event eventID the short code code, resetting its
+// counters; the former code stays an alias of it. It returns
+// errShortCodeTaken when another event uses code, as its code or an alias.
+func ChangeShortCode(db *sql.DB, eventID int64, code string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	var owner int64
+	var current bool
+	switch err := tx.QueryRow(`SELECT event_id, 1 FROM short_links WHERE code=?1
+		UNION ALL SELECT event_id, 0 FROM short_link_aliases WHERE code=?1`, code).Scan(&owner, &current); {
+	case err == nil && owner != eventID:
+		return errShortCodeTaken
+	case err == nil && current:
+		return nil
+	case err != nil && err != sql.ErrNoRows:
+		return err
+	}
+	for _, q := range []string{
+		"INSERT OR IGNORE INTO short_link_aliases (code, event_id) SELECT code, event_id FROM short_links WHERE event_id=?2",
+		"DELETE FROM short_link_aliases WHERE code=?1",
+		`INSERT INTO short_links (code, event_id) VALUES (?1, ?2)
+			ON CONFLICT(event_id) DO UPDATE SET code=excluded.code, clicks=0, scans=0`,
+	} {
+		if _, err := tx.Exec(q, code, eventID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetEventByShortCode returns the event whose short code, or one of whose
+// former codes, is code.
+func GetEventByShortCode(db *sql.DB, code string) (*Event, error) {
+	return scanEvent(db.QueryRow(
+		`SELECT `+eventCols+` FROM events WHERE id=(SELECT event_id FROM short_links WHERE code=?1
+			UNION ALL SELECT event_id FROM short_link_aliases WHERE code=?1) AND trash_id IS NULL`, code,
+	))
+}
+
+// shortLinkURL is the address of event's short link with code.
+func (app *App) shortLinkURL(r *http.Request, event *Event, code string) string {
+	return app.eventBaseURL(r, event) + "/s/" + code
+}
+
+// handleShortLink redirects /s/<code> to the event's page, keeping the
+// query but ?qr, and counts the visit on the event's short link, whichever
+// of its codes it came through. The redirect is temporary so that browsers
+// come back through it and every visit counts.
+func (app *App) handleShortLink(w http.ResponseWriter, r *http.Request) {
+	code := strings.ToLower(strings.Trim(strings.TrimPrefix(r.URL.Path, "/s/"), "/"))
+	event, err := GetEventByShortCode(app.DB, code)
+	if err != nil || app.hiddenDraft(r, event) {
+		http.NotFound(w, r)
+		return
+	}
+	query := r.URL.Query()
+	counter := "clicks"
+	if query.Has("qr") {
+		counter = "scans"
+		query.Del("qr")
+	}
+	if r.Method == http.MethodGet {
+		if _, err := app.DB.Exec("UPDATE short_links SET "+counter+"="+counter+"+1 WHERE event_id=?", event.ID); err != nil {
+			log.Printf("count visit of short link %s: %v", code, err)
+		}
+	}
+	target := "/e/" + event.Slug
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	http.Redirect(w, r, target, http.StatusFound)
+}
+
+func (app *App) handleAdminShortLink(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/admin", http.StatusSeeOther)
+		return
+	}
+	lang := LangFromRequest(r)
+	id, _ := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	event, err := GetEvent(app.DB, id)
+	if err != nil {
+		http.Redirect(w, r, "/admin", http.StatusSeeOther)
+		return
+	}
+	back := fmt.Sprintf("/admin/event/edit?id=%d&lang=%s", event.ID, lang)
+	code := normalizeShortCode(r.FormValue("code"))
+	if code == "" {
+		setFlash(w, "error", T("short_link_invalid", lang))
+		http.Redirect(w, r, back, http.StatusSeeOther)
+		return
+	}
+	switch err := ChangeShortCode(app.DB, event.ID, code); {
+	case errors.Is(err, errShortCodeTaken):
+		setFlash(w, "error", fmt.Sprintf(T("short_link_taken", lang), code))
+	case err != nil:
+		log.Printf("change short code of event %d: %v", event.ID, err)
+		setFlash(w, "error", T("error_server", lang))
+	default:
+		setFlash(w, "success", fmt.Sprintf(T("short_link_changed", lang), code))
+	}
+	http.Redirect(w, r, back, http.StatusSeeOther)
+}
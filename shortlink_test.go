@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestShortLink(t *testing.T) {
+	app := testApp(t)
+	mux := newMux(app)
+	admin := adminCookie(app)
+	e := seedEvent(t, app.DB)
+	other := &Event{TitleFR: "Autre", EventDate: "2026-07-01"}
+	CreateEvent(app.DB, other)
+
+	// Opening the event draws its code, once.
+	w := getRequest(mux, fmt.Sprintf("/admin/event/edit?id=%d&lang=en", e.ID), admin)
+	short, err := GetShortLink(app.DB, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(short.Code) != shortCodeLen || !strings.Contains(w.Body.String(), "/s/"+short.Code) {
+		t.Fatalf("code %q not shown", short.Code)
+	}
+	if again, _ := EnsureShortLink(app.DB, e.ID); again.Code != short.Code {
+		t.Errorf("code changed to %q", again.Code)
+	}
+
+	w = getRequest(mux, "/s/"+strings.ToUpper(short.Code)+"?lang=en")
+	if w.Code != 302 || w.Header().Get("Location") != "/e/"+e.Slug+"?lang=en" {
+		t.Errorf("click: status %d, location %q", w.Code, w.Header().Get("Location"))
+	}
+	w = getRequest(mux, "/s/"+short.Code+"?qr")
+	if w.Code != 302 || w.Header().Get("Location") != "/e/"+e.Slug {
+		t.Errorf("scan: status %d, location %q", w.Code, w.Header().Get("Location"))
+	}
+	getRequest(mux, "/s/"+short.Code)
+	if got, _ := GetShortLink(app.DB, e.ID); got.Clicks != 2 || got.Scans != 1 {
+		t.Errorf("clicks %d, scans %d, want 2 and 1", got.Clicks, got.Scans)
+	}
+
+	change := func(id int64, code string) {
+		t.Helper()
+		w := postForm(mux, "/admin/event/shortlink?lang=en", url.Values{"id": {fmt.Sprint(id)}, "code": {code}}, admin)
+		if w.Code != 303 {
+			t.Fatalf("change to %q: status %d", code, w.Code)
+		}
+	}
+	change(e.ID, " Fete ")
+	if got, _ := GetShortLink(app.DB, e.ID); got.Code != "fete" || got.Clicks != 0 {
+		t.Errorf("after change: %+v", got)
+	}
+	// The former code, printed on posters already, still leads to the event
+	// and counts there.
+	w = getRequest(mux, "/s/"+short.Code+"?qr")
+	if w.Code != 302 || w.Header().Get("Location") != "/e/"+e.Slug {
+		t.Errorf("former code: status %d, location %q", w.Code, w.Header().Get("Location"))
+	}
+	if got, _ := GetShortLink(app.DB, e.ID); got.Scans != 1 {
+		t.Errorf("scans through the former code = %d, want 1", got.Scans)
+	}
+	change(e.ID, short.Code)
+	if got, _ := GetShortLink(app.DB, e.ID); got.Code != short.Code {
+		t.Errorf("taking back the former code: %+v", got)
+	}
+	if w = getRequest(mux, "/s/fete"); w.Code != 302 {
+		t.Errorf("code given up: status %d", w.Code)
+	}
+
+	// Taken and malformed codes change nothing, former codes included.
+	change(other.ID, short.Code)
+	change(other.ID, "fete")
+	change(other.ID, "x")
+	change(other.ID, "a b")
+	if _, err := GetShortLink(app.DB, other.ID); err == nil {
+		t.Error("other event got a code")
+	}
+
+	SetEventDraft(app.DB, e.ID, true)
+	if w = getRequest(mux, "/s/"+short.Code); w.Code != 404 {
+		t.Errorf("draft: status %d", w.Code)
+	}
+}
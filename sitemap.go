@@ -0,0 +1,86 @@
+package main
+
+// Search engines. /sitemap.xml lists the public page of every published
+// event, and /robots.txt points crawlers to it and away from the admin and
+// the personal links sent by email. An event can be unlisted — a parish
+// event meant for those handed the link: it stays off the sitemap and its
+// pages carry a noindex tag, which search engines honor, unlike robots.txt
+// rules, for pages linked from elsewhere.
+
+import (
+	"encoding/xml"
+	"fmt"
+	"log"
+	"net/http"
+)
+
+// robotsDisallow are the paths crawlers have no business in.
+var robotsDisallow = []string{"/admin", "/cancel/", "/my", "/unsubscribe", "/santa/", "/stats/", "/dev/"}
+
+func (app *App) handleRobots(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintln(w, "User-agent: *")
+	for _, path := range robotsDisallow {
+		fmt.Fprintln(w, "Disallow:", path)
+	}
+	fmt.Fprintf(w, "\nSitemap: %s/sitemap.xml\n", app.baseURLFor(r))
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	NS      string       `xml:"xmlns,attr"`
+	XHTML   string       `xml:"xmlns:xhtml,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc        string           `xml:"loc"`
+	Alternates []sitemapAltLink `xml:"xhtml:link"`
+}
+
+// sitemapAltLink points to the page in another language.
+type sitemapAltLink struct {
+	Rel      string `xml:"rel,attr"`
+	HrefLang string `xml:"hreflang,attr"`
+	Href     string `xml:"href,attr"`
+}
+
+// sitemapEvents returns the events the sitemap of base lists: published,
+// not unlisted nor invite-only, and served from base rather than their own
+// domain.
+func sitemapEvents(events []Event, base string) []Event {
+	var listed []Event
+	for _, e := range events {
+		if !e.Draft && !e.NoIndex && !e.InviteOnly && e.BaseURLOr(base) == base {
+			listed = append(listed, e)
+		}
+	}
+	return listed
+}
+
+func (app *App) handleSitemap(w http.ResponseWriter, r *http.Request) {
+	events, err := ListEvents(app.DB)
+	if err != nil {
+		log.Printf("sitemap: %v", err)
+		http.Error(w, T("error_server", LangFromRequest(r)), 500)
+		return
+	}
+	base := app.baseURLFor(r)
+	set := sitemapURLSet{NS: "http://www.sitemaps.org/schemas/sitemap/0.9", XHTML: "http://www.w3.org/1999/xhtml"}
+	for _, e := range sitemapEvents(events, base) {
+		u := sitemapURL{Loc: base + "/e/" + e.Slug}
+		if e.Lang == "" {
+			for _, lang := range []string{LangFR, LangEN} {
+				u.Alternates = append(u.Alternates, sitemapAltLink{Rel: "alternate", HrefLang: lang, Href: u.Loc + "?lang=" + lang})
+			}
+		}
+		set.URLs = append(set.URLs, u)
+	}
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	fmt.Fprint(w, xml.Header)
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(set); err != nil {
+		log.Printf("sitemap: %v", err)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSitemapAndNoIndex(t *testing.T) {
+	app := testApp(t)
+	mux := newMux(app)
+	listed := seedEvent(t, app.DB)
+	unlisted := seedEvent(t, app.DB)
+	draft := seedEvent(t, app.DB)
+	SetEventDraft(app.DB, draft.ID, true)
+	elsewhere := seedEvent(t, app.DB)
+	elsewhere.BaseURL = "https://fete.example.org"
+	UpdateEvent(app.DB, elsewhere)
+
+	body, _ := json.Marshal(map[string]any{
+		"event_id": unlisted.ID, "title_fr": unlisted.TitleFR, "event_date": unlisted.EventDate, "noindex": true,
+	})
+	req := httptest.NewRequest(http.MethodPost, "/admin/api/event/save", bytes.NewReader(body))
+	req.AddCookie(adminCookie(app))
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != 200 {
+		t.Fatalf("save: status %d: %s", w.Code, w.Body)
+	}
+	if got, _ := GetEvent(app.DB, unlisted.ID); !got.NoIndex {
+		t.Fatal("noindex not saved")
+	}
+
+	sitemap := getRequest(mux, "/sitemap.xml").Body.String()
+	if !strings.Contains(sitemap, "<loc>http://example.com/e/"+listed.Slug+"</loc>") || !strings.Contains(sitemap, `hreflang="en"`) {
+		t.Errorf("sitemap misses the published event:\n%s", sitemap)
+	}
+	for _, e := range []*Event{unlisted, draft, elsewhere} {
+		if strings.Contains(sitemap, "/e/"+e.Slug+"<") {
+			t.Errorf("sitemap lists %s", e.Slug)
+		}
+	}
+	if robots := getRequest(mux, "/robots.txt").Body.String(); !strings.Contains(robots, "Sitemap: http://example.com/sitemap.xml") || !strings.Contains(robots, "Disallow: /admin") {
+		t.Errorf("robots.txt = %s", robots)
+	}
+
+	w = getRequest(mux, "/e/"+unlisted.Slug)
+	if w.Header().Get("X-Robots-Tag") != "noindex" || !strings.Contains(w.Body.String(), `<meta name="robots" content="noindex">`) {
+		t.Error("unlisted event page not marked noindex")
+	}
+	w = getRequest(mux, "/e/"+listed.Slug)
+	if w.Header().Get("X-Robots-Tag") != "" || strings.Contains(w.Body.String(), `name="robots"`) {
+		t.Error("listed event page marked noindex")
+	}
+}
@@ -0,0 +1,225 @@
+package main
+
+// Skills. A task can list the skills it needs — "first aid", "driving",
+// "cooking" — as tags typed in the admin tree; the signup form of an event
+// whose tasks need some asks volunteers which of them they have, and their
+// profile remembers it for the next events (see volunteers.go). The skills
+// page then shows, task by task, which registered volunteers have the skills
+// it needs, which skills none of them has, and who else signed up for the
+// event could fill those gaps.
+
+import (
+	"database/sql"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	maxSkills   = 20 // per task or volunteer
+	maxSkillLen = 40 // characters
+)
+
+// parseSkills reads a comma-separated list of skills: each trimmed and
+// lowercased, without duplicates or blank and overlong ones.
+func parseSkills(raw string) []string {
+	var skills []string
+	for _, s := range strings.Split(raw, ",") {
+		s = strings.ToLower(strings.Join(strings.Fields(s), " "))
+		if s == "" || utf8.RuneCountInString(s) > maxSkillLen || slices.Contains(skills, s) {
+			continue
+		}
+		if skills = append(skills, s); len(skills) == maxSkills {
+			break
+		}
+	}
+	return skills
+}
+
+// normalizeSkills is parseSkills joined back for storage.
+func normalizeSkills(raw string) string {
+	return strings.Join(parseSkills(raw), ", ")
+}
+
+// SkillList returns the skills the task needs.
+func (t Task) SkillList() []string { return parseSkills(t.Skills) }
+
+// eventSkills returns the skills needed by any of tasks, sorted.
+func eventSkills(tasks []Task) []string {
+	var skills []string
+	for _, t := range tasks {
+		for _, s := range t.SkillList() {
+			if !slices.Contains(skills, s) {
+				skills = append(skills, s)
+			}
+		}
+	}
+	slices.Sort(skills)
+	return skills
+}
+
+// SaveVolunteerSkills records which of the skills offered on a signup form
+// email declared. The skills it declared elsewhere stay.
+func SaveVolunteerSkills(db *sql.DB, email string, offered, declared []string) error {
+	var current string
+	if err := db.QueryRow("SELECT skills FROM volunteers WHERE email=?", strings.ToLower(email)).Scan(&current); err != nil {
+		return err
+	}
+	var skills []string
+	for _, s := range parseSkills(current) {
+		if !slices.Contains(offered, s) {
+			skills = append(skills, s)
+		}
+	}
+	for _, s := range declared {
+		if slices.Contains(offered, s) {
+			skills = append(skills, s)
+		}
+	}
+	_, err := db.Exec("UPDATE volunteers SET skills=?, updated_at=CURRENT_TIMESTAMP WHERE email=?",
+		normalizeSkills(strings.Join(skills, ",")), strings.ToLower(email))
+	return err
+}
+
+// saveSignupSkills records the skills email declared signing up for event
+// eventID, when its tasks need some.
+func saveSignupSkills(db *sql.DB, eventID int64, email string, declared []string) error {
+	tasks, err := ListTasks(db, eventID)
+	if err != nil {
+		return err
+	}
+	offered := eventSkills(tasks)
+	if len(offered) == 0 {
+		return nil
+	}
+	return SaveVolunteerSkills(db, email, offered, declared)
+}
+
+// SkillPerson is someone signed up for an event, with the skills declared.
+type SkillPerson struct {
+	FirstName string
+	LastName  string
+	Email     string
+	Skills    []string
+	// Of the skills a task needs, those the person has and lacks.
+	Has     []string
+	Missing []string
+}
+
+// SkillMatch is how the people signed up for a task cover the skills it
+// needs.
+type SkillMatch struct {
+	Task   Task
+	People []SkillPerson
+	// Gaps are the needed skills none of People has; Candidates are the
+	// others signed up for the event who have some of them.
+	Gaps       []string
+	Candidates []SkillPerson
+}
+
+// withSkills returns a copy of p with Has and Missing set for needed.
+func (p SkillPerson) withSkills(needed []string) SkillPerson {
+	p.Has, p.Missing = nil, nil
+	for _, s := range needed {
+		if slices.Contains(p.Skills, s) {
+			p.Has = append(p.Has, s)
+		} else {
+			p.Missing = append(p.Missing, s)
+		}
+	}
+	return p
+}
+
+// GetSkillMatches returns, for each task of event eventID that needs
+// skills, how its registrants cover them.
+func GetSkillMatches(db *sql.DB, eventID int64) ([]SkillMatch, error) {
+	tasks, err := ListTasks(db, eventID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.Query(`SELECT r.task_id, r.first_name, r.last_name, r.email, COALESCE(v.skills, '')
+		FROM registrations r JOIN tasks t ON t.id = r.task_id
+		LEFT JOIN volunteers v ON v.email = LOWER(r.email)
+		WHERE t.event_id = ? AND t.trash_id IS NULL AND r.trash_id IS NULL
+		ORDER BY r.created_at, r.id`, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	byTask := map[int64][]SkillPerson{}
+	var everyone []SkillPerson
+	onTask := map[int64]map[string]bool{}
+	for rows.Next() {
+		var taskID int64
+		var p SkillPerson
+		var skills string
+		if err := rows.Scan(&taskID, &p.FirstName, &p.LastName, &p.Email, &skills); err != nil {
+			return nil, err
+		}
+		p.Skills = parseSkills(skills)
+		byTask[taskID] = append(byTask[taskID], p)
+		key := strings.ToLower(p.Email)
+		if onTask[taskID] == nil {
+			onTask[taskID] = map[string]bool{}
+		}
+		onTask[taskID][key] = true
+		if !slices.ContainsFunc(everyone, func(o SkillPerson) bool { return strings.EqualFold(o.Email, p.Email) }) {
+			everyone = append(everyone, p)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var matches []SkillMatch
+	for _, t := range tasks {
+		needed := t.SkillList()
+		if len(needed) == 0 {
+			continue
+		}
+		m := SkillMatch{Task: t}
+		covered := map[string]bool{}
+		for _, p := range byTask[t.ID] {
+			p = p.withSkills(needed)
+			for _, s := range p.Has {
+				covered[s] = true
+			}
+			m.People = append(m.People, p)
+		}
+		for _, s := range needed {
+			if !covered[s] {
+				m.Gaps = append(m.Gaps, s)
+			}
+		}
+		for _, p := range everyone {
+			if onTask[t.ID][strings.ToLower(p.Email)] {
+				continue
+			}
+			if p = p.withSkills(m.Gaps); len(p.Has) > 0 {
+				m.Candidates = append(m.Candidates, p)
+			}
+		}
+		matches = append(matches, m)
+	}
+	return matches, nil
+}
+
+// handleAdminSkills shows how the registrants of an event cover the skills
+// its tasks need.
+func (app *App) handleAdminSkills(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	event, err := GetEvent(app.DB, id)
+	if err != nil {
+		http.Redirect(w, r, "/admin", http.StatusSeeOther)
+		return
+	}
+	matches, err := GetSkillMatches(app.DB, event.ID)
+	if err != nil {
+		http.Error(w, T("error_server", LangFromRequest(r)), 500)
+		return
+	}
+	pd := app.newPageData(r, map[string]any{"Event": event, "Matches": matches})
+	app.render(w, r, "admin_skills.html", pd)
+}
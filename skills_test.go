@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseSkills(t *testing.T) {
+	got := parseSkills(" First  Aid, driving,,first aid, " + strings.Repeat("x", maxSkillLen+1))
+	if want := []string{"first aid", "driving"}; !slices.Equal(got, want) {
+		t.Errorf("parseSkills = %q, want %q", got, want)
+	}
+}
+
+func TestSkillMatches(t *testing.T) {
+	app := testApp(t)
+	mux := newMux(app)
+	admin := adminCookie(app)
+	e := &Event{TitleFR: "Fête", EventDate: "2026-06-15", MaxTasksPerPerson: 2}
+	if err := CreateEvent(app.DB, e); err != nil {
+		t.Fatal(err)
+	}
+	shuttle := &Task{EventID: e.ID, TitleFR: "Navette", Skills: normalizeSkills("Driving, first aid")}
+	CreateTask(app.DB, shuttle)
+	bar := seedTask(t, app.DB, e.ID, "Buvette", nil)
+
+	signup := func(taskID int64, first, email string, skills ...string) {
+		postForm(mux, "/signup?lang=en", url.Values{
+			"task_id": {fmt.Sprint(taskID)}, "first_name": {first}, "last_name": {"Test"},
+			"email": {email}, "phone": {"0601"}, "skills": skills,
+		})
+	}
+	SaveVolunteer(app.DB, "alice@test.com", "Alice", "Test", "0601")
+	app.DB.Exec("UPDATE volunteers SET skills='cooking, driving' WHERE email='alice@test.com'")
+	signup(shuttle.ID, "Alice", "alice@test.com")
+	signup(bar.ID, "Bob", "bob@test.com", "first aid", "juggling")
+
+	if v, _ := GetVolunteer(app.DB, "alice@test.com"); !slices.Equal(v.Skills, []string{"cooking"}) {
+		t.Errorf("skills unchecked on the form kept: %q", v.Skills)
+	}
+	if v, _ := GetVolunteer(app.DB, "bob@test.com"); !slices.Equal(v.Skills, []string{"first aid"}) {
+		t.Errorf("declared skills = %q", v.Skills)
+	}
+	signup(bar.ID, "Alice", "alice@test.com", "driving")
+
+	matches, err := GetSkillMatches(app.DB, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 {
+		t.Fatalf("matches = %+v", matches)
+	}
+	m := matches[0]
+	if len(m.People) != 1 || !slices.Equal(m.People[0].Has, []string{"driving"}) || !slices.Equal(m.Gaps, []string{"first aid"}) {
+		t.Errorf("shuttle = %+v", m)
+	}
+	if len(m.Candidates) != 1 || m.Candidates[0].FirstName != "Bob" {
+		t.Errorf("candidates = %+v", m.Candidates)
+	}
+
+	body := getRequest(mux, fmt.Sprintf("/admin/event/skills?id=%d&lang=en", e.ID), admin).Body.String()
+	if !strings.Contains(body, T("skills_gaps", "en")) || !strings.Contains(body, "Bob") {
+		t.Error("skills page misses the gap or the candidate")
+	}
+}
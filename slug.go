@@ -0,0 +1,137 @@
+package main
+
+// Editable slugs. Admins may change an event's public address from its edit
+// page; the address it had is kept in slug_history and answers with a
+// permanent redirect to the current one, so links already shared by email or
+// on social media keep working. An old slug stays reserved for its event:
+// no other event can take it, but the event itself can go back to it.
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var errSlugTaken = errors.New("slug already in use")
+
+// slugInUse reports whether slug is the address, current or former, of an
+// event other than excludeID.
+func slugInUse(db *sql.DB, slug string, excludeID int64) (bool, error) {
+	var count int
+	err := db.QueryRow(
+		`SELECT (SELECT COUNT(*) FROM events WHERE slug=? AND id!=?)
+			+ (SELECT COUNT(*) FROM slug_history WHERE slug=? AND event_id!=?)`,
+		slug, excludeID, slug, excludeID,
+	).Scan(&count)
+	return count > 0, err
+}
+
+// ChangeEventSlug gives event id the address slug, keeping the one it had
+// as a redirect. It returns errSlugTaken when another event uses slug.
+func ChangeEventSlug(db *sql.DB, id int64, slug string) error {
+	taken, err := slugInUse(db, slug, id)
+	if err != nil {
+		return err
+	} else if taken {
+		return errSlugTaken
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	var old string
+	if err := tx.QueryRow("SELECT slug FROM events WHERE id=?", id).Scan(&old); err != nil {
+		return err
+	}
+	if old == slug {
+		return nil
+	}
+	if _, err := tx.Exec("INSERT OR REPLACE INTO slug_history (slug, event_id) VALUES (?, ?)", old, id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM slug_history WHERE slug=?", slug); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE events SET slug=? WHERE id=?", slug, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetEventByOldSlug returns the event that went by slug before a rename.
+func GetEventByOldSlug(db *sql.DB, slug string) (*Event, error) {
+	return scanEvent(db.QueryRow(
+		"SELECT "+eventCols+" FROM events WHERE id=(SELECT event_id FROM slug_history WHERE slug=?) AND trash_id IS NULL", slug,
+	))
+}
+
+// ListOldSlugs returns the former slugs of event eventID, latest first.
+func ListOldSlugs(db *sql.DB, eventID int64) ([]string, error) {
+	rows, err := db.Query("SELECT slug FROM slug_history WHERE event_id=? ORDER BY created_at DESC, rowid DESC", eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var slugs []string
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			return nil, err
+		}
+		slugs = append(slugs, s)
+	}
+	return slugs, rows.Err()
+}
+
+// redirectOldSlug answers a public event address that matches no current
+// slug: a permanent redirect when it is a former one, keeping the rest of
+// the path and the query, a 404 otherwise.
+func (app *App) redirectOldSlug(w http.ResponseWriter, r *http.Request, slug, suffix string) {
+	event, err := GetEventByOldSlug(app.DB, slug)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	target := "/e/" + event.Slug + suffix
+	if r.URL.RawQuery != "" {
+		target += "?" + r.URL.RawQuery
+	}
+	http.Redirect(w, r, target, http.StatusMovedPermanently)
+}
+
+func (app *App) handleAdminEventSlug(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/admin", http.StatusSeeOther)
+		return
+	}
+	lang := LangFromRequest(r)
+	id, _ := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	event, err := GetEvent(app.DB, id)
+	if err != nil {
+		http.Redirect(w, r, "/admin", http.StatusSeeOther)
+		return
+	}
+	back := fmt.Sprintf("/admin/event/edit?id=%d&lang=%s", event.ID, lang)
+	input := strings.TrimSpace(r.FormValue("slug"))
+	if input == "" {
+		setFlash(w, "error", T("event_slug_invalid", lang))
+		http.Redirect(w, r, back, http.StatusSeeOther)
+		return
+	}
+	slug := GenerateSlug(input)
+	switch err := ChangeEventSlug(app.DB, event.ID, slug); {
+	case errors.Is(err, errSlugTaken):
+		setFlash(w, "error", fmt.Sprintf(T("event_slug_taken", lang), slug))
+	case err != nil:
+		log.Printf("change slug of event %d: %v", event.ID, err)
+		setFlash(w, "error", T("error_server", lang))
+	default:
+		setFlash(w, "success", fmt.Sprintf(T("event_slug_changed", lang), slug))
+	}
+	http.Redirect(w, r, back, http.StatusSeeOther)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestChangeEventSlug(t *testing.T) {
+	app := testApp(t)
+	mux := newMux(app)
+	admin := adminCookie(app)
+	e := seedEvent(t, app.DB)
+	other := &Event{TitleFR: "Autre", EventDate: "2026-07-01"}
+	CreateEvent(app.DB, other)
+	oldSlug := e.Slug
+
+	rename := func(slug string) {
+		t.Helper()
+		w := postForm(mux, "/admin/event/slug?lang=en", url.Values{"id": {fmt.Sprint(e.ID)}, "slug": {slug}}, admin)
+		if w.Code != 303 {
+			t.Fatalf("rename to %q: status %d", slug, w.Code)
+		}
+	}
+	rename("Fête de l'été")
+	got, _ := GetEvent(app.DB, e.ID)
+	if got.Slug != "fete-de-lete" {
+		t.Fatalf("slug = %q, want fete-de-lete", got.Slug)
+	}
+
+	w := getRequest(mux, "/e/"+oldSlug+"/calendar.ics?lang=en")
+	if w.Code != 301 || w.Header().Get("Location") != "/e/fete-de-lete/calendar.ics?lang=en" {
+		t.Errorf("old slug: status %d, location %q", w.Code, w.Header().Get("Location"))
+	}
+	if w = getRequest(mux, "/e/fete-de-lete"); w.Code != 200 {
+		t.Errorf("new slug: status %d", w.Code)
+	}
+	w = getRequest(mux, fmt.Sprintf("/admin/event/edit?id=%d&lang=en", e.ID), admin)
+	if !strings.Contains(w.Body.String(), "/e/"+oldSlug) {
+		t.Error("edit page should list the former address")
+	}
+
+	// Another event's address, current or former, is taken; nothing changes.
+	rename(other.Slug)
+	if got, _ := GetEvent(app.DB, e.ID); got.Slug != "fete-de-lete" {
+		t.Errorf("took another event's slug: %q", got.Slug)
+	}
+	if err := ChangeEventSlug(app.DB, other.ID, oldSlug); err != errSlugTaken {
+		t.Errorf("former slug of another event: %v, want errSlugTaken", err)
+	}
+	dup := &Event{TitleFR: "Test Event", EventDate: "2026-08-01"}
+	CreateEvent(app.DB, dup)
+	if dup.Slug == oldSlug {
+		t.Error("new event reused a former slug")
+	}
+
+	// Going back to the former address is fine and drops the redirect.
+	rename(oldSlug)
+	if got, _ := GetEvent(app.DB, e.ID); got.Slug != oldSlug {
+		t.Errorf("slug = %q, want %q back", got.Slug, oldSlug)
+	}
+	if w = getRequest(mux, "/e/fete-de-lete"); w.Code != 301 {
+		t.Errorf("second old slug: status %d", w.Code)
+	}
+	if slugs, _ := ListOldSlugs(app.DB, e.ID); len(slugs) != 1 || slugs[0] != "fete-de-lete" {
+		t.Errorf("old slugs = %v", slugs)
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+// Spam protection for the public signup and RSVP forms, whose links get
+// shared widely. Every form carries a honeypot field hidden from people and a
+// signed stamp of when it was rendered: bots fill in the first and post
+// faster than anyone can type. A CAPTCHA (hCaptcha or Cloudflare Turnstile)
+// can be added on top through EVENT_SIGNUP_CAPTCHA_*.
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	honeypotField  = "website"
+	formStampField = "form_stamp"
+)
+
+// minSubmitDelay is how long a form must have been on screen before it is
+// posted.
+const minSubmitDelay = 3 * time.Second
+
+var captchaHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// Captcha is the configured CAPTCHA service.
+type Captcha struct {
+	Provider string // "hcaptcha" or "turnstile"
+	SiteKey  string
+	Secret   string
+
+	verifyURL string // overrides the provider's endpoint in tests
+}
+
+// captchaProviders maps a provider to its script, widget class, response
+// field and verification endpoint.
+var captchaProviders = map[string]struct {
+	script, class, field, verify string
+}{
+	"hcaptcha":  {"https://js.hcaptcha.com/1/api.js", "h-captcha", "h-captcha-response", "https://api.hcaptcha.com/siteverify"},
+	"turnstile": {"https://challenges.cloudflare.com/turnstile/v0/api.js", "cf-turnstile", "cf-turnstile-response", "https://challenges.cloudflare.com/turnstile/v0/siteverify"},
+}
+
+// widget is the markup that shows the CAPTCHA in a form.
+func (c *Captcha) widget(lang string) template.HTML {
+	p := captchaProviders[c.Provider]
+	return template.HTML(fmt.Sprintf(`<script src="%s" async defer></script><div class="%s captcha" data-sitekey="%s" data-language="%s" data-hl="%s"></div>`,
+		p.script, p.class, template.HTMLEscapeString(c.SiteKey), lang, lang))
+}
+
+// verify asks the provider whether the response posted in r is a solved
+// challenge.
+func (c *Captcha) verify(ctx context.Context, r *http.Request) (bool, error) {
+	p := captchaProviders[c.Provider]
+	response := r.FormValue(p.field)
+	if response == "" {
+		return false, nil
+	}
+	endpoint := p.verify
+	if c.verifyURL != "" {
+		endpoint = c.verifyURL
+	}
+	form := url.Values{"secret": {c.Secret}, "response": {response}, "sitekey": {c.SiteKey}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := captchaHTTPClient.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("captcha: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("captcha: HTTP %d", resp.StatusCode)
+	}
+	var result struct {
+		Success bool     `json:"success"`
+		Errors  []string `json:"error-codes"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&result); err != nil {
+		return false, fmt.Errorf("captcha: %w", err)
+	}
+	if !result.Success && len(result.Errors) > 0 {
+		log.Printf("captcha: rejected: %s", strings.Join(result.Errors, ", "))
+	}
+	return result.Success, nil
+}
+
+// formStampSig signs the render time of a form.
+func (app *App) formStampSig(stamp string) (string, error) {
+	secret, err := SettingSecret(app.DB, "form_secret")
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(stamp))
+	return hex.EncodeToString(mac.Sum(nil)[:16]), nil
+}
+
+// formStamp returns a signed stamp of t for a form rendered then.
+func (app *App) formStamp(t time.Time) string {
+	stamp := strconv.FormatInt(t.Unix(), 36)
+	sig, err := app.formStampSig(stamp)
+	if err != nil {
+		log.Printf("spam: sign: %v", err)
+		return ""
+	}
+	return stamp + "." + sig
+}
+
+// formStampTime checks a stamp's signature and returns when its form was
+// rendered.
+func (app *App) formStampTime(value string) (time.Time, bool) {
+	stamp, sig, found := strings.Cut(value, ".")
+	if !found {
+		return time.Time{}, false
+	}
+	want, err := app.formStampSig(stamp)
+	if err != nil {
+		log.Printf("spam: sign: %v", err)
+		return time.Time{}, false
+	}
+	unix, err := strconv.ParseInt(stamp, 36, 64)
+	if err != nil || !hmac.Equal([]byte(want), []byte(sig)) {
+		return time.Time{}, false
+	}
+	return time.Unix(unix, 0), true
+}
+
+// spamGuard is the markup a public form carries: the honeypot, the render
+// stamp and, when configured, the CAPTCHA.
+func (app *App) spamGuard(lang string) template.HTML {
+	html := `<div class="hp-field" aria-hidden="true"><label>Website <input type="text" name="` + honeypotField + `" tabindex="-1" autocomplete="off"></label></div>` +
+		`<input type="hidden" name="` + formStampField + `" value="` + app.formStamp(time.Now()) + `">`
+	if app.Captcha != nil {
+		html += string(app.Captcha.widget(lang))
+	}
+	return template.HTML(html)
+}
+
+// checkSpam returns the i18n key of the reason r looks like a bot's, or "".
+// An unreachable CAPTCHA service lets the form through, logged: the honeypot
+// and the stamp still apply, and an outage shouldn't stop signups.
+func (app *App) checkSpam(r *http.Request) string {
+	if r.FormValue(honeypotField) != "" {
+		log.Printf("spam: honeypot filled on %s", r.URL.Path)
+		return "spam_rejected"
+	}
+	if !app.SkipFormStamp {
+		rendered, ok := app.formStampTime(r.FormValue(formStampField))
+		if !ok || time.Since(rendered) < minSubmitDelay {
+			log.Printf("spam: missing or early form stamp on %s", r.URL.Path)
+			return "spam_rejected"
+		}
+	}
+	if app.Captcha != nil {
+		ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+		defer cancel()
+		ok, err := app.Captcha.verify(ctx, r)
+		if err != nil {
+			log.Printf("%v", err)
+			return ""
+		}
+		if !ok {
+			return "captcha_failed"
+		}
+	}
+	return ""
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSpamGuard(t *testing.T) {
+	app := testApp(t)
+	app.SkipFormStamp = false
+	e := seedEvent(t, app.DB)
+	tk := seedTask(t, app.DB, e.ID, "Vaisselle", nil)
+	mux := newMux(app)
+
+	// The public page hands out the honeypot and a signed stamp.
+	body := getRequest(mux, "/e/"+e.Slug+"?lang=fr").Body.String()
+	m := regexp.MustCompile(`name="form_stamp" value="([^"]+)"`).FindStringSubmatch(body)
+	if m == nil || !strings.Contains(body, `name="website"`) {
+		t.Fatal("public form lacks the spam guard fields")
+	}
+	signup := func(extra url.Values) *httptest.ResponseRecorder {
+		form := url.Values{
+			"task_id": {fmt.Sprint(tk.ID)}, "first_name": {"Alice"}, "last_name": {"Dupont"},
+			"email": {"alice@test.com"}, "phone": {"0601"},
+		}
+		for k, v := range extra {
+			form[k] = v
+		}
+		return postForm(mux, "/signup?lang=fr", form)
+	}
+
+	earlier := app.formStamp(time.Now().Add(-time.Minute))
+	cases := []struct {
+		name  string
+		extra url.Values
+	}{
+		{"no stamp", nil},
+		{"stamp just issued", url.Values{"form_stamp": {m[1]}}},
+		{"forged stamp", url.Values{"form_stamp": {strings.Split(earlier, ".")[0] + ".00000000000000000000000000000000"}}},
+		{"honeypot filled", url.Values{"form_stamp": {earlier}, "website": {"http://spam.example"}}},
+	}
+	for _, c := range cases {
+		w := signup(c.extra)
+		if !strings.Contains(w.Body.String(), template.HTMLEscapeString(T("spam_rejected", "fr"))) {
+			t.Errorf("%s: not rejected", c.name)
+		}
+	}
+	if CountRegistrations(app.DB, e.ID) != 0 {
+		t.Fatal("a rejected form registered someone")
+	}
+	signup(url.Values{"form_stamp": {earlier}})
+	if CountRegistrations(app.DB, e.ID) != 1 {
+		t.Fatal("a form filled in by a person was rejected")
+	}
+
+	// With a CAPTCHA, the provider has the last word.
+	var got url.Values
+	verifier := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		got = r.PostForm
+		if r.PostForm.Get("response") == "solved" {
+			w.Write([]byte(`{"success":true}`))
+			return
+		}
+		w.Write([]byte(`{"success":false,"error-codes":["invalid-input-response"]}`))
+	}))
+	defer verifier.Close()
+	app.Captcha = &Captcha{Provider: "turnstile", SiteKey: "site", Secret: "shh", verifyURL: verifier.URL}
+	att := seedEvent(t, app.DB)
+	att.EventType = "attendance"
+	UpdateEvent(app.DB, att)
+	if body := getRequest(mux, "/e/"+att.Slug+"?lang=fr").Body.String(); !strings.Contains(body, `class="cf-turnstile captcha" data-sitekey="site"`) {
+		t.Error("RSVP page lacks the CAPTCHA widget")
+	}
+	rsvp := func(response string) *httptest.ResponseRecorder {
+		return postForm(mux, "/rsvp?lang=fr", url.Values{
+			"event_id": {fmt.Sprint(att.ID)}, "first_name": {"Bob"}, "last_name": {"Martin"},
+			"email": {"bob@test.com"}, "attending": {"yes"},
+			"form_stamp": {earlier}, "cf-turnstile-response": {response},
+		})
+	}
+	if w := rsvp("robot"); !strings.Contains(w.Body.String(), T("captcha_failed", "fr")) {
+		t.Error("unsolved CAPTCHA accepted")
+	}
+	if got.Get("secret") != "shh" {
+		t.Errorf("verification sent secret %q", got.Get("secret"))
+	}
+	if yes, _ := CountAttendances(app.DB, att.ID); yes != 0 {
+		t.Fatal("RSVP recorded despite the CAPTCHA")
+	}
+	rsvp("solved")
+	if yes, _ := CountAttendances(app.DB, att.ID); yes != 1 {
+		t.Error("solved CAPTCHA refused")
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+// Staffing minimums. A task can say how many people it needs to go ahead,
+// apart from how many it can take: the public page shows how many are still
+// missing, and /admin/understaffed lists the tasks of upcoming events that
+// haven't reached their minimum.
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"time"
+)
+
+// normalizeMinSlots keeps t's minimum between 0 and its capacity.
+func normalizeMinSlots(t *Task) {
+	t.MinSlots = max(t.MinSlots, 0)
+	if t.MaxSlots.Valid {
+		t.MinSlots = min(t.MinSlots, int(t.MaxSlots.Int64))
+	}
+}
+
+// UnderstaffedTask is a task short of its minimum, with its event.
+type UnderstaffedTask struct {
+	EventID      int64
+	EventTitleFR string
+	EventTitleEN string
+	EventDate    string
+	TaskID       int64
+	TaskTitleFR  string
+	TaskTitleEN  string
+	StartTime    string
+	EndTime      string
+	MinSlots     int
+	RegCount     int // people signed up, guests included
+}
+
+// StillNeeded returns how many people the task is missing.
+func (u UnderstaffedTask) StillNeeded() int { return u.MinSlots - u.RegCount }
+
+const understaffedQuery = `SELECT e.id, e.title_fr, e.title_en, e.event_date,
+	t.id, t.title_fr, t.title_en, t.start_time, t.end_time, t.min_slots, ` + countPeople + `
+	FROM tasks t
+	JOIN events e ON e.id = t.event_id
+	LEFT JOIN registrations r ON r.task_id = t.id AND r.trash_id IS NULL
+	WHERE t.min_slots > 0 AND t.trash_id IS NULL AND e.trash_id IS NULL AND e.event_type = 'tasks'
+		AND COALESCE(NULLIF(e.end_date, ''), e.event_date) >= ?
+	GROUP BY t.id
+	HAVING ` + countPeople + ` < t.min_slots`
+
+// ListUnderstaffedTasks returns the tasks short of their minimum among the
+// events not over by day (YYYY-MM-DD), soonest event first.
+func ListUnderstaffedTasks(db *sql.DB, day string) ([]UnderstaffedTask, error) {
+	rows, err := db.Query(understaffedQuery+" ORDER BY e.event_date, e.id, t.start_time, t.position, t.id", day)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []UnderstaffedTask
+	for rows.Next() {
+		var u UnderstaffedTask
+		if err := rows.Scan(&u.EventID, &u.EventTitleFR, &u.EventTitleEN, &u.EventDate,
+			&u.TaskID, &u.TaskTitleFR, &u.TaskTitleEN, &u.StartTime, &u.EndTime, &u.MinSlots, &u.RegCount); err != nil {
+			return nil, err
+		}
+		list = append(list, u)
+	}
+	return list, rows.Err()
+}
+
+// CountUnderstaffedTasks returns how many tasks ListUnderstaffedTasks lists.
+func CountUnderstaffedTasks(db *sql.DB, day string) int {
+	var n int
+	db.QueryRow("SELECT COUNT(*) FROM ("+understaffedQuery+")", day).Scan(&n)
+	return n
+}
+
+func (app *App) handleAdminUnderstaffed(w http.ResponseWriter, r *http.Request) {
+	tasks, err := ListUnderstaffedTasks(app.DB, time.Now().Format("2006-01-02"))
+	if err != nil {
+		log.Printf("understaffed: list: %v", err)
+	}
+	pd := app.newPageData(r, map[string]any{"Tasks": tasks})
+	app.render(w, r, "admin_understaffed.html", pd)
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+func TestUnderstaffedTasks(t *testing.T) {
+	app := testApp(t)
+	mux := newMux(app)
+	e := &Event{TitleFR: "Kermesse", EventDate: "2099-06-15"}
+	if err := CreateEvent(app.DB, e); err != nil {
+		t.Fatal(err)
+	}
+	kitchen := seedTask(t, app.DB, e.ID, "Cuisine", int64Ptr(5))
+	kitchen.MinSlots = 3
+	UpdateTask(app.DB, kitchen)
+	bar := seedTask(t, app.DB, e.ID, "Buvette", nil)
+	bar.MinSlots = 1
+	UpdateTask(app.DB, bar)
+	seedTask(t, app.DB, e.ID, "Rangement", nil) // no minimum
+
+	RegisterGroupForTask(app.DB, kitchen.ID, 0, "Alice", "Dupont", "alice@test.com", "0601", "fr")
+	RegisterForTask(app.DB, bar.ID, "Bob", "Martin", "bob@test.com", "0602", "fr")
+
+	tasks, err := ListUnderstaffedTasks(app.DB, "2099-01-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 1 || tasks[0].TaskID != kitchen.ID || tasks[0].StillNeeded() != 2 {
+		t.Fatalf("understaffed = %+v", tasks)
+	}
+	if n := CountUnderstaffedTasks(app.DB, "2099-07-01"); n != 0 {
+		t.Errorf("%d understaffed tasks after the event", n)
+	}
+
+	body := getRequest(mux, "/e/"+e.Slug+"?lang=en").Body.String()
+	if !strings.Contains(body, `<span class="radio-task-needed-count">2</span>`) {
+		t.Error("public page lacks the missing count")
+	}
+	body = getRequest(mux, "/admin/understaffed?lang=en", adminCookie(app)).Body.String()
+	if !strings.Contains(body, "Cuisine") || strings.Contains(body, "Buvette") {
+		t.Error("report should list the kitchen only")
+	}
+
+	// The minimum can't exceed the capacity.
+	kitchen.MinSlots = 8
+	normalizeMinSlots(kitchen)
+	if kitchen.MinSlots != 5 {
+		t.Errorf("min slots = %d, want the capacity", kitchen.MinSlots)
+	}
+	kitchen.MaxSlots = sql.NullInt64{}
+	normalizeMinSlots(kitchen)
+	if kitchen.MinSlots != 5 {
+		t.Errorf("min slots = %d without a capacity", kitchen.MinSlots)
+	}
+}
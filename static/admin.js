@@ -27,10 +27,16 @@ function showSave(state, msg) {
     }
 }
 
+// csrfToken returns the token the server expects on every POST.
+function csrfToken() {
+    var meta = document.querySelector('meta[name="csrf-token"]');
+    return meta ? meta.content : '';
+}
+
 function apiPost(url, data) {
     return fetch(url, {
         method: 'POST',
-        headers: {'Content-Type': 'application/json'},
+        headers: {'Content-Type': 'application/json', 'X-CSRF-Token': csrfToken()},
         body: JSON.stringify(data)
     }).then(function(resp) {
         if (!resp.ok) return resp.text().then(function(t) { throw new Error(t); });
@@ -40,8 +46,8 @@ function apiPost(url, data) {
 
 // ---- Copy public link ----
 
-function copyLink() {
-    var el = document.getElementById('public-url');
+function copyLink(id) {
+    var el = document.getElementById(id || 'public-url');
     if (el) {
         navigator.clipboard.writeText(el.textContent.trim());
         var btn = el.nextElementSibling;
@@ -73,6 +79,11 @@ function fieldValue(id) {
     return el ? el.value : '';
 }
 
+function fieldChecked(id) {
+    var el = document.getElementById(id);
+    return el ? el.checked : false;
+}
+
 var saveEvent = debounce(function(eventId) {
     var data = {
         event_id: eventId,
@@ -82,6 +93,35 @@ var saveEvent = debounce(function(eventId) {
         description_en: fieldValue('description_en'),
         event_date: fieldValue('event_date'),
         event_time: fieldValue('event_time'),
+        end_date: fieldValue('end_date'),
+        end_time: fieldValue('end_time'),
+        base_url: fieldValue('base_url'),
+        organizer_email: fieldValue('organizer_email'),
+        timezone: fieldValue('timezone'),
+        // Admin notification recipients (absent on secret_santa events).
+        notify_emails: fieldValue('notify_emails'),
+        // Cancellation link window (only present on tasks events).
+        cancel_days: fieldValue('cancel_days'),
+        // Registration window (only present on tasks events).
+        registration_opens_at: fieldValue('registration_opens_at'),
+        registration_closes_at: fieldValue('registration_closes_at'),
+        access_code: fieldValue('access_code'),
+        max_tasks_per_person: fieldValue('max_tasks_per_person'),
+        max_participants: fieldValue('max_participants'),
+        collect_diet: fieldChecked('collect_diet'),
+        counts_only: fieldChecked('counts_only'),
+        checkin: fieldChecked('checkin'),
+        assign_mode: fieldChecked('assign_mode'),
+        family_signup: fieldChecked('family_signup'),
+        lang: fieldValue('event_lang'),
+        noindex: fieldChecked('noindex'),
+        // Plus-ones per RSVP (only present on attendance events).
+        max_guests: fieldValue('event_max_guests'),
+        notes: fieldValue('notes'),
+        retention_months: fieldValue('retention_months'),
+        // Signup terms (only present on tasks events).
+        terms_fr: fieldValue('terms_fr'),
+        terms_en: fieldValue('terms_en'),
         // Per-event email overrides (only present on secret_santa events).
         email_hook_fr: fieldValue('email_hook_fr'),
         email_hook_en: fieldValue('email_hook_en'),
@@ -96,33 +136,63 @@ var saveEvent = debounce(function(eventId) {
         email_button_fr: fieldValue('email_button_fr'),
         email_button_en: fieldValue('email_button_en'),
         email_disclaimer_fr: fieldValue('email_disclaimer_fr'),
-        email_disclaimer_en: fieldValue('email_disclaimer_en')
+        email_disclaimer_en: fieldValue('email_disclaimer_en'),
+        // After-signup texts (only present on tasks events).
+        after_info_fr: fieldValue('after_info_fr'),
+        after_info_en: fieldValue('after_info_en'),
+        after_bring_fr: fieldValue('after_bring_fr'),
+        after_bring_en: fieldValue('after_bring_en'),
+        after_contact_fr: fieldValue('after_contact_fr'),
+        after_contact_en: fieldValue('after_contact_en')
     };
     showSave('', 'Saving...');
     apiPost('/admin/api/event/save', data)
-        .then(function() { showSave('saved', 'Saved'); })
+        .then(function() { showSave('saved', 'Saved'); refreshPublicURL(); })
         .catch(function() { showSave('error', 'Save failed'); });
 }, 500);
 
+// Keep the displayed public link in step with the custom domain field.
+function refreshPublicURL() {
+    var el = document.getElementById('public-url');
+    if (!el) return;
+    var base = fieldValue('base_url').trim().replace(/\/+$/, '') || el.dataset.requestBase;
+    el.textContent = base + '/e/' + el.dataset.slug;
+}
+
 function initEventAutoSave() {
     var form = document.querySelector('#event-details [data-event-id]');
     if (!form) return;
     var eventId = parseInt(form.dataset.eventId);
-    var trigger = function() { saveEvent(eventId); };
+    var trigger = function(e) {
+        // The slug has its own form, saved on submit only.
+        if (e.target.closest && e.target.closest('.slug-edit')) return;
+        saveEvent(eventId);
+    };
     form.addEventListener('input', trigger);
     form.addEventListener('change', trigger);
+    // A single-language event hides its English fields at once; the labels
+    // follow on the next load.
+    var eventLang = document.getElementById('event_lang');
+    if (eventLang) {
+        eventLang.addEventListener('change', function() {
+            document.querySelector('.event-edit').classList.toggle('single-lang', eventLang.value !== '');
+        });
+    }
     // Trix updates its backing hidden input programmatically, which does not
     // fire 'input' on a hidden field. Wire the editor's own 'trix-change'
     // event to the same debounced saver.
     form.addEventListener('trix-change', trigger);
 
-    // The email-customization panel lives in a separate <details> section
-    // (outside #event-details), so it needs its own listener pair.
-    var customize = document.getElementById('email-customize-body');
-    if (customize) {
-        customize.addEventListener('input', trigger);
-        customize.addEventListener('change', trigger);
-    }
+    // The email-customization and after-signup panels live in separate
+    // <details> sections (outside #event-details), so they need their own
+    // listener pairs.
+    ['email-customize-body', 'after-signup-body'].forEach(function(id) {
+        var panel = document.getElementById(id);
+        if (panel) {
+            panel.addEventListener('input', trigger);
+            panel.addEventListener('change', trigger);
+        }
+    });
 }
 
 // Reject file/image attachments anywhere a Trix editor is mounted. CSS also
@@ -212,11 +282,19 @@ function saveGroup(el) {
     var item = el.closest('[data-type="group"]');
     if (!item) return;
     var id = parseInt(item.dataset.id);
+    // The group's own inputs come before its children's.
+    var msVal = item.querySelector('[data-field="max_slots"]').value.trim();
+    var color = item.querySelector('[data-field="color"]').value;
     var data = {
         id: id,
         title_fr: item.querySelector('[data-field="title_fr"]').value,
-        title_en: item.querySelector('[data-field="title_en"]').value
+        title_en: item.querySelector('[data-field="title_en"]').value,
+        max_slots: msVal === '' ? null : parseInt(msVal),
+        color: color,
+        icon: item.querySelector('[data-field="icon"]').value
     };
+    if (color) item.style.setProperty('--group-color', color);
+    else item.style.removeProperty('--group-color');
     getGroupSaver(id)(data);
 }
 
@@ -247,7 +325,14 @@ function saveTask(el) {
         title_en: (item.querySelector('[data-field="title_en"]') || {}).value || '',
         description_fr: (item.querySelector('[data-field="description_fr"]') || {}).value || '',
         description_en: (item.querySelector('[data-field="description_en"]') || {}).value || '',
-        max_slots: msVal === '' ? null : parseInt(msVal)
+        max_slots: msVal === '' ? null : parseInt(msVal),
+        start_time: (item.querySelector('[data-field="start_time"]') || {}).value || '',
+        end_time: (item.querySelector('[data-field="end_time"]') || {}).value || '',
+        max_guests: parseInt((item.querySelector('[data-field="max_guests"]') || {}).value) || 0,
+        min_slots: parseInt((item.querySelector('[data-field="min_slots"]') || {}).value) || 0,
+        reserved_slots: parseInt((item.querySelector('[data-field="reserved_slots"]') || {}).value) || 0,
+        skills: (item.querySelector('[data-field="skills"]') || {}).value || '',
+        notes: (item.querySelector('[data-field="notes"]') || {}).value || ''
     };
     getTaskSaver(id)(data);
 }
@@ -275,6 +360,54 @@ function initTreeAutoSave() {
     });
 }
 
+// ---- Bulk max slots ----
+
+function toggleTreeSelect() {
+    var panel = document.getElementById('groups-tasks');
+    if (!panel) return;
+    if (!panel.classList.toggle('tree-selecting')) {
+        panel.querySelectorAll('.tree-select').forEach(function(cb) { cb.checked = false; });
+    }
+    updateTreeSelection();
+}
+
+function updateTreeSelection() {
+    var n = document.querySelectorAll('.tree-select-task:checked').length;
+    var count = document.getElementById('tree-select-count');
+    if (count) count.textContent = count.dataset.label.replace('%d', n);
+    var apply = document.getElementById('tree-select-apply');
+    if (apply) apply.disabled = n === 0;
+}
+
+function initTreeSelect() {
+    var root = document.getElementById('sortable-container');
+    if (!root) return;
+    root.addEventListener('change', function(e) {
+        if (!e.target.classList.contains('tree-select')) return;
+        // A group's box ticks everything inside it.
+        if (e.target.classList.contains('tree-select-group')) {
+            var group = e.target.closest('[data-type="group"]');
+            group.querySelectorAll('.tree-select').forEach(function(cb) { cb.checked = e.target.checked; });
+        }
+        updateTreeSelection();
+    });
+}
+
+function applyBulkMaxSlots() {
+    var container = document.getElementById('sortable-container');
+    var ids = [];
+    document.querySelectorAll('.tree-select-task:checked').forEach(function(cb) { ids.push(parseInt(cb.value)); });
+    if (!container || ids.length === 0) return;
+    var val = document.getElementById('tree-select-max').value.trim();
+    apiPost('/admin/api/max-slots/bulk', {
+        event_id: parseInt(container.dataset.eventId),
+        task_ids: ids,
+        max_slots: val === '' ? null : parseInt(val)
+    })
+        .then(function() { location.reload(); })
+        .catch(function() { showSave('error', 'Save failed'); });
+}
+
 // ---- Create new group / task ----
 
 function createGroup() {
@@ -308,12 +441,23 @@ function deleteItem(type, id) {
     }).catch(function() { showSave('error', 'Delete failed'); });
 }
 
+// ---- Duplicate item ----
+
+function duplicateItem(type, id) {
+    var url = type === 'group' ? '/admin/api/group/duplicate' : '/admin/api/task/duplicate';
+    apiPost(url, { id: id })
+        .then(function() { location.reload(); })
+        .catch(function() { showSave('error', 'Duplicate failed'); });
+}
+
 // ---- Toggle description ----
 
-function toggleDescription(btn) {
+// toggleDescription shows or hides the task's descriptions, or the section
+// matching selector (its private notes, say).
+function toggleDescription(btn, selector) {
     var taskEl = btn.closest('[data-type="task"]');
     if (!taskEl) return;
-    var descSection = taskEl.querySelector('.task-descriptions');
+    var descSection = taskEl.querySelector(selector || '.task-descriptions');
     if (!descSection) return;
     if (descSection.classList.contains('desc-hidden')) {
         descSection.classList.remove('desc-hidden');
@@ -454,4 +598,5 @@ function updatePlaceholders() {
 initEventAutoSave();
 initEmailPreview();
 initTreeAutoSave();
+initTreeSelect();
 updatePlaceholders();
@@ -0,0 +1,72 @@
+// Service worker for the public event pages. Keeps the last-seen copy of each
+// event page (task list, registered view) and its assets so volunteers can
+// still read them at a venue with no signal. The server substitutes the
+// current static build ID below, so a deploy that changes any asset rolls the
+// cache over on the next visit.
+
+var CACHE = 'event-signup-__BUILD_ID__';
+
+self.addEventListener('install', function(event) {
+    event.waitUntil(
+        caches.open(CACHE).then(function(cache) {
+            return cache.addAll(['/static/style.css', '/static/logo.png']);
+        }).then(function() { return self.skipWaiting(); })
+    );
+});
+
+self.addEventListener('activate', function(event) {
+    event.waitUntil(
+        caches.keys().then(function(keys) {
+            return Promise.all(keys.map(function(key) {
+                if (key.indexOf('event-signup-') === 0 && key !== CACHE) {
+                    return caches.delete(key);
+                }
+            }));
+        }).then(function() { return self.clients.claim(); })
+    );
+});
+
+// networkFirst serves fresh content when online and falls back to the last
+// cached copy when the network is unreachable. Query strings are ignored on
+// the fallback so /e/slug?lang=en still finds /e/slug?lang=fr offline.
+function networkFirst(request) {
+    return fetch(request).then(function(resp) {
+        if (resp && resp.ok) {
+            var copy = resp.clone();
+            caches.open(CACHE).then(function(cache) { cache.put(request, copy); });
+        }
+        return resp;
+    }).catch(function() {
+        return caches.match(request).then(function(hit) {
+            return hit || caches.match(request, { ignoreSearch: true });
+        });
+    });
+}
+
+// cacheFirst is used for versioned static assets, which never change under
+// the same ?v= build ID.
+function cacheFirst(request) {
+    return caches.match(request).then(function(hit) {
+        if (hit) return hit;
+        return fetch(request).then(function(resp) {
+            if (resp && resp.ok) {
+                var copy = resp.clone();
+                caches.open(CACHE).then(function(cache) { cache.put(request, copy); });
+            }
+            return resp;
+        });
+    });
+}
+
+self.addEventListener('fetch', function(event) {
+    var req = event.request;
+    if (req.method !== 'GET') return;
+    var url = new URL(req.url);
+    if (url.origin !== self.location.origin) return;
+
+    if (url.pathname.indexOf('/e/') === 0 || url.pathname === '/api/slots') {
+        event.respondWith(networkFirst(req));
+    } else if (url.pathname.indexOf('/static/') === 0) {
+        event.respondWith(cacheFirst(req));
+    }
+});
@@ -0,0 +1,407 @@
+package main
+
+// Structure history. Every change to an event's groups and tasks — an edit,
+// a deletion, a reorder, an AI or spreadsheet import — saves a copy of the
+// whole tree, in the same form as event templates. The history page shows
+// what changed since each version and rolls the tree back to it: items
+// deleted since come back from the trash with their registrations, items
+// added since go to the trash. Bulk changes also save the tree as it was
+// just before, so a bad AI update can always be undone.
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// structureHistoryLimit is how many versions are kept per event.
+	structureHistoryLimit = 50
+	// structureEditWindow merges successive edits into one version, so
+	// that typing in the inline editor does not fill the history.
+	structureEditWindow = 2 * time.Minute
+)
+
+// StructureVersion is an event's group/task tree as it was at CreatedAt.
+// Reason tells what saved it: "edit", "delete", "reorder", "ai", "import",
+// "clear" or "rollback", or one of the last four prefixed with "before_".
+type StructureVersion struct {
+	ID        int64
+	EventID   int64
+	Reason    string
+	Groups    []TaskGroup
+	Tasks     []Task
+	CreatedAt time.Time
+}
+
+// currentStructure returns the groups and tasks of event eventID.
+func currentStructure(db *sql.DB, eventID int64) (templateStructure, error) {
+	groups, err := ListTaskGroups(db, eventID)
+	if err != nil {
+		return templateStructure{}, err
+	}
+	tasks, err := ListTasks(db, eventID)
+	if err != nil {
+		return templateStructure{}, err
+	}
+	return templateStructure{Groups: groups, Tasks: tasks}, nil
+}
+
+// SnapshotStructure saves the current tree of event eventID, unless it is
+// the same as the latest version. An edit shortly after another replaces it.
+func SnapshotStructure(db *sql.DB, eventID int64, reason string) error {
+	s, err := currentStructure(db, eventID)
+	if err != nil {
+		return err
+	}
+	structure, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	var lastID int64
+	var lastReason, lastStructure string
+	var recent bool
+	err = db.QueryRow(`SELECT id, reason, structure, created_at > datetime('now', ?)
+		FROM structure_versions WHERE event_id=? ORDER BY id DESC LIMIT 1`,
+		fmt.Sprintf("-%d seconds", int(structureEditWindow.Seconds())), eventID,
+	).Scan(&lastID, &lastReason, &lastStructure, &recent)
+	switch {
+	case err == sql.ErrNoRows:
+	case err != nil:
+		return err
+	case lastStructure == string(structure):
+		return nil
+	case reason == "edit" && lastReason == "edit" && recent:
+		_, err := db.Exec("UPDATE structure_versions SET structure=?, created_at=CURRENT_TIMESTAMP WHERE id=?", string(structure), lastID)
+		return err
+	}
+	if _, err := db.Exec("INSERT INTO structure_versions (event_id, reason, structure) VALUES (?, ?, ?)",
+		eventID, reason, string(structure)); err != nil {
+		return err
+	}
+	_, err = db.Exec(`DELETE FROM structure_versions WHERE event_id=? AND id NOT IN
+		(SELECT id FROM structure_versions WHERE event_id=? ORDER BY id DESC LIMIT ?)`,
+		eventID, eventID, structureHistoryLimit)
+	return err
+}
+
+// snapshotStructure is SnapshotStructure for handlers, which log failures
+// rather than fail the change already made.
+func (app *App) snapshotStructure(eventID int64, reason string) {
+	if eventID == 0 {
+		return
+	}
+	if err := SnapshotStructure(app.DB, eventID, reason); err != nil {
+		log.Printf("structure history: snapshot of event %d (%s): %v", eventID, reason, err)
+	}
+}
+
+// structureEventID returns the event of group or task id, deleted or not; 0
+// when there is none.
+func structureEventID(db *sql.DB, kind string, id int64) int64 {
+	table := "tasks"
+	if kind == "group" {
+		table = "task_groups"
+	}
+	var eventID int64
+	db.QueryRow("SELECT event_id FROM "+table+" WHERE id=?", id).Scan(&eventID)
+	return eventID
+}
+
+func scanStructureVersion(row interface{ Scan(...any) error }) (*StructureVersion, error) {
+	var v StructureVersion
+	var structure string
+	if err := row.Scan(&v.ID, &v.EventID, &v.Reason, &structure, &v.CreatedAt); err != nil {
+		return nil, err
+	}
+	var s templateStructure
+	if err := json.Unmarshal([]byte(structure), &s); err != nil {
+		return nil, fmt.Errorf("structure version %d: %w", v.ID, err)
+	}
+	v.Groups, v.Tasks = s.Groups, s.Tasks
+	return &v, nil
+}
+
+func GetStructureVersion(db *sql.DB, id int64) (*StructureVersion, error) {
+	return scanStructureVersion(db.QueryRow("SELECT id, event_id, reason, structure, created_at FROM structure_versions WHERE id=?", id))
+}
+
+// ListStructureVersions returns the versions of event eventID, latest first.
+func ListStructureVersions(db *sql.DB, eventID int64) ([]StructureVersion, error) {
+	rows, err := db.Query("SELECT id, event_id, reason, structure, created_at FROM structure_versions WHERE event_id=? ORDER BY id DESC", eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []StructureVersion
+	for rows.Next() {
+		v, err := scanStructureVersion(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *v)
+	}
+	return list, rows.Err()
+}
+
+// structureChange is one difference between a version and the current
+// tree.
+type structureChange struct {
+	Kind    string // "group" or "task"
+	Change  string // "added", "deleted", "changed" or "moved" since the version
+	TitleFR string
+	TitleEN string
+}
+
+// diffStructure returns what changed from old to cur, items matched by id,
+// and whether the order of items within their groups changed.
+func diffStructure(old, cur templateStructure) (changes []structureChange, reordered bool) {
+	oldGroups := map[int64]TaskGroup{}
+	for _, g := range old.Groups {
+		oldGroups[g.ID] = g
+	}
+	curGroups := map[int64]TaskGroup{}
+	for _, g := range cur.Groups {
+		curGroups[g.ID] = g
+		o, ok := oldGroups[g.ID]
+		switch {
+		case !ok:
+			changes = append(changes, structureChange{"group", "added", g.TitleFR, g.TitleEN})
+		case o.ParentGroupID != g.ParentGroupID:
+			changes = append(changes, structureChange{"group", "moved", g.TitleFR, g.TitleEN})
+		case o.TitleFR != g.TitleFR || o.TitleEN != g.TitleEN || o.MaxSlots != g.MaxSlots || o.Color != g.Color || o.Icon != g.Icon:
+			changes = append(changes, structureChange{"group", "changed", g.TitleFR, g.TitleEN})
+		}
+		reordered = reordered || ok && o.Position != g.Position
+	}
+	for _, g := range old.Groups {
+		if _, ok := curGroups[g.ID]; !ok {
+			changes = append(changes, structureChange{"group", "deleted", g.TitleFR, g.TitleEN})
+		}
+	}
+
+	oldTasks := map[int64]Task{}
+	for _, t := range old.Tasks {
+		oldTasks[t.ID] = t
+	}
+	curTasks := map[int64]Task{}
+	for _, t := range cur.Tasks {
+		curTasks[t.ID] = t
+		o, ok := oldTasks[t.ID]
+		reordered = reordered || ok && o.Position != t.Position
+		switch {
+		case !ok:
+			changes = append(changes, structureChange{"task", "added", t.TitleFR, t.TitleEN})
+		case o.GroupID != t.GroupID:
+			changes = append(changes, structureChange{"task", "moved", t.TitleFR, t.TitleEN})
+		default:
+			o.Position, o.EventID = t.Position, t.EventID
+			if o != t {
+				changes = append(changes, structureChange{"task", "changed", t.TitleFR, t.TitleEN})
+			}
+		}
+	}
+	for _, t := range old.Tasks {
+		if _, ok := curTasks[t.ID]; !ok {
+			changes = append(changes, structureChange{"task", "deleted", t.TitleFR, t.TitleEN})
+		}
+	}
+	return changes, reordered
+}
+
+// RollbackStructure puts the groups and tasks of version v's event back as
+// they were in v. Items deleted since come back from the trash, with their
+// registrations, or are created again if they were purged; items added
+// since go to the trash.
+func RollbackStructure(db *sql.DB, v *StructureVersion) error {
+	for _, g := range v.Groups {
+		restoreTrashedItem(db, "group", g.ID, v.EventID)
+	}
+	for _, t := range v.Tasks {
+		restoreTrashedItem(db, "task", t.ID, v.EventID)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	exists := func(table string, id int64) bool {
+		var n int
+		tx.QueryRow("SELECT COUNT(*) FROM "+table+" WHERE id=? AND event_id=? AND trash_id IS NULL", id, v.EventID).Scan(&n)
+		return n > 0
+	}
+
+	// Parents must exist before their children, so walk the tree from the top.
+	ids := map[int64]int64{} // version's group id → current one
+	keepGroups := map[int64]bool{}
+	var putGroups func(parent sql.NullInt64) error
+	putGroups = func(parent sql.NullInt64) error {
+		for _, g := range v.Groups {
+			if g.ParentGroupID != parent {
+				continue
+			}
+			newParent := sql.NullInt64{}
+			if parent.Valid {
+				newParent = sql.NullInt64{Int64: ids[parent.Int64], Valid: true}
+			}
+			if exists("task_groups", g.ID) {
+				if _, err := tx.Exec("UPDATE task_groups SET parent_group_id=?, title_fr=?, title_en=?, position=?, max_slots=?, color=?, icon=? WHERE id=?",
+					newParent, g.TitleFR, g.TitleEN, g.Position, g.MaxSlots, g.Color, g.Icon, g.ID); err != nil {
+					return fmt.Errorf("roll back group %d: %w", g.ID, err)
+				}
+				ids[g.ID] = g.ID
+			} else {
+				res, err := tx.Exec("INSERT INTO task_groups (event_id, parent_group_id, title_fr, title_en, position, max_slots, color, icon) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+					v.EventID, newParent, g.TitleFR, g.TitleEN, g.Position, g.MaxSlots, g.Color, g.Icon)
+				if err != nil {
+					return fmt.Errorf("recreate group %d: %w", g.ID, err)
+				}
+				ids[g.ID], _ = res.LastInsertId()
+			}
+			keepGroups[ids[g.ID]] = true
+			if err := putGroups(sql.NullInt64{Int64: g.ID, Valid: true}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := putGroups(sql.NullInt64{}); err != nil {
+		return err
+	}
+
+	keepTasks := map[int64]bool{}
+	for _, t := range v.Tasks {
+		group := sql.NullInt64{}
+		if id, ok := ids[t.GroupID.Int64]; t.GroupID.Valid && ok {
+			group = sql.NullInt64{Int64: id, Valid: true}
+		}
+		if exists("tasks", t.ID) {
+			if _, err := tx.Exec("UPDATE tasks SET group_id=?, title_fr=?, title_en=?, description_fr=?, description_en=?, max_slots=?, start_time=?, end_time=?, position=?, max_guests=?, min_slots=?, skills=?, reserved_slots=?, notes=? WHERE id=?",
+				group, t.TitleFR, t.TitleEN, t.DescriptionFR, t.DescriptionEN, t.MaxSlots, t.StartTime, t.EndTime, t.Position, t.MaxGuests, t.MinSlots, t.Skills, t.ReservedSlots, t.Notes, t.ID); err != nil {
+				return fmt.Errorf("roll back task %d: %w", t.ID, err)
+			}
+			keepTasks[t.ID] = true
+			continue
+		}
+		res, err := tx.Exec(
+			"INSERT INTO tasks (event_id, group_id, title_fr, title_en, description_fr, description_en, max_slots, start_time, end_time, position, max_guests, min_slots, skills, reserved_slots, notes) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			v.EventID, group, t.TitleFR, t.TitleEN, t.DescriptionFR, t.DescriptionEN, t.MaxSlots, t.StartTime, t.EndTime, t.Position, t.MaxGuests, t.MinSlots, t.Skills, t.ReservedSlots, t.Notes,
+		)
+		if err != nil {
+			return fmt.Errorf("recreate task %d: %w", t.ID, err)
+		}
+		id, _ := res.LastInsertId()
+		keepTasks[id] = true
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	// Tasks first: the groups left then hold nothing worth promoting.
+	tasks, err := ListTasks(db, v.EventID)
+	if err != nil {
+		return err
+	}
+	for _, t := range tasks {
+		if !keepTasks[t.ID] {
+			if err := DeleteTask(db, t.ID); err != nil {
+				return err
+			}
+		}
+	}
+	groups, err := ListTaskGroups(db, v.EventID)
+	if err != nil {
+		return err
+	}
+	for _, g := range groups {
+		if !keepGroups[g.ID] {
+			if err := DeleteTaskGroup(db, g.ID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// restoreTrashedItem takes group or task id of event eventID out of the
+// trash, when it was deleted on its own. Failing that, the rollback
+// creates it again.
+func restoreTrashedItem(db *sql.DB, kind string, id, eventID int64) {
+	var trashID int64
+	err := db.QueryRow("SELECT id FROM trash WHERE kind=? AND item_id=? AND event_id=? ORDER BY id DESC LIMIT 1", kind, id, eventID).Scan(&trashID)
+	if err != nil {
+		return
+	}
+	if err := RestoreTrash(db, trashID); err != nil && !errors.Is(err, errTrashEmailTaken) {
+		log.Printf("structure history: restore %s %d: %v", kind, id, err)
+	}
+}
+
+// ---- Admin ----
+
+// structureVersionView is a version as listed on the history page.
+type structureVersionView struct {
+	StructureVersion
+	Changes   []structureChange
+	Reordered bool
+	Current   bool
+}
+
+func (app *App) handleAdminStructureHistory(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	event, err := GetEvent(app.DB, id)
+	if err != nil {
+		http.Redirect(w, r, "/admin", http.StatusSeeOther)
+		return
+	}
+	cur, err := currentStructure(app.DB, event.ID)
+	if err != nil {
+		log.Printf("structure history: tree of event %d: %v", event.ID, err)
+	}
+	versions, err := ListStructureVersions(app.DB, event.ID)
+	if err != nil {
+		log.Printf("structure history: list for event %d: %v", event.ID, err)
+	}
+	views := make([]structureVersionView, len(versions))
+	for i, v := range versions {
+		views[i].StructureVersion = v
+		views[i].Changes, views[i].Reordered = diffStructure(templateStructure{Groups: v.Groups, Tasks: v.Tasks}, cur)
+		views[i].Current = len(views[i].Changes) == 0 && !views[i].Reordered
+	}
+	pd := app.newPageData(r, map[string]any{"Event": event, "Versions": views})
+	pd.Success, pd.Error = takeFlash(w, r)
+	app.render(w, r, "admin_structure_history.html", pd)
+}
+
+func (app *App) handleAdminStructureRollback(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/admin", http.StatusSeeOther)
+		return
+	}
+	lang := LangFromRequest(r)
+	eventID, _ := strconv.ParseInt(r.FormValue("event_id"), 10, 64)
+	versionID, _ := strconv.ParseInt(r.FormValue("version_id"), 10, 64)
+	historyURL := fmt.Sprintf("/admin/event/history?id=%d&lang=%s", eventID, lang)
+	v, err := GetStructureVersion(app.DB, versionID)
+	if err != nil || v.EventID != eventID {
+		http.Redirect(w, r, historyURL, http.StatusSeeOther)
+		return
+	}
+
+	app.snapshotStructure(v.EventID, "before_rollback")
+	if err := RollbackStructure(app.DB, v); err != nil {
+		log.Printf("structure history: roll event %d back to version %d: %v", v.EventID, v.ID, err)
+		setFlash(w, "error", T("error_server", lang))
+		http.Redirect(w, r, historyURL, http.StatusSeeOther)
+		return
+	}
+	app.snapshotStructure(v.EventID, "rollback")
+	setFlash(w, "success", T("history_rolled_back", lang))
+	http.Redirect(w, r, fmt.Sprintf("/admin/event/edit?id=%d&lang=%s#groups-tasks", v.EventID, lang), http.StatusSeeOther)
+}
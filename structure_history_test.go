@@ -0,0 +1,87 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestStructureRollback(t *testing.T) {
+	app := testApp(t)
+	mux := newMux(app)
+	admin := adminCookie(app)
+	e := seedEvent(t, app.DB)
+	g := &TaskGroup{EventID: e.ID, TitleFR: "Bar", TitleEN: "Bar"}
+	CreateTaskGroup(app.DB, g)
+	bar := &Task{EventID: e.ID, GroupID: sql.NullInt64{Int64: g.ID, Valid: true}, TitleFR: "Service", TitleEN: "Service"}
+	CreateTask(app.DB, bar)
+	kitchen := seedTask(t, app.DB, e.ID, "Cuisine", nil)
+	RegisterForTask(app.DB, kitchen.ID, "Alice", "Dupont", "alice@test.com", "0601", "fr")
+
+	versions := func() []StructureVersion {
+		list, err := ListStructureVersions(app.DB, e.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return list
+	}
+	if err := SnapshotStructure(app.DB, e.ID, "edit"); err != nil {
+		t.Fatal(err)
+	}
+	SnapshotStructure(app.DB, e.ID, "before_ai")
+	if n := len(versions()); n != 1 {
+		t.Fatalf("%d versions of an unchanged tree, want 1", n)
+	}
+	first := versions()[0]
+
+	// A bad update: the kitchen goes to the trash, the group is deleted for
+	// good, the bar task is renamed and a parking task appears.
+	DeleteTask(app.DB, kitchen.ID)
+	DeleteTaskGroup(app.DB, g.ID)
+	var trashID int64
+	app.DB.QueryRow("SELECT id FROM trash WHERE kind='group' AND item_id=?", g.ID).Scan(&trashID)
+	PurgeTrashEntry(app.DB, trashID)
+	bar.TitleFR = "Plonge"
+	UpdateTask(app.DB, bar)
+	parking := seedTask(t, app.DB, e.ID, "Parking", nil)
+	SnapshotStructure(app.DB, e.ID, "ai")
+
+	w := getRequest(mux, fmt.Sprintf("/admin/event/history?id=%d&lang=en", e.ID), admin)
+	if w.Code != 200 {
+		t.Fatalf("history page: status %d", w.Code)
+	}
+	for _, want := range []string{"Parking", "Cuisine", "AI import", "Current version"} {
+		if !strings.Contains(w.Body.String(), want) {
+			t.Errorf("history page lacks %q", want)
+		}
+	}
+
+	w = postForm(mux, "/admin/event/history/rollback?lang=en", url.Values{
+		"event_id": {fmt.Sprint(e.ID)}, "version_id": {fmt.Sprint(first.ID)},
+	}, admin)
+	if w.Code != 303 {
+		t.Fatalf("rollback: status %d", w.Code)
+	}
+
+	if _, err := GetTask(app.DB, parking.ID); err != sql.ErrNoRows {
+		t.Error("task added since the version not deleted")
+	}
+	if n := CountRegistrations(app.DB, e.ID); n != 1 {
+		t.Errorf("%d registrations after rollback, want the kitchen's back", n)
+	}
+	got, err := GetTask(app.DB, bar.ID)
+	if err != nil || got.TitleFR != "Service" || !got.GroupID.Valid {
+		t.Fatalf("bar task after rollback: %+v, %v", got, err)
+	}
+	group, err := GetTaskGroup(app.DB, got.GroupID.Int64)
+	if err != nil || group.TitleFR != "Bar" {
+		t.Errorf("purged group not recreated: %+v, %v", group, err)
+	}
+
+	list := versions()
+	if len(list) != 3 || list[0].Reason != "rollback" {
+		t.Errorf("versions after rollback: %d, latest %q", len(list), list[0].Reason)
+	}
+}
@@ -0,0 +1,233 @@
+package main
+
+// Task rules. A task can require another — only people already signed up
+// for "Has license confirmed" may take "Driver" — or exclude one, when the
+// same person can't do both: "Bar" and "Cashier" at the same hour. Admins
+// set them on the event edit page, where the tree shows each task's rules;
+// RegisterGroupForTask enforces them against the email address's other
+// registrations for the event.
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// Kinds of task rules.
+const (
+	ruleRequires = "requires"
+	ruleExcludes = "excludes"
+)
+
+// TaskRule ties task TaskID to task OtherTaskID. Exclusion goes both ways.
+type TaskRule struct {
+	ID           int64
+	EventID      int64
+	TaskID       int64
+	OtherTaskID  int64
+	Kind         string // ruleRequires or ruleExcludes
+	TaskTitleFR  string
+	TaskTitleEN  string
+	OtherTitleFR string
+	OtherTitleEN string
+}
+
+// taskRuleError is returned by RegisterGroupForTask when a rule keeps the
+// email address from the task: it lacks the required task, or holds an
+// excluded one.
+type taskRuleError struct {
+	Kind                       string
+	OtherTitleFR, OtherTitleEN string
+}
+
+func (e *taskRuleError) Error() string {
+	return fmt.Sprintf("task rule: %s %q", e.Kind, e.OtherTitleFR)
+}
+
+// message spells out the error for someone signing up in lang.
+func (e *taskRuleError) message(lang string) string {
+	key := "error_task_requires"
+	if e.Kind == ruleExcludes {
+		key = "error_task_excludes"
+	}
+	return fmt.Sprintf(T(key, lang), Localized(e.OtherTitleFR, e.OtherTitleEN, lang))
+}
+
+// ListTaskRules returns the rules of event eventID between tasks not in the
+// trash.
+func ListTaskRules(db *sql.DB, eventID int64) ([]TaskRule, error) {
+	rows, err := db.Query(`SELECT r.id, r.event_id, r.task_id, r.other_task_id, r.kind,
+			t.title_fr, t.title_en, o.title_fr, o.title_en
+		FROM task_rules r
+		JOIN tasks t ON t.id = r.task_id AND t.trash_id IS NULL
+		JOIN tasks o ON o.id = r.other_task_id AND o.trash_id IS NULL
+		WHERE r.event_id = ? ORDER BY t.position, t.id, r.id`, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var rules []TaskRule
+	for rows.Next() {
+		var r TaskRule
+		if err := rows.Scan(&r.ID, &r.EventID, &r.TaskID, &r.OtherTaskID, &r.Kind,
+			&r.TaskTitleFR, &r.TaskTitleEN, &r.OtherTitleFR, &r.OtherTitleEN); err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+	}
+	return rules, rows.Err()
+}
+
+// rulesByTask returns the rules bearing on each task, by task id, each seen
+// from that task: an exclusion shows on both of its tasks.
+func rulesByTask(rules []TaskRule) map[int64][]TaskRule {
+	byTask := map[int64][]TaskRule{}
+	for _, r := range rules {
+		byTask[r.TaskID] = append(byTask[r.TaskID], r)
+		if r.Kind == ruleExcludes {
+			byTask[r.OtherTaskID] = append(byTask[r.OtherTaskID], TaskRule{
+				ID: r.ID, EventID: r.EventID, TaskID: r.OtherTaskID, OtherTaskID: r.TaskID, Kind: r.Kind,
+				TaskTitleFR: r.OtherTitleFR, TaskTitleEN: r.OtherTitleEN, OtherTitleFR: r.TaskTitleFR, OtherTitleEN: r.TaskTitleEN,
+			})
+		}
+	}
+	return byTask
+}
+
+// SaveTaskRule adds rule r; adding one that exists does nothing.
+func SaveTaskRule(db *sql.DB, r *TaskRule) error {
+	_, err := db.Exec("INSERT OR IGNORE INTO task_rules (event_id, task_id, other_task_id, kind) VALUES (?, ?, ?, ?)",
+		r.EventID, r.TaskID, r.OtherTaskID, r.Kind)
+	return err
+}
+
+// DeleteTaskRule removes rule id of event eventID.
+func DeleteTaskRule(db *sql.DB, eventID, id int64) error {
+	_, err := db.Exec("DELETE FROM task_rules WHERE id=? AND event_id=?", id, eventID)
+	return err
+}
+
+// ReplaceTaskRules gives event toID a copy of the task rules of event
+// fromID in place of its own, like ReplaceFormFields: it runs after
+// ReplaceTaskStructure and maps each task to its copy by order.
+func ReplaceTaskRules(db *sql.DB, fromID, toID int64) error {
+	rules, err := ListTaskRules(db, fromID)
+	if err != nil {
+		return err
+	}
+	fromTasks, err := ListTasks(db, fromID)
+	if err != nil {
+		return err
+	}
+	toTasks, err := ListTasks(db, toID)
+	if err != nil {
+		return err
+	}
+	taskCopies := map[int64]int64{}
+	if len(fromTasks) == len(toTasks) {
+		for i, t := range fromTasks {
+			taskCopies[t.ID] = toTasks[i].ID
+		}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec("DELETE FROM task_rules WHERE event_id=?", toID); err != nil {
+		return err
+	}
+	for _, r := range rules {
+		taskID, ok := taskCopies[r.TaskID]
+		otherID, otherOK := taskCopies[r.OtherTaskID]
+		if !ok || !otherOK {
+			continue
+		}
+		if _, err := tx.Exec("INSERT OR IGNORE INTO task_rules (event_id, task_id, other_task_id, kind) VALUES (?, ?, ?, ?)",
+			toID, taskID, otherID, r.Kind); err != nil {
+			return fmt.Errorf("copy task rule %d: %w", r.ID, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// checkTaskRules returns a *taskRuleError when the rules of task taskID keep
+// the person — email and member — from signing up for it.
+func checkTaskRules(tx *sql.Tx, taskID int64, email string, member int) error {
+	for _, c := range []struct{ kind, query string }{
+		{ruleRequires, `SELECT o.title_fr, o.title_en FROM task_rules r
+			JOIN tasks o ON o.id = r.other_task_id AND o.trash_id IS NULL
+			WHERE r.kind = 'requires' AND r.task_id = ?1 AND NOT EXISTS (
+				SELECT 1 FROM registrations g WHERE g.task_id = o.id AND g.trash_id IS NULL AND LOWER(g.email) = LOWER(?2) AND g.member = ?3)
+			ORDER BY r.id LIMIT 1`},
+		{ruleExcludes, `SELECT o.title_fr, o.title_en FROM task_rules r
+			JOIN tasks o ON o.id = CASE WHEN r.task_id = ?1 THEN r.other_task_id ELSE r.task_id END AND o.trash_id IS NULL
+			WHERE r.kind = 'excludes' AND ?1 IN (r.task_id, r.other_task_id) AND EXISTS (
+				SELECT 1 FROM registrations g WHERE g.task_id = o.id AND g.trash_id IS NULL AND LOWER(g.email) = LOWER(?2) AND g.member = ?3)
+			ORDER BY r.id LIMIT 1`},
+	} {
+		var titleFR, titleEN string
+		err := tx.QueryRow(c.query, taskID, email, member).Scan(&titleFR, &titleEN)
+		if err == nil {
+			return &taskRuleError{Kind: c.kind, OtherTitleFR: titleFR, OtherTitleEN: titleEN}
+		} else if err != sql.ErrNoRows {
+			return err
+		}
+	}
+	return nil
+}
+
+// ---- Admin ----
+
+func (app *App) handleAdminTaskRuleSave(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/admin", http.StatusSeeOther)
+		return
+	}
+	lang := LangFromRequest(r)
+	eventID, _ := strconv.ParseInt(r.FormValue("event_id"), 10, 64)
+	editURL := fmt.Sprintf("/admin/event/edit?id=%d&lang=%s#task-rules", eventID, lang)
+	rule := &TaskRule{EventID: eventID, Kind: r.FormValue("kind")}
+	rule.TaskID, _ = strconv.ParseInt(r.FormValue("task_id"), 10, 64)
+	rule.OtherTaskID, _ = strconv.ParseInt(r.FormValue("other_task_id"), 10, 64)
+	for _, id := range []int64{rule.TaskID, rule.OtherTaskID} {
+		if t, err := GetTask(app.DB, id); err != nil || t.EventID != eventID {
+			http.Redirect(w, r, editURL, http.StatusSeeOther)
+			return
+		}
+	}
+	if rule.Kind != ruleRequires && rule.Kind != ruleExcludes {
+		http.Redirect(w, r, editURL, http.StatusSeeOther)
+		return
+	}
+	if rule.TaskID == rule.OtherTaskID {
+		setFlash(w, "error", T("task_rule_error_same", lang))
+		http.Redirect(w, r, editURL, http.StatusSeeOther)
+		return
+	}
+	if err := SaveTaskRule(app.DB, rule); err != nil {
+		log.Printf("task rules: save for event %d: %v", eventID, err)
+		setFlash(w, "error", T("error_server", lang))
+	} else {
+		setFlash(w, "success", T("task_rule_saved", lang))
+	}
+	http.Redirect(w, r, editURL, http.StatusSeeOther)
+}
+
+func (app *App) handleAdminTaskRuleDelete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/admin", http.StatusSeeOther)
+		return
+	}
+	lang := LangFromRequest(r)
+	eventID, _ := strconv.ParseInt(r.FormValue("event_id"), 10, 64)
+	id, _ := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	if err := DeleteTaskRule(app.DB, eventID, id); err != nil {
+		log.Printf("task rules: delete %d: %v", id, err)
+		setFlash(w, "error", T("error_server", lang))
+	}
+	http.Redirect(w, r, fmt.Sprintf("/admin/event/edit?id=%d&lang=%s#task-rules", eventID, lang), http.StatusSeeOther)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestTaskRules(t *testing.T) {
+	app := testApp(t)
+	mux := newMux(app)
+	admin := adminCookie(app)
+	e := &Event{TitleFR: "Fête", EventDate: "2026-06-15", MaxTasksPerPerson: 3}
+	if err := CreateEvent(app.DB, e); err != nil {
+		t.Fatal(err)
+	}
+	license := seedTask(t, app.DB, e.ID, "Permis vérifié", nil)
+	driver := seedTask(t, app.DB, e.ID, "Chauffeur", nil)
+	bar := seedTask(t, app.DB, e.ID, "Buvette", nil)
+	cash := seedTask(t, app.DB, e.ID, "Caisse", nil)
+
+	for _, rule := range [][3]any{{driver.ID, ruleRequires, license.ID}, {bar.ID, ruleExcludes, cash.ID}, {bar.ID, ruleExcludes, bar.ID}} {
+		postForm(mux, "/admin/event/rules/save?lang=en", url.Values{
+			"event_id": {fmt.Sprint(e.ID)}, "task_id": {fmt.Sprint(rule[0])}, "kind": {rule[1].(string)}, "other_task_id": {fmt.Sprint(rule[2])},
+		}, admin)
+	}
+	rules, _ := ListTaskRules(app.DB, e.ID)
+	if len(rules) != 2 {
+		t.Fatalf("rules = %+v", rules)
+	}
+	if byTask := rulesByTask(rules); len(byTask[cash.ID]) != 1 || byTask[cash.ID][0].OtherTaskID != bar.ID {
+		t.Errorf("exclusion not shown on both tasks: %+v", byTask)
+	}
+
+	if _, err := RegisterForTask(app.DB, driver.ID, "Alice", "Dupont", "alice@test.com", "0601", "en"); err == nil || !strings.Contains(err.(*taskRuleError).message("en"), "Permis vérifié") {
+		t.Errorf("driver without the required task: %v", err)
+	}
+	RegisterForTask(app.DB, license.ID, "Alice", "Dupont", "alice@test.com", "0601", "en")
+	if _, err := RegisterForTask(app.DB, driver.ID, "Alice", "Dupont", "Alice@test.com", "0601", "en"); err != nil {
+		t.Errorf("driver with the required task: %v", err)
+	}
+
+	RegisterForTask(app.DB, cash.ID, "Bob", "Martin", "bob@test.com", "0602", "en")
+	w := postForm(mux, "/signup?lang=en", url.Values{
+		"task_id": {fmt.Sprint(bar.ID)}, "first_name": {"Bob"}, "last_name": {"Martin"},
+		"email": {"bob@test.com"}, "phone": {"0602"},
+	})
+	if !strings.Contains(w.Body.String(), "combined with") {
+		t.Error("signup for an excluded task not turned away")
+	}
+	if _, err := RegisterForTask(app.DB, bar.ID, "Carol", "Durand", "carol@test.com", "0603", "en"); err != nil {
+		t.Errorf("signup of someone else: %v", err)
+	}
+
+	body := getRequest(mux, fmt.Sprintf("/admin/event/edit?id=%d&lang=en", e.ID), admin).Body.String()
+	if !strings.Contains(body, "task-rule-requires") || strings.Count(body, "task-rule-excludes") != 2 {
+		t.Error("rules not shown in the admin tree")
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+// Task sheets. /admin/export/pdf prints an event's tasks for posting on the
+// wall on the day: a section per task, under its groups, listing who is on
+// it with their phone number, and a blank line for each spot still open so
+// latecomers can write themselves in.
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Task sheet layout, in points.
+const (
+	sheetMargin    = 40.0
+	sheetRowHeight = 17.0
+	sheetPhoneX    = 360.0
+	// sheetMaxBlank bounds the blank lines of a task with many spots open.
+	sheetMaxBlank = 15
+)
+
+func (app *App) handleAdminExportPDF(w http.ResponseWriter, r *http.Request) {
+	eventID, _ := strconv.ParseInt(r.URL.Query().Get("event_id"), 10, 64)
+	event, err := GetEvent(app.DB, eventID)
+	if err != nil || event.EventType != "tasks" {
+		http.Error(w, "Not found", 404)
+		return
+	}
+	tree, err := BuildEventTree(app.DB, event.ID)
+	if err != nil {
+		log.Printf("task sheets: tree of event %d: %v", event.ID, err)
+		http.Error(w, T("error_server", LangFromRequest(r)), 500)
+		return
+	}
+	loadTreeRegistrations(app.DB, tree)
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="%s-tasks.pdf"`, event.Slug))
+	w.Write(renderTaskSheets(event, tree, LangFromRequest(r)))
+}
+
+// renderTaskSheets returns the task sheets of event, whose tree has its
+// registrations loaded, as a PDF.
+func renderTaskSheets(event *Event, tree []TreeNode, lang string) []byte {
+	d := &pdfDoc{}
+	title := Localized(event.TitleFR, event.TitleEN, lang)
+	right := pdfPageWidth - sheetMargin
+	var y float64
+	newPage := func() {
+		d.addPage()
+		y = pdfPageHeight - sheetMargin
+	}
+	// need starts a new page unless height points are left on this one.
+	need := func(height float64) {
+		if y-height < sheetMargin+20 {
+			newPage()
+		}
+	}
+
+	newPage()
+	y = sheetHeader(d, event, "", lang)
+
+	printed := ""
+	var walk func(nodes []TreeNode, path []string)
+	walk = func(nodes []TreeNode, path []string) {
+		for _, n := range nodes {
+			if n.Type == "group" && n.Group != nil {
+				walk(n.Children, append(path, Localized(n.Group.TitleFR, n.Group.TitleEN, lang)))
+				continue
+			}
+			if n.Task == nil {
+				continue
+			}
+			task := n.Task
+			blank := 0
+			if task.SlotsLeft > 0 {
+				blank = min(task.SlotsLeft, sheetMaxBlank)
+			}
+			groups := strings.Join(path, " › ")
+			heading := 26.0
+			if groups != printed {
+				heading += 24
+			}
+			need(heading + sheetRowHeight)
+			if groups != printed {
+				y -= 20
+				if groups != "" {
+					d.text(sheetMargin, y, 13, true, pdfFit(groups, right-sheetMargin, 13, true))
+				}
+				y -= 4
+				printed = groups
+			}
+
+			y -= 18
+			name := Localized(task.TitleFR, task.TitleEN, lang)
+			if times := formatTaskTimes(task.Task, lang); times != "" {
+				name += " · " + times
+			}
+			count := strconv.Itoa(task.RegCount)
+			if task.MaxSlots.Valid {
+				count += " / " + strconv.FormatInt(task.MaxSlots.Int64, 10)
+			}
+			countWidth := pdfTextWidth(count, 10, false)
+			d.text(sheetMargin, y, 11, true, pdfFit(name, right-sheetMargin-countWidth-12, 11, true))
+			d.text(right-countWidth, y, 10, false, count)
+			y -= 6
+			d.line(sheetMargin, y, right, y, 0.75, 0)
+
+			if len(task.Registrations) == 0 && blank == 0 {
+				y -= sheetRowHeight - 4
+				d.text(sheetMargin+4, y, 10, false, T("sheets_nobody", lang))
+				y -= 4
+				continue
+			}
+			for _, reg := range task.Registrations {
+				need(sheetRowHeight)
+				y -= sheetRowHeight
+				person := strings.TrimSpace(reg.FirstName + " " + reg.LastName)
+				if reg.Guests > 0 {
+					person += fmt.Sprintf(" +%d", reg.Guests)
+				}
+				d.text(sheetMargin+4, y+5, 10, false, pdfFit(person, sheetPhoneX-sheetMargin-16, 10, false))
+				d.text(sheetPhoneX, y+5, 10, false, pdfFit(reg.Phone, right-sheetPhoneX, 10, false))
+				d.line(sheetMargin, y, right, y, 0.5, 0.75)
+			}
+			for range blank {
+				need(sheetRowHeight)
+				y -= sheetRowHeight
+				d.line(sheetMargin, y, right, y, 0.5, 0.75)
+			}
+		}
+	}
+	walk(tree, nil)
+	sheetFooters(d, title, lang)
+	return d.bytes()
+}
+
+// sheetHeader writes the title of event at the top of the first page, with
+// its date and subtitle, if any, and returns where the page goes on below.
+func sheetHeader(d *pdfDoc, event *Event, subtitle, lang string) (y float64) {
+	right := pdfPageWidth - sheetMargin
+	y = pdfPageHeight - sheetMargin - 18
+	d.text(sheetMargin, y, 18, true, pdfFit(Localized(event.TitleFR, event.TitleEN, lang), right-sheetMargin, 18, true))
+	y -= 18
+	when := formatEventDate(event.EventDate, lang)
+	if t := formatEventTimeZoned(*event, lang); t != "" {
+		when += " · " + t
+	}
+	if subtitle != "" {
+		when = subtitle + " — " + when
+	}
+	d.text(sheetMargin, y, 10, false, pdfFit(when, right-sheetMargin, 10, false))
+	y -= 10
+	d.line(sheetMargin, y, right, y, 1, 0)
+	return y - 10
+}
+
+// sheetFooters writes title and the page number at the bottom of each page.
+func sheetFooters(d *pdfDoc, title, lang string) {
+	right := pdfPageWidth - sheetMargin
+	for i, page := range d.pages {
+		d.page = page
+		footer := fmt.Sprintf(T("sheets_page", lang), i+1, len(d.pages))
+		d.text(sheetMargin, sheetMargin-16, 8, false, pdfFit(title, right-sheetMargin-80, 8, false))
+		d.text(right-pdfTextWidth(footer, 8, false), sheetMargin-16, 8, false, footer)
+	}
+}
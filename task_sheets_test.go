@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestWinAnsi(t *testing.T) {
+	got := pdfEscape(winAnsi("Élise (Œuvre) – 5 € \\ 日"))
+	want := []byte("\xc9lise \\(\x8cuvre\\) \x96 5 \x80 \\\\ ?")
+	if !bytes.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if s := pdfFit("Installation des tables et des chaises", 100, 10, false); !strings.HasSuffix(s, "…") || pdfTextWidth(s, 10, false) > 100 {
+		t.Errorf("pdfFit = %q", s)
+	}
+}
+
+func TestTaskSheets(t *testing.T) {
+	app := testApp(t)
+	mux := newMux(app)
+	e := seedEvent(t, app.DB)
+	three := int64(3)
+	bar := seedTask(t, app.DB, e.ID, "Buvette", &three)
+	RegisterGroupForTask(app.DB, bar.ID, 0, "Zoé", "Dupont", "zoe@test.com", "06 01 02 03 04", "fr")
+
+	w := getRequest(mux, fmt.Sprintf("/admin/export/pdf?event_id=%d&lang=fr", e.ID), adminCookie(app))
+	body := w.Body.Bytes()
+	if w.Code != 200 || w.Header().Get("Content-Type") != "application/pdf" || !bytes.HasPrefix(body, []byte("%PDF-")) {
+		t.Fatalf("status %d, type %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	for _, want := range []string{"Buvette", "Zo\xe9 Dupont", "06 01 02 03 04", "1 / 3", "/Count 1", "%%EOF"} {
+		if !bytes.Contains(body, []byte(want)) {
+			t.Errorf("PDF misses %q", want)
+		}
+	}
+	if w := getRequest(mux, fmt.Sprintf("/admin/export/pdf?event_id=%d", e.ID)); w.Code == 200 {
+		t.Error("task sheets served without a session")
+	}
+}
@@ -6,10 +6,13 @@ import (
 	"fmt"
 	"sync"
 	"testing"
+
+	"golang.org/x/crypto/bcrypt"
 )
 
 // testDB creates an in-memory SQLite database with the schema applied.
-// It returns the db and a cleanup function.
+// It returns the db and a cleanup function. An in-memory database only
+// exists within its connection, hence the single-connection pool.
 func testDB(t *testing.T) *sql.DB {
 	t.Helper()
 	db, err := sql.Open("sqlite3", ":memory:?_foreign_keys=ON")
@@ -28,11 +31,17 @@ func testDB(t *testing.T) *sql.DB {
 func testApp(t *testing.T) *App {
 	t.Helper()
 	db := testDB(t)
+	adminPasswordCost = bcrypt.MinCost
+	if err := loadInstanceSettings(db); err != nil {
+		t.Fatalf("instance settings: %v", err)
+	}
+	t.Cleanup(func() { instance.Store(nil) })
 	return &App{
 		DB:            db,
 		AdminPassword: "testpass",
 		Email:         &fakeEmailSender{},
 		SNSSkipVerify: true,
+		SkipFormStamp: true,
 		// EmailSendDelay: 0 and AsyncEmail: false (zero values) — reveal emails
 		// send synchronously in tests, so no goroutine races with t.Cleanup.
 	}
@@ -66,6 +75,7 @@ func int64Ptr(v int64) *int64 { return &v }
 // sentEmail records one email handed to fakeEmailSender.
 type sentEmail struct {
 	To          string
+	ReplyTo     string
 	Subject     string
 	HTML        string
 	MessageID   string
@@ -80,7 +90,7 @@ type fakeEmailSender struct {
 	failUntil int
 }
 
-func (f *fakeEmailSender) Send(ctx context.Context, to, subject, htmlBody string, attachments ...emailAttachment) (string, error) {
+func (f *fakeEmailSender) Send(ctx context.Context, to, replyTo, subject, htmlBody string, attachments ...emailAttachment) (string, error) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 	if f.failUntil > 0 {
@@ -92,7 +102,7 @@ func (f *fakeEmailSender) Send(ctx context.Context, to, subject, htmlBody string
 	for _, a := range attachments {
 		names = append(names, a.Filename)
 	}
-	f.sent = append(f.sent, sentEmail{To: to, Subject: subject, HTML: htmlBody, MessageID: id, Attachments: names})
+	f.sent = append(f.sent, sentEmail{To: to, ReplyTo: replyTo, Subject: subject, HTML: htmlBody, MessageID: id, Attachments: names})
 	return id, nil
 }
 
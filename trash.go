@@ -0,0 +1,277 @@
+package main
+
+// The admin trash. Deleting an event, group, task or registration from the
+// admin only marks it: the row, and everything deleted along with it (an
+// event's groups, tasks and registrations, a task's registrations), gets the
+// id of a trash entry in its trash_id column and drops out of every query.
+// "Clear all" on the edit page trashes an event's whole tree as one entry.
+// Restoring clears that mark; purging, by hand or 30 days later, deletes the
+// rows for good.
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	trashRetention     = 30 * 24 * time.Hour
+	trashPurgeInterval = time.Hour
+)
+
+// errTrashParentDeleted is returned when restoring something whose event or
+// task is itself in the trash.
+var errTrashParentDeleted = errors.New("trash: parent is deleted")
+
+// errTrashEmailTaken is returned when restoring a registration whose
+// email address has signed up for the event again since.
+var errTrashEmailTaken = errors.New("trash: email already registered")
+
+// TrashEntry is one deletion waiting in the trash.
+type TrashEntry struct {
+	ID           int64
+	Kind         string // "event", "group", "task", "registration" or "structure"
+	ItemID       int64
+	EventID      int64
+	LabelFR      string
+	LabelEN      string
+	EventTitleFR string
+	EventTitleEN string
+	DeletedAt    time.Time
+}
+
+// moveToTrash records the deletion of an item and marks its rows. Each stamp
+// statement takes the new trash id and itemID.
+func moveToTrash(db *sql.DB, kind string, itemID, eventID int64, labelFR, labelEN string, stamps ...string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	res, err := tx.Exec("INSERT INTO trash (kind, item_id, event_id, label_fr, label_en) VALUES (?, ?, ?, ?, ?)",
+		kind, itemID, eventID, labelFR, labelEN)
+	if err != nil {
+		return err
+	}
+	trashID, _ := res.LastInsertId()
+	for _, q := range stamps {
+		if _, err := tx.Exec(q, trashID, itemID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ListTrash returns the trash, most recently deleted first.
+func ListTrash(db *sql.DB) ([]TrashEntry, error) {
+	rows, err := db.Query(`SELECT tr.id, tr.kind, tr.item_id, tr.event_id, tr.label_fr, tr.label_en,
+			COALESCE(e.title_fr, ''), COALESCE(e.title_en, ''), tr.deleted_at
+		FROM trash tr LEFT JOIN events e ON e.id = tr.event_id
+		ORDER BY tr.deleted_at DESC, tr.id DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []TrashEntry
+	for rows.Next() {
+		var t TrashEntry
+		if err := rows.Scan(&t.ID, &t.Kind, &t.ItemID, &t.EventID, &t.LabelFR, &t.LabelEN,
+			&t.EventTitleFR, &t.EventTitleEN, &t.DeletedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, t)
+	}
+	return list, rows.Err()
+}
+
+// CountTrash returns how many deletions are in the trash.
+func CountTrash(db *sql.DB) int {
+	var n int
+	db.QueryRow("SELECT COUNT(*) FROM trash").Scan(&n)
+	return n
+}
+
+// RestoreTrash brings back everything deleted with trash entry id. Groups and
+// tasks whose parent group has been deleted since come back at the top level.
+func RestoreTrash(db *sql.DB, id int64) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	var kind string
+	var itemID, eventID int64
+	if err := tx.QueryRow("SELECT kind, item_id, event_id FROM trash WHERE id=?", id).Scan(&kind, &itemID, &eventID); err != nil {
+		return err
+	}
+	if kind != "event" {
+		var deleted int
+		tx.QueryRow("SELECT COUNT(*) FROM events WHERE id=? AND trash_id IS NOT NULL", eventID).Scan(&deleted)
+		if kind == "registration" {
+			var taskDeleted int
+			tx.QueryRow(`SELECT COUNT(*) FROM tasks t JOIN registrations r ON r.task_id = t.id
+				WHERE r.id=? AND t.trash_id IS NOT NULL`, itemID).Scan(&taskDeleted)
+			deleted += taskDeleted
+		}
+		if deleted > 0 {
+			return errTrashParentDeleted
+		}
+	}
+	for _, q := range []string{
+		"UPDATE task_groups SET parent_group_id=NULL WHERE trash_id=? AND parent_group_id IN (SELECT id FROM task_groups WHERE trash_id IS NOT NULL AND trash_id != ?)",
+		"UPDATE tasks SET group_id=NULL WHERE trash_id=? AND group_id IN (SELECT id FROM task_groups WHERE trash_id IS NOT NULL AND trash_id != ?)",
+	} {
+		if _, err := tx.Exec(q, id, id); err != nil {
+			return err
+		}
+	}
+	for _, q := range []string{
+		"UPDATE events SET trash_id=NULL WHERE trash_id=?",
+		"UPDATE task_groups SET trash_id=NULL WHERE trash_id=?",
+		"UPDATE tasks SET trash_id=NULL WHERE trash_id=?",
+		"UPDATE registrations SET trash_id=NULL WHERE trash_id=?",
+		"DELETE FROM trash WHERE id=?",
+	} {
+		if _, err := tx.Exec(q, id); isUniqueViolation(err) {
+			return errTrashEmailTaken
+		} else if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// PurgeTrashEntry deletes for good everything deleted with trash entry id.
+func PurgeTrashEntry(db *sql.DB, id int64) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, q := range []string{
+		"DELETE FROM registrations WHERE trash_id=?",
+		"DELETE FROM tasks WHERE trash_id=?",
+		"DELETE FROM task_groups WHERE trash_id=?",
+		"DELETE FROM events WHERE trash_id=?",
+		"DELETE FROM trash WHERE id=?",
+	} {
+		if _, err := tx.Exec(q, id); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	return pruneTrash(db)
+}
+
+// pruneTrash drops entries whose item is gone, deleted along with an event
+// or task purged before it.
+func pruneTrash(db *sql.DB) error {
+	_, err := db.Exec(`DELETE FROM trash WHERE
+		(kind IN ('event', 'structure') AND item_id NOT IN (SELECT id FROM events)) OR
+		(kind = 'group' AND item_id NOT IN (SELECT id FROM task_groups)) OR
+		(kind = 'task' AND item_id NOT IN (SELECT id FROM tasks)) OR
+		(kind = 'registration' AND item_id NOT IN (SELECT id FROM registrations))`)
+	return err
+}
+
+// PurgeTrash deletes for good what has been in the trash longer than d.
+func PurgeTrash(db *sql.DB, d time.Duration) error {
+	rows, err := db.Query("SELECT id FROM trash WHERE deleted_at <= ?", time.Now().UTC().Add(-d).Format("2006-01-02 15:04:05"))
+	if err != nil {
+		return err
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	for _, id := range ids {
+		if err := PurgeTrashEntry(db, id); err != nil {
+			return fmt.Errorf("purge trash %d: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// runTrashWorker purges expired trash until ctx is done.
+func (app *App) runTrashWorker(ctx context.Context) {
+	ticker := time.NewTicker(trashPurgeInterval)
+	defer ticker.Stop()
+	for {
+		if err := PurgeTrash(app.DB, trashRetention); err != nil {
+			log.Printf("trash: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ---- Admin ----
+
+func (app *App) handleAdminTrash(w http.ResponseWriter, r *http.Request) {
+	if err := pruneTrash(app.DB); err != nil {
+		log.Printf("trash: prune: %v", err)
+	}
+	entries, err := ListTrash(app.DB)
+	if err != nil {
+		log.Printf("trash: list: %v", err)
+	}
+	pd := app.newPageData(r, map[string]any{"Entries": entries})
+	pd.Success, pd.Error = takeFlash(w, r)
+	app.render(w, r, "admin_trash.html", pd)
+}
+
+func (app *App) handleAdminTrashRestore(w http.ResponseWriter, r *http.Request) {
+	lang := LangFromRequest(r)
+	trashURL := "/admin/trash?lang=" + lang
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, trashURL, http.StatusSeeOther)
+		return
+	}
+	id, _ := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	switch err := RestoreTrash(app.DB, id); {
+	case err == nil:
+		setFlash(w, "success", T("trash_restored", lang))
+	case errors.Is(err, errTrashParentDeleted):
+		setFlash(w, "error", T("trash_parent_deleted", lang))
+	case errors.Is(err, errTrashEmailTaken):
+		setFlash(w, "error", T("trash_email_taken", lang))
+	case errors.Is(err, sql.ErrNoRows):
+	default:
+		log.Printf("trash: restore %d: %v", id, err)
+		setFlash(w, "error", T("error_server", lang))
+	}
+	http.Redirect(w, r, trashURL, http.StatusSeeOther)
+}
+
+func (app *App) handleAdminTrashPurge(w http.ResponseWriter, r *http.Request) {
+	lang := LangFromRequest(r)
+	trashURL := "/admin/trash?lang=" + lang
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, trashURL, http.StatusSeeOther)
+		return
+	}
+	id, _ := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	if err := PurgeTrashEntry(app.DB, id); err != nil {
+		log.Printf("trash: purge %d: %v", id, err)
+		setFlash(w, "error", T("error_server", lang))
+	} else {
+		setFlash(w, "success", T("trash_purged", lang))
+	}
+	http.Redirect(w, r, trashURL, http.StatusSeeOther)
+}
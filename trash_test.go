@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTrash(t *testing.T) {
+	app := testApp(t)
+	e := seedEvent(t, app.DB)
+	tk := seedTask(t, app.DB, e.ID, "Vaisselle", int64Ptr(1))
+	alice, _ := RegisterForTask(app.DB, tk.ID, "Alice", "Dupont", "alice@test.com", "0601", "fr")
+	mux := newMux(app)
+	admin := adminCookie(app)
+
+	postForm(mux, "/admin/registrations/delete", url.Values{"id": {fmt.Sprint(alice.ID)}, "event_id": {fmt.Sprint(e.ID)}}, admin)
+	if CountRegistrations(app.DB, e.ID) != 0 {
+		t.Fatal("deleted registration still listed")
+	}
+	if _, err := RegisterForTask(app.DB, tk.ID, "Bob", "Martin", "bob@test.com", "0602", "fr"); err != nil {
+		t.Fatalf("deleted registration still takes its place: %v", err)
+	}
+	body := getRequest(mux, "/admin/trash?lang=en", admin).Body.String()
+	if !strings.Contains(body, "Alice Dupont &lt;alice@test.com&gt;") {
+		t.Error("trash does not list the registration")
+	}
+
+	// Deleting the event takes everything along; restoring brings it all back.
+	DeleteEvent(app.DB, e.ID)
+	if events, _ := ListEvents(app.DB); len(events) != 0 {
+		t.Fatal("deleted event still listed")
+	}
+	entries, _ := ListTrash(app.DB)
+	if len(entries) != 2 || entries[0].Kind != "event" {
+		t.Fatalf("trash = %+v", entries)
+	}
+	if err := RestoreTrash(app.DB, entries[1].ID); err != errTrashParentDeleted {
+		t.Errorf("restored a registration of a deleted event: %v", err)
+	}
+	postForm(mux, "/admin/trash/restore?lang=en", url.Values{"id": {fmt.Sprint(entries[0].ID)}}, admin)
+	if _, err := GetEvent(app.DB, e.ID); err != nil {
+		t.Fatalf("event not restored: %v", err)
+	}
+	if CountRegistrations(app.DB, e.ID) != 1 {
+		t.Error("restoring the event brought back the registration deleted before it")
+	}
+	if err := RestoreTrash(app.DB, entries[1].ID); err != nil {
+		t.Fatal(err)
+	}
+	if CountRegistrations(app.DB, e.ID) != 2 {
+		t.Error("registration not restored")
+	}
+
+	// Purging deletes for good.
+	DeleteTask(app.DB, tk.ID)
+	entries, _ = ListTrash(app.DB)
+	postForm(mux, "/admin/trash/purge?lang=en", url.Values{"id": {fmt.Sprint(entries[0].ID)}}, admin)
+	var n int
+	app.DB.QueryRow("SELECT COUNT(*) FROM registrations").Scan(&n)
+	if n != 0 || CountTrash(app.DB) != 0 {
+		t.Errorf("after purge: %d registrations, %d in trash", n, CountTrash(app.DB))
+	}
+
+	// Entries older than the retention period are purged automatically.
+	DeleteEvent(app.DB, e.ID)
+	PurgeTrash(app.DB, trashRetention)
+	if CountTrash(app.DB) != 1 {
+		t.Fatal("a fresh deletion was purged")
+	}
+	app.DB.Exec("UPDATE trash SET deleted_at=?", time.Now().UTC().Add(-trashRetention-time.Hour).Format("2006-01-02 15:04:05"))
+	PurgeTrash(app.DB, trashRetention)
+	app.DB.QueryRow("SELECT COUNT(*) FROM events").Scan(&n)
+	if n != 0 || CountTrash(app.DB) != 0 {
+		t.Errorf("after expiry: %d events, %d in trash", n, CountTrash(app.DB))
+	}
+}
+
+func TestTrashRestoreEmailTaken(t *testing.T) {
+	app := testApp(t)
+	e := seedEvent(t, app.DB)
+	tk := seedTask(t, app.DB, e.ID, "Vaisselle", nil)
+	alice, _ := RegisterForTask(app.DB, tk.ID, "Alice", "Dupont", "alice@test.com", "0601", "fr")
+	DeleteRegistration(app.DB, alice.ID)
+	if _, err := RegisterForTask(app.DB, tk.ID, "Alice", "Dupont", "alice@test.com", "0601", "fr"); err != nil {
+		t.Fatalf("register again after deletion: %v", err)
+	}
+
+	entries, _ := ListTrash(app.DB)
+	w := postForm(newMux(app), "/admin/trash/restore?lang=en", url.Values{"id": {fmt.Sprint(entries[0].ID)}}, adminCookie(app))
+	body := followRedirect(newMux(app), w, adminCookie(app)).Body.String()
+	if !strings.Contains(body, T("trash_email_taken", "en")) {
+		t.Error("no error about the email being taken")
+	}
+	if CountRegistrations(app.DB, e.ID) != 1 || CountTrash(app.DB) != 1 {
+		t.Error("restored a second registration for the same email")
+	}
+}
+
+func TestClearAllNeedsTitle(t *testing.T) {
+	app := testApp(t)
+	e := seedEvent(t, app.DB)
+	tk := seedTask(t, app.DB, e.ID, "Vaisselle", nil)
+	RegisterForTask(app.DB, tk.ID, "Alice", "Dupont", "alice@test.com", "0601", "fr")
+	mux := newMux(app)
+	admin := adminCookie(app)
+
+	postForm(mux, "/admin/clear-all?lang=en", url.Values{"event_id": {fmt.Sprint(e.ID)}, "confirm_title": {"Other Event"}}, admin)
+	if tasks, _ := ListTasks(app.DB, e.ID); len(tasks) != 1 || CountTrash(app.DB) != 0 {
+		t.Fatal("cleared without the right title")
+	}
+
+	postForm(mux, "/admin/clear-all?lang=en", url.Values{"event_id": {fmt.Sprint(e.ID)}, "confirm_title": {" test event "}}, admin)
+	if tasks, _ := ListTasks(app.DB, e.ID); len(tasks) != 0 || CountRegistrations(app.DB, e.ID) != 0 {
+		t.Fatal("tasks still listed after clearing")
+	}
+	entries, _ := ListTrash(app.DB)
+	if len(entries) != 1 || entries[0].Kind != "structure" {
+		t.Fatalf("trash = %+v", entries)
+	}
+	if err := RestoreTrash(app.DB, entries[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if tasks, _ := ListTasks(app.DB, e.ID); len(tasks) != 1 || CountRegistrations(app.DB, e.ID) != 1 {
+		t.Error("restoring did not bring back the tasks and their registrations")
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+// Duplicating a group or a task from the event edit page: the copy lands
+// just below the original, titles marked as a copy, with everything the
+// original holds — a group's subgroups and tasks, a task's shift and slots —
+// but none of its registrations. Handy when Sunday's tasks mirror Saturday's.
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// copyTitle marks title as a copy, in its language; empty titles stay empty.
+func copyTitle(title, lang string) string {
+	if title == "" {
+		return ""
+	}
+	if lang == LangFR {
+		return title + " (copie)"
+	}
+	return title + " (copy)"
+}
+
+// makeRoomAfter shifts the groups and tasks of eventID under parent that come
+// after position, to free the place just below it.
+func makeRoomAfter(tx *sql.Tx, eventID int64, parent sql.NullInt64, position int) error {
+	for _, q := range []string{
+		"UPDATE task_groups SET position=position+1 WHERE event_id=? AND parent_group_id IS ? AND position>? AND trash_id IS NULL",
+		"UPDATE tasks SET position=position+1 WHERE event_id=? AND group_id IS ? AND position>? AND trash_id IS NULL",
+	} {
+		if _, err := tx.Exec(q, eventID, parent, position); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyTask inserts a copy of t under group at position, its titles marked as
+// a copy when asked to.
+func copyTask(tx *sql.Tx, t Task, group sql.NullInt64, position int, rename bool) (int64, error) {
+	if rename {
+		t.TitleFR, t.TitleEN = copyTitle(t.TitleFR, LangFR), copyTitle(t.TitleEN, LangEN)
+	}
+	res, err := tx.Exec(
+		"INSERT INTO tasks (event_id, group_id, title_fr, title_en, description_fr, description_en, max_slots, start_time, end_time, position, max_guests, min_slots, skills, reserved_slots, notes) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		t.EventID, group, t.TitleFR, t.TitleEN, t.DescriptionFR, t.DescriptionEN, t.MaxSlots, t.StartTime, t.EndTime, position, t.MaxGuests, t.MinSlots, t.Skills, t.ReservedSlots, t.Notes,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("copy task %d: %w", t.ID, err)
+	}
+	return res.LastInsertId()
+}
+
+// DuplicateTask copies task id just below it and returns the copy.
+func DuplicateTask(db *sql.DB, id int64) (*Task, error) {
+	t, err := GetTask(db, id)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	if err := makeRoomAfter(tx, t.EventID, t.GroupID, t.Position); err != nil {
+		return nil, err
+	}
+	newID, err := copyTask(tx, *t, t.GroupID, t.Position+1, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return GetTask(db, newID)
+}
+
+// DuplicateTaskGroup copies group id, with its subgroups and tasks, just
+// below it and returns the copy. Only the copied group's own titles are
+// marked as a copy.
+func DuplicateTaskGroup(db *sql.DB, id int64) (*TaskGroup, error) {
+	g, err := GetTaskGroup(db, id)
+	if err != nil {
+		return nil, err
+	}
+	groups, err := ListTaskGroups(db, g.EventID)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := ListTasks(db, g.EventID)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	if err := makeRoomAfter(tx, g.EventID, g.ParentGroupID, g.Position); err != nil {
+		return nil, err
+	}
+	var copyGroup func(src TaskGroup, parent sql.NullInt64, position int, rename bool) (int64, error)
+	copyGroup = func(src TaskGroup, parent sql.NullInt64, position int, rename bool) (int64, error) {
+		if rename {
+			src.TitleFR, src.TitleEN = copyTitle(src.TitleFR, LangFR), copyTitle(src.TitleEN, LangEN)
+		}
+		res, err := tx.Exec(
+			"INSERT INTO task_groups (event_id, parent_group_id, title_fr, title_en, position, max_slots, color, icon) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+			src.EventID, parent, src.TitleFR, src.TitleEN, position, src.MaxSlots, src.Color, src.Icon,
+		)
+		if err != nil {
+			return 0, fmt.Errorf("copy group %d: %w", src.ID, err)
+		}
+		newID, _ := res.LastInsertId()
+		inside := sql.NullInt64{Int64: newID, Valid: true}
+		for _, child := range groups {
+			if child.ParentGroupID.Valid && child.ParentGroupID.Int64 == src.ID {
+				if _, err := copyGroup(child, inside, child.Position, false); err != nil {
+					return 0, err
+				}
+			}
+		}
+		for _, t := range tasks {
+			if t.GroupID.Valid && t.GroupID.Int64 == src.ID {
+				if _, err := copyTask(tx, t, inside, t.Position, false); err != nil {
+					return 0, err
+				}
+			}
+		}
+		return newID, nil
+	}
+	newID, err := copyGroup(*g, g.ParentGroupID, g.Position+1, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return GetTaskGroup(db, newID)
+}
+
+// handleAPIDuplicate returns the handler duplicating a group or a task, as
+// kind says: {"id"} of the original in, {"id"} of the copy out.
+func (app *App) handleAPIDuplicate(kind string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, `{"error":"method not allowed"}`, 405)
+			return
+		}
+		var req struct {
+			ID int64 `json:"id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, `{"error":"bad request"}`, 400)
+			return
+		}
+		var newID, eventID int64
+		var err error
+		if kind == "group" {
+			var g *TaskGroup
+			if g, err = DuplicateTaskGroup(app.DB, req.ID); err == nil {
+				newID, eventID = g.ID, g.EventID
+			}
+		} else {
+			var t *Task
+			if t, err = DuplicateTask(app.DB, req.ID); err == nil {
+				newID, eventID = t.ID, t.EventID
+			}
+		}
+		if err == sql.ErrNoRows {
+			http.Error(w, `{"error":"not found"}`, 404)
+			return
+		} else if err != nil {
+			http.Error(w, `{"error":"duplicate failed"}`, 500)
+			return
+		}
+		app.snapshotStructure(eventID, "edit")
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]int64{"id": newID})
+	}
+}
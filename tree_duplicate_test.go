@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDuplicateGroup(t *testing.T) {
+	app := testApp(t)
+	mux := newMux(app)
+	e := seedEvent(t, app.DB)
+	saturday := &TaskGroup{EventID: e.ID, TitleFR: "Samedi", TitleEN: "Saturday"}
+	CreateTaskGroup(app.DB, saturday)
+	sunday := &TaskGroup{EventID: e.ID, TitleFR: "Dimanche", TitleEN: "Sunday"}
+	CreateTaskGroup(app.DB, sunday)
+	in := sql.NullInt64{Int64: saturday.ID, Valid: true}
+	bar := &Task{EventID: e.ID, GroupID: in, TitleFR: "Buvette", TitleEN: "Bar", StartTime: "10:00", EndTime: "12:00", MaxSlots: sql.NullInt64{Int64: 3, Valid: true}}
+	CreateTask(app.DB, bar)
+	evening := &TaskGroup{EventID: e.ID, ParentGroupID: in, TitleFR: "Soir", TitleEN: "Evening"}
+	CreateTaskGroup(app.DB, evening)
+	CreateTask(app.DB, &Task{EventID: e.ID, GroupID: sql.NullInt64{Int64: evening.ID, Valid: true}, TitleFR: "Rangement"})
+	RegisterForTask(app.DB, bar.ID, "Alice", "Dupont", "alice@test.com", "0601", "fr")
+
+	body, _ := json.Marshal(map[string]int64{"id": saturday.ID})
+	req := httptest.NewRequest(http.MethodPost, "/admin/api/group/duplicate", bytes.NewReader(body))
+	req.AddCookie(adminCookie(app))
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != 200 {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	var resp struct{ ID int64 }
+	json.Unmarshal(w.Body.Bytes(), &resp)
+
+	tree, _ := BuildEventTree(app.DB, e.ID)
+	if len(tree) != 3 || tree[1].Group == nil || tree[1].Group.ID != resp.ID || tree[2].Group.ID != sunday.ID {
+		t.Fatalf("copy not placed below the original: %+v", tree)
+	}
+	copied := tree[1]
+	if copied.Group.TitleFR != "Samedi (copie)" || copied.Group.TitleEN != "Saturday (copy)" {
+		t.Errorf("copy titled %q / %q", copied.Group.TitleFR, copied.Group.TitleEN)
+	}
+	if len(copied.Children) != 2 {
+		t.Fatalf("copy has %d children, want 2", len(copied.Children))
+	}
+	task := copied.Children[0].Task
+	if task == nil || task.ID == bar.ID || task.TitleFR != "Buvette" || task.StartTime != "10:00" || task.MaxSlots.Int64 != 3 || task.RegCount != 0 {
+		t.Errorf("copied task %+v", task)
+	}
+	if sub := copied.Children[1]; sub.Group == nil || sub.Group.TitleFR != "Soir" || len(sub.Children) != 1 {
+		t.Errorf("copied subgroup %+v", sub)
+	}
+}
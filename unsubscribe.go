@@ -0,0 +1,77 @@
+package main
+
+// Unsubscribe link carried by outgoing email. The link names the address and
+// is signed with a per-install secret, so nobody can change someone else's
+// preferences by editing the URL.
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// unsubscribeSig signs an address (case-insensitively) for the unsubscribe link.
+func (app *App) unsubscribeSig(email string) (string, error) {
+	secret, err := SettingSecret(app.DB, "unsubscribe_secret")
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strings.ToLower(strings.TrimSpace(email))))
+	return hex.EncodeToString(mac.Sum(nil)[:16]), nil
+}
+
+// unsubscribeURL returns the signed preferences link for email, or "" if the
+// signing secret is unavailable (the email then simply goes without it).
+func (app *App) unsubscribeURL(baseURL, email, lang string) string {
+	sig, err := app.unsubscribeSig(email)
+	if err != nil {
+		log.Printf("unsubscribe: sign: %v", err)
+		return ""
+	}
+	q := url.Values{"email": {email}, "sig": {sig}, "lang": {lang}}
+	return baseURL + "/unsubscribe?" + q.Encode()
+}
+
+// handleUnsubscribe shows and updates the preferences of the address in a
+// signed link. A POST without "subscribed" opts out.
+func (app *App) handleUnsubscribe(w http.ResponseWriter, r *http.Request) {
+	lang := LangFromRequest(r)
+	email := strings.TrimSpace(r.FormValue("email"))
+	want, err := app.unsubscribeSig(email)
+	if err != nil {
+		log.Printf("unsubscribe: sign: %v", err)
+		http.Error(w, T("error_server", lang), 500)
+		return
+	}
+	if email == "" || !hmac.Equal([]byte(want), []byte(r.FormValue("sig"))) {
+		pd := app.newPageData(r, map[string]any{"Invalid": true})
+		w.WriteHeader(http.StatusNotFound)
+		app.render(w, r, "unsubscribe.html", pd)
+		return
+	}
+
+	var success, errMsg string
+	if r.Method == http.MethodPost {
+		subscribed := r.FormValue("subscribed") == "1"
+		if err := SetUnsubscribed(app.DB, email, !subscribed); err != nil {
+			log.Printf("unsubscribe: save %s: %v", email, err)
+			errMsg = T("error_server", lang)
+		} else if subscribed {
+			success = T("unsubscribe_resubscribed", lang)
+		} else {
+			success = T("unsubscribe_done", lang)
+		}
+	}
+	pd := app.newPageData(r, map[string]any{
+		"Email":        email,
+		"Sig":          want,
+		"Unsubscribed": IsUnsubscribed(app.DB, email),
+	})
+	pd.Success, pd.Error = success, errMsg
+	app.render(w, r, "unsubscribe.html", pd)
+}
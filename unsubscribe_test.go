@@ -0,0 +1,60 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestUnsubscribeLink(t *testing.T) {
+	app := testApp(t)
+	mux := newMux(app)
+
+	link := app.unsubscribeURL("http://localhost:8090", "Alice@Test.com", "en")
+	u, err := url.Parse(link)
+	if err != nil || u.Path != "/unsubscribe" {
+		t.Fatalf("unsubscribe url = %q", link)
+	}
+	q := u.Query()
+
+	if w := getRequest(mux, "/unsubscribe?"+q.Encode()); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "Alice@Test.com") {
+		t.Fatalf("preferences page: status %d", w.Code)
+	}
+	forged := url.Values{"email": {"bob@test.com"}, "sig": q["sig"]}
+	if w := getRequest(mux, "/unsubscribe?"+forged.Encode()); w.Code != http.StatusNotFound {
+		t.Errorf("forged link: status %d, want 404", w.Code)
+	}
+	postForm(mux, "/unsubscribe?lang=en", forged)
+	if IsUnsubscribed(app.DB, "bob@test.com") {
+		t.Error("forged link changed preferences")
+	}
+
+	postForm(mux, "/unsubscribe?lang=en", url.Values{"email": q["email"], "sig": q["sig"]})
+	if !IsUnsubscribed(app.DB, "alice@test.com") {
+		t.Fatal("opt-out not recorded (case-insensitively)")
+	}
+
+	postForm(mux, "/unsubscribe?lang=en", url.Values{"email": q["email"], "sig": q["sig"], "subscribed": {"1"}})
+	if IsUnsubscribed(app.DB, "alice@test.com") {
+		t.Error("resubscribe not recorded")
+	}
+}
+
+func TestSantaInvitesHonorOptOut(t *testing.T) {
+	app := testApp(t)
+	e := seedSantaEvent(t, app.DB)
+	seedSantaParticipant(t, app.DB, e.ID, "Alice", "alice@test.com", false)
+	seedSantaParticipant(t, app.DB, e.ID, "Bob", "bob@test.com", false)
+	SetUnsubscribed(app.DB, "ALICE@test.com", true)
+
+	app.sendInviteEmails(e.ID, "http://localhost:8090")
+
+	fake := app.Email.(*fakeEmailSender)
+	if fake.count() != 1 || fake.sent[0].To != "bob@test.com" {
+		t.Fatalf("invites = %+v, want only bob", fake.sent)
+	}
+	if !strings.Contains(fake.sent[0].HTML, "/unsubscribe?") {
+		t.Error("invitation lacks the preferences link")
+	}
+}
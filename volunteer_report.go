@@ -0,0 +1,113 @@
+package main
+
+// The volunteer report: everyone who signed up for tasks over a period,
+// across events, with how many events and tasks they helped with — the list
+// to go through for end-of-year thank-yous. People are told apart by email
+// address; registrations anonymized by the retention period no longer have
+// one and drop out.
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// VolunteerActivity is what someone volunteered for over a period.
+type VolunteerActivity struct {
+	Email     string
+	FirstName string // as given on their latest signup
+	LastName  string
+	Events    int
+	Tasks     int
+	LastEvent string // date of the latest event they helped with
+}
+
+// ListVolunteerActivity returns everyone signed up for a task of an event
+// held between from and to (YYYY-MM-DD, both included), those who helped
+// most first.
+func ListVolunteerActivity(db *sql.DB, from, to string) ([]VolunteerActivity, error) {
+	// SQLite takes the bare columns from the row holding MAX(r.created_at):
+	// the names of the latest signup.
+	rows, err := db.Query(`SELECT LOWER(r.email), r.first_name, r.last_name, MAX(r.created_at),
+			COUNT(DISTINCT e.id), COUNT(DISTINCT t.id), MAX(e.event_date)
+		FROM registrations r
+		JOIN tasks t ON t.id = r.task_id
+		JOIN events e ON e.id = t.event_id
+		WHERE r.trash_id IS NULL AND t.trash_id IS NULL AND e.trash_id IS NULL
+			AND r.email != '' AND e.event_date >= ? AND e.event_date <= ?
+		GROUP BY LOWER(r.email)
+		ORDER BY COUNT(DISTINCT e.id) DESC, COUNT(DISTINCT t.id) DESC, r.last_name, r.first_name`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []VolunteerActivity
+	for rows.Next() {
+		var v VolunteerActivity
+		var latest any
+		if err := rows.Scan(&v.Email, &v.FirstName, &v.LastName, &latest, &v.Events, &v.Tasks, &v.LastEvent); err != nil {
+			return nil, err
+		}
+		list = append(list, v)
+	}
+	return list, rows.Err()
+}
+
+// volunteerReportPeriod returns the period asked for in r, the current year
+// by default.
+func volunteerReportPeriod(r *http.Request) (from, to string) {
+	year := strconv.Itoa(time.Now().Year())
+	from, to = year+"-01-01", year+"-12-31"
+	if d := r.URL.Query().Get("from"); validDate(d) {
+		from = d
+	}
+	if d := r.URL.Query().Get("to"); validDate(d) {
+		to = d
+	}
+	return from, to
+}
+
+func validDate(s string) bool {
+	_, err := time.Parse("2006-01-02", s)
+	return err == nil
+}
+
+func (app *App) handleAdminVolunteerReport(w http.ResponseWriter, r *http.Request) {
+	from, to := volunteerReportPeriod(r)
+	list, err := ListVolunteerActivity(app.DB, from, to)
+	if err != nil {
+		log.Printf("volunteer report: %v", err)
+	}
+	if r.URL.Query().Get("format") == "csv" {
+		writeVolunteerReportCSV(w, list, from, to, LangFromRequest(r))
+		return
+	}
+	pd := app.newPageData(r, map[string]any{"Volunteers": list, "From": from, "To": to})
+	app.render(w, r, "admin_volunteer_report.html", pd)
+}
+
+// writeVolunteerReportCSV sends list, the volunteers from from to to, as a
+// CSV file download with its headings in lang.
+func writeVolunteerReportCSV(w http.ResponseWriter, list []VolunteerActivity, from, to, lang string) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="benevoles-%s-%s.csv"`, from, to))
+	w.Write([]byte{0xEF, 0xBB, 0xBF})
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{
+		T("registration_last_name", lang),
+		T("registration_first_name", lang),
+		T("registration_email", lang),
+		T("volunteer_report_events", lang),
+		T("volunteer_report_tasks", lang),
+		T("volunteer_report_last_event", lang),
+	})
+	for _, v := range list {
+		cw.Write([]string{v.LastName, v.FirstName, v.Email, strconv.Itoa(v.Events), strconv.Itoa(v.Tasks), v.LastEvent})
+	}
+	cw.Flush()
+}
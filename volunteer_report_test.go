@@ -0,0 +1,50 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVolunteerReport(t *testing.T) {
+	app := testApp(t)
+	mux := newMux(app)
+	admin := adminCookie(app)
+	spring := &Event{TitleFR: "Kermesse", EventDate: "2025-05-10", MaxTasksPerPerson: 2}
+	CreateEvent(app.DB, spring)
+	autumn := &Event{TitleFR: "Brocante", EventDate: "2025-10-04"}
+	CreateEvent(app.DB, autumn)
+	before := &Event{TitleFR: "Loto", EventDate: "2024-11-20"}
+	CreateEvent(app.DB, before)
+	bar := seedTask(t, app.DB, spring.ID, "Buvette", nil)
+	kitchen := seedTask(t, app.DB, spring.ID, "Cuisine", nil)
+	stand := seedTask(t, app.DB, autumn.ID, "Stand", nil)
+	bingo := seedTask(t, app.DB, before.ID, "Animation", nil)
+
+	RegisterForTask(app.DB, bar.ID, "Alice", "Dupont", "alice@test.com", "0601", "fr")
+	RegisterForTask(app.DB, kitchen.ID, "Alice", "Dupont", "Alice@Test.com", "0601", "fr")
+	RegisterForTask(app.DB, stand.ID, "Alice", "Martin", "alice@test.com", "0601", "fr")
+	RegisterForTask(app.DB, stand.ID, "Bob", "Leroy", "bob@test.com", "0602", "fr")
+	RegisterForTask(app.DB, bingo.ID, "Chloé", "Petit", "chloe@test.com", "0603", "fr")
+
+	list, err := ListVolunteerActivity(app.DB, "2025-01-01", "2025-12-31")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("%d volunteers in 2025, want 2: %+v", len(list), list)
+	}
+	alice := list[0]
+	if alice.Email != "alice@test.com" || alice.Events != 2 || alice.Tasks != 3 || alice.LastEvent != "2025-10-04" || alice.LastName != "Martin" {
+		t.Errorf("alice = %+v, want 2 events, 3 tasks under her latest name", alice)
+	}
+
+	w := getRequest(mux, "/admin/volunteers?from=2024-01-01&to=2025-12-31&lang=en", admin)
+	if w.Code != 200 || !strings.Contains(w.Body.String(), "chloe@test.com") || !strings.Contains(w.Body.String(), "3 volunteers.") {
+		t.Errorf("report page: status %d", w.Code)
+	}
+	w = getRequest(mux, "/admin/volunteers?from=2025-01-01&to=2025-12-31&format=csv&lang=en", admin)
+	lines := strings.Split(strings.TrimSpace(strings.TrimPrefix(w.Body.String(), "\xEF\xBB\xBF")), "\n")
+	if len(lines) != 3 || lines[0] != "Last name,First name,Email,Events,Tasks,Latest event" || !strings.HasPrefix(lines[1], "Martin,Alice,alice@test.com,2,3,") {
+		t.Errorf("csv = %q", lines)
+	}
+}
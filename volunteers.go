@@ -0,0 +1,119 @@
+package main
+
+// Returning volunteers. Every signup and RSVP updates the profile of its
+// email address with the name and phone number given, so when a known
+// address is typed on a signup form — or was remembered by the browser — the
+// form fills in the name and skills (/api/volunteer). Only a browser that
+// signed up with the address gets them: it must still hold the cookie of a
+// registration made with it (see returning.go), so typing someone else's
+// address reveals nothing. The phone number is never sent back. Lookups are
+// also limited per client address, and a profile goes once no registration
+// or RSVP carries its address any more, e.g. after the retention period
+// (see AnonymizeEvent).
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	volunteerLookupWindow = time.Hour
+	maxVolunteerLookups   = 30 // per client address and window
+)
+
+// Volunteer is what is known of an email address from its signups.
+type Volunteer struct {
+	Email     string `json:"-"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Phone     string `json:"-"` // never sent back to a browser
+	// Skills are those declared on signup forms. See skills.go.
+	Skills []string `json:"skills"`
+}
+
+// SaveVolunteer records the details given with email. Empty ones don't
+// erase what is known.
+func SaveVolunteer(db *sql.DB, email, firstName, lastName, phone string) error {
+	_, err := db.Exec(`INSERT INTO volunteers (email, first_name, last_name, phone) VALUES (?, ?, ?, ?)
+		ON CONFLICT(email) DO UPDATE SET
+			first_name = CASE WHEN excluded.first_name != '' THEN excluded.first_name ELSE first_name END,
+			last_name = CASE WHEN excluded.last_name != '' THEN excluded.last_name ELSE last_name END,
+			phone = CASE WHEN excluded.phone != '' THEN excluded.phone ELSE phone END,
+			updated_at = CURRENT_TIMESTAMP`,
+		strings.ToLower(email), firstName, lastName, phone)
+	return err
+}
+
+// GetVolunteer returns the profile of email.
+func GetVolunteer(db *sql.DB, email string) (*Volunteer, error) {
+	v := &Volunteer{}
+	var skills string
+	err := db.QueryRow("SELECT email, first_name, last_name, phone, skills FROM volunteers WHERE email=?",
+		strings.ToLower(email)).Scan(&v.Email, &v.FirstName, &v.LastName, &v.Phone, &skills)
+	if err != nil {
+		return nil, err
+	}
+	v.Skills = parseSkills(skills)
+	return v, nil
+}
+
+// pruneVolunteersSQL drops the profiles no registration or RSVP carries the
+// address of any more.
+const pruneVolunteersSQL = `DELETE FROM volunteers WHERE email NOT IN (
+	SELECT LOWER(email) FROM registrations WHERE email != ''
+	UNION SELECT LOWER(email) FROM attendances WHERE email != '')`
+
+// migrateVolunteers creates the profiles of addresses that signed up before
+// there were any, from their latest registration or RSVP. Idempotent.
+func migrateVolunteers(db *sql.DB) {
+	for _, q := range []string{
+		`INSERT OR IGNORE INTO volunteers (email, first_name, last_name, phone)
+			SELECT LOWER(email), first_name, last_name, phone FROM registrations
+			WHERE email != '' AND trash_id IS NULL ORDER BY created_at DESC, id DESC`,
+		`INSERT OR IGNORE INTO volunteers (email, first_name, last_name, phone)
+			SELECT LOWER(email), first_name, last_name, phone FROM attendances
+			WHERE email != '' ORDER BY created_at DESC, id DESC`,
+	} {
+		if _, err := db.Exec(q); err != nil {
+			log.Printf("migrate volunteers: %v", err)
+		}
+	}
+}
+
+// LogVolunteerLookup records a lookup from ip, drops those older than the
+// window and reports whether ip is still within the limit.
+func LogVolunteerLookup(db *sql.DB, ip string) bool {
+	since := time.Now().UTC().Add(-volunteerLookupWindow).Format("2006-01-02 15:04:05")
+	db.Exec("DELETE FROM volunteer_lookups WHERE created_at <= ?", since)
+	if _, err := db.Exec("INSERT INTO volunteer_lookups (ip) VALUES (?)", ip); err != nil {
+		log.Printf("volunteer lookup log: %v", err)
+	}
+	var n int
+	db.QueryRow("SELECT COUNT(*) FROM volunteer_lookups WHERE ip=? AND created_at > ?", ip, since).Scan(&n)
+	return n <= maxVolunteerLookups
+}
+
+// handleAPIVolunteer returns the details known for an email address typed
+// on a signup form, or an empty object when the browser didn't sign up
+// with it.
+func (app *App) handleAPIVolunteer(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	email := strings.TrimSpace(r.URL.Query().Get("email"))
+	if !validEmailSyntax(email) || !LogVolunteerLookup(app.DB, clientIP(r)) || !app.remembersEmail(r, email) {
+		w.Write([]byte("{}"))
+		return
+	}
+	v, err := GetVolunteer(app.DB, email)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Printf("volunteer lookup: %v", err)
+		}
+		w.Write([]byte("{}"))
+		return
+	}
+	json.NewEncoder(w).Encode(v)
+}
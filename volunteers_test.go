@@ -0,0 +1,76 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestVolunteerProfiles(t *testing.T) {
+	app := testApp(t)
+	mux := newMux(app)
+	e := seedEvent(t, app.DB)
+	tk := seedTask(t, app.DB, e.ID, "Task", nil)
+
+	w := postForm(mux, "/signup?lang=en", url.Values{
+		"task_id": {fmt.Sprint(tk.ID)}, "first_name": {"Alice"}, "last_name": {"Dupont"},
+		"email": {"Alice@Test.com"}, "phone": {"0601"},
+	})
+	var remembered *http.Cookie
+	for _, c := range w.Result().Cookies() {
+		if c.Name == returningCookieName(e.ID) {
+			remembered = c
+		}
+	}
+	if remembered == nil {
+		t.Fatal("signup left no cookie")
+	}
+	if err := SaveVolunteer(app.DB, "alice@test.com", "", "Dupont-Martin", ""); err != nil {
+		t.Fatal(err)
+	}
+	body := getRequest(mux, "/api/volunteer?email=ALICE@test.com", remembered).Body.String()
+	if !strings.Contains(body, `"first_name":"Alice","last_name":"Dupont-Martin"`) || strings.Contains(body, "0601") {
+		t.Errorf("lookup = %s", body)
+	}
+	// Another browser, or one remembering another address, learns nothing.
+	if body := getRequest(mux, "/api/volunteer?email=alice@test.com").Body.String(); body != "{}" {
+		t.Errorf("lookup without the cookie = %s", body)
+	}
+	RegisterForTask(app.DB, tk.ID, "Bob", "Martin", "bob@test.com", "0602", "en")
+	if body := getRequest(mux, "/api/volunteer?email=bob@test.com", remembered).Body.String(); body != "{}" {
+		t.Errorf("someone else's address = %s", body)
+	}
+
+	for i := 0; i < maxVolunteerLookups; i++ {
+		getRequest(mux, "/api/volunteer?email=alice@test.com", remembered)
+	}
+	if body := getRequest(mux, "/api/volunteer?email=alice@test.com", remembered).Body.String(); body != "{}" {
+		t.Errorf("lookup over the limit = %s", body)
+	}
+
+	if err := AnonymizeEvent(app.DB, e.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := GetVolunteer(app.DB, "alice@test.com"); err != sql.ErrNoRows {
+		t.Errorf("profile kept after the registration was anonymized: %v", err)
+	}
+}
+
+func TestMigrateVolunteers(t *testing.T) {
+	db := testDB(t)
+	e := seedEvent(t, db)
+	tk := seedTask(t, db, e.ID, "Task", nil)
+	RegisterForTask(db, tk.ID, "Bob", "Martin", "Bob@Test.com", "0602", "fr")
+	if _, err := UpsertAttendance(db, e.ID, "Carol", "Durand", "carol@test.com", "0603", true, 0, 0, "", "fr"); err != nil {
+		t.Fatal(err)
+	}
+	migrateVolunteers(db)
+	for email, phone := range map[string]string{"bob@test.com": "0602", "carol@test.com": "0603"} {
+		if v, err := GetVolunteer(db, email); err != nil || v.Phone != phone {
+			t.Errorf("%s: %+v, %v", email, v, err)
+		}
+	}
+}